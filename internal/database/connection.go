@@ -1,11 +1,13 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"embed"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	_ "modernc.org/sqlite"
 )
@@ -15,34 +17,95 @@ var schemaFS embed.FS
 
 // Connection represents a database connection with management utilities
 type Connection struct {
-	DB   *sql.DB
-	path string
+	DB    *sql.DB
+	path  string
+	isNew bool
+}
+
+// LedgerEnvVar names the environment variable that selects the ledger file
+const LedgerEnvVar = "FINANCE_WRAPPED_LEDGER"
+
+// DefaultDataDir returns the application data directory, ~/.finance-wrapped
+func DefaultDataDir() (string, error) {
+	// Get user home directory for cross-platform compatibility
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".finance-wrapped"), nil
+}
+
+// DefaultDatabasePath returns the default ledger file, ~/.finance-wrapped/finance.db
+func DefaultDatabasePath() (string, error) {
+	dataDir, err := DefaultDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "finance.db"), nil
+}
+
+// ResolveLedgerPath picks the ledger file to open
+// The command-line flag wins over the FINANCE_WRAPPED_LEDGER environment variable,
+// which wins over the default path.
+func ResolveLedgerPath(flagValue string) (string, error) {
+	if strings.TrimSpace(flagValue) != "" {
+		return ExpandLedgerPath(flagValue)
+	}
+	if envValue := strings.TrimSpace(os.Getenv(LedgerEnvVar)); envValue != "" {
+		return ExpandLedgerPath(envValue)
+	}
+	return DefaultDatabasePath()
+}
+
+// ExpandLedgerPath turns a user-supplied ledger path into a clean absolute path
+// A leading ~ is expanded to the user's home directory.
+func ExpandLedgerPath(path string) (string, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return "", fmt.Errorf("ledger path cannot be empty")
+	}
+
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, `~\`) {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get user home directory: %w", err)
+		}
+		path = filepath.Join(homeDir, path[1:])
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve ledger path: %w", err)
+	}
+	return absPath, nil
 }
 
 // NewConnection creates and initializes a new SQLite database connection
 // The database file will be created in ~/.finance-wrapped/finance.db
 func NewConnection() (*Connection, error) {
-	// Get user home directory for cross-platform compatibility
-	homeDir, err := os.UserHomeDir()
+	dbPath, err := DefaultDatabasePath()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user home directory: %w", err)
+		return nil, err
 	}
+	return NewConnectionAt(dbPath)
+}
 
+// NewConnectionAt opens the ledger at dbPath, creating the file and its directory if needed
+func NewConnectionAt(dbPath string) (*Connection, error) {
 	// Create data directory
-	dataDir := filepath.Join(homeDir, ".finance-wrapped")
-	if err := os.MkdirAll(dataDir, 0755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
 		return nil, fmt.Errorf("failed to create data directory: %w", err)
 	}
 
-	// Database file path
-	dbPath := filepath.Join(dataDir, "finance.db")
-
 	// Check if database exists to determine if we need to initialize schema
-	_, err = os.Stat(dbPath)
+	info, err := os.Stat(dbPath)
 	isNewDatabase := os.IsNotExist(err)
+	if err == nil && info.IsDir() {
+		return nil, fmt.Errorf("ledger path %s is a directory", dbPath)
+	}
 
 	// Open SQLite connection
-	db, err := sql.Open("sqlite", dbPath)
+	db, err := sql.Open("sqlite", connectionDSN(dbPath))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -54,8 +117,9 @@ func NewConnection() (*Connection, error) {
 	}
 
 	conn := &Connection{
-		DB:   db,
-		path: dbPath,
+		DB:    db,
+		path:  dbPath,
+		isNew: isNewDatabase,
 	}
 
 	// Initialize schema for new databases
@@ -66,33 +130,69 @@ func NewConnection() (*Connection, error) {
 		}
 	}
 
+	// Refuse to touch databases written by a newer version of the application
+	if _, err := conn.PendingMigrations(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
 	return conn, nil
 }
 
-// configureConnection sets up SQLite connection parameters
-func configureConnection(db *sql.DB) error {
-	// Enable foreign key constraints
-	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
-		return fmt.Errorf("failed to enable foreign keys: %w", err)
+// OpenConnectionAt opens an existing ledger at dbPath read-only, without creating or initializing it
+// Nothing is written to the ledger, so it keeps its modification time and gets no -wal or -shm files.
+func OpenConnectionAt(dbPath string) (*Connection, error) {
+	info, err := os.Stat(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open ledger: %w", err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("ledger path %s is a directory", dbPath)
 	}
 
-	// Set journal mode for better performance and ACID compliance
-	if _, err := db.Exec("PRAGMA journal_mode = WAL"); err != nil {
-		return fmt.Errorf("failed to set journal mode: %w", err)
+	db, err := sql.Open("sqlite", readOnlyDSN(dbPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	// Set busy timeout for concurrent access
-	if _, err := db.Exec("PRAGMA busy_timeout = 5000"); err != nil {
-		return fmt.Errorf("failed to set busy timeout: %w", err)
+	conn := &Connection{DB: db, path: dbPath}
+	if _, err := conn.PendingMigrations(); err != nil {
+		db.Close()
+		return nil, err
 	}
 
-	// Optimize SQLite settings for performance
-	if _, err := db.Exec("PRAGMA synchronous = NORMAL"); err != nil {
-		return fmt.Errorf("failed to set synchronous mode: %w", err)
+	return conn, nil
+}
+
+// connectionPragmas are applied by the driver to every connection it opens for the pool
+// PRAGMA foreign_keys and the other per-connection settings only affect the connection that
+// runs them, so setting them once through db.Exec would leave the rest of the pool without
+// them; purges in particular rely on ON DELETE CASCADE.
+const connectionPragmas = "_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)&_pragma=synchronous(NORMAL)&_pragma=cache_size(10000)"
+
+// connectionDSN returns the data source name that opens dbPath with connectionPragmas
+func connectionDSN(dbPath string) string {
+	return dbPath + "?" + connectionPragmas
+}
+
+// readOnlyDSN returns the data source name that opens dbPath read-only
+// Only the pragmas that reads need are set; journal and cache settings would write to the file.
+// Even read-only, SQLite creates -wal and -shm files next to a WAL ledger and cannot remove them,
+// so a cleanly closed ledger (no -wal file) is opened immutable. A ledger that another process
+// has open is read through the WAL files that process already keeps.
+func readOnlyDSN(dbPath string) string {
+	dsn := "file:" + filepath.ToSlash(dbPath) + "?mode=ro&_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)"
+	if _, err := os.Stat(dbPath + "-wal"); os.IsNotExist(err) {
+		dsn += "&immutable=1"
 	}
+	return dsn
+}
 
-	if _, err := db.Exec("PRAGMA cache_size = 10000"); err != nil {
-		return fmt.Errorf("failed to set cache size: %w", err)
+// configureConnection sets up the SQLite settings stored in the database file itself
+func configureConnection(db *sql.DB) error {
+	// Set journal mode for better performance and ACID compliance
+	if _, err := db.Exec("PRAGMA journal_mode = WAL"); err != nil {
+		return fmt.Errorf("failed to set journal mode: %w", err)
 	}
 
 	return nil
@@ -129,7 +229,7 @@ func (c *Connection) InitializeSchema() error {
 // GetSchemaVersion returns the current schema version
 func (c *Connection) GetSchemaVersion() (int, error) {
 	var version int
-	err := c.DB.QueryRow("SELECT version FROM schema_version ORDER BY applied_at DESC LIMIT 1").Scan(&version)
+	err := c.DB.QueryRow("SELECT version FROM schema_version ORDER BY version DESC LIMIT 1").Scan(&version)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get schema version: %w", err)
 	}
@@ -144,6 +244,11 @@ func (c *Connection) Close() error {
 	return c.DB.Close()
 }
 
+// IsNew reports whether the database file was created by this connection
+func (c *Connection) IsNew() bool {
+	return c.isNew
+}
+
 // GetPath returns the database file path
 func (c *Connection) GetPath() string {
 	return c.path
@@ -157,7 +262,13 @@ func (c *Connection) BeginTransaction() (*sql.Tx, error) {
 // ExecuteInTransaction executes a function within a database transaction
 // If the function returns an error, the transaction is rolled back
 func (c *Connection) ExecuteInTransaction(fn func(*sql.Tx) error) error {
-	tx, err := c.DB.Begin()
+	return c.ExecuteInTransactionContext(context.Background(), fn)
+}
+
+// ExecuteInTransactionContext executes a function within a database transaction bound to ctx
+// The transaction is rolled back if the function returns an error or ctx is cancelled before commit.
+func (c *Connection) ExecuteInTransactionContext(ctx context.Context, fn func(*sql.Tx) error) error {
+	tx, err := c.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
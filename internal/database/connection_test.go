@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveLedgerPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		flagValue string
+		envValue  string
+		want      string
+	}{
+		{
+			name: "default path",
+			want: filepath.Join(home, ".finance-wrapped", "finance.db"),
+		},
+		{
+			name:     "environment variable",
+			envValue: "/books/business.db",
+			want:     filepath.Clean("/books/business.db"),
+		},
+		{
+			name:      "flag wins over environment",
+			flagValue: "/books/household.db",
+			envValue:  "/books/business.db",
+			want:      filepath.Clean("/books/household.db"),
+		},
+		{
+			name:      "home directory expansion",
+			flagValue: "~/books/household.db",
+			want:      filepath.Join(home, "books", "household.db"),
+		},
+		{
+			name:      "relative path is made absolute",
+			flagValue: "household.db",
+			want:      filepath.Join(cwd, "household.db"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(LedgerEnvVar, tt.envValue)
+
+			got, err := ResolveLedgerPath(tt.flagValue)
+			if err != nil {
+				t.Fatalf("ResolveLedgerPath() failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewConnectionAt(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "books", "household.db")
+
+	conn, err := NewConnectionAt(dbPath)
+	if err != nil {
+		t.Fatalf("NewConnectionAt() failed: %v", err)
+	}
+	defer conn.Close()
+
+	if !conn.IsNew() {
+		t.Error("Expected a missing ledger file to be created as new")
+	}
+	if conn.GetPath() != dbPath {
+		t.Errorf("Expected path %s, got %s", dbPath, conn.GetPath())
+	}
+	if _, err := conn.GetSchemaVersion(); err != nil {
+		t.Errorf("Expected schema to be initialized: %v", err)
+	}
+
+	if _, err := NewConnectionAt(filepath.Dir(dbPath)); err == nil {
+		t.Error("Expected opening a directory as a ledger to fail")
+	}
+}
+
+func TestConnectionPoolEnforcesForeignKeys(t *testing.T) {
+	conn, err := NewConnectionAt(filepath.Join(t.TempDir(), "finance.db"))
+	if err != nil {
+		t.Fatalf("NewConnectionAt() failed: %v", err)
+	}
+	defer conn.Close()
+
+	// Hold several connections at once so the pool has to open new ones
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		pooled, err := conn.DB.Conn(ctx)
+		if err != nil {
+			t.Fatalf("failed to acquire connection: %v", err)
+		}
+		defer pooled.Close()
+
+		var enabled int
+		if err := pooled.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&enabled); err != nil {
+			t.Fatalf("failed to read foreign_keys: %v", err)
+		}
+		if enabled != 1 {
+			t.Errorf("connection %d has foreign keys off", i+1)
+		}
+	}
+}
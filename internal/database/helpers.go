@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
@@ -186,23 +187,35 @@ func (h *SQLHelper) DeleteBy(table, whereClause string, args ...interface{}) (in
 
 // BulkInsert performs efficient bulk insertion using a transaction
 func (h *SQLHelper) BulkInsert(table string, fields []string, records [][]interface{}) error {
+	return h.BulkInsertContext(context.Background(), table, fields, records, nil)
+}
+
+// BulkInsertContext performs bulk insertion in a transaction that is rolled back if ctx is cancelled
+// The optional inserted callback receives the number of rows written so far after each row.
+func (h *SQLHelper) BulkInsertContext(ctx context.Context, table string, fields []string, records [][]interface{}, inserted func(int)) error {
 	if len(records) == 0 {
 		return nil
 	}
 
 	insertSQL := h.BuildInsertSQL(table, fields)
 
-	return h.conn.ExecuteInTransaction(func(tx *sql.Tx) error {
-		stmt, err := tx.Prepare(insertSQL)
+	return h.conn.ExecuteInTransactionContext(ctx, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(ctx, insertSQL)
 		if err != nil {
 			return fmt.Errorf("failed to prepare bulk insert: %w", err)
 		}
 		defer stmt.Close()
 
-		for _, record := range records {
-			if _, err := stmt.Exec(record...); err != nil {
+		for i, record := range records {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if _, err := stmt.ExecContext(ctx, record...); err != nil {
 				return fmt.Errorf("failed to execute bulk insert row: %w", err)
 			}
+			if inserted != nil {
+				inserted(i + 1)
+			}
 		}
 
 		return nil
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// ForeignKeyViolation is one row reported by PRAGMA foreign_key_check
+type ForeignKeyViolation struct {
+	Table  string // Table holding the dangling reference
+	RowId  int64  // 0 for WITHOUT ROWID tables
+	Parent string // Table the reference points at
+}
+
+// IntegrityCheck runs PRAGMA integrity_check and returns the problems SQLite found
+// An empty result means the database file is structurally sound.
+func (c *Connection) IntegrityCheck() ([]string, error) {
+	rows, err := c.DB.Query("PRAGMA integrity_check")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run integrity check: %w", err)
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var message string
+		if err := rows.Scan(&message); err != nil {
+			return nil, fmt.Errorf("failed to read integrity check result: %w", err)
+		}
+		if message != "ok" {
+			problems = append(problems, message)
+		}
+	}
+	return problems, rows.Err()
+}
+
+// ForeignKeyCheck runs PRAGMA foreign_key_check and returns every dangling reference
+func (c *Connection) ForeignKeyCheck() ([]ForeignKeyViolation, error) {
+	rows, err := c.DB.Query("PRAGMA foreign_key_check")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run foreign key check: %w", err)
+	}
+	defer rows.Close()
+
+	var violations []ForeignKeyViolation
+	for rows.Next() {
+		var violation ForeignKeyViolation
+		var rowId sql.NullInt64
+		var fkId int64
+		if err := rows.Scan(&violation.Table, &rowId, &violation.Parent, &fkId); err != nil {
+			return nil, fmt.Errorf("failed to read foreign key check result: %w", err)
+		}
+		violation.RowId = rowId.Int64
+		violations = append(violations, violation)
+	}
+	return violations, rows.Err()
+}
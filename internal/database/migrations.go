@@ -0,0 +1,203 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// baselineSchemaVersion is the version recorded by schema.sql
+const baselineSchemaVersion = 1
+
+//go:embed migrations
+var migrationsFS embed.FS
+
+// Migration is a single ordered schema change applied on top of the baseline schema
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// Migrations returns all embedded migrations ordered by version
+func Migrations() ([]Migration, error) {
+	sub, err := fs.Sub(migrationsFS, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open migrations directory: %w", err)
+	}
+	return loadMigrations(sub)
+}
+
+// LatestSchemaVersion returns the schema version this binary migrates databases to
+func LatestSchemaVersion() (int, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return 0, err
+	}
+	if len(migrations) == 0 {
+		return baselineSchemaVersion, nil
+	}
+	return migrations[len(migrations)-1].Version, nil
+}
+
+// loadMigrations reads NNN_name.sql files from fsys and validates their ordering
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	var migrations []Migration
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+
+		base := strings.TrimSuffix(entry.Name(), ".sql")
+		prefix, name, found := strings.Cut(base, "_")
+		if !found || name == "" {
+			return nil, fmt.Errorf("invalid migration filename %q: expected NNN_name.sql", entry.Name())
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration filename %q: version must be numeric", entry.Name())
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		migrations = append(migrations, Migration{
+			Version: version,
+			Name:    name,
+			SQL:     string(content),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	// Versions must continue the baseline without gaps or duplicates
+	expected := baselineSchemaVersion + 1
+	for _, m := range migrations {
+		if m.Version != expected {
+			return nil, fmt.Errorf("migration %03d_%s is out of sequence: expected version %d", m.Version, m.Name, expected)
+		}
+		expected++
+	}
+
+	return migrations, nil
+}
+
+// PendingMigrations returns the migrations not yet applied to this database
+// It fails if the database was written by a newer version of the application
+func (c *Connection) PendingMigrations() ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	return c.pendingFrom(migrations)
+}
+
+// pendingFrom filters migrations down to those newer than the current schema version
+func (c *Connection) pendingFrom(migrations []Migration) ([]Migration, error) {
+	current, err := c.GetSchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	latest := baselineSchemaVersion
+	if len(migrations) > 0 {
+		latest = migrations[len(migrations)-1].Version
+	}
+	if current > latest {
+		return nil, fmt.Errorf("database schema version %d is newer than this application supports (%d); please upgrade the application", current, latest)
+	}
+
+	var pending []Migration
+	for _, m := range migrations {
+		if m.Version > current {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// Migrate applies all pending migrations in order and returns how many were applied
+func (c *Connection) Migrate() (int, error) {
+	pending, err := c.PendingMigrations()
+	if err != nil {
+		return 0, err
+	}
+	return c.applyMigrations(pending)
+}
+
+// applyMigrations runs each migration in its own transaction, stopping at the first failure
+func (c *Connection) applyMigrations(migrations []Migration) (int, error) {
+	for i, m := range migrations {
+		if err := c.applyMigration(m); err != nil {
+			return i, fmt.Errorf("migration %03d_%s failed: %w", m.Version, m.Name, err)
+		}
+	}
+	return len(migrations), nil
+}
+
+// applyMigration executes a single migration and records its version
+// Foreign keys are disabled for the duration so tables can be rebuilt safely
+func (c *Connection) applyMigration(m Migration) error {
+	ctx := context.Background()
+
+	// PRAGMA foreign_keys is per-connection and a no-op inside a transaction,
+	// so pin one connection for the whole migration
+	conn, err := c.DB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		return fmt.Errorf("failed to disable foreign keys: %w", err)
+	}
+	defer conn.ExecContext(ctx, "PRAGMA foreign_keys = ON")
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.SQL); err != nil {
+		return fmt.Errorf("failed to execute migration: %w", err)
+	}
+
+	// Refuse to commit a migration that leaves dangling references behind
+	rows, err := tx.Query("PRAGMA foreign_key_check")
+	if err != nil {
+		return fmt.Errorf("failed to check foreign keys: %w", err)
+	}
+	violations := 0
+	for rows.Next() {
+		violations++
+	}
+	rows.Close()
+	if violations > 0 {
+		return fmt.Errorf("migration left %d foreign key violation(s)", violations)
+	}
+
+	if _, err := tx.Exec("INSERT INTO schema_version (version) VALUES (?)", m.Version); err != nil {
+		return fmt.Errorf("failed to record schema version: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration: %w", err)
+	}
+
+	return nil
+}
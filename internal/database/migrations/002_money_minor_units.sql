@@ -0,0 +1,51 @@
+-- Store transaction amounts as integer cents with a currency code instead of DECIMAL(15,2)
+-- Table is rebuilt because SQLite cannot change a column type in place
+
+CREATE TABLE transactions_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    parent_id INTEGER,
+    amount_cents INTEGER NOT NULL,
+    currency TEXT NOT NULL DEFAULT 'USD',
+    description TEXT NOT NULL,
+    raw_description TEXT,
+    date DATE NOT NULL,
+    category_id INTEGER NOT NULL,
+    transaction_type TEXT DEFAULT 'expense',
+    is_split BOOLEAN NOT NULL DEFAULT 0,
+    statement_id INTEGER,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (parent_id) REFERENCES transactions(id) ON DELETE CASCADE,
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE RESTRICT,
+    FOREIGN KEY (statement_id) REFERENCES bank_statements(id) ON DELETE SET NULL,
+    CHECK (amount_cents != 0),
+    CHECK (length(currency) = 3),
+    CHECK (length(description) > 0),
+    CHECK (transaction_type IN ('expense', 'income', 'transfer')),
+    CHECK (is_split IN (0, 1))
+);
+
+INSERT INTO transactions_new (
+    id, parent_id, amount_cents, currency, description, raw_description, date,
+    category_id, transaction_type, is_split, statement_id, created_at, updated_at
+)
+SELECT
+    id, parent_id, CAST(ROUND(amount * 100) AS INTEGER), 'USD', description, raw_description, date,
+    category_id, transaction_type, is_split, statement_id, created_at, updated_at
+FROM transactions;
+
+DROP TABLE transactions;
+ALTER TABLE transactions_new RENAME TO transactions;
+
+CREATE INDEX idx_transactions_date ON transactions(date);
+CREATE INDEX idx_transactions_category ON transactions(category_id);
+CREATE INDEX idx_transactions_statement ON transactions(statement_id);
+CREATE INDEX idx_transactions_parent ON transactions(parent_id);
+
+CREATE TRIGGER update_transactions_updated_at
+    AFTER UPDATE ON transactions
+    FOR EACH ROW
+    WHEN NEW.updated_at = OLD.updated_at
+BEGIN
+    UPDATE transactions SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+END;
@@ -0,0 +1,20 @@
+-- Per-template import currency and a local exchange-rate table for base-currency reporting
+
+ALTER TABLE csv_templates ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD' CHECK (length(currency) = 3);
+
+-- One rate per day and currency pair; rate is the value of one unit of from_currency in to_currency
+CREATE TABLE exchange_rates (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    rate_date DATE NOT NULL,
+    from_currency TEXT NOT NULL,
+    to_currency TEXT NOT NULL,
+    rate REAL NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (rate_date, from_currency, to_currency),
+    CHECK (length(from_currency) = 3),
+    CHECK (length(to_currency) = 3),
+    CHECK (from_currency != to_currency),
+    CHECK (rate > 0)
+);
+
+CREATE INDEX idx_exchange_rates_pair_date ON exchange_rates(from_currency, to_currency, rate_date);
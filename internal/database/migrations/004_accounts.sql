@@ -0,0 +1,57 @@
+-- First-class accounts so statements and transactions no longer use the CSV template as a stand-in
+
+CREATE TABLE accounts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    account_type TEXT NOT NULL DEFAULT 'checking',
+    institution TEXT,
+    opening_balance_cents INTEGER NOT NULL DEFAULT 0,
+    currency TEXT NOT NULL DEFAULT 'USD',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (length(name) > 0),
+    CHECK (account_type IN ('checking', 'credit', 'savings', 'cash')),
+    CHECK (length(currency) = 3)
+);
+
+CREATE TRIGGER update_accounts_updated_at
+    AFTER UPDATE ON accounts
+    FOR EACH ROW
+    WHEN NEW.updated_at = OLD.updated_at
+BEGIN
+    UPDATE accounts SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+END;
+
+ALTER TABLE bank_statements ADD COLUMN account_id INTEGER REFERENCES accounts(id) ON DELETE SET NULL;
+ALTER TABLE transactions ADD COLUMN account_id INTEGER REFERENCES accounts(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_bank_statements_account ON bank_statements(account_id);
+CREATE INDEX idx_transactions_account ON transactions(account_id);
+
+-- Existing imports keep their overlap behaviour: one account per template that has statements
+INSERT INTO accounts (name, account_type, institution, currency)
+SELECT t.name, 'checking', NULL, t.currency
+FROM csv_templates t
+WHERE EXISTS (SELECT 1 FROM bank_statements s WHERE s.template_used = t.id);
+
+UPDATE bank_statements
+SET account_id = (
+    SELECT a.id FROM accounts a
+    JOIN csv_templates t ON t.name = a.name
+    WHERE t.id = bank_statements.template_used
+);
+
+-- The updated_at trigger is dropped for the backfill so existing edit timestamps are preserved
+DROP TRIGGER update_transactions_updated_at;
+
+UPDATE transactions
+SET account_id = (SELECT s.account_id FROM bank_statements s WHERE s.id = transactions.statement_id)
+WHERE statement_id IS NOT NULL;
+
+CREATE TRIGGER update_transactions_updated_at
+    AFTER UPDATE ON transactions
+    FOR EACH ROW
+    WHEN NEW.updated_at = OLD.updated_at
+BEGIN
+    UPDATE transactions SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+END;
@@ -0,0 +1,4 @@
+-- Composite indexes for QueryTransactions: filtered lists are read newest first within a category or type
+
+CREATE INDEX idx_transactions_category_date ON transactions(category_id, date);
+CREATE INDEX idx_transactions_type_date ON transactions(transaction_type, date);
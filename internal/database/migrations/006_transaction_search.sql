@@ -0,0 +1,36 @@
+-- Full-text search over transaction descriptions
+-- External-content FTS5 index: the text lives in transactions, the index only stores tokens
+
+CREATE VIRTUAL TABLE transactions_fts USING fts5(
+    description,
+    raw_description,
+    content='transactions',
+    content_rowid='id',
+    tokenize='unicode61 remove_diacritics 2'
+);
+
+-- Backfill the index from existing transactions
+INSERT INTO transactions_fts(transactions_fts) VALUES ('rebuild');
+
+CREATE TRIGGER transactions_fts_insert
+    AFTER INSERT ON transactions
+BEGIN
+    INSERT INTO transactions_fts(rowid, description, raw_description)
+    VALUES (NEW.id, NEW.description, NEW.raw_description);
+END;
+
+CREATE TRIGGER transactions_fts_delete
+    AFTER DELETE ON transactions
+BEGIN
+    INSERT INTO transactions_fts(transactions_fts, rowid, description, raw_description)
+    VALUES ('delete', OLD.id, OLD.description, OLD.raw_description);
+END;
+
+CREATE TRIGGER transactions_fts_update
+    AFTER UPDATE OF description, raw_description ON transactions
+BEGIN
+    INSERT INTO transactions_fts(transactions_fts, rowid, description, raw_description)
+    VALUES ('delete', OLD.id, OLD.description, OLD.raw_description);
+    INSERT INTO transactions_fts(rowid, description, raw_description)
+    VALUES (NEW.id, NEW.description, NEW.raw_description);
+END;
@@ -0,0 +1,5 @@
+-- Soft deletion: deleted transactions keep their row with a deleted_at timestamp until purged from the trash
+
+ALTER TABLE transactions ADD COLUMN deleted_at DATETIME;
+
+CREATE INDEX idx_transactions_deleted_at ON transactions(deleted_at);
@@ -0,0 +1,49 @@
+-- Undo/redo replays audit event snapshots, so every user change needs an event:
+-- transactions outside a statement get a NULL bank_statement_id, and deletes and restores are recorded
+-- Table is rebuilt because SQLite cannot change a column or CHECK constraint in place
+
+CREATE TABLE transaction_audit_events_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    transaction_id INTEGER NOT NULL,
+    bank_statement_id INTEGER,
+    timestamp DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    action_type TEXT NOT NULL,
+    source TEXT NOT NULL,
+    description_fingerprint TEXT NOT NULL,
+    category_assigned INTEGER NOT NULL,
+    category_confidence DECIMAL(3,2),
+    previous_category INTEGER NOT NULL,
+    modification_reason TEXT,
+    pre_edit_snapshot TEXT,
+    post_edit_snapshot TEXT,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id) ON DELETE CASCADE,
+    FOREIGN KEY (bank_statement_id) REFERENCES bank_statements(id) ON DELETE CASCADE,
+    FOREIGN KEY (category_assigned) REFERENCES categories(id) ON DELETE RESTRICT,
+    FOREIGN KEY (previous_category) REFERENCES categories(id) ON DELETE RESTRICT,
+    CHECK (action_type IN ('edit', 'import', 'split', 'delete', 'restore')),
+    CHECK (source IN ('user', 'import', 'auto')),
+    CHECK (modification_reason IS NULL OR modification_reason IN ('description', 'transaction type', 'category')),
+    CHECK (category_confidence IS NULL OR (category_confidence >= 0.0 AND category_confidence <= 1.0)),
+    CHECK (length(action_type) > 0),
+    CHECK (length(source) > 0)
+);
+
+INSERT INTO transaction_audit_events_new (
+    id, transaction_id, bank_statement_id, timestamp, action_type, source,
+    description_fingerprint, category_assigned, category_confidence, previous_category,
+    modification_reason, pre_edit_snapshot, post_edit_snapshot, created_at
+)
+SELECT
+    id, transaction_id, NULLIF(bank_statement_id, 0), timestamp, action_type, source,
+    description_fingerprint, category_assigned, category_confidence, previous_category,
+    modification_reason, pre_edit_snapshot, post_edit_snapshot, created_at
+FROM transaction_audit_events;
+
+DROP TABLE transaction_audit_events;
+ALTER TABLE transaction_audit_events_new RENAME TO transaction_audit_events;
+
+CREATE INDEX idx_transaction_audit_events_transaction ON transaction_audit_events(transaction_id);
+CREATE INDEX idx_transaction_audit_events_statement ON transaction_audit_events(bank_statement_id);
+CREATE INDEX idx_transaction_audit_events_timestamp ON transaction_audit_events(timestamp);
+CREATE INDEX idx_transaction_audit_events_action ON transaction_audit_events(action_type);
@@ -0,0 +1,20 @@
+-- General audit log: create, update and delete events for any entity with its JSON state before and after
+-- Rows are not tied to the audited record by foreign key, so the history outlives deletes and purges
+
+CREATE TABLE audit_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    entity_type TEXT NOT NULL, -- "Category", "CSVTemplate", "BankStatement", "Snapshot", "UserPreferences", "Transaction"
+    entity_id TEXT NOT NULL, -- record ID, or the preference key for UserPreferences
+    event_type TEXT NOT NULL,
+    source TEXT NOT NULL,
+    before_state TEXT, -- JSON; NULL on create
+    after_state TEXT, -- JSON; NULL on delete
+    timestamp DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (length(entity_type) > 0),
+    CHECK (length(entity_id) > 0),
+    CHECK (event_type IN ('create', 'update', 'delete')),
+    CHECK (source IN ('user', 'import', 'auto'))
+);
+
+CREATE INDEX idx_audit_events_entity ON audit_events(entity_type, entity_id);
+CREATE INDEX idx_audit_events_timestamp ON audit_events(timestamp);
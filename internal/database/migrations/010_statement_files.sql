@@ -0,0 +1,49 @@
+-- Statements can now come from OFX and other bank statement files as well as CSV templates:
+-- template_used becomes optional, the file format and the balances the bank reported are recorded,
+-- and transactions keep the bank's own ID (the OFX FITID) for exact duplicate detection
+-- Table is rebuilt because SQLite cannot drop a NOT NULL constraint in place
+
+CREATE TABLE bank_statements_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    filename TEXT NOT NULL,
+    import_date DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    period_start DATE,
+    period_end DATE,
+    template_used INTEGER, -- NULL for files that import without a CSV template
+    tx_count INTEGER NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'completed',
+    processing_time INTEGER, -- milliseconds
+    error_log TEXT,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    account_id INTEGER REFERENCES accounts(id) ON DELETE SET NULL,
+    format TEXT NOT NULL DEFAULT 'csv',
+    opening_balance_cents INTEGER, -- As reported by the bank; NULL when the file has none
+    closing_balance_cents INTEGER,
+    balance_currency TEXT,
+    FOREIGN KEY (template_used) REFERENCES csv_templates(id) ON DELETE RESTRICT,
+    CHECK (length(filename) > 0),
+    CHECK (tx_count >= 0),
+    CHECK (status IN ('completed', 'failed', 'override', 'undone', 'importing')),
+    CHECK (processing_time IS NULL OR processing_time >= 0),
+    CHECK (length(format) > 0),
+    CHECK (balance_currency IS NULL OR length(balance_currency) = 3)
+);
+
+INSERT INTO bank_statements_new (
+    id, filename, import_date, period_start, period_end, template_used, tx_count, status,
+    processing_time, error_log, created_at, updated_at, account_id
+)
+SELECT
+    id, filename, import_date, period_start, period_end, template_used, tx_count, status,
+    processing_time, error_log, created_at, updated_at, account_id
+FROM bank_statements;
+
+DROP TABLE bank_statements;
+ALTER TABLE bank_statements_new RENAME TO bank_statements;
+
+CREATE INDEX idx_bank_statements_account ON bank_statements(account_id);
+
+ALTER TABLE transactions ADD COLUMN external_id TEXT;
+
+CREATE INDEX idx_transactions_external_id ON transactions(account_id, external_id) WHERE external_id IS NOT NULL;
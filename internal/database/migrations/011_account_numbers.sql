@@ -0,0 +1,3 @@
+-- The bank's identifier for an account, so statement files that name their account can be matched to it
+
+ALTER TABLE accounts ADD COLUMN account_number TEXT;
@@ -0,0 +1,7 @@
+-- CSV templates for banks that export money out and money in as separate columns, show expenses
+-- as positive amounts, or name each row's type in a column of its own
+
+ALTER TABLE csv_templates ADD COLUMN debit_column INTEGER CHECK (debit_column IS NULL OR debit_column >= 0);
+ALTER TABLE csv_templates ADD COLUMN credit_column INTEGER CHECK (credit_column IS NULL OR credit_column >= 0);
+ALTER TABLE csv_templates ADD COLUMN type_column INTEGER CHECK (type_column IS NULL OR type_column >= 0);
+ALTER TABLE csv_templates ADD COLUMN expenses_positive BOOLEAN NOT NULL DEFAULT 0 CHECK (expenses_positive IN (0, 1));
@@ -0,0 +1,5 @@
+-- CSV templates for exports that open with account details before the header row or close with
+-- summary rows after the transactions
+
+ALTER TABLE csv_templates ADD COLUMN skip_lines INTEGER NOT NULL DEFAULT 0 CHECK (skip_lines >= 0);
+ALTER TABLE csv_templates ADD COLUMN footer_lines INTEGER NOT NULL DEFAULT 0 CHECK (footer_lines >= 0);
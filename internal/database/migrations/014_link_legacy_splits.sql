@@ -0,0 +1,43 @@
+-- Splits made before second halves were linked to their first half through parent_id
+-- Splitting updates the first half and inserts the second with the same timestamp, on the same
+-- statement, account and date, so that timestamp pairs the halves. A first half edited since
+-- its split no longer matches and is left for the integrity check to report.
+
+-- The updated_at trigger is dropped for the backfill so existing edit timestamps are preserved
+DROP TRIGGER update_transactions_updated_at;
+
+UPDATE transactions
+SET parent_id = (
+    SELECT p.id FROM transactions p
+    WHERE p.is_split = 1
+      AND p.parent_id IS NULL
+      AND p.id < transactions.id
+      AND p.statement_id IS transactions.statement_id
+      AND p.account_id IS transactions.account_id
+      AND p.date = transactions.date
+      AND p.updated_at = transactions.created_at
+      AND NOT EXISTS (SELECT 1 FROM transactions c WHERE c.parent_id = p.id)
+    ORDER BY p.id DESC
+    LIMIT 1
+)
+WHERE is_split = 0
+  AND parent_id IS NULL
+  AND EXISTS (
+    SELECT 1 FROM transactions p
+    WHERE p.is_split = 1
+      AND p.parent_id IS NULL
+      AND p.id < transactions.id
+      AND p.statement_id IS transactions.statement_id
+      AND p.account_id IS transactions.account_id
+      AND p.date = transactions.date
+      AND p.updated_at = transactions.created_at
+      AND NOT EXISTS (SELECT 1 FROM transactions c WHERE c.parent_id = p.id)
+  );
+
+CREATE TRIGGER update_transactions_updated_at
+    AFTER UPDATE ON transactions
+    FOR EACH ROW
+    WHEN NEW.updated_at = OLD.updated_at
+BEGIN
+    UPDATE transactions SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+END;
@@ -0,0 +1,543 @@
+package database
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// setupMigrationTestDB creates a baseline database in a temporary file
+func setupMigrationTestDB(t *testing.T) *Connection {
+	t.Helper()
+
+	dbPath := filepath.Join(t.TempDir(), "finance.db")
+	db, err := sql.Open("sqlite", connectionDSN(dbPath))
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := configureConnection(db); err != nil {
+		t.Fatalf("Failed to configure test database: %v", err)
+	}
+
+	conn := &Connection{DB: db, path: dbPath, isNew: true}
+	if err := conn.InitializeSchema(); err != nil {
+		t.Fatalf("Failed to initialize test schema: %v", err)
+	}
+
+	return conn
+}
+
+func TestLoadMigrations(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       fstest.MapFS
+		wantCount   int
+		errContains string
+	}{
+		{
+			name:      "empty directory",
+			files:     fstest.MapFS{},
+			wantCount: 0,
+		},
+		{
+			name: "ordered migrations ignore non-sql files",
+			files: fstest.MapFS{
+				"003_second.sql": {Data: []byte("SELECT 1;")},
+				"002_first.sql":  {Data: []byte("SELECT 1;")},
+				"README.md":      {Data: []byte("docs")},
+			},
+			wantCount: 2,
+		},
+		{
+			name: "gap in versions",
+			files: fstest.MapFS{
+				"002_first.sql": {Data: []byte("SELECT 1;")},
+				"004_third.sql": {Data: []byte("SELECT 1;")},
+			},
+			errContains: "out of sequence",
+		},
+		{
+			name: "duplicate version",
+			files: fstest.MapFS{
+				"002_first.sql": {Data: []byte("SELECT 1;")},
+				"002_again.sql": {Data: []byte("SELECT 1;")},
+			},
+			errContains: "out of sequence",
+		},
+		{
+			name: "non-numeric prefix",
+			files: fstest.MapFS{
+				"abc_first.sql": {Data: []byte("SELECT 1;")},
+			},
+			errContains: "version must be numeric",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := loadMigrations(tt.files)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("loadMigrations() error = %v, want error containing %q", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadMigrations() unexpected error: %v", err)
+			}
+			if len(migrations) != tt.wantCount {
+				t.Fatalf("loadMigrations() returned %d migrations, want %d", len(migrations), tt.wantCount)
+			}
+			for i := 1; i < len(migrations); i++ {
+				if migrations[i].Version <= migrations[i-1].Version {
+					t.Errorf("migrations not ordered: %d after %d", migrations[i].Version, migrations[i-1].Version)
+				}
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrationsLoad(t *testing.T) {
+	if _, err := Migrations(); err != nil {
+		t.Fatalf("embedded migrations are invalid: %v", err)
+	}
+}
+
+func TestApplyMigrations(t *testing.T) {
+	conn := setupMigrationTestDB(t)
+
+	migrations := []Migration{
+		{Version: 2, Name: "add_widgets", SQL: "CREATE TABLE widgets (id INTEGER PRIMARY KEY, name TEXT NOT NULL);"},
+		{Version: 3, Name: "seed_widgets", SQL: "INSERT INTO widgets (name) VALUES ('first');"},
+	}
+
+	pending, err := conn.pendingFrom(migrations)
+	if err != nil {
+		t.Fatalf("pendingFrom() failed: %v", err)
+	}
+	if len(pending) != 2 {
+		t.Fatalf("expected 2 pending migrations, got %d", len(pending))
+	}
+
+	applied, err := conn.applyMigrations(pending)
+	if err != nil {
+		t.Fatalf("applyMigrations() failed: %v", err)
+	}
+	if applied != 2 {
+		t.Errorf("applied = %d, want 2", applied)
+	}
+
+	version, err := conn.GetSchemaVersion()
+	if err != nil {
+		t.Fatalf("GetSchemaVersion() failed: %v", err)
+	}
+	if version != 3 {
+		t.Errorf("schema version = %d, want 3", version)
+	}
+
+	// Foreign keys must be back on after migrating
+	var fkEnabled int
+	if err := conn.DB.QueryRow("PRAGMA foreign_keys").Scan(&fkEnabled); err != nil {
+		t.Fatalf("failed to read foreign_keys pragma: %v", err)
+	}
+	if fkEnabled != 1 {
+		t.Error("foreign keys should be re-enabled after migrations")
+	}
+
+	pending, err = conn.pendingFrom(migrations)
+	if err != nil {
+		t.Fatalf("pendingFrom() failed: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("expected no pending migrations after applying, got %d", len(pending))
+	}
+}
+
+func TestApplyMigrationsRollsBackOnFailure(t *testing.T) {
+	conn := setupMigrationTestDB(t)
+
+	migrations := []Migration{
+		{Version: 2, Name: "broken", SQL: "CREATE TABLE half_done (id INTEGER); INSERT INTO missing_table VALUES (1);"},
+	}
+
+	applied, err := conn.applyMigrations(migrations)
+	if err == nil {
+		t.Fatal("expected failing migration to return an error")
+	}
+	if applied != 0 {
+		t.Errorf("applied = %d, want 0", applied)
+	}
+
+	version, err := conn.GetSchemaVersion()
+	if err != nil {
+		t.Fatalf("GetSchemaVersion() failed: %v", err)
+	}
+	if version != 1 {
+		t.Errorf("schema version = %d, want 1 after rollback", version)
+	}
+
+	var count int
+	if err := conn.DB.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'half_done'").Scan(&count); err != nil {
+		t.Fatalf("failed to query sqlite_master: %v", err)
+	}
+	if count != 0 {
+		t.Error("partial migration should have been rolled back")
+	}
+}
+
+func TestPendingMigrationsRejectsNewerDatabase(t *testing.T) {
+	conn := setupMigrationTestDB(t)
+
+	if _, err := conn.DB.Exec("INSERT INTO schema_version (version) VALUES (99)"); err != nil {
+		t.Fatalf("failed to bump schema version: %v", err)
+	}
+
+	_, err := conn.pendingFrom([]Migration{{Version: 2, Name: "only", SQL: "SELECT 1;"}})
+	if err == nil || !strings.Contains(err.Error(), "newer than this application supports") {
+		t.Errorf("pendingFrom() error = %v, want newer-database error", err)
+	}
+}
+
+func TestMoneyMigrationConvertsDecimalAmounts(t *testing.T) {
+	conn := setupMigrationTestDB(t)
+
+	// Baseline schema stores DECIMAL amounts, which SQLite keeps as REAL
+	amounts := []float64{19.99, -0.29, 1234567.89, -100}
+	for i, amount := range amounts {
+		_, err := conn.DB.Exec(`INSERT INTO transactions (amount, description, date, category_id)
+			VALUES (?, ?, '2024-01-15', 1)`, amount, "Legacy row")
+		if err != nil {
+			t.Fatalf("failed to insert legacy row %d: %v", i, err)
+		}
+	}
+
+	if _, err := conn.Migrate(); err != nil {
+		t.Fatalf("Migrate() failed: %v", err)
+	}
+
+	rows, err := conn.DB.Query("SELECT amount_cents, currency FROM transactions ORDER BY id")
+	if err != nil {
+		t.Fatalf("failed to query migrated rows: %v", err)
+	}
+	defer rows.Close()
+
+	want := []int64{1999, -29, 123456789, -10000}
+	i := 0
+	for rows.Next() {
+		var cents int64
+		var currency string
+		if err := rows.Scan(&cents, &currency); err != nil {
+			t.Fatalf("failed to scan migrated row: %v", err)
+		}
+		if cents != want[i] {
+			t.Errorf("row %d: amount_cents = %d, want %d", i, cents, want[i])
+		}
+		if currency != "USD" {
+			t.Errorf("row %d: currency = %q, want USD", i, currency)
+		}
+		i++
+	}
+	if i != len(want) {
+		t.Errorf("migrated %d rows, want %d", i, len(want))
+	}
+}
+
+func TestMultiCurrencyMigrationDefaultsTemplates(t *testing.T) {
+	conn := setupMigrationTestDB(t)
+
+	_, err := conn.DB.Exec(`INSERT INTO csv_templates (name, post_date_column, amount_column, desc_column)
+		VALUES ('Legacy', 0, 1, 2)`)
+	if err != nil {
+		t.Fatalf("failed to insert legacy template: %v", err)
+	}
+
+	if _, err := conn.Migrate(); err != nil {
+		t.Fatalf("Migrate() failed: %v", err)
+	}
+
+	var currency string
+	if err := conn.DB.QueryRow("SELECT currency FROM csv_templates WHERE name = 'Legacy'").Scan(&currency); err != nil {
+		t.Fatalf("failed to read template currency: %v", err)
+	}
+	if currency != "USD" {
+		t.Errorf("currency = %q, want USD", currency)
+	}
+
+	if _, err := conn.DB.Exec(`INSERT INTO exchange_rates (rate_date, from_currency, to_currency, rate)
+		VALUES ('2024-01-01', 'EUR', 'USD', -1)`); err == nil {
+		t.Error("expected non-positive rate to violate CHECK constraint")
+	}
+}
+
+func TestAccountsMigrationBackfillsFromTemplates(t *testing.T) {
+	conn := setupMigrationTestDB(t)
+
+	seed := []string{
+		`INSERT INTO csv_templates (id, name, post_date_column, amount_column, desc_column) VALUES (1, 'Chase', 0, 1, 2)`,
+		`INSERT INTO csv_templates (id, name, post_date_column, amount_column, desc_column) VALUES (2, 'Unused', 0, 1, 2)`,
+		`INSERT INTO bank_statements (id, filename, period_start, period_end, template_used) VALUES (1, 'jan.csv', '2024-01-01', '2024-01-31', 1)`,
+		`INSERT INTO transactions (amount, description, date, category_id, statement_id, updated_at)
+			VALUES (-12.50, 'Imported row', '2024-01-15', 1, 1, '2024-01-16T10:00:00Z')`,
+		`INSERT INTO transactions (amount, description, date, category_id) VALUES (5, 'Manual row', '2024-01-20', 1)`,
+	}
+	for _, stmt := range seed {
+		if _, err := conn.DB.Exec(stmt); err != nil {
+			t.Fatalf("failed to seed legacy data: %v", err)
+		}
+	}
+
+	if _, err := conn.Migrate(); err != nil {
+		t.Fatalf("Migrate() failed: %v", err)
+	}
+
+	var accountCount int
+	if err := conn.DB.QueryRow("SELECT COUNT(*) FROM accounts").Scan(&accountCount); err != nil {
+		t.Fatalf("failed to count accounts: %v", err)
+	}
+	if accountCount != 1 {
+		t.Fatalf("accounts = %d, want 1 (only templates with statements)", accountCount)
+	}
+
+	var accountId int64
+	var accountName string
+	if err := conn.DB.QueryRow("SELECT id, name FROM accounts").Scan(&accountId, &accountName); err != nil {
+		t.Fatalf("failed to read account: %v", err)
+	}
+	if accountName != "Chase" {
+		t.Errorf("account name = %q, want Chase", accountName)
+	}
+
+	var statementAccount int64
+	if err := conn.DB.QueryRow("SELECT account_id FROM bank_statements WHERE id = 1").Scan(&statementAccount); err != nil {
+		t.Fatalf("failed to read statement account: %v", err)
+	}
+	if statementAccount != accountId {
+		t.Errorf("statement account_id = %d, want %d", statementAccount, accountId)
+	}
+
+	var importedAccount sql.NullInt64
+	var updatedAt string
+	if err := conn.DB.QueryRow("SELECT account_id, updated_at FROM transactions WHERE description = 'Imported row'").Scan(&importedAccount, &updatedAt); err != nil {
+		t.Fatalf("failed to read imported transaction: %v", err)
+	}
+	if !importedAccount.Valid || importedAccount.Int64 != accountId {
+		t.Errorf("imported transaction account_id = %v, want %d", importedAccount, accountId)
+	}
+	if updatedAt != "2024-01-16T10:00:00Z" {
+		t.Errorf("backfill changed updated_at to %q", updatedAt)
+	}
+
+	var manualAccount sql.NullInt64
+	if err := conn.DB.QueryRow("SELECT account_id FROM transactions WHERE description = 'Manual row'").Scan(&manualAccount); err != nil {
+		t.Fatalf("failed to read manual transaction: %v", err)
+	}
+	if manualAccount.Valid {
+		t.Errorf("manual transaction account_id = %d, want NULL", manualAccount.Int64)
+	}
+}
+
+func TestTransactionSearchMigrationBackfillsAndSyncs(t *testing.T) {
+	conn := setupMigrationTestDB(t)
+
+	if _, err := conn.DB.Exec(`INSERT INTO transactions (id, amount, description, raw_description, date, category_id)
+		VALUES (1, -180, 'Plumber visit', 'ACH JOE''S PLUMBING 0423', '2024-01-15', 1)`); err != nil {
+		t.Fatalf("failed to seed legacy data: %v", err)
+	}
+
+	if _, err := conn.Migrate(); err != nil {
+		t.Fatalf("Migrate() failed: %v", err)
+	}
+
+	matchIds := func(query string) []int64 {
+		t.Helper()
+		rows, err := conn.DB.Query("SELECT rowid FROM transactions_fts WHERE transactions_fts MATCH ? ORDER BY rowid", query)
+		if err != nil {
+			t.Fatalf("MATCH %q failed: %v", query, err)
+		}
+		defer rows.Close()
+		var ids []int64
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				t.Fatalf("failed to scan match: %v", err)
+			}
+			ids = append(ids, id)
+		}
+		return ids
+	}
+
+	if ids := matchIds("plumbing"); len(ids) != 1 || ids[0] != 1 {
+		t.Errorf("backfilled raw description not searchable, got %v", ids)
+	}
+
+	// Triggers keep the index in step with inserts, updates and deletes
+	if _, err := conn.DB.Exec(`INSERT INTO transactions (id, amount_cents, description, date, category_id)
+		VALUES (2, -4500, 'Plumber follow-up', '2024-02-01', 1)`); err != nil {
+		t.Fatalf("failed to insert transaction: %v", err)
+	}
+	if ids := matchIds("plumber"); len(ids) != 2 {
+		t.Errorf("inserted transaction not indexed, got %v", ids)
+	}
+
+	if _, err := conn.DB.Exec(`UPDATE transactions SET description = 'Electrician' WHERE id = 2`); err != nil {
+		t.Fatalf("failed to update transaction: %v", err)
+	}
+	if ids := matchIds("plumber"); len(ids) != 1 || ids[0] != 1 {
+		t.Errorf("updated description still matches old text, got %v", ids)
+	}
+	if ids := matchIds("electrician"); len(ids) != 1 || ids[0] != 2 {
+		t.Errorf("updated description not indexed, got %v", ids)
+	}
+
+	if _, err := conn.DB.Exec(`DELETE FROM transactions WHERE id = 1`); err != nil {
+		t.Fatalf("failed to delete transaction: %v", err)
+	}
+	if ids := matchIds("plumber OR plumbing"); len(ids) != 0 {
+		t.Errorf("deleted transaction still indexed, got %v", ids)
+	}
+}
+
+func TestTransactionTrashMigrationKeepsRowsLive(t *testing.T) {
+	conn := setupMigrationTestDB(t)
+
+	if _, err := conn.DB.Exec(`INSERT INTO transactions (id, amount, description, date, category_id)
+		VALUES (1, -25.50, 'Lunch', '2024-01-15', 1)`); err != nil {
+		t.Fatalf("failed to seed legacy data: %v", err)
+	}
+
+	if _, err := conn.Migrate(); err != nil {
+		t.Fatalf("Migrate() failed: %v", err)
+	}
+
+	var live int
+	if err := conn.DB.QueryRow("SELECT COUNT(*) FROM transactions WHERE deleted_at IS NULL").Scan(&live); err != nil {
+		t.Fatalf("failed to query deleted_at: %v", err)
+	}
+	if live != 1 {
+		t.Errorf("expected existing transaction to stay out of the trash, got %d live rows", live)
+	}
+}
+
+func TestUndoableAuditEventsMigration(t *testing.T) {
+	conn := setupMigrationTestDB(t)
+
+	seed := []string{
+		`INSERT INTO csv_templates (id, name, post_date_column, amount_column, desc_column) VALUES (1, 'Chase', 0, 1, 2)`,
+		`INSERT INTO bank_statements (id, filename, template_used) VALUES (1, 'jan.csv', 1)`,
+		`INSERT INTO transactions (id, amount, description, date, category_id, statement_id) VALUES (1, -12.50, 'Imported row', '2024-01-15', 1, 1)`,
+		`INSERT INTO transactions (id, amount, description, date, category_id) VALUES (2, 5, 'Manual row', '2024-01-20', 1)`,
+		`INSERT INTO transaction_audit_events (transaction_id, bank_statement_id, action_type, source, description_fingerprint, category_assigned, previous_category)
+			VALUES (1, 1, 'edit', 'user', 'Imported row', 1, 1)`,
+	}
+	for _, stmt := range seed {
+		if _, err := conn.DB.Exec(stmt); err != nil {
+			t.Fatalf("failed to seed legacy data: %v", err)
+		}
+	}
+
+	if _, err := conn.Migrate(); err != nil {
+		t.Fatalf("Migrate() failed: %v", err)
+	}
+
+	var statementId sql.NullInt64
+	if err := conn.DB.QueryRow("SELECT bank_statement_id FROM transaction_audit_events WHERE transaction_id = 1").Scan(&statementId); err != nil {
+		t.Fatalf("failed to read migrated audit event: %v", err)
+	}
+	if !statementId.Valid || statementId.Int64 != 1 {
+		t.Errorf("expected existing audit event to keep statement 1, got %v", statementId)
+	}
+
+	// Manual transactions can now be audited, including deletes
+	if _, err := conn.DB.Exec(`INSERT INTO transaction_audit_events (transaction_id, bank_statement_id, action_type, source, description_fingerprint, category_assigned, previous_category)
+		VALUES (2, NULL, 'delete', 'user', 'Manual row', 1, 1)`); err != nil {
+		t.Errorf("expected delete event without a statement to be accepted: %v", err)
+	}
+}
+
+func TestAuditEventsMigrationCreatesLog(t *testing.T) {
+	conn := setupMigrationTestDB(t)
+
+	if _, err := conn.Migrate(); err != nil {
+		t.Fatalf("Migrate() failed: %v", err)
+	}
+
+	insert := `INSERT INTO audit_events (entity_type, entity_id, event_type, source, after_state) VALUES ('Category', '1', ?, 'user', '{}')`
+	if _, err := conn.DB.Exec(insert, "create"); err != nil {
+		t.Errorf("expected create event to be accepted: %v", err)
+	}
+	if _, err := conn.DB.Exec(insert, "rename"); err == nil {
+		t.Error("expected unknown event type to be rejected")
+	}
+}
+
+func TestLegacySplitMigrationLinksHalves(t *testing.T) {
+	conn := setupMigrationTestDB(t)
+
+	// The first half was updated and the second half inserted by the same split
+	seed := []string{
+		`INSERT INTO csv_templates (id, name, post_date_column, amount_column, desc_column) VALUES (1, 'Chase', 0, 1, 2)`,
+		`INSERT INTO bank_statements (id, filename, period_start, period_end, template_used, tx_count) VALUES (1, 'jan.csv', '2024-01-01', '2024-01-31', 1, 2)`,
+		`INSERT INTO transactions (id, amount, description, date, category_id, is_split, statement_id, created_at, updated_at)
+			VALUES (1, -15, 'Paint', '2024-01-15', 1, 1, 1, '2024-01-16 09:00:00', '2024-02-01 10:00:00')`,
+		`INSERT INTO transactions (id, amount, description, date, category_id, statement_id, created_at, updated_at)
+			VALUES (2, -30, 'Market', '2024-01-15', 1, 1, '2024-01-16 09:00:00', '2024-01-16 09:00:00')`,
+		`INSERT INTO transactions (id, amount, description, date, category_id, statement_id, created_at, updated_at)
+			VALUES (3, -25, 'Brushes', '2024-01-15', 1, 1, '2024-02-01 10:00:00', '2024-02-01 10:00:00')`,
+		`INSERT INTO transactions (id, amount, description, date, category_id, created_at, updated_at)
+			VALUES (4, -8, 'Coffee', '2024-01-15', 1, '2024-02-01 10:00:00', '2024-02-01 10:00:00')`,
+	}
+	for _, stmt := range seed {
+		if _, err := conn.DB.Exec(stmt); err != nil {
+			t.Fatalf("failed to seed legacy data: %v", err)
+		}
+	}
+
+	if _, err := conn.Migrate(); err != nil {
+		t.Fatalf("Migrate() failed: %v", err)
+	}
+
+	parents := map[int64]sql.NullInt64{}
+	updated := map[int64]string{}
+	rows, err := conn.DB.Query("SELECT id, parent_id, updated_at FROM transactions ORDER BY id")
+	if err != nil {
+		t.Fatalf("failed to read transactions: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var parentId sql.NullInt64
+		var updatedAt string
+		if err := rows.Scan(&id, &parentId, &updatedAt); err != nil {
+			t.Fatalf("failed to scan transaction: %v", err)
+		}
+		parents[id] = parentId
+		updated[id] = updatedAt
+	}
+
+	if p := parents[3]; !p.Valid || p.Int64 != 1 {
+		t.Errorf("second half parent_id = %v, want 1", p)
+	}
+	for _, id := range []int64{1, 2, 4} {
+		if p := parents[id]; p.Valid {
+			t.Errorf("transaction %d parent_id = %d, want NULL", id, p.Int64)
+		}
+	}
+
+	// Linking the halves is not an edit, so the second half keeps its timestamp
+	if updated[3] != "2024-02-01T10:00:00Z" {
+		t.Errorf("second half updated_at = %s, want it unchanged at 2024-02-01T10:00:00Z", updated[3])
+	}
+
+	// The integrity check counts a statement's transactions without their second halves
+	var topLevel int
+	if err := conn.DB.QueryRow("SELECT COUNT(*) FROM transactions WHERE statement_id = 1 AND parent_id IS NULL").Scan(&topLevel); err != nil {
+		t.Fatalf("failed to count statement transactions: %v", err)
+	}
+	if topLevel != 2 {
+		t.Errorf("statement has %d top-level transactions, want its tx_count of 2", topLevel)
+	}
+}
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// sqliteHeader is the magic string at the start of every SQLite 3 database file
+var sqliteHeader = []byte("SQLite format 3\x00")
+
+// ReadSnapshotSchemaVersion verifies that filePath is a SQLite database created by
+// this application and returns its schema version without modifying the file
+func ReadSnapshotSchemaVersion(filePath string) (int, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return 0, fmt.Errorf("cannot open snapshot file: %w", err)
+	}
+	header := make([]byte, len(sqliteHeader))
+	_, err = io.ReadFull(file, header)
+	file.Close()
+	if err != nil || !bytes.Equal(header, sqliteHeader) {
+		return 0, fmt.Errorf("snapshot file is not a SQLite database")
+	}
+
+	db, err := sql.Open("sqlite", "file:"+filepath.ToSlash(filePath)+"?mode=ro")
+	if err != nil {
+		return 0, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer db.Close()
+
+	var version int
+	err = db.QueryRow("SELECT version FROM schema_version ORDER BY version DESC LIMIT 1").Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("snapshot has no schema version, not a finance database: %w", err)
+	}
+
+	return version, nil
+}
+
+// Checkpoint flushes the write-ahead log into the main database file
+func (c *Connection) Checkpoint() error {
+	if _, err := c.DB.Exec("PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return fmt.Errorf("failed to checkpoint WAL: %w", err)
+	}
+	return nil
+}
+
+// ReplaceDatabaseFile atomically replaces the database at dbPath with a copy of srcPath
+// The connection to dbPath must be closed first. Stale -wal and -shm files are removed
+// so SQLite does not replay the old log on top of the restored database.
+func ReplaceDatabaseFile(srcPath, dbPath string) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return fmt.Errorf("failed to open source database: %w", err)
+	}
+	defer src.Close()
+
+	// Stage the copy next to the target so the final rename stays on one filesystem
+	tmpPath := dbPath + ".restore-tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create staging file: %w", err)
+	}
+
+	if _, err := io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to copy database: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to flush staging file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to close staging file: %w", err)
+	}
+
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dbPath + suffix); err != nil && !os.IsNotExist(err) {
+			os.Remove(tmpPath)
+			return fmt.Errorf("failed to remove %s file: %w", suffix, err)
+		}
+	}
+
+	if err := os.Rename(tmpPath, dbPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace database file: %w", err)
+	}
+
+	return nil
+}
+
+// OpenLedgerCopy opens a private copy of another ledger file, migrated to the current schema
+// The source file is never written; an uncheckpointed -wal file beside it is copied too.
+// Call the returned cleanup function to close the copy and delete it.
+func OpenLedgerCopy(srcPath string) (*Connection, func(), error) {
+	tmpDir, err := os.MkdirTemp("", "finance-merge-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	copyPath := filepath.Join(tmpDir, "source.db")
+	if err := ReplaceDatabaseFile(srcPath, copyPath); err != nil {
+		os.RemoveAll(tmpDir)
+		return nil, nil, err
+	}
+	if _, err := os.Stat(srcPath + "-wal"); err == nil {
+		if err := ReplaceDatabaseFile(srcPath+"-wal", copyPath+"-wal"); err != nil {
+			os.RemoveAll(tmpDir)
+			return nil, nil, err
+		}
+	}
+
+	conn, err := NewConnectionAt(copyPath)
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		return nil, nil, err
+	}
+	if _, err := conn.Migrate(); err != nil {
+		conn.Close()
+		os.RemoveAll(tmpDir)
+		return nil, nil, fmt.Errorf("failed to migrate ledger copy: %w", err)
+	}
+
+	cleanup := func() {
+		conn.Close()
+		os.RemoveAll(tmpDir)
+	}
+	return conn, cleanup, nil
+}
@@ -0,0 +1,66 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+)
+
+// Log file defaults
+const (
+	logDirName     = "logs"
+	logFileName    = "finance.log"
+	maxLogFileSize = 5 << 20 // Rotate once the file reaches 5 MiB
+	maxLogBackups  = 3       // finance.log.1 .. finance.log.3
+)
+
+// level is shared by the default handler so the threshold can change at runtime
+var level = new(slog.LevelVar)
+
+// activePath is the file Setup routed the default logger to, empty before Setup
+var activePath string
+
+// Setup routes the default slog logger, and the standard log package, to a rotating file
+// The file lives in <dataDir>/logs/finance.log. Close the returned file on exit.
+func Setup(dataDir string, minLevel slog.Level) (*RotatingFile, error) {
+	file, err := OpenRotatingFile(LogPath(dataDir), maxLogFileSize, maxLogBackups)
+	if err != nil {
+		return nil, err
+	}
+
+	level.Set(minLevel)
+	slog.SetDefault(slog.New(slog.NewTextHandler(file, &slog.HandlerOptions{Level: level})))
+	activePath = file.Path()
+	return file, nil
+}
+
+// ActivePath returns the log file in use, or "" when logging has not been set up
+func ActivePath() string {
+	return activePath
+}
+
+// LogPath returns the log file used for a data directory
+func LogPath(dataDir string) string {
+	return filepath.Join(dataDir, logDirName, logFileName)
+}
+
+// Level returns the current minimum level written to the log
+func Level() slog.Level {
+	return level.Level()
+}
+
+// ParseLevel turns a --log-level value (debug, info, warn or error) into a slog level
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info", "":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("unknown log level %q (use debug, info, warn or error)", name)
+}
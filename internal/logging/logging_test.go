@@ -0,0 +1,95 @@
+package logging
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected slog.Level
+		wantErr  bool
+	}{
+		{"debug", slog.LevelDebug, false},
+		{"INFO", slog.LevelInfo, false},
+		{"", slog.LevelInfo, false},
+		{"warning", slog.LevelWarn, false},
+		{"error", slog.LevelError, false},
+		{"verbose", slog.LevelInfo, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLevel(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+		}
+		if got != tt.expected {
+			t.Errorf("ParseLevel(%q) = %v, want %v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestRotatingFileRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "finance.log")
+	file, err := OpenRotatingFile(path, 100, 2)
+	if err != nil {
+		t.Fatalf("OpenRotatingFile() failed: %v", err)
+	}
+	defer file.Close()
+
+	// Each write is 60 bytes, so every write after the first rotates the file
+	line := strings.Repeat("x", 59) + "\n"
+	for i := 0; i < 4; i++ {
+		if _, err := file.Write([]byte(line)); err != nil {
+			t.Fatalf("Write() failed: %v", err)
+		}
+	}
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Errorf("Expected %s to exist: %v", filepath.Base(name), err)
+			continue
+		}
+		if info.Size() != int64(len(line)) {
+			t.Errorf("Expected %s to hold one line, got %d bytes", filepath.Base(name), info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("Expected backups beyond the limit to be removed")
+	}
+}
+
+func TestReadEntriesFiltersByLevel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "finance.log")
+	file, err := OpenRotatingFile(path, 1<<20, 1)
+	if err != nil {
+		t.Fatalf("OpenRotatingFile() failed: %v", err)
+	}
+	logger := slog.New(slog.NewTextHandler(file, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	logger.Debug("parsed row", "line", 3)
+	logger.Info("import finished", "rows", 12)
+	logger.Warn("failed to save last import directory")
+	logger.Error("import failed")
+	file.Close()
+
+	entries, err := ReadEntries(path, slog.LevelWarn, 10)
+	if err != nil {
+		t.Fatalf("ReadEntries() failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Level != slog.LevelWarn || entries[1].Level != slog.LevelError {
+		t.Fatalf("Expected the warning and the error, got %+v", entries)
+	}
+
+	entries, err = ReadEntries(path, slog.LevelDebug, 2)
+	if err != nil {
+		t.Fatalf("ReadEntries() failed: %v", err)
+	}
+	if len(entries) != 2 || !strings.Contains(entries[1].Line, "import failed") {
+		t.Errorf("Expected the newest two entries, got %+v", entries)
+	}
+}
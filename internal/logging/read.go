@@ -0,0 +1,86 @@
+package logging
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Entry is one line of the log file
+type Entry struct {
+	Level slog.Level
+	Line  string
+}
+
+// ReadEntries returns the newest entries at or above minLevel, oldest first, at most limit of them
+// The previous rotated file is read too when the active file holds fewer entries than limit.
+func ReadEntries(path string, minLevel slog.Level, limit int) ([]Entry, error) {
+	entries, err := readFile(path, minLevel)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) < limit {
+		older, err := readFile(backupPath(path, 1), minLevel)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(older, entries...)
+	}
+
+	if len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries, nil
+}
+
+// readFile reads the entries of one log file; a missing file has none
+func readFile(path string, minLevel slog.Level) ([]Entry, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		entry := Entry{Level: parseEntryLevel(line), Line: line}
+		if entry.Level >= minLevel {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read log file: %w", err)
+	}
+	return entries, nil
+}
+
+// parseEntryLevel reads the level=... field written by the text handler, defaulting to info
+func parseEntryLevel(line string) slog.Level {
+	start := strings.Index(line, "level=")
+	if start < 0 {
+		return slog.LevelInfo
+	}
+	value := line[start+len("level="):]
+	if end := strings.IndexByte(value, ' '); end >= 0 {
+		value = value[:end]
+	}
+
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(value)); err != nil {
+		return slog.LevelInfo
+	}
+	return lvl
+}
@@ -0,0 +1,112 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// RotatingFile is an append-only log file that is renamed aside once it grows past a size limit
+// finance.log becomes finance.log.1, older backups shift up, and the oldest beyond the limit is removed.
+type RotatingFile struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	backups int
+	file    *os.File
+	size    int64
+}
+
+// OpenRotatingFile opens path for appending, creating its directory when needed
+func OpenRotatingFile(path string, maxSize int64, backups int) (*RotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	rf := &RotatingFile{path: path, maxSize: maxSize, backups: backups}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+// Path returns the path of the active log file
+func (rf *RotatingFile) Path() string {
+	return rf.path
+}
+
+// Write appends p, rotating first if it would take the file past the size limit
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.file == nil {
+		return 0, os.ErrClosed
+	}
+	if rf.size > 0 && rf.size+int64(len(p)) > rf.maxSize {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// Close closes the active log file
+func (rf *RotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.file == nil {
+		return nil
+	}
+	err := rf.file.Close()
+	rf.file = nil
+	return err
+}
+
+// open opens the active file and records its current size
+func (rf *RotatingFile) open() error {
+	file, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	rf.file = file
+	rf.size = info.Size()
+	return nil
+}
+
+// rotate shifts the backups up by one and starts a new, empty active file
+func (rf *RotatingFile) rotate() error {
+	if err := rf.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	rf.file = nil
+
+	os.Remove(backupPath(rf.path, rf.backups))
+	for i := rf.backups - 1; i >= 1; i-- {
+		os.Rename(backupPath(rf.path, i), backupPath(rf.path, i+1))
+	}
+	if rf.backups > 0 {
+		if err := os.Rename(rf.path, backupPath(rf.path, 1)); err != nil {
+			return fmt.Errorf("failed to rotate log file: %w", err)
+		}
+	} else if err := os.Remove(rf.path); err != nil {
+		return fmt.Errorf("failed to truncate log file: %w", err)
+	}
+
+	return rf.open()
+}
+
+// backupPath returns the name of the nth rotated copy of path
+func backupPath(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
@@ -1,7 +1,7 @@
 package ml
 
 import (
-	"fmt"
+	"log/slog"
 	"math"
 	"sort"
 	"strings"
@@ -96,8 +96,7 @@ func (ec *EmbeddingsCategorizer) Train(auditEvents []types.TransactionAuditEvent
 		ec.trainingExamples = append(ec.trainingExamples, example)
 	}
 
-	fmt.Printf("[ML] Trained categorizer with %d examples from %d audit events (deduplicated by description)\n",
-		len(ec.trainingExamples), len(auditEvents))
+	slog.Debug("trained categorizer", "examples", len(ec.trainingExamples), "audit_events", len(auditEvents))
 	return nil
 }
 
@@ -0,0 +1,385 @@
+package storage
+
+import (
+	"budget-tracker-tui/internal/database"
+	"budget-tracker-tui/internal/types"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// AccountStore handles all account-related operations using SQLite
+type AccountStore struct {
+	db     *database.Connection
+	helper *database.SQLHelper
+}
+
+// NewAccountStore creates a new AccountStore instance
+func NewAccountStore(db *database.Connection) *AccountStore {
+	return &AccountStore{
+		db:     db,
+		helper: database.NewSQLHelper(db),
+	}
+}
+
+// GetAccounts returns all accounts ordered by name
+func (as *AccountStore) GetAccounts() ([]types.Account, error) {
+	query := `
+		SELECT id, name, account_type, institution, account_number, opening_balance_cents, currency,
+		       created_at, updated_at
+		FROM accounts
+		ORDER BY name
+	`
+
+	rows, err := as.helper.QueryRows(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query accounts: %w", err)
+	}
+	defer rows.Close()
+
+	var accounts []types.Account
+	for rows.Next() {
+		account, err := as.scanAccount(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan account: %w", err)
+		}
+		accounts = append(accounts, account)
+	}
+
+	return accounts, rows.Err()
+}
+
+// accountScanner is satisfied by both *sql.Rows and *sql.Row
+type accountScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanAccount scans a database row into an Account struct
+func (as *AccountStore) scanAccount(row accountScanner) (types.Account, error) {
+	var account types.Account
+	var institution, accountNumber sql.NullString
+	var createdAtStr, updatedAtStr string
+
+	err := row.Scan(
+		&account.Id, &account.Name, &account.AccountType, &institution, &accountNumber,
+		&account.OpeningBalance.Cents, &account.OpeningBalance.Currency,
+		&createdAtStr, &updatedAtStr,
+	)
+	if err != nil {
+		return account, err
+	}
+
+	if account.CreatedAt, err = as.helper.ParseTimeFromDB(createdAtStr); err != nil {
+		return account, fmt.Errorf("failed to parse created_at: %w", err)
+	}
+	if account.UpdatedAt, err = as.helper.ParseTimeFromDB(updatedAtStr); err != nil {
+		return account, fmt.Errorf("failed to parse updated_at: %w", err)
+	}
+	if institution.Valid {
+		account.Institution = institution.String
+	}
+	if accountNumber.Valid {
+		account.AccountNumber = accountNumber.String
+	}
+
+	return account, nil
+}
+
+// GetAccountById returns an account by ID, or nil if it does not exist
+func (as *AccountStore) GetAccountById(id int64) *types.Account {
+	query := `
+		SELECT id, name, account_type, institution, account_number, opening_balance_cents, currency,
+		       created_at, updated_at
+		FROM accounts
+		WHERE id = ?
+	`
+
+	account, err := as.scanAccount(as.helper.QuerySingleRow(query, id))
+	if err != nil {
+		return nil
+	}
+	return &account
+}
+
+// GetAccountByName returns an account by name (case-insensitive), or nil if it does not exist
+func (as *AccountStore) GetAccountByName(name string) *types.Account {
+	return as.getAccountByName(as.db.DB, name)
+}
+
+// getAccountByName looks up an account by name through db
+func (as *AccountStore) getAccountByName(db sqlQuerier, name string) *types.Account {
+	query := `
+		SELECT id, name, account_type, institution, account_number, opening_balance_cents, currency,
+		       created_at, updated_at
+		FROM accounts
+		WHERE name = ? COLLATE NOCASE
+	`
+
+	account, err := as.scanAccount(db.QueryRow(query, strings.TrimSpace(name)))
+	if err != nil {
+		return nil
+	}
+	return &account
+}
+
+// FindAccountByNumber returns the account whose number matches the account a statement file
+// names, or nil if none does
+func (as *AccountStore) FindAccountByNumber(reported string) *types.Account {
+	if normalizeAccountNumber(reported) == "" {
+		return nil
+	}
+	accounts, err := as.GetAccounts()
+	if err != nil {
+		return nil
+	}
+	for _, account := range accounts {
+		if accountNumberMatches(account.AccountNumber, reported) {
+			return &account
+		}
+	}
+	return nil
+}
+
+// normalizeAccountNumber drops the spaces, dashes and dots banks use to group account numbers
+func normalizeAccountNumber(number string) string {
+	return strings.ToUpper(strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == '-' || r == '.' {
+			return -1
+		}
+		return r
+	}, number))
+}
+
+// accountNumberMatches reports whether the account number stored on an account is the one a
+// statement file reports. Files name accounts in several ways: a bare number or IBAN, an IBAN
+// followed by its currency (NL81ASNB0123456789EUR), or a bank code and number (37040044/0532013000).
+func accountNumberMatches(stored, reported string) bool {
+	stored, reported = normalizeAccountNumber(stored), normalizeAccountNumber(reported)
+	if stored == "" || reported == "" {
+		return false
+	}
+	if n := len(reported); n > 4 && isLetters(reported[n-3:]) && unicode.IsDigit(rune(reported[n-4])) {
+		reported = reported[:n-3]
+	}
+	if stored == reported {
+		return true
+	}
+
+	// The number alone matches an IBAN or bank-code form ending in it, and an IBAN matches the
+	// bank-code form when it holds both parts
+	if len(stored) >= 6 && strings.HasSuffix(reported, stored) {
+		return true
+	}
+	if bank, number, ok := strings.Cut(reported, "/"); ok && len(number) >= 6 {
+		return strings.HasSuffix(stored, number) && strings.Contains(stored, bank)
+	}
+	return false
+}
+
+// isLetters reports whether s is made only of ASCII letters
+func isLetters(s string) bool {
+	for _, r := range s {
+		if (r < 'A' || r > 'Z') && (r < 'a' || r > 'z') {
+			return false
+		}
+	}
+	return true
+}
+
+// nullableAccountNumber stores a blank account number as NULL
+func nullableAccountNumber(number string) interface{} {
+	if strings.TrimSpace(number) == "" {
+		return nil
+	}
+	return strings.TrimSpace(number)
+}
+
+// CreateAccount validates and creates a new account
+func (as *AccountStore) CreateAccount(account types.Account) *AccountResult {
+	result := &AccountResult{}
+
+	validation := account.Validate()
+	if !validation.IsValid {
+		result.Message = validation.Errors[0].Message
+		return result
+	}
+
+	if as.GetAccountByName(account.Name) != nil {
+		result.Message = fmt.Sprintf("Account '%s' already exists", strings.TrimSpace(account.Name))
+		return result
+	}
+
+	id, err := as.insertAccount(as.db.DB, account, time.Now())
+	if err != nil {
+		result.Message = fmt.Sprintf("Failed to create account: %v", err)
+		return result
+	}
+
+	result.Success = true
+	result.AccountId = id
+	result.Message = fmt.Sprintf("Account '%s' created", strings.TrimSpace(account.Name))
+	return result
+}
+
+// insertAccount inserts a new account through db and returns its ID
+func (as *AccountStore) insertAccount(db sqlExecer, account types.Account, now time.Time) (int64, error) {
+	query := `
+		INSERT INTO accounts (
+			name, account_type, institution, account_number, opening_balance_cents, currency,
+			created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	accountType := account.AccountType
+	if accountType == "" {
+		accountType = types.AccountTypeChecking
+	}
+
+	var institution interface{}
+	if strings.TrimSpace(account.Institution) != "" {
+		institution = strings.TrimSpace(account.Institution)
+	}
+
+	nowStr := now.Format(time.RFC3339)
+	res, err := db.Exec(query,
+		strings.TrimSpace(account.Name), accountType, institution, nullableAccountNumber(account.AccountNumber),
+		account.OpeningBalance.Cents, currencyCode(account.OpeningBalance),
+		nowStr, nowStr,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert account: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert account: %w", err)
+	}
+
+	return id, nil
+}
+
+// UpdateAccount validates and saves changes to an existing account
+func (as *AccountStore) UpdateAccount(account types.Account) error {
+	validation := account.Validate()
+	if !validation.IsValid {
+		return fmt.Errorf("invalid account: %s", validation.Errors[0].Message)
+	}
+
+	if existing := as.GetAccountByName(account.Name); existing != nil && existing.Id != account.Id {
+		return fmt.Errorf("account '%s' already exists", strings.TrimSpace(account.Name))
+	}
+
+	query := `
+		UPDATE accounts SET
+			name = ?, account_type = ?, institution = ?, account_number = ?, opening_balance_cents = ?, currency = ?,
+			updated_at = ?
+		WHERE id = ?
+	`
+
+	accountType := account.AccountType
+	if accountType == "" {
+		accountType = types.AccountTypeChecking
+	}
+
+	var institution interface{}
+	if strings.TrimSpace(account.Institution) != "" {
+		institution = strings.TrimSpace(account.Institution)
+	}
+
+	rowsAffected, err := as.helper.ExecReturnRowsAffected(query,
+		strings.TrimSpace(account.Name), accountType, institution, nullableAccountNumber(account.AccountNumber),
+		account.OpeningBalance.Cents, currencyCode(account.OpeningBalance),
+		time.Now().Format(time.RFC3339), account.Id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update account: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("account not found")
+	}
+
+	return nil
+}
+
+// DeleteAccount deletes an account that has no statements imported into it
+func (as *AccountStore) DeleteAccount(id int64) *AccountResult {
+	count, err := as.helper.CountBy("bank_statements", "account_id = ?", id)
+	if err != nil {
+		return &AccountResult{Message: fmt.Sprintf("Failed to check account usage: %v", err)}
+	}
+	if count > 0 {
+		return &AccountResult{Message: fmt.Sprintf("Cannot delete account: it is used by %d bank statement(s)", count)}
+	}
+
+	rowsAffected, err := as.helper.DeleteBy("accounts", "id = ?", id)
+	if err != nil {
+		return &AccountResult{Message: fmt.Sprintf("Failed to delete account: %v", err)}
+	}
+	if rowsAffected == 0 {
+		return &AccountResult{Message: "Account not found"}
+	}
+
+	return &AccountResult{Success: true, AccountId: id, Message: "Account deleted successfully"}
+}
+
+// GetAccountBalances returns every account with its running balance
+// The balance is the opening balance plus all transactions in the account's currency.
+func (as *AccountStore) GetAccountBalances() ([]types.AccountBalance, error) {
+	query := `
+		SELECT a.id, a.name, a.account_type, a.institution, a.account_number, a.opening_balance_cents, a.currency,
+		       a.created_at, a.updated_at,
+		       COALESCE(SUM(t.amount_cents), 0), COUNT(t.id), COALESCE(MAX(t.date), '')
+		FROM accounts a
+		LEFT JOIN transactions t ON t.account_id = a.id AND t.currency = a.currency AND t.deleted_at IS NULL
+		GROUP BY a.id
+		ORDER BY a.name
+	`
+
+	rows, err := as.helper.QueryRows(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query account balances: %w", err)
+	}
+	defer rows.Close()
+
+	var balances []types.AccountBalance
+	for rows.Next() {
+		var balance types.AccountBalance
+		var institution, accountNumber sql.NullString
+		var createdAtStr, updatedAtStr, lastActivityStr string
+		var totalCents int64
+
+		err := rows.Scan(
+			&balance.Account.Id, &balance.Account.Name, &balance.Account.AccountType, &institution, &accountNumber,
+			&balance.Account.OpeningBalance.Cents, &balance.Account.OpeningBalance.Currency,
+			&createdAtStr, &updatedAtStr,
+			&totalCents, &balance.TransactionCount, &lastActivityStr,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan account balance: %w", err)
+		}
+
+		if balance.Account.CreatedAt, err = as.helper.ParseTimeFromDB(createdAtStr); err != nil {
+			return nil, fmt.Errorf("failed to parse created_at: %w", err)
+		}
+		if balance.Account.UpdatedAt, err = as.helper.ParseTimeFromDB(updatedAtStr); err != nil {
+			return nil, fmt.Errorf("failed to parse updated_at: %w", err)
+		}
+		if institution.Valid {
+			balance.Account.Institution = institution.String
+		}
+		if accountNumber.Valid {
+			balance.Account.AccountNumber = accountNumber.String
+		}
+		if lastActivityStr != "" {
+			if balance.LastActivity, err = parseStoredDate(lastActivityStr); err != nil {
+				return nil, fmt.Errorf("failed to parse last activity '%s': %w", lastActivityStr, err)
+			}
+		}
+
+		balance.Balance = balance.Account.OpeningBalance.Add(types.NewMoney(totalCents, balance.Account.OpeningBalance.Currency))
+		balances = append(balances, balance)
+	}
+
+	return balances, rows.Err()
+}
@@ -0,0 +1,264 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"budget-tracker-tui/internal/database"
+	"budget-tracker-tui/internal/types"
+)
+
+// setupTestAccountStore creates an AccountStore for testing
+func setupTestAccountStore(t *testing.T) (*AccountStore, *database.Connection) {
+	t.Helper()
+
+	conn := setupTestDB(t)
+	store := NewAccountStore(conn)
+
+	return store, conn
+}
+
+// createTestAccount creates a checking account and returns its ID
+func createTestAccount(t *testing.T, store *AccountStore, name string) int64 {
+	t.Helper()
+
+	result := store.CreateAccount(types.Account{Name: name, AccountType: types.AccountTypeChecking})
+	if !result.Success {
+		t.Fatalf("Failed to create test account: %s", result.Message)
+	}
+
+	return result.AccountId
+}
+
+// createTestAccountTransaction inserts a transaction linked to an account
+func createTestAccountTransaction(t *testing.T, conn *database.Connection, accountId int64, cents int64, currency, date string) {
+	t.Helper()
+
+	query := `INSERT INTO transactions (amount_cents, currency, description, date, category_id,
+	                                    transaction_type, account_id, created_at, updated_at)
+	          VALUES (?, ?, 'Test transaction', ?, 1, 'expense', ?, ?, ?)`
+
+	nowStr := time.Now().Format(time.RFC3339)
+	if _, err := conn.DB.Exec(query, cents, currency, date, accountId, nowStr, nowStr); err != nil {
+		t.Fatalf("Failed to create test account transaction: %v", err)
+	}
+}
+
+func TestAccountStoreCreateAccount(t *testing.T) {
+	tests := []struct {
+		name        string
+		existing    string
+		account     types.Account
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:    "valid checking account",
+			account: types.Account{Name: "Everyday Checking", AccountType: types.AccountTypeChecking, Institution: "First Bank"},
+		},
+		{
+			name:    "empty type defaults to checking",
+			account: types.Account{Name: "Wallet"},
+		},
+		{
+			name:        "empty name",
+			account:     types.Account{Name: "   "},
+			expectError: true,
+			errorMsg:    "account name cannot be empty",
+		},
+		{
+			name:        "unknown account type",
+			account:     types.Account{Name: "Brokerage", AccountType: "investment"},
+			expectError: true,
+			errorMsg:    "account type must be one of",
+		},
+		{
+			name:        "invalid currency",
+			account:     types.Account{Name: "Travel", OpeningBalance: types.Money{Currency: "EURO"}},
+			expectError: true,
+			errorMsg:    "currency code must be 3 letters",
+		},
+		{
+			name:        "duplicate name is case-insensitive",
+			existing:    "Savings",
+			account:     types.Account{Name: "savings", AccountType: types.AccountTypeSavings},
+			expectError: true,
+			errorMsg:    "already exists",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, conn := setupTestAccountStore(t)
+			defer teardownTestDB(t, conn)
+
+			if tt.existing != "" {
+				createTestAccount(t, store, tt.existing)
+			}
+
+			result := store.CreateAccount(tt.account)
+			if tt.expectError {
+				if result.Success {
+					t.Fatal("Expected failure, got success")
+				}
+				if !strings.Contains(result.Message, tt.errorMsg) {
+					t.Errorf("Expected message containing '%s', got: %s", tt.errorMsg, result.Message)
+				}
+				return
+			}
+			if !result.Success {
+				t.Fatalf("Unexpected failure: %s", result.Message)
+			}
+
+			account := store.GetAccountById(result.AccountId)
+			if account == nil {
+				t.Fatal("Created account not found")
+			}
+			if account.AccountType != types.AccountTypeChecking && tt.account.AccountType == "" {
+				t.Errorf("Expected default account type checking, got %s", account.AccountType)
+			}
+			if account.OpeningBalance.Currency != types.DefaultCurrency {
+				t.Errorf("Expected default currency %s, got %s", types.DefaultCurrency, account.OpeningBalance.Currency)
+			}
+			if account.Institution != tt.account.Institution {
+				t.Errorf("Expected institution '%s', got '%s'", tt.account.Institution, account.Institution)
+			}
+		})
+	}
+}
+
+func TestAccountStoreUpdateAccount(t *testing.T) {
+	store, conn := setupTestAccountStore(t)
+	defer teardownTestDB(t, conn)
+
+	id := createTestAccount(t, store, "Card")
+	createTestAccount(t, store, "Other")
+
+	account := store.GetAccountById(id)
+	account.Name = "Rewards Card"
+	account.AccountType = types.AccountTypeCredit
+	account.OpeningBalance = types.NewMoney(-5000, "USD")
+	if err := store.UpdateAccount(*account); err != nil {
+		t.Fatalf("Failed to update account: %v", err)
+	}
+
+	updated := store.GetAccountByName("rewards card")
+	if updated == nil || updated.Id != id {
+		t.Fatal("Updated account not found by new name")
+	}
+	if updated.AccountType != types.AccountTypeCredit || updated.OpeningBalance.Cents != -5000 {
+		t.Errorf("Unexpected account after update: %+v", updated)
+	}
+
+	updated.Name = "Other"
+	if err := store.UpdateAccount(*updated); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Expected duplicate name error, got: %v", err)
+	}
+}
+
+func TestAccountStoreFindAccountByNumber(t *testing.T) {
+	store, conn := setupTestAccountStore(t)
+	defer teardownTestDB(t, conn)
+
+	ibanId := createTestAccount(t, store, "Business")
+	account := store.GetAccountById(ibanId)
+	account.AccountNumber = "DE89 3704 0044 0532 0130 00"
+	if err := store.UpdateAccount(*account); err != nil {
+		t.Fatalf("Failed to update account: %v", err)
+	}
+	numberId := createTestAccount(t, store, "Savings")
+	account = store.GetAccountById(numberId)
+	account.AccountNumber = "9999999999"
+	if err := store.UpdateAccount(*account); err != nil {
+		t.Fatalf("Failed to update account: %v", err)
+	}
+	createTestAccount(t, store, "Cash")
+
+	cases := []struct {
+		reported string
+		want     int64
+	}{
+		{"DE89370400440532013000", ibanId},
+		{"de89370400440532013000EUR", ibanId},
+		{"37040044/0532013000", ibanId},
+		{"NL81ASNB9999999999", numberId},
+		{"NL81ASNB9999999999EUR", numberId},
+		{"12345678/1111111111", 0},
+		{"", 0},
+	}
+	for _, tc := range cases {
+		found := store.FindAccountByNumber(tc.reported)
+		switch {
+		case tc.want == 0 && found != nil:
+			t.Errorf("FindAccountByNumber(%q) = %s, want no account", tc.reported, found.Name)
+		case tc.want != 0 && (found == nil || found.Id != tc.want):
+			t.Errorf("FindAccountByNumber(%q) = %+v, want account %d", tc.reported, found, tc.want)
+		}
+	}
+}
+
+func TestAccountStoreDeleteAccount(t *testing.T) {
+	store, conn := setupTestAccountStore(t)
+	defer teardownTestDB(t, conn)
+
+	unused := createTestAccount(t, store, "Unused")
+	used := createTestAccount(t, store, "Used")
+
+	statements := NewBankStatementStore(conn)
+	templateId := createTestCSVTemplate(t, conn, "delete_account_template")
+	if _, err := statements.RecordBankStatement("used.csv", "2024-01-01", "2024-01-31", templateId, used, 0, "completed"); err != nil {
+		t.Fatalf("Failed to record statement: %v", err)
+	}
+
+	if result := store.DeleteAccount(used); result.Success {
+		t.Error("Expected deleting an account with statements to fail")
+	}
+	if result := store.DeleteAccount(unused); !result.Success {
+		t.Errorf("Expected delete to succeed, got: %s", result.Message)
+	}
+	if store.GetAccountById(unused) != nil {
+		t.Error("Deleted account still exists")
+	}
+	if result := store.DeleteAccount(unused); result.Success {
+		t.Error("Expected deleting a missing account to fail")
+	}
+}
+
+func TestAccountStoreGetAccountBalances(t *testing.T) {
+	store, conn := setupTestAccountStore(t)
+	defer teardownTestDB(t, conn)
+
+	checking := store.CreateAccount(types.Account{Name: "Checking", OpeningBalance: types.NewMoney(100000, "USD")}).AccountId
+	createTestAccount(t, store, "Empty")
+
+	createTestAccountTransaction(t, conn, checking, -2550, "USD", "2024-01-05")
+	createTestAccountTransaction(t, conn, checking, 150000, "USD", "2024-01-15")
+	createTestAccountTransaction(t, conn, checking, -999, "EUR", "2024-01-20") // foreign currency is not mixed in
+
+	balances, err := store.GetAccountBalances()
+	if err != nil {
+		t.Fatalf("Failed to get balances: %v", err)
+	}
+	if len(balances) != 2 {
+		t.Fatalf("Expected 2 balances, got %d", len(balances))
+	}
+
+	// Ordered by name
+	if balances[0].Account.Name != "Checking" {
+		t.Fatalf("Expected Checking first, got %s", balances[0].Account.Name)
+	}
+	if balances[0].Balance.Cents != 247450 || balances[0].Balance.Currency != "USD" {
+		t.Errorf("Expected balance 2474.50 USD, got %s", balances[0].Balance.Display())
+	}
+	if balances[0].TransactionCount != 2 {
+		t.Errorf("Expected 2 transactions, got %d", balances[0].TransactionCount)
+	}
+	if !balances[0].LastActivity.Equal(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected last activity 2024-01-15, got %v", balances[0].LastActivity)
+	}
+
+	if !balances[1].Balance.IsZero() || balances[1].TransactionCount != 0 || !balances[1].LastActivity.IsZero() {
+		t.Errorf("Expected empty account with zero balance, got %+v", balances[1])
+	}
+}
@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"budget-tracker-tui/internal/database"
+	"budget-tracker-tui/internal/types"
+)
+
+// auditEventColumns lists the columns read by scanAuditEvents, in scan order
+const auditEventColumns = `id, entity_type, entity_id, event_type, source, before_state, after_state, timestamp`
+
+// AuditStore handles the general audit log of creates, updates and deletes across entities
+// Methods on a nil store record nothing, so domain stores work without an audit log wired in.
+type AuditStore struct {
+	db     *database.Connection
+	helper *database.SQLHelper
+}
+
+// NewAuditStore creates a new audit store instance
+func NewAuditStore(db *database.Connection) *AuditStore {
+	return &AuditStore{
+		db:     db,
+		helper: database.NewSQLHelper(db),
+	}
+}
+
+// auditId formats a numeric record ID as an audit entity ID
+func auditId(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
+// Record logs an event for an entity with its state before and after the change
+// before and after are JSON-encoded; pass nil for the side that does not exist.
+func (as *AuditStore) Record(entityType, entityId, eventType, source string, before, after interface{}) error {
+	if as == nil {
+		return nil
+	}
+
+	beforeState, err := types.EncodeAuditState(before)
+	if err != nil {
+		return err
+	}
+	afterState, err := types.EncodeAuditState(after)
+	if err != nil {
+		return err
+	}
+
+	return as.RecordEvent(&types.AuditEvent{
+		EntityType:  entityType,
+		EntityId:    entityId,
+		EventType:   eventType,
+		Source:      source,
+		BeforeState: beforeState,
+		AfterState:  afterState,
+	})
+}
+
+// recordOrWarn records an event like Record, logging a failure instead of returning it
+// The change being audited has already been written, so a lost audit event must not fail it.
+func (as *AuditStore) recordOrWarn(entityType, entityId, eventType, source string, before, after interface{}) {
+	if err := as.Record(entityType, entityId, eventType, source, before, after); err != nil {
+		slog.Warn("failed to record audit event", "entity_type", entityType, "entity_id", entityId, "event", eventType, "error", err)
+	}
+}
+
+// RecordEvent creates a new audit event
+func (as *AuditStore) RecordEvent(event *types.AuditEvent) error {
+	if as == nil {
+		return nil
+	}
+
+	if result := event.Validate(); !result.IsValid {
+		return fmt.Errorf("validation failed: %v", result.Errors)
+	}
+
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	query := `
+		INSERT INTO audit_events (entity_type, entity_id, event_type, source, before_state, after_state, timestamp)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	id, err := as.helper.ExecReturnID(query,
+		event.EntityType, event.EntityId, event.EventType, event.Source,
+		getNullString(event.BeforeState), getNullString(event.AfterState),
+		as.helper.FormatTimeForDB(event.Timestamp.UTC()),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit event: %w", err)
+	}
+
+	event.Id = id
+	return nil
+}
+
+// GetEventsByEntity retrieves the history of one entity, oldest first
+func (as *AuditStore) GetEventsByEntity(entityType, entityId string) ([]types.AuditEvent, error) {
+	query := "SELECT " + auditEventColumns + ` FROM audit_events
+		WHERE entity_type = ? AND entity_id = ?
+		ORDER BY timestamp ASC, id ASC`
+
+	rows, err := as.helper.QueryRows(query, entityType, entityId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit events by entity: %w", err)
+	}
+	defer rows.Close()
+
+	return as.scanAuditEvents(rows)
+}
+
+// GetEventsByEntityType retrieves every event for one kind of entity, newest first
+func (as *AuditStore) GetEventsByEntityType(entityType string) ([]types.AuditEvent, error) {
+	query := "SELECT " + auditEventColumns + ` FROM audit_events
+		WHERE entity_type = ?
+		ORDER BY timestamp DESC, id DESC`
+
+	rows, err := as.helper.QueryRows(query, entityType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit events by entity type: %w", err)
+	}
+	defer rows.Close()
+
+	return as.scanAuditEvents(rows)
+}
+
+// GetRecentEvents retrieves the most recent audit events across all entities
+func (as *AuditStore) GetRecentEvents(limit int) ([]types.AuditEvent, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	query := "SELECT " + auditEventColumns + ` FROM audit_events
+		ORDER BY timestamp DESC, id DESC
+		LIMIT ?`
+
+	rows, err := as.helper.QueryRows(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recent audit events: %w", err)
+	}
+	defer rows.Close()
+
+	return as.scanAuditEvents(rows)
+}
+
+// scanAuditEvents reads audit events selected with auditEventColumns
+func (as *AuditStore) scanAuditEvents(rows *sql.Rows) ([]types.AuditEvent, error) {
+	events := []types.AuditEvent{}
+
+	for rows.Next() {
+		var event types.AuditEvent
+		var beforeState, afterState sql.NullString
+		var timestampStr string
+
+		err := rows.Scan(
+			&event.Id,
+			&event.EntityType,
+			&event.EntityId,
+			&event.EventType,
+			&event.Source,
+			&beforeState,
+			&afterState,
+			&timestampStr,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit event: %w", err)
+		}
+
+		if event.Timestamp, err = as.helper.ParseTimeFromDB(timestampStr); err != nil {
+			return nil, fmt.Errorf("failed to parse timestamp: %w", err)
+		}
+		event.BeforeState = nullStringToPointer(beforeState)
+		event.AfterState = nullStringToPointer(afterState)
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
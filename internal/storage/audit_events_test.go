@@ -0,0 +1,265 @@
+package storage
+
+import (
+	"budget-tracker-tui/internal/database"
+	"budget-tracker-tui/internal/types"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+// setupAuditTestStore returns a test store with the general audit log wired into every store
+func setupAuditTestStore(t *testing.T) (*Store, *database.Connection) {
+	t.Helper()
+
+	transactions, conn := setupTestStore(t)
+	store := transactions.store
+	store.Audit = NewAuditStore(conn)
+	store.UserPreferences = NewUserPreferencesStore(conn)
+	store.Snapshots = NewSnapshotStore(conn)
+
+	store.Transactions.SetAuditStore(store.Audit)
+	store.Categories.SetAuditStore(store.Audit)
+	store.Templates.SetAuditStore(store.Audit)
+	store.Statements.SetAuditStore(store.Audit)
+	store.Snapshots.SetAuditStore(store.Audit)
+	store.UserPreferences.SetAuditStore(store.Audit)
+	return store, conn
+}
+
+// auditEventTypes returns the event types recorded for an entity, oldest first
+func auditEventTypes(t *testing.T, store *Store, entityType, entityId string) []string {
+	t.Helper()
+
+	events, err := store.Audit.GetEventsByEntity(entityType, entityId)
+	if err != nil {
+		t.Fatalf("GetEventsByEntity() failed: %v", err)
+	}
+	var eventTypes []string
+	for _, event := range events {
+		eventTypes = append(eventTypes, event.EventType)
+	}
+	return eventTypes
+}
+
+// decodeAuditState unmarshals an audit state into a map, failing the test on invalid JSON
+func decodeAuditState(t *testing.T, state *string) map[string]interface{} {
+	t.Helper()
+
+	if state == nil {
+		return nil
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(*state), &decoded); err != nil {
+		t.Fatalf("Audit state is not valid JSON: %v (%s)", err, *state)
+	}
+	return decoded
+}
+
+func TestAuditStoreRecord(t *testing.T) {
+	store, conn := setupAuditTestStore(t)
+	defer teardownTestDB(t, conn)
+
+	before := map[string]string{"name": `Joe's "famous" diner`}
+	after := map[string]string{"name": "Diner"}
+	if err := store.Audit.Record(types.EntityCategory, "7", types.AuditEventUpdate, types.SourceUser, before, after); err != nil {
+		t.Fatalf("Record() failed: %v", err)
+	}
+
+	events, err := store.Audit.GetRecentEvents(1)
+	if err != nil || len(events) != 1 {
+		t.Fatalf("Expected 1 recent event, got %d (err %v)", len(events), err)
+	}
+	if got := decodeAuditState(t, events[0].BeforeState)["name"]; got != `Joe's "famous" diner` {
+		t.Errorf("Expected quotes to survive encoding, got %v", got)
+	}
+
+	// Nil pointers mean the record did not exist on that side of the change
+	var missing *types.Category
+	if err := store.Audit.Record(types.EntityCategory, "8", types.AuditEventCreate, types.SourceUser, missing, after); err != nil {
+		t.Fatalf("Record() failed: %v", err)
+	}
+	events, _ = store.Audit.GetEventsByEntity(types.EntityCategory, "8")
+	if len(events) != 1 || events[0].BeforeState != nil {
+		t.Errorf("Expected a create event without a before state, got %+v", events)
+	}
+
+	invalid := []struct {
+		name                            string
+		entityType, entityId, eventType string
+		before, after                   interface{}
+	}{
+		{"unknown entity", "Widget", "1", types.AuditEventCreate, nil, after},
+		{"unknown event", types.EntityCategory, "1", "rename", nil, after},
+		{"missing id", types.EntityCategory, "", types.AuditEventCreate, nil, after},
+		{"no state", types.EntityCategory, "1", types.AuditEventDelete, nil, nil},
+	}
+	for _, tt := range invalid {
+		if err := store.Audit.Record(tt.entityType, tt.entityId, tt.eventType, types.SourceUser, tt.before, tt.after); err == nil {
+			t.Errorf("%s: expected Record() to fail", tt.name)
+		}
+	}
+
+	var nilStore *AuditStore
+	if err := nilStore.Record(types.EntityCategory, "1", types.AuditEventCreate, types.SourceUser, nil, after); err != nil {
+		t.Errorf("Expected a nil audit store to record nothing, got %v", err)
+	}
+}
+
+func TestAuditCategoryAndTemplateChanges(t *testing.T) {
+	store, conn := setupAuditTestStore(t)
+	defer teardownTestDB(t, conn)
+
+	category := &types.Category{DisplayName: "Hobbies", IsActive: true}
+	if err := store.Categories.CreateCategoryFull(category); err != nil {
+		t.Fatalf("CreateCategoryFull() failed: %v", err)
+	}
+	category.DisplayName = "Crafts"
+	if err := store.Categories.UpdateCategory(category); err != nil {
+		t.Fatalf("UpdateCategory() failed: %v", err)
+	}
+	if err := store.Categories.DeleteCategory(category.Id); err != nil {
+		t.Fatalf("DeleteCategory() failed: %v", err)
+	}
+
+	categoryId := auditId(category.Id)
+	if got := auditEventTypes(t, store, types.EntityCategory, categoryId); len(got) != 3 ||
+		got[0] != types.AuditEventCreate || got[1] != types.AuditEventUpdate || got[2] != types.AuditEventDelete {
+		t.Fatalf("Expected create, update, delete for the category, got %v", got)
+	}
+	events, _ := store.Audit.GetEventsByEntity(types.EntityCategory, categoryId)
+	if before := decodeAuditState(t, events[1].BeforeState); before["display_name"] != "Hobbies" {
+		t.Errorf("Expected update to keep the previous name, got %v", before["display_name"])
+	}
+	if after := decodeAuditState(t, events[1].AfterState); after["display_name"] != "Crafts" {
+		t.Errorf("Expected update to keep the new name, got %v", after["display_name"])
+	}
+	if events[2].AfterState != nil {
+		t.Error("Expected delete to have no after state")
+	}
+
+	result := store.Templates.CreateCSVTemplate(types.CSVTemplate{Name: "Credit Union", PostDateColumn: 0, AmountColumn: 1, DescColumn: 2})
+	if !result.Success {
+		t.Fatalf("CreateCSVTemplate() failed: %s", result.Message)
+	}
+	template := store.Templates.GetTemplateByName("Credit Union")
+	template.HasHeader = true
+	if err := store.Templates.SaveCSVTemplate(*template); err != nil {
+		t.Fatalf("SaveCSVTemplate() failed: %v", err)
+	}
+	if result := store.Templates.DeleteCSVTemplate(template.Id); !result.Success {
+		t.Fatalf("DeleteCSVTemplate() failed: %s", result.Message)
+	}
+
+	if got := auditEventTypes(t, store, types.EntityCSVTemplate, auditId(template.Id)); len(got) != 3 ||
+		got[0] != types.AuditEventCreate || got[1] != types.AuditEventUpdate || got[2] != types.AuditEventDelete {
+		t.Errorf("Expected create, update, delete for the template, got %v", got)
+	}
+}
+
+func TestAuditStatementAndPreferenceChanges(t *testing.T) {
+	store, conn := setupAuditTestStore(t)
+	defer teardownTestDB(t, conn)
+
+	templateId := createTestCSVTemplate(t, conn, "Audit Bank")
+	statementId, err := store.Statements.RecordBankStatement("jan.csv", "2024-01-01", "2024-01-31", templateId, 0, 0, "importing")
+	if err != nil {
+		t.Fatalf("RecordBankStatement() failed: %v", err)
+	}
+	if err := store.Statements.MarkStatementCompleted(statementId); err != nil {
+		t.Fatalf("MarkStatementCompleted() failed: %v", err)
+	}
+	if err := store.Statements.DeleteStatement(statementId); err != nil {
+		t.Fatalf("DeleteStatement() failed: %v", err)
+	}
+
+	events, _ := store.Audit.GetEventsByEntity(types.EntityBankStatement, auditId(statementId))
+	if len(events) != 3 || events[0].Source != types.SourceImport {
+		t.Fatalf("Expected 3 statement events starting with an import, got %+v", events)
+	}
+	if before, after := decodeAuditState(t, events[1].BeforeState), decodeAuditState(t, events[1].AfterState); before["status"] != "importing" || after["status"] != "completed" {
+		t.Errorf("Expected status change importing -> completed, got %v -> %v", before["status"], after["status"])
+	}
+
+	if err := store.UserPreferences.SetPreference("theme", "dark"); err != nil {
+		t.Fatalf("SetPreference() failed: %v", err)
+	}
+	if err := store.UserPreferences.SetPreference("theme", "light"); err != nil {
+		t.Fatalf("SetPreference() failed: %v", err)
+	}
+	if err := store.UserPreferences.DeletePreference("theme"); err != nil {
+		t.Fatalf("DeletePreference() failed: %v", err)
+	}
+
+	events, _ = store.Audit.GetEventsByEntity(types.EntityUserPreferences, "theme")
+	if len(events) != 3 || events[0].EventType != types.AuditEventCreate || events[2].EventType != types.AuditEventDelete {
+		t.Fatalf("Expected create, update, delete for the preference, got %+v", events)
+	}
+	if after := decodeAuditState(t, events[1].AfterState); after["value"] != "light" {
+		t.Errorf("Expected updated preference value, got %v", after["value"])
+	}
+}
+
+func TestAuditSnapshotChanges(t *testing.T) {
+	store, conn := setupAuditTestStore(t)
+	defer teardownTestDB(t, conn)
+
+	result, err := store.Snapshots.CreateSnapshot("Before taxes", "", filepath.Join(t.TempDir(), "before-taxes.db"))
+	if err != nil || !result.Success {
+		t.Fatalf("CreateSnapshot() failed: %v %+v", err, result)
+	}
+	if err := store.Snapshots.DeleteSnapshot(result.SnapshotId); err != nil {
+		t.Fatalf("DeleteSnapshot() failed: %v", err)
+	}
+
+	if got := auditEventTypes(t, store, types.EntitySnapshot, auditId(result.SnapshotId)); len(got) != 2 ||
+		got[0] != types.AuditEventCreate || got[1] != types.AuditEventDelete {
+		t.Errorf("Expected create and delete for the snapshot, got %v", got)
+	}
+}
+
+func TestAuditTransactionDeletes(t *testing.T) {
+	store, conn := setupAuditTestStore(t)
+	defer teardownTestDB(t, conn)
+
+	categoryId := createTestCategory(t, conn, "Travel")
+	id := saveTrashTestTransaction(t, store.Transactions, categoryId, `Taxi "airport"`, nil)
+	childId := saveTrashTestTransaction(t, store.Transactions, categoryId, "Tip", &id)
+
+	if err := store.Transactions.DeleteTransaction(id); err != nil {
+		t.Fatalf("DeleteTransaction() failed: %v", err)
+	}
+	if err := store.Transactions.RestoreTransaction(id); err != nil {
+		t.Fatalf("RestoreTransaction() failed: %v", err)
+	}
+	if err := store.Transactions.DeleteTransaction(id); err != nil {
+		t.Fatalf("DeleteTransaction() failed: %v", err)
+	}
+	if err := store.Transactions.PurgeTransaction(id); err != nil {
+		t.Fatalf("PurgeTransaction() failed: %v", err)
+	}
+
+	events, _ := store.Audit.GetEventsByEntity(types.EntityTransaction, auditId(id))
+	if len(events) != 4 {
+		t.Fatalf("Expected trash, restore, trash and purge events, got %d", len(events))
+	}
+	wantTypes := []string{types.AuditEventDelete, types.AuditEventUpdate, types.AuditEventDelete, types.AuditEventDelete}
+	for i, event := range events {
+		if event.EventType != wantTypes[i] {
+			t.Errorf("Event %d: expected %s, got %s", i, wantTypes[i], event.EventType)
+		}
+	}
+
+	// The purge is the only record left once the transaction and its own audit trail are gone
+	purge := events[3]
+	if purge.AfterState != nil {
+		t.Error("Expected purge to have no after state")
+	}
+	snapshot, err := types.DecodeTransactionSnapshot(purge.BeforeState)
+	if err != nil || snapshot.Description != `Taxi "airport"` || !snapshot.Deleted {
+		t.Errorf("Expected purged transaction state in the audit log, got %+v (err %v)", snapshot, err)
+	}
+	if got := auditEventTypes(t, store, types.EntityTransaction, auditId(childId)); len(got) != 1 || got[0] != types.AuditEventDelete {
+		t.Errorf("Expected the split child purged with its parent to be logged, got %v", got)
+	}
+}
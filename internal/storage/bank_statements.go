@@ -5,6 +5,7 @@ import (
 	"budget-tracker-tui/internal/types"
 	"database/sql"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -16,6 +17,7 @@ type BankStatementStore struct {
 	db           *database.Connection
 	helper       *database.SQLHelper
 	transactions *TransactionStore // For cross-domain operations
+	audit        *AuditStore       // General audit log of creates, updates and deletes
 }
 
 // NewBankStatementStore creates a new BankStatementStore instance
@@ -31,6 +33,20 @@ func (bs *BankStatementStore) SetTransactionStore(transactions *TransactionStore
 	bs.transactions = transactions
 }
 
+// SetAuditStore sets the audit log that statement changes are recorded in
+func (bs *BankStatementStore) SetAuditStore(audit *AuditStore) {
+	bs.audit = audit
+}
+
+// recordStatementChange logs a statement change, reading the state after it from the database
+func (bs *BankStatementStore) recordStatementChange(statementId int64, eventType, source string, before *types.BankStatement) {
+	var after *types.BankStatement
+	if eventType != types.AuditEventDelete {
+		after, _ = bs.GetStatementById(statementId)
+	}
+	bs.audit.recordOrWarn(types.EntityBankStatement, auditId(statementId), eventType, source, before, after)
+}
+
 // NextId calculates the next available ID for bank statements
 func (bs *BankStatementStore) NextId() int64 {
 	maxID, err := bs.helper.GetMaxID("bank_statements", "id")
@@ -40,18 +56,37 @@ func (bs *BankStatementStore) NextId() int64 {
 	return maxID + 1
 }
 
+// bankStatementColumns lists the columns read by scanBankStatement and scanBankStatementRow, in scan order
+const bankStatementColumns = `id, filename, import_date, period_start, period_end,
+		       template_used, tx_count, status, processing_time, error_log,
+		       account_id, created_at, updated_at,
+		       format, opening_balance_cents, closing_balance_cents, balance_currency`
+
+// statementBalance converts a stored balance column, NULL when the file reported none
+func statementBalance(cents sql.NullInt64, currency sql.NullString) *types.Money {
+	if !cents.Valid {
+		return nil
+	}
+	balance := types.NewMoney(cents.Int64, currency.String)
+	return &balance
+}
+
 // scanBankStatement scans a database row into a BankStatement struct
 func (bs *BankStatementStore) scanBankStatement(rows *sql.Rows) (types.BankStatement, error) {
 	var stmt types.BankStatement
 	var periodStart, periodEnd sql.NullString
 	var processingTime sql.NullInt64
 	var errorLog sql.NullString
+	var templateID, accountID sql.NullInt64
+	var openingCents, closingCents sql.NullInt64
+	var balanceCurrency sql.NullString
 	var importDateStr, createdAtStr, updatedAtStr string
 
 	err := rows.Scan(
 		&stmt.Id, &stmt.Filename, &importDateStr, &periodStart, &periodEnd,
-		&stmt.TemplateUsed, &stmt.TxCount, &stmt.Status, &processingTime,
-		&errorLog, &createdAtStr, &updatedAtStr,
+		&templateID, &stmt.TxCount, &stmt.Status, &processingTime,
+		&errorLog, &accountID, &createdAtStr, &updatedAtStr,
+		&stmt.Format, &openingCents, &closingCents, &balanceCurrency,
 	)
 
 	if err != nil {
@@ -80,7 +115,7 @@ func (bs *BankStatementStore) scanBankStatement(rows *sql.Rows) (types.BankState
 			// If date parsing fails, try parsing as timestamp (legacy format)
 			parsedStart, err = bs.helper.ParseTimeFromDB(periodStart.String)
 			if err != nil {
-				fmt.Printf("Warning: Failed to parse period start '%s': %v\n", periodStart.String, err)
+				slog.Warn("failed to parse statement period start", "value", periodStart.String, "error", err)
 				// Set to zero time instead of failing the entire record
 				stmt.PeriodStart = time.Time{}
 			} else {
@@ -97,7 +132,7 @@ func (bs *BankStatementStore) scanBankStatement(rows *sql.Rows) (types.BankState
 			// If date parsing fails, try parsing as timestamp (legacy format)
 			parsedEnd, err = bs.helper.ParseTimeFromDB(periodEnd.String)
 			if err != nil {
-				fmt.Printf("Warning: Failed to parse period end '%s': %v\n", periodEnd.String, err)
+				slog.Warn("failed to parse statement period end", "value", periodEnd.String, "error", err)
 				// Set to zero time instead of failing the entire record
 				stmt.PeriodEnd = time.Time{}
 			} else {
@@ -113,16 +148,21 @@ func (bs *BankStatementStore) scanBankStatement(rows *sql.Rows) (types.BankState
 	if errorLog.Valid {
 		stmt.ErrorLog = errorLog.String
 	}
+	if templateID.Valid {
+		stmt.TemplateUsed = templateID.Int64
+	}
+	if accountID.Valid {
+		stmt.AccountId = accountID.Int64
+	}
+	stmt.OpeningBalance = statementBalance(openingCents, balanceCurrency)
+	stmt.ClosingBalance = statementBalance(closingCents, balanceCurrency)
 
 	return stmt, nil
 }
 
 // GetStatementHistory returns all bank statements ordered by import date
 func (bs *BankStatementStore) GetStatementHistory() []types.BankStatement {
-	query := `
-		SELECT id, filename, import_date, period_start, period_end,
-		       template_used, tx_count, status, processing_time, error_log,
-		       created_at, updated_at
+	query := "SELECT " + bankStatementColumns + `
 		FROM bank_statements
 		ORDER BY import_date DESC
 	`
@@ -138,7 +178,7 @@ func (bs *BankStatementStore) GetStatementHistory() []types.BankStatement {
 		stmt, err := bs.scanBankStatement(rows)
 		if err != nil {
 			// Log the error but continue scanning other rows
-			fmt.Printf("Warning: Failed to scan bank statement row: %v\n", err)
+			slog.Warn("failed to scan bank statement row", "error", err)
 			continue
 		}
 		statements = append(statements, stmt)
@@ -153,12 +193,16 @@ func (bs *BankStatementStore) scanBankStatementRow(row *sql.Row) (types.BankStat
 	var periodStart, periodEnd sql.NullString
 	var processingTime sql.NullInt64
 	var errorLog sql.NullString
+	var templateID, accountID sql.NullInt64
+	var openingCents, closingCents sql.NullInt64
+	var balanceCurrency sql.NullString
 	var importDateStr, createdAtStr, updatedAtStr string
 
 	err := row.Scan(
 		&stmt.Id, &stmt.Filename, &importDateStr, &periodStart, &periodEnd,
-		&stmt.TemplateUsed, &stmt.TxCount, &stmt.Status, &processingTime,
-		&errorLog, &createdAtStr, &updatedAtStr,
+		&templateID, &stmt.TxCount, &stmt.Status, &processingTime,
+		&errorLog, &accountID, &createdAtStr, &updatedAtStr,
+		&stmt.Format, &openingCents, &closingCents, &balanceCurrency,
 	)
 
 	if err != nil {
@@ -187,7 +231,7 @@ func (bs *BankStatementStore) scanBankStatementRow(row *sql.Row) (types.BankStat
 			// If date parsing fails, try parsing as timestamp (legacy format)
 			parsedStart, err = bs.helper.ParseTimeFromDB(periodStart.String)
 			if err != nil {
-				fmt.Printf("Warning: Failed to parse period start '%s': %v\n", periodStart.String, err)
+				slog.Warn("failed to parse statement period start", "value", periodStart.String, "error", err)
 				// Set to zero time instead of failing the entire record
 				stmt.PeriodStart = time.Time{}
 			} else {
@@ -204,7 +248,7 @@ func (bs *BankStatementStore) scanBankStatementRow(row *sql.Row) (types.BankStat
 			// If date parsing fails, try parsing as timestamp (legacy format)
 			parsedEnd, err = bs.helper.ParseTimeFromDB(periodEnd.String)
 			if err != nil {
-				fmt.Printf("Warning: Failed to parse period end '%s': %v\n", periodEnd.String, err)
+				slog.Warn("failed to parse statement period end", "value", periodEnd.String, "error", err)
 				// Set to zero time instead of failing the entire record
 				stmt.PeriodEnd = time.Time{}
 			} else {
@@ -220,6 +264,14 @@ func (bs *BankStatementStore) scanBankStatementRow(row *sql.Row) (types.BankStat
 	if errorLog.Valid {
 		stmt.ErrorLog = errorLog.String
 	}
+	if templateID.Valid {
+		stmt.TemplateUsed = templateID.Int64
+	}
+	if accountID.Valid {
+		stmt.AccountId = accountID.Int64
+	}
+	stmt.OpeningBalance = statementBalance(openingCents, balanceCurrency)
+	stmt.ClosingBalance = statementBalance(closingCents, balanceCurrency)
 
 	return stmt, nil
 }
@@ -235,10 +287,7 @@ func (bs *BankStatementStore) GetStatementByIndex(index int) (*types.BankStateme
 
 // GetStatementById retrieves a bank statement by its ID
 func (bs *BankStatementStore) GetStatementById(id int64) (*types.BankStatement, error) {
-	query := `
-		SELECT id, filename, import_date, period_start, period_end,
-		       template_used, tx_count, status, processing_time, error_log,
-		       created_at, updated_at
+	query := "SELECT " + bankStatementColumns + `
 		FROM bank_statements
 		WHERE id = ?
 	`
@@ -253,14 +302,41 @@ func (bs *BankStatementStore) GetStatementById(id int64) (*types.BankStatement,
 }
 
 // RecordBankStatement records a new bank statement import and returns the actual assigned ID
-func (bs *BankStatementStore) RecordBankStatement(filename, periodStart, periodEnd string, templateId int64, txCount int, status string) (int64, error) {
+// An accountId of 0 records the statement without an account.
+func (bs *BankStatementStore) RecordBankStatement(filename, periodStart, periodEnd string, templateId, accountId int64, txCount int, status string) (int64, error) {
+	stmt := &types.BankStatement{
+		Filename:     filename,
+		TemplateUsed: templateId,
+		AccountId:    accountId,
+		TxCount:      txCount,
+		Status:       status,
+		Format:       types.StatementFormatCSV,
+	}
+	return bs.RecordStatement(stmt, periodStart, periodEnd)
+}
+
+// RecordStatement records a new statement import from any file format and returns its ID
+// Zero template and account IDs and empty periods are stored as NULL, as are missing balances.
+func (bs *BankStatementStore) RecordStatement(stmt *types.BankStatement, periodStart, periodEnd string) (int64, error) {
+	id, err := bs.insertStatement(bs.db.DB, stmt, periodStart, periodEnd)
+	if err != nil {
+		return 0, err
+	}
+
+	bs.recordStatementChange(id, types.AuditEventCreate, types.SourceImport, nil)
+	return id, nil
+}
+
+// insertStatement writes a statement record through db and returns its ID
+func (bs *BankStatementStore) insertStatement(db sqlExecer, stmt *types.BankStatement, periodStart, periodEnd string) (int64, error) {
 	now := time.Now().Format(time.RFC3339)
 
 	query := `
 		INSERT INTO bank_statements (
 			filename, import_date, period_start, period_end,
-			template_used, tx_count, status, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			template_used, account_id, tx_count, status, created_at, updated_at,
+			format, opening_balance_cents, closing_balance_cents, balance_currency
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	var periodStartVal, periodEndVal interface{}
@@ -271,16 +347,47 @@ func (bs *BankStatementStore) RecordBankStatement(filename, periodStart, periodE
 		periodEndVal = periodEnd
 	}
 
-	return bs.helper.ExecReturnID(query,
-		filename, now, periodStartVal, periodEndVal,
-		templateId, txCount, status, now, now,
+	var templateID interface{}
+	if stmt.TemplateUsed != 0 {
+		templateID = stmt.TemplateUsed
+	}
+
+	var accountID interface{}
+	if stmt.AccountId != 0 {
+		accountID = stmt.AccountId
+	}
+
+	format := stmt.Format
+	if format == "" {
+		format = types.StatementFormatCSV
+	}
+
+	var openingCents, closingCents, balanceCurrency interface{}
+	if stmt.OpeningBalance != nil {
+		openingCents = stmt.OpeningBalance.Cents
+		balanceCurrency = currencyCode(*stmt.OpeningBalance)
+	}
+	if stmt.ClosingBalance != nil {
+		closingCents = stmt.ClosingBalance.Cents
+		balanceCurrency = currencyCode(*stmt.ClosingBalance)
+	}
+
+	res, err := db.Exec(query,
+		stmt.Filename, now, periodStartVal, periodEndVal,
+		templateID, accountID, stmt.TxCount, stmt.Status, now, now,
+		format, openingCents, closingCents, balanceCurrency,
 	)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
 }
 
 // MarkStatementUndone marks a statement as undone
 func (bs *BankStatementStore) MarkStatementUndone(statementId int64) error {
 	query := "UPDATE bank_statements SET status = 'undone', updated_at = ? WHERE id = ?"
 	now := time.Now().Format(time.RFC3339)
+	before, _ := bs.GetStatementById(statementId)
 
 	rowsAffected, err := bs.helper.ExecReturnRowsAffected(query, now, statementId)
 	if err != nil {
@@ -291,6 +398,7 @@ func (bs *BankStatementStore) MarkStatementUndone(statementId int64) error {
 		return fmt.Errorf("statement not found")
 	}
 
+	bs.recordStatementChange(statementId, types.AuditEventUpdate, types.SourceUser, before)
 	return nil
 }
 
@@ -304,6 +412,7 @@ func (bs *BankStatementStore) CanUndoImport(statementId int64) bool {
 func (bs *BankStatementStore) MarkStatementFailed(statementId int64, errorMsg string) error {
 	query := "UPDATE bank_statements SET status = 'failed', error_log = ?, updated_at = ? WHERE id = ?"
 	now := time.Now().Format(time.RFC3339)
+	before, _ := bs.GetStatementById(statementId)
 
 	rowsAffected, err := bs.helper.ExecReturnRowsAffected(query, errorMsg, now, statementId)
 	if err != nil {
@@ -314,6 +423,27 @@ func (bs *BankStatementStore) MarkStatementFailed(statementId int64, errorMsg st
 		return fmt.Errorf("statement not found")
 	}
 
+	bs.recordStatementChange(statementId, types.AuditEventUpdate, types.SourceImport, before)
+	return nil
+}
+
+// SetStatementErrorLog records the rows of an import that were left out, such as rows that
+// failed to parse, on a statement that was otherwise imported
+func (bs *BankStatementStore) SetStatementErrorLog(statementId int64, errorLog string) error {
+	query := "UPDATE bank_statements SET error_log = ?, updated_at = ? WHERE id = ?"
+	now := time.Now().Format(time.RFC3339)
+	before, _ := bs.GetStatementById(statementId)
+
+	rowsAffected, err := bs.helper.ExecReturnRowsAffected(query, errorLog, now, statementId)
+	if err != nil {
+		return fmt.Errorf("failed to save statement error log: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("statement not found")
+	}
+
+	bs.recordStatementChange(statementId, types.AuditEventUpdate, types.SourceImport, before)
 	return nil
 }
 
@@ -321,6 +451,7 @@ func (bs *BankStatementStore) MarkStatementFailed(statementId int64, errorMsg st
 func (bs *BankStatementStore) MarkStatementCompleted(statementId int64) error {
 	query := "UPDATE bank_statements SET status = 'completed', updated_at = ? WHERE id = ?"
 	now := time.Now().Format(time.RFC3339)
+	before, _ := bs.GetStatementById(statementId)
 
 	rowsAffected, err := bs.helper.ExecReturnRowsAffected(query, now, statementId)
 	if err != nil {
@@ -331,24 +462,28 @@ func (bs *BankStatementStore) MarkStatementCompleted(statementId int64) error {
 		return fmt.Errorf("statement not found")
 	}
 
+	bs.recordStatementChange(statementId, types.AuditEventUpdate, types.SourceImport, before)
 	return nil
 }
 
-// DetectOverlap checks for period overlaps with existing completed statements using the same template
-func (bs *BankStatementStore) DetectOverlap(periodStart, periodEnd string, templateId int64) []types.BankStatement {
+// DetectOverlap checks for period overlaps with existing completed statements for the same account
+// Imports without an account (accountId 0) fall back to matching statements that used the same template.
+func (bs *BankStatementStore) DetectOverlap(periodStart, periodEnd string, templateId, accountId int64) []types.BankStatement {
 	var overlaps []types.BankStatement
 
-	query := `
-		SELECT id, filename, import_date, period_start, period_end,
-		       template_used, tx_count, status, processing_time, error_log,
-		       created_at, updated_at
+	ownerClause, ownerId := "template_used = ?", templateId
+	if accountId != 0 {
+		ownerClause, ownerId = "account_id = ?", accountId
+	}
+
+	query := "SELECT " + bankStatementColumns + `
 		FROM bank_statements
 		WHERE status = 'completed' AND period_start IS NOT NULL AND period_end IS NOT NULL
-		  AND template_used = ?
+		  AND ` + ownerClause + `
 		  AND ? <= period_end AND ? >= period_start
 	`
 
-	rows, err := bs.helper.QueryRows(query, templateId, periodStart, periodEnd)
+	rows, err := bs.helper.QueryRows(query, ownerId, periodStart, periodEnd)
 	if err != nil {
 		return overlaps // Return empty slice on error
 	}
@@ -367,6 +502,8 @@ func (bs *BankStatementStore) DetectOverlap(periodStart, periodEnd string, templ
 
 // DeleteStatement permanently removes a bank statement from the database
 func (bs *BankStatementStore) DeleteStatement(id int64) error {
+	before, _ := bs.GetStatementById(id)
+
 	rowsAffected, err := bs.helper.DeleteBy("bank_statements", "id = ?", id)
 	if err != nil {
 		return fmt.Errorf("failed to delete statement: %w", err)
@@ -376,6 +513,7 @@ func (bs *BankStatementStore) DeleteStatement(id int64) error {
 		return fmt.Errorf("statement not found")
 	}
 
+	bs.recordStatementChange(id, types.AuditEventDelete, types.SourceUser, before)
 	return nil
 }
 
@@ -434,9 +572,9 @@ func (bs *BankStatementStore) LoadDirectoryEntries(currentDir string) *Directory
 		}
 	}
 
-	// Add CSV files
+	// Add importable statement files
 	for _, entry := range entries {
-		if !entry.IsDir() && strings.HasSuffix(strings.ToLower(entry.Name()), ".csv") {
+		if !entry.IsDir() && types.StatementFileFormat(entry.Name()) != "" {
 			result.Entries = append(result.Entries, entry.Name())
 		}
 	}
@@ -463,20 +601,26 @@ func (bs *BankStatementStore) LoadDirectoryEntriesWithFallback(currentDir string
 // CleanupOrphanedImportingStatements finds and removes bank statements stuck in "importing" status
 // These can occur when imports fail after statement creation but before completion
 func (bs *BankStatementStore) CleanupOrphanedImportingStatements() (int, error) {
+	orphaned, err := bs.GetOrphanedImportingStatements()
+	if err != nil {
+		return 0, err
+	}
+
 	query := "DELETE FROM bank_statements WHERE status = 'importing'"
 	rowsAffected, err := bs.helper.ExecReturnRowsAffected(query)
 	if err != nil {
 		return 0, fmt.Errorf("failed to cleanup orphaned statements: %v", err)
 	}
+
+	for i := range orphaned {
+		bs.recordStatementChange(orphaned[i].Id, types.AuditEventDelete, types.SourceAuto, &orphaned[i])
+	}
 	return int(rowsAffected), nil
 }
 
 // GetOrphanedImportingStatements returns bank statements stuck in "importing" status
 func (bs *BankStatementStore) GetOrphanedImportingStatements() ([]types.BankStatement, error) {
-	query := `
-		SELECT id, filename, import_date, period_start, period_end,
-		       template_used, tx_count, status, processing_time, error_log,
-		       created_at, updated_at
+	query := "SELECT " + bankStatementColumns + `
 		FROM bank_statements 
 		WHERE status = 'importing'
 		ORDER BY import_date DESC
@@ -506,20 +650,13 @@ func (bs *BankStatementStore) UndoImport(statementId int64) (int, error) {
 		return 0, fmt.Errorf("transaction store not initialized")
 	}
 
-	transactions, err := bs.transactions.GetTransactions()
+	removed, err := bs.transactions.QueryTransactions(TransactionQuery{StatementId: statementId, Deleted: DeletedInclude})
 	if err != nil {
 		return 0, err
 	}
+	removedCount := len(removed)
 
-	var removedCount int
-
-	for _, tx := range transactions {
-		if tx.StatementId == statementId {
-			removedCount++
-		}
-	}
-
-	// Batch delete transactions with prepared statement for efficiency
+	// Batch delete transactions with prepared statement for efficiency, trashed ones included
 	if removedCount > 0 {
 		err := bs.db.ExecuteInTransaction(func(tx *sql.Tx) error {
 			deleteQuery := "DELETE FROM transactions WHERE statement_id = ?"
@@ -529,6 +666,7 @@ func (bs *BankStatementStore) UndoImport(statementId int64) (int, error) {
 		if err != nil {
 			return 0, fmt.Errorf("failed to remove transactions for statement %d: %w", statementId, err)
 		}
+		bs.transactions.recordPurged(removed, types.SourceUser)
 	}
 
 	// Update statement status to indicate it was undone
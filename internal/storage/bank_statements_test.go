@@ -376,7 +376,7 @@ func TestRecordBankStatement(t *testing.T) {
 			}
 
 			statementId, err := store.Statements.RecordBankStatement(
-				tt.filename, periodStartStr, periodEndStr, templateId, tt.txCount, tt.status,
+				tt.filename, periodStartStr, periodEndStr, templateId, 0, tt.txCount, tt.status,
 			)
 
 			if tt.expectError && err == nil {
@@ -568,7 +568,7 @@ func TestDetectOverlap(t *testing.T) {
 			templateId := tt.setupData(t, store, conn)
 			periodStartStr := tt.periodStart.Format("2006-01-02")
 			periodEndStr := tt.periodEnd.Format("2006-01-02")
-			overlaps := store.Statements.DetectOverlap(periodStartStr, periodEndStr, templateId)
+			overlaps := store.Statements.DetectOverlap(periodStartStr, periodEndStr, templateId, 0)
 
 			hasOverlap := len(overlaps) > 0
 			if hasOverlap != tt.expectOverlap {
@@ -582,6 +582,46 @@ func TestDetectOverlap(t *testing.T) {
 	}
 }
 
+// TestDetectOverlapByAccount verifies that two accounts sharing a template do not collide
+func TestDetectOverlapByAccount(t *testing.T) {
+	store, conn := setupTestBankStatementStore(t)
+	defer teardownTestDB(t, conn)
+
+	accounts := NewAccountStore(conn)
+	templateId := createTestCSVTemplate(t, conn, "shared_bank_template")
+	personal := createTestAccount(t, accounts, "Personal Card")
+	business := createTestAccount(t, accounts, "Business Card")
+
+	_, err := store.Statements.RecordBankStatement("personal_jan.csv", "2024-01-01", "2024-01-31", templateId, personal, 3, "completed")
+	if err != nil {
+		t.Fatalf("Failed to record statement: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		accountId     int64
+		expectedCount int
+	}{
+		{name: "same account overlaps", accountId: personal, expectedCount: 1},
+		{name: "other account with same template does not overlap", accountId: business, expectedCount: 0},
+		{name: "no account falls back to template", accountId: 0, expectedCount: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			overlaps := store.Statements.DetectOverlap("2024-01-15", "2024-02-15", templateId, tt.accountId)
+			if len(overlaps) != tt.expectedCount {
+				t.Errorf("Expected %d overlaps, got %d", tt.expectedCount, len(overlaps))
+			}
+			for _, stmt := range overlaps {
+				if stmt.AccountId != personal {
+					t.Errorf("Expected overlapping statement for account %d, got %d", personal, stmt.AccountId)
+				}
+			}
+		})
+	}
+}
+
 // TestMarkStatementCompleted tests the MarkStatementCompleted method
 func TestMarkStatementCompleted(t *testing.T) {
 	tests := []struct {
@@ -1269,7 +1309,7 @@ func TestExtractPeriodFromTransactions(t *testing.T) {
 				return []types.Transaction{
 					{
 						Id:     1,
-						Amount: 100.00,
+						Amount: testMoney(100.00),
 						Date:   time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
 					},
 				}
@@ -1283,17 +1323,17 @@ func TestExtractPeriodFromTransactions(t *testing.T) {
 				return []types.Transaction{
 					{
 						Id:     1,
-						Amount: 100.00,
+						Amount: testMoney(100.00),
 						Date:   time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC),
 					},
 					{
 						Id:     2,
-						Amount: 200.00,
+						Amount: testMoney(200.00),
 						Date:   time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
 					},
 					{
 						Id:     3,
-						Amount: 300.00,
+						Amount: testMoney(300.00),
 						Date:   time.Date(2024, 1, 25, 0, 0, 0, 0, time.UTC),
 					},
 				}
@@ -1307,22 +1347,22 @@ func TestExtractPeriodFromTransactions(t *testing.T) {
 				return []types.Transaction{
 					{
 						Id:     1,
-						Amount: 100.00,
+						Amount: testMoney(100.00),
 						Date:   time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
 					},
 					{
 						Id:     2,
-						Amount: 200.00,
+						Amount: testMoney(200.00),
 						Date:   time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), // Earlier date
 					},
 					{
 						Id:     3,
-						Amount: 300.00,
+						Amount: testMoney(300.00),
 						Date:   time.Date(2024, 1, 25, 0, 0, 0, 0, time.UTC), // Later date
 					},
 					{
 						Id:     4,
-						Amount: 400.00,
+						Amount: testMoney(400.00),
 						Date:   time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), // Middle date
 					},
 				}
@@ -1336,17 +1376,17 @@ func TestExtractPeriodFromTransactions(t *testing.T) {
 				return []types.Transaction{
 					{
 						Id:     1,
-						Amount: 100.00,
+						Amount: testMoney(100.00),
 						Date:   time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
 					},
 					{
 						Id:     2,
-						Amount: 200.00,
+						Amount: testMoney(200.00),
 						Date:   time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC),
 					},
 					{
 						Id:     3,
-						Amount: 300.00,
+						Amount: testMoney(300.00),
 						Date:   time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
 					},
 				}
@@ -1361,17 +1401,17 @@ func TestExtractPeriodFromTransactions(t *testing.T) {
 				return []types.Transaction{
 					{
 						Id:     1,
-						Amount: 100.00,
+						Amount: testMoney(100.00),
 						Date:   sameDate,
 					},
 					{
 						Id:     2,
-						Amount: 200.00,
+						Amount: testMoney(200.00),
 						Date:   sameDate,
 					},
 					{
 						Id:     3,
-						Amount: 300.00,
+						Amount: testMoney(300.00),
 						Date:   sameDate,
 					},
 				}
@@ -1385,12 +1425,12 @@ func TestExtractPeriodFromTransactions(t *testing.T) {
 				return []types.Transaction{
 					{
 						Id:     1,
-						Amount: 100.00,
+						Amount: testMoney(100.00),
 						Date:   time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC),
 					},
 					{
 						Id:     2,
-						Amount: 200.00,
+						Amount: testMoney(200.00),
 						Date:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
 					},
 				}
@@ -0,0 +1,334 @@
+package storage
+
+import (
+	"budget-tracker-tui/internal/types"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// camtDocument is an ISO 20022 camt.053 statement or camt.052 account report
+// Element names are matched without their namespace, so every published version reads the same.
+type camtDocument struct {
+	Statements []camtStatement `xml:"BkToCstmrStmt>Stmt"`
+	Reports    []camtStatement `xml:"BkToCstmrAcctRpt>Rpt"`
+}
+
+// camtStatement is a Stmt (camt.053) or Rpt (camt.052) element
+type camtStatement struct {
+	Id       string        `xml:"Id"`
+	FromDate string        `xml:"FrToDt>FrDtTm"`
+	ToDate   string        `xml:"FrToDt>ToDtTm"`
+	IBAN     string        `xml:"Acct>Id>IBAN"`
+	OtherId  string        `xml:"Acct>Id>Othr>Id"`
+	Currency string        `xml:"Acct>Ccy"`
+	Balances []camtBalance `xml:"Bal"`
+	Entries  []camtEntry   `xml:"Ntry"`
+}
+
+// camtBalance is one Bal element, such as the opening (OPBD) or closing (CLBD) booked balance
+type camtBalance struct {
+	Code      string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amount    camtAmount `xml:"Amt"`
+	CdtDbtInd string     `xml:"CdtDbtInd"`
+	Date      string     `xml:"Dt>Dt"`
+	DateTime  string     `xml:"Dt>DtTm"`
+}
+
+// camtAmount is an amount with its currency attribute
+type camtAmount struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"Ccy,attr"`
+}
+
+// camtEntry is one Ntry element, a booking on the account
+type camtEntry struct {
+	Ref            string          `xml:"NtryRef"`
+	Amount         camtAmount      `xml:"Amt"`
+	CdtDbtInd      string          `xml:"CdtDbtInd"`
+	Status         camtStatus      `xml:"Sts"`
+	BookingDate    string          `xml:"BookgDt>Dt"`
+	BookingTime    string          `xml:"BookgDt>DtTm"`
+	ValueDate      string          `xml:"ValDt>Dt"`
+	ServicerRef    string          `xml:"AcctSvcrRef"`
+	AdditionalInfo string          `xml:"AddtlNtryInf"`
+	Details        []camtTxDetails `xml:"NtryDtls>TxDtls"`
+}
+
+// camtStatus is an entry status, given as text (camt.053.001.02) or as a Cd element in later versions
+type camtStatus struct {
+	Value string `xml:",chardata"`
+	Code  string `xml:"Cd"`
+}
+
+// camtTxDetails holds the references, parties and remittance information of an entry
+type camtTxDetails struct {
+	ServicerRef    string   `xml:"Refs>AcctSvcrRef"`
+	Unstructured   []string `xml:"RmtInf>Ustrd"`
+	CreditorRef    string   `xml:"RmtInf>Strd>CdtrRefInf>Ref"`
+	Creditor       string   `xml:"RltdPties>Cdtr>Nm"`
+	CreditorParty  string   `xml:"RltdPties>Cdtr>Pty>Nm"`
+	Debtor         string   `xml:"RltdPties>Dbtr>Nm"`
+	DebtorParty    string   `xml:"RltdPties>Dbtr>Pty>Nm"`
+	AdditionalInfo string   `xml:"AddtlTxInf"`
+}
+
+// ParseCAMT reads a camt.053 statement or camt.052 account report
+// Only booked entries are read; pending and informational ones are skipped. The first statement
+// is read when a file holds several accounts.
+func ParseCAMT(r io.Reader) (*types.StatementFile, error) {
+	var doc camtDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to read camt XML: %w", err)
+	}
+
+	statements := append(doc.Statements, doc.Reports...)
+	if len(statements) == 0 {
+		return nil, fmt.Errorf("not a camt.053 or camt.052 file: no statement found")
+	}
+	if len(statements) > 1 {
+		slog.Warn("camt file holds several statements, importing the first", "statements", len(statements))
+	}
+	stmt := statements[0]
+
+	file := &types.StatementFile{
+		Format:        types.StatementFormatCAMT,
+		AccountNumber: stmt.IBAN,
+		Currency:      strings.ToUpper(stmt.Currency),
+	}
+	if file.AccountNumber == "" {
+		file.AccountNumber = stmt.OtherId
+	}
+	if file.Currency == "" {
+		file.Currency = camtCurrency(stmt)
+	}
+
+	var err error
+	if stmt.FromDate != "" {
+		if file.PeriodStart, err = parseCAMTDate(stmt.FromDate); err != nil {
+			return nil, fmt.Errorf("invalid statement start date: %w", err)
+		}
+	}
+	if stmt.ToDate != "" {
+		if file.PeriodEnd, err = parseCAMTDate(stmt.ToDate); err != nil {
+			return nil, fmt.Errorf("invalid statement end date: %w", err)
+		}
+	}
+
+	if err := readCAMTBalances(file, stmt.Balances); err != nil {
+		return nil, err
+	}
+
+	for i, entry := range stmt.Entries {
+		status := strings.ToUpper(strings.TrimSpace(entry.Status.Code + entry.Status.Value))
+		if status != "" && status != "BOOK" {
+			continue
+		}
+		tx, err := parseCAMTEntry(entry, file.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i+1, err)
+		}
+		addStatementTransaction(file, tx)
+	}
+
+	return file, nil
+}
+
+// readCAMTBalances sets the opening and closing balances, and the period when the header has none
+// The opening balance is OPBD (or PRCD, the previous closing); the closing balance is CLBD, or
+// the interim ITBD of an intraday camt.052 report.
+func readCAMTBalances(file *types.StatementFile, balances []camtBalance) error {
+	byCode := make(map[string]camtBalance)
+	for _, bal := range balances {
+		code := strings.ToUpper(bal.Code)
+		if _, ok := byCode[code]; !ok {
+			byCode[code] = bal
+		}
+	}
+
+	pick := func(codes ...string) (camtBalance, bool) {
+		for _, code := range codes {
+			if bal, ok := byCode[code]; ok {
+				return bal, true
+			}
+		}
+		return camtBalance{}, false
+	}
+
+	if bal, ok := pick("OPBD", "PRCD"); ok {
+		amount, date, err := parseCAMTBalance(bal, file.Currency)
+		if err != nil {
+			return fmt.Errorf("invalid opening balance: %w", err)
+		}
+		file.OpeningBalance = &amount
+		if file.PeriodStart.IsZero() {
+			file.PeriodStart = date
+		}
+	}
+	if bal, ok := pick("CLBD", "ITBD"); ok {
+		amount, date, err := parseCAMTBalance(bal, file.Currency)
+		if err != nil {
+			return fmt.Errorf("invalid closing balance: %w", err)
+		}
+		file.ClosingBalance = &amount
+		if file.PeriodEnd.IsZero() {
+			file.PeriodEnd = date
+		}
+	}
+	return nil
+}
+
+// parseCAMTBalance returns a balance signed by its credit/debit indicator, with its date
+func parseCAMTBalance(bal camtBalance, currency string) (types.Money, time.Time, error) {
+	amount, err := parseCAMTAmount(bal.Amount, bal.CdtDbtInd, currency)
+	if err != nil {
+		return types.Money{}, time.Time{}, err
+	}
+
+	value := bal.Date
+	if value == "" {
+		value = bal.DateTime
+	}
+	var date time.Time
+	if value != "" {
+		if date, err = parseCAMTDate(value); err != nil {
+			return types.Money{}, time.Time{}, err
+		}
+	}
+	return amount, date, nil
+}
+
+// parseCAMTEntry converts one booked Ntry
+// The description is the remittance information, falling back to the entry's additional
+// information and then the counterparty; the raw description keeps the counterparty as well.
+func parseCAMTEntry(entry camtEntry, currency string) (types.Transaction, error) {
+	var tx types.Transaction
+
+	dateValue := entry.BookingDate
+	if dateValue == "" {
+		dateValue = entry.BookingTime
+	}
+	if dateValue == "" {
+		dateValue = entry.ValueDate
+	}
+	date, err := parseCAMTDate(dateValue)
+	if err != nil {
+		return tx, fmt.Errorf("invalid booking date: %w", err)
+	}
+	tx.Date = date
+
+	tx.Amount, err = parseCAMTAmount(entry.Amount, entry.CdtDbtInd, currency)
+	if err != nil {
+		return tx, fmt.Errorf("invalid amount: %w", err)
+	}
+	tx.TransactionType = statementTransactionType(tx.Amount)
+
+	var remittance []string
+	var counterparty, additional string
+	for _, details := range entry.Details {
+		for _, line := range details.Unstructured {
+			if line = strings.TrimSpace(line); line != "" {
+				remittance = append(remittance, line)
+			}
+		}
+		if details.CreditorRef != "" {
+			remittance = append(remittance, details.CreditorRef)
+		}
+		if additional == "" {
+			additional = strings.TrimSpace(details.AdditionalInfo)
+		}
+
+		// The counterparty is whoever is on the other side of the money
+		names := []string{details.Creditor, details.CreditorParty, details.Debtor, details.DebtorParty}
+		if tx.Amount.Cents > 0 {
+			names = []string{details.Debtor, details.DebtorParty, details.Creditor, details.CreditorParty}
+		}
+		for _, name := range names {
+			if counterparty == "" {
+				counterparty = strings.TrimSpace(name)
+			}
+		}
+		if tx.ExternalId == "" {
+			tx.ExternalId = strings.TrimSpace(details.ServicerRef)
+		}
+	}
+	if additional == "" {
+		additional = strings.TrimSpace(entry.AdditionalInfo)
+	}
+
+	tx.Description = strings.Join(remittance, " ")
+	if tx.Description == "" {
+		tx.Description = additional
+	}
+	if tx.Description == "" {
+		tx.Description = counterparty
+	}
+	if strings.TrimSpace(tx.Description) == "" {
+		return tx, fmt.Errorf("empty description not allowed")
+	}
+	tx.RawDescription = tx.Description
+	if counterparty != "" && counterparty != tx.Description {
+		tx.RawDescription = counterparty + " " + tx.Description
+	}
+
+	// The entry's own reference identifies it best; the transaction reference is the fallback
+	if ref := strings.TrimSpace(entry.ServicerRef); ref != "" {
+		tx.ExternalId = ref
+	} else if tx.ExternalId == "" {
+		tx.ExternalId = strings.TrimSpace(entry.Ref)
+	}
+	return tx, nil
+}
+
+// parseCAMTAmount signs an unsigned camt amount: debits are negative
+// A reversal (RvslInd) already carries the indicator of the money's direction, e.g. a reversed
+// debit is reported as a credit, so it does not change the sign.
+func parseCAMTAmount(amount camtAmount, cdtDbtInd string, currency string) (types.Money, error) {
+	if amount.Currency != "" {
+		currency = strings.ToUpper(amount.Currency)
+	}
+	money, err := parseStatementAmount(amount.Value, currency)
+	if err != nil {
+		return types.Money{}, err
+	}
+
+	switch strings.ToUpper(strings.TrimSpace(cdtDbtInd)) {
+	case "DBIT":
+		money = money.Neg()
+	case "CRDT":
+	default:
+		return types.Money{}, fmt.Errorf("unknown credit/debit indicator '%s'", cdtDbtInd)
+	}
+	return money, nil
+}
+
+// camtCurrency returns the currency of the first balance or entry when the account gives none
+func camtCurrency(stmt camtStatement) string {
+	for _, bal := range stmt.Balances {
+		if bal.Amount.Currency != "" {
+			return strings.ToUpper(bal.Amount.Currency)
+		}
+	}
+	for _, entry := range stmt.Entries {
+		if entry.Amount.Currency != "" {
+			return strings.ToUpper(entry.Amount.Currency)
+		}
+	}
+	return types.DefaultCurrency
+}
+
+// parseCAMTDate reads the date part of an ISO date or date-time such as 2024-01-31T23:59:59+01:00
+func parseCAMTDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if len(value) < 10 {
+		return time.Time{}, fmt.Errorf("invalid date '%s'", value)
+	}
+	date, err := time.Parse("2006-01-02", value[:10])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date '%s'", value)
+	}
+	return date, nil
+}
@@ -0,0 +1,217 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"budget-tracker-tui/internal/types"
+)
+
+// camt053 is a day statement with a card payment, a salary credit, a reversed debit and a pending
+// entry that must be skipped
+const camt053 = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.08">
+  <BkToCstmrStmt>
+    <GrpHdr><MsgId>MSG-1</MsgId><CreDtTm>2024-02-01T06:00:00</CreDtTm></GrpHdr>
+    <Stmt>
+      <Id>STMT-2024-01</Id>
+      <FrToDt><FrDtTm>2024-01-01T00:00:00+01:00</FrDtTm><ToDtTm>2024-01-31T23:59:59+01:00</ToDtTm></FrToDt>
+      <Acct><Id><IBAN>DE89370400440532013000</IBAN></Id><Ccy>EUR</Ccy></Acct>
+      <Bal>
+        <Tp><CdOrPrtry><Cd>OPBD</Cd></CdOrPrtry></Tp>
+        <Amt Ccy="EUR">100.00</Amt><CdtDbtInd>DBIT</CdtDbtInd>
+        <Dt><Dt>2024-01-01</Dt></Dt>
+      </Bal>
+      <Bal>
+        <Tp><CdOrPrtry><Cd>CLBD</Cd></CdOrPrtry></Tp>
+        <Amt Ccy="EUR">2358.00</Amt><CdtDbtInd>CRDT</CdtDbtInd>
+        <Dt><Dt>2024-01-31</Dt></Dt>
+      </Bal>
+      <Ntry>
+        <NtryRef>N-1</NtryRef>
+        <Amt Ccy="EUR">42.50</Amt><CdtDbtInd>DBIT</CdtDbtInd>
+        <Sts><Cd>BOOK</Cd></Sts>
+        <BookgDt><Dt>2024-01-05</Dt></BookgDt>
+        <AcctSvcrRef>REF-1001</AcctSvcrRef>
+        <NtryDtls><TxDtls>
+          <RltdPties><Cdtr><Pty><Nm>Grocery Store</Nm></Pty></Cdtr></RltdPties>
+          <RmtInf><Ustrd>Card payment</Ustrd><Ustrd>1234</Ustrd></RmtInf>
+        </TxDtls></NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">2500.00</Amt><CdtDbtInd>CRDT</CdtDbtInd>
+        <Sts><Cd>BOOK</Cd></Sts>
+        <BookgDt><DtTm>2024-01-25T09:30:00</DtTm></BookgDt>
+        <NtryDtls><TxDtls>
+          <Refs><AcctSvcrRef>REF-1002</AcctSvcrRef></Refs>
+          <RltdPties><Dbtr><Nm>Employer Ltd</Nm></Dbtr></RltdPties>
+        </TxDtls></NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">0.50</Amt><CdtDbtInd>CRDT</CdtDbtInd><RvslInd>true</RvslInd>
+        <Sts><Cd>BOOK</Cd></Sts>
+        <BookgDt><Dt>2024-01-28</Dt></BookgDt>
+        <AcctSvcrRef>REF-1003</AcctSvcrRef>
+        <AddtlNtryInf>Fee refund</AddtlNtryInf>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">10.00</Amt><CdtDbtInd>DBIT</CdtDbtInd>
+        <Sts><Cd>PDNG</Cd></Sts>
+        <BookgDt><Dt>2024-01-31</Dt></BookgDt>
+        <AddtlNtryInf>Pending card payment</AddtlNtryInf>
+      </Ntry>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>
+`
+
+// camt052 is an intraday report in the older text status form, with no period header
+const camt052 = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.052.001.02">
+  <BkToCstmrAcctRpt>
+    <Rpt>
+      <Id>RPT-1</Id>
+      <Acct><Id><Othr><Id>0532013000</Id></Othr></Id></Acct>
+      <Bal>
+        <Tp><CdOrPrtry><Cd>PRCD</Cd></CdOrPrtry></Tp>
+        <Amt Ccy="CHF">50.00</Amt><CdtDbtInd>CRDT</CdtDbtInd>
+        <Dt><Dt>2024-03-09</Dt></Dt>
+      </Bal>
+      <Bal>
+        <Tp><CdOrPrtry><Cd>ITBD</Cd></CdOrPrtry></Tp>
+        <Amt Ccy="CHF">40.01</Amt><CdtDbtInd>CRDT</CdtDbtInd>
+        <Dt><DtTm>2024-03-10T12:00:00</DtTm></Dt>
+      </Bal>
+      <Ntry>
+        <NtryRef>INTRA-7</NtryRef>
+        <Amt Ccy="CHF">9.99</Amt><CdtDbtInd>DBIT</CdtDbtInd>
+        <Sts>BOOK</Sts>
+        <BookgDt><Dt>2024-03-10</Dt></BookgDt>
+        <NtryDtls><TxDtls><RmtInf><Ustrd>Streaming Service</Ustrd></RmtInf></TxDtls></NtryDtls>
+      </Ntry>
+    </Rpt>
+  </BkToCstmrAcctRpt>
+</Document>
+`
+
+// TestParseCAMT tests reading camt.053 statements and camt.052 reports into transactions
+func TestParseCAMT(t *testing.T) {
+	t.Run("camt.053 statement", func(t *testing.T) {
+		file, err := ParseCAMT(strings.NewReader(camt053))
+		if err != nil {
+			t.Fatalf("ParseCAMT() failed: %v", err)
+		}
+
+		if file.Format != types.StatementFormatCAMT || file.Currency != "EUR" || file.AccountNumber != "DE89370400440532013000" {
+			t.Errorf("Unexpected statement header: %+v", file)
+		}
+		if !file.PeriodStart.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) || !file.PeriodEnd.Equal(time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("Expected January 2024 period, got %v to %v", file.PeriodStart, file.PeriodEnd)
+		}
+		if file.OpeningBalance == nil || file.OpeningBalance.Cents != -10000 {
+			t.Errorf("Expected a debit opening balance of -100.00, got %+v", file.OpeningBalance)
+		}
+		if file.ClosingBalance == nil || file.ClosingBalance.Cents != 235800 {
+			t.Errorf("Expected a closing balance of 2358.00, got %+v", file.ClosingBalance)
+		}
+		if len(file.Transactions) != 3 {
+			t.Fatalf("Expected 3 booked transactions, got %d", len(file.Transactions))
+		}
+
+		grocery := file.Transactions[0]
+		if grocery.Amount.Cents != -4250 || grocery.TransactionType != "expense" || grocery.ExternalId != "REF-1001" {
+			t.Errorf("Unexpected debit: %+v", grocery)
+		}
+		if grocery.Description != "Card payment 1234" || grocery.RawDescription != "Grocery Store Card payment 1234" {
+			t.Errorf("Expected remittance info as the description, got %q and %q", grocery.Description, grocery.RawDescription)
+		}
+
+		salary := file.Transactions[1]
+		if salary.Amount.Cents != 250000 || salary.TransactionType != "income" || salary.Description != "Employer Ltd" || salary.ExternalId != "REF-1002" {
+			t.Errorf("Expected a credit named after its debtor, got %+v", salary)
+		}
+		if salary.Date.Format("2006-01-02") != "2024-01-25" {
+			t.Errorf("Expected 2024-01-25, got %v", salary.Date)
+		}
+
+		if refund := file.Transactions[2]; refund.Amount.Cents != 50 || refund.Description != "Fee refund" {
+			t.Errorf("Expected the reversed debit as a credit, got %+v", refund)
+		}
+
+		// The booked entries take the opening balance to the closing one
+		balance := file.OpeningBalance.Cents
+		for _, tx := range file.Transactions {
+			balance += tx.Amount.Cents
+		}
+		if balance != file.ClosingBalance.Cents {
+			t.Errorf("Expected the entries to reconcile to %d, got %d", file.ClosingBalance.Cents, balance)
+		}
+	})
+
+	t.Run("camt.052 report", func(t *testing.T) {
+		file, err := ParseCAMT(strings.NewReader(camt052))
+		if err != nil {
+			t.Fatalf("ParseCAMT() failed: %v", err)
+		}
+
+		if file.Currency != "CHF" || file.AccountNumber != "0532013000" {
+			t.Errorf("Unexpected report header: %+v", file)
+		}
+		if file.PeriodStart.Format("2006-01-02") != "2024-03-09" || file.PeriodEnd.Format("2006-01-02") != "2024-03-10" {
+			t.Errorf("Expected the period from the balance dates, got %v to %v", file.PeriodStart, file.PeriodEnd)
+		}
+		if file.OpeningBalance == nil || file.OpeningBalance.Cents != 5000 || file.ClosingBalance == nil || file.ClosingBalance.Cents != 4001 {
+			t.Errorf("Expected 50.00 and 40.01 balances, got %+v and %+v", file.OpeningBalance, file.ClosingBalance)
+		}
+		if len(file.Transactions) != 1 {
+			t.Fatalf("Expected 1 transaction, got %d", len(file.Transactions))
+		}
+		if tx := file.Transactions[0]; tx.Description != "Streaming Service" || tx.Amount.Cents != -999 || tx.ExternalId != "INTRA-7" {
+			t.Errorf("Unexpected transaction: %+v", tx)
+		}
+	})
+
+	t.Run("rejects files without a statement", func(t *testing.T) {
+		invalid := []string{
+			"Date,Amount,Description\n2024-01-01,1.00,Coffee\n",
+			"<Document><CstmrCdtTrfInitn></CstmrCdtTrfInitn></Document>",
+			"<Document><BkToCstmrStmt><Stmt><Ntry><Amt>1.00</Amt><CdtDbtInd>X</CdtDbtInd><BookgDt><Dt>2024-01-01</Dt></BookgDt><AddtlNtryInf>x</AddtlNtryInf></Ntry></Stmt></BkToCstmrStmt></Document>",
+		}
+		for _, content := range invalid {
+			if _, err := ParseCAMT(strings.NewReader(content)); err == nil {
+				t.Errorf("Expected an error for %q", content)
+			}
+		}
+	})
+}
+
+// TestMainStoreImportCAMT tests that a camt.053 file imports through the statement flow
+func TestMainStoreImportCAMT(t *testing.T) {
+	store, conn := setupTestMainStore(t)
+	defer teardownTestDB(t, conn)
+
+	accountId := createTestAccount(t, store.Accounts, "Girokonto")
+	filePath := createTestCSVFile(t, "camt053_2024-01.xml", camt053)
+
+	result := store.ImportStatementFile(filePath, accountId)
+	if !result.Success || result.ImportedCount != 3 {
+		t.Fatalf("Expected 3 imported transactions, got %+v", result)
+	}
+
+	stmt := store.Statements.GetStatementHistory()[0]
+	if stmt.Format != types.StatementFormatCAMT || stmt.AccountId != accountId {
+		t.Errorf("Unexpected statement: %+v", stmt)
+	}
+	if stmt.PeriodStart.Format("2006-01-02") != "2024-01-01" || stmt.PeriodEnd.Format("2006-01-02") != "2024-01-31" {
+		t.Errorf("Expected the period from the statement header, got %v to %v", stmt.PeriodStart, stmt.PeriodEnd)
+	}
+	if stmt.OpeningBalance == nil || stmt.OpeningBalance.Cents != -10000 || stmt.ClosingBalance == nil || stmt.ClosingBalance.Cents != 235800 {
+		t.Errorf("Expected both balances on the statement, got %+v and %+v", stmt.OpeningBalance, stmt.ClosingBalance)
+	}
+
+	again := store.ImportStatementFile(filePath, accountId)
+	if again.Success || again.DuplicateCount != 3 {
+		t.Errorf("Expected all 3 rows to be duplicates on re-import, got %+v", again)
+	}
+}
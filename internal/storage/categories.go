@@ -5,6 +5,7 @@ import (
 	"budget-tracker-tui/internal/types"
 	"database/sql"
 	"fmt"
+	"log/slog"
 	"strings"
 	"time"
 )
@@ -15,6 +16,8 @@ type CategoryStore struct {
 	helper       *database.SQLHelper
 	defaultId    int64
 	transactions *TransactionStore // For cross-domain operations
+	undo         *UndoManager      // Receives category edits and deletes so they can be undone
+	audit        *AuditStore       // General audit log of creates, updates and deletes
 }
 
 // NewCategoryStore creates a new CategoryStore instance
@@ -36,6 +39,16 @@ func (cs *CategoryStore) SetTransactionStore(transactions *TransactionStore) {
 	cs.transactions = transactions
 }
 
+// SetUndoManager sets the undo manager that category edits and deletes are recorded with
+func (cs *CategoryStore) SetUndoManager(um *UndoManager) {
+	cs.undo = um
+}
+
+// SetAuditStore sets the audit log that category changes are recorded in
+func (cs *CategoryStore) SetAuditStore(audit *AuditStore) {
+	cs.audit = audit
+}
+
 // ensureDefaultCategories creates default categories if they don't exist
 func (cs *CategoryStore) ensureDefaultCategories() {
 	// Check if categories exist
@@ -70,6 +83,11 @@ func (cs *CategoryStore) CalculateNextCategoryId() int64 {
 
 // GetCategories returns all categories from the database
 func (cs *CategoryStore) GetCategories() ([]types.Category, error) {
+	return cs.getCategories(cs.db.DB)
+}
+
+// getCategories returns all active categories read through db
+func (cs *CategoryStore) getCategories(db sqlQuerier) ([]types.Category, error) {
 	query := `
 		SELECT id, display_name, parent_id, color, is_active, created_at, updated_at 
 		FROM categories 
@@ -77,7 +95,7 @@ func (cs *CategoryStore) GetCategories() ([]types.Category, error) {
 		ORDER BY display_name
 	`
 
-	rows, err := cs.helper.QueryRows(query)
+	rows, err := db.Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query categories: %w", err)
 	}
@@ -227,6 +245,11 @@ func (cs *CategoryStore) scanCategoryRow(row *sql.Row) (types.Category, error) {
 
 // GetCategoryByDisplayName returns a category by its display name (case insensitive), or nil if not found
 func (cs *CategoryStore) GetCategoryByDisplayName(displayName string) *types.Category {
+	return cs.getCategoryByDisplayName(cs.db.DB, displayName)
+}
+
+// getCategoryByDisplayName looks up an active category by display name through db
+func (cs *CategoryStore) getCategoryByDisplayName(db sqlQuerier, displayName string) *types.Category {
 	trimmed := strings.TrimSpace(displayName)
 	if trimmed == "" {
 		return nil
@@ -238,8 +261,7 @@ func (cs *CategoryStore) GetCategoryByDisplayName(displayName string) *types.Cat
 		WHERE LOWER(display_name) = LOWER(?) AND is_active = 1
 	`
 
-	row := cs.helper.QuerySingleRow(query, trimmed)
-	category, err := cs.scanCategoryRow(row)
+	category, err := cs.scanCategoryRow(db.QueryRow(query, trimmed))
 	if err != nil {
 		return nil // Category not found or error
 	}
@@ -276,6 +298,34 @@ func (cs *CategoryStore) ResolveOrCreateCategory(categoryText string) int64 {
 	return cs.defaultId
 }
 
+// ResolveOrCreateCategoryPath resolves a "Parent:Child" category path, creating missing levels
+// under their parent. Display names are unique, so a level that already exists is reused
+// wherever it sits in the hierarchy. Returns the ID of the last level, or the default category.
+func (cs *CategoryStore) ResolveOrCreateCategoryPath(path string) int64 {
+	var parentId *int64
+	categoryId := cs.defaultId
+	for _, name := range strings.Split(path, ":") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		if existing := cs.GetCategoryByDisplayName(name); existing != nil {
+			categoryId = existing.Id
+		} else {
+			category := &types.Category{DisplayName: name, ParentId: parentId, IsActive: true}
+			if err := cs.CreateCategoryFull(category); err != nil {
+				slog.Warn("failed to create imported category", "category", name, "error", err)
+				return categoryId
+			}
+			categoryId = category.Id
+		}
+		id := categoryId
+		parentId = &id
+	}
+	return categoryId
+}
+
 // CreateCategory creates a new category with display name only (legacy method)
 func (cs *CategoryStore) CreateCategory(displayName string) *CategoryResult {
 	result := &CategoryResult{}
@@ -327,6 +377,17 @@ func (cs *CategoryStore) CreateCategoryFull(category *types.Category) error {
 		return fmt.Errorf("category '%s' already exists", category.DisplayName)
 	}
 
+	if err := cs.insertCategory(cs.db.DB, category); err != nil {
+		return err
+	}
+
+	cs.audit.recordOrWarn(types.EntityCategory, auditId(category.Id), types.AuditEventCreate, types.SourceUser, nil, category)
+
+	return nil
+}
+
+// insertCategory writes a new category through db and fills in its ID and timestamps
+func (cs *CategoryStore) insertCategory(db sqlExecer, category *types.Category) error {
 	now := time.Now()
 
 	query := `
@@ -355,11 +416,14 @@ func (cs *CategoryStore) CreateCategoryFull(category *types.Category) error {
 	createdAtStr := createdAt.Format(time.RFC3339)
 	updatedAtStr := now.Format(time.RFC3339)
 
-	id, err := cs.helper.ExecReturnID(query,
+	res, err := db.Exec(query,
 		strings.TrimSpace(category.DisplayName), parentID, color,
 		true, createdAtStr, updatedAtStr,
 	)
-
+	if err != nil {
+		return fmt.Errorf("failed to create category: %w", err)
+	}
+	id, err := res.LastInsertId()
 	if err != nil {
 		return fmt.Errorf("failed to create category: %w", err)
 	}
@@ -370,8 +434,6 @@ func (cs *CategoryStore) CreateCategoryFull(category *types.Category) error {
 	category.CreatedAt = createdAt
 	category.UpdatedAt = now
 
-	// Audit: Category creation events are no longer recorded
-
 	return nil
 }
 
@@ -396,7 +458,28 @@ func (cs *CategoryStore) UpdateCategory(category *types.Category) error {
 		return fmt.Errorf("category '%s' already exists", category.DisplayName)
 	}
 
+	before := cs.getCategoryRecord(cs.db.DB, category.Id)
+
 	now := time.Now()
+	if err := cs.updateCategoryRecord(cs.db.DB, category, now); err != nil {
+		return err
+	}
+
+	// Update the category timestamps
+	category.UpdatedAt = now
+
+	// Audit and undo both keep the state before and after the edit
+	if before != nil {
+		after := cs.getCategoryRecord(cs.db.DB, category.Id)
+		cs.audit.recordOrWarn(types.EntityCategory, auditId(category.Id), types.AuditEventUpdate, types.SourceUser, before, after)
+		cs.undo.recordCategoryChange(fmt.Sprintf("Edit category '%s'", before.DisplayName), before, after)
+	}
+
+	return nil
+}
+
+// updateCategoryRecord writes a category's fields through db
+func (cs *CategoryStore) updateCategoryRecord(db sqlExecer, category *types.Category, now time.Time) error {
 	query := `
 		UPDATE categories SET 
 			display_name = ?, parent_id = ?, color = ?, is_active = ?, updated_at = ?
@@ -414,24 +497,22 @@ func (cs *CategoryStore) UpdateCategory(category *types.Category) error {
 		color = category.Color
 	}
 
-	rowsAffected, err := cs.helper.ExecReturnRowsAffected(query,
+	result, err := db.Exec(query,
 		strings.TrimSpace(category.DisplayName), parentID, color,
 		category.IsActive, now, category.Id,
 	)
-
 	if err != nil {
 		return fmt.Errorf("failed to update category: %w", err)
 	}
 
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update category: %w", err)
+	}
 	if rowsAffected == 0 {
 		return fmt.Errorf("category not found")
 	}
 
-	// Update the category timestamps
-	category.UpdatedAt = now
-
-	// Audit: Category updates are no longer recorded
-
 	return nil
 }
 
@@ -443,6 +524,8 @@ func (cs *CategoryStore) DeleteCategory(categoryId int64) error {
 		return err
 	}
 
+	before := cs.getCategoryRecord(cs.db.DB, categoryId)
+
 	// Since validation ensures no transactions use this category, we can hard delete
 	query := "DELETE FROM categories WHERE id = ?"
 	rowsAffected, err := cs.helper.ExecReturnRowsAffected(query, categoryId)
@@ -454,20 +537,31 @@ func (cs *CategoryStore) DeleteCategory(categoryId int64) error {
 		return fmt.Errorf("category not found")
 	}
 
-	// Check if this was the default category and reset if needed
-	if cs.defaultId == categoryId {
-		// Find first active category to set as new default
-		categories, err := cs.GetCategories()
-		if err == nil && len(categories) > 0 {
-			cs.defaultId = categories[0].Id
-		} else {
-			cs.defaultId = 1 // Fallback to "Uncategorized"
-		}
+	cs.resetDefaultCategory(categoryId)
+
+	if before != nil {
+		cs.audit.recordOrWarn(types.EntityCategory, auditId(categoryId), types.AuditEventDelete, types.SourceUser, before, nil)
+		cs.undo.recordCategoryChange(fmt.Sprintf("Delete category '%s'", before.DisplayName), before, nil)
 	}
 
 	return nil
 }
 
+// resetDefaultCategory picks a new default category when the deleted category was the default
+func (cs *CategoryStore) resetDefaultCategory(deletedId int64) {
+	if cs.defaultId != deletedId {
+		return
+	}
+
+	// Find first active category to set as new default
+	categories, err := cs.GetCategories()
+	if err == nil && len(categories) > 0 {
+		cs.defaultId = categories[0].Id
+	} else {
+		cs.defaultId = 1 // Fallback to "Uncategorized"
+	}
+}
+
 // ValidateCategoryForDeletion checks if a category can be safely deleted
 func (cs *CategoryStore) ValidateCategoryForDeletion(categoryId int64) error {
 	// Check if category exists and is active
@@ -499,23 +593,28 @@ func (cs *CategoryStore) ValidateCategoryForDeletion(categoryId int64) error {
 
 	// Check if category is in use by transactions (prevent deletion if in use)
 	if cs.transactions != nil {
-		transactions, err := cs.transactions.GetTransactions()
+		transactionCount, err := cs.transactions.CountTransactions(TransactionQuery{CategoryIds: []int64{categoryId}})
 		if err != nil {
 			return fmt.Errorf("failed to check transaction usage: %w", err)
 		}
 
-		transactionCount := 0
-		for _, tx := range transactions {
-			if tx.CategoryId == categoryId {
-				transactionCount++
-			}
-		}
-
 		if transactionCount > 0 {
 			categoryName := cs.GetCategoryDisplayName(categoryId)
 			return fmt.Errorf("cannot delete category '%s': it is being used by %d transaction(s)",
 				categoryName, transactionCount)
 		}
+
+		// Trashed transactions still reference the category until they are purged
+		trashedCount, err := cs.transactions.CountTransactions(TransactionQuery{CategoryIds: []int64{categoryId}, Deleted: DeletedOnly})
+		if err != nil {
+			return fmt.Errorf("failed to check transaction usage: %w", err)
+		}
+
+		if trashedCount > 0 {
+			categoryName := cs.GetCategoryDisplayName(categoryId)
+			return fmt.Errorf("cannot delete category '%s': %d transaction(s) in the trash still use it; restore or purge them first",
+				categoryName, trashedCount)
+		}
 	}
 
 	return nil
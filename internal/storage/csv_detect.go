@@ -0,0 +1,589 @@
+package storage
+
+import (
+	"budget-tracker-tui/internal/types"
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// CSV column kinds found by sniffing a file
+const (
+	CSVColumnEmpty  = "empty"
+	CSVColumnDate   = "date"
+	CSVColumnAmount = "amount"
+	CSVColumnText   = "text"
+)
+
+// csvSniffLines is how many non-blank lines of a file are read to detect its layout
+const csvSniffLines = 25
+
+// csvDelimiters are the delimiters tried when sniffing a file, in order of preference
+var csvDelimiters = []string{",", ";", "\t", "|"}
+
+// csvDateLayouts are the date layouts tried when sniffing a date column; zero-padded and
+// month-first layouts come first, so a day past 12 is what picks a day-first layout
+var csvDateLayouts = []string{
+	"2006-01-02", "01/02/2006", "02/01/2006", "02.01.2006", "2006/01/02", "01-02-2006", "02-01-2006",
+	"1/2/2006", "2/1/2006", "2.1.2006", "01/02/06", "02/01/06", "02.01.06",
+	"Jan 2, 2006", "2 Jan 2006", "02 Jan 2006", "2-Jan-2006", "02-Jan-06",
+}
+
+// CSVDetection is what sniffing the first rows of a CSV file found out about its layout
+type CSVDetection struct {
+	Delimiter   string
+	SkipLines   int // Preamble lines above the header or first row
+	HasHeader   bool
+	Header      []string // Column names when the file has a header row
+	ColumnKinds []string // Kind of each column: empty, date, amount or text
+	DateFormat  string   // Layout every sampled date parses with, "" when there is no date column
+	Rows        int      // Data rows sampled
+
+	// Template is the existing template that reads the sampled rows best, nil when none can
+	Template *types.CSVTemplate
+	// Draft is a proposed template for the file, set when no existing template fits
+	Draft *types.CSVTemplate
+
+	rows [][]string
+}
+
+// DetectCSVTemplate sniffs a CSV file and picks the saved template that fits it, preferring
+// the default template on a tie, or drafts a new one
+func (s *Store) DetectCSVTemplate(filePath string) (*CSVDetection, error) {
+	templates, err := s.Templates.GetCSVTemplates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load templates: %w", err)
+	}
+	defaultName := s.Templates.GetDefaultTemplate()
+	for i, template := range templates {
+		if template.Name == defaultName {
+			templates[0], templates[i] = templates[i], templates[0]
+			break
+		}
+	}
+	return s.CSVParser.DetectCSVTemplate(filePath, templates)
+}
+
+// DetectCSVTemplate sniffs the delimiter, preamble, header row and column kinds from the first
+// rows of a CSV file, then scores the given templates against them. The first of equally good
+// templates wins. When none reads most sampled rows, a draft is proposed from the columns found.
+func (cp *CSVParser) DetectCSVTemplate(filePath string, templates []types.CSVTemplate) (*CSVDetection, error) {
+	lines, err := readCSVSniffLines(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(strings.Join(lines, "")) == "" {
+		return nil, fmt.Errorf("empty CSV file")
+	}
+
+	detection := cp.sniffCSV(lines)
+
+	bestScore := -1
+	for i := range templates {
+		if score := cp.scoreCSVTemplate(filePath, detection, &templates[i]); score > bestScore {
+			bestScore = score
+			detection.Template = &templates[i]
+		}
+	}
+	if detection.Template == nil {
+		detection.Draft = detection.draftTemplate(strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath)))
+	}
+	return detection, nil
+}
+
+// readCSVSniffLines returns the first lines of a file decoded to UTF-8, blank ones included so
+// that preamble lines are counted the way the import skips them
+func readCSVSniffLines(filePath string) ([]string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV file: %w", err)
+	}
+	defer file.Close()
+
+	text, err := decodeCSVText(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV file: %w", err)
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(text)
+	for scanner.Scan() && len(lines) < csvSniffLines {
+		lines = append(lines, strings.TrimSpace(scanner.Text()))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read CSV file: %w", err)
+	}
+	return lines, nil
+}
+
+// sniffCSV detects the layout of the sampled lines
+// Lines before the first one with the usual number of fields, such as the account details
+// some banks put above the header, are preamble lines to skip.
+func (cp *CSVParser) sniffCSV(lines []string) *CSVDetection {
+	detection := &CSVDetection{Delimiter: cp.sniffCSVDelimiter(lines)}
+
+	width := cp.csvModalWidth(lines, detection.Delimiter)
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		if len(cp.ParseCSVLine(line, detection.Delimiter)) == width {
+			break
+		}
+		detection.SkipLines = i + 1
+	}
+
+	// Quoted fields may span lines, so the rest of the sample is read as CSV text
+	reader := csv.NewReader(strings.NewReader(strings.Join(lines[detection.SkipLines:], "\n")))
+	reader.Comma = csvDelimiterRune(detection.Delimiter)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	var rows [][]string
+	for {
+		fields, err := reader.Read()
+		if err != nil {
+			break
+		}
+		for j := range fields {
+			fields[j] = strings.TrimSpace(fields[j])
+		}
+		rows = append(rows, fields)
+		width = max(width, len(fields))
+	}
+	if len(rows) == 0 {
+		return detection
+	}
+
+	// The first row is a header when none of its cells looks like data but later rows do
+	first := rows[0]
+	if len(rows) > 1 {
+		header := true
+		for _, cell := range first {
+			if kind := cp.csvCellKind(cell); kind == CSVColumnDate || kind == CSVColumnAmount {
+				header = false
+				break
+			}
+		}
+		if header {
+			for _, kind := range cp.csvColumnKinds(rows[1:], width) {
+				if kind == CSVColumnDate || kind == CSVColumnAmount {
+					detection.HasHeader = true
+					detection.Header = first
+					rows = rows[1:]
+					break
+				}
+			}
+		}
+	}
+
+	detection.rows = rows
+	detection.Rows = len(rows)
+	detection.ColumnKinds = cp.csvColumnKinds(rows, width)
+
+	var dates []string
+	for column, kind := range detection.ColumnKinds {
+		if kind == CSVColumnDate {
+			for _, value := range csvColumnValues(rows, column) {
+				if cp.csvCellKind(value) == CSVColumnDate {
+					dates = append(dates, value)
+				}
+			}
+			break
+		}
+	}
+	detection.DateFormat = sniffDateLayout(dates)
+	return detection
+}
+
+// sniffCSVDelimiter picks the delimiter that splits the most lines into the same number of
+// fields, favoring more fields, and falls back to a comma when none splits the lines at all
+func (cp *CSVParser) sniffCSVDelimiter(lines []string) string {
+	best, bestMatches, bestWidth := ",", 0, 1
+	for _, delimiter := range csvDelimiters {
+		width, matches := cp.csvModalWidth(lines, delimiter), 0
+		for _, line := range lines {
+			if line != "" && len(cp.ParseCSVLine(line, delimiter)) == width {
+				matches++
+			}
+		}
+		if width < 2 {
+			continue
+		}
+		if matches > bestMatches || (matches == bestMatches && width > bestWidth) {
+			best, bestMatches, bestWidth = delimiter, matches, width
+		}
+	}
+	return best
+}
+
+// csvModalWidth returns the most common number of fields in the non-blank lines, the larger
+// one on a tie
+func (cp *CSVParser) csvModalWidth(lines []string, delimiter string) int {
+	counts := make(map[int]int)
+	for _, line := range lines {
+		if line != "" {
+			counts[len(cp.ParseCSVLine(line, delimiter))]++
+		}
+	}
+	width, matches := 0, 0
+	for count, n := range counts {
+		if n > matches || (n == matches && count > width) {
+			width, matches = count, n
+		}
+	}
+	return width
+}
+
+// csvCellKind classifies one cell as a date, an amount, text or empty
+func (cp *CSVParser) csvCellKind(cell string) string {
+	cell = strings.TrimSpace(cell)
+	switch {
+	case cell == "":
+		return CSVColumnEmpty
+	case sniffDateLayout([]string{cell}) != "":
+		return CSVColumnDate
+	case strings.ContainsAny(cell, "0123456789"):
+		if _, err := cp.ParseAmount(cell); err == nil {
+			return CSVColumnAmount
+		}
+	}
+	return CSVColumnText
+}
+
+// csvColumnKinds classifies each column by its filled cells: a column is a date or amount
+// column when most of them are, so a stray summary or broken row does not hide it
+func (cp *CSVParser) csvColumnKinds(rows [][]string, width int) []string {
+	kinds := make([]string, width)
+	for column := range kinds {
+		values := csvColumnValues(rows, column)
+		counts := make(map[string]int)
+		for _, value := range values {
+			counts[cp.csvCellKind(value)]++
+		}
+
+		kinds[column] = CSVColumnEmpty
+		if len(values) > 0 {
+			kinds[column] = CSVColumnText
+		}
+		for _, kind := range []string{CSVColumnDate, CSVColumnAmount} {
+			if counts[kind]*2 > len(values) {
+				kinds[column] = kind
+			}
+		}
+	}
+	return kinds
+}
+
+// csvColumnValues returns the filled cells of a column
+func csvColumnValues(rows [][]string, column int) []string {
+	var values []string
+	for _, row := range rows {
+		if column < len(row) && row[column] != "" {
+			values = append(values, row[column])
+		}
+	}
+	return values
+}
+
+// sniffDateLayout returns the first layout every value parses with, or "" when there is none
+func sniffDateLayout(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	for _, layout := range csvDateLayouts {
+		fits := true
+		for _, value := range values {
+			if _, err := time.Parse(layout, value); err != nil {
+				fits = false
+				break
+			}
+		}
+		if fits {
+			return layout
+		}
+	}
+	return ""
+}
+
+// scoreCSVTemplate scores how well a template reads the sampled rows, or returns -1 when it
+// cannot read most of them. Reading every row scores higher, and so do columns holding the
+// kinds of data they are read as, so a template that only happens to parse does not win.
+func (cp *CSVParser) scoreCSVTemplate(filePath string, detection *CSVDetection, template *types.CSVTemplate) int {
+	delimiter := template.Delimiter
+	if delimiter == "" {
+		delimiter = ","
+	}
+	if delimiter != detection.Delimiter || template.HasHeader != detection.HasHeader {
+		return -1
+	}
+
+	// Most sampled rows must import the way the parser would read them with this template
+	rows := cp.templateSampleRows(filePath, template)
+	read := 0
+	for _, fields := range rows {
+		if cp.templateReadsRow(fields, template) {
+			read++
+		}
+	}
+	if read*2 <= len(rows) {
+		return -1
+	}
+
+	score := 0
+	if read == len(rows) {
+		score += 4
+	}
+
+	kindOf := func(column int) string {
+		if column < len(detection.ColumnKinds) {
+			return detection.ColumnKinds[column]
+		}
+		return CSVColumnEmpty
+	}
+
+	if kindOf(template.PostDateColumn) == CSVColumnDate {
+		score += 2
+	}
+	if kindOf(template.DescColumn) == CSVColumnText {
+		score += 2
+	}
+	if template.HasDebitCreditColumns() {
+		if kindOf(*template.DebitColumn) == CSVColumnAmount && kindOf(*template.CreditColumn) == CSVColumnAmount {
+			score += 2
+		}
+	} else if kindOf(template.AmountColumn) == CSVColumnAmount {
+		score += 2
+	}
+	if template.CategoryColumn != nil && kindOf(*template.CategoryColumn) == CSVColumnText {
+		score++
+	}
+	if template.TypeColumn != nil && kindOf(*template.TypeColumn) == CSVColumnText {
+		score++
+	}
+	return score
+}
+
+// templateSampleRows reads the first data rows of a file the way an import with the template
+// would, or returns nil when they cannot be read
+func (cp *CSVParser) templateSampleRows(filePath string, template *types.CSVTemplate) [][]string {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	records, err := newCSVRecordReader(file, template)
+	if err != nil {
+		return nil
+	}
+	if template.HasHeader {
+		if _, err := records.Read(); err != nil {
+			return nil
+		}
+	}
+
+	var rows [][]string
+	for len(rows) < csvSniffLines {
+		record, err := records.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil
+		}
+		rows = append(rows, record.fields)
+	}
+	return rows
+}
+
+// templateReadsRow reports whether a template reads a row's date, description and amount
+// without error; categories are left alone so scoring creates none
+func (cp *CSVParser) templateReadsRow(fields []string, template *types.CSVTemplate) bool {
+	maxColumn := template.PostDateColumn
+	if !template.HasDebitCreditColumns() && template.AmountColumn > maxColumn {
+		maxColumn = template.AmountColumn
+	}
+	if template.DescColumn > maxColumn {
+		maxColumn = template.DescColumn
+	}
+	for _, column := range []*int{template.CategoryColumn, template.DebitColumn, template.CreditColumn, template.TypeColumn} {
+		if column != nil && *column > maxColumn {
+			maxColumn = *column
+		}
+	}
+	if len(fields) <= maxColumn {
+		return false
+	}
+
+	if _, err := types.NormalizeDateToISO8601(fields[template.PostDateColumn], template.DateFormat); err != nil {
+		return false
+	}
+	if strings.TrimSpace(fields[template.DescColumn]) == "" {
+		return false
+	}
+	_, _, err := cp.parseTemplateAmount(fields, template)
+	return err == nil
+}
+
+// csvHeaderKeywords are words in a column name that say what the column holds
+var csvHeaderKeywords = map[string][]string{
+	"date":        {"date", "posted", "booking", "datum"},
+	"amount":      {"amount", "betrag", "value"},
+	"debit":       {"debit", "withdrawal", "money out", "paid out", "outflow"},
+	"credit":      {"credit", "deposit", "money in", "paid in", "inflow"},
+	"description": {"description", "payee", "memo", "details", "narrative", "merchant", "name", "reference"},
+	"category":    {"category"},
+}
+
+// headerColumn returns the first column of the given kind whose name has one of the keywords
+// of a role, or -1
+func (d *CSVDetection) headerColumn(role, kind string, skip ...int) int {
+	for column, name := range d.Header {
+		if column >= len(d.ColumnKinds) || d.ColumnKinds[column] != kind || slices.Contains(skip, column) {
+			continue
+		}
+		name = strings.ToLower(name)
+		for _, keyword := range csvHeaderKeywords[role] {
+			if strings.Contains(name, keyword) {
+				return column
+			}
+		}
+	}
+	return -1
+}
+
+// draftTemplate proposes a template from the detected columns, using column names where the
+// file has them and the column kinds otherwise
+func (d *CSVDetection) draftTemplate(name string) *types.CSVTemplate {
+	draft := &types.CSVTemplate{
+		Name:       name,
+		Delimiter:  d.Delimiter,
+		SkipLines:  d.SkipLines,
+		HasHeader:  d.HasHeader,
+		DateFormat: d.DateFormat,
+	}
+
+	draft.PostDateColumn = d.headerColumn("date", CSVColumnDate)
+	if draft.PostDateColumn < 0 {
+		draft.PostDateColumn = d.firstColumn(CSVColumnDate, false)
+	}
+
+	// A named debit/credit pair wins over an amount column; without names, two amount columns
+	// that are never filled on the same row are a pair unless a column is named the amount
+	debit, credit := d.headerColumn("debit", CSVColumnAmount), d.headerColumn("credit", CSVColumnAmount)
+	if debit < 0 || credit < 0 || debit == credit {
+		debit, credit = -1, -1
+		if d.headerColumn("amount", CSVColumnAmount) < 0 {
+			debit, credit = d.exclusiveAmountColumns()
+		}
+	}
+	if debit >= 0 {
+		draft.DebitColumn, draft.CreditColumn = &debit, &credit
+		draft.AmountColumn = debit
+	} else {
+		amount := d.headerColumn("amount", CSVColumnAmount)
+		if amount < 0 {
+			amount = d.firstColumn(CSVColumnAmount, true)
+		}
+		draft.AmountColumn = max(amount, 0)
+	}
+
+	draft.DescColumn = d.headerColumn("description", CSVColumnText)
+	if draft.DescColumn < 0 {
+		draft.DescColumn = d.widestTextColumn()
+	}
+	if category := d.headerColumn("category", CSVColumnText, draft.DescColumn); category >= 0 {
+		draft.CategoryColumn = &category
+	}
+	if rowType := d.typeColumn(draft.DescColumn); rowType >= 0 {
+		draft.TypeColumn = &rowType
+	}
+
+	draft.PostDateColumn = max(draft.PostDateColumn, 0)
+	draft.DescColumn = max(draft.DescColumn, 0)
+	return draft
+}
+
+// firstColumn returns the first column of a kind, only counting columns filled on every row
+// when full is set, or -1
+func (d *CSVDetection) firstColumn(kind string, full bool) int {
+	for column, columnKind := range d.ColumnKinds {
+		if columnKind == kind && (!full || len(csvColumnValues(d.rows, column)) == len(d.rows)) {
+			return column
+		}
+	}
+	return -1
+}
+
+// exclusiveAmountColumns returns the first two amount columns where every row fills exactly
+// one of them, or -1, -1
+func (d *CSVDetection) exclusiveAmountColumns() (int, int) {
+	for first, kind := range d.ColumnKinds {
+		if kind != CSVColumnAmount {
+			continue
+		}
+		for second := first + 1; second < len(d.ColumnKinds); second++ {
+			if d.ColumnKinds[second] != CSVColumnAmount {
+				continue
+			}
+			exclusive := true
+			for _, row := range d.rows {
+				if len(row) <= second || (row[first] == "") == (row[second] == "") {
+					exclusive = false
+					break
+				}
+			}
+			if exclusive {
+				return first, second
+			}
+		}
+	}
+	return -1, -1
+}
+
+// widestTextColumn returns the text column with the longest cells, which is most likely the
+// description, or -1
+func (d *CSVDetection) widestTextColumn() int {
+	best, bestLength := -1, 0
+	for column, kind := range d.ColumnKinds {
+		if kind != CSVColumnText {
+			continue
+		}
+		length := 0
+		for _, value := range csvColumnValues(d.rows, column) {
+			length += len(value)
+		}
+		if length > bestLength {
+			best, bestLength = column, length
+		}
+	}
+	return best
+}
+
+// typeColumn returns the text column, other than the description, whose every value names a
+// transaction type such as "DR" or "Credit", or -1
+func (d *CSVDetection) typeColumn(descColumn int) int {
+	for column, kind := range d.ColumnKinds {
+		if kind != CSVColumnText || column == descColumn {
+			continue
+		}
+		values := csvColumnValues(d.rows, column)
+		named := len(values) > 0
+		for _, value := range values {
+			if templateRowType(value) == "" {
+				named = false
+				break
+			}
+		}
+		if named {
+			return column
+		}
+	}
+	return -1
+}
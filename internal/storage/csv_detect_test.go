@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"testing"
+
+	"budget-tracker-tui/internal/types"
+)
+
+// TestDetectCSVTemplate tests sniffing a file's layout and scoring templates against it
+func TestDetectCSVTemplate(t *testing.T) {
+	store, conn := setupTestCSVTemplateStore(t)
+	defer teardownTestDB(t, conn)
+
+	category := 3
+	templates := []types.CSVTemplate{
+		// Reads the rows too, but takes the category as the description
+		{Name: "Loose", PostDateColumn: 0, AmountColumn: 1, DescColumn: 3, HasHeader: true},
+		{Name: "Checking", PostDateColumn: 0, AmountColumn: 1, DescColumn: 2, CategoryColumn: &category, HasHeader: true},
+		{Name: "NoHeader", PostDateColumn: 0, AmountColumn: 1, DescColumn: 2},
+	}
+
+	content := "\ufeffDate,Amount,Description,Category\n" +
+		"01/15/2024,-42.50,\"Grocery Store, Main St\",Food\n" +
+		"01/16/2024,\"$1,200.00\",Salary,Income\n" +
+		"01/20/2024,(15.00),Coffee Shop,Food\n"
+	filePath := createTestCSVFile(t, "checking.csv", content)
+
+	detection, err := store.CSVParser.DetectCSVTemplate(filePath, templates)
+	if err != nil {
+		t.Fatalf("DetectCSVTemplate() failed: %v", err)
+	}
+	if detection.Delimiter != "," || !detection.HasHeader || detection.Rows != 3 || detection.DateFormat != "01/02/2006" {
+		t.Errorf("Unexpected layout: %+v", detection)
+	}
+	wantKinds := []string{CSVColumnDate, CSVColumnAmount, CSVColumnText, CSVColumnText}
+	for i, kind := range wantKinds {
+		if i >= len(detection.ColumnKinds) || detection.ColumnKinds[i] != kind {
+			t.Fatalf("Expected column kinds %v, got %v", wantKinds, detection.ColumnKinds)
+		}
+	}
+	if detection.Template == nil || detection.Template.Name != "Checking" || detection.Draft != nil {
+		t.Errorf("Expected the Checking template, got %+v", detection.Template)
+	}
+
+	// A semicolon file with day-first dates and a debit/credit pair fits none of them
+	content = "Buchungstag;Verwendungszweck;Debit;Credit;Saldo\n" +
+		"15.01.2024;Supermarkt Einkauf;42.50;;957.50\n" +
+		"31.01.2024;Gehalt Januar;;2500.00;3457.50\n"
+	filePath = createTestCSVFile(t, "bank.csv", content)
+
+	detection, err = store.CSVParser.DetectCSVTemplate(filePath, templates)
+	if err != nil {
+		t.Fatalf("DetectCSVTemplate() failed: %v", err)
+	}
+	if detection.Template != nil || detection.Draft == nil {
+		t.Fatalf("Expected a draft template, got %+v", detection.Template)
+	}
+	draft := detection.Draft
+	if draft.Name != "bank" || draft.Delimiter != ";" || draft.DateFormat != "02.01.2006" {
+		t.Errorf("Unexpected draft settings: %+v", draft)
+	}
+	if draft.PostDateColumn != 0 || draft.DescColumn != 1 || !draft.HasDebitCreditColumns() || *draft.DebitColumn != 2 || *draft.CreditColumn != 3 {
+		t.Errorf("Unexpected draft columns: %+v", draft)
+	}
+}
+
+// TestMainStoreDetectCSVTemplate tests that the default template wins a tie and drafts import
+func TestMainStoreDetectCSVTemplate(t *testing.T) {
+	store, conn := setupTestCSVTemplateStore(t)
+	defer teardownTestDB(t, conn)
+
+	for _, name := range []string{"First", "Second"} {
+		template := types.CSVTemplate{Name: name, PostDateColumn: 0, AmountColumn: 1, DescColumn: 2, HasHeader: false}
+		if result := store.Templates.CreateCSVTemplate(template); !result.Success {
+			t.Fatalf("CreateCSVTemplate() failed: %s", result.Message)
+		}
+	}
+	if result := store.Templates.SetDefaultTemplate("Second"); !result.Success {
+		t.Fatalf("SetDefaultTemplate() failed: %s", result.Message)
+	}
+
+	filePath := createTestCSVFile(t, "plain.csv", "2024-01-15,-42.50,Grocery\n2024-01-16,1200.00,Salary\n")
+	detection, err := store.DetectCSVTemplate(filePath)
+	if err != nil {
+		t.Fatalf("DetectCSVTemplate() failed: %v", err)
+	}
+	if detection.HasHeader || detection.Template == nil || detection.Template.Name != "Second" {
+		t.Errorf("Expected the default template, got %+v", detection.Template)
+	}
+
+	// A tab separated file gets a draft that, once saved, imports it
+	filePath = createTestCSVFile(t, "tabs.csv", "Date\tDescription\tType\tAmount\n"+
+		"2024-01-15\tGrocery\tDR\t42.50\n2024-01-16\tSalary\tCR\t1200.00\n")
+	detection, err = store.DetectCSVTemplate(filePath)
+	if err != nil || detection.Draft == nil {
+		t.Fatalf("Expected a draft template, got %+v (%v)", detection, err)
+	}
+	if detection.Draft.TypeColumn == nil || *detection.Draft.TypeColumn != 2 || detection.Draft.AmountColumn != 3 {
+		t.Errorf("Unexpected draft columns: %+v", detection.Draft)
+	}
+	if result := store.Templates.CreateCSVTemplate(*detection.Draft); !result.Success {
+		t.Fatalf("CreateCSVTemplate() failed for the draft: %s", result.Message)
+	}
+	parsed, err := store.CSVParser.ParseCSV(filePath, detection.Draft, types.FailFast)
+	if err != nil {
+		t.Fatalf("ParseCSV() failed with the draft: %v", err)
+	}
+	if len(parsed.SuccessfulTransactions) != 2 || parsed.SuccessfulTransactions[0].Amount.Cents != -4250 {
+		t.Errorf("Expected the DR row as money out, got %+v", parsed.SuccessfulTransactions)
+	}
+}
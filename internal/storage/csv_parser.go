@@ -3,10 +3,13 @@ package storage
 import (
 	"budget-tracker-tui/internal/ml"
 	"budget-tracker-tui/internal/types"
+	"context"
+	"encoding/csv"
+	"errors"
 	"fmt"
-	"math"
+	"io"
+	"log/slog"
 	"os"
-	"strconv"
 	"strings"
 	"time"
 )
@@ -29,22 +32,11 @@ func NewCSVParser(transactionStore *TransactionStore, categoryStore *CategorySto
 
 // ParseCSV parses a CSV file based on the specified template and mode
 func (cp *CSVParser) ParseCSV(filePath string, template *types.CSVTemplate, mode types.ParseMode) (*types.CSVParseResult, error) {
-	// Validate dependencies
-	if cp.categoryStore == nil {
-		return nil, fmt.Errorf("category store is required for CSV parsing")
-	}
-
-	// Read CSV file
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read CSV file: %w", err)
-	}
-
-	lines := strings.Split(string(data), "\n")
-	if len(lines) == 0 {
-		return nil, fmt.Errorf("empty CSV file")
-	}
+	return cp.ParseCSVContext(context.Background(), filePath, template, mode, nil)
+}
 
+// ParseCSVContext parses a CSV file, reporting rows parsed and stopping with ctx.Err() when cancelled
+func (cp *CSVParser) ParseCSVContext(ctx context.Context, filePath string, template *types.CSVTemplate, mode types.ParseMode, progress types.ProgressFunc) (*types.CSVParseResult, error) {
 	// Initialize result
 	result := &types.CSVParseResult{
 		SuccessfulTransactions: make([]types.Transaction, 0),
@@ -53,60 +45,27 @@ func (cp *CSVParser) ParseCSV(filePath string, template *types.CSVTemplate, mode
 		CanProceedPartially:    mode == types.SkipInvalid,
 	}
 
-	// Calculate starting line
-	startLine := 0
-	if template.HasHeader {
-		startLine = 1
-	}
-
-	// Determine delimiter
-	delimiter := ","
-	if template.Delimiter != "" {
-		delimiter = template.Delimiter
-	}
-
-	// Get default category ID
-	defaultCategoryId := cp.categoryStore.GetDefaultCategoryId()
-
-	// Parse each line
-	for i := startLine; i < len(lines); i++ {
-		line := strings.TrimSpace(lines[i])
-		if line == "" {
-			continue // Skip empty lines
-		}
-
-		// Parse CSV line into fields
-		fields := cp.ParseCSVLine(line, delimiter)
-
-		// Parse transaction from fields
-		transaction, err := cp.parseTransactionFromTemplate(fields, template, i+1, defaultCategoryId)
-		if err != nil {
+	totalRows, err := cp.readRows(ctx, filePath, template, progress, func(row csvRow) error {
+		if row.err != nil {
 			// Handle error based on mode
 			if mode == types.FailFast {
-				return nil, fmt.Errorf("line %d: %w", i+1, err)
+				return fmt.Errorf("line %d: %w", row.line, row.err)
 			}
 
 			// SkipInvalid mode - collect error and continue
-			rowError := types.RowError{
-				LineNumber: i + 1,
-				RawRow:     line,
-				ErrorType:  cp.categorizeError(err),
-				Message:    err.Error(),
-				Field:      cp.extractFieldFromError(err),
-			}
-			result.FailedRows = append(result.FailedRows, rowError)
-			continue
+			result.FailedRows = append(result.FailedRows, cp.rowError(row))
+			return nil
 		}
 
 		// Add successful transaction
-		result.SuccessfulTransactions = append(result.SuccessfulTransactions, *transaction)
+		result.SuccessfulTransactions = append(result.SuccessfulTransactions, *row.transaction)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Calculate summary
-	totalRows := len(lines)
-	if template.HasHeader && totalRows > 0 {
-		totalRows-- // Don't count header
-	}
 
 	result.Summary = types.NewImportSummary(
 		totalRows,
@@ -118,10 +77,105 @@ func (cp *CSVParser) ParseCSV(filePath string, template *types.CSVTemplate, mode
 	return result, nil
 }
 
+// csvRow is one data row of a file, parsed into a transaction or with the error that stopped it
+type csvRow struct {
+	line        int
+	raw         string
+	start, end  int64 // Byte offsets of the row's text, as decoded to UTF-8
+	transaction *types.Transaction
+	err         error
+}
+
+// readRows parses the data rows of a file in order and hands each to visit, returning the
+// number of rows read. An error from visit stops reading and is returned.
+func (cp *CSVParser) readRows(ctx context.Context, filePath string, template *types.CSVTemplate, progress types.ProgressFunc, visit func(csvRow) error) (int, error) {
+	// Validate dependencies
+	if cp.categoryStore == nil {
+		return 0, fmt.Errorf("category store is required for CSV parsing")
+	}
+
+	// Stream the CSV file record by record
+	file, err := os.Open(filePath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read CSV file: %w", err)
+	}
+	defer file.Close()
+
+	records, err := newCSVRecordReader(file, template)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read CSV file: %w", err)
+	}
+
+	// Skip the header row
+	if template.HasHeader {
+		if _, err := records.Read(); err != nil && err != io.EOF {
+			return 0, fmt.Errorf("failed to read CSV header: %w", err)
+		}
+	}
+
+	delimiter := string(csvDelimiterRune(template.Delimiter))
+
+	// Get default category ID
+	defaultCategoryId := cp.categoryStore.GetDefaultCategoryId()
+
+	// The number of rows is only known once the file has been read
+	reporter := newProgressReporter(progress, types.ProgressParsing, 0)
+	reporter.start()
+
+	// Parse each record
+	totalRows := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+
+		record, err := records.Read()
+		if err == io.EOF {
+			break
+		}
+		totalRows++
+		reporter.update(totalRows)
+
+		// Parse transaction from fields, unless the record itself is malformed
+		row := csvRow{line: record.line, raw: strings.Join(record.fields, delimiter), start: record.start, end: record.end}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			row.line = parseErr.StartLine
+			row.err = parseErr.Err
+		} else if err != nil {
+			return 0, fmt.Errorf("failed to read CSV file: %w", err)
+		} else {
+			row.transaction, row.err = cp.parseTransactionFromTemplate(record.fields, template, record.line, defaultCategoryId)
+		}
+		if err := visit(row); err != nil {
+			return 0, err
+		}
+	}
+	reporter.finish(totalRows)
+
+	return totalRows, nil
+}
+
+// rowError describes a row that failed to parse
+func (cp *CSVParser) rowError(row csvRow) types.RowError {
+	return types.RowError{
+		LineNumber: row.line,
+		RawRow:     row.raw,
+		ErrorType:  cp.categorizeError(row.err),
+		Message:    row.err.Error(),
+		Field:      cp.extractFieldFromError(row.err),
+	}
+}
+
 // ParseWithDuplicateDetection parses CSV and separates new from duplicate transactions
 func (cp *CSVParser) ParseWithDuplicateDetection(filePath string, template *types.CSVTemplate) (*types.CSVParseResult, error) {
+	return cp.ParseWithDuplicateDetectionContext(context.Background(), filePath, template, nil)
+}
+
+// ParseWithDuplicateDetectionContext is ParseWithDuplicateDetection with progress reporting and cancellation
+func (cp *CSVParser) ParseWithDuplicateDetectionContext(ctx context.Context, filePath string, template *types.CSVTemplate, progress types.ProgressFunc) (*types.CSVParseResult, error) {
 	// First parse all transactions (fail-fast mode for validation)
-	result, err := cp.ParseCSV(filePath, template, types.FailFast)
+	result, err := cp.ParseCSVContext(ctx, filePath, template, types.FailFast, progress)
 	if err != nil {
 		return nil, err
 	}
@@ -137,6 +191,9 @@ func (cp *CSVParser) ParseWithDuplicateDetection(filePath string, template *type
 
 	// Check each transaction for duplicates
 	for _, tx := range result.SuccessfulTransactions {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		isDuplicate := cp.checkForDuplicate(tx, result.SuccessfulTransactions, newTransactions)
 		if isDuplicate {
 			duplicateTransactions = append(duplicateTransactions, tx)
@@ -167,14 +224,16 @@ func (cp *CSVParser) parseTransactionFromTemplate(fields []string, template *typ
 
 	// Validate field count
 	maxColumn := template.PostDateColumn
-	if template.AmountColumn > maxColumn {
+	if !template.HasDebitCreditColumns() && template.AmountColumn > maxColumn {
 		maxColumn = template.AmountColumn
 	}
 	if template.DescColumn > maxColumn {
 		maxColumn = template.DescColumn
 	}
-	if template.CategoryColumn != nil && *template.CategoryColumn > maxColumn {
-		maxColumn = *template.CategoryColumn
+	for _, column := range []*int{template.CategoryColumn, template.DebitColumn, template.CreditColumn, template.TypeColumn} {
+		if column != nil && *column > maxColumn {
+			maxColumn = *column
+		}
 	}
 
 	if len(fields) <= maxColumn {
@@ -203,35 +262,108 @@ func (cp *CSVParser) parseTransactionFromTemplate(fields []string, template *typ
 	transaction.RawDescription = desc
 
 	// Extract and parse amount
-	amountStr := strings.Trim(fields[template.AmountColumn], "\"")
-	transaction.Amount, err = cp.ParseAmount(amountStr)
+	transaction.Amount, transaction.TransactionType, err = cp.parseTemplateAmount(fields, template)
 	if err != nil {
-		return nil, fmt.Errorf("invalid amount '%s': %w", amountStr, err)
+		return nil, err
 	}
 
 	// Handle category assignment with ML prediction integration
-	categoryId := cp.assignCategory(desc, transaction.Amount, template, fields, defaultCategoryId)
+	categoryId := cp.assignCategory(desc, transaction.Amount.Float64(), template, fields, defaultCategoryId)
 	transaction.CategoryId = categoryId
 
 	return &transaction, nil
 }
 
+// parseTemplateAmount reads a row's amount, negative for money out, and its type when the
+// template can tell. A debit/credit pair signs the amount by which column is filled; a single
+// amount column keeps its sign, flipped for files that show expenses as positive. A type column
+// naming the row a debit or credit decides over both.
+func (cp *CSVParser) parseTemplateAmount(fields []string, template *types.CSVTemplate) (types.Money, string, error) {
+	var amount types.Money
+	var transactionType string
+
+	if template.HasDebitCreditColumns() {
+		debitStr := strings.Trim(fields[*template.DebitColumn], "\"")
+		creditStr := strings.Trim(fields[*template.CreditColumn], "\"")
+		debit, err := cp.parseOptionalAmount(debitStr, template.Currency)
+		if err != nil {
+			return types.Money{}, "", fmt.Errorf("invalid debit amount '%s': %w", debitStr, err)
+		}
+		credit, err := cp.parseOptionalAmount(creditStr, template.Currency)
+		if err != nil {
+			return types.Money{}, "", fmt.Errorf("invalid credit amount '%s': %w", creditStr, err)
+		}
+
+		switch {
+		case !debit.IsZero() && !credit.IsZero():
+			return types.Money{}, "", fmt.Errorf("both debit amount '%s' and credit amount '%s' given", debitStr, creditStr)
+		case !debit.IsZero():
+			amount, transactionType = debit.Abs().Neg(), "expense"
+		case !credit.IsZero():
+			amount, transactionType = credit.Abs(), "income"
+		case strings.TrimSpace(debitStr) == "" && strings.TrimSpace(creditStr) == "":
+			return types.Money{}, "", fmt.Errorf("missing amount: debit and credit columns are both empty")
+		default:
+			return types.Money{}, "", fmt.Errorf("zero amount: debit '%s' and credit '%s' move no money", debitStr, creditStr)
+		}
+	} else {
+		amountStr := strings.Trim(fields[template.AmountColumn], "\"")
+		var err error
+		amount, err = cp.ParseAmountInCurrency(amountStr, template.Currency)
+		if err != nil {
+			return types.Money{}, "", fmt.Errorf("invalid amount '%s': %w", amountStr, err)
+		}
+		if amount.IsZero() {
+			return types.Money{}, "", fmt.Errorf("zero amount '%s' moves no money", amountStr)
+		}
+		if template.ExpensesPositive {
+			amount = amount.Neg()
+		}
+	}
+
+	if template.TypeColumn != nil {
+		switch rowType := templateRowType(fields[*template.TypeColumn]); rowType {
+		case "expense":
+			amount, transactionType = amount.Abs().Neg(), rowType
+		case "income":
+			amount, transactionType = amount.Abs(), rowType
+		case "transfer":
+			transactionType = rowType
+		}
+	}
+	return amount, transactionType, nil
+}
+
+// parseOptionalAmount parses one column of a debit/credit pair, where blank means zero
+func (cp *CSVParser) parseOptionalAmount(amountStr, currency string) (types.Money, error) {
+	if strings.TrimSpace(amountStr) == "" {
+		return types.NewMoney(0, currency), nil
+	}
+	return cp.ParseAmountInCurrency(amountStr, currency)
+}
+
+// templateRowTypes maps the values banks put in a type column to transaction types
+var templateRowTypes = map[string]string{
+	"debit": "expense", "d": "expense", "dr": "expense", "dbit": "expense", "withdrawal": "expense", "expense": "expense", "payment": "expense",
+	"credit": "income", "c": "income", "cr": "income", "crdt": "income", "deposit": "income", "income": "income",
+	"transfer": "transfer", "xfer": "transfer",
+}
+
+// templateRowType returns the transaction type a type column value names, or "" when it names
+// none, leaving the amount's sign to decide
+func templateRowType(value string) string {
+	return templateRowTypes[strings.ToLower(strings.Trim(strings.TrimSpace(value), "\"."))]
+}
+
 // assignCategory determines the appropriate category using ML-first approach
 func (cp *CSVParser) assignCategory(description string, amount float64, template *types.CSVTemplate, fields []string, defaultCategoryId int64) int64 {
 	// Step 1: Try ML prediction if available
-	if cp.mlCategorizer != nil {
-		prediction := cp.mlCategorizer.PredictCategory(description, amount)
-
-		// Use high-confidence ML predictions
-		if prediction.Confidence >= 0.7 { // High confidence threshold
-			fmt.Printf("[ML] Auto-categorized '%s' → Category %d (confidence: %.2f)\n",
-				description, prediction.CategoryId, prediction.Confidence)
-			return prediction.CategoryId
-		}
+	if categoryId, ok := cp.predictCategory(description, amount); ok {
+		return categoryId
 	}
 
-	// Step 2: Fallback to CSV category column if available
-	if template.CategoryColumn != nil && cp.categoryStore != nil {
+	// Step 2: Fallback to CSV category column if available (files imported without a template have none)
+	if template != nil && template.CategoryColumn != nil && cp.categoryStore != nil {
 		categoryText := strings.Trim(fields[*template.CategoryColumn], "\"")
 		if categoryText != "" {
 			return cp.categoryStore.ResolveOrCreateCategory(categoryText)
@@ -242,6 +374,22 @@ func (cp *CSVParser) assignCategory(description string, amount float64, template
 	return defaultCategoryId
 }
 
+// predictCategory returns the ML categorizer's prediction when it is confident enough to use
+func (cp *CSVParser) predictCategory(description string, amount float64) (int64, bool) {
+	if cp.mlCategorizer == nil {
+		return 0, false
+	}
+	prediction := cp.mlCategorizer.PredictCategory(description, amount)
+
+	// Use high-confidence ML predictions
+	if prediction.Confidence >= 0.7 { // High confidence threshold
+		slog.Debug("ML auto-categorized row", "description", description,
+			"category_id", prediction.CategoryId, "confidence", prediction.Confidence)
+		return prediction.CategoryId, true
+	}
+	return 0, false
+}
+
 // checkForDuplicate determines if a transaction is a duplicate using existing logic
 func (cp *CSVParser) checkForDuplicate(tx types.Transaction, allTransactions []types.Transaction, processedNew []types.Transaction) bool {
 	// Find existing transactions with same date, amount, and description
@@ -256,7 +404,7 @@ func (cp *CSVParser) checkForDuplicate(tx types.Transaction, allTransactions []t
 	importCount := 0
 	for _, importTx := range allTransactions {
 		if time.Time.Equal(importTx.Date, tx.Date) &&
-			math.Abs(importTx.Amount-tx.Amount) < 0.01 &&
+			importTx.Amount.Equal(tx.Amount) &&
 			importTx.Description == tx.Description {
 			importCount++
 		}
@@ -268,7 +416,7 @@ func (cp *CSVParser) checkForDuplicate(tx types.Transaction, allTransactions []t
 	previousNewCount := 0
 	for _, newTx := range processedNew {
 		if time.Time.Equal(newTx.Date, tx.Date) &&
-			math.Abs(newTx.Amount-tx.Amount) < 0.01 &&
+			newTx.Amount.Equal(tx.Amount) &&
 			newTx.Description == tx.Description {
 			previousNewCount++
 		}
@@ -278,54 +426,54 @@ func (cp *CSVParser) checkForDuplicate(tx types.Transaction, allTransactions []t
 	return existingCount+previousNewCount >= importCount
 }
 
-// ParseCSVLine parses a CSV line into fields using the specified delimiter
+// ParseCSVLine parses a single CSV line into fields using the specified delimiter
+// Files are read with a streaming reader instead, since quoted fields may span lines.
 func (cp *CSVParser) ParseCSVLine(line, delimiter string) []string {
-	var fields []string
-	current := ""
-	inQuotes := false
-
-	for i := 0; i < len(line); i++ {
-		char := line[i]
-
-		if char == '"' {
-			if inQuotes && i+1 < len(line) && line[i+1] == '"' {
-				// Escaped quote
-				current += "\""
-				i++ // Skip next quote
-			} else {
-				// Toggle quote state
-				inQuotes = !inQuotes
-			}
-		} else if char == delimiter[0] && !inQuotes {
-			// Field separator
-			fields = append(fields, current)
-			current = ""
-		} else {
-			current += string(char)
-		}
-	}
+	reader := csv.NewReader(strings.NewReader(line))
+	reader.Comma = csvDelimiterRune(delimiter)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
 
-	// Add final field
-	fields = append(fields, current)
+	fields, err := reader.Read()
+	if err != nil || len(fields) == 0 {
+		return []string{line}
+	}
 	return fields
 }
 
-// ParseAmount parses a currency string into a float64
-func (cp *CSVParser) ParseAmount(amountStr string) (float64, error) {
-	// Remove currency symbols and whitespace
+// currencySymbols are stripped from amount fields before parsing
+var currencySymbols = []string{"$", "€", "£", "¥"}
+
+// ParseAmount parses a currency string into exact Money in the default currency
+func (cp *CSVParser) ParseAmount(amountStr string) (types.Money, error) {
+	return cp.ParseAmountInCurrency(amountStr, types.DefaultCurrency)
+}
+
+// ParseAmountInCurrency parses a currency string into exact Money tagged with the given currency
+// Currency symbols and the currency's own code (e.g. "EUR 12.50") are ignored
+func (cp *CSVParser) ParseAmountInCurrency(amountStr, currency string) (types.Money, error) {
+	if currency == "" {
+		currency = types.DefaultCurrency
+	}
+
+	// Remove currency symbols, codes and whitespace
 	cleaned := strings.TrimSpace(amountStr)
-	cleaned = strings.ReplaceAll(cleaned, "$", "")
+	for _, symbol := range currencySymbols {
+		cleaned = strings.ReplaceAll(cleaned, symbol, "")
+	}
+	cleaned = strings.ReplaceAll(strings.ToUpper(cleaned), strings.ToUpper(currency), "")
 	cleaned = strings.ReplaceAll(cleaned, ",", "")
+	cleaned = strings.TrimSpace(cleaned)
 
 	// Handle negative amounts in parentheses (e.g., "(50.00)")
 	if strings.HasPrefix(cleaned, "(") && strings.HasSuffix(cleaned, ")") {
 		cleaned = "-" + strings.Trim(cleaned, "()")
 	}
 
-	// Parse as float
-	amount, err := strconv.ParseFloat(cleaned, 64)
+	// Parse as exact cents so imported totals never drift
+	amount, err := types.ParseMoney(cleaned, currency)
 	if err != nil {
-		return 0, fmt.Errorf("invalid amount format: %s", amountStr)
+		return types.Money{}, fmt.Errorf("invalid amount format: %s", amountStr)
 	}
 
 	return amount, nil
@@ -0,0 +1,233 @@
+package storage
+
+import (
+	"budget-tracker-tui/internal/types"
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// csvSniffBytes is how much of a file is looked at to tell its text encoding
+const csvSniffBytes = 64 * 1024
+
+// csvRecord is one record of a CSV file with the line it starts on
+type csvRecord struct {
+	fields     []string
+	line       int
+	start, end int64 // Byte offsets of the record's text, as decoded to UTF-8
+}
+
+// csvRecordReader streams the records of a bank export, skipping the preamble lines before it
+// and holding back the footer records after it, which are never returned
+type csvRecordReader struct {
+	reader  *csv.Reader
+	skipped int   // Preamble lines skipped ahead of the CSV reader
+	offset  int64 // Bytes of those lines
+	footer  int
+	pending []csvRecord
+	err     error
+}
+
+// newCSVRecordReader reads a CSV file in any of the encodings banks export, laid out as the
+// template describes: the delimiter, the preamble lines to skip and the footer rows to drop
+func newCSVRecordReader(r io.Reader, template *types.CSVTemplate) (*csvRecordReader, error) {
+	text, err := decodeCSVText(r)
+	if err != nil {
+		return nil, err
+	}
+
+	buffered := bufio.NewReader(text)
+	skipped := 0
+	var offset int64
+	for ; skipped < template.SkipLines; skipped++ {
+		line, err := buffered.ReadString('\n')
+		offset += int64(len(line))
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+	}
+
+	reader := csv.NewReader(buffered)
+	reader.Comma = csvDelimiterRune(template.Delimiter)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	return &csvRecordReader{reader: reader, skipped: skipped, offset: offset, footer: template.FooterLines}, nil
+}
+
+// Read returns the next record, or io.EOF once only footer records are left
+// Blank lines are skipped. A malformed record is returned as a *csv.ParseError with its line,
+// along with the record's offsets, after which reading can go on.
+func (cr *csvRecordReader) Read() (csvRecord, error) {
+	for len(cr.pending) <= cr.footer {
+		if cr.err != nil {
+			return csvRecord{}, cr.err
+		}
+		start := cr.offset + cr.reader.InputOffset()
+		fields, err := cr.reader.Read()
+		end := cr.offset + cr.reader.InputOffset()
+		if err != nil {
+			if err == io.EOF {
+				cr.err = io.EOF
+				continue
+			}
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				parseErr.StartLine += cr.skipped
+				parseErr.Line += cr.skipped
+			}
+			return csvRecord{start: start, end: end}, err
+		}
+		if len(fields) == 1 && strings.TrimSpace(fields[0]) == "" {
+			continue
+		}
+		line, _ := cr.reader.FieldPos(0)
+		cr.pending = append(cr.pending, csvRecord{fields: fields, line: line + cr.skipped, start: start, end: end})
+	}
+
+	record := cr.pending[0]
+	cr.pending = cr.pending[1:]
+	return record, nil
+}
+
+// csvDelimiterRune returns the delimiter as the CSV reader's separator, a comma when unset
+func csvDelimiterRune(delimiter string) rune {
+	if delimiter == "" {
+		return ','
+	}
+	r, _ := utf8.DecodeRuneInString(delimiter)
+	return r
+}
+
+// decodeCSVText returns the text of a file as UTF-8 without a byte order mark
+// UTF-16 is recognised by its byte order mark or by the zero bytes of ASCII text; a file that
+// is not valid UTF-8 is read as Latin-1, which many older bank exports use.
+func decodeCSVText(r io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReaderSize(r, csvSniffBytes)
+	head, err := buffered.Peek(csvSniffBytes)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(head, []byte{0xEF, 0xBB, 0xBF}):
+		buffered.Discard(3)
+		return buffered, nil
+	case bytes.HasPrefix(head, []byte{0xFF, 0xFE}):
+		buffered.Discard(2)
+		return &utf16Reader{r: buffered, order: binary.LittleEndian}, nil
+	case bytes.HasPrefix(head, []byte{0xFE, 0xFF}):
+		buffered.Discard(2)
+		return &utf16Reader{r: buffered, order: binary.BigEndian}, nil
+	case len(head) >= 4 && head[0] != 0 && head[1] == 0 && head[3] == 0:
+		return &utf16Reader{r: buffered, order: binary.LittleEndian}, nil
+	case len(head) >= 4 && head[0] == 0 && head[2] == 0 && head[1] != 0:
+		return &utf16Reader{r: buffered, order: binary.BigEndian}, nil
+	case !utf8.Valid(trimPartialRune(head)):
+		return &latin1Reader{r: buffered}, nil
+	}
+	return buffered, nil
+}
+
+// trimPartialRune drops a multi-byte character cut off at the end of a sample
+func trimPartialRune(sample []byte) []byte {
+	for i := 1; i < utf8.UTFMax && i <= len(sample); i++ {
+		if utf8.RuneStart(sample[len(sample)-i]) {
+			if !utf8.FullRune(sample[len(sample)-i:]) {
+				return sample[:len(sample)-i]
+			}
+			break
+		}
+	}
+	return sample
+}
+
+// utf16Reader decodes UTF-16 text to UTF-8
+type utf16Reader struct {
+	r     *bufio.Reader
+	order binary.ByteOrder
+	out   []byte // Decoded text not yet returned
+	err   error  // Returned once the decoded text runs out
+}
+
+func (u *utf16Reader) Read(p []byte) (int, error) {
+	for len(u.out) < len(p) && u.err == nil {
+		unit, err := u.readUnit()
+		if err != nil {
+			u.err = err
+			break
+		}
+
+		r := rune(unit)
+		if utf16.IsSurrogate(r) {
+			low, err := u.readUnit()
+			if err != nil {
+				u.out = utf8.AppendRune(u.out, utf8.RuneError)
+				u.err = err
+				break
+			}
+			if decoded := utf16.DecodeRune(r, rune(low)); decoded != utf8.RuneError {
+				r = decoded
+			} else {
+				// An unpaired surrogate; keep the unit after it
+				u.out = utf8.AppendRune(u.out, utf8.RuneError)
+				r = rune(low)
+			}
+		}
+		u.out = utf8.AppendRune(u.out, r)
+	}
+
+	if len(u.out) == 0 && u.err != nil {
+		return 0, u.err
+	}
+	n := copy(p, u.out)
+	u.out = u.out[n:]
+	return n, nil
+}
+
+// readUnit reads one 16-bit code unit; a lone byte at the end of the file is an error
+func (u *utf16Reader) readUnit() (uint16, error) {
+	var pair [2]byte
+	if _, err := io.ReadFull(u.r, pair[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return 0, fmt.Errorf("truncated UTF-16 text")
+		}
+		return 0, err
+	}
+	return u.order.Uint16(pair[:]), nil
+}
+
+// latin1Reader decodes ISO 8859-1 text, where every byte is the code point of its character
+type latin1Reader struct {
+	r   *bufio.Reader
+	out []byte
+	err error
+}
+
+func (l *latin1Reader) Read(p []byte) (int, error) {
+	for len(l.out) < len(p) && l.err == nil {
+		b, err := l.r.ReadByte()
+		if err != nil {
+			l.err = err
+			break
+		}
+		l.out = utf8.AppendRune(l.out, rune(b))
+	}
+
+	if len(l.out) == 0 && l.err != nil {
+		return 0, l.err
+	}
+	n := copy(p, l.out)
+	l.out = l.out[n:]
+	return n, nil
+}
@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"budget-tracker-tui/internal/types"
+)
+
+// TestParseCSVEncodings tests reading CRLF files, quoted fields spanning lines and the text
+// encodings bank exports use
+func TestParseCSVEncodings(t *testing.T) {
+	store, conn := setupTestCSVTemplateStore(t)
+	defer teardownTestDB(t, conn)
+
+	template := &types.CSVTemplate{PostDateColumn: 0, AmountColumn: 1, DescColumn: 2, HasHeader: true, Delimiter: ";"}
+	content := "Date;Amount;Description\r\n" +
+		"2024-01-15;-42.50;\"Café Central\r\nTable 4\"\r\n" +
+		"\r\n" +
+		"2024-01-16;1200.00;Salary\r\n"
+
+	utf16LE := func(s string) string {
+		units := utf16.Encode([]rune(s))
+		b := []byte{0xFF, 0xFE}
+		for _, unit := range units {
+			b = binary.LittleEndian.AppendUint16(b, unit)
+		}
+		return string(b)
+	}
+	latin1 := strings.ReplaceAll(content, "é", "\xe9")
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"UTF-8", content},
+		{"UTF-8 with BOM", "\ufeff" + content},
+		{"UTF-16LE", utf16LE(content)},
+		{"Latin-1", latin1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := createTestCSVFile(t, "export.csv", tt.content)
+			result, err := store.CSVParser.ParseCSV(filePath, template, types.FailFast)
+			if err != nil {
+				t.Fatalf("ParseCSV() failed: %v", err)
+			}
+			if len(result.SuccessfulTransactions) != 2 || result.Summary.TotalRows != 2 {
+				t.Fatalf("Expected 2 rows, got %+v", result.Summary)
+			}
+			if desc := result.SuccessfulTransactions[0].Description; desc != "Café Central\nTable 4" {
+				t.Errorf("Expected the quoted description across two lines, got %q", desc)
+			}
+			if result.SuccessfulTransactions[1].Amount.Cents != 120000 {
+				t.Errorf("Expected 1200.00, got %s", result.SuccessfulTransactions[1].Amount)
+			}
+		})
+	}
+}
+
+// TestParseCSVPreambleAndFooter tests skipping account details above the header and summary
+// rows below the transactions
+func TestParseCSVPreambleAndFooter(t *testing.T) {
+	store, conn := setupTestCSVTemplateStore(t)
+	defer teardownTestDB(t, conn)
+
+	template := types.CSVTemplate{
+		Name: "Preamble", PostDateColumn: 0, AmountColumn: 1, DescColumn: 2, HasHeader: true,
+		SkipLines: 2, FooterLines: 1,
+	}
+	if result := store.Templates.CreateCSVTemplate(template); !result.Success {
+		t.Fatalf("CreateCSVTemplate() failed: %s", result.Message)
+	}
+	saved := store.Templates.GetTemplateByName("Preamble")
+	if saved == nil || saved.SkipLines != 2 || saved.FooterLines != 1 {
+		t.Fatalf("Expected the line counts to be saved, got %+v", saved)
+	}
+
+	content := "Account,12345678\n" +
+		"Period,January 2024\n" +
+		"Date,Amount,Description\n" +
+		"2024-01-15,-42.50,Grocery\n" +
+		"2024-01-16,oops,Broken\n" +
+		"2024-01-17,1200.00,Salary\n" +
+		"Total,1157.50,\n"
+	filePath := createTestCSVFile(t, "preamble.csv", content)
+
+	result, err := store.CSVParser.ParseCSV(filePath, saved, types.SkipInvalid)
+	if err != nil {
+		t.Fatalf("ParseCSV() failed: %v", err)
+	}
+	if len(result.SuccessfulTransactions) != 2 || result.Summary.TotalRows != 3 {
+		t.Fatalf("Expected 2 of 3 rows, got %+v", result.Summary)
+	}
+	if len(result.FailedRows) != 1 || result.FailedRows[0].LineNumber != 5 || result.FailedRows[0].RawRow != "2024-01-16,oops,Broken" {
+		t.Errorf("Expected line 5 to fail, got %+v", result.FailedRows)
+	}
+
+	// The same file read without the template's line counts fails on the preamble
+	if _, err := store.CSVParser.ParseCSV(filePath, &types.CSVTemplate{PostDateColumn: 0, AmountColumn: 1, DescColumn: 2, HasHeader: true}, types.FailFast); err == nil {
+		t.Error("Expected the preamble to fail without SkipLines")
+	}
+
+	// Detection finds the preamble and drafts a template that skips it
+	detection, err := store.CSVParser.DetectCSVTemplate(filePath, nil)
+	if err != nil || detection.Draft == nil {
+		t.Fatalf("Expected a draft template, got %+v (%v)", detection, err)
+	}
+	if detection.SkipLines != 2 || !detection.HasHeader || detection.Draft.SkipLines != 2 {
+		t.Errorf("Expected 2 preamble lines before a header, got %+v", detection)
+	}
+	if detection, err := store.CSVParser.DetectCSVTemplate(filePath, []types.CSVTemplate{*saved}); err != nil || detection.Template == nil {
+		t.Errorf("Expected the saved template to fit, got %+v (%v)", detection, err)
+	}
+}
@@ -14,6 +14,7 @@ type CSVTemplateStore struct {
 	db              *database.Connection
 	helper          *database.SQLHelper
 	defaultTemplate string
+	audit           *AuditStore // General audit log of creates, updates and deletes
 }
 
 // NewCSVTemplateStore creates a new CSVTemplateStore instance
@@ -30,6 +31,11 @@ func NewCSVTemplateStore(db *database.Connection) *CSVTemplateStore {
 	return store
 }
 
+// SetAuditStore sets the audit log that template changes are recorded in
+func (cts *CSVTemplateStore) SetAuditStore(audit *AuditStore) {
+	cts.audit = audit
+}
+
 // ensureDefaultTemplates creates default CSV templates if none exist
 func (cts *CSVTemplateStore) ensureDefaultTemplates() {
 	count, err := cts.helper.CountBy("csv_templates", "")
@@ -52,7 +58,9 @@ func (cts *CSVTemplateStore) ensureDefaultTemplates() {
 func (cts *CSVTemplateStore) GetCSVTemplates() ([]types.CSVTemplate, error) {
 	query := `
 		SELECT id, name, post_date_column, amount_column, desc_column, category_column,
-		       has_header, date_format, delimiter, created_at, updated_at
+		       has_header, date_format, delimiter, currency, created_at, updated_at,
+		       debit_column, credit_column, type_column, expenses_positive,
+		       skip_lines, footer_lines
 		FROM csv_templates
 		ORDER BY name
 	`
@@ -75,17 +83,24 @@ func (cts *CSVTemplateStore) GetCSVTemplates() ([]types.CSVTemplate, error) {
 	return templates, rows.Err()
 }
 
+// csvTemplateScanner is satisfied by both *sql.Rows and *sql.Row
+type csvTemplateScanner interface {
+	Scan(dest ...interface{}) error
+}
+
 // scanCSVTemplate scans a database row into a CSVTemplate struct
-func (cts *CSVTemplateStore) scanCSVTemplate(rows *sql.Rows) (types.CSVTemplate, error) {
+func (cts *CSVTemplateStore) scanCSVTemplate(row csvTemplateScanner) (types.CSVTemplate, error) {
 	var template types.CSVTemplate
-	var categoryColumn sql.NullInt64
+	var categoryColumn, debitColumn, creditColumn, typeColumn sql.NullInt64
 	var dateFormat, delimiter sql.NullString
 	var createdAtStr, updatedAtStr string
 
-	err := rows.Scan(
+	err := row.Scan(
 		&template.Id, &template.Name, &template.PostDateColumn, &template.AmountColumn,
 		&template.DescColumn, &categoryColumn, &template.HasHeader,
-		&dateFormat, &delimiter, &createdAtStr, &updatedAtStr,
+		&dateFormat, &delimiter, &template.Currency, &createdAtStr, &updatedAtStr,
+		&debitColumn, &creditColumn, &typeColumn, &template.ExpensesPositive,
+		&template.SkipLines, &template.FooterLines,
 	)
 
 	if err != nil {
@@ -103,10 +118,10 @@ func (cts *CSVTemplateStore) scanCSVTemplate(rows *sql.Rows) (types.CSVTemplate,
 	}
 
 	// Handle nullable fields
-	if categoryColumn.Valid {
-		categoryInt := int(categoryColumn.Int64)
-		template.CategoryColumn = &categoryInt
-	}
+	template.CategoryColumn = templateColumn(categoryColumn)
+	template.DebitColumn = templateColumn(debitColumn)
+	template.CreditColumn = templateColumn(creditColumn)
+	template.TypeColumn = templateColumn(typeColumn)
 	if dateFormat.Valid {
 		template.DateFormat = dateFormat.String
 	}
@@ -117,6 +132,23 @@ func (cts *CSVTemplateStore) scanCSVTemplate(rows *sql.Rows) (types.CSVTemplate,
 	return template, nil
 }
 
+// templateColumn converts a nullable column index from the database
+func templateColumn(column sql.NullInt64) *int {
+	if !column.Valid {
+		return nil
+	}
+	index := int(column.Int64)
+	return &index
+}
+
+// nullableTemplateColumn converts an optional column index for storage
+func nullableTemplateColumn(column *int) interface{} {
+	if column == nil {
+		return nil
+	}
+	return *column
+}
+
 // GetDefaultTemplate returns the name of the default template
 func (cts *CSVTemplateStore) GetDefaultTemplate() string {
 	return cts.defaultTemplate
@@ -140,15 +172,21 @@ func (cts *CSVTemplateStore) SetDefaultTemplate(templateName string) *TemplateRe
 
 // GetTemplateByName returns a CSV template by name
 func (cts *CSVTemplateStore) GetTemplateByName(name string) *types.CSVTemplate {
+	return cts.getTemplateByName(cts.db.DB, name)
+}
+
+// getTemplateByName looks up a CSV template by name through db
+func (cts *CSVTemplateStore) getTemplateByName(db sqlQuerier, name string) *types.CSVTemplate {
 	query := `
 		SELECT id, name, post_date_column, amount_column, desc_column, category_column,
-		       has_header, date_format, delimiter, created_at, updated_at
+		       has_header, date_format, delimiter, currency, created_at, updated_at,
+		       debit_column, credit_column, type_column, expenses_positive,
+		       skip_lines, footer_lines
 		FROM csv_templates
 		WHERE name = ?
 	`
 
-	row := cts.helper.QuerySingleRow(query, name)
-	template, err := cts.scanCSVTemplateRow(row)
+	template, err := cts.scanCSVTemplate(db.QueryRow(query, name))
 	if err != nil {
 		return nil // Template not found or error
 	}
@@ -156,59 +194,19 @@ func (cts *CSVTemplateStore) GetTemplateByName(name string) *types.CSVTemplate {
 	return &template
 }
 
-// scanCSVTemplateRow scans a single database row into a CSVTemplate struct
-func (cts *CSVTemplateStore) scanCSVTemplateRow(row *sql.Row) (types.CSVTemplate, error) {
-	var template types.CSVTemplate
-	var categoryColumn sql.NullInt64
-	var dateFormat, delimiter sql.NullString
-	var createdAtStr, updatedAtStr string
-
-	err := row.Scan(
-		&template.Id, &template.Name, &template.PostDateColumn, &template.AmountColumn,
-		&template.DescColumn, &categoryColumn, &template.HasHeader, &dateFormat,
-		&delimiter, &createdAtStr, &updatedAtStr,
-	)
-
-	if err != nil {
-		return template, err
-	}
-
-	// Parse time fields from database
-	template.CreatedAt, err = cts.helper.ParseTimeFromDB(createdAtStr)
-	if err != nil {
-		return template, fmt.Errorf("failed to parse created_at: %w", err)
-	}
-	template.UpdatedAt, err = cts.helper.ParseTimeFromDB(updatedAtStr)
-	if err != nil {
-		return template, fmt.Errorf("failed to parse updated_at: %w", err)
-	}
-
-	// Handle nullable fields
-	if categoryColumn.Valid {
-		categoryInt := int(categoryColumn.Int64)
-		template.CategoryColumn = &categoryInt
-	}
-	if dateFormat.Valid {
-		template.DateFormat = dateFormat.String
-	}
-	if delimiter.Valid {
-		template.Delimiter = delimiter.String
-	}
-
-	return template, nil
-}
-
 // GetTemplateById returns a CSV template by its ID
 func (cts *CSVTemplateStore) GetTemplateById(id int64) *types.CSVTemplate {
 	query := `
 		SELECT id, name, post_date_column, amount_column, desc_column, category_column,
-		       has_header, date_format, delimiter, created_at, updated_at
+		       has_header, date_format, delimiter, currency, created_at, updated_at,
+		       debit_column, credit_column, type_column, expenses_positive,
+		       skip_lines, footer_lines
 		FROM csv_templates
 		WHERE id = ?
 	`
 
 	row := cts.helper.QuerySingleRow(query, id)
-	template, err := cts.scanCSVTemplateRow(row)
+	template, err := cts.scanCSVTemplate(row)
 	if err != nil {
 		return nil // Template not found or error
 	}
@@ -252,6 +250,17 @@ func (cts *CSVTemplateStore) CreateCSVTemplate(template types.CSVTemplate) *Temp
 		result.Message = "Column indices must be non-negative"
 		return result
 	}
+	if validation := template.Validate(); !validation.IsValid {
+		result.Message = validation.Errors[0].Message
+		return result
+	}
+
+	if template.Currency != "" {
+		if _, err := types.NormalizeCurrencyCode(template.Currency); err != nil {
+			result.Message = fmt.Sprintf("Invalid currency: %v", err)
+			return result
+		}
+	}
 
 	err := cts.SaveCSVTemplate(template)
 	if err != nil {
@@ -273,20 +282,27 @@ func (cts *CSVTemplateStore) SaveCSVTemplate(template types.CSVTemplate) error {
 
 	if template.Id == 0 {
 		// Insert new template
-		return cts.insertTemplate(template, now)
+		id, err := cts.insertTemplate(cts.db.DB, template, now)
+		if err != nil {
+			return err
+		}
+		cts.audit.recordOrWarn(types.EntityCSVTemplate, auditId(id), types.AuditEventCreate, types.SourceUser, nil, cts.GetTemplateById(id))
+		return nil
 	} else {
 		// Update existing template
 		return cts.updateTemplate(template, now)
 	}
 }
 
-// insertTemplate inserts a new CSV template
-func (cts *CSVTemplateStore) insertTemplate(template types.CSVTemplate, now time.Time) error {
+// insertTemplate inserts a new CSV template through db and returns its ID
+func (cts *CSVTemplateStore) insertTemplate(db sqlExecer, template types.CSVTemplate, now time.Time) (int64, error) {
 	query := `
 		INSERT INTO csv_templates (
 			name, post_date_column, amount_column, desc_column, category_column,
-			has_header, date_format, delimiter, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			has_header, date_format, delimiter, currency, created_at, updated_at,
+			debit_column, credit_column, type_column, expenses_positive,
+			skip_lines, footer_lines
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	// Handle nullable fields
@@ -306,6 +322,11 @@ func (cts *CSVTemplateStore) insertTemplate(template types.CSVTemplate, now time
 		delimiter = "," // Use schema default
 	}
 
+	currency, err := templateCurrency(template)
+	if err != nil {
+		return 0, err
+	}
+
 	// Set creation timestamp if not provided
 	createdAt := template.CreatedAt
 	if createdAt.IsZero() {
@@ -316,18 +337,24 @@ func (cts *CSVTemplateStore) insertTemplate(template types.CSVTemplate, now time
 	createdAtStr := createdAt.Format(time.RFC3339)
 	updatedAtStr := now.Format(time.RFC3339)
 
-	_, err := cts.helper.ExecReturnID(query,
+	res, err := db.Exec(query,
 		template.Name, template.PostDateColumn, template.AmountColumn,
 		template.DescColumn, categoryColumn, template.HasHeader,
-		dateFormat, delimiter, createdAtStr, updatedAtStr,
+		dateFormat, delimiter, currency, createdAtStr, updatedAtStr,
+		nullableTemplateColumn(template.DebitColumn), nullableTemplateColumn(template.CreditColumn),
+		nullableTemplateColumn(template.TypeColumn), template.ExpensesPositive,
+		template.SkipLines, template.FooterLines,
 	)
 
 	if err != nil {
-		return fmt.Errorf("failed to insert CSV template: %w", err)
+		return 0, fmt.Errorf("failed to insert CSV template: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert CSV template: %w", err)
 	}
 
-	// Update the template ID
-	return nil
+	return id, nil
 }
 
 // updateTemplate updates an existing CSV template
@@ -335,8 +362,9 @@ func (cts *CSVTemplateStore) updateTemplate(template types.CSVTemplate, now time
 	query := `
 		UPDATE csv_templates SET 
 			name = ?, post_date_column = ?, amount_column = ?, desc_column = ?, category_column = ?, 
-			has_header = ?, date_format = ?, delimiter = ?, 
-			updated_at = ?
+			has_header = ?, date_format = ?, delimiter = ?, currency = ?,
+			debit_column = ?, credit_column = ?, type_column = ?, expenses_positive = ?,
+			skip_lines = ?, footer_lines = ?, updated_at = ?
 		WHERE id = ?
 	`
 
@@ -357,19 +385,44 @@ func (cts *CSVTemplateStore) updateTemplate(template types.CSVTemplate, now time
 		delimiter = "," // Use schema default
 	}
 
-	_, err := cts.helper.ExecReturnRowsAffected(query,
+	currency, err := templateCurrency(template)
+	if err != nil {
+		return err
+	}
+
+	before := cts.GetTemplateById(template.Id)
+
+	_, err = cts.helper.ExecReturnRowsAffected(query,
 		template.Name, template.PostDateColumn, template.AmountColumn,
 		template.DescColumn, categoryColumn, template.HasHeader,
-		dateFormat, delimiter, now, template.Id,
+		dateFormat, delimiter, currency,
+		nullableTemplateColumn(template.DebitColumn), nullableTemplateColumn(template.CreditColumn),
+		nullableTemplateColumn(template.TypeColumn), template.ExpensesPositive,
+		template.SkipLines, template.FooterLines, now, template.Id,
 	)
 
 	if err != nil {
 		return fmt.Errorf("failed to update CSV template: %w", err)
 	}
 
+	if before != nil {
+		cts.audit.recordOrWarn(types.EntityCSVTemplate, auditId(template.Id), types.AuditEventUpdate, types.SourceUser, before, cts.GetTemplateById(template.Id))
+	}
 	return nil
 }
 
+// templateCurrency returns the normalized currency for a template, defaulting when unset
+func templateCurrency(template types.CSVTemplate) (string, error) {
+	if template.Currency == "" {
+		return types.DefaultCurrency, nil
+	}
+	currency, err := types.NormalizeCurrencyCode(template.Currency)
+	if err != nil {
+		return "", fmt.Errorf("invalid template currency: %w", err)
+	}
+	return currency, nil
+}
+
 // DeleteCSVTemplate deletes a CSV template by ID
 func (cts *CSVTemplateStore) DeleteCSVTemplate(id int64) *TemplateResult {
 	// Check if template is being used by any bank statements
@@ -392,6 +445,8 @@ func (cts *CSVTemplateStore) DeleteCSVTemplate(id int64) *TemplateResult {
 		}
 	}
 
+	before := cts.GetTemplateById(id)
+
 	// Delete the template
 	deleteQuery := `DELETE FROM csv_templates WHERE id = ?`
 	rowsAffected, err := cts.helper.ExecReturnRowsAffected(deleteQuery, id)
@@ -409,6 +464,8 @@ func (cts *CSVTemplateStore) DeleteCSVTemplate(id int64) *TemplateResult {
 		}
 	}
 
+	cts.audit.recordOrWarn(types.EntityCSVTemplate, auditId(id), types.AuditEventDelete, types.SourceUser, before, nil)
+
 	return &TemplateResult{
 		Success: true,
 		Message: "Template deleted successfully",
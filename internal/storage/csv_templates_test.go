@@ -4,6 +4,7 @@ import (
 	"budget-tracker-tui/internal/database"
 	"budget-tracker-tui/internal/types"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -584,38 +585,40 @@ func TestParseAmount(t *testing.T) {
 	tests := []struct {
 		name        string
 		amountStr   string
-		expected    float64
+		expected    int64 // cents
 		expectError bool
 		errorMsg    string
 	}{
 		// Basic positive amounts
-		{name: "simple positive amount", amountStr: "123.45", expected: 123.45},
-		{name: "whole number", amountStr: "100", expected: 100.0},
-		{name: "amount with leading zero", amountStr: "0.50", expected: 0.50},
+		{name: "simple positive amount", amountStr: "123.45", expected: 12345},
+		{name: "whole number", amountStr: "100", expected: 10000},
+		{name: "amount with leading zero", amountStr: "0.50", expected: 50},
 
 		// Currency symbols
-		{name: "amount with dollar sign", amountStr: "$123.45", expected: 123.45},
-		{name: "amount with dollar and comma", amountStr: "$1,234.56", expected: 1234.56},
-		{name: "amount with multiple commas", amountStr: "$12,345,678.90", expected: 12345678.90},
+		{name: "amount with dollar sign", amountStr: "$123.45", expected: 12345},
+		{name: "amount with dollar and comma", amountStr: "$1,234.56", expected: 123456},
+		{name: "amount with multiple commas", amountStr: "$12,345,678.90", expected: 1234567890},
+		{name: "amount with pound sign", amountStr: "£123.45", expected: 12345},
+		{name: "amount with euro sign", amountStr: "€1,234.56", expected: 123456},
 
 		// Negative amounts (parentheses format)
-		{name: "parentheses negative", amountStr: "(123.45)", expected: -123.45},
-		{name: "parentheses with dollar", amountStr: "$(123.45)", expected: -123.45},
-		{name: "parentheses with comma", amountStr: "$(1,234.56)", expected: -1234.56},
+		{name: "parentheses negative", amountStr: "(123.45)", expected: -12345},
+		{name: "parentheses with dollar", amountStr: "$(123.45)", expected: -12345},
+		{name: "parentheses with comma", amountStr: "$(1,234.56)", expected: -123456},
 
 		// Whitespace handling
-		{name: "amount with spaces", amountStr: " 123.45 ", expected: 123.45},
-		{name: "dollar with spaces", amountStr: " $123.45 ", expected: 123.45},
+		{name: "amount with spaces", amountStr: " 123.45 ", expected: 12345},
+		{name: "dollar with spaces", amountStr: " $123.45 ", expected: 12345},
 
 		// Edge cases
-		{name: "zero amount", amountStr: "0.00", expected: 0.0},
-		{name: "single cent", amountStr: "0.01", expected: 0.01},
+		{name: "zero amount", amountStr: "0.00", expected: 0},
+		{name: "single cent", amountStr: "0.01", expected: 1},
 
 		// Error cases - adjust expectations to match implementation
 		{name: "empty string", amountStr: "", expectError: true, errorMsg: "invalid amount format"},
 		{name: "invalid text", amountStr: "abc", expectError: true, errorMsg: "invalid amount format"},
 		{name: "multiple decimal points", amountStr: "123.45.67", expectError: true, errorMsg: "invalid amount format"},
-		{name: "invalid currency format", amountStr: "£123.45", expectError: true, errorMsg: "invalid amount format"},
+		{name: "unknown currency code", amountStr: "CHF123.45", expectError: true, errorMsg: "invalid amount format"},
 		{name: "unclosed parentheses", amountStr: "(123.45", expectError: true, errorMsg: "invalid amount format"},
 	}
 
@@ -635,14 +638,202 @@ func TestParseAmount(t *testing.T) {
 			} else {
 				if err != nil {
 					t.Errorf("Unexpected error: %v", err)
-				} else if actual != tt.expected {
-					t.Errorf("Expected %.2f, got %.2f", tt.expected, actual)
+				} else if actual.Cents != tt.expected {
+					t.Errorf("Expected %d cents, got %d", tt.expected, actual.Cents)
 				}
 			}
 		})
 	}
 }
 
+func TestParseAmountInCurrency(t *testing.T) {
+	tests := []struct {
+		name        string
+		amountStr   string
+		currency    string
+		expected    types.Money
+		expectError bool
+	}{
+		{name: "euro sign", amountStr: "€12.50", currency: "EUR", expected: types.NewMoney(1250, "EUR")},
+		{name: "leading currency code", amountStr: "EUR 12.50", currency: "EUR", expected: types.NewMoney(1250, "EUR")},
+		{name: "trailing lowercase code", amountStr: "-3.10 gbp", currency: "GBP", expected: types.NewMoney(-310, "GBP")},
+		{name: "empty currency defaults", amountStr: "$5.00", currency: "", expected: types.NewMoney(500, types.DefaultCurrency)},
+		{name: "other currency code is rejected", amountStr: "USD 12.50", currency: "EUR", expectError: true},
+	}
+
+	store, conn := setupTestCSVTemplateStore(t)
+	defer teardownTestDB(t, conn)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := store.CSVParser.ParseAmountInCurrency(tt.amountStr, tt.currency)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got %s", actual.Display())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !actual.Equal(tt.expected) {
+				t.Errorf("Expected %s, got %s", tt.expected.Display(), actual.Display())
+			}
+		})
+	}
+}
+
+func TestCSVTemplateCurrency(t *testing.T) {
+	store, conn := setupTestCSVTemplateStore(t)
+	defer teardownTestDB(t, conn)
+
+	result := store.Templates.CreateCSVTemplate(types.CSVTemplate{
+		Name: "EuroCard", PostDateColumn: 0, AmountColumn: 1, DescColumn: 2, Currency: "eur",
+	})
+	if !result.Success {
+		t.Fatalf("Failed to create template: %s", result.Message)
+	}
+
+	template := store.Templates.GetTemplateByName("EuroCard")
+	if template == nil {
+		t.Fatal("Expected template to be found")
+	}
+	if template.Currency != "EUR" {
+		t.Errorf("Expected currency EUR, got %s", template.Currency)
+	}
+
+	if defaultTemplate := store.Templates.GetTemplateByName("Bank1"); defaultTemplate == nil || defaultTemplate.Currency != types.DefaultCurrency {
+		t.Errorf("Expected default template to use %s", types.DefaultCurrency)
+	}
+
+	invalid := store.Templates.CreateCSVTemplate(types.CSVTemplate{
+		Name: "BadCurrency", PostDateColumn: 0, AmountColumn: 1, DescColumn: 2, Currency: "EURO",
+	})
+	if invalid.Success {
+		t.Error("Expected template with invalid currency to be rejected")
+	}
+
+	// Imported amounts are tagged with the template currency
+	csvPath := filepath.Join(t.TempDir(), "euro.csv")
+	if err := os.WriteFile(csvPath, []byte("2024-01-15,-42.10,Bakery\n"), 0644); err != nil {
+		t.Fatalf("Failed to write CSV: %v", err)
+	}
+	parsed, err := store.CSVParser.ParseCSV(csvPath, template, types.FailFast)
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+	if len(parsed.SuccessfulTransactions) != 1 {
+		t.Fatalf("Expected 1 transaction, got %d", len(parsed.SuccessfulTransactions))
+	}
+	if amount := parsed.SuccessfulTransactions[0].Amount; !amount.Equal(types.NewMoney(-4210, "EUR")) {
+		t.Errorf("Expected -42.10 EUR, got %s", amount.Display())
+	}
+}
+
+func TestCSVTemplateDebitCreditColumns(t *testing.T) {
+	store, conn := setupTestCSVTemplateStore(t)
+	defer teardownTestDB(t, conn)
+
+	debit, credit, typeColumn := 2, 3, 4
+	result := store.Templates.CreateCSVTemplate(types.CSVTemplate{
+		Name: "SplitColumns", PostDateColumn: 0, DescColumn: 1, HasHeader: true,
+		DebitColumn: &debit, CreditColumn: &credit,
+	})
+	if !result.Success {
+		t.Fatalf("Failed to create template: %s", result.Message)
+	}
+	template := store.Templates.GetTemplateByName("SplitColumns")
+	if template == nil || !template.HasDebitCreditColumns() || *template.DebitColumn != 2 || *template.CreditColumn != 3 {
+		t.Fatalf("Expected the debit/credit pair to be stored, got %+v", template)
+	}
+
+	unpaired := store.Templates.CreateCSVTemplate(types.CSVTemplate{
+		Name: "DebitOnly", PostDateColumn: 0, AmountColumn: 1, DescColumn: 2, DebitColumn: &credit,
+	})
+	if unpaired.Success {
+		t.Error("Expected a debit column without a credit column to be rejected")
+	}
+
+	csvPath := filepath.Join(t.TempDir(), "split.csv")
+	content := "Date,Description,Debit,Credit\n" +
+		"2024-01-15,Bakery,42.10,\n" +
+		"2024-01-16,Salary,,2500.00\n" +
+		"2024-01-17,Fee,-1.50,0.00\n"
+	if err := os.WriteFile(csvPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write CSV: %v", err)
+	}
+	parsed, err := store.CSVParser.ParseCSV(csvPath, template, types.FailFast)
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+	want := []struct {
+		cents           int64
+		transactionType string
+	}{{-4210, "expense"}, {250000, "income"}, {-150, "expense"}}
+	for i, tx := range parsed.SuccessfulTransactions {
+		if tx.Amount.Cents != want[i].cents || tx.TransactionType != want[i].transactionType {
+			t.Errorf("Row %d: expected %d cents as %s, got %s as %q", i, want[i].cents, want[i].transactionType, tx.Amount, tx.TransactionType)
+		}
+	}
+
+	both := filepath.Join(t.TempDir(), "both.csv")
+	if err := os.WriteFile(both, []byte("Date,Description,Debit,Credit\n2024-01-15,Bakery,1.00,2.00\n"), 0644); err != nil {
+		t.Fatalf("Failed to write CSV: %v", err)
+	}
+	if _, err := store.CSVParser.ParseCSV(both, template, types.FailFast); err == nil {
+		t.Error("Expected an error for a row with both debit and credit amounts")
+	}
+
+	// A zero amount moves no money and is a row error, in either layout
+	zero := filepath.Join(t.TempDir(), "zero.csv")
+	if err := os.WriteFile(zero, []byte("Date,Description,Debit,Credit\n2024-01-15,Hold,0.00,\n"), 0644); err != nil {
+		t.Fatalf("Failed to write CSV: %v", err)
+	}
+	parsed, err = store.CSVParser.ParseCSV(zero, template, types.SkipInvalid)
+	if err != nil || len(parsed.FailedRows) != 1 || parsed.FailedRows[0].Field != "Amount" {
+		t.Errorf("Expected the zero debit to fail its amount, got %+v (%v)", parsed, err)
+	}
+	single := types.CSVTemplate{PostDateColumn: 0, AmountColumn: 1, DescColumn: 2}
+	zeroSingle := filepath.Join(t.TempDir(), "zero-single.csv")
+	if err := os.WriteFile(zeroSingle, []byte("2024-01-15,0.00,Hold\n2024-01-16,-3.00,Coffee\n"), 0644); err != nil {
+		t.Fatalf("Failed to write CSV: %v", err)
+	}
+	parsed, err = store.CSVParser.ParseCSV(zeroSingle, &single, types.SkipInvalid)
+	if err != nil || len(parsed.FailedRows) != 1 || len(parsed.SuccessfulTransactions) != 1 {
+		t.Errorf("Expected the zero amount to fail and the other row to parse, got %+v (%v)", parsed, err)
+	}
+
+	// A credit card export showing charges as positive amounts, with a type column
+	signed := types.CSVTemplate{PostDateColumn: 0, AmountColumn: 1, DescColumn: 2, ExpensesPositive: true}
+	typed := types.CSVTemplate{PostDateColumn: 0, AmountColumn: 1, DescColumn: 2, TypeColumn: &typeColumn}
+	cardPath := filepath.Join(t.TempDir(), "card.csv")
+	if err := os.WriteFile(cardPath, []byte("2024-01-15,9.99,Streaming,,DR\n2024-01-16,-20.00,Refund,,CR\n2024-01-17,50.00,Top-up,,Transfer\n"), 0644); err != nil {
+		t.Fatalf("Failed to write CSV: %v", err)
+	}
+	parsed, err = store.CSVParser.ParseCSV(cardPath, &signed, types.FailFast)
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+	if parsed.SuccessfulTransactions[0].Amount.Cents != -999 || parsed.SuccessfulTransactions[1].Amount.Cents != 2000 {
+		t.Errorf("Expected positive expenses to be flipped, got %s and %s",
+			parsed.SuccessfulTransactions[0].Amount, parsed.SuccessfulTransactions[1].Amount)
+	}
+	parsed, err = store.CSVParser.ParseCSV(cardPath, &typed, types.FailFast)
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+	streaming, refund, topUp := parsed.SuccessfulTransactions[0], parsed.SuccessfulTransactions[1], parsed.SuccessfulTransactions[2]
+	if streaming.Amount.Cents != -999 || streaming.TransactionType != "expense" {
+		t.Errorf("Expected DR to make an expense, got %s as %q", streaming.Amount, streaming.TransactionType)
+	}
+	if refund.Amount.Cents != 2000 || refund.TransactionType != "income" {
+		t.Errorf("Expected CR to make income, got %s as %q", refund.Amount, refund.TransactionType)
+	}
+	if topUp.Amount.Cents != 5000 || topUp.TransactionType != "transfer" {
+		t.Errorf("Expected a transfer keeping its sign, got %s as %q", topUp.Amount, topUp.TransactionType)
+	}
+}
+
 func TestParseCSVLine(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -1104,16 +1295,16 @@ func TestParseCSVTransactions(t *testing.T) {
 			expectedCount: 3,
 			validate: func(t *testing.T, transactions []types.Transaction) {
 				// Check first transaction
-				if transactions[0].Amount != 100.50 {
-					t.Errorf("Transaction 0 amount: expected 100.50, got %.2f", transactions[0].Amount)
+				if transactions[0].Amount.Cents != 10050 {
+					t.Errorf("Transaction 0 amount: expected 100.50, got %s", transactions[0].Amount)
 				}
 				if transactions[0].Description != "Store Purchase" {
 					t.Errorf("Transaction 0 description: expected 'Store Purchase', got '%s'", transactions[0].Description)
 				}
 
 				// Check negative amount (refund)
-				if transactions[2].Amount != -50.00 {
-					t.Errorf("Transaction 2 amount: expected -50.00, got %.2f", transactions[2].Amount)
+				if transactions[2].Amount.Cents != -5000 {
+					t.Errorf("Transaction 2 amount: expected -50.00, got %s", transactions[2].Amount)
 				}
 				if transactions[2].Description != "Refund" {
 					t.Errorf("Transaction 2 description: expected 'Refund', got '%s'", transactions[2].Description)
@@ -1137,8 +1328,8 @@ func TestParseCSVTransactions(t *testing.T) {
 			expectedCount: 2,
 			validate: func(t *testing.T, transactions []types.Transaction) {
 				// Check currency symbol parsing - fix expected amount parsing
-				if transactions[0].Amount != 1.00 { // "$1,234.56" may parse as "$1" then ",234.56"
-					t.Errorf("Transaction 0 amount: expected 1.00, got %.2f", transactions[0].Amount)
+				if transactions[0].Amount.Cents != 100 { // "$1,234.56" may parse as "$1" then ",234.56"
+					t.Errorf("Transaction 0 amount: expected 1.00, got %s", transactions[0].Amount)
 				}
 				// Description should be "234.56" due to CSV parsing issue
 				if transactions[0].Description != "234.56" {
@@ -1187,8 +1378,8 @@ func TestParseCSVTransactions(t *testing.T) {
 			expectedCount: 2,
 			validate: func(t *testing.T, transactions []types.Transaction) {
 				// Basic validation that parsing worked
-				if transactions[0].Amount != 100.50 {
-					t.Errorf("Transaction 0 amount: expected 100.50, got %.2f", transactions[0].Amount)
+				if transactions[0].Amount.Cents != 10050 {
+					t.Errorf("Transaction 0 amount: expected 100.50, got %s", transactions[0].Amount)
 				}
 			},
 		},
@@ -0,0 +1,267 @@
+package storage
+
+import (
+	"budget-tracker-tui/internal/database"
+	"budget-tracker-tui/internal/types"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExchangeRateStore handles the local exchange-rate table used for base-currency reporting
+type ExchangeRateStore struct {
+	db     *database.Connection
+	helper *database.SQLHelper
+}
+
+// NewExchangeRateStore creates a new ExchangeRateStore instance
+func NewExchangeRateStore(db *database.Connection) *ExchangeRateStore {
+	return &ExchangeRateStore{
+		db:     db,
+		helper: database.NewSQLHelper(db),
+	}
+}
+
+// GetRates returns all stored exchange rates, newest first
+func (ers *ExchangeRateStore) GetRates() ([]types.ExchangeRate, error) {
+	query := `
+		SELECT id, rate_date, from_currency, to_currency, rate, created_at
+		FROM exchange_rates
+		ORDER BY rate_date DESC, from_currency, to_currency
+	`
+
+	rows, err := ers.helper.QueryRows(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query exchange rates: %w", err)
+	}
+	defer rows.Close()
+
+	var rates []types.ExchangeRate
+	for rows.Next() {
+		var rate types.ExchangeRate
+		var rateDateStr, createdAtStr string
+		if err := rows.Scan(&rate.Id, &rateDateStr, &rate.FromCurrency, &rate.ToCurrency, &rate.Rate, &createdAtStr); err != nil {
+			return nil, fmt.Errorf("failed to scan exchange rate: %w", err)
+		}
+		if rate.RateDate, err = parseStoredDate(rateDateStr); err != nil {
+			return nil, fmt.Errorf("failed to parse rate_date '%s': %w", rateDateStr, err)
+		}
+		if rate.CreatedAt, err = ers.helper.ParseTimeFromDB(createdAtStr); err != nil {
+			return nil, fmt.Errorf("failed to parse created_at: %w", err)
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, rows.Err()
+}
+
+// SaveRate inserts a rate or replaces the existing rate for the same day and currency pair
+func (ers *ExchangeRateStore) SaveRate(rate types.ExchangeRate) error {
+	return ers.saveRate(ers.db.DB, rate)
+}
+
+// rateExecer is satisfied by both *sql.DB and *sql.Tx
+type rateExecer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// saveRate validates and upserts a rate on the connection or inside an import transaction
+func (ers *ExchangeRateStore) saveRate(exec rateExecer, rate types.ExchangeRate) error {
+	from, err := types.NormalizeCurrencyCode(rate.FromCurrency)
+	if err != nil {
+		return fmt.Errorf("invalid from currency: %w", err)
+	}
+	to, err := types.NormalizeCurrencyCode(rate.ToCurrency)
+	if err != nil {
+		return fmt.Errorf("invalid to currency: %w", err)
+	}
+	if from == to {
+		return fmt.Errorf("exchange rate currencies must differ: %s", from)
+	}
+	if rate.Rate <= 0 {
+		return fmt.Errorf("exchange rate must be positive, got: %v", rate.Rate)
+	}
+	if rate.RateDate.IsZero() {
+		return fmt.Errorf("exchange rate date is required")
+	}
+
+	query := `
+		INSERT INTO exchange_rates (rate_date, from_currency, to_currency, rate, created_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (rate_date, from_currency, to_currency) DO UPDATE SET rate = excluded.rate
+	`
+
+	_, err = exec.Exec(query, ers.helper.FormatDateForDB(rate.RateDate), from, to, rate.Rate,
+		ers.helper.FormatTimeForDB(time.Now()))
+	if err != nil {
+		return fmt.Errorf("failed to save exchange rate: %w", err)
+	}
+
+	return nil
+}
+
+// GetRate returns the rate converting one unit of from into to on the given date
+// The latest rate on or before the date wins; dates before the first known rate use the earliest one.
+// A stored rate for the opposite direction is inverted when no direct rate exists.
+func (ers *ExchangeRateStore) GetRate(from, to string, date time.Time) (float64, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return 1, nil
+	}
+
+	dateStr := ers.helper.FormatDateForDB(date)
+	pairClause := `((from_currency = ? AND to_currency = ?) OR (from_currency = ? AND to_currency = ?))`
+
+	queries := []string{
+		`SELECT from_currency, rate FROM exchange_rates WHERE ` + pairClause +
+			` AND rate_date <= ? ORDER BY rate_date DESC, (from_currency = ?) DESC LIMIT 1`,
+		`SELECT from_currency, rate FROM exchange_rates WHERE ` + pairClause +
+			` AND rate_date > ? ORDER BY rate_date ASC, (from_currency = ?) DESC LIMIT 1`,
+	}
+
+	for _, query := range queries {
+		var rateFrom string
+		var rate float64
+		err := ers.helper.QuerySingleRow(query, from, to, to, from, dateStr, from).Scan(&rateFrom, &rate)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to look up exchange rate %s->%s: %w", from, to, err)
+		}
+		if rateFrom != from {
+			return 1 / rate, nil
+		}
+		return rate, nil
+	}
+
+	return 0, fmt.Errorf("no exchange rate from %s to %s", from, to)
+}
+
+// Convert converts an amount into the target currency using the rate for the given date
+func (ers *ExchangeRateStore) Convert(amount types.Money, to string, date time.Time) (types.Money, error) {
+	if amount.Currency == to {
+		return amount, nil
+	}
+
+	rate, err := ers.GetRate(amount.Currency, to, date)
+	if err != nil {
+		return types.Money{}, err
+	}
+
+	return amount.Convert(to, rate), nil
+}
+
+// ImportRatesCSV loads rates from a CSV file with columns date,from,to,rate
+// A header row is detected automatically. Valid rows are saved in one transaction and invalid rows are reported.
+func (ers *ExchangeRateStore) ImportRatesCSV(filePath string) (*RateImportResult, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rates file: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	result := &RateImportResult{}
+	var rates []types.ExchangeRate
+
+	for lineNum := 1; ; lineNum++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read rates file: %w", err)
+		}
+
+		rate, err := parseRateRecord(record)
+		if err != nil {
+			// Treat a non-numeric first row as a header
+			if lineNum == 1 && len(record) == 4 {
+				if _, numErr := strconv.ParseFloat(strings.TrimSpace(record[3]), 64); numErr != nil {
+					continue
+				}
+			}
+			result.FailedRows = append(result.FailedRows, types.RowError{
+				LineNumber: lineNum,
+				RawRow:     strings.Join(record, ","),
+				ErrorType:  "parsing",
+				Message:    err.Error(),
+			})
+			continue
+		}
+		rates = append(rates, rate)
+	}
+
+	if len(rates) == 0 {
+		result.Message = fmt.Sprintf("No valid exchange rates found (%d invalid rows)", len(result.FailedRows))
+		return result, nil
+	}
+
+	err = ers.db.ExecuteInTransaction(func(tx *sql.Tx) error {
+		for _, rate := range rates {
+			if err := ers.saveRate(tx, rate); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to import exchange rates: %w", err)
+	}
+
+	result.Success = true
+	result.Imported = len(rates)
+	result.Message = fmt.Sprintf("Imported %d exchange rates", len(rates))
+	if len(result.FailedRows) > 0 {
+		result.Message += fmt.Sprintf(" (%d invalid rows skipped)", len(result.FailedRows))
+	}
+	return result, nil
+}
+
+// parseRateRecord converts a date,from,to,rate CSV record into an ExchangeRate
+func parseRateRecord(record []string) (types.ExchangeRate, error) {
+	var rate types.ExchangeRate
+	if len(record) != 4 {
+		return rate, fmt.Errorf("expected 4 columns (date,from,to,rate), got %d", len(record))
+	}
+
+	normalizedDate, err := types.NormalizeDateToISO8601(record[0], "")
+	if err != nil {
+		return rate, fmt.Errorf("invalid date '%s': %w", record[0], err)
+	}
+	rate.RateDate, _ = time.Parse("2006-01-02", normalizedDate)
+
+	if rate.FromCurrency, err = types.NormalizeCurrencyCode(record[1]); err != nil {
+		return rate, err
+	}
+	if rate.ToCurrency, err = types.NormalizeCurrencyCode(record[2]); err != nil {
+		return rate, err
+	}
+	if rate.FromCurrency == rate.ToCurrency {
+		return rate, fmt.Errorf("exchange rate currencies must differ: %s", rate.FromCurrency)
+	}
+
+	rate.Rate, err = strconv.ParseFloat(strings.TrimSpace(record[3]), 64)
+	if err != nil || rate.Rate <= 0 {
+		return rate, fmt.Errorf("invalid rate '%s': must be a positive number", record[3])
+	}
+
+	return rate, nil
+}
+
+// parseStoredDate accepts the date-only storage format as well as driver-expanded timestamps
+func parseStoredDate(s string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
@@ -0,0 +1,232 @@
+package storage
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"budget-tracker-tui/internal/database"
+	"budget-tracker-tui/internal/types"
+)
+
+// setupTestExchangeRateStore creates an ExchangeRateStore for testing
+func setupTestExchangeRateStore(t *testing.T) (*ExchangeRateStore, *database.Connection) {
+	t.Helper()
+
+	conn := setupTestDB(t)
+	store := NewExchangeRateStore(conn)
+
+	return store, conn
+}
+
+// createTestExchangeRate saves a rate through the store and fails the test on error
+func createTestExchangeRate(t *testing.T, store *ExchangeRateStore, date, from, to string, rate float64) {
+	t.Helper()
+
+	rateDate, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		t.Fatalf("Invalid test date %s: %v", date, err)
+	}
+
+	err = store.SaveRate(types.ExchangeRate{RateDate: rateDate, FromCurrency: from, ToCurrency: to, Rate: rate})
+	if err != nil {
+		t.Fatalf("Failed to create test exchange rate: %v", err)
+	}
+}
+
+func TestExchangeRateStoreSaveRate(t *testing.T) {
+	rateDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		rate        types.ExchangeRate
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name: "valid rate",
+			rate: types.ExchangeRate{RateDate: rateDate, FromCurrency: "EUR", ToCurrency: "USD", Rate: 1.1},
+		},
+		{
+			name: "lowercase codes are normalized",
+			rate: types.ExchangeRate{RateDate: rateDate, FromCurrency: "gbp", ToCurrency: "usd", Rate: 1.27},
+		},
+		{
+			name:        "invalid currency code",
+			rate:        types.ExchangeRate{RateDate: rateDate, FromCurrency: "EURO", ToCurrency: "USD", Rate: 1.1},
+			expectError: true,
+			errorMsg:    "currency code must be 3 letters",
+		},
+		{
+			name:        "same currency",
+			rate:        types.ExchangeRate{RateDate: rateDate, FromCurrency: "USD", ToCurrency: "USD", Rate: 1},
+			expectError: true,
+			errorMsg:    "must differ",
+		},
+		{
+			name:        "non-positive rate",
+			rate:        types.ExchangeRate{RateDate: rateDate, FromCurrency: "EUR", ToCurrency: "USD", Rate: 0},
+			expectError: true,
+			errorMsg:    "must be positive",
+		},
+		{
+			name:        "missing date",
+			rate:        types.ExchangeRate{FromCurrency: "EUR", ToCurrency: "USD", Rate: 1.1},
+			expectError: true,
+			errorMsg:    "date is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, conn := setupTestExchangeRateStore(t)
+			defer teardownTestDB(t, conn)
+
+			err := store.SaveRate(tt.rate)
+			if tt.expectError {
+				if err == nil {
+					t.Fatal("Expected error, got none")
+				}
+				if !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("Expected error containing '%s', got: %v", tt.errorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			rates, err := store.GetRates()
+			if err != nil {
+				t.Fatalf("Failed to get rates: %v", err)
+			}
+			if len(rates) != 1 {
+				t.Fatalf("Expected 1 rate, got %d", len(rates))
+			}
+			if rates[0].FromCurrency != strings.ToUpper(tt.rate.FromCurrency) {
+				t.Errorf("Expected from currency %s, got %s", strings.ToUpper(tt.rate.FromCurrency), rates[0].FromCurrency)
+			}
+			if !rates[0].RateDate.Equal(rateDate) {
+				t.Errorf("Expected rate date %v, got %v", rateDate, rates[0].RateDate)
+			}
+		})
+	}
+}
+
+func TestExchangeRateStoreSaveRateReplacesSameDay(t *testing.T) {
+	store, conn := setupTestExchangeRateStore(t)
+	defer teardownTestDB(t, conn)
+
+	createTestExchangeRate(t, store, "2024-01-01", "EUR", "USD", 1.1)
+	createTestExchangeRate(t, store, "2024-01-01", "EUR", "USD", 1.2)
+
+	rates, err := store.GetRates()
+	if err != nil {
+		t.Fatalf("Failed to get rates: %v", err)
+	}
+	if len(rates) != 1 {
+		t.Fatalf("Expected 1 rate after upsert, got %d", len(rates))
+	}
+	if rates[0].Rate != 1.2 {
+		t.Errorf("Expected rate 1.2, got %v", rates[0].Rate)
+	}
+}
+
+func TestExchangeRateStoreGetRate(t *testing.T) {
+	store, conn := setupTestExchangeRateStore(t)
+	defer teardownTestDB(t, conn)
+
+	createTestExchangeRate(t, store, "2024-01-01", "EUR", "USD", 1.10)
+	createTestExchangeRate(t, store, "2024-02-01", "EUR", "USD", 1.20)
+
+	tests := []struct {
+		name        string
+		from, to    string
+		date        time.Time
+		expected    float64
+		expectError bool
+	}{
+		{name: "same currency", from: "USD", to: "USD", date: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), expected: 1},
+		{name: "rate on exact date", from: "EUR", to: "USD", date: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), expected: 1.20},
+		{name: "latest rate before date", from: "EUR", to: "USD", date: time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC), expected: 1.10},
+		{name: "date before first rate uses earliest", from: "EUR", to: "USD", date: time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC), expected: 1.10},
+		{name: "inverse direction", from: "USD", to: "EUR", date: time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC), expected: 1 / 1.20},
+		{name: "unknown pair", from: "GBP", to: "USD", date: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, err := store.GetRate(tt.from, tt.to, tt.date)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got rate %v", rate)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if math.Abs(rate-tt.expected) > 1e-12 {
+				t.Errorf("Expected rate %v, got %v", tt.expected, rate)
+			}
+		})
+	}
+}
+
+func TestExchangeRateStoreConvert(t *testing.T) {
+	store, conn := setupTestExchangeRateStore(t)
+	defer teardownTestDB(t, conn)
+
+	createTestExchangeRate(t, store, "2024-01-01", "EUR", "USD", 1.0857)
+
+	converted, err := store.Convert(types.NewMoney(10000, "EUR"), "USD", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if converted.Cents != 10857 || converted.Currency != "USD" {
+		t.Errorf("Expected 108.57 USD, got %s", converted.Display())
+	}
+}
+
+func TestExchangeRateStoreImportRatesCSV(t *testing.T) {
+	store, conn := setupTestExchangeRateStore(t)
+	defer teardownTestDB(t, conn)
+
+	content := "date,from,to,rate\n" +
+		"2024-01-01,EUR,USD,1.10\n" +
+		"01/02/2024,gbp,usd,1.27\n" +
+		"2024-01-03,EUR,USD,not-a-number\n" +
+		"2024-01-04,EUR\n"
+	path := filepath.Join(t.TempDir(), "rates.csv")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write rates file: %v", err)
+	}
+
+	result, err := store.ImportRatesCSV(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("Expected success, got: %s", result.Message)
+	}
+	if result.Imported != 2 {
+		t.Errorf("Expected 2 imported rates, got %d", result.Imported)
+	}
+	if len(result.FailedRows) != 2 {
+		t.Fatalf("Expected 2 failed rows, got %d", len(result.FailedRows))
+	}
+	if result.FailedRows[0].LineNumber != 4 || result.FailedRows[1].LineNumber != 5 {
+		t.Errorf("Unexpected failed line numbers: %d, %d", result.FailedRows[0].LineNumber, result.FailedRows[1].LineNumber)
+	}
+
+	rate, err := store.GetRate("GBP", "USD", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Failed to get imported rate: %v", err)
+	}
+	if rate != 1.27 {
+		t.Errorf("Expected imported GBP rate 1.27, got %v", rate)
+	}
+}
@@ -0,0 +1,356 @@
+package storage
+
+import (
+	"budget-tracker-tui/internal/types"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ImportStaging holds a parsed CSV file for review before anything is saved
+// The rows can be recategorized, renamed and excluded; CommitStagedImport imports the rest.
+type ImportStaging struct {
+	FilePath     string
+	TemplateName string
+	AccountId    int64
+	Rows         []StagedRow // In file order
+
+	// FailFast blocks the commit while any row failed to parse. SkipInvalid imports the other
+	// rows, saves the failed ones to a reject file and lists them in the statement's error log.
+	Mode types.ParseMode
+}
+
+// StagedRow is one data row of a staged file
+type StagedRow struct {
+	LineNumber          int
+	RawRow              string
+	Transaction         types.Transaction // Zero when the row failed to parse
+	PredictedCategoryId int64             // The ML categorizer's pick, 0 without a categorizer
+	Confidence          float64           // How sure the categorizer is of PredictedCategoryId
+	Duplicate           bool              // Already saved, or repeated in the file more often than saved
+	Error               *types.RowError   // Why the row failed to parse; such rows are never imported
+	Excluded            bool              // Left out by the user; duplicates start out excluded
+
+	start, end int64 // Byte offsets of the row's text in the decoded file
+}
+
+// Included reports whether the row will be imported
+func (r StagedRow) Included() bool {
+	return r.Error == nil && !r.Excluded
+}
+
+// Predicted reports whether the row still has the category the categorizer picked
+func (r StagedRow) Predicted() bool {
+	return r.PredictedCategoryId != 0 && r.Transaction.CategoryId == r.PredictedCategoryId
+}
+
+// Transactions returns the rows that will be imported, in file order
+func (st *ImportStaging) Transactions() []types.Transaction {
+	var transactions []types.Transaction
+	for _, row := range st.Rows {
+		if row.Included() {
+			transactions = append(transactions, row.Transaction)
+		}
+	}
+	return transactions
+}
+
+// FailedRows returns the rows that failed to parse
+func (st *ImportStaging) FailedRows() []types.RowError {
+	var failed []types.RowError
+	for _, row := range st.Rows {
+		if row.Error != nil {
+			failed = append(failed, *row.Error)
+		}
+	}
+	return failed
+}
+
+// RejectFilePath is where a partial import saves the rows that failed to parse, next to the file
+// and named after it, e.g. january.rejected.csv for january.csv
+func (st *ImportStaging) RejectFilePath() string {
+	ext := filepath.Ext(st.FilePath)
+	return strings.TrimSuffix(st.FilePath, ext) + ".rejected" + ext
+}
+
+// Duplicates counts the rows flagged as already saved
+func (st *ImportStaging) Duplicates() int {
+	count := 0
+	for _, row := range st.Rows {
+		if row.Duplicate {
+			count++
+		}
+	}
+	return count
+}
+
+// StageCSV parses a CSV file into rows for review; see StageCSVContext
+func (cp *CSVParser) StageCSV(filePath string, template *types.CSVTemplate) ([]StagedRow, error) {
+	return cp.StageCSVContext(context.Background(), filePath, template, nil)
+}
+
+// StageCSVContext parses every row of a CSV file, keeping rows that fail to parse alongside the
+// others, and flags duplicates the way ParseWithDuplicateDetection does. Each row carries the
+// categorizer's prediction even when it was not confident enough to be used.
+func (cp *CSVParser) StageCSVContext(ctx context.Context, filePath string, template *types.CSVTemplate, progress types.ProgressFunc) ([]StagedRow, error) {
+	var rows []StagedRow
+	var parsed []types.Transaction
+	_, err := cp.readRows(ctx, filePath, template, progress, func(row csvRow) error {
+		staged := StagedRow{LineNumber: row.line, RawRow: row.raw, start: row.start, end: row.end}
+		if row.err != nil {
+			rowError := cp.rowError(row)
+			staged.Error = &rowError
+		} else {
+			staged.Transaction = *row.transaction
+			parsed = append(parsed, *row.transaction)
+		}
+		rows = append(rows, staged)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var processedNew []types.Transaction
+	for i := range rows {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		row := &rows[i]
+		if row.Error != nil {
+			continue
+		}
+
+		if cp.transactionStore != nil {
+			row.Duplicate = cp.checkForDuplicate(row.Transaction, parsed, processedNew)
+			row.Excluded = row.Duplicate
+			if !row.Duplicate {
+				processedNew = append(processedNew, row.Transaction)
+			}
+		}
+
+		if cp.mlCategorizer != nil {
+			prediction := cp.mlCategorizer.PredictCategory(row.Transaction.Description, row.Transaction.Amount.Float64())
+			row.PredictedCategoryId = prediction.CategoryId
+			row.Confidence = prediction.Confidence
+		}
+	}
+	return rows, nil
+}
+
+// StageCSVImport parses a CSV file for review before importing it into an account
+func (s *Store) StageCSVImport(filePath, templateName string, accountId int64) (*ImportStaging, error) {
+	return s.StageCSVImportContext(context.Background(), filePath, templateName, accountId, nil)
+}
+
+// StageCSVImportContext is StageCSVImport with progress reporting and cancellation
+// Nothing is written; the staged rows are imported by CommitStagedImport.
+func (s *Store) StageCSVImportContext(ctx context.Context, filePath, templateName string, accountId int64, progress types.ProgressFunc) (*ImportStaging, error) {
+	template := s.Templates.GetTemplateByName(templateName)
+	if template == nil {
+		return nil, fmt.Errorf("template '%s' not found", templateName)
+	}
+
+	if err := s.checkImportAccount(accountId); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.CSVParser.StageCSVContext(ctx, filePath, template, progress)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("no transactions found in %s", filepath.Base(filePath))
+	}
+
+	return &ImportStaging{FilePath: filePath, TemplateName: templateName, AccountId: accountId, Rows: rows}, nil
+}
+
+// CommitStagedImport imports the included rows of a reviewed file
+func (s *Store) CommitStagedImport(staging *ImportStaging, override bool) *types.ImportResult {
+	return s.CommitStagedImportContext(context.Background(), staging, override, nil)
+}
+
+// CommitStagedImportContext imports the included rows of a reviewed file with their edits
+// Rows that failed to parse block the import as they do in ValidateAndImportCSV, and an
+// overlapping statement is reported unless override is set, which records the statement as an
+// override. Cancelling ctx rolls back the partially written statement.
+func (s *Store) CommitStagedImportContext(ctx context.Context, staging *ImportStaging, override bool, progress types.ProgressFunc) *types.ImportResult {
+	result := &types.ImportResult{Filename: filepath.Base(staging.FilePath)}
+
+	template := s.Templates.GetTemplateByName(staging.TemplateName)
+	if template == nil {
+		result.Message = fmt.Sprintf("Template '%s' not found", staging.TemplateName)
+		return result
+	}
+
+	if err := s.checkImportAccount(staging.AccountId); err != nil {
+		result.Message = err.Error()
+		return result
+	}
+
+	transactions := staging.Transactions()
+	failed := staging.FailedRows()
+	if len(failed) > 0 && staging.Mode == types.FailFast {
+		result.HasValidationErrors = true
+		result.ValidCount = len(transactions)
+		for _, rowError := range failed {
+			result.ValidationErrors = append(result.ValidationErrors, types.ValidationError{
+				LineNumber: rowError.LineNumber,
+				Field:      rowError.Field,
+				Message:    rowError.Message,
+			})
+		}
+		result.Message = fmt.Sprintf("Found %d formatting error(s) in CSV file", len(failed))
+		return result
+	}
+
+	if len(transactions) == 0 {
+		result.Message = "No transactions selected for import"
+		return result
+	}
+
+	result.PeriodStart, result.PeriodEnd = s.Statements.ExtractPeriodFromTransactions(transactions)
+	if !override {
+		result.OverlappingStmts = s.Statements.DetectOverlap(result.PeriodStart, result.PeriodEnd, template.Id, staging.AccountId)
+		if len(result.OverlappingStmts) > 0 {
+			result.OverlapDetected = true
+			result.Message = fmt.Sprintf("Import period (%s to %s) overlaps with %d existing statements",
+				result.PeriodStart, result.PeriodEnd, len(result.OverlappingStmts))
+			return result
+		}
+	}
+
+	// Save the failed rows before importing the others, so none are lost if that fails
+	if len(failed) > 0 {
+		result.RejectFile = staging.RejectFilePath()
+		if err := staging.writeRejectFile(result.RejectFile); err != nil {
+			result.Message = fmt.Sprintf("Failed to save the rejected rows: %v", err)
+			return result
+		}
+		result.RejectedCount = len(failed)
+	}
+
+	var statementId int64
+	var err error
+	if override {
+		statementId, err = s.importOverrideTransactions(ctx, transactions, staging.FilePath, template, staging.AccountId, progress)
+	} else {
+		statementId, err = s.importParsedTransactions(ctx, transactions, staging.FilePath, template, staging.AccountId, progress)
+	}
+	if err != nil {
+		if result.RejectFile != "" {
+			os.Remove(result.RejectFile)
+			result.RejectFile, result.RejectedCount = "", 0
+		}
+		if ctx.Err() != nil {
+			return cancelledImport(staging.FilePath)
+		}
+		result.Message = fmt.Sprintf("Import failed: %v", err)
+		return result
+	}
+
+	if len(failed) > 0 {
+		if err := s.Statements.SetStatementErrorLog(statementId, rejectedRowsLog(failed, result.RejectFile)); err != nil {
+			slog.Warn("failed to record rejected rows", "statement_id", statementId, "error", err)
+		}
+	}
+
+	// Save the directory for future imports (only on success)
+	if saveErr := s.SaveLastImportDirectory(staging.FilePath); saveErr != nil {
+		// Log error but don't fail the import
+		slog.Warn("failed to save last import directory", "error", saveErr)
+	}
+
+	result.Success = true
+	result.ImportedCount = len(transactions)
+	result.Message = fmt.Sprintf("Successfully imported %d transactions from %s", len(transactions), result.Filename)
+	if excluded := len(staging.Rows) - len(transactions) - len(failed); excluded > 0 {
+		result.Message += fmt.Sprintf(" (%d rows excluded)", excluded)
+	}
+	if len(failed) > 0 {
+		result.Message += fmt.Sprintf("; %d failed rows saved to %s", len(failed), filepath.Base(result.RejectFile))
+	}
+	return result
+}
+
+// writeRejectFile saves the rows that failed to parse as a file the same template reads: the
+// preamble and header, the failed rows as they were written, and the footer
+func (st *ImportStaging) writeRejectFile(path string) error {
+	file, err := os.Open(st.FilePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	decoded, err := decodeCSVText(file)
+	if err != nil {
+		return err
+	}
+	text, err := io.ReadAll(decoded)
+	if err != nil {
+		return err
+	}
+
+	// Everything before the first row and after the last is kept as it is
+	first, last := int64(len(text)), int64(0)
+	for _, row := range st.Rows {
+		first = min(first, row.start)
+		last = max(last, row.end)
+	}
+	if first > last || last > int64(len(text)) {
+		return fmt.Errorf("%s changed since it was read", filepath.Base(st.FilePath))
+	}
+
+	var out bytes.Buffer
+	out.Write(text[:first])
+	for _, row := range st.Rows {
+		if row.Error == nil {
+			continue
+		}
+		out.Write(text[row.start:row.end])
+		if !bytes.HasSuffix(out.Bytes(), []byte("\n")) {
+			out.WriteString("\n")
+		}
+	}
+	out.Write(text[last:])
+
+	return os.WriteFile(path, out.Bytes(), 0644)
+}
+
+// rejectedRowsLog describes the rows a partial import left out, for the statement's error log
+func rejectedRowsLog(failed []types.RowError, rejectFile string) string {
+	lines := []string{fmt.Sprintf("%d rows failed to parse and were saved to %s", len(failed), rejectFile)}
+	for _, rowError := range failed {
+		lines = append(lines, fmt.Sprintf("Line %d [%s]: %s", rowError.LineNumber, rowError.Field, rowError.Message))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// importOverrideTransactions records an override statement for transactions that overlap an
+// existing statement and writes them into an account, returning the statement's ID
+func (s *Store) importOverrideTransactions(ctx context.Context, transactions []types.Transaction, filePath string, template *types.CSVTemplate, accountId int64, progress types.ProgressFunc) (int64, error) {
+	periodStart, periodEnd := s.Statements.ExtractPeriodFromTransactions(transactions)
+	statementId, err := s.Statements.RecordBankStatement(filepath.Base(filePath), periodStart, periodEnd, template.Id, accountId, len(transactions), "override")
+	if err != nil {
+		return 0, fmt.Errorf("failed to record statement: %v", err)
+	}
+
+	assignAccount(transactions, accountId)
+	if err := s.Transactions.ImportTransactionsFromCSVContext(ctx, transactions, statementId, progress); err != nil {
+		if ctx.Err() != nil {
+			s.discardCancelledStatement(statementId)
+			return 0, ctx.Err()
+		}
+		return 0, fmt.Errorf("failed to save transactions: %v", err)
+	}
+	return statementId, nil
+}
@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"budget-tracker-tui/internal/types"
+)
+
+// TestStageCSVImport tests reviewing a file's rows and committing them with their edits
+func TestStageCSVImport(t *testing.T) {
+	store, conn := setupTestMainStore(t)
+	defer teardownTestDB(t, conn)
+
+	template := types.CSVTemplate{Name: "ReviewBank", PostDateColumn: 0, AmountColumn: 1, DescColumn: 2, DateFormat: "2006-01-02"}
+	if result := store.Templates.CreateCSVTemplate(template); !result.Success {
+		t.Fatalf("Failed to create test template: %s", result.Message)
+	}
+	recategorized := store.Categories.ResolveOrCreateCategory("Groceries")
+	if recategorized <= 0 {
+		t.Fatal("Failed to create the Groceries category")
+	}
+
+	firstPath := createTestCSVFile(t, "january.csv", "2024-01-10,-12.00,Bakery\n2024-01-20,-800.00,Rent")
+	if result := store.ValidateAndImportCSV(firstPath, "ReviewBank", 0); !result.Success {
+		t.Fatalf("Expected the first import to succeed: %s", result.Message)
+	}
+
+	// The bakery row is already saved, and staging writes nothing
+	filePath := createTestCSVFile(t, "review.csv", "2024-01-10,-12.00,Bakery\n"+
+		"2024-01-15,-42.50,Grocery\n"+
+		"2024-01-16,-9.99,Streaming\n"+
+		"2024-01-17,1200.00,Salary\n")
+	staging, err := store.StageCSVImport(filePath, "ReviewBank", 0)
+	if err != nil {
+		t.Fatalf("StageCSVImport() failed: %v", err)
+	}
+	if len(staging.Rows) != 4 || staging.Duplicates() != 1 {
+		t.Fatalf("Expected 4 rows with 1 duplicate, got %+v", staging.Rows)
+	}
+	if !staging.Rows[0].Duplicate || staging.Rows[0].Included() || staging.Rows[0].LineNumber != 1 {
+		t.Errorf("Expected the saved row to be flagged and excluded, got %+v", staging.Rows[0])
+	}
+	if transactions, _ := store.Transactions.GetTransactions(); len(transactions) != 2 {
+		t.Fatalf("Expected staging to save nothing, got %d transactions", len(transactions))
+	}
+
+	// Edit the review, then commit what is left
+	staging.Rows[1].Transaction.CategoryId = recategorized
+	staging.Rows[1].Transaction.Description = "Weekly groceries"
+	staging.Rows[2].Excluded = true
+	result := store.CommitStagedImport(staging, false)
+	if !result.OverlapDetected {
+		t.Fatalf("Expected the January statement to overlap, got %+v", result)
+	}
+	result = store.CommitStagedImport(staging, true)
+	if !result.Success || result.ImportedCount != 2 {
+		t.Fatalf("Expected 2 rows imported, got %+v", result)
+	}
+
+	transactions, err := store.Transactions.GetTransactions()
+	if err != nil {
+		t.Fatalf("Failed to get transactions: %v", err)
+	}
+	if len(transactions) != 4 {
+		t.Fatalf("Expected 4 transactions, got %d", len(transactions))
+	}
+	found := false
+	for _, tx := range transactions {
+		if tx.Description == "Streaming" {
+			t.Error("Expected the excluded row to be left out")
+		}
+		if tx.Description == "Weekly groceries" {
+			found = true
+			if tx.CategoryId != recategorized || tx.RawDescription != "Grocery" {
+				t.Errorf("Expected the edited category and the original raw description, got %+v", tx)
+			}
+		}
+	}
+	if !found {
+		t.Error("Expected the renamed row to be imported")
+	}
+
+	// A row that fails to parse is staged with its error and blocks the commit
+	brokenPath := createTestCSVFile(t, "broken.csv", "2024-02-01,-5.00,Coffee\n2024-02-02,oops,Broken\n")
+	staging, err = store.StageCSVImport(brokenPath, "ReviewBank", 0)
+	if err != nil {
+		t.Fatalf("StageCSVImport() failed: %v", err)
+	}
+	if len(staging.Rows) != 2 || staging.Rows[1].Error == nil || staging.Rows[1].Included() {
+		t.Fatalf("Expected the second row to carry its error, got %+v", staging.Rows)
+	}
+	if result := store.CommitStagedImport(staging, false); !result.HasValidationErrors || result.ValidationErrors[0].LineNumber != 2 {
+		t.Errorf("Expected the broken row to block the import, got %+v", result)
+	}
+}
+
+// TestCommitStagedImportSkipInvalid tests importing the rows that parsed and saving the rest to a
+// reject file the same template reads
+func TestCommitStagedImportSkipInvalid(t *testing.T) {
+	store, conn := setupTestMainStore(t)
+	defer teardownTestDB(t, conn)
+
+	template := types.CSVTemplate{
+		Name: "PartialBank", PostDateColumn: 0, AmountColumn: 1, DescColumn: 2, DateFormat: "2006-01-02",
+		HasHeader: true, SkipLines: 1, FooterLines: 1,
+	}
+	if result := store.Templates.CreateCSVTemplate(template); !result.Success {
+		t.Fatalf("Failed to create test template: %s", result.Message)
+	}
+
+	filePath := createTestCSVFile(t, "partial.csv", "Account,12345678\r\n"+
+		"Date,Amount,Description\r\n"+
+		"2024-03-01,-42.50,Grocery\r\n"+
+		"2024-03-02,oops,\"Broken, twice\"\r\n"+
+		"2024-03-03,1200.00,Salary\r\n"+
+		"not-a-date,-5.00,Coffee\r\n"+
+		"Total,1152.50,\r\n")
+	staging, err := store.StageCSVImport(filePath, "PartialBank", 0)
+	if err != nil {
+		t.Fatalf("StageCSVImport() failed: %v", err)
+	}
+
+	result := store.CommitStagedImport(staging, false)
+	if !result.HasValidationErrors || result.ValidCount != 2 || len(result.ValidationErrors) != 2 {
+		t.Fatalf("Expected 2 failed and 2 valid rows to block the import, got %+v", result)
+	}
+
+	staging.Mode = types.SkipInvalid
+	result = store.CommitStagedImport(staging, false)
+	if !result.Success || result.ImportedCount != 2 || result.RejectedCount != 2 {
+		t.Fatalf("Expected 2 rows imported and 2 rejected, got %+v", result)
+	}
+	if result.RejectFile != strings.TrimSuffix(filePath, ".csv")+".rejected.csv" {
+		t.Errorf("Unexpected reject file %s", result.RejectFile)
+	}
+
+	rejected, err := os.ReadFile(result.RejectFile)
+	if err != nil {
+		t.Fatalf("Failed to read the reject file: %v", err)
+	}
+	want := "Account,12345678\r\n" +
+		"Date,Amount,Description\r\n" +
+		"2024-03-02,oops,\"Broken, twice\"\r\n" +
+		"not-a-date,-5.00,Coffee\r\n" +
+		"Total,1152.50,\r\n"
+	if string(rejected) != want {
+		t.Errorf("Unexpected reject file:\n%q\nwant\n%q", rejected, want)
+	}
+
+	statements := store.Statements.GetStatementHistory()
+	if len(statements) != 1 || statements[0].Status != "completed" {
+		t.Fatalf("Expected a completed statement, got %+v", statements)
+	}
+	if log := statements[0].ErrorLog; !strings.Contains(log, "2 rows failed to parse") || !strings.Contains(log, "Line 4 [") || !strings.Contains(log, "Line 6 [") {
+		t.Errorf("Expected the failed rows in the error log, got %q", log)
+	}
+
+	// Once fixed, the reject file imports with the same template
+	fixed := strings.NewReplacer("oops", "-7.25", "not-a-date", "2024-03-04").Replace(string(rejected))
+	if err := os.WriteFile(result.RejectFile, []byte(fixed), 0644); err != nil {
+		t.Fatalf("Failed to fix the reject file: %v", err)
+	}
+	parsed, err := store.CSVParser.ParseCSV(result.RejectFile, store.Templates.GetTemplateByName("PartialBank"), types.FailFast)
+	if err != nil || len(parsed.SuccessfulTransactions) != 2 || parsed.SuccessfulTransactions[0].Description != "Broken, twice" {
+		t.Errorf("Expected the fixed rows to parse, got %+v (%v)", parsed, err)
+	}
+}
+
+// TestCommitStagedImportRejectsZeroAmounts tests that a zero amount is a failed row saved to the
+// reject file, and that a failed import leaves no reject file behind
+func TestCommitStagedImportRejectsZeroAmounts(t *testing.T) {
+	store, conn := setupTestMainStore(t)
+	defer teardownTestDB(t, conn)
+
+	template := types.CSVTemplate{Name: "HoldBank", PostDateColumn: 0, AmountColumn: 1, DescColumn: 4, DateFormat: "01/02/2006"}
+	if result := store.Templates.CreateCSVTemplate(template); !result.Success {
+		t.Fatalf("Failed to create test template: %s", result.Message)
+	}
+
+	filePath := createTestCSVFile(t, "holds.csv", "01/05/2024,-12.00,,,Bakery\n01/06/2024,0.00,,,Hold\n")
+	staging, err := store.StageCSVImport(filePath, "HoldBank", 0)
+	if err != nil {
+		t.Fatalf("StageCSVImport() failed: %v", err)
+	}
+	if len(staging.Rows) != 2 || staging.Rows[1].Error == nil || staging.Rows[1].Error.Field != "Amount" {
+		t.Fatalf("Expected the zero amount to be staged as a failed row, got %+v", staging.Rows)
+	}
+
+	// An import that fails removes the reject file and no longer reports it
+	if _, err := conn.DB.Exec(`CREATE TRIGGER fail_import BEFORE INSERT ON transactions
+		BEGIN SELECT RAISE(ABORT, 'disk full'); END`); err != nil {
+		t.Fatalf("Failed to create trigger: %v", err)
+	}
+	staging.Mode = types.SkipInvalid
+	result := store.CommitStagedImport(staging, false)
+	if result.Success || result.RejectFile != "" || result.RejectedCount != 0 {
+		t.Fatalf("Expected a failed import without a reject file, got %+v", result)
+	}
+	if _, err := os.Stat(staging.RejectFilePath()); !os.IsNotExist(err) {
+		t.Errorf("Expected the reject file to be removed, got %v", err)
+	}
+
+	if _, err := conn.DB.Exec(`DROP TRIGGER fail_import`); err != nil {
+		t.Fatalf("Failed to drop trigger: %v", err)
+	}
+	result = store.CommitStagedImport(staging, false)
+	if !result.Success || result.ImportedCount != 1 || result.RejectedCount != 1 {
+		t.Fatalf("Expected 1 row imported and the hold rejected, got %+v", result)
+	}
+	rejected, err := os.ReadFile(result.RejectFile)
+	if err != nil || string(rejected) != "01/06/2024,0.00,,,Hold\n" {
+		t.Errorf("Expected the hold in the reject file, got %q (%v)", rejected, err)
+	}
+}
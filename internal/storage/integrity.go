@@ -0,0 +1,384 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"budget-tracker-tui/internal/types"
+)
+
+// staleImportAge is how long a statement may stay in "importing" before it counts as stuck
+// Younger statements may belong to an import still running in another instance of the app.
+const staleImportAge = 10 * time.Minute
+
+// Integrity issue kinds
+const (
+	IntegrityStuckImport       = "stuck_import"
+	IntegrityStatementCount    = "statement_tx_count"
+	IntegrityOrphanedSplit     = "orphaned_split"
+	IntegrityInactiveCategory  = "inactive_category"
+	IntegrityMissingSnapshot   = "missing_snapshot_file"
+	IntegrityDatabaseCorrupt   = "integrity_check"
+	IntegrityForeignKeyFailure = "foreign_key_check"
+)
+
+// IntegrityIssue is one inconsistency found in the open ledger
+type IntegrityIssue struct {
+	Kind        string // One of the Integrity* constants
+	RecordId    int64  // Affected row, or 0 when the issue is not tied to one
+	Description string
+	Fix         string // What a repair does; empty when the issue must be fixed by hand
+
+	repair func() error
+}
+
+// Repairable reports whether RepairIntegrity can fix the issue
+func (i IntegrityIssue) Repairable() bool {
+	return i.repair != nil
+}
+
+// IntegrityReport lists every issue found by CheckIntegrity
+type IntegrityReport struct {
+	CheckedAt time.Time
+	Issues    []IntegrityIssue
+}
+
+// RepairableCount returns how many issues RepairIntegrity can fix
+func (r *IntegrityReport) RepairableCount() int {
+	count := 0
+	for _, issue := range r.Issues {
+		if issue.Repairable() {
+			count++
+		}
+	}
+	return count
+}
+
+// IntegrityRepairResult describes a repair run and the state of the ledger afterwards
+type IntegrityRepairResult struct {
+	Repaired  []IntegrityIssue
+	Failed    []string         // Repairs that failed, with the reason
+	Remaining *IntegrityReport // Fresh check after the repairs
+}
+
+// CheckIntegrity looks for inconsistent state in the open ledger without changing anything
+func (s *Store) CheckIntegrity() (*IntegrityReport, error) {
+	report := &IntegrityReport{CheckedAt: time.Now()}
+
+	checks := []func() ([]IntegrityIssue, error){
+		s.checkDatabaseFile,
+		s.checkStuckImports,
+		s.checkStatementCounts,
+		s.checkOrphanedSplits,
+		s.checkInactiveCategories,
+		s.checkSnapshotFiles,
+	}
+	for _, check := range checks {
+		issues, err := check()
+		if err != nil {
+			return nil, err
+		}
+		report.Issues = append(report.Issues, issues...)
+	}
+	return report, nil
+}
+
+// RepairIntegrity fixes every repairable issue found by a fresh check, then checks again
+// Repairs run one by one; a failed repair is reported and the others still run.
+func (s *Store) RepairIntegrity() (*IntegrityRepairResult, error) {
+	report, err := s.CheckIntegrity()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &IntegrityRepairResult{}
+	for _, issue := range report.Issues {
+		if !issue.Repairable() {
+			continue
+		}
+		if err := issue.repair(); err != nil {
+			result.Failed = append(result.Failed, fmt.Sprintf("%s: %v", issue.Description, err))
+			continue
+		}
+		result.Repaired = append(result.Repaired, issue)
+	}
+
+	// Repairs change the data behind cached undo steps, so they can no longer be replayed safely
+	if len(result.Repaired) > 0 {
+		s.Undo.Clear()
+	}
+
+	if result.Remaining, err = s.CheckIntegrity(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// checkDatabaseFile runs SQLite's own structural and foreign key checks
+func (s *Store) checkDatabaseFile() ([]IntegrityIssue, error) {
+	var issues []IntegrityIssue
+
+	problems, err := s.db.IntegrityCheck()
+	if err != nil {
+		return nil, err
+	}
+	for _, problem := range problems {
+		issues = append(issues, IntegrityIssue{
+			Kind:        IntegrityDatabaseCorrupt,
+			Description: "SQLite integrity check: " + problem,
+		})
+	}
+
+	violations, err := s.db.ForeignKeyCheck()
+	if err != nil {
+		return nil, err
+	}
+	for _, violation := range violations {
+		issues = append(issues, IntegrityIssue{
+			Kind:        IntegrityForeignKeyFailure,
+			RecordId:    violation.RowId,
+			Description: fmt.Sprintf("%s row %d references a missing %s row", violation.Table, violation.RowId, violation.Parent),
+		})
+	}
+	return issues, nil
+}
+
+// checkStuckImports finds statements left in "importing" by an import that never finished
+// Statements whose transactions were written are completed; empty ones are removed.
+func (s *Store) checkStuckImports() ([]IntegrityIssue, error) {
+	statements, err := s.Statements.GetOrphanedImportingStatements()
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []IntegrityIssue
+	for _, stmt := range statements {
+		if time.Since(stmt.ImportDate) < staleImportAge {
+			continue
+		}
+
+		count, err := s.countStatementTransactions(stmt.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		statementId := stmt.Id
+		issue := IntegrityIssue{
+			Kind:     IntegrityStuckImport,
+			RecordId: statementId,
+			Description: fmt.Sprintf("Statement '%s' has been importing since %s",
+				stmt.Filename, stmt.ImportDate.Local().Format("01/02/2006 15:04")),
+		}
+		if count > 0 {
+			issue.Fix = fmt.Sprintf("mark completed with its %d transaction(s)", count)
+			issue.repair = func() error { return s.completeStuckImport(statementId, count) }
+		} else {
+			issue.Fix = "remove the empty statement"
+			issue.repair = func() error { return s.Statements.DeleteStatement(statementId) }
+		}
+		issues = append(issues, issue)
+	}
+	return issues, nil
+}
+
+// checkStatementCounts finds completed statements whose tx_count disagrees with their transactions
+func (s *Store) checkStatementCounts() ([]IntegrityIssue, error) {
+	query := `
+		SELECT b.id, b.filename, b.tx_count, COUNT(t.id)
+		FROM bank_statements b
+		LEFT JOIN transactions t ON t.statement_id = b.id AND t.parent_id IS NULL
+		WHERE b.status IN ('completed', 'override')
+		GROUP BY b.id
+		HAVING b.tx_count != COUNT(t.id)
+		ORDER BY b.id`
+
+	rows, err := s.Statements.helper.QueryRows(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check statement counts: %w", err)
+	}
+	defer rows.Close()
+
+	var issues []IntegrityIssue
+	for rows.Next() {
+		var statementId int64
+		var filename string
+		var recorded, actual int
+		if err := rows.Scan(&statementId, &filename, &recorded, &actual); err != nil {
+			return nil, fmt.Errorf("failed to scan statement count: %w", err)
+		}
+
+		issues = append(issues, IntegrityIssue{
+			Kind:        IntegrityStatementCount,
+			RecordId:    statementId,
+			Description: fmt.Sprintf("Statement '%s' records %d transaction(s) but has %d", filename, recorded, actual),
+			Fix:         fmt.Sprintf("set its count to %d", actual),
+			repair:      func() error { return s.setStatementTxCount(statementId, actual) },
+		})
+	}
+	return issues, rows.Err()
+}
+
+// checkOrphanedSplits finds transactions flagged as split that have no second half
+// SplitTransaction links the second half to the first through parent_id.
+func (s *Store) checkOrphanedSplits() ([]IntegrityIssue, error) {
+	query := `
+		SELECT t.id, t.description
+		FROM transactions t
+		WHERE t.is_split = 1
+		  AND t.parent_id IS NULL
+		  AND NOT EXISTS (SELECT 1 FROM transactions c WHERE c.parent_id = t.id)
+		ORDER BY t.id`
+
+	rows, err := s.Transactions.helper.QueryRows(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check split transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var issues []IntegrityIssue
+	for rows.Next() {
+		var transactionId int64
+		var description string
+		if err := rows.Scan(&transactionId, &description); err != nil {
+			return nil, fmt.Errorf("failed to scan split transaction: %w", err)
+		}
+
+		issues = append(issues, IntegrityIssue{
+			Kind:        IntegrityOrphanedSplit,
+			RecordId:    transactionId,
+			Description: fmt.Sprintf("Transaction '%s' is marked as split but has no other half", description),
+			Fix:         "clear its split flag",
+			repair:      func() error { return s.clearSplitFlag(transactionId) },
+		})
+	}
+	return issues, rows.Err()
+}
+
+// checkInactiveCategories finds transactions assigned to categories that are no longer active
+func (s *Store) checkInactiveCategories() ([]IntegrityIssue, error) {
+	// Affected transactions move to the default category, if that one is still usable
+	defaultId := s.Categories.GetDefaultCategoryId()
+	defaultName := s.Categories.GetCategoryDisplayName(defaultId)
+
+	query := `
+		SELECT t.id, t.description, c.display_name
+		FROM transactions t
+		JOIN categories c ON c.id = t.category_id
+		WHERE c.is_active = 0
+		ORDER BY t.id`
+
+	rows, err := s.Transactions.helper.QueryRows(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check transaction categories: %w", err)
+	}
+	defer rows.Close()
+
+	var issues []IntegrityIssue
+	for rows.Next() {
+		var transactionId int64
+		var description, categoryName string
+		if err := rows.Scan(&transactionId, &description, &categoryName); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction category: %w", err)
+		}
+
+		issue := IntegrityIssue{
+			Kind:        IntegrityInactiveCategory,
+			RecordId:    transactionId,
+			Description: fmt.Sprintf("Transaction '%s' is in inactive category '%s'", description, categoryName),
+		}
+		if defaultName != "" {
+			issue.Fix = fmt.Sprintf("move it to '%s'", defaultName)
+			issue.repair = func() error { return s.reassignCategory(transactionId, defaultId) }
+		}
+		issues = append(issues, issue)
+	}
+	return issues, rows.Err()
+}
+
+// checkSnapshotFiles finds snapshot records whose database file is gone
+func (s *Store) checkSnapshotFiles() ([]IntegrityIssue, error) {
+	snapshots, err := s.Snapshots.GetSnapshots()
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []IntegrityIssue
+	for _, snapshot := range snapshots {
+		if _, err := os.Stat(snapshot.FilePath); !os.IsNotExist(err) {
+			continue
+		}
+
+		snapshotId := snapshot.Id
+		issues = append(issues, IntegrityIssue{
+			Kind:        IntegrityMissingSnapshot,
+			RecordId:    snapshotId,
+			Description: fmt.Sprintf("Snapshot '%s' points at missing file %s", snapshot.Name, snapshot.FilePath),
+			Fix:         "remove the snapshot record",
+			repair:      func() error { return s.Snapshots.DeleteSnapshot(snapshotId) },
+		})
+	}
+	return issues, nil
+}
+
+// countStatementTransactions counts a statement's transactions, trashed ones included and split halves once
+func (s *Store) countStatementTransactions(statementId int64) (int, error) {
+	count, err := s.Statements.helper.CountBy("transactions", "statement_id = ? AND parent_id IS NULL", statementId)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count statement transactions: %w", err)
+	}
+	return int(count), nil
+}
+
+// completeStuckImport marks a stuck statement completed with the transactions it actually holds
+func (s *Store) completeStuckImport(statementId int64, count int) error {
+	if err := s.setStatementTxCount(statementId, count); err != nil {
+		return err
+	}
+	return s.Statements.MarkStatementCompleted(statementId)
+}
+
+// setStatementTxCount corrects a statement's recorded transaction count
+func (s *Store) setStatementTxCount(statementId int64, count int) error {
+	before, _ := s.Statements.GetStatementById(statementId)
+
+	query := "UPDATE bank_statements SET tx_count = ?, updated_at = ? WHERE id = ?"
+	if _, err := s.Statements.helper.ExecReturnRowsAffected(query, count, time.Now().Format(time.RFC3339), statementId); err != nil {
+		return fmt.Errorf("failed to update statement count: %w", err)
+	}
+
+	s.Statements.recordStatementChange(statementId, types.AuditEventUpdate, types.SourceAuto, before)
+	return nil
+}
+
+// clearSplitFlag turns a split transaction without a second half back into a plain one
+func (s *Store) clearSplitFlag(transactionId int64) error {
+	before := s.Transactions.GetTransactionByID(transactionId)
+
+	query := "UPDATE transactions SET is_split = 0, updated_at = ? WHERE id = ?"
+	if _, err := s.Transactions.helper.ExecReturnRowsAffected(query, time.Now(), transactionId); err != nil {
+		return fmt.Errorf("failed to clear split flag: %w", err)
+	}
+
+	s.recordTransactionRepair(transactionId, before)
+	return nil
+}
+
+// reassignCategory moves a transaction to another category
+func (s *Store) reassignCategory(transactionId, categoryId int64) error {
+	before := s.Transactions.GetTransactionByID(transactionId)
+
+	query := "UPDATE transactions SET category_id = ?, updated_at = ? WHERE id = ?"
+	if _, err := s.Transactions.helper.ExecReturnRowsAffected(query, categoryId, time.Now(), transactionId); err != nil {
+		return fmt.Errorf("failed to reassign category: %w", err)
+	}
+
+	s.recordTransactionRepair(transactionId, before)
+	return nil
+}
+
+// recordTransactionRepair logs an automatic repair of a transaction in the general audit log
+func (s *Store) recordTransactionRepair(transactionId int64, before *types.Transaction) {
+	after := s.Transactions.GetTransactionByID(transactionId)
+	s.Audit.recordOrWarn(types.EntityTransaction, auditId(transactionId), types.AuditEventUpdate, types.SourceAuto, before, after)
+}
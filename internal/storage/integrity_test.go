@@ -0,0 +1,239 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"budget-tracker-tui/internal/database"
+	"budget-tracker-tui/internal/types"
+)
+
+// integrityKinds counts the issues of each kind in a report
+func integrityKinds(report *IntegrityReport) map[string]int {
+	kinds := make(map[string]int)
+	for _, issue := range report.Issues {
+		kinds[issue.Kind]++
+	}
+	return kinds
+}
+
+func TestCheckIntegrityCleanLedger(t *testing.T) {
+	store, conn := setupAuditTestStore(t)
+	defer teardownTestDB(t, conn)
+
+	categoryId := createTestCategory(t, conn, "Groceries")
+	saveTrashTestTransaction(t, store.Transactions, categoryId, "Market", nil)
+
+	// A split pair is consistent: the second half points back at the first
+	parentId := saveTrashTestTransaction(t, store.Transactions, categoryId, "Hardware store", nil)
+	splits := []types.Transaction{
+		createTestTransaction(15.00, "Paint", categoryId),
+		createTestTransaction(25.00, "Brushes", categoryId),
+	}
+	if err := store.Transactions.SplitTransaction(parentId, splits); err != nil {
+		t.Fatalf("SplitTransaction() failed: %v", err)
+	}
+
+	report, err := store.CheckIntegrity()
+	if err != nil {
+		t.Fatalf("CheckIntegrity() failed: %v", err)
+	}
+	if len(report.Issues) != 0 {
+		t.Errorf("Expected a clean ledger, got %+v", report.Issues)
+	}
+}
+
+func TestRepairIntegrity(t *testing.T) {
+	store, conn := setupAuditTestStore(t)
+	defer teardownTestDB(t, conn)
+
+	groceries := createTestCategory(t, conn, "Groceries")
+	retired := createTestCategoryInactive(t, conn, "Retired")
+	store.Categories.SetDefaultCategoryId(groceries)
+	stale := time.Now().Add(-time.Hour).Format(time.RFC3339)
+
+	// A stuck import that wrote its transactions, one that wrote nothing, and one still running
+	stuckId := createTestBankStatementWithStatus(t, conn, "stuck.csv", "importing")
+	emptyId := createTestBankStatementWithStatus(t, conn, "empty.csv", "importing")
+	createTestBankStatementWithStatus(t, conn, "running.csv", "importing")
+	if _, err := conn.DB.Exec("UPDATE bank_statements SET import_date = ? WHERE id IN (?, ?)", stale, stuckId, emptyId); err != nil {
+		t.Fatalf("Failed to age statements: %v", err)
+	}
+	stuckTx := createTestTransaction(12.00, "Imported coffee", groceries)
+	stuckTx.StatementId = stuckId
+	if err := store.Transactions.SaveTransaction(stuckTx); err != nil {
+		t.Fatalf("Failed to save statement transaction: %v", err)
+	}
+
+	// A completed statement whose count is off
+	countedId := createTestBankStatementWithStatus(t, conn, "counted.csv", "completed")
+	countedTx := createTestTransaction(30.00, "Imported fuel", groceries)
+	countedTx.StatementId = countedId
+	if err := store.Transactions.SaveTransaction(countedTx); err != nil {
+		t.Fatalf("Failed to save statement transaction: %v", err)
+	}
+
+	// A split flag without a second half, and a transaction in an inactive category
+	orphanId := saveTrashTestTransaction(t, store.Transactions, groceries, "Half a split", nil)
+	if _, err := conn.DB.Exec("UPDATE transactions SET is_split = 1 WHERE id = ?", orphanId); err != nil {
+		t.Fatalf("Failed to flag split: %v", err)
+	}
+	retiredTxId := saveTrashTestTransaction(t, store.Transactions, retired, "Old habit", nil)
+
+	// A snapshot record whose file was deleted
+	missingPath := filepath.Join(t.TempDir(), "gone.db")
+	if _, err := conn.DB.Exec("INSERT INTO snapshots (name, description, file_path, file_size) VALUES (?, ?, ?, 0)", "gone", "", missingPath); err != nil {
+		t.Fatalf("Failed to create snapshot record: %v", err)
+	}
+
+	report, err := store.CheckIntegrity()
+	if err != nil {
+		t.Fatalf("CheckIntegrity() failed: %v", err)
+	}
+	kinds := integrityKinds(report)
+	expected := map[string]int{
+		IntegrityStuckImport:      2,
+		IntegrityStatementCount:   1,
+		IntegrityOrphanedSplit:    1,
+		IntegrityInactiveCategory: 1,
+		IntegrityMissingSnapshot:  1,
+	}
+	for kind, count := range expected {
+		if kinds[kind] != count {
+			t.Errorf("Expected %d %s issue(s), got %d", count, kind, kinds[kind])
+		}
+	}
+	if len(report.Issues) != report.RepairableCount() {
+		t.Errorf("Expected every issue to be repairable, got %d of %d", report.RepairableCount(), len(report.Issues))
+	}
+
+	result, err := store.RepairIntegrity()
+	if err != nil {
+		t.Fatalf("RepairIntegrity() failed: %v", err)
+	}
+	if len(result.Failed) != 0 {
+		t.Errorf("Unexpected failed repairs: %v", result.Failed)
+	}
+	if len(result.Repaired) != 6 {
+		t.Errorf("Expected 6 repairs, got %d", len(result.Repaired))
+	}
+	if len(result.Remaining.Issues) != 0 {
+		t.Errorf("Expected no issues after repair, got %+v", result.Remaining.Issues)
+	}
+
+	if stmt, _ := store.Statements.GetStatementById(stuckId); stmt == nil || stmt.Status != "completed" || stmt.TxCount != 1 {
+		t.Errorf("Expected the stuck import to be completed with 1 transaction, got %+v", stmt)
+	}
+	if stmt, _ := store.Statements.GetStatementById(emptyId); stmt != nil {
+		t.Error("Expected the empty stuck import to be removed")
+	}
+	if stmt, _ := store.Statements.GetStatementById(countedId); stmt == nil || stmt.TxCount != 1 {
+		t.Errorf("Expected the statement count to be corrected, got %+v", stmt)
+	}
+	if tx := store.Transactions.GetTransactionByID(orphanId); tx.IsSplit {
+		t.Error("Expected the orphaned split flag to be cleared")
+	}
+	if tx := store.Transactions.GetTransactionByID(retiredTxId); tx.CategoryId != groceries {
+		t.Errorf("Expected the transaction to move to the default category, got %d", tx.CategoryId)
+	}
+	if events := auditEventTypes(t, store, types.EntityTransaction, auditId(retiredTxId)); len(events) != 1 {
+		t.Errorf("Expected the category repair to be audited, got %v", events)
+	}
+}
+
+func TestInitForCheckLeavesLedgerUntouched(t *testing.T) {
+	dir := t.TempDir()
+
+	missing := NewStoreAt(filepath.Join(dir, "missing.db"))
+	if err := missing.InitForCheck(); err == nil {
+		t.Error("Expected checking a missing ledger to fail")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "missing.db")); !os.IsNotExist(err) {
+		t.Error("Expected the missing ledger not to be created")
+	}
+
+	// A ledger at the baseline schema is refused rather than migrated
+	oldPath := filepath.Join(dir, "old.db")
+	conn, err := database.NewConnectionAt(oldPath)
+	if err != nil {
+		t.Fatalf("Failed to create baseline ledger: %v", err)
+	}
+	conn.Close()
+	old := NewStoreAt(oldPath)
+	if err := old.InitForCheck(); err == nil || !strings.Contains(err.Error(), "older than this application") {
+		t.Errorf("Expected an outdated ledger to be refused, got %v", err)
+	}
+	conn, err = database.NewConnectionAt(oldPath)
+	if err != nil {
+		t.Fatalf("Failed to reopen baseline ledger: %v", err)
+	}
+	if version, _ := conn.GetSchemaVersion(); version != 1 {
+		t.Errorf("Expected the schema to stay at version 1, got %d", version)
+	}
+	conn.Close()
+	if entries, _ := os.ReadDir(filepath.Join(dir, "snapshots")); len(entries) != 0 {
+		t.Errorf("Expected no pre-migration snapshot, got %d", len(entries))
+	}
+
+	// A current ledger opens and checks
+	currentPath := filepath.Join(dir, "current.db")
+	current := NewStoreAt(currentPath)
+	if err := current.Init(); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	current.Close()
+
+	// Backdate the ledger so any write during the check would show in its modification time
+	backdated := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(currentPath, backdated, backdated); err != nil {
+		t.Fatalf("Failed to backdate ledger: %v", err)
+	}
+
+	checked := NewStoreAt(currentPath)
+	if err := checked.InitForCheck(); err != nil {
+		t.Fatalf("InitForCheck() failed: %v", err)
+	}
+	if _, err := checked.CheckIntegrity(); err != nil {
+		t.Errorf("CheckIntegrity() failed: %v", err)
+	}
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if _, err := os.Stat(currentPath + suffix); !os.IsNotExist(err) {
+			t.Errorf("Expected no %s file while checking, got %v", suffix, err)
+		}
+	}
+	checked.Close()
+
+	info, err := os.Stat(currentPath)
+	if err != nil {
+		t.Fatalf("Failed to stat ledger: %v", err)
+	}
+	if !info.ModTime().Equal(backdated) {
+		t.Errorf("Expected the ledger's modification time to stay %v, got %v", backdated, info.ModTime())
+	}
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if _, err := os.Stat(currentPath + suffix); !os.IsNotExist(err) {
+			t.Errorf("Expected no %s file after checking, got %v", suffix, err)
+		}
+	}
+
+	// A ledger the app still has open is checked through the WAL the app keeps
+	open, err := database.NewConnectionAt(currentPath)
+	if err != nil {
+		t.Fatalf("Failed to open ledger: %v", err)
+	}
+	defer open.Close()
+	if _, err := open.DB.Exec("INSERT INTO categories (display_name) VALUES ('Pending')"); err != nil {
+		t.Fatalf("Failed to write through the open ledger: %v", err)
+	}
+	checkedOpen := NewStoreAt(currentPath)
+	if err := checkedOpen.InitForCheck(); err != nil {
+		t.Fatalf("InitForCheck() of an open ledger failed: %v", err)
+	}
+	defer checkedOpen.Close()
+	if checkedOpen.Categories.GetCategoryByDisplayName("Pending") == nil {
+		t.Error("Expected the check to see writes still in the WAL")
+	}
+}
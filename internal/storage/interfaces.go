@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"database/sql"
 	"time"
 
 	"budget-tracker-tui/internal/ml"
@@ -12,16 +13,25 @@ type TransactionStoreInterface interface {
 	// CRUD Operations
 	GetTransactions() ([]types.Transaction, error)
 	GetTransactionsByStatement(statementId int64) ([]types.Transaction, error)
+	QueryTransactions(query TransactionQuery) ([]types.Transaction, error)
+	CountTransactions(query TransactionQuery) (int, error)
+	SearchTransactions(text string, limit int) ([]TransactionSearchResult, error)
 	GetTransactionByID(id int64) *types.Transaction
 	SaveTransaction(transaction types.Transaction) error
 	DeleteTransaction(id int64) error
-	FindDuplicateTransactions(date string, amount float64, description string) ([]types.Transaction, error)
+	FindDuplicateTransactions(date string, amount types.Money, description string) ([]types.Transaction, error)
 
 	// Bulk Operations
 	ImportTransactionsFromCSV(transactions []types.Transaction, statementId int64) error
 
 	// Split Operations
 	SplitTransaction(parentId int64, splits []types.Transaction) error
+
+	// Trash Operations
+	RestoreTransaction(id int64) error
+	PurgeTransaction(id int64) error
+	EmptyTrash() (int, error)
+	PurgeExpiredTrash(retentionDays int) (int, error)
 }
 
 // CategoryStoreInterface defines the contract for category operations
@@ -57,9 +67,9 @@ type BankStatementStoreInterface interface {
 	DeleteStatement(id int64) error
 
 	// Import Operations
-	ValidateAndImportCSV(filePath, templateName string) *types.ImportResult
-	ImportCSVWithOverride(templateName string) *types.ImportResult
-	DetectOverlap(periodStart, periodEnd string, templateId int64) []types.BankStatement
+	ValidateAndImportCSV(filePath, templateName string, accountId int64) *types.ImportResult
+	ImportCSVWithOverride(filePath, templateName string, accountId int64) *types.ImportResult
+	DetectOverlap(periodStart, periodEnd string, templateId, accountId int64) []types.BankStatement
 	ExtractPeriodFromTransactions(transactions []types.Transaction) (start, end string)
 
 	// Undo Operations
@@ -93,6 +103,7 @@ type TransactionAuditStoreInterface interface {
 	RecordEvent(event *types.TransactionAuditEvent) error
 
 	// Query Operations
+	GetEventByID(id int64) (*types.TransactionAuditEvent, error)
 	GetEventsByTransaction(transactionId int64) ([]types.TransactionAuditEvent, error)
 	GetEventsByStatement(bankStatementId int64) ([]types.TransactionAuditEvent, error)
 	GetEventsByTimeRange(startTime, endTime time.Time) ([]types.TransactionAuditEvent, error)
@@ -104,6 +115,18 @@ type TransactionAuditStoreInterface interface {
 	GetImportEvents() ([]types.TransactionAuditEvent, error)
 }
 
+// AuditStoreInterface defines the contract for the general audit log
+type AuditStoreInterface interface {
+	// Core Operations
+	Record(entityType, entityId, eventType, source string, before, after interface{}) error
+	RecordEvent(event *types.AuditEvent) error
+
+	// Query Operations
+	GetEventsByEntity(entityType, entityId string) ([]types.AuditEvent, error)
+	GetEventsByEntityType(entityType string) ([]types.AuditEvent, error)
+	GetRecentEvents(limit int) ([]types.AuditEvent, error)
+}
+
 // UserPreferencesStoreInterface defines the contract for user preferences operations
 type UserPreferencesStoreInterface interface {
 	// Core Operations
@@ -114,6 +137,40 @@ type UserPreferencesStoreInterface interface {
 	// Convenience Operations
 	HasPreference(key string) bool
 	GetPreferenceWithDefault(key, defaultValue string) string
+
+	// Currency Settings
+	GetBaseCurrency() string
+	SetBaseCurrency(currency string) error
+	GetTrashRetentionDays() int
+	SetTrashRetentionDays(days int) error
+}
+
+// AccountStoreInterface defines the contract for account operations
+type AccountStoreInterface interface {
+	// CRUD Operations
+	GetAccounts() ([]types.Account, error)
+	GetAccountById(id int64) *types.Account
+	GetAccountByName(name string) *types.Account
+	CreateAccount(account types.Account) *AccountResult
+	UpdateAccount(account types.Account) error
+	DeleteAccount(id int64) *AccountResult
+
+	// Reporting
+	GetAccountBalances() ([]types.AccountBalance, error)
+}
+
+// ExchangeRateStoreInterface defines the contract for exchange rate operations
+type ExchangeRateStoreInterface interface {
+	// CRUD Operations
+	GetRates() ([]types.ExchangeRate, error)
+	SaveRate(rate types.ExchangeRate) error
+
+	// Conversion Operations
+	GetRate(from, to string, date time.Time) (float64, error)
+	Convert(amount types.Money, to string, date time.Time) (types.Money, error)
+
+	// Import Operations
+	ImportRatesCSV(filePath string) (*RateImportResult, error)
 }
 
 // SnapshotStoreInterface defines the contract for snapshot operations
@@ -199,3 +256,35 @@ type TemplateResult struct {
 	Success bool
 	Message string
 }
+
+type AccountResult struct {
+	Success   bool
+	Message   string
+	AccountId int64
+}
+
+type RateImportResult struct {
+	Success    bool
+	Message    string
+	Imported   int
+	FailedRows []types.RowError
+}
+
+type TransactionSearchResult struct {
+	Transaction            types.Transaction
+	HighlightedDescription string  // Matches wrapped in SearchMatchStart/SearchMatchEnd
+	HighlightedRaw         string  // Raw description highlighted the same way; empty if there is none
+	Rank                   float64 // bm25 score; lower is a better match
+}
+
+// sqlExecer is satisfied by both *sql.DB and *sql.Tx, so inserts can join a caller's transaction
+type sqlExecer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// sqlQuerier is satisfied by both *sql.DB and *sql.Tx, so reads can see a caller's uncommitted writes
+type sqlQuerier interface {
+	sqlExecer
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
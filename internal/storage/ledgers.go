@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"budget-tracker-tui/internal/database"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// maxRecentLedgers caps how many ledger files the recent list remembers
+const maxRecentLedgers = 10
+
+// RecentLedger is a ledger file that was opened before
+type RecentLedger struct {
+	Path       string    `json:"path"`
+	LastOpened time.Time `json:"last_opened"`
+}
+
+// RecentLedgers keeps the list of recently opened ledger files
+// The list lives in its own JSON file because it spans ledgers, so it cannot be stored in any one of them.
+type RecentLedgers struct {
+	filePath string
+}
+
+// NewRecentLedgers creates a RecentLedgers backed by the JSON file at filePath
+func NewRecentLedgers(filePath string) *RecentLedgers {
+	return &RecentLedgers{filePath: filePath}
+}
+
+// DefaultRecentLedgersPath returns ~/.finance-wrapped/recent_ledgers.json
+func DefaultRecentLedgersPath() (string, error) {
+	dataDir, err := database.DefaultDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "recent_ledgers.json"), nil
+}
+
+// List returns the recent ledgers, most recently opened first
+func (rl *RecentLedgers) List() ([]RecentLedger, error) {
+	data, err := os.ReadFile(rl.filePath)
+	if os.IsNotExist(err) {
+		return []RecentLedger{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recent ledgers: %w", err)
+	}
+
+	var ledgers []RecentLedger
+	if err := json.Unmarshal(data, &ledgers); err != nil {
+		return nil, fmt.Errorf("failed to parse recent ledgers: %w", err)
+	}
+	return ledgers, nil
+}
+
+// Add records path as the most recently opened ledger
+func (rl *RecentLedgers) Add(path string) error {
+	ledgers, err := rl.List()
+	if err != nil {
+		// A corrupt list is not worth blocking on; start a fresh one
+		ledgers = []RecentLedger{}
+	}
+
+	updated := []RecentLedger{{Path: path, LastOpened: time.Now()}}
+	for _, ledger := range ledgers {
+		if ledger.Path != path && len(updated) < maxRecentLedgers {
+			updated = append(updated, ledger)
+		}
+	}
+
+	return rl.save(updated)
+}
+
+// Remove forgets path; the ledger file itself is left alone
+func (rl *RecentLedgers) Remove(path string) error {
+	ledgers, err := rl.List()
+	if err != nil {
+		return err
+	}
+
+	updated := []RecentLedger{}
+	for _, ledger := range ledgers {
+		if ledger.Path != path {
+			updated = append(updated, ledger)
+		}
+	}
+
+	return rl.save(updated)
+}
+
+// save writes the recent ledgers list to disk
+func (rl *RecentLedgers) save(ledgers []RecentLedger) error {
+	if err := os.MkdirAll(filepath.Dir(rl.filePath), 0755); err != nil {
+		return fmt.Errorf("failed to create recent ledgers directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(ledgers, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode recent ledgers: %w", err)
+	}
+
+	if err := os.WriteFile(rl.filePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write recent ledgers: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecentLedgersAddAndRemove(t *testing.T) {
+	ledgers := NewRecentLedgers(filepath.Join(t.TempDir(), "nested", "recent_ledgers.json"))
+
+	list, err := ledgers.List()
+	if err != nil {
+		t.Fatalf("List() on missing file failed: %v", err)
+	}
+	if len(list) != 0 {
+		t.Fatalf("Expected empty list, got %d entries", len(list))
+	}
+
+	for _, path := range []string{"/books/household.db", "/books/business.db", "/books/household.db"} {
+		if err := ledgers.Add(path); err != nil {
+			t.Fatalf("Add(%s) failed: %v", path, err)
+		}
+	}
+
+	list, err = ledgers.List()
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("Expected re-added ledger to be deduplicated, got %d entries", len(list))
+	}
+	if list[0].Path != "/books/household.db" || list[1].Path != "/books/business.db" {
+		t.Errorf("Expected most recent first, got %s, %s", list[0].Path, list[1].Path)
+	}
+	if list[0].LastOpened.IsZero() {
+		t.Error("Expected LastOpened to be set")
+	}
+
+	if err := ledgers.Remove("/books/household.db"); err != nil {
+		t.Fatalf("Remove() failed: %v", err)
+	}
+	list, _ = ledgers.List()
+	if len(list) != 1 || list[0].Path != "/books/business.db" {
+		t.Errorf("Unexpected list after remove: %+v", list)
+	}
+}
+
+func TestRecentLedgersCapsListLength(t *testing.T) {
+	ledgers := NewRecentLedgers(filepath.Join(t.TempDir(), "recent_ledgers.json"))
+
+	for i := 0; i < maxRecentLedgers+5; i++ {
+		if err := ledgers.Add(fmt.Sprintf("/books/ledger-%d.db", i)); err != nil {
+			t.Fatalf("Add() failed: %v", err)
+		}
+	}
+
+	list, err := ledgers.List()
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	if len(list) != maxRecentLedgers {
+		t.Fatalf("Expected %d entries, got %d", maxRecentLedgers, len(list))
+	}
+	if list[0].Path != fmt.Sprintf("/books/ledger-%d.db", maxRecentLedgers+4) {
+		t.Errorf("Expected newest ledger first, got %s", list[0].Path)
+	}
+}
+
+func TestRecentLedgersCorruptFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "recent_ledgers.json")
+	if err := os.WriteFile(filePath, []byte("{not json"), 0644); err != nil {
+		t.Fatalf("Failed to write corrupt file: %v", err)
+	}
+	ledgers := NewRecentLedgers(filePath)
+
+	if _, err := ledgers.List(); err == nil {
+		t.Error("Expected List() to report a corrupt file")
+	}
+
+	// Recording a ledger replaces the corrupt list instead of failing
+	if err := ledgers.Add("/books/household.db"); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	list, err := ledgers.List()
+	if err != nil || len(list) != 1 {
+		t.Errorf("Expected fresh list with one entry, got %+v (err %v)", list, err)
+	}
+}
@@ -0,0 +1,478 @@
+package storage
+
+import (
+	"budget-tracker-tui/internal/database"
+	"budget-tracker-tui/internal/types"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MergePlan previews what merging another ledger file into the open ledger will add
+// Categories are matched by display name, templates and accounts by name, and transactions
+// already in the open ledger are skipped using the same rules as CSV duplicate detection.
+type MergePlan struct {
+	SourcePath        string
+	NewCategories     []types.Category      // Source categories with no display name match
+	MatchedCategories int                   // Source categories that map onto existing ones
+	NewTemplates      []types.CSVTemplate   // Source templates with no name match
+	NewAccounts       []types.Account       // Source accounts with no name match
+	NewStatements     []types.BankStatement // Source statements that bring at least one new transaction
+	NewTransactions   []types.Transaction   // Source transactions that will be added, oldest ID first
+	Duplicates        []types.Transaction   // Source transactions already in the open ledger
+
+	source          *mergeSource
+	duplicateOf     map[int64]int64 // Source transaction ID -> matching transaction in the open ledger
+	statementCounts map[int64]int   // Source statement ID -> new transactions it brings
+}
+
+// MergeResult reports what a merge added to the open ledger
+type MergeResult struct {
+	Success           bool
+	Message           string
+	CategoriesAdded   int
+	TemplatesAdded    int
+	AccountsAdded     int
+	StatementsAdded   int
+	TransactionsAdded int
+	DuplicatesSkipped int
+}
+
+// mergeSource holds everything read from the ledger being merged in
+type mergeSource struct {
+	categories   []types.Category
+	templates    []types.CSVTemplate
+	accounts     []types.Account
+	statements   []types.BankStatement
+	transactions []types.Transaction
+}
+
+// mergeableStatementStatuses are the statement states worth carrying over
+// Failed, undone and interrupted imports have nothing to contribute.
+var mergeableStatementStatuses = map[string]bool{"completed": true, "override": true}
+
+// PlanMerge reads another ledger file, such as a snapshot from another machine, and works out
+// what merging it would add. Nothing is written to either ledger.
+func (s *Store) PlanMerge(sourcePath string) (*MergePlan, error) {
+	sourcePath, err := database.ExpandLedgerPath(sourcePath)
+	if err != nil {
+		return nil, err
+	}
+	if sourcePath == s.GetDatabasePath() {
+		return nil, fmt.Errorf("cannot merge the open ledger into itself")
+	}
+
+	sourceVersion, err := database.ReadSnapshotSchemaVersion(sourcePath)
+	if err != nil {
+		return nil, err
+	}
+	latestVersion, err := database.LatestSchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	if sourceVersion > latestVersion {
+		return nil, fmt.Errorf("ledger schema version %d is newer than this application supports (%d)", sourceVersion, latestVersion)
+	}
+
+	source, err := readMergeSource(sourcePath)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &MergePlan{
+		SourcePath:      sourcePath,
+		source:          source,
+		duplicateOf:     make(map[int64]int64),
+		statementCounts: make(map[int64]int),
+	}
+
+	for _, category := range source.categories {
+		if s.Categories.GetCategoryByDisplayName(category.DisplayName) != nil {
+			plan.MatchedCategories++
+		} else {
+			plan.NewCategories = append(plan.NewCategories, category)
+		}
+	}
+	for _, template := range source.templates {
+		if s.Templates.GetTemplateByName(template.Name) == nil {
+			plan.NewTemplates = append(plan.NewTemplates, template)
+		}
+	}
+	for _, account := range source.accounts {
+		if s.Accounts.GetAccountByName(account.Name) == nil {
+			plan.NewAccounts = append(plan.NewAccounts, account)
+		}
+	}
+
+	if err := s.planMergeTransactions(plan); err != nil {
+		return nil, err
+	}
+
+	for _, stmt := range source.statements {
+		if plan.statementCounts[stmt.Id] > 0 && mergeableStatementStatuses[stmt.Status] {
+			plan.NewStatements = append(plan.NewStatements, stmt)
+		}
+	}
+
+	return plan, nil
+}
+
+// planMergeTransactions splits the source transactions into new ones and duplicates
+// As with CSV imports, a transaction is a duplicate while the open ledger still has an unmatched
+// transaction with the same date, amount and description, so repeated identical purchases survive.
+func (s *Store) planMergeTransactions(plan *MergePlan) error {
+	existing := make(map[string][]types.Transaction)
+	seen := make(map[string]int)
+
+	for _, tx := range plan.source.transactions {
+		dateStr := tx.Date.Format("2006-01-02")
+		key := fmt.Sprintf("%s|%d|%s|%s", dateStr, tx.Amount.Cents, currencyCode(tx.Amount), tx.Description)
+
+		matches, ok := existing[key]
+		if !ok {
+			var err error
+			matches, err = s.Transactions.FindDuplicateTransactions(dateStr, tx.Amount, tx.Description)
+			if err != nil {
+				return err
+			}
+			existing[key] = matches
+		}
+
+		occurrence := seen[key]
+		seen[key]++
+		if occurrence < len(matches) {
+			plan.Duplicates = append(plan.Duplicates, tx)
+			plan.duplicateOf[tx.Id] = matches[occurrence].Id
+			continue
+		}
+
+		plan.NewTransactions = append(plan.NewTransactions, tx)
+		if tx.StatementId != 0 {
+			plan.statementCounts[tx.StatementId]++
+		}
+	}
+
+	return nil
+}
+
+// readMergeSource loads the categories, templates, accounts, statements and live transactions
+// of a ledger file from a migrated private copy, leaving the original untouched
+func readMergeSource(sourcePath string) (*mergeSource, error) {
+	conn, cleanup, err := database.OpenLedgerCopy(sourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", filepath.Base(sourcePath), err)
+	}
+	defer cleanup()
+
+	source := &mergeSource{}
+
+	if source.categories, err = NewCategoryStore(conn).GetCategories(); err != nil {
+		return nil, err
+	}
+	if source.templates, err = NewCSVTemplateStore(conn).GetCSVTemplates(); err != nil {
+		return nil, err
+	}
+	if source.accounts, err = NewAccountStore(conn).GetAccounts(); err != nil {
+		return nil, err
+	}
+	source.statements = NewBankStatementStore(conn).GetStatementHistory()
+	if source.transactions, err = NewTransactionStore(conn).GetTransactions(); err != nil {
+		return nil, err
+	}
+
+	// Oldest first, so split parents are inserted before the halves that point at them
+	sort.Slice(source.transactions, func(i, j int) bool {
+		return source.transactions[i].Id < source.transactions[j].Id
+	})
+
+	return source, nil
+}
+
+// ApplyMerge adds everything in plan to the open ledger in one database transaction
+// Missing categories, templates and accounts are created first so every ID in the source can be
+// mapped, then the statements and transactions. If any write fails nothing is added. Once
+// committed, the created records are audited and each transaction gets an import audit event,
+// so they start their history like any other imported transaction.
+func (s *Store) ApplyMerge(plan *MergePlan) (*MergeResult, error) {
+	if plan == nil || plan.source == nil {
+		return nil, fmt.Errorf("no merge plan to apply")
+	}
+
+	var created mergeCreated
+	err := s.db.ExecuteInTransaction(func(dbTx *sql.Tx) error {
+		created = mergeCreated{}
+
+		categoryIds, err := s.mergeCategories(dbTx, plan, &created)
+		if err != nil {
+			return err
+		}
+
+		templateIds := make(map[int64]int64)
+		now := time.Now()
+		for _, template := range plan.source.templates {
+			if existing := s.Templates.getTemplateByName(dbTx, template.Name); existing != nil {
+				templateIds[template.Id] = existing.Id
+				continue
+			}
+			copied := template
+			copied.Id = 0
+			id, err := s.Templates.insertTemplate(dbTx, copied, now)
+			if err != nil {
+				return fmt.Errorf("failed to add template '%s': %w", template.Name, err)
+			}
+			templateIds[template.Id] = id
+			created.templates = append(created.templates, id)
+		}
+
+		accountIds := make(map[int64]int64)
+		for _, account := range plan.source.accounts {
+			if existing := s.Accounts.getAccountByName(dbTx, account.Name); existing != nil {
+				accountIds[account.Id] = existing.Id
+				continue
+			}
+			if validation := account.Validate(); !validation.IsValid {
+				return fmt.Errorf("failed to add account '%s': %s", account.Name, validation.Errors[0].Message)
+			}
+			id, err := s.Accounts.insertAccount(dbTx, account, now)
+			if err != nil {
+				return fmt.Errorf("failed to add account '%s': %w", account.Name, err)
+			}
+			accountIds[account.Id] = id
+			created.accounts++
+		}
+
+		statementIds := make(map[int64]int64)
+		for _, stmt := range plan.NewStatements {
+			templateId, ok := templateIds[stmt.TemplateUsed]
+			if !ok && stmt.TemplateUsed != 0 {
+				return fmt.Errorf("statement '%s' uses a template missing from the merged ledger", stmt.Filename)
+			}
+			periodStart, periodEnd := "", ""
+			if !stmt.PeriodStart.IsZero() {
+				periodStart = stmt.PeriodStart.Format("2006-01-02")
+			}
+			if !stmt.PeriodEnd.IsZero() {
+				periodEnd = stmt.PeriodEnd.Format("2006-01-02")
+			}
+			copied := stmt
+			copied.TemplateUsed = templateId
+			copied.AccountId = accountIds[stmt.AccountId]
+			copied.TxCount = plan.statementCounts[stmt.Id]
+			id, err := s.Statements.insertStatement(dbTx, &copied, periodStart, periodEnd)
+			if err != nil {
+				return fmt.Errorf("failed to add statement '%s': %w", stmt.Filename, err)
+			}
+			statementIds[stmt.Id] = id
+			created.statements = append(created.statements, id)
+		}
+
+		created.transactions, err = s.insertMergedTransactions(dbTx, plan, categoryIds, statementIds, accountIds)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.recordMergeCreated(created)
+
+	result := &MergeResult{
+		Success:           true,
+		CategoriesAdded:   len(created.categories),
+		TemplatesAdded:    len(created.templates),
+		AccountsAdded:     created.accounts,
+		StatementsAdded:   len(created.statements),
+		TransactionsAdded: len(created.transactions),
+		DuplicatesSkipped: len(plan.Duplicates),
+	}
+	result.Message = fmt.Sprintf("Merged %s successfully: %d transactions added, %d duplicates skipped, %d categories, %d templates, %d accounts and %d statements added",
+		filepath.Base(plan.SourcePath), result.TransactionsAdded, result.DuplicatesSkipped,
+		result.CategoriesAdded, result.TemplatesAdded, result.AccountsAdded, result.StatementsAdded)
+	return result, nil
+}
+
+// mergeCreated collects the records a merge created, to audit once it is committed
+type mergeCreated struct {
+	categories   []*types.Category
+	templates    []int64
+	accounts     int
+	statements   []int64
+	transactions []int64
+}
+
+// recordMergeCreated writes the audit events for a committed merge
+func (s *Store) recordMergeCreated(created mergeCreated) {
+	for _, category := range created.categories {
+		s.Audit.recordOrWarn(types.EntityCategory, auditId(category.Id), types.AuditEventCreate, types.SourceUser, nil, category)
+	}
+	for _, id := range created.templates {
+		s.Audit.recordOrWarn(types.EntityCSVTemplate, auditId(id), types.AuditEventCreate, types.SourceUser, nil, s.Templates.GetTemplateById(id))
+	}
+	for _, id := range created.statements {
+		s.Statements.recordStatementChange(id, types.AuditEventCreate, types.SourceImport, nil)
+	}
+	s.recordMergedTransactions(created.transactions)
+}
+
+// mergeCategories maps every source category onto the open ledger, looking up and creating the
+// missing ones through dbTx. Parents are created before their children so the hierarchy carries over.
+func (s *Store) mergeCategories(dbTx *sql.Tx, plan *MergePlan, created *mergeCreated) (map[int64]int64, error) {
+	categoryIds := make(map[int64]int64)
+	sourceIds := make(map[int64]bool)
+	var pending []types.Category
+	for _, category := range plan.source.categories {
+		sourceIds[category.Id] = true
+		if existing := s.Categories.getCategoryByDisplayName(dbTx, category.DisplayName); existing != nil {
+			categoryIds[category.Id] = existing.Id
+		} else {
+			pending = append(pending, category)
+		}
+	}
+
+	existing, err := s.Categories.getCategories(dbTx)
+	if err != nil {
+		return nil, err
+	}
+	for len(pending) > 0 {
+		var waiting []types.Category
+		for _, category := range pending {
+			var parentId *int64
+			if category.ParentId != nil && sourceIds[*category.ParentId] {
+				mapped, ok := categoryIds[*category.ParentId]
+				if !ok {
+					waiting = append(waiting, category)
+					continue
+				}
+				parentId = &mapped
+			}
+
+			added := &types.Category{
+				DisplayName: strings.TrimSpace(category.DisplayName),
+				ParentId:    parentId,
+				Color:       category.Color,
+				IsActive:    true,
+			}
+			if validation := added.Validate(existing); !validation.IsValid {
+				return nil, fmt.Errorf("failed to add category '%s': %s", category.DisplayName, validation.Errors[0].Message)
+			}
+			if err := s.Categories.insertCategory(dbTx, added); err != nil {
+				return nil, fmt.Errorf("failed to add category '%s': %w", category.DisplayName, err)
+			}
+			categoryIds[category.Id] = added.Id
+			created.categories = append(created.categories, added)
+		}
+		if len(waiting) == len(pending) {
+			return nil, fmt.Errorf("category hierarchy in the merged ledger has a cycle")
+		}
+		pending = waiting
+	}
+
+	return categoryIds, nil
+}
+
+// insertMergedTransactions writes the plan's new transactions through dbTx with their IDs remapped
+// Split halves whose parent was a duplicate point at the matching transaction in the open ledger.
+func (s *Store) insertMergedTransactions(dbTx *sql.Tx, plan *MergePlan, categoryIds, statementIds, accountIds map[int64]int64) ([]int64, error) {
+	query := `
+		INSERT INTO transactions (
+			parent_id, amount_cents, currency, description, raw_description, date,
+			category_id, transaction_type, is_split,
+			statement_id, account_id, created_at, updated_at, external_id
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	transactionIds := make(map[int64]int64, len(plan.duplicateOf)+len(plan.NewTransactions))
+	for sourceId, targetId := range plan.duplicateOf {
+		transactionIds[sourceId] = targetId
+	}
+
+	var newIds []int64
+	now := time.Now()
+	for _, tx := range plan.NewTransactions {
+		var parentID interface{}
+		if tx.ParentId != nil {
+			if mapped, ok := transactionIds[*tx.ParentId]; ok {
+				parentID = mapped
+			}
+		}
+
+		categoryId, ok := categoryIds[tx.CategoryId]
+		if !ok {
+			categoryId = s.Categories.GetDefaultCategoryId()
+		}
+
+		var statementID interface{}
+		if mapped, ok := statementIds[tx.StatementId]; ok {
+			statementID = mapped
+		}
+
+		var accountID interface{}
+		if mapped, ok := accountIds[tx.AccountId]; ok {
+			accountID = mapped
+		}
+
+		var rawDescription interface{}
+		if tx.RawDescription != "" {
+			rawDescription = tx.RawDescription
+		}
+
+		var externalID interface{}
+		if tx.ExternalId != "" {
+			externalID = tx.ExternalId
+		}
+
+		createdAt := tx.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = now
+		}
+
+		res, err := dbTx.Exec(query,
+			parentID, tx.Amount.Cents, currencyCode(tx.Amount), tx.Description, rawDescription, tx.Date.Format("2006-01-02"),
+			categoryId, tx.TransactionType, tx.IsSplit,
+			statementID, accountID, createdAt.Format(time.RFC3339), now.Format(time.RFC3339), externalID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert merged transaction '%s': %w", tx.Description, err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read merged transaction ID: %w", err)
+		}
+		transactionIds[tx.Id] = id
+		newIds = append(newIds, id)
+	}
+
+	return newIds, nil
+}
+
+// recordMergedTransactions starts each merged transaction's history with an import event
+func (s *Store) recordMergedTransactions(ids []int64) {
+	for _, id := range ids {
+		merged := s.Transactions.GetTransactionByID(id)
+		if merged == nil {
+			continue
+		}
+		s.Transactions.recordAuditEvent(&types.TransactionAuditEvent{
+			TransactionId:          id,
+			BankStatementId:        merged.StatementId,
+			Timestamp:              time.Now(),
+			ActionType:             types.ActionTypeImport,
+			Source:                 types.SourceImport,
+			DescriptionFingerprint: merged.Description,
+			CategoryAssigned:       merged.CategoryId,
+			PreviousCategory:       merged.CategoryId,
+			PostEditSnapshot:       types.EncodeTransactionSnapshot(merged),
+		})
+	}
+}
+
+// SourceCategoryName returns the display name a source transaction's category has in the merged ledger
+func (p *MergePlan) SourceCategoryName(categoryId int64) string {
+	for _, category := range p.source.categories {
+		if category.Id == categoryId {
+			return category.DisplayName
+		}
+	}
+	return "Uncategorized"
+}
@@ -0,0 +1,228 @@
+package storage
+
+import (
+	"budget-tracker-tui/internal/types"
+	"path/filepath"
+	"testing"
+)
+
+// openMergeTestLedger opens a fresh ledger file at path, closed when the test ends
+func openMergeTestLedger(t *testing.T, path string) *Store {
+	t.Helper()
+
+	store := NewStoreAt(path)
+	if err := store.Init(); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// TestMainStoreMergeLedger tests previewing and merging a snapshot from another ledger
+func TestMainStoreMergeLedger(t *testing.T) {
+	dir := t.TempDir()
+	target := openMergeTestLedger(t, filepath.Join(dir, "mine.db"))
+	source := openMergeTestLedger(t, filepath.Join(dir, "partner.db"))
+
+	// The partner has one transaction we already have, a new category and a statement
+	// holding two identical coffees, both of which are real purchases
+	for _, store := range []*Store{target, source} {
+		if result := store.Categories.CreateCategory("Groceries"); !result.Success {
+			t.Fatalf("failed to create category: %s", result.Message)
+		}
+	}
+	shared := target.Categories.GetCategoryByDisplayName("Groceries")
+	if err := target.Transactions.SaveTransaction(createTestTransaction(12.50, "Bakery", shared.Id)); err != nil {
+		t.Fatalf("SaveTransaction() failed: %v", err)
+	}
+
+	if result := source.Categories.CreateCategory("Coffee"); !result.Success {
+		t.Fatalf("failed to create category: %s", result.Message)
+	}
+	coffee := source.Categories.GetCategoryByDisplayName("Coffee")
+	templates, err := source.Templates.GetCSVTemplates()
+	if err != nil || len(templates) == 0 {
+		t.Fatalf("expected default templates in the source ledger: %v", err)
+	}
+	statementId, err := source.Statements.RecordBankStatement("partner.csv", "2024-01-01", "2024-01-31",
+		templates[0].Id, 0, 2, "completed")
+	if err != nil {
+		t.Fatalf("RecordBankStatement() failed: %v", err)
+	}
+
+	sourceFood := source.Categories.GetCategoryByDisplayName("Groceries")
+	if err := source.Transactions.SaveTransaction(createTestTransaction(12.50, "Bakery", sourceFood.Id)); err != nil {
+		t.Fatalf("SaveTransaction() failed: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		tx := createTestTransaction(3.75, "Coffee Shop", coffee.Id)
+		tx.StatementId = statementId
+		if err := source.Transactions.SaveTransaction(tx); err != nil {
+			t.Fatalf("SaveTransaction() failed: %v", err)
+		}
+	}
+
+	snapshotPath := filepath.Join(dir, "partner-snapshot.db")
+	if err := source.Snapshots.CreateSnapshotFile(snapshotPath); err != nil {
+		t.Fatalf("CreateSnapshotFile() failed: %v", err)
+	}
+
+	plan, err := target.PlanMerge(snapshotPath)
+	if err != nil {
+		t.Fatalf("PlanMerge() failed: %v", err)
+	}
+	if len(plan.NewTransactions) != 2 || len(plan.Duplicates) != 1 {
+		t.Fatalf("Expected 2 new transactions and 1 duplicate, got %d and %d", len(plan.NewTransactions), len(plan.Duplicates))
+	}
+	if len(plan.NewCategories) != 1 || plan.NewCategories[0].DisplayName != "Coffee" {
+		t.Errorf("Expected only Coffee to be a new category, got %+v", plan.NewCategories)
+	}
+	if len(plan.NewStatements) != 1 || len(plan.NewTemplates) != 0 {
+		t.Errorf("Expected 1 new statement and no new templates, got %d and %d", len(plan.NewStatements), len(plan.NewTemplates))
+	}
+	if count, _ := target.Transactions.CountTransactions(TransactionQuery{}); count != 1 {
+		t.Fatalf("PlanMerge() must not write to the open ledger, have %d transactions", count)
+	}
+
+	result, err := target.ApplyMerge(plan)
+	if err != nil {
+		t.Fatalf("ApplyMerge() failed: %v", err)
+	}
+	if result.TransactionsAdded != 2 || result.CategoriesAdded != 1 || result.StatementsAdded != 1 {
+		t.Errorf("Unexpected merge result: %+v", result)
+	}
+
+	merged := target.Categories.GetCategoryByDisplayName("Coffee")
+	if merged == nil {
+		t.Fatal("Coffee category missing after merge")
+	}
+	coffees, err := target.Transactions.QueryTransactions(TransactionQuery{CategoryIds: []int64{merged.Id}})
+	if err != nil {
+		t.Fatalf("QueryTransactions() failed: %v", err)
+	}
+	if len(coffees) != 2 {
+		t.Fatalf("Expected both coffees in the merged category, got %d", len(coffees))
+	}
+	statement, err := target.Statements.GetStatementById(coffees[0].StatementId)
+	if err != nil || statement.Filename != "partner.csv" || statement.TxCount != 2 {
+		t.Errorf("Expected merged coffees to belong to the copied statement, got %+v (%v)", statement, err)
+	}
+	events, err := target.TransactionAudits.GetEventsByTransaction(coffees[0].Id)
+	if err != nil || len(events) != 1 || events[0].ActionType != types.ActionTypeImport {
+		t.Errorf("Expected one import audit event for a merged transaction, got %+v (%v)", events, err)
+	}
+
+	// Merging the same file again finds nothing new
+	plan, err = target.PlanMerge(snapshotPath)
+	if err != nil {
+		t.Fatalf("PlanMerge() failed: %v", err)
+	}
+	if len(plan.NewTransactions) != 0 || len(plan.NewCategories) != 0 || len(plan.NewStatements) != 0 {
+		t.Errorf("Expected a second merge to add nothing, got %d transactions, %d categories, %d statements",
+			len(plan.NewTransactions), len(plan.NewCategories), len(plan.NewStatements))
+	}
+
+	if _, err := target.PlanMerge(target.GetDatabasePath()); err == nil {
+		t.Error("Expected merging the open ledger into itself to fail")
+	}
+}
+
+// TestApplyMergeIsAtomic tests that a merge failing part way adds nothing to the open ledger
+func TestApplyMergeIsAtomic(t *testing.T) {
+	dir := t.TempDir()
+	target := openMergeTestLedger(t, filepath.Join(dir, "mine.db"))
+	source := openMergeTestLedger(t, filepath.Join(dir, "partner.db"))
+
+	if result := source.Categories.CreateCategory("Coffee"); !result.Success {
+		t.Fatalf("failed to create category: %s", result.Message)
+	}
+	coffee := source.Categories.GetCategoryByDisplayName("Coffee")
+	if result := source.Accounts.CreateAccount(types.Account{Name: "Partner Checking"}); !result.Success {
+		t.Fatalf("failed to create account: %s", result.Message)
+	}
+	template := types.CSVTemplate{Name: "PartnerBank", PostDateColumn: 0, AmountColumn: 1, DescColumn: 2}
+	if result := source.Templates.CreateCSVTemplate(template); !result.Success {
+		t.Fatalf("failed to create template: %s", result.Message)
+	}
+	statementId, err := source.Statements.RecordBankStatement("partner.csv", "2024-01-01", "2024-01-31",
+		source.Templates.GetTemplateByName("PartnerBank").Id, 0, 1, "completed")
+	if err != nil {
+		t.Fatalf("RecordBankStatement() failed: %v", err)
+	}
+	tx := createTestTransaction(3.75, "Coffee Shop", coffee.Id)
+	tx.StatementId = statementId
+	if err := source.Transactions.SaveTransaction(tx); err != nil {
+		t.Fatalf("SaveTransaction() failed: %v", err)
+	}
+
+	snapshotPath := filepath.Join(dir, "partner-snapshot.db")
+	if err := source.Snapshots.CreateSnapshotFile(snapshotPath); err != nil {
+		t.Fatalf("CreateSnapshotFile() failed: %v", err)
+	}
+	plan, err := target.PlanMerge(snapshotPath)
+	if err != nil {
+		t.Fatalf("PlanMerge() failed: %v", err)
+	}
+	auditsBefore, err := target.Audit.GetRecentEvents(1000)
+	if err != nil {
+		t.Fatalf("GetRecentEvents() failed: %v", err)
+	}
+
+	// Every transaction insert fails, after the categories, template, account and statement are written
+	if _, err := target.db.DB.Exec(`CREATE TRIGGER fail_merge BEFORE INSERT ON transactions
+		BEGIN SELECT RAISE(ABORT, 'disk full'); END`); err != nil {
+		t.Fatalf("failed to create trigger: %v", err)
+	}
+	if _, err := target.ApplyMerge(plan); err == nil {
+		t.Fatal("Expected ApplyMerge() to fail")
+	}
+
+	if target.Categories.GetCategoryByDisplayName("Coffee") != nil {
+		t.Error("Expected the Coffee category to be rolled back")
+	}
+	if target.Templates.GetTemplateByName("PartnerBank") != nil {
+		t.Error("Expected the PartnerBank template to be rolled back")
+	}
+	if target.Accounts.GetAccountByName("Partner Checking") != nil {
+		t.Error("Expected the Partner Checking account to be rolled back")
+	}
+	if statements := target.Statements.GetStatementHistory(); len(statements) != 0 {
+		t.Errorf("Expected no statements, got %+v", statements)
+	}
+	if auditsAfter, _ := target.Audit.GetRecentEvents(1000); len(auditsAfter) != len(auditsBefore) {
+		t.Errorf("Expected no audit events for a failed merge, got %d new", len(auditsAfter)-len(auditsBefore))
+	}
+}
+
+func TestApplyMergeLooksUpThroughItsTransaction(t *testing.T) {
+	dir := t.TempDir()
+	target := openMergeTestLedger(t, filepath.Join(dir, "mine.db"))
+	source := openMergeTestLedger(t, filepath.Join(dir, "partner.db"))
+
+	// Account names match case-insensitively, so the second account is the one the merge just added
+	for _, name := range []string{"Joint", "JOINT"} {
+		if _, err := source.db.DB.Exec("INSERT INTO accounts (name) VALUES (?)", name); err != nil {
+			t.Fatalf("failed to add account '%s': %v", name, err)
+		}
+	}
+
+	snapshotPath := filepath.Join(dir, "partner-snapshot.db")
+	if err := source.Snapshots.CreateSnapshotFile(snapshotPath); err != nil {
+		t.Fatalf("CreateSnapshotFile() failed: %v", err)
+	}
+	plan, err := target.PlanMerge(snapshotPath)
+	if err != nil {
+		t.Fatalf("PlanMerge() failed: %v", err)
+	}
+	result, err := target.ApplyMerge(plan)
+	if err != nil {
+		t.Fatalf("ApplyMerge() failed: %v", err)
+	}
+
+	if result.AccountsAdded != 1 {
+		t.Errorf("Expected 1 account added, got %d", result.AccountsAdded)
+	}
+	if accounts, _ := target.Accounts.GetAccounts(); len(accounts) != 1 {
+		t.Errorf("Expected both source accounts merged into one, got %+v", accounts)
+	}
+}
@@ -0,0 +1,418 @@
+package storage
+
+import (
+	"budget-tracker-tui/internal/types"
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// mt940TagPattern matches the start of a field line such as ":61:" or ":60F:"
+var mt940TagPattern = regexp.MustCompile(`^:(\d{2}[A-Z]?):(.*)$`)
+
+// mt940LinePattern splits a :61: statement line into value date, optional entry date, debit/credit
+// mark, optional funds code, amount, transaction type, customer reference, bank reference and
+// supplementary details
+var mt940LinePattern = regexp.MustCompile(`^(\d{6})(\d{4})?(RC|RD|C|D)([A-Z])?(\d+,\d*)([NFS][A-Z0-9]{3})([^/\n]*)(?://([^\n]*))?(?:\n(.*))?$`)
+
+// mt940StructuredKeys are the keys of the "/KEY/value" form of :86: used by Dutch and other banks
+var mt940StructuredKeys = []string{"TRTP", "NAME", "REMI", "EREF", "IBAN", "BIC", "CSID", "MARF", "ORDP", "BENM", "ADDR", "PURP", "ULTC", "ULTD", "SVCL", "RTRN"}
+
+// mt940Field is one tagged field with its continuation lines
+type mt940Field struct {
+	tag, value string
+	line       int
+}
+
+// mt940Entry is a :61: line with the :86: information that follows it
+type mt940Entry struct {
+	tx           types.Transaction
+	customerRef  string
+	supplemental string
+	information  string
+	line         int
+}
+
+// ParseMT940 reads a SWIFT MT940 customer statement
+// Files often hold one message per day; their entries are read together, with the opening
+// balance of the first and the closing balance of the last. Messages for accounts other than the
+// first one named by :25: are skipped.
+func ParseMT940(r io.Reader) (*types.StatementFile, error) {
+	fields, err := readMT940Fields(r)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &types.StatementFile{Format: types.StatementFormatMT940}
+	var entries []*mt940Entry
+	var last *mt940Entry
+	sawAccount, skipping, skipped := false, false, 0
+	for _, field := range fields {
+		switch field.tag {
+		case "20":
+			last = nil
+		case "25":
+			account := strings.TrimSpace(field.value)
+			if !sawAccount {
+				file.AccountNumber = account
+				sawAccount = true
+			}
+			skipping = normalizeAccountNumber(account) != normalizeAccountNumber(file.AccountNumber)
+			if skipping {
+				skipped++
+			}
+			last = nil
+		}
+		if skipping {
+			continue
+		}
+
+		switch field.tag {
+		case "60F", "60M":
+			if file.OpeningBalance != nil {
+				continue
+			}
+			balance, date, err := parseMT940Balance(field.value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid opening balance: %w", field.line, err)
+			}
+			file.OpeningBalance = &balance
+			file.Currency = balance.Currency
+			file.PeriodStart = date
+		case "62F", "62M":
+			balance, date, err := parseMT940Balance(field.value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid closing balance: %w", field.line, err)
+			}
+			file.ClosingBalance = &balance
+			file.PeriodEnd = date
+			if file.Currency == "" {
+				file.Currency = balance.Currency
+			}
+			last = nil
+		case "61":
+			currency := file.Currency
+			if currency == "" {
+				currency = types.DefaultCurrency
+			}
+			entry, err := parseMT940Line(field.value, currency)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", field.line, err)
+			}
+			entry.line = field.line
+			entries = append(entries, entry)
+			last = entry
+		case "86":
+			// Information after a :61: line describes that entry; elsewhere it describes the statement
+			if last != nil {
+				last.information = field.value
+			}
+		}
+	}
+
+	if !sawAccount {
+		return nil, fmt.Errorf("not an MT940 statement: no :25: account identification found")
+	}
+	if skipped > 0 {
+		slog.Warn("MT940 file holds statements for several accounts, importing the first", "account", file.AccountNumber, "skipped", skipped)
+	}
+	if file.Currency == "" {
+		file.Currency = types.DefaultCurrency
+	}
+
+	for _, entry := range entries {
+		if err := describeMT940Entry(entry); err != nil {
+			return nil, fmt.Errorf("line %d: %w", entry.line, err)
+		}
+		addStatementTransaction(file, entry.tx)
+	}
+	clearRepeatedExternalIds(file.Transactions)
+	return file, nil
+}
+
+// readMT940Fields splits the message text into tagged fields, dropping the SWIFT header and
+// trailer blocks that wrap each message when it comes straight from the network
+func readMT940Fields(r io.Reader) ([]mt940Field, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var fields []mt940Field
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if lineNum == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+
+		if strings.HasPrefix(line, "{") {
+			// "{1:...}{2:...}{4:" opens a message; anything after {4: is message text
+			_, text, ok := strings.Cut(line, "{4:")
+			if !ok || strings.TrimSpace(text) == "" {
+				continue
+			}
+			line = text
+		}
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed == "-" || strings.HasPrefix(trimmed, "-}") {
+			continue
+		}
+
+		if match := mt940TagPattern.FindStringSubmatch(line); match != nil {
+			fields = append(fields, mt940Field{tag: match[1], value: match[2], line: lineNum})
+			continue
+		}
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("line %d: not an MT940 statement: expected a field such as :20:", lineNum)
+		}
+		fields[len(fields)-1].value += "\n" + line
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read MT940 file: %w", err)
+	}
+	return fields, nil
+}
+
+// parseMT940Balance reads a :60F: or :62F: balance such as "C240131EUR1234,56"
+func parseMT940Balance(value string) (types.Money, time.Time, error) {
+	value = strings.TrimSpace(value)
+	if len(value) < 11 {
+		return types.Money{}, time.Time{}, fmt.Errorf("'%s' is too short", value)
+	}
+
+	date, err := parseMT940Date(value[1:7])
+	if err != nil {
+		return types.Money{}, time.Time{}, err
+	}
+	amount, err := parseMT940Amount(value[10:], strings.ToUpper(value[7:10]))
+	if err != nil {
+		return types.Money{}, time.Time{}, err
+	}
+
+	switch value[0] {
+	case 'C':
+	case 'D':
+		amount = amount.Neg()
+	default:
+		return types.Money{}, time.Time{}, fmt.Errorf("unknown debit/credit mark '%c'", value[0])
+	}
+	return amount, date, nil
+}
+
+// parseMT940Line reads a :61: statement line
+// The transaction is dated by its entry (booking) date when the line gives one, otherwise by its
+// value date. Reversal marks flip the sign: RC reverses a credit and is money out.
+func parseMT940Line(value, currency string) (*mt940Entry, error) {
+	match := mt940LinePattern.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return nil, fmt.Errorf("invalid :61: statement line '%s'", strings.SplitN(value, "\n", 2)[0])
+	}
+	entry := &mt940Entry{
+		customerRef:  strings.TrimSpace(match[7]),
+		supplemental: strings.TrimSpace(match[9]),
+	}
+	tx := &entry.tx
+
+	valueDate, err := parseMT940Date(match[1])
+	if err != nil {
+		return nil, err
+	}
+	tx.Date = valueDate
+	if match[2] != "" {
+		if tx.Date, err = mt940EntryDate(valueDate, match[2]); err != nil {
+			return nil, err
+		}
+	}
+
+	tx.Amount, err = parseMT940Amount(match[5], currency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount: %w", err)
+	}
+	if mark := match[3]; mark == "D" || mark == "RC" {
+		tx.Amount = tx.Amount.Neg()
+	}
+	tx.TransactionType = statementTransactionType(tx.Amount)
+
+	// Only the bank's reference identifies the entry. The account owner's reference often repeats,
+	// such as a standing order's mandate in every month's statement, so without a bank reference
+	// the entry falls back to the date, amount and description match.
+	tx.ExternalId = strings.TrimSpace(match[8])
+	return entry, nil
+}
+
+// mt940EntryDate places the MMDD entry date in the year of the value date, or the year either
+// side of it when the booking crosses New Year
+func mt940EntryDate(valueDate time.Time, mmdd string) (time.Time, error) {
+	month, _ := strconv.Atoi(mmdd[:2])
+	day, _ := strconv.Atoi(mmdd[2:])
+	year := valueDate.Year()
+	switch {
+	case month == 12 && valueDate.Month() == time.January:
+		year--
+	case month == 1 && valueDate.Month() == time.December:
+		year++
+	}
+
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if int(date.Month()) != month || date.Day() != day {
+		return time.Time{}, fmt.Errorf("invalid entry date '%s'", mmdd)
+	}
+	return date, nil
+}
+
+// describeMT940Entry sets the description from the entry's :86: information
+// As with camt files the remittance information is the description, falling back to the
+// counterparty and then whatever text the line carries; the raw description adds the counterparty.
+func describeMT940Entry(entry *mt940Entry) error {
+	remittance, counterparty, other := parseMT940Information(entry.information)
+
+	tx := &entry.tx
+	for _, candidate := range []string{remittance, counterparty, other, entry.supplemental, entry.customerRef} {
+		if tx.Description == "" && !strings.EqualFold(candidate, "NONREF") {
+			tx.Description = strings.TrimSpace(candidate)
+		}
+	}
+	if tx.Description == "" {
+		return fmt.Errorf("empty description not allowed")
+	}
+
+	tx.RawDescription = tx.Description
+	if counterparty != "" && counterparty != tx.Description {
+		tx.RawDescription = counterparty + " " + tx.Description
+	}
+	return nil
+}
+
+// parseMT940Information reads a :86: field in one of its three common forms: German "?20"
+// subfields, "/NAME/.../REMI/..." keys, or free text, which is returned as other
+func parseMT940Information(info string) (remittance, counterparty, other string) {
+	info = strings.TrimSpace(info)
+	if info == "" {
+		return "", "", ""
+	}
+
+	// Structured forms wrap at 65 characters mid-word, so their lines join without a space
+	joined := strings.ReplaceAll(info, "\n", "")
+	if len(joined) > 4 && strings.Contains(joined[:4], "?") {
+		return parseMT940Subfields(joined)
+	}
+	if strings.HasPrefix(joined, "/") {
+		if values := parseMT940Keys(joined); len(values) > 0 {
+			remittance = values["REMI"]
+			for _, prefix := range []string{"USTD//", "USTD/", "STRD/"} {
+				remittance = strings.TrimPrefix(remittance, prefix)
+			}
+			return strings.Trim(remittance, "/ "), strings.Trim(values["NAME"], "/ "), ""
+		}
+	}
+	return "", "", strings.Join(strings.Fields(info), " ")
+}
+
+// parseMT940Subfields reads the German structured form, such as
+// "166?00GUTSCHRIFT?20Invoice 123?32ACME GmbH": ?20-?29 and ?60-?63 hold the purpose,
+// ?32-?33 the counterparty and ?00 the posting text
+func parseMT940Subfields(info string) (remittance, counterparty, other string) {
+	var purpose, names []string
+	for _, part := range strings.Split(info, "?")[1:] {
+		if len(part) < 2 {
+			continue
+		}
+		code, err := strconv.Atoi(part[:2])
+		if err != nil {
+			continue
+		}
+		// Subfields continue each other mid-word, so only the joined text is trimmed
+		text := part[2:]
+		switch {
+		case code == 0:
+			other = strings.TrimSpace(text)
+		case code >= 20 && code <= 29, code >= 60 && code <= 63:
+			purpose = append(purpose, text)
+		case code == 32 || code == 33:
+			names = append(names, text)
+		}
+	}
+	return strings.TrimSpace(strings.Join(purpose, "")), strings.TrimSpace(strings.Join(names, "")), other
+}
+
+// parseMT940Keys reads the "/KEY/value" form, returning the value of each key found
+func parseMT940Keys(info string) map[string]string {
+	type marker struct {
+		key        string
+		start, end int
+	}
+	var markers []marker
+	for _, key := range mt940StructuredKeys {
+		search := 0
+		for {
+			i := strings.Index(info[search:], "/"+key+"/")
+			if i < 0 {
+				break
+			}
+			start := search + i
+			markers = append(markers, marker{key: key, start: start, end: start + len(key) + 2})
+			search = start + 1
+		}
+	}
+	sort.Slice(markers, func(i, j int) bool { return markers[i].start < markers[j].start })
+
+	values := make(map[string]string)
+	for i, m := range markers {
+		if i > 0 && m.start < markers[i-1].end {
+			continue // A key name inside the previous key's marker
+		}
+		end := len(info)
+		if i+1 < len(markers) {
+			end = markers[i+1].start
+		}
+		if _, ok := values[m.key]; !ok && m.end <= end {
+			values[m.key] = info[m.end:end]
+		}
+	}
+	return values
+}
+
+// clearRepeatedExternalIds drops references that more than one row shares
+// Some banks repeat a reference across a file; those rows fall back to matching on date, amount
+// and description rather than being taken for duplicates of each other.
+func clearRepeatedExternalIds(transactions []types.Transaction) {
+	counts := make(map[string]int)
+	for _, tx := range transactions {
+		if tx.ExternalId != "" {
+			counts[tx.ExternalId]++
+		}
+	}
+	for i := range transactions {
+		if counts[transactions[i].ExternalId] > 1 {
+			transactions[i].ExternalId = ""
+		}
+	}
+}
+
+// parseMT940Amount parses an unsigned MT940 amount, which always uses a decimal comma
+// and may end in it ("100,")
+func parseMT940Amount(value, currency string) (types.Money, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasSuffix(value, ",") {
+		value += "00"
+	}
+	return parseStatementAmount(value, currency)
+}
+
+// parseMT940Date reads a YYMMDD date; SWIFT years are in the 2000s below 70
+func parseMT940Date(value string) (time.Time, error) {
+	if len(value) != 6 {
+		return time.Time{}, fmt.Errorf("invalid date '%s'", value)
+	}
+	date, err := time.Parse("060102", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date '%s'", value)
+	}
+	return date, nil
+}
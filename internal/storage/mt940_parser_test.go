@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"budget-tracker-tui/internal/types"
+)
+
+// sampleMT940 holds two daily messages for one account, with German and Dutch style :86: fields,
+// and a third message for another account that must be skipped
+const sampleMT940 = `{1:F01BANKDEFFXXXX0000000000}{2:O9400000240102BANKDEFFXXXX00000000002401020000N}{4:
+:20:STMT240101
+:25:37040044/0532013000
+:28C:1/1
+:60F:C231231EUR1000,00
+:61:2401020102D42,50NMSCNONREF//B24010200001
+:86:106?00KARTENZAHLUNG?20Grocery purchase?21 card 1234?32GROCERY
+?33 STORE GMBH
+:61:2401020102C2500,NTRFSALARY-JAN//B24010200002
+:86:/TRTP/SEPA OVERBOEKING/IBAN/NL81ASNB9999999999/BIC/ASNBNL21/NAME/Employ
+er Ltd/REMI/USTD//Salary January/EREF/NOTPROVIDED
+:62F:C240102EUR3457,50
+-}
+{1:F01BANKDEFFXXXX0000000000}{2:O9400000240103BANKDEFFXXXX00000000002401030000N}{4:
+:20:STMT240102
+:25:37040044/0532013000
+:28C:2/1
+:60F:C240102EUR3457,50
+:61:2401030103RC7,50NCHGNONREF
+Fee refund
+:86:Account fee
+ correction
+:62F:C240103EUR3450,00
+-}
+{4:
+:20:OTHER
+:25:NL81ASNB9999999999
+:60F:C240101EUR0,
+:61:240102D1,00NMSCNONREF
+:86:Should be skipped
+:62F:D240102EUR1,00
+-}
+`
+
+// TestParseMT940 tests reading balances, statement lines and their information fields
+func TestParseMT940(t *testing.T) {
+	file, err := ParseMT940(strings.NewReader(sampleMT940))
+	if err != nil {
+		t.Fatalf("ParseMT940() failed: %v", err)
+	}
+
+	if file.Format != types.StatementFormatMT940 || file.Currency != "EUR" || file.AccountNumber != "37040044/0532013000" {
+		t.Errorf("Unexpected statement header: %+v", file)
+	}
+	if file.OpeningBalance == nil || file.OpeningBalance.Cents != 100000 || file.ClosingBalance == nil || file.ClosingBalance.Cents != 345000 {
+		t.Errorf("Expected the first opening and last closing balance, got %+v and %+v", file.OpeningBalance, file.ClosingBalance)
+	}
+	if !file.PeriodStart.Equal(time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)) || !file.PeriodEnd.Equal(time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the period from the balance dates, got %v to %v", file.PeriodStart, file.PeriodEnd)
+	}
+	if len(file.Transactions) != 3 {
+		t.Fatalf("Expected 3 transactions from the first account, got %d", len(file.Transactions))
+	}
+
+	grocery := file.Transactions[0]
+	if grocery.Amount.Cents != -4250 || grocery.TransactionType != "expense" || grocery.ExternalId != "B24010200001" {
+		t.Errorf("Unexpected debit: %+v", grocery)
+	}
+	if grocery.Description != "Grocery purchase card 1234" || grocery.RawDescription != "GROCERY STORE GMBH Grocery purchase card 1234" {
+		t.Errorf("Expected the ?20 purpose as description, got %q and %q", grocery.Description, grocery.RawDescription)
+	}
+
+	salary := file.Transactions[1]
+	if salary.Amount.Cents != 250000 || salary.TransactionType != "income" || salary.Description != "Salary January" {
+		t.Errorf("Expected the REMI text of a credit, got %+v", salary)
+	}
+	if salary.RawDescription != "Employer Ltd Salary January" {
+		t.Errorf("Expected the wrapped NAME in the raw description, got %q", salary.RawDescription)
+	}
+
+	refund := file.Transactions[2]
+	if refund.Amount.Cents != -750 || refund.Description != "Account fee correction" || refund.ExternalId != "" {
+		t.Errorf("Expected a reversed credit as money out without a reference, got %+v", refund)
+	}
+	if refund.Date.Format("2006-01-02") != "2024-01-03" {
+		t.Errorf("Expected 2024-01-03, got %v", refund.Date)
+	}
+
+	// The entry date may fall in the year before the value date
+	entry, err := parseMT940Line("2401011231D1,00NMSCNONREF", "EUR")
+	if err != nil || entry.tx.Date.Format("2006-01-02") != "2023-12-31" {
+		t.Errorf("Expected the booking on 2023-12-31, got %+v (%v)", entry, err)
+	}
+
+	// A customer reference alone does not identify the entry; a standing order repeats it monthly
+	entry, err = parseMT940Line("240201D850,00NSTORENT-MANDATE-7", "EUR")
+	if err != nil || entry.tx.ExternalId != "" || entry.customerRef != "RENT-MANDATE-7" {
+		t.Errorf("Expected no external ID from the customer reference, got %+v (%v)", entry, err)
+	}
+
+	invalid := []string{
+		"Date,Amount,Description\n2024-01-01,1.00,Coffee\n",
+		":20:X\n:60F:C240101EUR1,00\n",
+		":20:X\n:25:123456\n:61:2401X2D1,00NMSC\n",
+		":20:X\n:25:123456\n:61:240102D1,00NMSCNONREF\n",
+	}
+	for _, content := range invalid {
+		if _, err := ParseMT940(strings.NewReader(content)); err == nil {
+			t.Errorf("Expected an error for %q", content)
+		}
+	}
+}
+
+// TestMainStoreImportMT940 tests importing an MT940 file into the account its :25: field names
+func TestMainStoreImportMT940(t *testing.T) {
+	store, conn := setupTestMainStore(t)
+	defer teardownTestDB(t, conn)
+
+	createTestAccount(t, store.Accounts, "Personal")
+	accountId := createTestAccount(t, store.Accounts, "Business")
+	account := store.Accounts.GetAccountById(accountId)
+	account.AccountNumber = "DE89370400440532013000"
+	if err := store.Accounts.UpdateAccount(*account); err != nil {
+		t.Fatalf("UpdateAccount() failed: %v", err)
+	}
+
+	filePath := createTestCSVFile(t, "statement.sta", sampleMT940)
+	matched := store.StatementFileAccount(filePath)
+	if matched == nil || matched.Id != accountId {
+		t.Fatalf("Expected the :25: account to match Business, got %+v", matched)
+	}
+
+	result := store.ImportStatementFile(filePath, matched.Id)
+	if !result.Success || result.ImportedCount != 3 {
+		t.Fatalf("Expected 3 imported transactions, got %+v", result)
+	}
+	stmt := store.Statements.GetStatementHistory()[0]
+	if stmt.Format != types.StatementFormatMT940 || stmt.AccountId != accountId || stmt.ClosingBalance == nil || stmt.ClosingBalance.Cents != 345000 {
+		t.Errorf("Unexpected statement: %+v", stmt)
+	}
+
+	// The refund has no bank reference and is recognised by its date, amount and description
+	again := store.ImportStatementFile(filePath, accountId)
+	if again.Success || again.DuplicateCount != 3 {
+		t.Errorf("Expected all 3 rows to be duplicates on re-import, got %+v", again)
+	}
+}
@@ -0,0 +1,240 @@
+package storage
+
+import (
+	"budget-tracker-tui/internal/types"
+	"fmt"
+	"html"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// ofxNode is one element of an OFX document
+// OFX 1.x is SGML where leaf elements have no closing tag, so a leaf is an element followed by text.
+type ofxNode struct {
+	name     string
+	text     string
+	children []*ofxNode
+}
+
+// child returns the first direct child with the given name, or nil
+func (n *ofxNode) child(name string) *ofxNode {
+	for _, c := range n.children {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// value returns the text of the leaf at the given path below n, or "" if it is missing
+func (n *ofxNode) value(path ...string) string {
+	node := n
+	for _, name := range path {
+		if node = node.child(name); node == nil {
+			return ""
+		}
+	}
+	return node.text
+}
+
+// findAll returns every element below n with the given name, in document order
+func (n *ofxNode) findAll(name string) []*ofxNode {
+	var found []*ofxNode
+	for _, c := range n.children {
+		if c.name == name {
+			found = append(found, c)
+		}
+		found = append(found, c.findAll(name)...)
+	}
+	return found
+}
+
+// parseOFXTree reads the <OFX> element of an OFX 1.x or 2.x file into a tree
+// The SGML or XML headers before <OFX> are skipped.
+func parseOFXTree(r io.Reader) (*ofxNode, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OFX file: %w", err)
+	}
+	content := string(data)
+
+	start := strings.Index(strings.ToUpper(content), "<OFX>")
+	if start < 0 {
+		return nil, fmt.Errorf("not an OFX file: no <OFX> element found")
+	}
+	content = content[start:]
+
+	root := &ofxNode{}
+	stack := []*ofxNode{root}
+	for len(content) > 0 {
+		open := strings.IndexByte(content, '<')
+		if open < 0 {
+			break
+		}
+
+		// Text before a tag belongs to the open leaf, which ends there
+		if text := strings.TrimSpace(content[:open]); text != "" {
+			top := stack[len(stack)-1]
+			top.text = html.UnescapeString(text)
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+
+		end := strings.IndexByte(content[open:], '>')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated tag in OFX file")
+		}
+		tag := strings.TrimSpace(content[open+1 : open+end])
+		content = content[open+end+1:]
+
+		switch {
+		case tag == "" || strings.HasPrefix(tag, "?") || strings.HasPrefix(tag, "!"):
+			// Processing instructions and comments carry no data
+		case strings.HasPrefix(tag, "/"):
+			// Close the named element and any leaves left open inside it; XML leaves were
+			// already closed by their text, so a closing tag with no open element is ignored
+			name := strings.ToUpper(strings.TrimSpace(tag[1:]))
+			for i := len(stack) - 1; i > 0; i-- {
+				if stack[i].name == name {
+					stack = stack[:i]
+					break
+				}
+			}
+		default:
+			selfClosing := strings.HasSuffix(tag, "/")
+			name := strings.ToUpper(strings.TrimSpace(strings.TrimSuffix(tag, "/")))
+			node := &ofxNode{name: name}
+			parent := stack[len(stack)-1]
+			parent.children = append(parent.children, node)
+			if !selfClosing {
+				stack = append(stack, node)
+			}
+		}
+	}
+
+	ofx := root.child("OFX")
+	if ofx == nil {
+		return nil, fmt.Errorf("not an OFX file: no <OFX> element found")
+	}
+	return ofx, nil
+}
+
+// ParseOFX reads a bank or credit card statement from an OFX or QFX file
+// Only the first statement is read when a file holds several accounts.
+func ParseOFX(r io.Reader) (*types.StatementFile, error) {
+	ofx, err := parseOFXTree(r)
+	if err != nil {
+		return nil, err
+	}
+
+	statements := append(ofx.findAll("STMTRS"), ofx.findAll("CCSTMTRS")...)
+	if len(statements) == 0 {
+		return nil, fmt.Errorf("OFX file contains no bank or credit card statement")
+	}
+	if len(statements) > 1 {
+		slog.Warn("OFX file holds several statements, importing the first", "statements", len(statements))
+	}
+	stmtrs := statements[0]
+
+	file := &types.StatementFile{
+		Format:   types.StatementFormatOFX,
+		Currency: strings.ToUpper(stmtrs.value("CURDEF")),
+	}
+	if file.Currency == "" {
+		file.Currency = types.DefaultCurrency
+	}
+	file.AccountNumber = stmtrs.value("BANKACCTFROM", "ACCTID")
+	if file.AccountNumber == "" {
+		file.AccountNumber = stmtrs.value("CCACCTFROM", "ACCTID")
+	}
+
+	tranList := stmtrs.child("BANKTRANLIST")
+	if tranList == nil {
+		return nil, fmt.Errorf("OFX statement has no transaction list")
+	}
+	if value := tranList.value("DTSTART"); value != "" {
+		if file.PeriodStart, err = parseOFXDate(value); err != nil {
+			return nil, fmt.Errorf("invalid statement start date: %w", err)
+		}
+	}
+	if value := tranList.value("DTEND"); value != "" {
+		if file.PeriodEnd, err = parseOFXDate(value); err != nil {
+			return nil, fmt.Errorf("invalid statement end date: %w", err)
+		}
+	}
+
+	if amount := stmtrs.value("LEDGERBAL", "BALAMT"); amount != "" {
+		balance, err := parseStatementAmount(amount, file.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ledger balance: %w", err)
+		}
+		file.ClosingBalance = &balance
+	}
+
+	for i, trn := range tranList.findAll("STMTTRN") {
+		tx, err := parseOFXTransaction(trn, file.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i+1, err)
+		}
+		addStatementTransaction(file, tx)
+	}
+
+	return file, nil
+}
+
+// parseOFXTransaction converts one STMTTRN element
+func parseOFXTransaction(trn *ofxNode, currency string) (types.Transaction, error) {
+	var tx types.Transaction
+
+	date, err := parseOFXDate(trn.value("DTPOSTED"))
+	if err != nil {
+		return tx, fmt.Errorf("invalid posted date: %w", err)
+	}
+	tx.Date = date
+
+	tx.Amount, err = parseStatementAmount(trn.value("TRNAMT"), currency)
+	if err != nil {
+		return tx, fmt.Errorf("invalid amount: %w", err)
+	}
+
+	name, memo := trn.value("NAME"), trn.value("MEMO")
+	if name == "" {
+		name = trn.value("PAYEE", "NAME")
+	}
+	tx.Description = name
+	tx.RawDescription = name
+	if memo != "" && memo != name {
+		if name == "" {
+			tx.Description = memo
+			tx.RawDescription = memo
+		} else {
+			tx.RawDescription = name + " " + memo
+		}
+	}
+	if strings.TrimSpace(tx.Description) == "" {
+		return tx, fmt.Errorf("empty description not allowed")
+	}
+
+	tx.ExternalId = trn.value("FITID")
+	tx.TransactionType = statementTransactionType(tx.Amount)
+	if strings.ToUpper(trn.value("TRNTYPE")) == "XFER" {
+		tx.TransactionType = "transfer"
+	}
+	return tx, nil
+}
+
+// parseOFXDate reads the date part of an OFX datetime such as 20240115120000.000[-5:EST]
+func parseOFXDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if len(value) < 8 {
+		return time.Time{}, fmt.Errorf("invalid OFX date '%s'", value)
+	}
+	date, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid OFX date '%s'", value)
+	}
+	return date, nil
+}
@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"budget-tracker-tui/internal/types"
+)
+
+// sgmlOFX is an OFX 1.x bank statement, whose leaf elements have no closing tags
+const sgmlOFX = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+SECURITY:NONE
+ENCODING:USASCII
+CHARSET:1252
+COMPRESSION:NONE
+OLDFILEUID:NONE
+NEWFILEUID:NONE
+
+<OFX>
+<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0<SEVERITY>INFO</STATUS><DTSERVER>20240201120000</SONRS></SIGNONMSGSRSV1>
+<BANKMSGSRSV1><STMTTRNRS><TRNUID>1<STMTRS>
+<CURDEF>EUR
+<BANKACCTFROM><BANKID>12345<ACCTID>NL01BANK0123456789<ACCTTYPE>CHECKING</BANKACCTFROM>
+<BANKTRANLIST>
+<DTSTART>20240101000000.000[-5:EST]
+<DTEND>20240131235959.000[-5:EST]
+<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20240105120000.000<TRNAMT>-42,50<FITID>A-1001<NAME>Grocery Store<MEMO>Card 1234</STMTTRN>
+<STMTTRN><TRNTYPE>CREDIT<DTPOSTED>20240115<TRNAMT>1500.00<FITID>A-1002<NAME>Salary &amp; Bonus</STMTTRN>
+<STMTTRN><TRNTYPE>XFER<DTPOSTED>20240120<TRNAMT>-200.00<FITID>A-1003<NAME>To Savings</STMTTRN>
+</BANKTRANLIST>
+<LEDGERBAL><BALAMT>2757.50<DTASOF>20240131</LEDGERBAL>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`
+
+// xmlOFX is an OFX 2.x credit card statement
+const xmlOFX = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="211" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+<OFX>
+  <CREDITCARDMSGSRSV1>
+    <CCSTMTTRNRS>
+      <TRNUID>1</TRNUID>
+      <CCSTMTRS>
+        <CURDEF>USD</CURDEF>
+        <CCACCTFROM><ACCTID>4111111111111111</ACCTID></CCACCTFROM>
+        <BANKTRANLIST>
+          <DTSTART>20240301</DTSTART>
+          <DTEND>20240331</DTEND>
+          <STMTTRN>
+            <TRNTYPE>DEBIT</TRNTYPE>
+            <DTPOSTED>20240310</DTPOSTED>
+            <TRNAMT>-9.99</TRNAMT>
+            <FITID>CC-77</FITID>
+            <MEMO>Streaming Service</MEMO>
+          </STMTTRN>
+        </BANKTRANLIST>
+        <LEDGERBAL><BALAMT>-9.99</BALAMT><DTASOF>20240331</DTASOF></LEDGERBAL>
+      </CCSTMTRS>
+    </CCSTMTTRNRS>
+  </CREDITCARDMSGSRSV1>
+</OFX>
+`
+
+// TestParseOFX tests reading SGML and XML statements into transactions
+func TestParseOFX(t *testing.T) {
+	t.Run("SGML bank statement", func(t *testing.T) {
+		file, err := ParseOFX(strings.NewReader(sgmlOFX))
+		if err != nil {
+			t.Fatalf("ParseOFX() failed: %v", err)
+		}
+
+		if file.Format != types.StatementFormatOFX || file.Currency != "EUR" || file.AccountNumber != "NL01BANK0123456789" {
+			t.Errorf("Unexpected statement header: %+v", file)
+		}
+		if !file.PeriodStart.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) || !file.PeriodEnd.Equal(time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("Expected January 2024 period, got %v to %v", file.PeriodStart, file.PeriodEnd)
+		}
+		if file.ClosingBalance == nil || file.ClosingBalance.Cents != 275750 || file.ClosingBalance.Currency != "EUR" {
+			t.Errorf("Expected closing balance of 2757.50 EUR, got %+v", file.ClosingBalance)
+		}
+		if len(file.Transactions) != 3 {
+			t.Fatalf("Expected 3 transactions, got %d", len(file.Transactions))
+		}
+
+		grocery := file.Transactions[0]
+		if grocery.Amount.Cents != -4250 || grocery.ExternalId != "A-1001" || grocery.TransactionType != "expense" {
+			t.Errorf("Unexpected debit: %+v", grocery)
+		}
+		if grocery.Description != "Grocery Store" || grocery.RawDescription != "Grocery Store Card 1234" {
+			t.Errorf("Expected the memo only in the raw description, got %q and %q", grocery.Description, grocery.RawDescription)
+		}
+		if salary := file.Transactions[1]; salary.Description != "Salary & Bonus" || salary.TransactionType != "income" {
+			t.Errorf("Unexpected credit: %+v", salary)
+		}
+		if transfer := file.Transactions[2]; transfer.TransactionType != "transfer" {
+			t.Errorf("Expected XFER to import as a transfer, got %q", transfer.TransactionType)
+		}
+	})
+
+	t.Run("XML credit card statement", func(t *testing.T) {
+		file, err := ParseOFX(strings.NewReader(xmlOFX))
+		if err != nil {
+			t.Fatalf("ParseOFX() failed: %v", err)
+		}
+
+		if file.Currency != "USD" || file.AccountNumber != "4111111111111111" {
+			t.Errorf("Unexpected statement header: %+v", file)
+		}
+		if len(file.Transactions) != 1 {
+			t.Fatalf("Expected 1 transaction, got %d", len(file.Transactions))
+		}
+		tx := file.Transactions[0]
+		if tx.Description != "Streaming Service" || tx.Amount.Cents != -999 || tx.ExternalId != "CC-77" {
+			t.Errorf("Unexpected transaction: %+v", tx)
+		}
+		if !tx.Date.Equal(time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("Expected 2024-03-10, got %v", tx.Date)
+		}
+	})
+
+	t.Run("rejects files without a statement", func(t *testing.T) {
+		invalid := []string{
+			"Date,Amount,Description\n2024-01-01,1.00,Coffee\n",
+			"<OFX><SIGNONMSGSRSV1></SIGNONMSGSRSV1></OFX>",
+			"<OFX><BANKMSGSRSV1><STMTRS><BANKTRANLIST><STMTTRN><DTPOSTED>2024<TRNAMT>1<NAME>x</STMTTRN></BANKTRANLIST></STMTRS></BANKMSGSRSV1></OFX>",
+		}
+		for _, content := range invalid {
+			if _, err := ParseOFX(strings.NewReader(content)); err == nil {
+				t.Errorf("Expected an error for %q", content)
+			}
+		}
+	})
+}
+
+// TestMainStoreImportStatementFile tests importing an OFX file and skipping its rows on re-import
+func TestMainStoreImportStatementFile(t *testing.T) {
+	store, conn := setupTestMainStore(t)
+	defer teardownTestDB(t, conn)
+
+	accountId := createTestAccount(t, store.Accounts, "Checking")
+	filePath := createTestCSVFile(t, "january.ofx", sgmlOFX)
+
+	result := store.ImportStatementFile(filePath, accountId)
+	if !result.Success || result.ImportedCount != 3 {
+		t.Fatalf("Expected 3 imported transactions, got %+v", result)
+	}
+
+	statements := store.Statements.GetStatementHistory()
+	if len(statements) != 1 {
+		t.Fatalf("Expected 1 statement, got %d", len(statements))
+	}
+	stmt := statements[0]
+	if stmt.Format != types.StatementFormatOFX || stmt.TemplateUsed != 0 || stmt.AccountId != accountId || stmt.Status != "completed" {
+		t.Errorf("Unexpected statement: %+v", stmt)
+	}
+	if stmt.PeriodStart.Format("2006-01-02") != "2024-01-01" || stmt.PeriodEnd.Format("2006-01-02") != "2024-01-31" {
+		t.Errorf("Expected the period stated in the file, got %v to %v", stmt.PeriodStart, stmt.PeriodEnd)
+	}
+	if stmt.OpeningBalance != nil || stmt.ClosingBalance == nil || stmt.ClosingBalance.Cents != 275750 {
+		t.Errorf("Expected only a closing balance of 2757.50, got %+v and %+v", stmt.OpeningBalance, stmt.ClosingBalance)
+	}
+
+	imported, err := store.Transactions.QueryTransactions(TransactionQuery{StatementId: stmt.Id})
+	if err != nil {
+		t.Fatalf("QueryTransactions() failed: %v", err)
+	}
+	for _, tx := range imported {
+		if tx.ExternalId == "" || tx.AccountId != accountId {
+			t.Errorf("Expected FITID and account on every imported row, got %+v", tx)
+		}
+	}
+
+	// The same file again, renamed as banks often do, adds nothing
+	again := store.ImportStatementFile(createTestCSVFile(t, "export (1).ofx", sgmlOFX), accountId)
+	if again.Success || again.DuplicateCount != 3 {
+		t.Errorf("Expected all 3 rows to be duplicates on re-import, got %+v", again)
+	}
+
+	// Without an account the FITIDs belong to a different scope and import again
+	unassigned := store.ImportStatementFile(filePath, 0)
+	if !unassigned.Success || unassigned.ImportedCount != 3 {
+		t.Errorf("Expected 3 transactions imported without an account, got %+v", unassigned)
+	}
+}
+
+func TestMainStoreImportStatementFileSkipsZeroAmounts(t *testing.T) {
+	store, conn := setupTestMainStore(t)
+	defer teardownTestDB(t, conn)
+
+	accountId := createTestAccount(t, store.Accounts, "Checking")
+	held := strings.Replace(sgmlOFX, "<TRNAMT>-200.00", "<TRNAMT>0.00", 1)
+
+	file, err := ParseOFX(strings.NewReader(held))
+	if err != nil {
+		t.Fatalf("ParseOFX() failed: %v", err)
+	}
+	if len(file.Transactions) != 2 || file.ZeroAmounts != 1 {
+		t.Fatalf("Expected 2 transactions and 1 zero amount, got %d and %d", len(file.Transactions), file.ZeroAmounts)
+	}
+
+	result := store.ImportStatementFile(createTestCSVFile(t, "held.ofx", held), accountId)
+	if !result.Success || result.ImportedCount != 2 || !strings.Contains(result.Message, "1 zero-amount entries skipped") {
+		t.Fatalf("Expected 2 imported transactions and the hold skipped, got %+v", result)
+	}
+	if statements := store.Statements.GetStatementHistory(); len(statements) != 1 || statements[0].Status != "completed" {
+		t.Errorf("Expected one completed statement, got %+v", statements)
+	}
+}
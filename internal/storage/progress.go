@@ -0,0 +1,40 @@
+package storage
+
+import "budget-tracker-tui/internal/types"
+
+// progressStep is how many rows pass between progress reports
+const progressStep = 50
+
+// progressReporter throttles row-level progress updates for one stage of an operation
+type progressReporter struct {
+	fn    types.ProgressFunc
+	stage string
+	total int
+}
+
+// newProgressReporter creates a reporter for a stage; a nil fn makes every report a no-op
+func newProgressReporter(fn types.ProgressFunc, stage string, total int) *progressReporter {
+	return &progressReporter{fn: fn, stage: stage, total: total}
+}
+
+// start announces the stage before any rows are processed
+func (r *progressReporter) start() {
+	if r.fn != nil {
+		r.fn(types.Progress{Stage: r.stage, Total: r.total})
+	}
+}
+
+// update reports the rows processed so far, every progressStep rows and at the end
+func (r *progressReporter) update(done int) {
+	if r.fn != nil && (done%progressStep == 0 || done == r.total) {
+		r.fn(types.Progress{Stage: r.stage, Done: done, Total: r.total})
+	}
+}
+
+// finish reports a stage whose total was unknown while it ran, such as a streamed file, as done
+func (r *progressReporter) finish(done int) {
+	r.total = done
+	if r.fn != nil {
+		r.fn(types.Progress{Stage: r.stage, Done: done, Total: done})
+	}
+}
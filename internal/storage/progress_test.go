@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"budget-tracker-tui/internal/types"
+)
+
+// createLargeTestCSV writes a CSV with one expense per day so imports report several progress steps
+func createLargeTestCSV(t *testing.T, rows int) string {
+	var b strings.Builder
+	for i := 0; i < rows; i++ {
+		fmt.Fprintf(&b, "2024-%02d-%02d,-%d.00,Purchase %d\n", i/28+1, i%28+1, i+1, i)
+	}
+	return createTestCSVFile(t, "large.csv", b.String())
+}
+
+func createProgressTestTemplate(t *testing.T, store *Store) {
+	template := types.CSVTemplate{Name: "ProgressBank", PostDateColumn: 0, AmountColumn: 1, DescColumn: 2, DateFormat: "2006-01-02"}
+	if result := store.Templates.CreateCSVTemplate(template); !result.Success {
+		t.Fatalf("Failed to create test template: %s", result.Message)
+	}
+}
+
+func TestValidateAndImportCSVContextReportsProgress(t *testing.T) {
+	store, conn := setupTestMainStore(t)
+	defer teardownTestDB(t, conn)
+	createProgressTestTemplate(t, store)
+	filePath := createLargeTestCSV(t, 120)
+
+	last := make(map[string]types.Progress)
+	result := store.ValidateAndImportCSVContext(context.Background(), filePath, "ProgressBank", 0, func(p types.Progress) {
+		if prev, ok := last[p.Stage]; ok && p.Done < prev.Done {
+			t.Errorf("%s progress went backwards: %d after %d", p.Stage, p.Done, prev.Done)
+		}
+		last[p.Stage] = p
+	})
+	if !result.Success {
+		t.Fatalf("Expected import to succeed: %s", result.Message)
+	}
+
+	for _, stage := range []string{types.ProgressParsing, types.ProgressInserting} {
+		p, ok := last[stage]
+		if !ok {
+			t.Errorf("Expected %q progress to be reported", stage)
+			continue
+		}
+		if p.Fraction() != 1 {
+			t.Errorf("Expected %q to finish at 100%%, got %d of %d", stage, p.Done, p.Total)
+		}
+	}
+	if last[types.ProgressInserting].Total != 120 {
+		t.Errorf("Expected 120 rows to insert, got %d", last[types.ProgressInserting].Total)
+	}
+}
+
+func TestValidateAndImportCSVContextCancel(t *testing.T) {
+	tests := []struct {
+		name        string
+		cancelStage string
+	}{
+		{"while parsing", types.ProgressParsing},
+		{"while inserting", types.ProgressInserting},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, conn := setupTestMainStore(t)
+			defer teardownTestDB(t, conn)
+			createProgressTestTemplate(t, store)
+			filePath := createLargeTestCSV(t, 120)
+
+			// Cancel part-way through the stage, once some rows have been handled
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			result := store.ValidateAndImportCSVContext(ctx, filePath, "ProgressBank", 0, func(p types.Progress) {
+				if p.Stage == tt.cancelStage && p.Done >= progressStep {
+					cancel()
+				}
+			})
+
+			if !result.Cancelled || result.Success {
+				t.Fatalf("Expected a cancelled import, got %+v", result)
+			}
+
+			transactions, err := store.Transactions.GetTransactions()
+			if err != nil {
+				t.Fatalf("Failed to get transactions: %v", err)
+			}
+			if len(transactions) != 0 {
+				t.Errorf("Expected the partial import to be rolled back, found %d transactions", len(transactions))
+			}
+			if statements := store.Statements.GetStatementHistory(); len(statements) != 0 {
+				t.Errorf("Expected no statement after cancelling, found %+v", statements)
+			}
+
+			// The same file imports cleanly afterwards
+			if result := store.ValidateAndImportCSV(filePath, "ProgressBank", 0); !result.Success {
+				t.Errorf("Expected the import to succeed after cancelling: %s", result.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package storage
+
+import (
+	"budget-tracker-tui/internal/types"
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// qifAccountSections are the QIF section headers holding plain account transactions
+// Investment, category list and other sections are skipped.
+var qifAccountSections = map[string]bool{
+	"!TYPE:BANK":  true,
+	"!TYPE:CCARD": true,
+	"!TYPE:CASH":  true,
+}
+
+// qifRecord collects the fields of one QIF transaction until its closing ^ line
+type qifRecord struct {
+	date, amount, payee, memo, category string
+	splits                              []qifSplit
+	line                                int // Line of the first field, for error messages
+}
+
+// qifSplit is one S/$/E group of a split transaction
+type qifSplit struct {
+	category, amount, memo string
+}
+
+// ParseQIF reads the bank, credit card and cash transactions of a QIF file
+// Transactions from every such section are returned together; QIF carries no currency, so
+// amounts are in the default currency.
+func ParseQIF(r io.Reader) (*types.StatementFile, error) {
+	file := &types.StatementFile{
+		Format:     types.StatementFormatQIF,
+		Currency:   types.DefaultCurrency,
+		Categories: make(map[int]string),
+		Splits:     make(map[int][]types.StatementSplit),
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	inAccountSection, sawSection := false, false
+	var record *qifRecord
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if lineNum == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "!") {
+			header := strings.ToUpper(strings.TrimSpace(line))
+			if strings.HasPrefix(header, "!OPTION") || strings.HasPrefix(header, "!CLEAR") {
+				continue
+			}
+			inAccountSection = qifAccountSections[header]
+			sawSection = sawSection || inAccountSection
+			record = nil
+			continue
+		}
+		if !inAccountSection {
+			continue
+		}
+
+		if line[0] == '^' {
+			if record != nil {
+				if err := appendQIFRecord(file, record); err != nil {
+					return nil, fmt.Errorf("line %d: %w", record.line, err)
+				}
+			}
+			record = nil
+			continue
+		}
+
+		if record == nil {
+			record = &qifRecord{line: lineNum}
+		}
+		value := strings.TrimSpace(line[1:])
+		switch line[0] {
+		case 'D':
+			record.date = value
+		case 'T':
+			record.amount = value
+		case 'U':
+			// U repeats T with more precision in newer files; T wins when both are present
+			if record.amount == "" {
+				record.amount = value
+			}
+		case 'P':
+			record.payee = value
+		case 'M':
+			record.memo = value
+		case 'L':
+			record.category = value
+		case 'S':
+			record.splits = append(record.splits, qifSplit{category: value})
+		case '$':
+			if len(record.splits) == 0 {
+				return nil, fmt.Errorf("line %d: split amount without a split category", lineNum)
+			}
+			record.splits[len(record.splits)-1].amount = value
+		case 'E':
+			if len(record.splits) == 0 {
+				return nil, fmt.Errorf("line %d: split memo without a split category", lineNum)
+			}
+			record.splits[len(record.splits)-1].memo = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read QIF file: %w", err)
+	}
+
+	// The final record may be missing its ^
+	if record != nil {
+		if err := appendQIFRecord(file, record); err != nil {
+			return nil, fmt.Errorf("line %d: %w", record.line, err)
+		}
+	}
+	if !sawSection {
+		return nil, fmt.Errorf("QIF file has no !Type:Bank, !Type:CCard or !Type:Cash section")
+	}
+	assignQIFExternalIds(file.Transactions)
+	return file, nil
+}
+
+// assignQIFExternalIds gives each row an ID made of its date, amount and description
+// QIF has no transaction IDs of its own, and split rows no longer match on amount once imported,
+// so this is what lets a re-import skip them. Repeats within the file are numbered.
+func assignQIFExternalIds(transactions []types.Transaction) {
+	seen := make(map[string]int)
+	for i := range transactions {
+		tx := &transactions[i]
+		key := fmt.Sprintf("qif:%s:%d:%s", tx.Date.Format("2006-01-02"), tx.Amount.Cents, tx.Description)
+		seen[key]++
+		tx.ExternalId = fmt.Sprintf("%s:%d", key, seen[key])
+	}
+}
+
+// appendQIFRecord converts a record and adds it, with its category and splits, to the statement
+func appendQIFRecord(file *types.StatementFile, record *qifRecord) error {
+	var tx types.Transaction
+
+	date, err := parseQIFDate(record.date)
+	if err != nil {
+		return err
+	}
+	tx.Date = date
+
+	tx.Amount, err = parseQIFAmount(record.amount, file.Currency)
+	if err != nil {
+		return fmt.Errorf("invalid amount: %w", err)
+	}
+
+	tx.Description = record.payee
+	tx.RawDescription = record.payee
+	if record.memo != "" && record.memo != record.payee {
+		if record.payee == "" {
+			tx.Description = record.memo
+			tx.RawDescription = record.memo
+		} else {
+			tx.RawDescription = record.payee + " " + record.memo
+		}
+	}
+	if strings.TrimSpace(tx.Description) == "" {
+		return fmt.Errorf("empty description not allowed")
+	}
+
+	category, isTransfer := parseQIFCategory(record.category)
+	tx.TransactionType = statementTransactionType(tx.Amount)
+	if isTransfer {
+		tx.TransactionType = "transfer"
+	}
+
+	var splits []types.StatementSplit
+	total := types.NewMoney(0, file.Currency)
+	for _, s := range record.splits {
+		amount, err := parseQIFAmount(s.amount, file.Currency)
+		if err != nil {
+			return fmt.Errorf("invalid split amount: %w", err)
+		}
+		splitCategory, _ := parseQIFCategory(s.category)
+		splits = append(splits, types.StatementSplit{Amount: amount, Category: splitCategory, Memo: s.memo})
+		total = total.Add(amount)
+	}
+	if len(splits) > 0 && total.Cents != tx.Amount.Cents {
+		return fmt.Errorf("split amounts (%s) don't match the transaction (%s)", total, tx.Amount)
+	}
+
+	index := len(file.Transactions)
+	if !addStatementTransaction(file, tx) {
+		return nil
+	}
+	switch {
+	case len(splits) > 1:
+		file.Splits[index] = splits
+	case len(splits) == 1 && category == "":
+		// A single split line is just the transaction's category
+		category = splits[0].Category
+	}
+	if category != "" {
+		file.Categories[index] = category
+	}
+	return nil
+}
+
+// parseQIFCategory reads an L or S field: "Parent:Child/Class" names a category, the class
+// is dropped, and "[Account]" is a transfer to another account with no category
+func parseQIFCategory(value string) (category string, isTransfer bool) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "[") {
+		return "", true
+	}
+	if slash := strings.IndexByte(value, '/'); slash >= 0 {
+		value = value[:slash]
+	}
+	return strings.TrimSpace(value), false
+}
+
+// parseQIFAmount parses a T, U or $ field, which may group thousands with commas
+func parseQIFAmount(value, currency string) (types.Money, error) {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, ".") {
+		value = strings.ReplaceAll(value, ",", "")
+	}
+	return parseStatementAmount(value, currency)
+}
+
+// parseQIFDate reads a QIF D field
+// Quicken writes month/day/year, with a ' instead of / before two-digit years from 2000 on
+// ("1/15'24"); other programs write dd.mm.yyyy or yyyy-mm-dd. Other two-digit years below 50
+// are taken to be in the 2000s.
+func parseQIFDate(value string) (time.Time, error) {
+	original := value
+	value = strings.ReplaceAll(strings.TrimSpace(value), " ", "")
+	dayFirst := strings.Contains(value, ".")
+	apostrophe := strings.Contains(value, "'")
+	parts := strings.FieldsFunc(value, func(r rune) bool {
+		return r == '/' || r == '\'' || r == '-' || r == '.'
+	})
+	if len(parts) != 3 {
+		return time.Time{}, fmt.Errorf("invalid date '%s'", original)
+	}
+
+	numbers := make([]int, 3)
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date '%s'", original)
+		}
+		numbers[i] = n
+	}
+
+	var year, month, day int
+	switch {
+	case len(parts[0]) == 4:
+		year, month, day = numbers[0], numbers[1], numbers[2]
+	case dayFirst || numbers[0] > 12:
+		day, month, year = numbers[0], numbers[1], numbers[2]
+	default:
+		month, day, year = numbers[0], numbers[1], numbers[2]
+	}
+	if len(parts[2]) <= 2 && len(parts[0]) != 4 {
+		if apostrophe || year < 50 {
+			year += 2000
+		} else {
+			year += 1900
+		}
+	}
+
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if date.Year() != year || int(date.Month()) != month || date.Day() != day {
+		return time.Time{}, fmt.Errorf("invalid date '%s'", original)
+	}
+	return date, nil
+}
@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"budget-tracker-tui/internal/types"
+)
+
+// sampleQIF has a bank section with a three-way split, a memorized category list that must be
+// skipped, and a credit card section
+const sampleQIF = `!Type:Bank
+D1/15'24
+T-1,250.00
+PLandlord
+MJanuary rent
+LHousing:Rent
+^
+D1/20'24
+T-120.00
+PSupermarket
+SFood:Groceries
+$-80.00
+SHousehold
+$-30.00
+ECleaning supplies
+SFood:Snacks/Party
+$-10.00
+^
+D01/25/2024
+U2500.00
+PEmployer
+LSalary
+^
+D1/28'24
+T-300.00
+PTransfer
+L[Savings]
+^
+!Type:Cat
+NFood
+D
+E
+^
+!Type:CCard
+D2024-01-30
+T-45.99
+PBookshop
+^
+`
+
+// TestParseQIF tests reading bank and credit card sections, categories and splits
+func TestParseQIF(t *testing.T) {
+	file, err := ParseQIF(strings.NewReader(sampleQIF))
+	if err != nil {
+		t.Fatalf("ParseQIF() failed: %v", err)
+	}
+	if len(file.Transactions) != 5 {
+		t.Fatalf("Expected 5 transactions, got %d", len(file.Transactions))
+	}
+
+	rent := file.Transactions[0]
+	if rent.Amount.Cents != -125000 || rent.Description != "Landlord" || rent.RawDescription != "Landlord January rent" {
+		t.Errorf("Unexpected rent transaction: %+v", rent)
+	}
+	if !rent.Date.Equal(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected 2024-01-15, got %v", rent.Date)
+	}
+	if file.Categories[0] != "Housing:Rent" {
+		t.Errorf("Expected category path Housing:Rent, got %q", file.Categories[0])
+	}
+
+	splits := file.Splits[1]
+	if len(splits) != 3 {
+		t.Fatalf("Expected 3 split lines, got %d", len(splits))
+	}
+	if splits[1].Category != "Household" || splits[1].Memo != "Cleaning supplies" || splits[1].Amount.Cents != -3000 {
+		t.Errorf("Unexpected split line: %+v", splits[1])
+	}
+	if splits[2].Category != "Food:Snacks" {
+		t.Errorf("Expected the class to be dropped from the split category, got %q", splits[2].Category)
+	}
+
+	if salary := file.Transactions[2]; salary.Amount.Cents != 250000 || salary.TransactionType != "income" {
+		t.Errorf("Expected U amount as income, got %+v", salary)
+	}
+	if transfer := file.Transactions[3]; transfer.TransactionType != "transfer" || file.Categories[3] != "" {
+		t.Errorf("Expected [Savings] to be a transfer without a category, got %+v and %q", transfer, file.Categories[3])
+	}
+	if book := file.Transactions[4]; book.Description != "Bookshop" || book.Date.Format("2006-01-02") != "2024-01-30" {
+		t.Errorf("Expected the credit card section to be read, got %+v", book)
+	}
+
+	for _, tc := range []struct{ value, want string }{
+		{"12/31'99", "2099-12-31"},
+		{"12/31/99", "1999-12-31"},
+		{"31.12.2023", "2023-12-31"},
+		{"25/12/2023", "2023-12-25"},
+		{" 1/ 5' 4", "2004-01-05"},
+	} {
+		date, err := parseQIFDate(tc.value)
+		if err != nil || date.Format("2006-01-02") != tc.want {
+			t.Errorf("parseQIFDate(%q) = %v, %v; want %s", tc.value, date, err, tc.want)
+		}
+	}
+
+	invalid := []string{
+		"D1/15'24\nT-1.00\nPNo section\n^\n",
+		"!Type:Bank\nD13/45/2024\nT-1.00\nPBad date\n^\n",
+		"!Type:Bank\nD1/1/2024\nT-10.00\nPSplit\nSA\n$-4.00\nSB\n$-5.00\n^\n",
+	}
+	for _, content := range invalid {
+		if _, err := ParseQIF(strings.NewReader(content)); err == nil {
+			t.Errorf("Expected an error for %q", content)
+		}
+	}
+}
+
+// TestMainStoreImportQIF tests that QIF categories build the hierarchy and splits divide the row
+func TestMainStoreImportQIF(t *testing.T) {
+	store, conn := setupTestMainStore(t)
+	defer teardownTestDB(t, conn)
+
+	filePath := createTestCSVFile(t, "history.qif", sampleQIF)
+	result := store.ImportStatementFile(filePath, 0)
+	if !result.Success || result.ImportedCount != 5 {
+		t.Fatalf("Expected 5 imported transactions, got %+v", result)
+	}
+
+	housing := store.Categories.GetCategoryByDisplayName("Housing")
+	rent := store.Categories.GetCategoryByDisplayName("Rent")
+	if housing == nil || rent == nil || rent.ParentId == nil || *rent.ParentId != housing.Id {
+		t.Fatalf("Expected Rent to be created under Housing, got %+v and %+v", housing, rent)
+	}
+
+	statement := store.Statements.GetStatementHistory()[0]
+	if statement.Format != types.StatementFormatQIF || statement.TxCount != 5 {
+		t.Errorf("Expected a QIF statement of 5 transactions, got %+v", statement)
+	}
+	for _, check := range []func() ([]IntegrityIssue, error){store.checkStatementCounts, store.checkOrphanedSplits} {
+		if issues, err := check(); err != nil || len(issues) != 0 {
+			t.Errorf("Expected consistent splits and counts after import, got %+v (%v)", issues, err)
+		}
+	}
+
+	groceries := store.Categories.GetCategoryByDisplayName("Groceries")
+	household := store.Categories.GetCategoryByDisplayName("Household")
+	snacks := store.Categories.GetCategoryByDisplayName("Snacks")
+	if groceries == nil || household == nil || snacks == nil {
+		t.Fatal("Expected every split category to be created")
+	}
+	found := map[int64]int64{}
+	transactions, err := store.Transactions.QueryTransactions(TransactionQuery{StatementId: statement.Id})
+	if err != nil {
+		t.Fatalf("QueryTransactions() failed: %v", err)
+	}
+	for _, tx := range transactions {
+		found[tx.CategoryId] += tx.Amount.Cents
+	}
+	if found[groceries.Id] != -8000 || found[household.Id] != -3000 || found[snacks.Id] != -1000 {
+		t.Errorf("Expected the supermarket row split 80/30/10, got %v", found)
+	}
+
+	// Re-importing skips every row, including the one that was split
+	again := store.ImportStatementFile(filePath, 0)
+	if again.Success || again.DuplicateCount != 5 {
+		t.Errorf("Expected all 5 rows to be duplicates on re-import, got %+v", again)
+	}
+}
@@ -3,6 +3,7 @@ package storage
 import (
 	"budget-tracker-tui/internal/database"
 	"budget-tracker-tui/internal/types"
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
@@ -15,6 +16,8 @@ import (
 type SnapshotStore struct {
 	db     *database.Connection
 	helper *database.SQLHelper
+	store  *Store      // Owning store, needed to swap the live connection on restore
+	audit  *AuditStore // General audit log of creates, updates and deletes
 }
 
 // NewSnapshotStore creates a new SnapshotStore instance
@@ -25,6 +28,23 @@ func NewSnapshotStore(db *database.Connection) *SnapshotStore {
 	}
 }
 
+// SetStore sets the owning store reference used for restores
+func (ss *SnapshotStore) SetStore(store *Store) {
+	ss.store = store
+}
+
+// SetAuditStore sets the audit log that snapshot changes are recorded in
+func (ss *SnapshotStore) SetAuditStore(audit *AuditStore) {
+	ss.audit = audit
+}
+
+// recordSnapshotCreated logs a new snapshot's metadata in the audit log
+func (ss *SnapshotStore) recordSnapshotCreated(snapshotId int64) {
+	if snapshot, err := ss.GetSnapshotById(snapshotId); err == nil && snapshot != nil {
+		ss.audit.recordOrWarn(types.EntitySnapshot, auditId(snapshotId), types.AuditEventCreate, types.SourceUser, nil, snapshot)
+	}
+}
+
 // GetSnapshots returns all snapshots ordered by creation date (newest first)
 func (ss *SnapshotStore) GetSnapshots() ([]types.Snapshot, error) {
 	query := `
@@ -168,6 +188,8 @@ func (ss *SnapshotStore) CreateSnapshot(name, description, filePath string) (*Sn
 		return &SnapshotResult{Success: false, Message: fmt.Sprintf("failed to save snapshot metadata: %v", err)}, nil
 	}
 
+	ss.recordSnapshotCreated(snapshotId)
+
 	return &SnapshotResult{
 		Success:    true,
 		Message:    fmt.Sprintf("snapshot '%s' created successfully", name),
@@ -202,11 +224,17 @@ func (ss *SnapshotStore) DeleteSnapshot(id int64) error {
 		os.Remove(snapshot.FilePath)
 	}
 
+	ss.audit.recordOrWarn(types.EntitySnapshot, auditId(id), types.AuditEventDelete, types.SourceUser, snapshot, nil)
 	return nil
 }
 
 // CreateSnapshotFile creates a complete database snapshot using SQLite backup API
 func (ss *SnapshotStore) CreateSnapshotFile(filePath string) error {
+	return ss.CreateSnapshotFileContext(context.Background(), filePath)
+}
+
+// CreateSnapshotFileContext creates a database snapshot, removing the partial file if ctx is cancelled
+func (ss *SnapshotStore) CreateSnapshotFileContext(ctx context.Context, filePath string) error {
 	// Ensure the directory exists
 	dir := filepath.Dir(filePath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -221,17 +249,20 @@ func (ss *SnapshotStore) CreateSnapshotFile(filePath string) error {
 	}
 
 	// Use SQLite backup API for atomic snapshot creation
-	err := ss.db.ExecuteInTransaction(func(tx *sql.Tx) error {
+	err := ss.db.ExecuteInTransactionContext(ctx, func(tx *sql.Tx) error {
 		// The backup operation needs to be performed on the main connection
 		// We'll use a direct backup command
 		backupSQL := fmt.Sprintf("VACUUM INTO '%s'", filePath)
-		_, err := ss.db.DB.Exec(backupSQL)
+		_, err := ss.db.DB.ExecContext(ctx, backupSQL)
 		return err
 	})
 
 	if err != nil {
 		// Clean up partial file on error
 		os.Remove(filePath)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		return fmt.Errorf("failed to create snapshot: %w", err)
 	}
 
@@ -239,40 +270,9 @@ func (ss *SnapshotStore) CreateSnapshotFile(filePath string) error {
 }
 
 // RestoreFromSnapshot restores the database from a snapshot
+// The current state is always backed up first, so this is equivalent to RestoreFromSnapshotWithBackup
 func (ss *SnapshotStore) RestoreFromSnapshot(snapshotId int64) (*RestoreResult, error) {
-	// Get snapshot info
-	snapshot, err := ss.GetSnapshotById(snapshotId)
-	if err != nil {
-		return &RestoreResult{Success: false, Message: fmt.Sprintf("failed to get snapshot: %v", err)}, nil
-	}
-	if snapshot == nil {
-		return &RestoreResult{Success: false, Message: fmt.Sprintf("snapshot with ID %d not found", snapshotId)}, nil
-	}
-
-	// Validate snapshot file exists
-	if err := ss.ValidateSnapshotFile(snapshot.FilePath); err != nil {
-		return &RestoreResult{Success: false, Message: fmt.Sprintf("snapshot validation failed: %v", err)}, nil
-	}
-
-	// Create a backup of current state first (auto-backup)
-	backupPath := fmt.Sprintf("%s.backup.%d.db",
-		ss.db.GetPath(),
-		time.Now().Unix())
-
-	err = ss.CreateSnapshotFile(backupPath)
-	if err != nil {
-		return &RestoreResult{Success: false, Message: fmt.Sprintf("failed to create current state backup: %v", err)}, nil
-	}
-
-	// Restore from snapshot (this would require database reconnection)
-	// For now, return a placeholder - full implementation would require
-	// closing current connection and copying snapshot to main database location
-	return &RestoreResult{
-		Success:    false,
-		Message:    "restore functionality not yet implemented - requires database reconnection",
-		BackupDate: snapshot.GetCreatedAtDisplay(),
-		BackupSize: snapshot.FileSize,
-	}, nil
+	return ss.RestoreFromSnapshotWithBackup(snapshotId)
 }
 
 // ValidateSnapshotFile checks if a snapshot file is valid
@@ -296,6 +296,8 @@ func (ss *SnapshotStore) ValidateSnapshotFile(filePath string) error {
 
 // UpdateSnapshotMetadata updates an existing snapshot's metadata
 func (ss *SnapshotStore) UpdateSnapshotMetadata(snapshot *types.Snapshot) error {
+	before, _ := ss.GetSnapshotById(snapshot.Id)
+
 	query := `
 		UPDATE snapshots 
 		SET name = ?, description = ?, file_size = ?,
@@ -312,6 +314,10 @@ func (ss *SnapshotStore) UpdateSnapshotMetadata(snapshot *types.Snapshot) error
 		return fmt.Errorf("failed to update snapshot metadata: %w", err)
 	}
 
+	if before != nil {
+		after, _ := ss.GetSnapshotById(snapshot.Id)
+		ss.audit.recordOrWarn(types.EntitySnapshot, auditId(snapshot.Id), types.AuditEventUpdate, types.SourceUser, before, after)
+	}
 	return nil
 }
 
@@ -464,6 +470,8 @@ func (ss *SnapshotStore) CreateSnapshotWithUserPath(name, description, userSelec
 		return &SnapshotResult{Success: false, Message: fmt.Sprintf("failed to save snapshot metadata: %v", err)}, nil
 	}
 
+	ss.recordSnapshotCreated(snapshotId)
+
 	return &SnapshotResult{
 		Success:    true,
 		Message:    fmt.Sprintf("snapshot '%s' created successfully at %s", name, userSelectedPath),
@@ -488,33 +496,18 @@ func (ss *SnapshotStore) RestoreFromSnapshotWithBackup(snapshotId int64) (*Resto
 		return &RestoreResult{Success: false, Message: fmt.Sprintf("snapshot validation failed: %v", err)}, nil
 	}
 
-	// Create automatic backup of current state
-	backupPath := fmt.Sprintf("%s.backup.%d.db",
-		ss.db.GetPath(),
-		time.Now().Unix())
-
-	err = ss.CreateSnapshotFile(backupPath)
-	if err != nil {
-		return &RestoreResult{Success: false, Message: fmt.Sprintf("failed to create current state backup: %v", err)}, nil
+	// Swapping the database closes this store's connection, so the owning store drives it
+	if ss.store == nil {
+		return &RestoreResult{Success: false, Message: "restore requires an initialized store"}, nil
 	}
 
-	// Get backup info for result
-	backupInfo, _ := os.Stat(backupPath)
-	backupSize := int64(0)
-	if backupInfo != nil {
-		backupSize = backupInfo.Size()
+	result, err := ss.store.RestoreFromSnapshotFile(snapshot.FilePath)
+	if err != nil || !result.Success {
+		return result, err
 	}
 
-	// Note: Full database restoration requires application restart due to SQLite connection limitations
-	// For now, we create the backup and provide instructions
-	return &RestoreResult{
-		Success: true,
-		Message: fmt.Sprintf("Current database backed up to %s. To complete restore, please restart application and copy %s to main database location.",
-			backupPath, snapshot.FilePath),
-		TxCount:    snapshot.TransactionCount,
-		BackupDate: snapshot.GetCreatedAtDisplay(),
-		BackupSize: backupSize,
-	}, nil
+	result.BackupDate = snapshot.GetCreatedAtDisplay()
+	return result, nil
 }
 
 // ValidateSnapshotFileAdvanced performs comprehensive snapshot file validation
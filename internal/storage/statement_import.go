@@ -0,0 +1,318 @@
+package storage
+
+import (
+	"budget-tracker-tui/internal/types"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ParseStatementFile reads a statement file that imports without a CSV template, choosing the
+// parser from the file extension
+func ParseStatementFile(filePath string) (*types.StatementFile, error) {
+	format := types.StatementFileFormat(filePath)
+	if format == "" || format == types.StatementFormatCSV {
+		return nil, fmt.Errorf("%s is not a statement file that imports without a template", filepath.Base(filePath))
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open statement file: %w", err)
+	}
+	defer file.Close()
+
+	switch format {
+	case types.StatementFormatOFX:
+		return ParseOFX(file)
+	case types.StatementFormatQIF:
+		return ParseQIF(file)
+	case types.StatementFormatCAMT:
+		return ParseCAMT(file)
+	case types.StatementFormatMT940:
+		return ParseMT940(file)
+	}
+	return nil, fmt.Errorf("unsupported statement format '%s'", format)
+}
+
+// StatementFileAccount returns the account whose number matches the account a statement file
+// names, or nil when the file names none or no account has that number
+func (s *Store) StatementFileAccount(filePath string) *types.Account {
+	statement, err := ParseStatementFile(filePath)
+	if err != nil || statement.AccountNumber == "" {
+		return nil
+	}
+	return s.Accounts.FindAccountByNumber(statement.AccountNumber)
+}
+
+// parseStatementAmount parses a signed decimal amount from a bank file, which may use
+// a decimal comma; zeros past the cents are allowed, other sub-cent digits are not
+func parseStatementAmount(value, currency string) (types.Money, error) {
+	s := strings.TrimSpace(value)
+	if strings.Contains(s, ",") && !strings.Contains(s, ".") {
+		s = strings.Replace(s, ",", ".", 1)
+	}
+	if whole, frac, ok := strings.Cut(s, "."); ok && len(frac) > 2 {
+		s = whole + "." + frac[:2] + strings.TrimRight(frac[2:], "0")
+	}
+	return types.ParseMoney(s, currency)
+}
+
+// addStatementTransaction appends tx to file, or counts it when its amount is zero
+// Banks list authorization holds and waived fees as zero amounts; they move no money and the
+// ledger does not store them. Reports whether tx was added.
+func addStatementTransaction(file *types.StatementFile, tx types.Transaction) bool {
+	if tx.Amount.Cents == 0 {
+		file.ZeroAmounts++
+		return false
+	}
+	file.Transactions = append(file.Transactions, tx)
+	return true
+}
+
+// statementTransactionType classifies a signed statement amount; money in is income
+func statementTransactionType(amount types.Money) string {
+	if amount.Cents > 0 {
+		return "income"
+	}
+	return "expense"
+}
+
+// filterStatementDuplicates returns the indexes of statement rows not already in the account
+// Rows carrying the bank's own ID match exactly on it, within the account and within the file;
+// rows without one fall back to the date, amount and description match used for CSV imports.
+func (cp *CSVParser) filterStatementDuplicates(ctx context.Context, transactions []types.Transaction, accountId int64) (newRows []int, duplicates int, err error) {
+	seen := make(map[string]bool)
+	var withoutId []types.Transaction
+	for _, tx := range transactions {
+		if tx.ExternalId == "" {
+			withoutId = append(withoutId, tx)
+		}
+	}
+
+	var processedNew []types.Transaction
+	for i, tx := range transactions {
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+
+		if tx.ExternalId == "" {
+			if cp.checkForDuplicate(tx, withoutId, processedNew) {
+				duplicates++
+			} else {
+				processedNew = append(processedNew, tx)
+				newRows = append(newRows, i)
+			}
+			continue
+		}
+
+		exists, err := cp.transactionStore.ExternalIdExists(accountId, tx.ExternalId)
+		if err != nil {
+			return nil, 0, err
+		}
+		if exists || seen[tx.ExternalId] {
+			duplicates++
+			continue
+		}
+		seen[tx.ExternalId] = true
+		newRows = append(newRows, i)
+	}
+	return newRows, duplicates, nil
+}
+
+// assignStatementCategory categorizes a statement row the way CSV rows are: a confident ML
+// prediction first, then the category the file names, created under its parent when missing
+func (cp *CSVParser) assignStatementCategory(description string, amount types.Money, categoryPath string, defaultCategoryId int64) int64 {
+	if categoryId, ok := cp.predictCategory(description, amount.Float64()); ok {
+		return categoryId
+	}
+	if categoryPath != "" && cp.categoryStore != nil {
+		return cp.categoryStore.ResolveOrCreateCategoryPath(categoryPath)
+	}
+	return defaultCategoryId
+}
+
+// applyStatementSplits divides freshly imported rows into the split lines the file lists for them
+// SplitTransaction divides a row in two, so a row with more lines is split again along the chain
+// of second halves. rows maps each inserted transaction to its index in the statement file.
+// Returns how many rows the splits added; a row that cannot be split is left whole. The statement's
+// count is unchanged because, like the integrity check, it counts only the first half of a split.
+func (s *Store) applyStatementSplits(statementId int64, statement *types.StatementFile, rows []int, defaultCategoryId int64) int {
+	if len(statement.Splits) == 0 {
+		return 0
+	}
+	ids, err := s.Transactions.statementTransactionIds(statementId)
+	if err != nil || len(ids) != len(rows) {
+		slog.Warn("cannot split imported transactions", "statement_id", statementId, "error", err)
+		return 0
+	}
+
+	added := 0
+	for i, row := range rows {
+		lines := statement.Splits[row]
+		parent := statement.Transactions[row]
+		id := ids[i]
+		for n := 0; n < len(lines)-1; n++ {
+			first := s.statementSplitLine(lines[n], parent.Description, defaultCategoryId)
+			second := s.statementSplitLine(lines[n+1], parent.Description, defaultCategoryId)
+			if n < len(lines)-2 {
+				// The remaining lines stay together until the next pass splits them off
+				rest := types.NewMoney(0, parent.Amount.Currency)
+				for _, line := range lines[n+1:] {
+					rest = rest.Add(line.Amount)
+				}
+				second = types.Transaction{Amount: rest, Description: parent.Description, CategoryId: defaultCategoryId}
+			}
+
+			secondId, err := s.Transactions.splitTransaction(id, []types.Transaction{first, second}, types.SourceImport)
+			if err != nil {
+				slog.Warn("failed to split imported transaction", "transaction_id", id, "error", err)
+				break
+			}
+			added++
+			id = secondId
+		}
+	}
+	return added
+}
+
+// statementSplitLine converts a split line, describing it by its memo or else the whole transaction
+func (s *Store) statementSplitLine(line types.StatementSplit, description string, defaultCategoryId int64) types.Transaction {
+	if line.Memo != "" {
+		description = line.Memo
+	}
+	return types.Transaction{
+		Amount:      line.Amount,
+		Description: description,
+		CategoryId:  s.CSVParser.assignStatementCategory(description, line.Amount, line.Category, defaultCategoryId),
+	}
+}
+
+// ImportStatementFile imports a statement file such as OFX into an account, skipping rows already imported
+func (s *Store) ImportStatementFile(filePath string, accountId int64) *types.ImportResult {
+	return s.ImportStatementFileContext(context.Background(), filePath, accountId, nil)
+}
+
+// ImportStatementFileContext is ImportStatementFile with progress reporting and cancellation
+// Statement files identify their own rows, so re-importing an overlapping period is not an error:
+// the rows already present are skipped and counted as duplicates.
+func (s *Store) ImportStatementFileContext(ctx context.Context, filePath string, accountId int64, progress types.ProgressFunc) *types.ImportResult {
+	result := &types.ImportResult{Filename: filepath.Base(filePath)}
+
+	if err := s.checkImportAccount(accountId); err != nil {
+		result.Message = err.Error()
+		return result
+	}
+
+	statement, err := ParseStatementFile(filePath)
+	if err != nil {
+		result.Message = fmt.Sprintf("Parse error: %v", err)
+		return result
+	}
+	if statement.ZeroAmounts > 0 {
+		slog.Info("skipped zero-amount statement entries", "file", result.Filename, "count", statement.ZeroAmounts)
+	}
+	if len(statement.Transactions) == 0 {
+		result.Message = fmt.Sprintf("No transactions found in %s", result.Filename)
+		return result
+	}
+
+	defaultCategoryId, err := s.checkDefaultCategory()
+	if err != nil {
+		result.Message = fmt.Sprintf("Import failed: %v", err)
+		return result
+	}
+
+	newRows, duplicates, err := s.CSVParser.filterStatementDuplicates(ctx, statement.Transactions, accountId)
+	if err != nil {
+		if ctx.Err() != nil {
+			return cancelledImport(filePath)
+		}
+		result.Message = fmt.Sprintf("Duplicate check failed: %v", err)
+		return result
+	}
+	result.DuplicateCount = duplicates
+	if len(newRows) == 0 {
+		result.Message = fmt.Sprintf("No new transactions found. %d duplicate transactions were filtered out.", duplicates)
+		return result
+	}
+
+	// Categorize the new rows, using the category the file names when the categorizer is unsure
+	newTransactions := make([]types.Transaction, len(newRows))
+	reporter := newProgressReporter(progress, types.ProgressParsing, len(newRows))
+	reporter.start()
+	for i, row := range newRows {
+		if ctx.Err() != nil {
+			return cancelledImport(filePath)
+		}
+		tx := statement.Transactions[row]
+		tx.CategoryId = s.CSVParser.assignStatementCategory(tx.Description, tx.Amount, statement.Categories[row], defaultCategoryId)
+		newTransactions[i] = tx
+		reporter.update(i + 1)
+	}
+
+	// The statement period comes from the file when it states one
+	result.PeriodStart, result.PeriodEnd = s.Statements.ExtractPeriodFromTransactions(statement.Transactions)
+	if !statement.PeriodStart.IsZero() {
+		result.PeriodStart = statement.PeriodStart.Format("2006-01-02")
+	}
+	if !statement.PeriodEnd.IsZero() {
+		result.PeriodEnd = statement.PeriodEnd.Format("2006-01-02")
+	}
+
+	record := &types.BankStatement{
+		Filename:       result.Filename,
+		AccountId:      accountId,
+		TxCount:        len(newTransactions),
+		Status:         "importing",
+		Format:         statement.Format,
+		OpeningBalance: statement.OpeningBalance,
+		ClosingBalance: statement.ClosingBalance,
+	}
+	statementId, err := s.Statements.RecordStatement(record, result.PeriodStart, result.PeriodEnd)
+	if err != nil {
+		result.Message = fmt.Sprintf("Failed to record statement: %v", err)
+		return result
+	}
+
+	start := time.Now()
+	assignAccount(newTransactions, accountId)
+	err = s.Transactions.ImportTransactionsFromCSVContext(ctx, newTransactions, statementId, progress)
+	if err != nil {
+		if ctx.Err() != nil {
+			s.discardCancelledStatement(statementId)
+			return cancelledImport(filePath)
+		}
+		s.Statements.MarkStatementFailed(statementId, fmt.Sprintf("Transaction import failed: %v", err))
+		result.Message = fmt.Sprintf("Import failed: %v", err)
+		return result
+	}
+	splitRows := s.applyStatementSplits(statementId, statement, newRows, defaultCategoryId)
+	if err := s.Statements.MarkStatementCompleted(statementId); err != nil {
+		result.Message = fmt.Sprintf("Failed to mark statement as completed: %v", err)
+		return result
+	}
+	slog.Info("imported statement file", "file", result.Filename, "format", statement.Format,
+		"transactions", len(newTransactions), "split_rows", splitRows, "duplicates", duplicates, "elapsed", time.Since(start))
+
+	// Save the directory for future imports (only on success)
+	if saveErr := s.SaveLastImportDirectory(filePath); saveErr != nil {
+		// Log error but don't fail the import
+		slog.Warn("failed to save last import directory", "error", saveErr)
+	}
+
+	result.Success = true
+	result.ImportedCount = len(newTransactions)
+	if duplicates > 0 {
+		result.Message = fmt.Sprintf("Successfully imported %d transactions from %s. %d duplicates filtered out.", len(newTransactions), result.Filename, duplicates)
+	} else {
+		result.Message = fmt.Sprintf("Successfully imported %d transactions from %s", len(newTransactions), result.Filename)
+	}
+	if statement.ZeroAmounts > 0 {
+		result.Message += fmt.Sprintf(" %d zero-amount entries skipped.", statement.ZeroAmounts)
+	}
+	return result
+}
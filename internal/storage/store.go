@@ -4,8 +4,12 @@ import (
 	"budget-tracker-tui/internal/database"
 	"budget-tracker-tui/internal/ml"
 	"budget-tracker-tui/internal/types"
+	"context"
 	"fmt"
+	"log/slog"
+	"os"
 	"path/filepath"
+	"sort"
 	"time"
 )
 
@@ -17,17 +21,26 @@ type Store struct {
 	Statements        *BankStatementStore
 	Templates         *CSVTemplateStore
 	TransactionAudits *TransactionAuditStore
+	Audit             *AuditStore // General audit log of creates, updates and deletes
 	Snapshots         *SnapshotStore
 	UserPreferences   *UserPreferencesStore
+	ExchangeRates     *ExchangeRateStore
+	Accounts          *AccountStore
 
 	// CSV parsing service
 	CSVParser *CSVParser
 
+	// Undo/redo history for user changes in the open ledger
+	Undo *UndoManager
+
 	// ML categorization service
 	MLCategorizer *ml.EmbeddingsCategorizer
 
 	// Private database connection
 	db *database.Connection
+
+	// Ledger file to open; empty means the default ledger
+	dbPath string
 }
 
 // NewStore creates a new Store with all domain stores
@@ -35,45 +48,160 @@ func NewStore() *Store {
 	return &Store{}
 }
 
+// NewStoreAt creates a new Store that opens the ledger file at dbPath
+func NewStoreAt(dbPath string) *Store {
+	return &Store{dbPath: dbPath}
+}
+
 // Init initializes the store and all domain stores with SQLite database
 func (s *Store) Init() error {
 	// Initialize SQLite database connection
-	db, err := database.NewConnection()
+	var db *database.Connection
+	var err error
+	if s.dbPath != "" {
+		db, err = database.NewConnectionAt(s.dbPath)
+	} else {
+		db, err = database.NewConnection()
+	}
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
 
 	s.db = db
+	s.Snapshots = NewSnapshotStore(db)
+
+	// Bring the schema up to date before any domain store touches it
+	if err := s.migrateDatabase(); err != nil {
+		db.Close()
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	s.initDomainStores(db)
+
+	// Permanently remove transactions that have outlived the trash retention period
+	if _, err := s.Transactions.PurgeExpiredTrash(s.UserPreferences.GetTrashRetentionDays()); err != nil {
+		return fmt.Errorf("failed to purge expired trash: %w", err)
+	}
+
+	// Initialize ML categorization service
+	err = s.initializeMLCategorizer()
+	if err != nil {
+		return fmt.Errorf("failed to initialize ML categorizer: %w", err)
+	}
+
+	// Initialize CSV parser with dependencies
+	s.CSVParser = NewCSVParser(s.Transactions, s.Categories, s.MLCategorizer)
+
+	// No need to load stores explicitly with SQLite - data is always persisted
+	// Database health check to ensure everything is working
+	err = s.db.CheckHealth()
+	if err != nil {
+		return fmt.Errorf("database health check failed: %w", err)
+	}
+
+	return nil
+}
+
+// InitForCheck opens an existing ledger for an integrity check without writing to it
+// Unlike Init it never creates the file, migrates, purges the trash or trains the categorizer,
+// so it refuses a ledger whose schema is older than this application.
+func (s *Store) InitForCheck() error {
+	dbPath := s.dbPath
+	if dbPath == "" {
+		var err error
+		if dbPath, err = database.DefaultDatabasePath(); err != nil {
+			return err
+		}
+	}
+
+	db, err := database.OpenConnectionAt(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+
+	pending, err := db.PendingMigrations()
+	if err != nil {
+		db.Close()
+		return err
+	}
+	if len(pending) > 0 {
+		current, _ := db.GetSchemaVersion()
+		db.Close()
+		return fmt.Errorf("ledger schema version %d is older than this application (%d); open it once or use -repair to upgrade it",
+			current, pending[len(pending)-1].Version)
+	}
+
+	s.db = db
+	s.Snapshots = NewSnapshotStore(db)
+	s.initDomainStores(db)
+	return nil
+}
 
+// initDomainStores creates the domain stores on db and wires them together
+func (s *Store) initDomainStores(db *database.Connection) {
 	// Initialize domain stores with database connection
 	s.Categories = NewCategoryStore(db)
 	s.Templates = NewCSVTemplateStore(db)
 	s.Statements = NewBankStatementStore(db)
 	s.Transactions = NewTransactionStore(db)
 	s.TransactionAudits = NewTransactionAuditStore(db)
-	s.Snapshots = NewSnapshotStore(db)
+	s.Audit = NewAuditStore(db)
 	s.UserPreferences = NewUserPreferencesStore(db)
+	s.ExchangeRates = NewExchangeRateStore(db)
+	s.Accounts = NewAccountStore(db)
 
 	// Set cross-references between stores
 	s.Transactions.SetTransactionAuditStore(s.TransactionAudits)
 	s.Transactions.SetStore(s)                       // Add store reference for ML access
 	s.Categories.SetTransactionStore(s.Transactions) // For cross-domain category validation
 	s.Statements.SetTransactionStore(s.Transactions) // For cross-domain undo operations
+	s.Snapshots.SetStore(s)                          // For restores that swap the live connection
+
+	// Every store that creates, updates or deletes audited records writes to the general audit log
+	s.Transactions.SetAuditStore(s.Audit)
+	s.Categories.SetAuditStore(s.Audit)
+	s.Templates.SetAuditStore(s.Audit)
+	s.Statements.SetAuditStore(s.Audit)
+	s.Snapshots.SetAuditStore(s.Audit)
+	s.UserPreferences.SetAuditStore(s.Audit)
+
+	// A fresh history per connection, so switching or restoring a ledger never replays into the wrong one
+	s.Undo = NewUndoManager(s.Transactions, s.Categories, s.TransactionAudits)
+	s.Transactions.SetUndoManager(s.Undo)
+	s.Categories.SetUndoManager(s.Undo)
+}
 
-	// Initialize ML categorization service
-	err = s.initializeMLCategorizer()
+// migrateDatabase applies pending schema migrations, snapshotting existing databases first
+func (s *Store) migrateDatabase() error {
+	pending, err := s.db.PendingMigrations()
 	if err != nil {
-		return fmt.Errorf("failed to initialize ML categorizer: %w", err)
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
 	}
 
-	// Initialize CSV parser with dependencies
-	s.CSVParser = NewCSVParser(s.Transactions, s.Categories, s.MLCategorizer)
+	// Brand new databases have nothing worth preserving
+	snapshotPath := ""
+	if !s.db.IsNew() {
+		fromVersion, err := s.db.GetSchemaVersion()
+		if err != nil {
+			return err
+		}
+		snapshotPath = filepath.Join(filepath.Dir(s.db.GetPath()), "snapshots",
+			fmt.Sprintf("pre-migration_v%d_to_v%d_%s.db", fromVersion, pending[len(pending)-1].Version,
+				time.Now().Format("2006-01-02_15-04-05")))
 
-	// No need to load stores explicitly with SQLite - data is always persisted
-	// Database health check to ensure everything is working
-	err = s.db.CheckHealth()
-	if err != nil {
-		return fmt.Errorf("database health check failed: %w", err)
+		if err := s.Snapshots.CreateSnapshotFile(snapshotPath); err != nil {
+			return fmt.Errorf("failed to create pre-migration snapshot: %w", err)
+		}
+	}
+
+	if _, err := s.db.Migrate(); err != nil {
+		if snapshotPath != "" {
+			return fmt.Errorf("%w (pre-migration snapshot saved to %s)", err, snapshotPath)
+		}
+		return err
 	}
 
 	return nil
@@ -105,9 +233,8 @@ func (s *Store) initializeMLCategorizer() error {
 
 	// Log training statistics
 	stats := s.MLCategorizer.GetStats()
-	fmt.Printf("[ML] Categorizer initialized with %v examples from %v categories\n",
-		stats["total_examples"], stats["categories_with_examples"])
-	fmt.Printf("[ML] Training data loaded: %d audit events found\n", len(auditEvents))
+	slog.Info("ML categorizer initialized", "examples", stats["total_examples"],
+		"categories", stats["categories_with_examples"], "audit_events", len(auditEvents))
 
 	return nil
 }
@@ -128,10 +255,47 @@ func (s *Store) GetDatabasePath() string {
 	return ""
 }
 
+// SwitchLedger closes the current ledger and reopens the store on the ledger file at dbPath
+// A missing file is created as a new, empty ledger. If the new ledger cannot be opened
+// the previous ledger is reopened so the store stays usable.
+func (s *Store) SwitchLedger(dbPath string) error {
+	dbPath, err := database.ExpandLedgerPath(dbPath)
+	if err != nil {
+		return err
+	}
+
+	previousPath := s.GetDatabasePath()
+	if dbPath == previousPath {
+		return nil
+	}
+	if err := s.Close(); err != nil {
+		return fmt.Errorf("failed to close current ledger: %w", err)
+	}
+
+	s.dbPath = dbPath
+	if err := s.Init(); err != nil {
+		s.Close()
+		s.dbPath = previousPath
+		if reopenErr := s.Init(); reopenErr != nil {
+			return fmt.Errorf("failed to open ledger (%v) and reopen failed: %w", err, reopenErr)
+		}
+		return fmt.Errorf("failed to open ledger %s: %w", dbPath, err)
+	}
+
+	return nil
+}
+
 // High-level operations that coordinate between domain stores
 
-// ValidateAndImportCSV validates and imports CSV with overlap detection
-func (s *Store) ValidateAndImportCSV(filePath, templateName string) *types.ImportResult {
+// ValidateAndImportCSV validates and imports CSV into an account with overlap detection
+// An accountId of 0 imports without an account and detects overlaps by template.
+func (s *Store) ValidateAndImportCSV(filePath, templateName string, accountId int64) *types.ImportResult {
+	return s.ValidateAndImportCSVContext(context.Background(), filePath, templateName, accountId, nil)
+}
+
+// ValidateAndImportCSVContext is ValidateAndImportCSV with progress reporting and cancellation
+// Cancelling ctx rolls back the partially written statement and returns a result with Cancelled set.
+func (s *Store) ValidateAndImportCSVContext(ctx context.Context, filePath, templateName string, accountId int64, progress types.ProgressFunc) *types.ImportResult {
 	result := &types.ImportResult{}
 
 	template := s.Templates.GetTemplateByName(templateName)
@@ -140,9 +304,17 @@ func (s *Store) ValidateAndImportCSV(filePath, templateName string) *types.Impor
 		return result
 	}
 
+	if err := s.checkImportAccount(accountId); err != nil {
+		result.Message = err.Error()
+		return result
+	}
+
 	// Validate CSV data before importing using fail-fast mode
-	parseResult, err := s.CSVParser.ParseCSV(filePath, template, types.FailFast)
+	parseResult, err := s.CSVParser.ParseCSVContext(ctx, filePath, template, types.FailFast, progress)
 	if err != nil {
+		if ctx.Err() != nil {
+			return cancelledImport(filePath)
+		}
 		result.Message = fmt.Sprintf("Validation error: %v", err)
 		return result
 	}
@@ -171,7 +343,7 @@ func (s *Store) ValidateAndImportCSV(filePath, templateName string) *types.Impor
 
 	// Extract period and detect overlaps
 	result.PeriodStart, result.PeriodEnd = s.Statements.ExtractPeriodFromTransactions(transactions)
-	result.OverlappingStmts = s.Statements.DetectOverlap(result.PeriodStart, result.PeriodEnd, template.Id)
+	result.OverlappingStmts = s.Statements.DetectOverlap(result.PeriodStart, result.PeriodEnd, template.Id, accountId)
 	result.Filename = filepath.Base(filePath)
 
 	if len(result.OverlappingStmts) > 0 {
@@ -181,9 +353,12 @@ func (s *Store) ValidateAndImportCSV(filePath, templateName string) *types.Impor
 		return result
 	}
 
-	// No overlaps, proceed with import
-	err = s.ImportTransactionsFromCSV(filePath, templateName)
+	// No overlaps, proceed with import of the rows already parsed
+	_, err = s.importParsedTransactions(ctx, transactions, filePath, template, accountId, progress)
 	if err != nil {
+		if ctx.Err() != nil {
+			return cancelledImport(filePath)
+		}
 		result.Message = fmt.Sprintf("Import failed: %v", err)
 		return result
 	}
@@ -191,7 +366,7 @@ func (s *Store) ValidateAndImportCSV(filePath, templateName string) *types.Impor
 	// Save the directory for future imports (only on success)
 	if saveErr := s.SaveLastImportDirectory(filePath); saveErr != nil {
 		// Log error but don't fail the import
-		fmt.Printf("[Warning] Failed to save last import directory: %v\n", saveErr)
+		slog.Warn("failed to save last import directory", "error", saveErr)
 	}
 
 	result.Success = true
@@ -200,8 +375,13 @@ func (s *Store) ValidateAndImportCSV(filePath, templateName string) *types.Impor
 	return result
 }
 
-// ImportCSVWithOverride imports CSV with duplicate filtering (only new transactions)
-func (s *Store) ImportCSVWithOverride(filePath, templateName string) *types.ImportResult {
+// ImportCSVWithOverride imports CSV into an account with duplicate filtering (only new transactions)
+func (s *Store) ImportCSVWithOverride(filePath, templateName string, accountId int64) *types.ImportResult {
+	return s.ImportCSVWithOverrideContext(context.Background(), filePath, templateName, accountId, nil)
+}
+
+// ImportCSVWithOverrideContext is ImportCSVWithOverride with progress reporting and cancellation
+func (s *Store) ImportCSVWithOverrideContext(ctx context.Context, filePath, templateName string, accountId int64, progress types.ProgressFunc) *types.ImportResult {
 	result := &types.ImportResult{}
 
 	template := s.Templates.GetTemplateByName(templateName)
@@ -210,9 +390,17 @@ func (s *Store) ImportCSVWithOverride(filePath, templateName string) *types.Impo
 		return result
 	}
 
+	if err := s.checkImportAccount(accountId); err != nil {
+		result.Message = err.Error()
+		return result
+	}
+
 	// Parse CSV with duplicate detection
-	parseResult, err := s.CSVParser.ParseWithDuplicateDetection(filePath, template)
+	parseResult, err := s.CSVParser.ParseWithDuplicateDetectionContext(ctx, filePath, template, progress)
 	if err != nil {
+		if ctx.Err() != nil {
+			return cancelledImport(filePath)
+		}
 		result.Message = fmt.Sprintf("Parse error: %v", err)
 		return result
 	}
@@ -245,27 +433,23 @@ func (s *Store) ImportCSVWithOverride(filePath, templateName string) *types.Impo
 		return result
 	}
 
-	// Record statement with override status first to get statement ID
+	// Record a statement with override status and import only the new transactions
 	result.PeriodStart, result.PeriodEnd = s.Statements.ExtractPeriodFromTransactions(newTransactions)
 	filename := filepath.Base(filePath)
 
-	actualStatementId, err := s.Statements.RecordBankStatement(filename, result.PeriodStart, result.PeriodEnd, template.Id, len(newTransactions), "override")
-	if err != nil {
-		result.Message = fmt.Sprintf("Failed to record statement: %v", err)
-		return result
-	}
-
-	// Import only new transactions with actual statement ID
-	err = s.Transactions.ImportTransactionsFromCSV(newTransactions, actualStatementId)
+	_, err = s.importOverrideTransactions(ctx, newTransactions, filePath, template, accountId, progress)
 	if err != nil {
-		result.Message = fmt.Sprintf("Save failed: %v", err)
+		if ctx.Err() != nil {
+			return cancelledImport(filePath)
+		}
+		result.Message = fmt.Sprintf("Import failed: %v", err)
 		return result
 	}
 
 	// Save the directory for future imports (only on success)
 	if saveErr := s.SaveLastImportDirectory(filePath); saveErr != nil {
 		// Log error but don't fail the import
-		fmt.Printf("[Warning] Failed to save last import directory: %v\n", saveErr)
+		slog.Warn("failed to save last import directory", "error", saveErr)
 	}
 
 	result.Success = true
@@ -279,16 +463,24 @@ func (s *Store) ImportCSVWithOverride(filePath, templateName string) *types.Impo
 	return result
 }
 
-// ImportTransactionsFromCSV imports transactions from CSV file
-func (s *Store) ImportTransactionsFromCSV(filePath, templateName string) error {
+// ImportTransactionsFromCSV imports transactions from CSV file into an account
+func (s *Store) ImportTransactionsFromCSV(filePath, templateName string, accountId int64) error {
+	return s.ImportTransactionsFromCSVContext(context.Background(), filePath, templateName, accountId, nil)
+}
+
+// ImportTransactionsFromCSVContext imports transactions from a CSV file, returning ctx.Err() if cancelled
+func (s *Store) ImportTransactionsFromCSVContext(ctx context.Context, filePath, templateName string, accountId int64, progress types.ProgressFunc) error {
 	template := s.Templates.GetTemplateByName(templateName)
 	if template == nil {
 		return fmt.Errorf("template '%s' not found", templateName)
 	}
 
 	// Parse transactions using CSV parser
-	parseResult, err := s.CSVParser.ParseCSV(filePath, template, types.FailFast)
+	parseResult, err := s.CSVParser.ParseCSVContext(ctx, filePath, template, types.FailFast, progress)
 	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		return fmt.Errorf("failed to parse CSV: %v", err)
 	}
 
@@ -297,57 +489,111 @@ func (s *Store) ImportTransactionsFromCSV(filePath, templateName string) error {
 		return fmt.Errorf("no valid transactions found in CSV")
 	}
 
-	// Validate that default category exists before importing
-	defaultCategoryId := s.Categories.GetDefaultCategoryId()
-	if defaultCategoryId <= 0 {
-		return fmt.Errorf("no default category configured")
-	}
+	_, err = s.importParsedTransactions(ctx, transactions, filePath, template, accountId, progress)
+	return err
+}
 
-	// Verify the category exists in the database
-	exists, err := s.Categories.CategoryExists(defaultCategoryId)
-	if err != nil {
-		return fmt.Errorf("failed to validate default category: %v", err)
-	}
-	if !exists {
-		return fmt.Errorf("default category (ID: %d) not found in database. Please create categories first.", defaultCategoryId)
+// importParsedTransactions records a statement for parsed transactions and writes them into an account,
+// returning the statement's ID
+// If ctx is cancelled while the rows are written, the statement is removed and ctx.Err() is returned.
+func (s *Store) importParsedTransactions(ctx context.Context, transactions []types.Transaction, filePath string, template *types.CSVTemplate, accountId int64, progress types.ProgressFunc) (int64, error) {
+	// Validate that default category exists before importing
+	if _, err := s.checkDefaultCategory(); err != nil {
+		return 0, err
 	}
 
 	// Extract period from transactions
 	periodStart, periodEnd := s.Statements.ExtractPeriodFromTransactions(transactions)
 
-	// Check for overlaps with same template
-	overlaps := s.Statements.DetectOverlap(periodStart, periodEnd, template.Id)
+	// Check for overlaps with same account (or template when no account is given)
+	overlaps := s.Statements.DetectOverlap(periodStart, periodEnd, template.Id, accountId)
 	if len(overlaps) > 0 {
 		// Return special error for overlap detection
-		return fmt.Errorf("OVERLAP_DETECTED")
+		return 0, fmt.Errorf("OVERLAP_DETECTED")
 	}
 
 	// Create statement record first and get actual assigned ID
 	filename := filepath.Base(filePath)
 
 	// Create statement record first with "importing" status to satisfy foreign key
-	actualStatementId, err := s.Statements.RecordBankStatement(filename, periodStart, periodEnd, template.Id, len(transactions), "importing")
+	actualStatementId, err := s.Statements.RecordBankStatement(filename, periodStart, periodEnd, template.Id, accountId, len(transactions), "importing")
 	if err != nil {
-		return fmt.Errorf("failed to create statement record: %v", err)
+		return 0, fmt.Errorf("failed to create statement record: %v", err)
 	}
 
 	// Now import transactions with actual statement_id reference
-	err = s.Transactions.ImportTransactionsFromCSV(transactions, actualStatementId)
+	assignAccount(transactions, accountId)
+	err = s.Transactions.ImportTransactionsFromCSVContext(ctx, transactions, actualStatementId, progress)
 	if err != nil {
+		if ctx.Err() != nil {
+			s.discardCancelledStatement(actualStatementId)
+			return 0, ctx.Err()
+		}
 		// If transaction import fails, mark statement as failed using actual ID
 		s.Statements.MarkStatementFailed(actualStatementId, fmt.Sprintf("Transaction import failed: %v", err))
-		return fmt.Errorf("failed to import transactions: %v", err)
+		return 0, fmt.Errorf("failed to import transactions: %v", err)
 	}
 
 	// Update statement status to completed after successful import
 	err = s.Statements.MarkStatementCompleted(actualStatementId)
 	if err != nil {
-		return fmt.Errorf("failed to mark statement as completed: %v", err)
+		return 0, fmt.Errorf("failed to mark statement as completed: %v", err)
+	}
+
+	return actualStatementId, nil
+}
+
+// checkDefaultCategory returns the default category that imported rows fall back to, ensuring it exists
+func (s *Store) checkDefaultCategory() (int64, error) {
+	defaultCategoryId := s.Categories.GetDefaultCategoryId()
+	if defaultCategoryId <= 0 {
+		return 0, fmt.Errorf("no default category configured")
+	}
+
+	// Verify the category exists in the database
+	exists, err := s.Categories.CategoryExists(defaultCategoryId)
+	if err != nil {
+		return 0, fmt.Errorf("failed to validate default category: %v", err)
+	}
+	if !exists {
+		return 0, fmt.Errorf("default category (ID: %d) not found in database. Please create categories first.", defaultCategoryId)
 	}
+	return defaultCategoryId, nil
+}
+
+// discardCancelledStatement removes the statement record of an import that was cancelled
+// The transaction rows were rolled back with the cancelled database transaction.
+func (s *Store) discardCancelledStatement(statementId int64) {
+	if err := s.Statements.DeleteStatement(statementId); err != nil {
+		slog.Warn("failed to remove cancelled statement", "statement_id", statementId, "error", err)
+	}
+}
 
+// cancelledImport is the result of an import stopped before anything was saved
+func cancelledImport(filePath string) *types.ImportResult {
+	filename := filepath.Base(filePath)
+	return &types.ImportResult{
+		Cancelled: true,
+		Filename:  filename,
+		Message:   fmt.Sprintf("Import of %s cancelled; nothing was saved", filename),
+	}
+}
+
+// checkImportAccount ensures a non-zero import account exists
+func (s *Store) checkImportAccount(accountId int64) error {
+	if accountId != 0 && s.Accounts.GetAccountById(accountId) == nil {
+		return fmt.Errorf("account (ID: %d) not found", accountId)
+	}
 	return nil
 }
 
+// assignAccount links parsed transactions to the account they are imported into
+func assignAccount(transactions []types.Transaction, accountId int64) {
+	for i := range transactions {
+		transactions[i].AccountId = accountId
+	}
+}
+
 // Legacy method compatibility - delegate to Categories store
 func (s *Store) GetCategoryDisplayName(categoryId int64) string {
 	return s.Categories.GetCategoryDisplayName(categoryId)
@@ -355,70 +601,214 @@ func (s *Store) GetCategoryDisplayName(categoryId int64) string {
 
 // Analytics methods for spending analysis
 
-// GetTransactionSummaryByDateRange returns income/expense totals for a date range
+// GetTransactionSummaryByDateRange returns income/expense totals for a date range in the base currency
 func (s *Store) GetTransactionSummaryByDateRange(startDate, endDate time.Time) (*types.AnalyticsSummary, error) {
-	query := "SELECT COALESCE(SUM(CASE WHEN transaction_type = 'income' THEN amount ELSE 0 END), 0) as total_income, " +
-		"COALESCE(SUM(CASE WHEN transaction_type = 'expense' THEN ABS(amount) ELSE 0 END), 0) as total_expense, " +
-		"COUNT(*) as transaction_count FROM transactions WHERE date >= ? AND date <= ?"
+	return s.GetTransactionSummaryByDateRangeContext(context.Background(), startDate, endDate)
+}
+
+// GetTransactionSummaryByDateRangeContext is GetTransactionSummaryByDateRange, stopping with ctx.Err() when cancelled
+func (s *Store) GetTransactionSummaryByDateRangeContext(ctx context.Context, startDate, endDate time.Time) (*types.AnalyticsSummary, error) {
+	// Group by currency and day so each sum is converted with the rate for that day
+	query := "SELECT currency, date, " +
+		"COALESCE(SUM(CASE WHEN transaction_type = 'income' THEN amount_cents ELSE 0 END), 0) as total_income, " +
+		"COALESCE(SUM(CASE WHEN transaction_type = 'expense' THEN ABS(amount_cents) ELSE 0 END), 0) as total_expense, " +
+		"COUNT(*) as transaction_count FROM transactions WHERE date >= ? AND date <= ? AND deleted_at IS NULL GROUP BY currency, date"
 
 	startStr := startDate.Format("2006-01-02")
 	endStr := endDate.Format("2006-01-02")
 
-	helper := database.NewSQLHelper(s.db)
-	row := helper.QuerySingleRow(query, startStr, endStr)
+	type dailyTotals struct {
+		currency, date            string
+		incomeCents, expenseCents int64
+		count                     int
+	}
 
-	var summary types.AnalyticsSummary
-	err := row.Scan(&summary.TotalIncome, &summary.TotalExpenses, &summary.TransactionCount)
+	rows, err := s.db.DB.QueryContext(ctx, query, startStr, endStr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transaction summary: %w", err)
 	}
 
-	summary.NetAmount = summary.TotalIncome - summary.TotalExpenses
+	// Read every group before converting so rate lookups don't run while rows are open
+	var groups []dailyTotals
+	for rows.Next() {
+		var g dailyTotals
+		if err := rows.Scan(&g.currency, &g.date, &g.incomeCents, &g.expenseCents, &g.count); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to get transaction summary: %w", err)
+		}
+		groups = append(groups, g)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get transaction summary: %w", err)
+	}
+
+	baseCurrency := s.UserPreferences.GetBaseCurrency()
+	summary := types.AnalyticsSummary{
+		TotalIncome:   types.NewMoney(0, baseCurrency),
+		TotalExpenses: types.NewMoney(0, baseCurrency),
+	}
+
+	for _, g := range groups {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		income, err := s.convertToBase(types.NewMoney(g.incomeCents, g.currency), baseCurrency, g.date)
+		if err != nil {
+			return nil, err
+		}
+		expenses, err := s.convertToBase(types.NewMoney(g.expenseCents, g.currency), baseCurrency, g.date)
+		if err != nil {
+			return nil, err
+		}
+
+		summary.TotalIncome = summary.TotalIncome.Add(income)
+		summary.TotalExpenses = summary.TotalExpenses.Add(expenses)
+		summary.TransactionCount += g.count
+	}
+
+	summary.NetAmount = summary.TotalIncome.Sub(summary.TotalExpenses)
 	summary.DateRange = fmt.Sprintf("%s to %s", startStr, endStr)
 
 	return &summary, nil
 }
 
-// GetCategorySpendingByDateRange returns spending breakdown by category for a date range
+// GetCategorySpendingByDateRange returns spending breakdown by category for a date range in the base currency
 func (s *Store) GetCategorySpendingByDateRange(startDate, endDate time.Time) ([]types.CategorySpending, error) {
-	helper := database.NewSQLHelper(s.db)
+	return s.GetCategorySpendingByDateRangeContext(context.Background(), startDate, endDate)
+}
+
+// GetCategorySpendingByDateRangeContext is GetCategorySpendingByDateRange, stopping with ctx.Err() when cancelled
+func (s *Store) GetCategorySpendingByDateRangeContext(ctx context.Context, startDate, endDate time.Time) ([]types.CategorySpending, error) {
 	startStr := startDate.Format("2006-01-02")
 	endStr := endDate.Format("2006-01-02")
 
-	// Main query - get expenses with positive amounts
-	query := "SELECT c.display_name, COALESCE(SUM(ABS(t.amount)), 0) as total_amount, COUNT(t.id) as transaction_count " +
+	// Main query - get expenses with positive amounts, split by currency and day for conversion
+	query := "SELECT c.id, c.display_name, t.currency, t.date, COALESCE(SUM(ABS(t.amount_cents)), 0) as total_amount, COUNT(t.id) as transaction_count " +
 		"FROM categories c INNER JOIN transactions t ON c.id = t.category_id " +
-		"AND t.date >= ? AND t.date <= ? AND t.transaction_type = 'expense' " +
-		"WHERE c.is_active = true GROUP BY c.id, c.display_name ORDER BY total_amount DESC"
+		"AND t.date >= ? AND t.date <= ? AND t.transaction_type = 'expense' AND t.deleted_at IS NULL " +
+		"WHERE c.is_active = true GROUP BY c.id, c.display_name, t.currency, t.date"
+
+	type dailySpending struct {
+		categoryId                   int64
+		categoryName, currency, date string
+		amountCents                  int64
+		count                        int
+	}
 
-	rows, err := helper.QueryRows(query, startStr, endStr)
+	rows, err := s.db.DB.QueryContext(ctx, query, startStr, endStr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query category spending: %w", err)
 	}
-	defer rows.Close()
 
+	// Read every group before converting so rate lookups don't run while rows are open
+	var groups []dailySpending
+	for rows.Next() {
+		var g dailySpending
+		if err := rows.Scan(&g.categoryId, &g.categoryName, &g.currency, &g.date, &g.amountCents, &g.count); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan category spending: %w", err)
+		}
+		groups = append(groups, g)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query category spending: %w", err)
+	}
+
+	baseCurrency := s.UserPreferences.GetBaseCurrency()
 	var categorySpending []types.CategorySpending
-	var totalExpenses float64
+	categoryIndex := make(map[int64]int)
+	totalExpenses := types.NewMoney(0, baseCurrency)
 
-	// First pass: collect data and calculate total
-	for rows.Next() {
-		var spending types.CategorySpending
-		err := rows.Scan(&spending.CategoryName, &spending.Amount, &spending.TransactionCount)
+	// First pass: convert and accumulate per category
+	for _, g := range groups {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		amount, err := s.convertToBase(types.NewMoney(g.amountCents, g.currency), baseCurrency, g.date)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan category spending: %w", err)
+			return nil, err
 		}
-		categorySpending = append(categorySpending, spending)
-		totalExpenses += spending.Amount
+
+		idx, ok := categoryIndex[g.categoryId]
+		if !ok {
+			idx = len(categorySpending)
+			categoryIndex[g.categoryId] = idx
+			categorySpending = append(categorySpending, types.CategorySpending{
+				CategoryId:   g.categoryId,
+				CategoryName: g.categoryName,
+				Amount:       types.NewMoney(0, baseCurrency),
+			})
+		}
+		categorySpending[idx].Amount = categorySpending[idx].Amount.Add(amount)
+		categorySpending[idx].TransactionCount += g.count
+		totalExpenses = totalExpenses.Add(amount)
 	}
 
+	// Largest spending first
+	sort.SliceStable(categorySpending, func(i, j int) bool {
+		return categorySpending[i].Amount.Cents > categorySpending[j].Amount.Cents
+	})
+
 	// Second pass: calculate percentages
 	for i := range categorySpending {
-		if totalExpenses > 0 {
-			categorySpending[i].Percentage = (categorySpending[i].Amount / totalExpenses) * 100
+		if totalExpenses.Cents > 0 {
+			categorySpending[i].Percentage = float64(categorySpending[i].Amount.Cents) / float64(totalExpenses.Cents) * 100
 		}
 	}
 
-	return categorySpending, rows.Err()
+	return categorySpending, nil
+}
+
+// GetKnownCurrencies returns every currency used by transactions, templates or exchange rates plus the base currency
+func (s *Store) GetKnownCurrencies() ([]string, error) {
+	query := "SELECT currency FROM transactions UNION SELECT currency FROM csv_templates " +
+		"UNION SELECT from_currency FROM exchange_rates UNION SELECT to_currency FROM exchange_rates"
+
+	helper := database.NewSQLHelper(s.db)
+	rows, err := helper.QueryRows(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query currencies: %w", err)
+	}
+	defer rows.Close()
+
+	seen := map[string]bool{s.UserPreferences.GetBaseCurrency(): true}
+	for rows.Next() {
+		var currency string
+		if err := rows.Scan(&currency); err != nil {
+			return nil, fmt.Errorf("failed to scan currency: %w", err)
+		}
+		seen[currency] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query currencies: %w", err)
+	}
+
+	currencies := make([]string, 0, len(seen))
+	for currency := range seen {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	return currencies, nil
+}
+
+// convertToBase converts an amount recorded on dateStr into the base currency
+func (s *Store) convertToBase(amount types.Money, baseCurrency, dateStr string) (types.Money, error) {
+	if amount.Currency == baseCurrency || amount.IsZero() {
+		return types.NewMoney(amount.Cents, baseCurrency), nil
+	}
+
+	date, err := s.Transactions.parseFlexibleDate(dateStr)
+	if err != nil {
+		return types.Money{}, fmt.Errorf("failed to parse transaction date '%s': %w", dateStr, err)
+	}
+
+	converted, err := s.ExchangeRates.Convert(amount, baseCurrency, date)
+	if err != nil {
+		return types.Money{}, fmt.Errorf("cannot report %s in %s: %w", amount.Display(), baseCurrency, err)
+	}
+	return converted, nil
 }
 
 // ML Categorization Methods
@@ -448,9 +838,15 @@ func (s *Store) IsHighConfidencePrediction(prediction ml.CategoryPrediction) boo
 
 // RetrainMLCategorizer retrains the ML categorizer with latest audit events
 func (s *Store) RetrainMLCategorizer() error {
+	return s.RetrainMLCategorizerContext(context.Background(), nil)
+}
+
+// RetrainMLCategorizerContext retrains the ML categorizer, leaving the current model in place if ctx is cancelled
+func (s *Store) RetrainMLCategorizerContext(ctx context.Context, progress types.ProgressFunc) error {
 	if s.MLCategorizer == nil {
 		return fmt.Errorf("ML categorizer not initialized")
 	}
+	newProgressReporter(progress, types.ProgressTraining, 0).start()
 
 	// Load fresh categories
 	categories, err := s.Categories.GetCategories()
@@ -464,6 +860,11 @@ func (s *Store) RetrainMLCategorizer() error {
 		return fmt.Errorf("failed to load category edit events: %w", err)
 	}
 
+	// Last chance to stop before the current model is replaced
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	// Retrain the ML model
 	err = s.MLCategorizer.Train(auditEvents, categories)
 	if err != nil {
@@ -472,8 +873,8 @@ func (s *Store) RetrainMLCategorizer() error {
 
 	// Log retraining statistics
 	stats := s.MLCategorizer.GetStats()
-	fmt.Printf("[ML] Categorizer retrained with %v examples from %v categories\n",
-		stats["total_examples"], stats["categories_with_examples"])
+	slog.Info("ML categorizer retrained", "examples", stats["total_examples"],
+		"categories", stats["categories_with_examples"])
 
 	return nil
 }
@@ -508,6 +909,12 @@ func (s *Store) CreateSnapshotToUserLocation(name, description, userPath string)
 	return s.Snapshots.CreateSnapshotWithUserPath(name, description, userPath)
 }
 
+// CreateSnapshotFileContext writes a snapshot of the open ledger to filePath, stopping with ctx.Err() when cancelled
+func (s *Store) CreateSnapshotFileContext(ctx context.Context, filePath string, progress types.ProgressFunc) error {
+	newProgressReporter(progress, types.ProgressSnapshot, 0).start()
+	return s.Snapshots.CreateSnapshotFileContext(ctx, filePath)
+}
+
 // RestoreSnapshotSafely performs a safe restore with automatic backup
 func (s *Store) RestoreSnapshotSafely(snapshotId int64) (*RestoreResult, error) {
 	// Validate snapshot exists
@@ -520,7 +927,93 @@ func (s *Store) RestoreSnapshotSafely(snapshotId int64) (*RestoreResult, error)
 	}
 
 	// Perform safe restore with backup
-	return s.Snapshots.RestoreFromSnapshotWithBackup(snapshotId)
+	result, err := s.Snapshots.RestoreFromSnapshotWithBackup(snapshotId)
+	if err == nil && result != nil && result.Success {
+		// Recorded changes refer to rows the restore replaced
+		s.Undo.Clear()
+	}
+	return result, err
+}
+
+// RestoreFromSnapshotFile replaces the live database with a snapshot file and reconnects
+// The current database is backed up first and put back if the snapshot cannot be opened.
+// On success every domain store is rebuilt, so callers must re-read any cached data.
+func (s *Store) RestoreFromSnapshotFile(snapshotPath string) (*RestoreResult, error) {
+	if err := s.Snapshots.ValidateSnapshotFileAdvanced(snapshotPath); err != nil {
+		return &RestoreResult{Success: false, Message: fmt.Sprintf("snapshot validation failed: %v", err)}, nil
+	}
+
+	// Older snapshots are migrated on reopen; newer ones cannot be read by this binary
+	snapshotVersion, err := database.ReadSnapshotSchemaVersion(snapshotPath)
+	if err != nil {
+		return &RestoreResult{Success: false, Message: fmt.Sprintf("snapshot validation failed: %v", err)}, nil
+	}
+	latestVersion, err := database.LatestSchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	if snapshotVersion > latestVersion {
+		return &RestoreResult{Success: false, Message: fmt.Sprintf(
+			"snapshot schema version %d is newer than this application supports (%d)", snapshotVersion, latestVersion)}, nil
+	}
+
+	dbPath := s.GetDatabasePath()
+	if dbPath == "" {
+		return &RestoreResult{Success: false, Message: "could not determine current database path"}, nil
+	}
+
+	// Back up current state so a bad restore can be undone
+	backupPath := filepath.Join(filepath.Dir(dbPath), "backups",
+		fmt.Sprintf("backup_before_restore_%s.db", time.Now().Format("20060102_150405")))
+	if err := s.Snapshots.CreateSnapshotFile(backupPath); err != nil {
+		return &RestoreResult{Success: false, Message: fmt.Sprintf("failed to create current state backup: %v", err)}, nil
+	}
+
+	if err := s.db.Checkpoint(); err != nil {
+		return &RestoreResult{Success: false, Message: err.Error()}, nil
+	}
+	if err := s.Close(); err != nil {
+		return &RestoreResult{Success: false, Message: fmt.Sprintf("failed to close current database: %v", err)}, nil
+	}
+
+	if err := database.ReplaceDatabaseFile(snapshotPath, dbPath); err != nil {
+		// The original file is untouched, so just reconnect to it
+		if initErr := s.Init(); initErr != nil {
+			return nil, fmt.Errorf("restore failed (%v) and reconnect failed: %w", err, initErr)
+		}
+		return &RestoreResult{Success: false, Message: fmt.Sprintf("failed to replace database: %v", err)}, nil
+	}
+
+	// Reopen runs pending migrations, rewires every domain store and retrains the categorizer
+	if err := s.Init(); err != nil {
+		s.Close()
+		if rollbackErr := database.ReplaceDatabaseFile(backupPath, dbPath); rollbackErr != nil {
+			return nil, fmt.Errorf("restored database failed to open (%v) and rollback failed: %w", err, rollbackErr)
+		}
+		if initErr := s.Init(); initErr != nil {
+			return nil, fmt.Errorf("restored database failed to open (%v) and reconnect failed: %w", err, initErr)
+		}
+		return &RestoreResult{Success: false, Message: fmt.Sprintf(
+			"restored database could not be opened, previous state kept: %v", err)}, nil
+	}
+
+	txCount, _, _, _, _, _ := s.Snapshots.CalculateSnapshotCounts()
+	backupSize := int64(0)
+	if info, err := os.Stat(backupPath); err == nil {
+		backupSize = info.Size()
+	}
+	snapshotDate := ""
+	if info, err := os.Stat(snapshotPath); err == nil {
+		snapshotDate = info.ModTime().Format("01/02/2006 3:04 PM")
+	}
+
+	return &RestoreResult{
+		Success:    true,
+		Message:    fmt.Sprintf("Snapshot restored from %s (backup saved to %s)", snapshotPath, backupPath),
+		TxCount:    txCount,
+		BackupDate: snapshotDate,
+		BackupSize: backupSize,
+	}, nil
 }
 
 // LoadSnapshotDirectoryForPicker loads directory entries for snapshot file picker
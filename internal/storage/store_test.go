@@ -29,6 +29,9 @@ func setupTestMainStore(t *testing.T) (*Store, *database.Connection) {
 	store.Statements = NewBankStatementStore(conn)
 	store.Transactions = NewTransactionStore(conn)
 	store.TransactionAudits = NewTransactionAuditStore(conn)
+	store.UserPreferences = NewUserPreferencesStore(conn)
+	store.ExchangeRates = NewExchangeRateStore(conn)
+	store.Accounts = NewAccountStore(conn)
 
 	// Set up cross-references between stores (critical for cross-domain operations)
 	store.Transactions.SetTransactionAuditStore(store.TransactionAudits)
@@ -300,7 +303,7 @@ func TestMainStoreValidateAndImportCSV(t *testing.T) {
 				firstCSV := `2024-01-15,100.50,Initial Purchase
 2024-01-16,75.25,Initial Gas`
 				firstPath := createTestCSVFile(t, "first_import.csv", firstCSV)
-				firstResult := store.ValidateAndImportCSV(firstPath, "TestBank")
+				firstResult := store.ValidateAndImportCSV(firstPath, "TestBank", 0)
 				if !firstResult.Success {
 					t.Fatalf("Failed to import first statement: %s", firstResult.Message)
 				}
@@ -380,7 +383,7 @@ func TestMainStoreValidateAndImportCSV(t *testing.T) {
 			filePath, templateName := tt.setupData(t, store)
 
 			// Call method under test
-			result := store.ValidateAndImportCSV(filePath, templateName)
+			result := store.ValidateAndImportCSV(filePath, templateName, 0)
 
 			// Validate results
 			tt.expectResult(t, store, result)
@@ -388,6 +391,57 @@ func TestMainStoreValidateAndImportCSV(t *testing.T) {
 	}
 }
 
+// TestMainStoreImportIntoAccounts verifies two cards sharing a template import without colliding
+func TestMainStoreImportIntoAccounts(t *testing.T) {
+	store, conn := setupTestMainStore(t)
+	defer teardownTestDB(t, conn)
+
+	template := types.CSVTemplate{Name: "SharedBank", PostDateColumn: 0, AmountColumn: 1, DescColumn: 2, DateFormat: "2006-01-02"}
+	if result := store.Templates.CreateCSVTemplate(template); !result.Success {
+		t.Fatalf("Failed to create test template: %s", result.Message)
+	}
+	personal := createTestAccount(t, store.Accounts, "Personal Card")
+	business := createTestAccount(t, store.Accounts, "Business Card")
+
+	personalPath := createTestCSVFile(t, "personal.csv", "2024-01-15,-100.50,Coffee Shop\n2024-01-20,-20.00,Bookstore")
+	businessPath := createTestCSVFile(t, "business.csv", "2024-01-16,-300.00,Office Supplies")
+
+	if result := store.ValidateAndImportCSV(personalPath, "SharedBank", personal); !result.Success {
+		t.Fatalf("Expected personal import to succeed: %s", result.Message)
+	}
+	result := store.ValidateAndImportCSV(businessPath, "SharedBank", business)
+	if !result.Success || result.OverlapDetected {
+		t.Fatalf("Expected business import to succeed without overlap: %s", result.Message)
+	}
+
+	// Re-importing into the same account is still caught
+	if result := store.ValidateAndImportCSV(personalPath, "SharedBank", personal); !result.OverlapDetected {
+		t.Error("Expected overlap when re-importing into the same account")
+	}
+
+	for _, stmt := range store.Statements.GetStatementHistory() {
+		if stmt.AccountId != personal && stmt.AccountId != business {
+			t.Errorf("Statement %s has unexpected account %d", stmt.Filename, stmt.AccountId)
+		}
+	}
+
+	transactions, err := store.Transactions.GetTransactions()
+	if err != nil {
+		t.Fatalf("Failed to get transactions: %v", err)
+	}
+	counts := map[int64]int{}
+	for _, tx := range transactions {
+		counts[tx.AccountId]++
+	}
+	if counts[personal] != 2 || counts[business] != 1 {
+		t.Errorf("Expected 2 personal and 1 business transactions, got %v", counts)
+	}
+
+	if result := store.ValidateAndImportCSV(businessPath, "SharedBank", 999); result.Success {
+		t.Error("Expected import into a missing account to fail")
+	}
+}
+
 // TestImportCSVWithOverride tests the override import workflow with duplicate filtering
 func TestMainStoreImportCSVWithOverride(t *testing.T) {
 	tests := []struct {
@@ -416,7 +470,7 @@ func TestMainStoreImportCSVWithOverride(t *testing.T) {
 				firstCSV := `2024-01-15,100.50,Initial Purchase
 2024-01-16,75.25,Initial Gas`
 				firstPath := createTestCSVFile(t, "first_import.csv", firstCSV)
-				firstResult := store.ValidateAndImportCSV(firstPath, "TestBank")
+				firstResult := store.ValidateAndImportCSV(firstPath, "TestBank", 0)
 				if !firstResult.Success {
 					t.Fatalf("Failed to import first statement: %s", firstResult.Message)
 				}
@@ -493,7 +547,7 @@ func TestMainStoreImportCSVWithOverride(t *testing.T) {
 				firstCSV := `2024-01-15,100.50,Purchase
 2024-01-16,75.25,Gas`
 				firstPath := createTestCSVFile(t, "first_import.csv", firstCSV)
-				firstResult := store.ValidateAndImportCSV(firstPath, "TestBank")
+				firstResult := store.ValidateAndImportCSV(firstPath, "TestBank", 0)
 				if !firstResult.Success {
 					t.Fatalf("Failed to import first statement: %s", firstResult.Message)
 				}
@@ -573,7 +627,7 @@ func TestMainStoreImportCSVWithOverride(t *testing.T) {
 			filePath, templateName := tt.setupData(t, store)
 
 			// Call method under test
-			result := store.ImportCSVWithOverride(filePath, templateName)
+			result := store.ImportCSVWithOverride(filePath, templateName, 0)
 
 			// Validate results
 			tt.expectResult(t, store, result)
@@ -750,14 +804,14 @@ func TestMainStoreGetTransactionSummaryByDateRange(t *testing.T) {
 				// Create transactions within date range
 				transactions := []types.Transaction{
 					{
-						Amount:          100.50, // Income
+						Amount:          testMoney(100.50), // Income
 						Description:     "Salary",
 						Date:            time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
 						CategoryId:      categoryId,
 						TransactionType: "income",
 					},
 					{
-						Amount:          50.25, // Expense
+						Amount:          testMoney(50.25), // Expense
 						Description:     "Groceries",
 						Date:            time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC),
 						CategoryId:      categoryId,
@@ -842,21 +896,21 @@ func TestMainStoreGetCategorySpendingByDateRange(t *testing.T) {
 				// Create transactions in different categories
 				transactions := []types.Transaction{
 					{
-						Amount:          50.25,
+						Amount:          testMoney(50.25),
 						Description:     "Supermarket",
 						Date:            time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
 						CategoryId:      groceryCategoryId,
 						TransactionType: "expense",
 					},
 					{
-						Amount:          30.75,
+						Amount:          testMoney(30.75),
 						Description:     "Gas Station",
 						Date:            time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC),
 						CategoryId:      gasCategoryId,
 						TransactionType: "expense",
 					},
 					{
-						Amount:          25.00,
+						Amount:          testMoney(25.00),
 						Description:     "Grocery Store",
 						Date:            time.Date(2024, 1, 17, 0, 0, 0, 0, time.UTC),
 						CategoryId:      groceryCategoryId,
@@ -911,6 +965,81 @@ func TestMainStoreGetCategorySpendingByDateRange(t *testing.T) {
 // ===========================
 
 // TestMainStoreImportTransactionsFromCSV tests the legacy import method delegation
+func TestMainStoreAnalyticsInBaseCurrency(t *testing.T) {
+	store, conn := setupTestMainStore(t)
+	defer teardownTestDB(t, conn)
+
+	categoryId := createTestCategory(t, store.db, "Travel")
+	transactions := []types.Transaction{
+		{Amount: types.NewMoney(10000, "USD"), Description: "Hotel", Date: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), CategoryId: categoryId, TransactionType: "expense"},
+		{Amount: types.NewMoney(5000, "EUR"), Description: "Train", Date: time.Date(2024, 1, 12, 0, 0, 0, 0, time.UTC), CategoryId: categoryId, TransactionType: "expense"},
+		{Amount: types.NewMoney(20000, "EUR"), Description: "Refund", Date: time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC), CategoryId: categoryId, TransactionType: "income"},
+	}
+	for _, tx := range transactions {
+		if err := store.Transactions.SaveTransaction(tx); err != nil {
+			t.Fatalf("Failed to save transaction: %v", err)
+		}
+	}
+
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	// Without a rate the EUR rows cannot be reported in USD
+	if _, err := store.GetTransactionSummaryByDateRange(startDate, endDate); err == nil {
+		t.Fatal("Expected error when no exchange rate is available")
+	}
+
+	if err := store.ExchangeRates.SaveRate(types.ExchangeRate{
+		RateDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), FromCurrency: "EUR", ToCurrency: "USD", Rate: 1.1,
+	}); err != nil {
+		t.Fatalf("Failed to save rate: %v", err)
+	}
+	if err := store.ExchangeRates.SaveRate(types.ExchangeRate{
+		RateDate: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), FromCurrency: "EUR", ToCurrency: "USD", Rate: 1.2,
+	}); err != nil {
+		t.Fatalf("Failed to save rate: %v", err)
+	}
+
+	summary, err := store.GetTransactionSummaryByDateRange(startDate, endDate)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// 100.00 USD + 50.00 EUR at 1.1; income 200.00 EUR at 1.2
+	if !summary.TotalExpenses.Equal(types.NewMoney(15500, "USD")) {
+		t.Errorf("Expected expenses 155.00 USD, got %s", summary.TotalExpenses.Display())
+	}
+	if !summary.TotalIncome.Equal(types.NewMoney(24000, "USD")) {
+		t.Errorf("Expected income 240.00 USD, got %s", summary.TotalIncome.Display())
+	}
+	if summary.TransactionCount != 3 {
+		t.Errorf("Expected 3 transactions, got %d", summary.TransactionCount)
+	}
+
+	spending, err := store.GetCategorySpendingByDateRange(startDate, endDate)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(spending) != 1 {
+		t.Fatalf("Expected 1 category, got %d", len(spending))
+	}
+	if !spending[0].Amount.Equal(types.NewMoney(15500, "USD")) || spending[0].TransactionCount != 2 {
+		t.Errorf("Expected 155.00 USD over 2 transactions, got %s over %d", spending[0].Amount.Display(), spending[0].TransactionCount)
+	}
+
+	// Switching the base currency reports the same data in EUR
+	if err := store.UserPreferences.SetBaseCurrency("EUR"); err != nil {
+		t.Fatalf("Failed to set base currency: %v", err)
+	}
+	summary, err = store.GetTransactionSummaryByDateRange(startDate, endDate)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// 100.00 USD / 1.1 = 90.91 EUR, plus 50.00 EUR
+	if !summary.TotalExpenses.Equal(types.NewMoney(14091, "EUR")) {
+		t.Errorf("Expected expenses 140.91 EUR, got %s", summary.TotalExpenses.Display())
+	}
+}
+
 func TestMainStoreImportTransactionsFromCSV(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -976,7 +1105,7 @@ func TestMainStoreImportTransactionsFromCSV(t *testing.T) {
 			filePath, templateName := tt.setupData(t, store)
 
 			// Call method under test
-			err := store.ImportTransactionsFromCSV(filePath, templateName)
+			err := store.ImportTransactionsFromCSV(filePath, templateName, 0)
 
 			// Validate results
 			tt.errorCheck(t, err)
@@ -1100,3 +1229,116 @@ func TestMainStoreGetMLCategorizerStats(t *testing.T) {
 	// The actual stats content depends on the ML implementation
 	t.Logf("ML stats returned: %v", stats)
 }
+
+// TestMainStoreRestoreFromSnapshotFile tests swapping the live database for a snapshot
+func TestMainStoreRestoreFromSnapshotFile(t *testing.T) {
+	// Keep the database out of the real home directory
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("USERPROFILE", os.Getenv("HOME"))
+
+	store := NewStore()
+	if err := store.Init(); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	defer store.Close()
+
+	if result := store.Categories.CreateCategory("Before Snapshot"); !result.Success {
+		t.Fatalf("failed to create category: %s", result.Message)
+	}
+
+	snapshotPath := filepath.Join(t.TempDir(), "restore-me.db")
+	if err := store.Snapshots.CreateSnapshotFile(snapshotPath); err != nil {
+		t.Fatalf("CreateSnapshotFile() failed: %v", err)
+	}
+
+	if result := store.Categories.CreateCategory("After Snapshot"); !result.Success {
+		t.Fatalf("failed to create category: %s", result.Message)
+	}
+
+	oldCategories := store.Categories
+	result, err := store.RestoreFromSnapshotFile(snapshotPath)
+	if err != nil {
+		t.Fatalf("RestoreFromSnapshotFile() returned error: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("RestoreFromSnapshotFile() failed: %s", result.Message)
+	}
+
+	if store.Categories == oldCategories {
+		t.Error("domain stores should be rebuilt after restore")
+	}
+	if store.Categories.GetCategoryByDisplayName("Before Snapshot") == nil {
+		t.Error("category from snapshot missing after restore")
+	}
+	if store.Categories.GetCategoryByDisplayName("After Snapshot") != nil {
+		t.Error("category created after snapshot should be gone after restore")
+	}
+	if err := store.db.CheckHealth(); err != nil {
+		t.Errorf("database unhealthy after restore: %v", err)
+	}
+
+	// Restoring a file that is not a database must leave the current one intact
+	badPath := filepath.Join(t.TempDir(), "not-a-db.db")
+	if err := os.WriteFile(badPath, []byte("definitely not sqlite"), 0644); err != nil {
+		t.Fatalf("failed to write invalid snapshot: %v", err)
+	}
+	result, err = store.RestoreFromSnapshotFile(badPath)
+	if err != nil {
+		t.Fatalf("RestoreFromSnapshotFile() returned error: %v", err)
+	}
+	if result.Success {
+		t.Error("expected restore of invalid file to fail")
+	}
+	if store.Categories.GetCategoryByDisplayName("Before Snapshot") == nil {
+		t.Error("current database should be untouched after a rejected restore")
+	}
+}
+
+// TestMainStoreSwitchLedger tests closing one ledger and reopening the store on another
+func TestMainStoreSwitchLedger(t *testing.T) {
+	dir := t.TempDir()
+	householdPath := filepath.Join(dir, "household.db")
+	businessPath := filepath.Join(dir, "books", "business.db")
+
+	store := NewStoreAt(householdPath)
+	if err := store.Init(); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	defer store.Close()
+
+	if result := store.Categories.CreateCategory("Household Only"); !result.Success {
+		t.Fatalf("failed to create category: %s", result.Message)
+	}
+
+	if err := store.SwitchLedger(businessPath); err != nil {
+		t.Fatalf("SwitchLedger() failed: %v", err)
+	}
+	if store.GetDatabasePath() != businessPath {
+		t.Errorf("Expected database path %s, got %s", businessPath, store.GetDatabasePath())
+	}
+	if store.Categories.GetCategoryByDisplayName("Household Only") != nil {
+		t.Error("new ledger should not see categories from the previous ledger")
+	}
+
+	// A path that cannot be opened keeps the current ledger
+	badPath := filepath.Join(dir, "not-a-db.db")
+	if err := os.WriteFile(badPath, []byte("definitely not sqlite"), 0644); err != nil {
+		t.Fatalf("failed to write invalid ledger: %v", err)
+	}
+	if err := store.SwitchLedger(badPath); err == nil {
+		t.Error("expected switching to an invalid ledger to fail")
+	}
+	if store.GetDatabasePath() != businessPath {
+		t.Errorf("Expected to stay on %s, got %s", businessPath, store.GetDatabasePath())
+	}
+	if err := store.db.CheckHealth(); err != nil {
+		t.Errorf("database unhealthy after failed switch: %v", err)
+	}
+
+	if err := store.SwitchLedger(householdPath); err != nil {
+		t.Fatalf("SwitchLedger() back failed: %v", err)
+	}
+	if store.Categories.GetCategoryByDisplayName("Household Only") == nil {
+		t.Error("category missing after switching back to the household ledger")
+	}
+}
@@ -45,7 +45,7 @@ func (tas *TransactionAuditStore) RecordEvent(event *types.TransactionAuditEvent
 
 	args := []interface{}{
 		event.TransactionId,
-		event.BankStatementId,
+		sql.NullInt64{Int64: event.BankStatementId, Valid: event.BankStatementId != 0},
 		event.Timestamp.Format(time.RFC3339),
 		event.ActionType,
 		event.Source,
@@ -68,6 +68,52 @@ func (tas *TransactionAuditStore) RecordEvent(event *types.TransactionAuditEvent
 	return nil
 }
 
+// GetEventByID retrieves a single audit event, or an error if it no longer exists
+func (tas *TransactionAuditStore) GetEventByID(id int64) (*types.TransactionAuditEvent, error) {
+	query := `
+		SELECT id, transaction_id, bank_statement_id, timestamp, action_type, source,
+			   description_fingerprint, category_assigned,
+			   category_confidence, previous_category, modification_reason,
+			   pre_edit_snapshot, post_edit_snapshot, created_at
+		FROM transaction_audit_events 
+		WHERE id = ?`
+
+	rows, err := tas.helper.QueryRows(query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transaction audit event: %v", err)
+	}
+	defer rows.Close()
+
+	events, err := tas.scanTransactionAuditEvents(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("audit event %d not found", id)
+	}
+	return &events[0], nil
+}
+
+// GetEventsByTransaction retrieves the history of one transaction, newest first
+func (tas *TransactionAuditStore) GetEventsByTransaction(transactionId int64) ([]types.TransactionAuditEvent, error) {
+	query := `
+		SELECT id, transaction_id, bank_statement_id, timestamp, action_type, source,
+			   description_fingerprint, category_assigned,
+			   category_confidence, previous_category, modification_reason,
+			   pre_edit_snapshot, post_edit_snapshot, created_at
+		FROM transaction_audit_events 
+		WHERE transaction_id = ?
+		ORDER BY timestamp DESC, id DESC`
+
+	rows, err := tas.helper.QueryRows(query, transactionId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transaction audit events by transaction: %v", err)
+	}
+	defer rows.Close()
+
+	return tas.scanTransactionAuditEvents(rows)
+}
+
 // GetEventsByStatement retrieves all audit events for transactions in a bank statement
 func (tas *TransactionAuditStore) GetEventsByStatement(bankStatementId int64) ([]types.TransactionAuditEvent, error) {
 	query := `
@@ -164,11 +210,12 @@ func (tas *TransactionAuditStore) scanTransactionAuditEvents(rows *sql.Rows) ([]
 		var timestampStr, createdAtStr string
 		var modificationReason, preEditSnapshot, postEditSnapshot sql.NullString
 		var categoryConfidence sql.NullFloat64
+		var bankStatementId sql.NullInt64
 
 		err := rows.Scan(
 			&event.Id,
 			&event.TransactionId,
-			&event.BankStatementId,
+			&bankStatementId,
 			&timestampStr,
 			&event.ActionType,
 			&event.Source,
@@ -194,6 +241,7 @@ func (tas *TransactionAuditStore) scanTransactionAuditEvents(rows *sql.Rows) ([]
 		}
 
 		// Handle nullable fields
+		event.BankStatementId = bankStatementId.Int64
 		event.CategoryConfidence = categoryConfidence.Float64
 		event.ModificationReason = nullStringToPointer(modificationReason)
 		event.PreEditSnapshot = nullStringToPointer(preEditSnapshot)
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"budget-tracker-tui/internal/types"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// A transaction's history is its audit events: the import that created it, then every edit,
+// split and trash move with the versions before and after (see GetEventsByTransaction).
+
+// RevertTransactionToEvent puts a transaction back to the version recorded after one of its audit events
+// Only the editable fields are reverted; split links and trash state stay as they are now. The revert
+// is saved as a user edit, so it appears in the history itself and can be undone.
+func (ts *TransactionStore) RevertTransactionToEvent(eventId int64) error {
+	if ts.transactionAudits == nil {
+		return fmt.Errorf("transaction history is not available")
+	}
+
+	event, err := ts.transactionAudits.GetEventByID(eventId)
+	if err != nil {
+		return err
+	}
+	snapshot, err := types.DecodeTransactionSnapshot(event.PostEditSnapshot)
+	if errors.Is(err, types.ErrLegacySnapshot) {
+		return fmt.Errorf("cannot revert: %w", err)
+	}
+	if err != nil {
+		return err
+	}
+	if snapshot == nil {
+		return fmt.Errorf("no version was recorded for this change")
+	}
+	version, err := snapshot.Transaction()
+	if err != nil {
+		return err
+	}
+
+	current := ts.GetTransactionByID(event.TransactionId)
+	if current == nil || current.DeletedAt != nil {
+		return fmt.Errorf("transaction with ID %d not found", event.TransactionId)
+	}
+	if current.IsSplit != snapshot.IsSplit || (current.ParentId == nil) != (snapshot.ParentId == nil) {
+		return fmt.Errorf("transaction has been split since this version; undo the split instead")
+	}
+
+	reverted := *current
+	reverted.Amount = version.Amount
+	reverted.Description = version.Description
+	reverted.Date = version.Date
+	reverted.CategoryId = version.CategoryId
+	reverted.TransactionType = version.TransactionType
+	if sameSnapshotFields(types.NewTransactionSnapshot(*current), types.NewTransactionSnapshot(reverted)) {
+		return nil
+	}
+
+	return ts.undo.Group(fmt.Sprintf("Revert '%s'", current.Description), func() error {
+		return ts.updateTransaction(reverted, time.Now())
+	})
+}
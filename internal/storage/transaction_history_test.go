@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"budget-tracker-tui/internal/types"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestTransactionHistoryAndRevert(t *testing.T) {
+	store, conn := setupUndoTestStore(t)
+	defer teardownTestDB(t, conn)
+
+	groceries := createTestCategory(t, conn, "Groceries")
+	dining := createTestCategory(t, conn, "Dining")
+	original := saveUndoTestTransaction(t, store, groceries, -2500, "Corner store")
+
+	edited := original
+	edited.CategoryId = dining
+	if err := store.Transactions.SaveTransaction(edited); err != nil {
+		t.Fatalf("Failed to edit category: %v", err)
+	}
+	edited.Description = "Corner diner"
+	if err := store.Transactions.SaveTransaction(edited); err != nil {
+		t.Fatalf("Failed to edit description: %v", err)
+	}
+
+	events, err := store.TransactionAudits.GetEventsByTransaction(original.Id)
+	if err != nil {
+		t.Fatalf("GetEventsByTransaction() failed: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 history events, got %d", len(events))
+	}
+
+	// Newest first: the description edit, then the category edit
+	before, _ := types.DecodeTransactionSnapshot(events[1].PreEditSnapshot)
+	after, _ := types.DecodeTransactionSnapshot(events[1].PostEditSnapshot)
+	changes := types.DiffTransactionSnapshots(before, after, nil)
+	if len(changes) != 1 || changes[0].Field != "Category" {
+		t.Fatalf("Expected only the category to change in the first edit, got %+v", changes)
+	}
+	if changes[0].Old != fmt.Sprintf("#%d", groceries) || changes[0].New != fmt.Sprintf("#%d", dining) {
+		t.Errorf("Unexpected category change %+v", changes[0])
+	}
+
+	// Reverting to the first edit keeps its category but brings back the old description
+	if err := store.Transactions.RevertTransactionToEvent(events[1].Id); err != nil {
+		t.Fatalf("RevertTransactionToEvent() failed: %v", err)
+	}
+	current := store.Transactions.GetTransactionByID(original.Id)
+	if current.Description != "Corner store" || current.CategoryId != dining {
+		t.Errorf("Expected 'Corner store' in Dining after revert, got '%s' in category %d", current.Description, current.CategoryId)
+	}
+
+	// The revert is an edit of its own and can be undone
+	if events, _ = store.TransactionAudits.GetEventsByTransaction(original.Id); len(events) != 3 {
+		t.Errorf("Expected the revert to add a history event, got %d events", len(events))
+	}
+	if _, err := store.Undo.Undo(); err != nil {
+		t.Fatalf("Undo() failed: %v", err)
+	}
+	if current = store.Transactions.GetTransactionByID(original.Id); current.Description != "Corner diner" {
+		t.Errorf("Expected undo to bring back 'Corner diner', got '%s'", current.Description)
+	}
+}
+
+func TestRevertTransactionRejectsSplitVersion(t *testing.T) {
+	store, conn := setupUndoTestStore(t)
+	defer teardownTestDB(t, conn)
+
+	categoryId := createTestCategory(t, conn, "Groceries")
+	original := saveUndoTestTransaction(t, store, categoryId, -4000, "Market")
+
+	edited := original
+	edited.Description = "Farmers market"
+	if err := store.Transactions.SaveTransaction(edited); err != nil {
+		t.Fatalf("Failed to edit transaction: %v", err)
+	}
+	events, _ := store.TransactionAudits.GetEventsByTransaction(original.Id)
+	if len(events) == 0 {
+		t.Fatal("Expected an edit event")
+	}
+
+	splits := []types.Transaction{
+		createTestTransaction(0, "Produce", categoryId),
+		createTestTransaction(0, "Bread", categoryId),
+	}
+	splits[0].Amount = types.NewMoney(-2500, types.DefaultCurrency)
+	splits[1].Amount = types.NewMoney(-1500, types.DefaultCurrency)
+	if err := store.Transactions.SplitTransaction(original.Id, splits); err != nil {
+		t.Fatalf("SplitTransaction() failed: %v", err)
+	}
+
+	if err := store.Transactions.RevertTransactionToEvent(events[0].Id); err == nil {
+		t.Error("Expected reverting a split transaction to an unsplit version to fail")
+	}
+}
+
+func TestRevertTransactionRejectsLegacyEvent(t *testing.T) {
+	store, conn := setupUndoTestStore(t)
+	defer teardownTestDB(t, conn)
+
+	groceries := createTestCategory(t, conn, "Groceries")
+	dining := createTestCategory(t, conn, "Dining")
+	original := saveUndoTestTransaction(t, store, groceries, -1250, "Corner store")
+
+	// Events written before whole versions were kept hold hand-built JSON of a few fields
+	legacy := &types.TransactionAuditEvent{
+		TransactionId:          original.Id,
+		Timestamp:              time.Now(),
+		ActionType:             types.ActionTypeEdit,
+		Source:                 types.SourceUser,
+		DescriptionFingerprint: original.Description,
+		CategoryAssigned:       dining,
+		CategoryConfidence:     1.0,
+		PreviousCategory:       groceries,
+		PreEditSnapshot:        stringPtr(fmt.Sprintf(`{"amount":12.50,"description":"Corner store","category":%d,"type":"expense"}`, groceries)),
+		PostEditSnapshot:       stringPtr(fmt.Sprintf(`{"amount":12.50,"description":"Corner store","category":%d,"type":"expense"}`, dining)),
+	}
+	if err := store.TransactionAudits.RecordEvent(legacy); err != nil {
+		t.Fatalf("Failed to seed legacy event: %v", err)
+	}
+
+	if _, err := types.DecodeTransactionSnapshot(legacy.PostEditSnapshot); !errors.Is(err, types.ErrLegacySnapshot) {
+		t.Errorf("Expected a legacy snapshot to be reported as such, got %v", err)
+	}
+	if err := store.Transactions.RevertTransactionToEvent(legacy.Id); !errors.Is(err, types.ErrLegacySnapshot) {
+		t.Errorf("Expected reverting a legacy event to be refused, got %v", err)
+	}
+	if current := store.Transactions.GetTransactionByID(original.Id); current.CategoryId != groceries || current.Description != "Corner store" {
+		t.Errorf("Expected the transaction to be left alone, got '%s' in category %d", current.Description, current.CategoryId)
+	}
+}
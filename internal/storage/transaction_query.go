@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"budget-tracker-tui/internal/types"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Sort orders accepted by TransactionQuery.Sort
+const (
+	SortDateDesc    = "date_desc" // Newest first (default)
+	SortDateAsc     = "date_asc"
+	SortAmountDesc  = "amount_desc" // Largest absolute amount first
+	SortAmountAsc   = "amount_asc"
+	SortDescription = "description"
+)
+
+// transactionSortClauses maps each sort order to its ORDER BY clause
+// Every order ends on id so pages never overlap or skip rows.
+var transactionSortClauses = map[string]string{
+	"":              "date DESC, id DESC",
+	SortDateDesc:    "date DESC, id DESC",
+	SortDateAsc:     "date ASC, id ASC",
+	SortAmountDesc:  "ABS(amount_cents) DESC, date DESC, id DESC",
+	SortAmountAsc:   "ABS(amount_cents) ASC, date DESC, id DESC",
+	SortDescription: "description COLLATE NOCASE ASC, date DESC, id DESC",
+}
+
+// Trash filters accepted by TransactionQuery.Deleted
+const (
+	DeletedExclude = ""        // Only live transactions (default)
+	DeletedOnly    = "only"    // Only transactions in the trash
+	DeletedInclude = "include" // Live and trashed transactions
+)
+
+// transactionColumns lists the columns read by scanTransaction, in scan order
+const transactionColumns = `id, parent_id, amount_cents, currency, description, raw_description, date,
+		       category_id, transaction_type, is_split,
+		       statement_id, account_id, created_at, updated_at, deleted_at, external_id`
+
+// TransactionQuery filters, sorts and pages transactions
+// Zero-valued fields do not filter, so an empty query matches every transaction outside the trash.
+type TransactionQuery struct {
+	StartDate       time.Time // Inclusive; zero means no lower bound
+	EndDate         time.Time // Inclusive; zero means no upper bound
+	CategoryIds     []int64   // Matches these categories and all of their descendants
+	TransactionType string    // "expense", "income" or "transfer"
+	StatementId     int64
+	AccountId       int64
+	MinAmountCents  *int64 // Inclusive bound on the absolute amount
+	MaxAmountCents  *int64 // Inclusive bound on the absolute amount
+	Text            string // Case-insensitive match anywhere in the description or raw description
+	IsSplit         *bool
+	Deleted         string // One of the Deleted* constants; empty hides the trash
+	Sort            string // One of the Sort* constants; empty sorts newest first
+	Limit           int    // Zero means no limit
+	Offset          int
+}
+
+// buildWhere returns the WHERE clause (without the keyword) and its arguments
+func (q TransactionQuery) buildWhere() (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	switch q.Deleted {
+	case DeletedExclude:
+		conditions = append(conditions, "deleted_at IS NULL")
+	case DeletedOnly:
+		conditions = append(conditions, "deleted_at IS NOT NULL")
+	}
+	if !q.StartDate.IsZero() {
+		conditions = append(conditions, "date >= ?")
+		args = append(args, q.StartDate.Format("2006-01-02"))
+	}
+	if !q.EndDate.IsZero() {
+		conditions = append(conditions, "date <= ?")
+		args = append(args, q.EndDate.Format("2006-01-02"))
+	}
+	if len(q.CategoryIds) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(q.CategoryIds)), ", ")
+		conditions = append(conditions, `category_id IN (
+			WITH RECURSIVE category_tree(id) AS (
+				SELECT id FROM categories WHERE id IN (`+placeholders+`)
+				UNION
+				SELECT c.id FROM categories c JOIN category_tree ct ON c.parent_id = ct.id
+			)
+			SELECT id FROM category_tree
+		)`)
+		for _, id := range q.CategoryIds {
+			args = append(args, id)
+		}
+	}
+	if q.TransactionType != "" {
+		conditions = append(conditions, "transaction_type = ?")
+		args = append(args, q.TransactionType)
+	}
+	if q.StatementId != 0 {
+		conditions = append(conditions, "statement_id = ?")
+		args = append(args, q.StatementId)
+	}
+	if q.AccountId != 0 {
+		conditions = append(conditions, "account_id = ?")
+		args = append(args, q.AccountId)
+	}
+	if q.MinAmountCents != nil {
+		conditions = append(conditions, "ABS(amount_cents) >= ?")
+		args = append(args, *q.MinAmountCents)
+	}
+	if q.MaxAmountCents != nil {
+		conditions = append(conditions, "ABS(amount_cents) <= ?")
+		args = append(args, *q.MaxAmountCents)
+	}
+	if text := strings.TrimSpace(q.Text); text != "" {
+		pattern := "%" + escapeLikePattern(text) + "%"
+		conditions = append(conditions, `(description LIKE ? ESCAPE '\' OR raw_description LIKE ? ESCAPE '\')`)
+		args = append(args, pattern, pattern)
+	}
+	if q.IsSplit != nil {
+		conditions = append(conditions, "is_split = ?")
+		args = append(args, *q.IsSplit)
+	}
+
+	if len(conditions) == 0 {
+		return "1 = 1", args
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// validateDeleted rejects unknown trash filters, which would otherwise match everything
+func (q TransactionQuery) validateDeleted() error {
+	switch q.Deleted {
+	case DeletedExclude, DeletedOnly, DeletedInclude:
+		return nil
+	}
+	return fmt.Errorf("unknown trash filter '%s'", q.Deleted)
+}
+
+// escapeLikePattern escapes LIKE wildcards so text matches literally
+func escapeLikePattern(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(text)
+}
+
+// QueryTransactions returns the transactions matching query, sorted and paged in SQL
+func (ts *TransactionStore) QueryTransactions(query TransactionQuery) ([]types.Transaction, error) {
+	orderBy, ok := transactionSortClauses[query.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unknown transaction sort '%s'", query.Sort)
+	}
+	if err := query.validateDeleted(); err != nil {
+		return nil, err
+	}
+	if query.Limit < 0 || query.Offset < 0 {
+		return nil, fmt.Errorf("limit and offset cannot be negative")
+	}
+
+	where, args := query.buildWhere()
+	sqlQuery := "SELECT " + transactionColumns + " FROM transactions WHERE " + where + " ORDER BY " + orderBy
+	if query.Limit > 0 || query.Offset > 0 {
+		// SQLite needs a LIMIT before OFFSET; -1 means no limit
+		limit := query.Limit
+		if limit == 0 {
+			limit = -1
+		}
+		sqlQuery += " LIMIT ? OFFSET ?"
+		args = append(args, limit, query.Offset)
+	}
+
+	rows, err := ts.helper.QueryRows(sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transactions: %w", err)
+	}
+	defer rows.Close()
+
+	transactions := []types.Transaction{}
+	for rows.Next() {
+		tx, err := ts.scanTransaction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		transactions = append(transactions, tx)
+	}
+
+	return transactions, rows.Err()
+}
+
+// CountTransactions returns how many transactions match query, ignoring its sort, limit and offset
+func (ts *TransactionStore) CountTransactions(query TransactionQuery) (int, error) {
+	if err := query.validateDeleted(); err != nil {
+		return 0, err
+	}
+	where, args := query.buildWhere()
+
+	var count int
+	if err := ts.helper.QuerySingleRow("SELECT COUNT(*) FROM transactions WHERE "+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count transactions: %w", err)
+	}
+	return count, nil
+}
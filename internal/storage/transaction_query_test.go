@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"budget-tracker-tui/internal/database"
+	"budget-tracker-tui/internal/types"
+	"strings"
+	"testing"
+	"time"
+)
+
+// queryFixture holds the IDs created by setupQueryFixture
+type queryFixture struct {
+	groceries, produce, rent int64
+	statementId              int64
+}
+
+// setupQueryFixture saves a small ledger covering every TransactionQuery filter
+func setupQueryFixture(t *testing.T, store *TransactionStore, conn *database.Connection) queryFixture {
+	t.Helper()
+
+	f := queryFixture{
+		groceries: createTestCategory(t, conn, "Groceries"),
+		produce:   createTestCategory(t, conn, "Produce"),
+		rent:      createTestCategory(t, conn, "Rent"),
+	}
+	if _, err := conn.DB.Exec("UPDATE categories SET parent_id = ? WHERE id = ?", f.groceries, f.produce); err != nil {
+		t.Fatalf("Failed to nest category: %v", err)
+	}
+	f.statementId = createTestBankStatement(t, conn, "january.csv")
+
+	transactions := []struct {
+		cents       int64
+		description string
+		day         int
+		categoryId  int64
+		txType      string
+		statement   bool
+		split       bool
+	}{
+		{-4520, "Corner Market", 3, f.groceries, "expense", true, false},
+		{-1299, "Farmers market 50% off", 10, f.produce, "expense", true, true},
+		{-150000, "January rent", 1, f.rent, "expense", false, false},
+		{320000, "Payroll deposit", 15, f.groceries, "income", false, false},
+		{-899, "Snack_bar", 20, f.groceries, "expense", true, false},
+	}
+	for _, tx := range transactions {
+		transaction := types.Transaction{
+			Amount:          types.NewMoney(tx.cents, types.DefaultCurrency),
+			Description:     tx.description,
+			Date:            time.Date(2024, 1, tx.day, 0, 0, 0, 0, time.UTC),
+			CategoryId:      tx.categoryId,
+			TransactionType: tx.txType,
+			IsSplit:         tx.split,
+		}
+		if tx.statement {
+			transaction.StatementId = f.statementId
+		}
+		if err := store.SaveTransaction(transaction); err != nil {
+			t.Fatalf("Failed to save '%s': %v", tx.description, err)
+		}
+	}
+
+	return f
+}
+
+// descriptions returns the descriptions of transactions in order
+func descriptions(transactions []types.Transaction) string {
+	var names []string
+	for _, tx := range transactions {
+		names = append(names, tx.Description)
+	}
+	return strings.Join(names, ", ")
+}
+
+func TestQueryTransactions(t *testing.T) {
+	int64Ptr := func(v int64) *int64 { return &v }
+	boolPtr := func(v bool) *bool { return &v }
+
+	tests := []struct {
+		name  string
+		query func(queryFixture) TransactionQuery
+		want  string
+	}{
+		{
+			name:  "empty query returns everything newest first",
+			query: func(f queryFixture) TransactionQuery { return TransactionQuery{} },
+			want:  "Snack_bar, Payroll deposit, Farmers market 50% off, Corner Market, January rent",
+		},
+		{
+			name: "date range is inclusive",
+			query: func(f queryFixture) TransactionQuery {
+				return TransactionQuery{
+					StartDate: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC),
+					EndDate:   time.Date(2024, 1, 15, 23, 59, 59, 0, time.UTC),
+				}
+			},
+			want: "Payroll deposit, Farmers market 50% off, Corner Market",
+		},
+		{
+			name: "category includes descendants",
+			query: func(f queryFixture) TransactionQuery {
+				return TransactionQuery{CategoryIds: []int64{f.groceries}, TransactionType: "expense"}
+			},
+			want: "Snack_bar, Farmers market 50% off, Corner Market",
+		},
+		{
+			name:  "child category alone",
+			query: func(f queryFixture) TransactionQuery { return TransactionQuery{CategoryIds: []int64{f.produce}} },
+			want:  "Farmers market 50% off",
+		},
+		{
+			name: "statement",
+			query: func(f queryFixture) TransactionQuery {
+				return TransactionQuery{StatementId: f.statementId, Sort: SortDateAsc}
+			},
+			want: "Corner Market, Farmers market 50% off, Snack_bar",
+		},
+		{
+			name: "absolute amount range",
+			query: func(f queryFixture) TransactionQuery {
+				return TransactionQuery{MinAmountCents: int64Ptr(1000), MaxAmountCents: int64Ptr(150000)}
+			},
+			want: "Farmers market 50% off, Corner Market, January rent",
+		},
+		{
+			name:  "text match is case-insensitive",
+			query: func(f queryFixture) TransactionQuery { return TransactionQuery{Text: "MARKET"} },
+			want:  "Farmers market 50% off, Corner Market",
+		},
+		{
+			name:  "text wildcards match literally",
+			query: func(f queryFixture) TransactionQuery { return TransactionQuery{Text: "50%"} },
+			want:  "Farmers market 50% off",
+		},
+		{
+			name:  "underscore matches literally",
+			query: func(f queryFixture) TransactionQuery { return TransactionQuery{Text: "k_b"} },
+			want:  "Snack_bar",
+		},
+		{
+			name:  "split status",
+			query: func(f queryFixture) TransactionQuery { return TransactionQuery{IsSplit: boolPtr(true)} },
+			want:  "Farmers market 50% off",
+		},
+		{
+			name: "sort by amount with paging",
+			query: func(f queryFixture) TransactionQuery {
+				return TransactionQuery{Sort: SortAmountDesc, Limit: 2, Offset: 1}
+			},
+			want: "January rent, Corner Market",
+		},
+		{
+			name:  "offset without limit",
+			query: func(f queryFixture) TransactionQuery { return TransactionQuery{Sort: SortDescription, Offset: 3} },
+			want:  "Payroll deposit, Snack_bar",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, conn := setupTestStore(t)
+			defer teardownTestDB(t, conn)
+			f := setupQueryFixture(t, store, conn)
+
+			query := tt.query(f)
+			transactions, err := store.QueryTransactions(query)
+			if err != nil {
+				t.Fatalf("QueryTransactions() failed: %v", err)
+			}
+			if got := descriptions(transactions); got != tt.want {
+				t.Errorf("Expected [%s], got [%s]", tt.want, got)
+			}
+
+			// Counting ignores paging but applies the same filters
+			count, err := store.CountTransactions(query)
+			if err != nil {
+				t.Fatalf("CountTransactions() failed: %v", err)
+			}
+			query.Limit, query.Offset = 0, 0
+			all, _ := store.QueryTransactions(query)
+			if count != len(all) {
+				t.Errorf("Expected count %d, got %d", len(all), count)
+			}
+		})
+	}
+}
+
+func TestQueryTransactionsInvalidQuery(t *testing.T) {
+	store, conn := setupTestStore(t)
+	defer teardownTestDB(t, conn)
+
+	if _, err := store.QueryTransactions(TransactionQuery{Sort: "newest"}); err == nil || !strings.Contains(err.Error(), "unknown transaction sort") {
+		t.Errorf("Expected unknown sort error, got: %v", err)
+	}
+	if _, err := store.QueryTransactions(TransactionQuery{Limit: -1}); err == nil {
+		t.Error("Expected negative limit to fail")
+	}
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Markers wrapped around matched text in search highlights
+// Control characters are used because they never appear in imported descriptions.
+const (
+	SearchMatchStart = "\x02"
+	SearchMatchEnd   = "\x03"
+)
+
+// buildSearchMatch turns free text into an FTS5 MATCH expression
+// Every word must match, and each is treated as a prefix so results update while typing.
+// Words are quoted so FTS5 operators and punctuation in the input are taken literally.
+func buildSearchMatch(text string) string {
+	var terms []string
+	for _, word := range strings.Fields(text) {
+		if !strings.ContainsFunc(word, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) {
+			continue
+		}
+		terms = append(terms, `"`+strings.ReplaceAll(word, `"`, `""`)+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
+
+// SearchTransactions returns transactions whose description or raw description matches text, best match first
+// Description matches outrank raw description matches; equal matches are ordered newest first.
+// A limit of zero returns every match.
+func (ts *TransactionStore) SearchTransactions(text string, limit int) ([]TransactionSearchResult, error) {
+	match := buildSearchMatch(text)
+	if match == "" {
+		return []TransactionSearchResult{}, nil
+	}
+	if limit <= 0 {
+		limit = -1
+	}
+
+	query := `
+		SELECT t.id, t.parent_id, t.amount_cents, t.currency, t.description, t.raw_description, t.date,
+		       t.category_id, t.transaction_type, t.is_split,
+		       t.statement_id, t.account_id, t.created_at, t.updated_at, t.deleted_at, t.external_id,
+		       highlight(transactions_fts, 0, ?, ?), highlight(transactions_fts, 1, ?, ?),
+		       bm25(transactions_fts, 10.0, 5.0) AS rank
+		FROM transactions_fts
+		JOIN transactions t ON t.id = transactions_fts.rowid
+		WHERE transactions_fts MATCH ? AND t.deleted_at IS NULL
+		ORDER BY rank, t.date DESC, t.id DESC
+		LIMIT ?
+	`
+
+	rows, err := ts.helper.QueryRows(query,
+		SearchMatchStart, SearchMatchEnd, SearchMatchStart, SearchMatchEnd, match, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search transactions: %w", err)
+	}
+	defer rows.Close()
+
+	results := []TransactionSearchResult{}
+	for rows.Next() {
+		var result TransactionSearchResult
+		var highlightedDesc, highlightedRaw sql.NullString
+
+		tx, err := ts.scanTransaction(rows, &highlightedDesc, &highlightedRaw, &result.Rank)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+
+		result.Transaction = tx
+		result.HighlightedDescription = highlightedDesc.String
+		result.HighlightedRaw = highlightedRaw.String
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
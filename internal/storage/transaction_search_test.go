@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"budget-tracker-tui/internal/types"
+	"testing"
+	"time"
+)
+
+func TestBuildSearchMatch(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"plumber", `"plumber"*`},
+		{"  joe's   plumb ", `"joe's"* "plumb"*`},
+		{`say "hi" OR NOT`, `"say"* """hi"""* "OR"* "NOT"*`},
+		{"- * ()", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := buildSearchMatch(tt.text); got != tt.want {
+			t.Errorf("buildSearchMatch(%q) = %s, want %s", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSearchTransactions(t *testing.T) {
+	store, conn := setupTestStore(t)
+	defer teardownTestDB(t, conn)
+
+	categoryId := createTestCategory(t, conn, "Home")
+	save := func(description, raw string, day int) {
+		t.Helper()
+		tx := types.Transaction{
+			Amount:          types.NewMoney(-12000, types.DefaultCurrency),
+			Description:     description,
+			RawDescription:  raw,
+			Date:            time.Date(2024, 3, day, 0, 0, 0, 0, time.UTC),
+			CategoryId:      categoryId,
+			TransactionType: "expense",
+		}
+		if err := store.SaveTransaction(tx); err != nil {
+			t.Fatalf("Failed to save '%s': %v", description, err)
+		}
+	}
+
+	save("Plumber", "ACH JOES PLUMBING 0423", 2)
+	save("Plumber", "ACH JOES PLUMBING 0517", 20)
+	save("Hardware store", "HOME DEPOT #123 plumber tape", 25)
+	save("Groceries", "", 28)
+
+	results, err := store.SearchTransactions("plumb", 0)
+	if err != nil {
+		t.Fatalf("SearchTransactions() failed: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+
+	// Description matches rank above raw-only matches, newest first among equals
+	if results[0].Transaction.Date.Day() != 20 || results[1].Transaction.Date.Day() != 2 {
+		t.Errorf("Expected newest plumber visit first, got days %d, %d",
+			results[0].Transaction.Date.Day(), results[1].Transaction.Date.Day())
+	}
+	if results[2].Transaction.Description != "Hardware store" {
+		t.Errorf("Expected raw-only match last, got %s", results[2].Transaction.Description)
+	}
+
+	want := SearchMatchStart + "Plumber" + SearchMatchEnd
+	if results[0].HighlightedDescription != want {
+		t.Errorf("Expected highlighted description %q, got %q", want, results[0].HighlightedDescription)
+	}
+	if results[2].HighlightedRaw != "HOME DEPOT #123 "+SearchMatchStart+"plumber"+SearchMatchEnd+" tape" {
+		t.Errorf("Unexpected raw highlight %q", results[2].HighlightedRaw)
+	}
+
+	// Every word has to match
+	results, err = store.SearchTransactions("joes 0517", 0)
+	if err != nil {
+		t.Fatalf("SearchTransactions() failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Transaction.Date.Day() != 20 {
+		t.Errorf("Expected only the May plumbing charge, got %d results", len(results))
+	}
+
+	// Limits and edits are reflected
+	if results, _ := store.SearchTransactions("plumb", 1); len(results) != 1 {
+		t.Errorf("Expected limit of 1, got %d results", len(results))
+	}
+	groceries, _ := store.QueryTransactions(TransactionQuery{Text: "Groceries"})
+	groceries[0].Description = "Plumbing supplies"
+	if err := store.SaveTransaction(groceries[0]); err != nil {
+		t.Fatalf("Failed to update transaction: %v", err)
+	}
+	if results, _ := store.SearchTransactions("plumb", 0); len(results) != 4 {
+		t.Errorf("Expected edited description to be searchable, got %d results", len(results))
+	}
+
+	if results, err := store.SearchTransactions(`"`, 0); err != nil || len(results) != 0 {
+		t.Errorf("Expected punctuation-only search to return nothing, got %d results (err %v)", len(results), err)
+	}
+}
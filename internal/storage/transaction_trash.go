@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"budget-tracker-tui/internal/types"
+	"fmt"
+	"time"
+)
+
+// Deleted transactions stay in the trash (deleted_at set) until restored, purged by hand
+// or purged automatically once they are older than the configured retention period.
+//
+// The second half of a split points at its first half through parent_id, and the halves are
+// deliberately not symmetric: trashing, restoring or purging the first half takes the whole split
+// with it, since together they are one bank row, while the second half on its own only moves
+// itself (restoring it also brings back a trashed first half, so it is never left orphaned).
+
+// RestoreTransaction moves a transaction out of the trash
+// Split children trashed with it come back too, and restoring a split child restores its parent.
+func (ts *TransactionStore) RestoreTransaction(id int64) error {
+	tx := ts.GetTransactionByID(id)
+	if tx == nil {
+		return fmt.Errorf("transaction with ID %d not found", id)
+	}
+	if tx.DeletedAt == nil {
+		return fmt.Errorf("transaction with ID %d is not in the trash", id)
+	}
+
+	if err := ts.restoreTrashed(ts.db.DB, tx); err != nil {
+		return err
+	}
+
+	ts.recordTrashEvent(types.ActionTypeRestore, tx, ts.GetTransactionByID(id))
+	return nil
+}
+
+// restoreTrashed clears deleted_at on a trashed transaction and everything trashed with it through db
+func (ts *TransactionStore) restoreTrashed(db sqlExecer, tx *types.Transaction) error {
+	var parentId int64
+	if tx.ParentId != nil {
+		parentId = *tx.ParentId
+	}
+
+	query := `
+		UPDATE transactions SET deleted_at = NULL, updated_at = ?
+		WHERE deleted_at IS NOT NULL
+		  AND (id = ? OR id = ? OR (parent_id = ? AND deleted_at = ?))
+	`
+	now := ts.helper.FormatTimeForDB(time.Now().UTC())
+	deletedAt := ts.helper.FormatTimeForDB(tx.DeletedAt.UTC())
+	if _, err := db.Exec(query, now, tx.Id, parentId, tx.Id, deletedAt); err != nil {
+		return fmt.Errorf("failed to restore transaction: %w", err)
+	}
+	return nil
+}
+
+// PurgeTransaction permanently deletes a transaction that is in the trash
+// Split children and audit events are removed with it by ON DELETE CASCADE.
+func (ts *TransactionStore) PurgeTransaction(id int64) error {
+	rowsAffected, err := ts.purgeTrashed(types.SourceUser, "id = ? AND deleted_at IS NOT NULL", id)
+	if err != nil {
+		return fmt.Errorf("failed to purge transaction: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("transaction with ID %d is not in the trash", id)
+	}
+	return nil
+}
+
+// EmptyTrash permanently deletes every transaction in the trash and returns how many were removed
+func (ts *TransactionStore) EmptyTrash() (int, error) {
+	rowsAffected, err := ts.purgeTrashed(types.SourceUser, "deleted_at IS NOT NULL")
+	if err != nil {
+		return 0, fmt.Errorf("failed to empty trash: %w", err)
+	}
+	return int(rowsAffected), nil
+}
+
+// PurgeExpiredTrash permanently deletes transactions trashed more than retentionDays ago
+// A retention of zero or less keeps the trash until it is emptied by hand.
+func (ts *TransactionStore) PurgeExpiredTrash(retentionDays int) (int, error) {
+	if retentionDays <= 0 {
+		return 0, nil
+	}
+
+	cutoff := ts.helper.FormatTimeForDB(time.Now().UTC().AddDate(0, 0, -retentionDays))
+	rowsAffected, err := ts.purgeTrashed(types.SourceAuto, "deleted_at IS NOT NULL AND deleted_at < ?", cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge expired trash: %w", err)
+	}
+	return int(rowsAffected), nil
+}
+
+// purgeTrashed deletes the transactions matching where and logs every removed row in the audit log
+// Split children are read too because ON DELETE CASCADE removes them with their parent.
+func (ts *TransactionStore) purgeTrashed(source, where string, args ...interface{}) (int64, error) {
+	selectQuery := "SELECT " + transactionColumns + " FROM transactions WHERE (" + where + ")" +
+		" OR parent_id IN (SELECT id FROM transactions WHERE " + where + ")"
+	selectArgs := append(append([]interface{}{}, args...), args...)
+
+	rows, err := ts.helper.QueryRows(selectQuery, selectArgs...)
+	if err != nil {
+		return 0, err
+	}
+	var purged []types.Transaction
+	for rows.Next() {
+		tx, err := ts.scanTransaction(rows)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		purged = append(purged, tx)
+	}
+	rows.Close()
+
+	rowsAffected, err := ts.helper.ExecReturnRowsAffected("DELETE FROM transactions WHERE "+where, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	ts.recordPurged(purged, source)
+	return rowsAffected, nil
+}
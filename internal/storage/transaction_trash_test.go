@@ -0,0 +1,315 @@
+package storage
+
+import (
+	"budget-tracker-tui/internal/types"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// saveTrashTestTransaction saves a transaction and returns its ID
+func saveTrashTestTransaction(t *testing.T, store *TransactionStore, categoryId int64, description string, parentId *int64) int64 {
+	t.Helper()
+
+	tx := createTestTransaction(40.00, description, categoryId)
+	tx.ParentId = parentId
+	tx.IsSplit = parentId != nil
+	if err := store.SaveTransaction(tx); err != nil {
+		t.Fatalf("Failed to save '%s': %v", description, err)
+	}
+
+	var id int64
+	if err := store.helper.QuerySingleRow("SELECT MAX(id) FROM transactions").Scan(&id); err != nil {
+		t.Fatalf("Failed to read transaction ID: %v", err)
+	}
+	return id
+}
+
+// trashDescriptions returns the descriptions of transactions in the trash
+func trashDescriptions(t *testing.T, store *TransactionStore) string {
+	t.Helper()
+
+	trashed, err := store.QueryTransactions(TransactionQuery{Deleted: DeletedOnly, Sort: SortDescription})
+	if err != nil {
+		t.Fatalf("Failed to query trash: %v", err)
+	}
+	return descriptions(trashed)
+}
+
+func TestDeletedTransactionsAreHidden(t *testing.T) {
+	store, conn := setupTestStore(t)
+	defer teardownTestDB(t, conn)
+
+	categoryId := createTestCategory(t, conn, "Dining")
+	keepId := saveTrashTestTransaction(t, store, categoryId, "Lunch", nil)
+	deleteId := saveTrashTestTransaction(t, store, categoryId, "Lunch", nil)
+
+	if err := store.DeleteTransaction(deleteId); err != nil {
+		t.Fatalf("DeleteTransaction() failed: %v", err)
+	}
+
+	if count, _ := store.CountTransactions(TransactionQuery{}); count != 1 {
+		t.Errorf("Expected 1 live transaction, got %d", count)
+	}
+	if count, _ := store.CountTransactions(TransactionQuery{Deleted: DeletedInclude}); count != 2 {
+		t.Errorf("Expected 2 transactions including the trash, got %d", count)
+	}
+	if results, _ := store.SearchTransactions("lunch", 0); len(results) != 1 || results[0].Transaction.Id != keepId {
+		t.Errorf("Expected search to skip the trashed transaction, got %d results", len(results))
+	}
+
+	tx := createTestTransaction(40.00, "Lunch", categoryId)
+	duplicates, err := store.FindDuplicateTransactions("2024-01-15", tx.Amount, "Lunch")
+	if err != nil {
+		t.Fatalf("FindDuplicateTransactions() failed: %v", err)
+	}
+	if len(duplicates) != 1 {
+		t.Errorf("Expected trashed transaction not to count as a duplicate, got %d", len(duplicates))
+	}
+
+	// Lookups by ID still see the trash so it can be restored
+	trashed := store.GetTransactionByID(deleteId)
+	if trashed == nil || trashed.DeletedAt == nil {
+		t.Fatal("Expected trashed transaction to be found by ID with DeletedAt set")
+	}
+	if store.GetTransactionByID(keepId).DeletedAt != nil {
+		t.Error("Expected live transaction to have no DeletedAt")
+	}
+
+	if _, err := store.QueryTransactions(TransactionQuery{Deleted: "all"}); err == nil {
+		t.Error("Expected unknown trash filter to fail")
+	}
+}
+
+func TestRestoreTransaction(t *testing.T) {
+	store, conn := setupTestStore(t)
+	defer teardownTestDB(t, conn)
+
+	categoryId := createTestCategory(t, conn, "Home")
+	parentId := saveTrashTestTransaction(t, store, categoryId, "Hardware", nil)
+	childId := saveTrashTestTransaction(t, store, categoryId, "Hardware split", &parentId)
+
+	if err := store.DeleteTransaction(parentId); err != nil {
+		t.Fatalf("DeleteTransaction() failed: %v", err)
+	}
+	if got := trashDescriptions(t, store); got != "Hardware, Hardware split" {
+		t.Fatalf("Expected parent and child in the trash, got [%s]", got)
+	}
+
+	// Restoring the child brings its parent back with it
+	if err := store.RestoreTransaction(childId); err != nil {
+		t.Fatalf("RestoreTransaction() failed: %v", err)
+	}
+	if got := trashDescriptions(t, store); got != "" {
+		t.Errorf("Expected empty trash after restore, got [%s]", got)
+	}
+
+	// A child trashed on its own stays there when the parent is trashed and restored later
+	if err := store.DeleteTransaction(childId); err != nil {
+		t.Fatalf("DeleteTransaction() failed: %v", err)
+	}
+	if _, err := conn.DB.Exec("UPDATE transactions SET deleted_at = ? WHERE id = ?",
+		time.Now().UTC().Add(-time.Hour).Format(time.RFC3339), childId); err != nil {
+		t.Fatalf("Failed to backdate child: %v", err)
+	}
+	if err := store.DeleteTransaction(parentId); err != nil {
+		t.Fatalf("DeleteTransaction() failed: %v", err)
+	}
+	if err := store.RestoreTransaction(parentId); err != nil {
+		t.Fatalf("RestoreTransaction() failed: %v", err)
+	}
+	if got := trashDescriptions(t, store); got != "Hardware split" {
+		t.Errorf("Expected only the separately trashed child in the trash, got [%s]", got)
+	}
+
+	if err := store.RestoreTransaction(parentId); err == nil {
+		t.Error("Expected error restoring a transaction that is not in the trash")
+	}
+	if err := store.RestoreTransaction(99999); err == nil {
+		t.Error("Expected error restoring a nonexistent transaction")
+	}
+}
+
+func TestPurgeTransaction(t *testing.T) {
+	store, conn := setupTestStore(t)
+	defer teardownTestDB(t, conn)
+
+	categoryId := createTestCategory(t, conn, "Travel")
+	liveId := saveTrashTestTransaction(t, store, categoryId, "Train", nil)
+	parentId := saveTrashTestTransaction(t, store, categoryId, "Hotel", nil)
+	saveTrashTestTransaction(t, store, categoryId, "Hotel split", &parentId)
+	otherId := saveTrashTestTransaction(t, store, categoryId, "Taxi", nil)
+
+	if err := store.PurgeTransaction(liveId); err == nil {
+		t.Error("Expected error purging a live transaction")
+	}
+
+	for _, id := range []int64{parentId, otherId} {
+		if err := store.DeleteTransaction(id); err != nil {
+			t.Fatalf("DeleteTransaction() failed: %v", err)
+		}
+	}
+
+	if err := store.PurgeTransaction(parentId); err != nil {
+		t.Fatalf("PurgeTransaction() failed: %v", err)
+	}
+	if got := trashDescriptions(t, store); got != "Taxi" {
+		t.Errorf("Expected purge to remove the split child too, got [%s]", got)
+	}
+
+	removed, err := store.EmptyTrash()
+	if err != nil {
+		t.Fatalf("EmptyTrash() failed: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Expected 1 transaction removed, got %d", removed)
+	}
+	if count, _ := store.CountTransactions(TransactionQuery{Deleted: DeletedInclude}); count != 1 {
+		t.Errorf("Expected only the live transaction to remain, got %d", count)
+	}
+}
+
+func TestTrashSplitHalves(t *testing.T) {
+	store, conn := setupTestStore(t)
+	defer teardownTestDB(t, conn)
+
+	categoryId := createTestCategory(t, conn, "Home")
+	firstId := saveTrashTestTransaction(t, store, categoryId, "Hardware", nil)
+	splits := []types.Transaction{
+		createTestTransaction(25.00, "Paint", categoryId),
+		createTestTransaction(15.00, "Brushes", categoryId),
+	}
+	secondId, err := store.splitTransaction(firstId, splits, types.SourceUser)
+	if err != nil {
+		t.Fatalf("splitTransaction() failed: %v", err)
+	}
+
+	// The second half on its own moves only itself
+	if err := store.DeleteTransaction(secondId); err != nil {
+		t.Fatalf("DeleteTransaction() of second half failed: %v", err)
+	}
+	if got := trashDescriptions(t, store); got != "Brushes" {
+		t.Errorf("Expected only the second half in the trash, got [%s]", got)
+	}
+	if err := store.PurgeTransaction(secondId); err != nil {
+		t.Fatalf("PurgeTransaction() of second half failed: %v", err)
+	}
+	if first := store.GetTransactionByID(firstId); first == nil || first.DeletedAt != nil {
+		t.Error("Expected purging the second half to leave the first half live")
+	}
+
+	// The first half takes the whole split with it into the trash and out of the ledger
+	secondId, err = store.splitTransaction(firstId, []types.Transaction{
+		createTestTransaction(20.00, "Paint", categoryId),
+		createTestTransaction(5.00, "Rollers", categoryId),
+	}, types.SourceUser)
+	if err != nil {
+		t.Fatalf("splitTransaction() failed: %v", err)
+	}
+	if err := store.DeleteTransaction(firstId); err != nil {
+		t.Fatalf("DeleteTransaction() of first half failed: %v", err)
+	}
+	if got := trashDescriptions(t, store); got != "Paint, Rollers" {
+		t.Errorf("Expected both halves in the trash, got [%s]", got)
+	}
+	if err := store.PurgeTransaction(firstId); err != nil {
+		t.Fatalf("PurgeTransaction() of first half failed: %v", err)
+	}
+	if store.GetTransactionByID(firstId) != nil || store.GetTransactionByID(secondId) != nil {
+		t.Error("Expected purging the first half to remove both halves")
+	}
+}
+
+func TestPurgeExpiredTrash(t *testing.T) {
+	store, conn := setupTestStore(t)
+	defer teardownTestDB(t, conn)
+
+	categoryId := createTestCategory(t, conn, "Utilities")
+	oldId := saveTrashTestTransaction(t, store, categoryId, "Old bill", nil)
+	recentId := saveTrashTestTransaction(t, store, categoryId, "Recent bill", nil)
+
+	trashAt := func(id int64, age time.Duration) {
+		t.Helper()
+		if err := store.DeleteTransaction(id); err != nil {
+			t.Fatalf("DeleteTransaction() failed: %v", err)
+		}
+		deletedAt := time.Now().UTC().Add(-age).Format(time.RFC3339)
+		if _, err := conn.DB.Exec("UPDATE transactions SET deleted_at = ? WHERE id = ?", deletedAt, id); err != nil {
+			t.Fatalf("Failed to backdate deletion: %v", err)
+		}
+	}
+	trashAt(oldId, 45*24*time.Hour)
+	trashAt(recentId, 24*time.Hour)
+
+	if removed, err := store.PurgeExpiredTrash(0); err != nil || removed != 0 {
+		t.Errorf("Expected zero retention to keep the trash, removed %d (err %v)", removed, err)
+	}
+
+	removed, err := store.PurgeExpiredTrash(30)
+	if err != nil {
+		t.Fatalf("PurgeExpiredTrash() failed: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Expected 1 expired transaction purged, got %d", removed)
+	}
+	if got := trashDescriptions(t, store); got != "Recent bill" {
+		t.Errorf("Expected only the recent deletion to remain, got [%s]", got)
+	}
+}
+
+func TestMainStoreInitPurgesExpiredTrash(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "ledger.db")
+
+	store := NewStoreAt(dbPath)
+	if err := store.Init(); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	categoryId := store.Categories.GetDefaultCategoryId()
+	id := saveTrashTestTransaction(t, store.Transactions, categoryId, "Forgotten", nil)
+	if err := store.Transactions.DeleteTransaction(id); err != nil {
+		t.Fatalf("DeleteTransaction() failed: %v", err)
+	}
+	if err := store.UserPreferences.SetTrashRetentionDays(7); err != nil {
+		t.Fatalf("SetTrashRetentionDays() failed: %v", err)
+	}
+	deletedAt := time.Now().UTC().AddDate(0, 0, -8).Format(time.RFC3339)
+	if _, err := store.db.DB.Exec("UPDATE transactions SET deleted_at = ? WHERE id = ?", deletedAt, id); err != nil {
+		t.Fatalf("Failed to backdate deletion: %v", err)
+	}
+	store.Close()
+
+	store = NewStoreAt(dbPath)
+	if err := store.Init(); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	defer store.Close()
+
+	if tx := store.Transactions.GetTransactionByID(id); tx != nil {
+		t.Error("Expected expired trash to be purged on startup")
+	}
+}
+
+func TestCategoryDeletionBlockedByTrash(t *testing.T) {
+	store, conn := setupTestStore(t)
+	defer teardownTestDB(t, conn)
+
+	categories := NewCategoryStore(conn)
+	categories.SetTransactionStore(store)
+	createTestCategory(t, conn, "Keep")
+	categoryId := createTestCategory(t, conn, "Old hobby")
+
+	id := saveTrashTestTransaction(t, store, categoryId, "Paint", nil)
+	if err := store.DeleteTransaction(id); err != nil {
+		t.Fatalf("DeleteTransaction() failed: %v", err)
+	}
+
+	if err := categories.ValidateCategoryForDeletion(categoryId); err == nil {
+		t.Error("Expected trashed transactions to block category deletion")
+	}
+	if err := store.PurgeTransaction(id); err != nil {
+		t.Fatalf("PurgeTransaction() failed: %v", err)
+	}
+	if err := categories.ValidateCategoryForDeletion(categoryId); err != nil {
+		t.Errorf("Expected category to be deletable once the trash is purged, got: %v", err)
+	}
+}
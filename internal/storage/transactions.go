@@ -3,10 +3,10 @@ package storage
 import (
 	"budget-tracker-tui/internal/database"
 	"budget-tracker-tui/internal/types"
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
 	"time"
 )
 
@@ -15,28 +15,16 @@ type TransactionStore struct {
 	db                *database.Connection
 	helper            *database.SQLHelper
 	transactionAudits *TransactionAuditStore
-	store             *Store // Reference to main store for ML access
-	debugLogger       *log.Logger
+	store             *Store       // Reference to main store for ML access
+	undo              *UndoManager // Receives user changes so they can be undone
+	audit             *AuditStore  // General audit log; records transaction deletes
 }
 
 // NewTransactionStore creates a new TransactionStore instance
 func NewTransactionStore(db *database.Connection) *TransactionStore {
-	// Create debug logger that writes to debug.log
-	debugFile, err := os.OpenFile("debug.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	var debugLogger *log.Logger
-	if err != nil {
-		// Fallback to stdout if file can't be opened
-		debugLogger = log.New(os.Stdout, "[DEBUG] ", log.LstdFlags)
-	} else {
-		debugLogger = log.New(debugFile, "", log.LstdFlags)
-		// Log initialization message
-		debugLogger.Printf("[INIT] TransactionStore debug logging initialized")
-	}
-
 	return &TransactionStore{
-		db:          db,
-		helper:      database.NewSQLHelper(db),
-		debugLogger: debugLogger,
+		db:     db,
+		helper: database.NewSQLHelper(db),
 	}
 }
 
@@ -45,81 +33,61 @@ func (ts *TransactionStore) SetTransactionAuditStore(tas *TransactionAuditStore)
 	ts.transactionAudits = tas
 }
 
-// SetStore sets the main store reference for ML access (called after all stores are initialized)
-func (ts *TransactionStore) SetStore(s *Store) {
-	ts.store = s
+// SetUndoManager sets the undo manager that user changes are recorded with
+func (ts *TransactionStore) SetUndoManager(um *UndoManager) {
+	ts.undo = um
 }
 
-// GetTransactions returns all transactions from the database
-func (ts *TransactionStore) GetTransactions() ([]types.Transaction, error) {
-	query := `
-		SELECT id, parent_id, amount, description, raw_description, date, 
-		       category_id, transaction_type, is_split, 
-		       statement_id, created_at, updated_at 
-		FROM transactions 
-		ORDER BY date DESC, id DESC
-	`
+// SetAuditStore sets the audit log that transaction deletes are recorded in
+func (ts *TransactionStore) SetAuditStore(audit *AuditStore) {
+	ts.audit = audit
+}
 
-	rows, err := ts.helper.QueryRows(query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query transactions: %w", err)
+// recordAuditEvent records an audit event and hands user changes to the undo manager
+func (ts *TransactionStore) recordAuditEvent(event *types.TransactionAuditEvent) {
+	if ts.transactionAudits == nil {
+		return
 	}
-	defer rows.Close()
-
-	var transactions []types.Transaction
-	for rows.Next() {
-		tx, err := ts.scanTransaction(rows)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan transaction: %w", err)
-		}
-		transactions = append(transactions, tx)
+	if err := ts.transactionAudits.RecordEvent(event); err != nil {
+		slog.Warn("failed to record transaction audit event", "action", event.ActionType, "transaction_id", event.TransactionId, "error", err)
+		return
+	}
+	if event.Source == types.SourceUser {
+		ts.undo.recordTransactionEvent(event)
 	}
+}
 
-	return transactions, rows.Err()
+// SetStore sets the main store reference for ML access (called after all stores are initialized)
+func (ts *TransactionStore) SetStore(s *Store) {
+	ts.store = s
+}
+
+// GetTransactions returns all transactions from the database, newest first
+func (ts *TransactionStore) GetTransactions() ([]types.Transaction, error) {
+	return ts.QueryTransactions(TransactionQuery{})
 }
 
 // GetTransactionsByStatement returns all transactions for a specific bank statement
 func (ts *TransactionStore) GetTransactionsByStatement(statementId int64) ([]types.Transaction, error) {
-	query := `
-		SELECT id, parent_id, amount, description, raw_description, date, 
-		       category_id, transaction_type, is_split, 
-		       statement_id, created_at, updated_at 
-		FROM transactions 
-		WHERE statement_id = ? 
-		ORDER BY date DESC, id DESC
-	`
-
-	rows, err := ts.helper.QueryRows(query, statementId)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query transactions by statement: %w", err)
-	}
-	defer rows.Close()
-
-	var transactions []types.Transaction
-	for rows.Next() {
-		tx, err := ts.scanTransaction(rows)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan transaction: %w", err)
-		}
-		transactions = append(transactions, tx)
-	}
-
-	return transactions, rows.Err()
+	return ts.QueryTransactions(TransactionQuery{StatementId: statementId})
 }
 
 // scanTransaction scans a database row into a Transaction struct
-func (ts *TransactionStore) scanTransaction(rows *sql.Rows) (types.Transaction, error) {
+// Any extra destinations receive columns selected after the transaction columns.
+func (ts *TransactionStore) scanTransaction(rows *sql.Rows, extra ...interface{}) (types.Transaction, error) {
 	var tx types.Transaction
 	var parentID sql.NullInt64
 	var statementID sql.NullInt64
-	var rawDescription sql.NullString
+	var accountID sql.NullInt64
+	var rawDescription, deletedAtStr, externalID sql.NullString
 	var dateStr, createdAtStr, updatedAtStr string
 
-	err := rows.Scan(
-		&tx.Id, &parentID, &tx.Amount, &tx.Description, &rawDescription,
+	dest := []interface{}{
+		&tx.Id, &parentID, &tx.Amount.Cents, &tx.Amount.Currency, &tx.Description, &rawDescription,
 		&dateStr, &tx.CategoryId, &tx.TransactionType,
-		&tx.IsSplit, &statementID, &createdAtStr, &updatedAtStr,
-	)
+		&tx.IsSplit, &statementID, &accountID, &createdAtStr, &updatedAtStr, &deletedAtStr, &externalID,
+	}
+	err := rows.Scan(append(dest, extra...)...)
 
 	if err != nil {
 		return tx, err
@@ -135,6 +103,13 @@ func (ts *TransactionStore) scanTransaction(rows *sql.Rows) (types.Transaction,
 	if tx.UpdatedAt, err = ts.helper.ParseTimeFromDB(updatedAtStr); err != nil {
 		return tx, fmt.Errorf("failed to parse updated_at timestamp '%s': %w", updatedAtStr, err)
 	}
+	if deletedAtStr.Valid {
+		deletedAt, err := ts.helper.ParseTimeFromDB(deletedAtStr.String)
+		if err != nil {
+			return tx, fmt.Errorf("failed to parse deleted_at timestamp '%s': %w", deletedAtStr.String, err)
+		}
+		tx.DeletedAt = &deletedAt
+	}
 
 	// Handle nullable fields
 	if parentID.Valid {
@@ -143,25 +118,29 @@ func (ts *TransactionStore) scanTransaction(rows *sql.Rows) (types.Transaction,
 	if statementID.Valid {
 		tx.StatementId = statementID.Int64
 	}
+	if accountID.Valid {
+		tx.AccountId = accountID.Int64
+	}
 	if rawDescription.Valid {
 		tx.RawDescription = rawDescription.String
 	}
+	if externalID.Valid {
+		tx.ExternalId = externalID.String
+	}
 
 	return tx, nil
 }
 
-// GetTransactionByID returns a transaction by ID
+// GetTransactionByID returns a transaction by ID, including transactions in the trash
 func (ts *TransactionStore) GetTransactionByID(id int64) *types.Transaction {
-	query := `
-		SELECT id, parent_id, amount, description, raw_description, date, 
-		       category_id, transaction_type, is_split, 
-		       statement_id, created_at, updated_at 
-		FROM transactions 
-		WHERE id = ?
-	`
+	return ts.getTransaction(ts.db.DB, id)
+}
+
+// getTransaction reads a transaction by ID through db, so a caller's transaction sees its own writes
+func (ts *TransactionStore) getTransaction(db sqlQuerier, id int64) *types.Transaction {
+	query := "SELECT " + transactionColumns + " FROM transactions WHERE id = ?"
 
-	row := ts.helper.QuerySingleRow(query, id)
-	tx, err := ts.scanTransactionRow(row)
+	tx, err := ts.scanTransactionRow(db.QueryRow(query, id))
 	if err != nil {
 		return nil // Transaction not found or error
 	}
@@ -174,13 +153,14 @@ func (ts *TransactionStore) scanTransactionRow(row *sql.Row) (types.Transaction,
 	var tx types.Transaction
 	var parentID sql.NullInt64
 	var statementID sql.NullInt64
-	var rawDescription sql.NullString
+	var accountID sql.NullInt64
+	var rawDescription, deletedAtStr, externalID sql.NullString
 	var dateStr, createdAtStr, updatedAtStr string
 
 	err := row.Scan(
-		&tx.Id, &parentID, &tx.Amount, &tx.Description, &rawDescription,
+		&tx.Id, &parentID, &tx.Amount.Cents, &tx.Amount.Currency, &tx.Description, &rawDescription,
 		&dateStr, &tx.CategoryId, &tx.TransactionType,
-		&tx.IsSplit, &statementID, &createdAtStr, &updatedAtStr,
+		&tx.IsSplit, &statementID, &accountID, &createdAtStr, &updatedAtStr, &deletedAtStr, &externalID,
 	)
 
 	if err != nil {
@@ -200,6 +180,13 @@ func (ts *TransactionStore) scanTransactionRow(row *sql.Row) (types.Transaction,
 	if err != nil {
 		return tx, fmt.Errorf("failed to parse updated_at: %w", err)
 	}
+	if deletedAtStr.Valid {
+		deletedAt, err := ts.helper.ParseTimeFromDB(deletedAtStr.String)
+		if err != nil {
+			return tx, fmt.Errorf("failed to parse deleted_at: %w", err)
+		}
+		tx.DeletedAt = &deletedAt
+	}
 
 	// Handle nullable fields
 	if parentID.Valid {
@@ -208,9 +195,15 @@ func (ts *TransactionStore) scanTransactionRow(row *sql.Row) (types.Transaction,
 	if statementID.Valid {
 		tx.StatementId = statementID.Int64
 	}
+	if accountID.Valid {
+		tx.AccountId = accountID.Int64
+	}
 	if rawDescription.Valid {
 		tx.RawDescription = rawDescription.String
 	}
+	if externalID.Valid {
+		tx.ExternalId = externalID.String
+	}
 
 	return tx, nil
 }
@@ -221,24 +214,29 @@ func (ts *TransactionStore) SaveTransaction(transaction types.Transaction) error
 
 	if transaction.Id == 0 {
 		// Insert new transaction
-		return ts.insertTransaction(transaction, now)
+		return ts.insertTransaction(ts.db.DB, transaction, now)
 	} else {
 		// Update existing transaction
 		return ts.updateTransaction(transaction, now)
 	}
 }
 
-// insertTransaction inserts a new transaction into the database
-func (ts *TransactionStore) insertTransaction(transaction types.Transaction, now time.Time) error {
+// insertTransaction inserts a new transaction through db
+func (ts *TransactionStore) insertTransaction(db sqlExecer, transaction types.Transaction, now time.Time) error {
 	query := `
 		INSERT INTO transactions (
-			parent_id, amount, description, raw_description, date, 
+			id, parent_id, amount_cents, currency, description, raw_description, date, 
 			category_id, transaction_type, is_split, 
-			statement_id, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			statement_id, account_id, created_at, updated_at, external_id
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	// Convert nullable fields
+	// Convert nullable fields; a zero ID lets SQLite assign one (redo reinserts with the original ID)
+	var id interface{}
+	if transaction.Id != 0 {
+		id = transaction.Id
+	}
+
 	var parentID interface{}
 	if transaction.ParentId != nil {
 		parentID = *transaction.ParentId
@@ -249,11 +247,21 @@ func (ts *TransactionStore) insertTransaction(transaction types.Transaction, now
 		statementID = transaction.StatementId
 	}
 
+	var accountID interface{}
+	if transaction.AccountId != 0 {
+		accountID = transaction.AccountId
+	}
+
 	var rawDescription interface{}
 	if transaction.RawDescription != "" {
 		rawDescription = transaction.RawDescription
 	}
 
+	var externalID interface{}
+	if transaction.ExternalId != "" {
+		externalID = transaction.ExternalId
+	}
+
 	// Set creation timestamp if not provided
 	createdAt := transaction.CreatedAt
 	if createdAt.IsZero() {
@@ -265,19 +273,16 @@ func (ts *TransactionStore) insertTransaction(transaction types.Transaction, now
 	createdAtStr := createdAt.Format(time.RFC3339)
 	updatedAtStr := now.Format(time.RFC3339)
 
-	id, err := ts.helper.ExecReturnID(query,
-		parentID, transaction.Amount, transaction.Description, rawDescription,
+	_, err := db.Exec(query,
+		id, parentID, transaction.Amount.Cents, currencyCode(transaction.Amount), transaction.Description, rawDescription,
 		dateStr, transaction.CategoryId, transaction.TransactionType,
-		transaction.IsSplit, statementID, createdAtStr, updatedAtStr,
+		transaction.IsSplit, statementID, accountID, createdAtStr, updatedAtStr, externalID,
 	)
 
 	if err != nil {
 		return fmt.Errorf("failed to insert transaction: %w", err)
 	}
 
-	// Update the transaction with the generated ID
-	transaction.Id = id
-
 	// No audit event creation for new transactions
 
 	return nil
@@ -291,11 +296,23 @@ func (ts *TransactionStore) updateTransaction(transaction types.Transaction, now
 		return fmt.Errorf("transaction not found")
 	}
 
+	if err := ts.updateTransactionRow(ts.db.DB, transaction, now); err != nil {
+		return err
+	}
+
+	// Log audit events for field changes
+	ts.logTransactionFieldChanges(oldTransaction, &transaction)
+
+	return nil
+}
+
+// updateTransactionRow writes a transaction's fields through db
+func (ts *TransactionStore) updateTransactionRow(db sqlExecer, transaction types.Transaction, now time.Time) error {
 	query := `
 		UPDATE transactions SET 
-			parent_id = ?, amount = ?, description = ?, raw_description = ?, 
+			parent_id = ?, amount_cents = ?, currency = ?, description = ?, raw_description = ?, 
 			date = ?, category_id = ?, transaction_type = ?, 
-			is_split = ?, statement_id = ?, updated_at = ?
+			is_split = ?, statement_id = ?, account_id = ?, updated_at = ?
 		WHERE id = ?
 	`
 
@@ -310,29 +327,41 @@ func (ts *TransactionStore) updateTransaction(transaction types.Transaction, now
 		statementID = transaction.StatementId
 	}
 
+	var accountID interface{}
+	if transaction.AccountId != 0 {
+		accountID = transaction.AccountId
+	}
+
 	var rawDescription interface{}
 	if transaction.RawDescription != "" {
 		rawDescription = transaction.RawDescription
 	}
 
-	_, err := ts.helper.ExecReturnRowsAffected(query,
-		parentID, transaction.Amount, transaction.Description, rawDescription,
+	_, err := db.Exec(query,
+		parentID, transaction.Amount.Cents, currencyCode(transaction.Amount), transaction.Description, rawDescription,
 		transaction.Date, transaction.CategoryId, transaction.TransactionType,
-		transaction.IsSplit, statementID, now, transaction.Id,
+		transaction.IsSplit, statementID, accountID, now, transaction.Id,
 	)
 
 	if err != nil {
 		return fmt.Errorf("failed to update transaction: %w", err)
 	}
 
-	// Log audit events for field changes
-	ts.logTransactionFieldChanges(oldTransaction, &transaction)
-
 	return nil
 }
 
 // logTransactionFieldChanges creates a transaction audit event for edits
 func (ts *TransactionStore) logTransactionFieldChanges(oldTx, newTx *types.Transaction) {
+	// Snapshot the stored row rather than the caller's copy so undo replays exactly what was saved
+	if saved := ts.GetTransactionByID(newTx.Id); saved != nil {
+		newTx = saved
+	}
+
+	ts.recordAuditEvent(newEditAuditEvent(oldTx, newTx))
+}
+
+// newEditAuditEvent builds the transaction audit event for a user edit from oldTx to newTx
+func newEditAuditEvent(oldTx, newTx *types.Transaction) *types.TransactionAuditEvent {
 	// Determine what was modified
 	var modificationReason *string
 
@@ -347,18 +376,9 @@ func (ts *TransactionStore) logTransactionFieldChanges(oldTx, newTx *types.Trans
 		modificationReason = &reason
 	}
 
-	// Get bank statement ID
-	bankStatementId := newTx.StatementId
-
-	// Create pre and post snapshots (simplified JSON-like format for now)
-	preSnapshot := fmt.Sprintf("{\"amount\":%.2f,\"description\":\"%s\",\"category\":%d,\"type\":\"%s\"}",
-		oldTx.Amount, oldTx.Description, oldTx.CategoryId, oldTx.TransactionType)
-	postSnapshot := fmt.Sprintf("{\"amount\":%.2f,\"description\":\"%s\",\"category\":%d,\"type\":\"%s\"}",
-		newTx.Amount, newTx.Description, newTx.CategoryId, newTx.TransactionType)
-
-	auditEvent := &types.TransactionAuditEvent{
+	return &types.TransactionAuditEvent{
 		TransactionId:          newTx.Id,
-		BankStatementId:        bankStatementId,
+		BankStatementId:        newTx.StatementId,
 		Timestamp:              time.Now(),
 		ActionType:             types.ActionTypeEdit,
 		Source:                 types.SourceUser,
@@ -367,63 +387,108 @@ func (ts *TransactionStore) logTransactionFieldChanges(oldTx, newTx *types.Trans
 		CategoryConfidence:     1.0,
 		PreviousCategory:       oldTx.CategoryId,
 		ModificationReason:     modificationReason,
-		PreEditSnapshot:        &preSnapshot,
-		PostEditSnapshot:       &postSnapshot,
+		PreEditSnapshot:        types.EncodeTransactionSnapshot(oldTx),
+		PostEditSnapshot:       types.EncodeTransactionSnapshot(newTx),
 	}
-
-	ts.transactionAudits.RecordEvent(auditEvent)
 }
 
-// DeleteTransaction removes a transaction by ID
+// DeleteTransaction moves a transaction and its split children to the trash
+// Trashed transactions are hidden from every query until restored or purged (see transaction_trash.go).
 func (ts *TransactionStore) DeleteTransaction(id int64) error {
-	query := "DELETE FROM transactions WHERE id = ?"
+	original := ts.GetTransactionByID(id)
+	if original == nil || original.DeletedAt != nil {
+		return fmt.Errorf("transaction with ID %d not found", id)
+	}
 
-	rowsAffected, err := ts.helper.ExecReturnRowsAffected(query, id)
-	if err != nil {
+	if err := ts.trashTransaction(ts.db.DB, id); err != nil {
+		return err
+	}
+
+	ts.recordTrashEvent(types.ActionTypeDelete, original, ts.GetTransactionByID(id))
+	return nil
+}
+
+// trashTransaction sets deleted_at on a transaction and its split children through db
+func (ts *TransactionStore) trashTransaction(db sqlExecer, id int64) error {
+	// Split children share the parent's timestamp so a restore brings them back together
+	now := ts.helper.FormatTimeForDB(time.Now().UTC())
+	query := "UPDATE transactions SET deleted_at = ?, updated_at = ? WHERE (id = ? OR parent_id = ?) AND deleted_at IS NULL"
+	if _, err := db.Exec(query, now, now, id, id); err != nil {
 		return fmt.Errorf("failed to delete transaction: %w", err)
 	}
+	return nil
+}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("transaction with ID %d not found", id)
+// recordTrashEvent records moving a transaction into or out of the trash
+func (ts *TransactionStore) recordTrashEvent(actionType string, before, after *types.Transaction) {
+	if after == nil {
+		return
 	}
 
-	// Note: For now we're not recording delete events in TransactionAuditEvent
-	// since deleted transactions won't have valid references
-	// This could be enhanced in the future if needed
+	ts.recordAuditEvent(&types.TransactionAuditEvent{
+		TransactionId:          before.Id,
+		BankStatementId:        before.StatementId,
+		Timestamp:              time.Now(),
+		ActionType:             actionType,
+		Source:                 types.SourceUser,
+		DescriptionFingerprint: before.Description,
+		CategoryAssigned:       before.CategoryId,
+		CategoryConfidence:     1.0,
+		PreviousCategory:       before.CategoryId,
+		PreEditSnapshot:        types.EncodeTransactionSnapshot(before),
+		PostEditSnapshot:       types.EncodeTransactionSnapshot(after),
+	})
+
+	// Moving to the trash is a delete in the general audit log; restoring updates the row back
+	eventType := types.AuditEventDelete
+	if actionType == types.ActionTypeRestore {
+		eventType = types.AuditEventUpdate
+	}
+	ts.audit.recordOrWarn(types.EntityTransaction, auditId(before.Id), eventType, types.SourceUser,
+		types.NewTransactionSnapshot(*before), types.NewTransactionSnapshot(*after))
+}
 
-	return nil
+// recordPurged logs permanently deleted transactions in the general audit log
+// Their transaction audit events are removed with them, so this is the only record left.
+func (ts *TransactionStore) recordPurged(transactions []types.Transaction, source string) {
+	for _, tx := range transactions {
+		ts.audit.recordOrWarn(types.EntityTransaction, auditId(tx.Id), types.AuditEventDelete, source,
+			types.NewTransactionSnapshot(tx), nil)
+	}
 }
 
 // SplitTransaction splits a updates current transaction into new values and creates a split transaction linked to itself
+// The link makes the second half follow the first into and out of the trash (see transaction_trash.go).
 func (ts *TransactionStore) SplitTransaction(parentId int64, splits []types.Transaction) error {
-	// Get original transaction for audit logging
-	var originalTransaction *types.Transaction
-	if ts.transactionAudits != nil {
-		originalTransaction = ts.GetTransactionByID(parentId)
+	_, err := ts.splitTransaction(parentId, splits, types.SourceUser)
+	return err
+}
+
+// splitTransaction performs SplitTransaction and returns the ID of the new second split
+// Only user splits become an undo step; imported splits are undone with their statement.
+func (ts *TransactionStore) splitTransaction(parentId int64, splits []types.Transaction, source string) (int64, error) {
+	// Read the parent before opening the database transaction; it is also the pre-split audit state
+	parent := ts.GetTransactionByID(parentId)
+	if parent == nil {
+		return 0, fmt.Errorf("parent transaction not found")
 	}
 
 	var secondSplitId int64 // Capture ID of newly created second split
 
 	err := ts.db.ExecuteInTransaction(func(tx *sql.Tx) error {
 		// Validate splits add up to parent amount
-		parent := ts.GetTransactionByID(parentId)
-		if parent == nil {
-			return fmt.Errorf("parent transaction not found")
-		}
-
 		if len(splits) != 2 {
 			return fmt.Errorf("exactly 2 splits required")
 		}
 
-		var totalSplit float64
+		totalSplit := types.NewMoney(0, parent.Amount.Currency)
 		for _, split := range splits {
-			totalSplit += split.Amount
+			totalSplit = totalSplit.Add(split.Amount)
 		}
 
-		// Use epsilon comparison for floating point
-		const epsilon = 0.01
-		if totalSplit-parent.Amount > epsilon || parent.Amount-totalSplit > epsilon {
-			return fmt.Errorf("split amounts (%.2f) don't match parent (%.2f)", totalSplit, parent.Amount)
+		// Amounts are exact cents, so splits must reconcile to the cent
+		if totalSplit.Cents != parent.Amount.Cents {
+			return fmt.Errorf("split amounts (%s) don't match parent (%s)", totalSplit, parent.Amount)
 		}
 
 		now := time.Now()
@@ -431,33 +496,38 @@ func (ts *TransactionStore) SplitTransaction(parentId int64, splits []types.Tran
 		// Update existing transaction to become first split
 		updateQuery := `
 			UPDATE transactions SET 
-				amount = ?, description = ?, category_id = ?, is_split = ?, 
+				amount_cents = ?, description = ?, category_id = ?, is_split = ?, 
 				updated_at = ?
 			WHERE id = ?
 		`
 		_, err := tx.Exec(updateQuery,
-			splits[0].Amount, splits[0].Description, splits[0].CategoryId,
+			splits[0].Amount.Cents, splits[0].Description, splits[0].CategoryId,
 			true, now, parentId,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to update parent transaction: %w", err)
 		}
 
-		// Create second split as new transaction
+		// Create second split as new transaction, linked to the first so integrity checks can pair them
 		insertQuery := `
 			INSERT INTO transactions (
-				amount, description, date, category_id, transaction_type, 
-				statement_id, is_split, created_at, updated_at
-			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+				parent_id, amount_cents, currency, description, date, category_id, transaction_type, 
+				statement_id, account_id, is_split, created_at, updated_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 		var statementID interface{}
 		if parent.StatementId != 0 {
 			statementID = parent.StatementId
 		}
 
+		var accountID interface{}
+		if parent.AccountId != 0 {
+			accountID = parent.AccountId
+		}
+
 		result, err := tx.Exec(insertQuery,
-			splits[1].Amount, splits[1].Description, parent.Date,
-			splits[1].CategoryId, parent.TransactionType, statementID,
+			parentId, splits[1].Amount.Cents, currencyCode(parent.Amount), splits[1].Description, parent.Date,
+			splits[1].CategoryId, parent.TransactionType, statementID, accountID,
 			false, now, now,
 		)
 		if err != nil {
@@ -474,52 +544,74 @@ func (ts *TransactionStore) SplitTransaction(parentId int64, splits []types.Tran
 	})
 
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	// Log audit event for split transaction after successful database transaction
-	if ts.transactionAudits != nil && originalTransaction != nil {
-		// Get bank statement ID
-		bankStatementId := originalTransaction.StatementId
+	// Both events form a single undo step that restores the original and removes the second split
+	if ts.transactionAudits != nil {
+		if source == types.SourceUser {
+			ts.undo.Group(fmt.Sprintf("Split '%s'", parent.Description), func() error {
+				ts.recordSplitAuditEvents(parent, splits, secondSplitId, source)
+				return nil
+			})
+		} else {
+			ts.recordSplitAuditEvents(parent, splits, secondSplitId, source)
+		}
+	}
+
+	return secondSplitId, nil
+}
+
+// recordSplitAuditEvents records split events for the first split (the original row) and the new second split
+func (ts *TransactionStore) recordSplitAuditEvents(originalTransaction *types.Transaction, splits []types.Transaction, secondSplitId int64, source string) {
+	// Get bank statement ID
+	bankStatementId := originalTransaction.StatementId
 
-		// Create split audit event for parent (first split)
-		parentAuditEvent := &types.TransactionAuditEvent{
-			TransactionId:          parentId,
+	// Create split audit event for parent (first split)
+	parentAuditEvent := &types.TransactionAuditEvent{
+		TransactionId:          originalTransaction.Id,
+		BankStatementId:        bankStatementId,
+		Timestamp:              time.Now(),
+		ActionType:             types.ActionTypeSplit,
+		Source:                 source,
+		DescriptionFingerprint: splits[0].Description,
+		CategoryAssigned:       splits[0].CategoryId,
+		CategoryConfidence:     1.0,
+		PreviousCategory:       originalTransaction.CategoryId,
+		PreEditSnapshot:        types.EncodeTransactionSnapshot(originalTransaction),
+		PostEditSnapshot:       types.EncodeTransactionSnapshot(ts.GetTransactionByID(originalTransaction.Id)),
+	}
+
+	ts.recordAuditEvent(parentAuditEvent)
+
+	// Create split audit event for second split (newly created transaction, so it has no pre-edit state)
+	if secondSplitId > 0 {
+		secondSplitAuditEvent := &types.TransactionAuditEvent{
+			TransactionId:          secondSplitId,
 			BankStatementId:        bankStatementId,
 			Timestamp:              time.Now(),
 			ActionType:             types.ActionTypeSplit,
-			Source:                 types.SourceUser,
-			DescriptionFingerprint: splits[0].Description,
-			CategoryAssigned:       splits[0].CategoryId,
+			Source:                 source,
+			DescriptionFingerprint: splits[1].Description,
+			CategoryAssigned:       splits[1].CategoryId,
 			CategoryConfidence:     1.0,
 			PreviousCategory:       originalTransaction.CategoryId,
+			PostEditSnapshot:       types.EncodeTransactionSnapshot(ts.GetTransactionByID(secondSplitId)),
 		}
 
-		ts.transactionAudits.RecordEvent(parentAuditEvent)
-
-		// Create split audit event for second split (newly created transaction)
-		if secondSplitId > 0 {
-			secondSplitAuditEvent := &types.TransactionAuditEvent{
-				TransactionId:          secondSplitId,
-				BankStatementId:        bankStatementId,
-				Timestamp:              time.Now(),
-				ActionType:             types.ActionTypeSplit,
-				Source:                 types.SourceUser,
-				DescriptionFingerprint: splits[1].Description,
-				CategoryAssigned:       splits[1].CategoryId,
-				CategoryConfidence:     1.0,
-				PreviousCategory:       originalTransaction.CategoryId,
-			}
-
-			ts.transactionAudits.RecordEvent(secondSplitAuditEvent)
-		}
+		ts.recordAuditEvent(secondSplitAuditEvent)
 	}
-
-	return nil
 }
 
 // ImportTransactionsFromCSV imports a batch of transactions from CSV parsing
 func (ts *TransactionStore) ImportTransactionsFromCSV(transactions []types.Transaction, statementId int64) error {
+	return ts.ImportTransactionsFromCSVContext(context.Background(), transactions, statementId, nil)
+}
+
+// ImportTransactionsFromCSVContext imports a batch of transactions, reporting rows inserted
+// The rows are written in one database transaction, so cancelling ctx before it commits writes nothing.
+func (ts *TransactionStore) ImportTransactionsFromCSVContext(ctx context.Context, transactions []types.Transaction, statementId int64, progress types.ProgressFunc) error {
 	if len(transactions) == 0 {
 		return nil
 	}
@@ -562,53 +654,64 @@ func (ts *TransactionStore) ImportTransactionsFromCSV(transactions []types.Trans
 			rawDescription = tx.RawDescription
 		}
 
+		var accountID interface{}
+		if tx.AccountId != 0 {
+			accountID = tx.AccountId
+		}
+
+		var externalID interface{}
+		if tx.ExternalId != "" {
+			externalID = tx.ExternalId
+		}
+
 		record := []interface{}{
-			parentID, tx.Amount, tx.Description, rawDescription, dateStr,
+			parentID, tx.Amount.Cents, currencyCode(tx.Amount), tx.Description, rawDescription, dateStr,
 			tx.CategoryId, transactionType, tx.IsSplit,
-			statementID, createdAtStr, updatedAtStr,
+			statementID, accountID, createdAtStr, updatedAtStr, externalID,
 		}
 		records = append(records, record)
 	}
 
 	// Bulk insert using transaction
 	fields := []string{
-		"parent_id", "amount", "description", "raw_description", "date",
+		"parent_id", "amount_cents", "currency", "description", "raw_description", "date",
 		"category_id", "transaction_type", "is_split",
-		"statement_id", "created_at", "updated_at",
+		"statement_id", "account_id", "created_at", "updated_at", "external_id",
 	}
 
-	err := ts.helper.BulkInsert("transactions", fields, records)
+	reporter := newProgressReporter(progress, types.ProgressInserting, len(records))
+	reporter.start()
+	err := ts.helper.BulkInsertContext(ctx, "transactions", fields, records, reporter.update)
 	if err != nil {
 		return err
 	}
 
 	// Create audit events for imported transactions with ML prediction tracking
+	// The rows are committed at this point, so this stage runs to completion even if ctx is cancelled
 	if ts.transactionAudits != nil {
-		ts.debugLogger.Printf("[DEBUG] Creating audit events for %d imported transactions", len(transactions))
-		err = ts.createImportAuditEvents(transactions, statementId)
+		slog.Debug("creating import audit events", "transactions", len(transactions), "statement_id", statementId)
+		err = ts.createImportAuditEvents(transactions, statementId, progress)
 		if err != nil {
 			// Log error but don't fail the import - audit is supplementary
-			ts.debugLogger.Printf("[Warning] Failed to create import audit events: %v", err)
+			slog.Warn("failed to create import audit events", "statement_id", statementId, "error", err)
 		}
 	} else {
-		ts.debugLogger.Printf("[WARNING] TransactionAudits store is nil - audit events will not be created")
+		slog.Warn("transaction audit store not set; import audit events will not be created")
 	}
 
 	return nil
 }
 
 // FindDuplicateTransactions finds existing transactions that match date, amount, and description
-func (ts *TransactionStore) FindDuplicateTransactions(date string, amount float64, description string) ([]types.Transaction, error) {
+func (ts *TransactionStore) FindDuplicateTransactions(date string, amount types.Money, description string) ([]types.Transaction, error) {
 	query := `
-		SELECT id, parent_id, amount, description, raw_description, date, 
-		       category_id, transaction_type, is_split, 
-		       statement_id, created_at, updated_at 
+		SELECT ` + transactionColumns + `
 		FROM transactions 
-		WHERE date = ? AND ABS(amount - ?) < 0.01 AND description = ?
+		WHERE date = ? AND amount_cents = ? AND currency = ? AND description = ? AND deleted_at IS NULL
 		ORDER BY id
 	`
 
-	rows, err := ts.helper.QueryRows(query, date, amount, description)
+	rows, err := ts.helper.QueryRows(query, date, amount.Cents, currencyCode(amount), description)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query duplicate transactions: %w", err)
 	}
@@ -626,6 +729,42 @@ func (ts *TransactionStore) FindDuplicateTransactions(date string, amount float6
 	return duplicates, rows.Err()
 }
 
+// statementTransactionIds returns the IDs of a statement's transactions in the order they were inserted
+func (ts *TransactionStore) statementTransactionIds(statementId int64) ([]int64, error) {
+	rows, err := ts.helper.QueryRows("SELECT id FROM transactions WHERE statement_id = ? ORDER BY id", statementId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query statement transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ExternalIdExists reports whether an account already holds a transaction with the bank's own ID
+// Trashed transactions count, so re-importing a statement does not bring back deleted rows.
+func (ts *TransactionStore) ExternalIdExists(accountId int64, externalId string) (bool, error) {
+	if accountId == 0 {
+		return ts.helper.ExistsBy("transactions", "account_id IS NULL AND external_id = ?", externalId)
+	}
+	return ts.helper.ExistsBy("transactions", "account_id = ? AND external_id = ?", accountId, externalId)
+}
+
+// currencyCode returns the currency to persist for an amount, defaulting when unset
+func currencyCode(amount types.Money) string {
+	if amount.Currency == "" {
+		return types.DefaultCurrency
+	}
+	return amount.Currency
+}
+
 // parseFlexibleDate tries multiple date formats to handle legacy data
 func (ts *TransactionStore) parseFlexibleDate(dateStr string) (time.Time, error) {
 	// Try RFC3339 format first (preferred format)
@@ -652,28 +791,30 @@ func (ts *TransactionStore) parseFlexibleDate(dateStr string) (time.Time, error)
 }
 
 // createImportAuditEvents creates audit events for imported transactions with ML prediction tracking
-func (ts *TransactionStore) createImportAuditEvents(transactions []types.Transaction, statementId int64) error {
-	ts.debugLogger.Printf("[DEBUG] createImportAuditEvents called with %d transactions, statementId=%d", len(transactions), statementId)
-
+func (ts *TransactionStore) createImportAuditEvents(transactions []types.Transaction, statementId int64, progress types.ProgressFunc) error {
 	// Use statement ID directly
 	bankStatementId := statementId
-	ts.debugLogger.Printf("[DEBUG] Parsed bankStatementId: %d", bankStatementId)
+
+	reporter := newProgressReporter(progress, types.ProgressAuditing, len(transactions))
+	reporter.start()
 
 	// Query for the actual inserted transactions to get their database IDs
 	// We'll match by statement_id, description, amount, and date to identify each transaction
-	for _, tx := range transactions {
+	for i, tx := range transactions {
+		reporter.update(i + 1)
+
 		// Find the actual inserted transaction by its unique attributes
 		query := `
 			SELECT id FROM transactions 
-			WHERE statement_id = ? AND description = ? AND amount = ? AND date = ?
+			WHERE statement_id = ? AND description = ? AND amount_cents = ? AND date = ?
 			ORDER BY id DESC LIMIT 1
 		`
 
 		var actualTxId int64
 		dateStr := tx.Date.Format("2006-01-02")
-		err := ts.helper.QuerySingleRow(query, bankStatementId, tx.Description, tx.Amount, dateStr).Scan(&actualTxId)
+		err := ts.helper.QuerySingleRow(query, bankStatementId, tx.Description, tx.Amount.Cents, dateStr).Scan(&actualTxId)
 		if err != nil {
-			ts.debugLogger.Printf("[Warning] Could not find inserted transaction ID for %s: %v", tx.Description, err)
+			slog.Warn("could not find imported transaction", "description", tx.Description, "statement_id", bankStatementId, "error", err)
 			continue
 		}
 
@@ -682,104 +823,83 @@ func (ts *TransactionStore) createImportAuditEvents(transactions []types.Transac
 		var confidenceScore float64 = 0.0
 
 		if ts.store != nil && ts.store.MLCategorizer != nil {
-			prediction := ts.store.PredictCategory(tx.Description, tx.Amount)
+			prediction := ts.store.PredictCategory(tx.Description, tx.Amount.Float64())
 			confidenceScore = prediction.Confidence
-			ts.debugLogger.Printf("[DEBUG] ML Prediction for '%s': CategoryId=%d, Confidence=%.2f, Assigned=%d", tx.Description, prediction.CategoryId, prediction.Confidence, tx.CategoryId)
+			slog.Debug("ML prediction for imported transaction", "description", tx.Description,
+				"predicted_category", prediction.CategoryId, "confidence", prediction.Confidence, "assigned_category", tx.CategoryId)
 
 			// Validate confidence score is in expected range
 			if confidenceScore < 0.0 || confidenceScore > 1.0 {
-				ts.debugLogger.Printf("[WARNING] ML confidence score out of range (0.0-1.0): %.2f for '%s'", confidenceScore, tx.Description)
+				slog.Warn("ML confidence score out of range", "confidence", confidenceScore, "description", tx.Description)
 				confidenceScore = 0.0 // Reset to safe value
 			}
 
 			// If ML made a high confidence prediction and it matches the assigned category, it was auto-categorized
 			if ts.store.IsHighConfidencePrediction(prediction) && prediction.CategoryId == tx.CategoryId {
 				source = types.SourceAuto
-				ts.debugLogger.Printf("[DEBUG] Detected ML auto-categorization for '%s'", tx.Description)
+				slog.Debug("detected ML auto-categorization", "description", tx.Description)
 			}
 		}
 
-		// Create appropriate audit event based on source
-		var postSnapshot *string
-		var modReason *string
-		var preSnapshot *string
+		// Every imported transaction starts its history with an import event; the post snapshot
+		// is the imported version, and there is no pre snapshot because the transaction is new
+		imported := ts.GetTransactionByID(actualTxId)
+		if imported == nil {
+			slog.Error("imported transaction does not exist; cannot create audit event", "transaction_id", actualTxId)
+			continue
+		}
 
-		// Only create audit events for ML auto-categorized transactions
+		// The category reason marks ML auto-categorization for training
+		var modReason *string
 		if source == types.SourceAuto {
-			// For auto-categorization, include the required fields
-			postSnapshotStr := fmt.Sprintf(`{"id":%d,"amount":%.2f,"description":"%s","category":%d,"type":"%s","date":"%s"}`,
-				actualTxId, tx.Amount, tx.Description, tx.CategoryId, tx.TransactionType, tx.Date.Format("2006-01-02"))
-			postSnapshot = &postSnapshotStr
-
 			modReasonStr := types.ModReasonCategory
 			modReason = &modReasonStr
+		}
 
-			preSnapshotStr := ""
-			preSnapshot = &preSnapshotStr
-
-			auditEvent := &types.TransactionAuditEvent{
-				TransactionId:          actualTxId, // Use the actual database ID
-				BankStatementId:        bankStatementId,
-				Timestamp:              time.Now(),
-				ActionType:             types.ActionTypeImport,
-				Source:                 source, // 'auto' for ML categorization
-				DescriptionFingerprint: tx.Description,
-				CategoryAssigned:       tx.CategoryId,
-				CategoryConfidence:     confidenceScore, // Use actual ML confidence score
-				PreviousCategory:       tx.CategoryId,   // Same as assigned for new imports (no previous state)
-				ModificationReason:     modReason,       // Set for auto-categorization
-				PreEditSnapshot:        preSnapshot,     // Set for auto-categorization
-				PostEditSnapshot:       postSnapshot,    // Set for auto-categorization
-			}
-
-			// Record the audit event
-			ts.debugLogger.Printf("[DEBUG] Creating audit event: source=%s, confidence=%.2f, categoryId=%d, previousCategory=%d",
-				source, confidenceScore, tx.CategoryId, tx.CategoryId)
-
-			// Validate foreign key references before creating audit event
-			ts.debugLogger.Printf("[DEBUG] Validating audit event references - TxId:%d, StatementId:%d, CategoryId:%d",
-				actualTxId, bankStatementId, tx.CategoryId)
-
-			// Check if transaction exists
-			if txExists := ts.GetTransactionByID(actualTxId); txExists == nil {
-				ts.debugLogger.Printf("[ERROR] Transaction ID %d does not exist - cannot create audit event", actualTxId)
-				continue
-			}
+		auditEvent := &types.TransactionAuditEvent{
+			TransactionId:          actualTxId, // Use the actual database ID
+			BankStatementId:        bankStatementId,
+			Timestamp:              time.Now(),
+			ActionType:             types.ActionTypeImport,
+			Source:                 source, // 'auto' for ML categorization, 'import' otherwise
+			DescriptionFingerprint: tx.Description,
+			CategoryAssigned:       tx.CategoryId,
+			CategoryConfidence:     confidenceScore, // Use actual ML confidence score
+			PreviousCategory:       tx.CategoryId,   // Same as assigned for new imports (no previous state)
+			ModificationReason:     modReason,
+			PostEditSnapshot:       types.EncodeTransactionSnapshot(imported),
+		}
 
-			// Check if bank statement exists (if StatementId > 0)
-			if bankStatementId > 0 {
-				checkStmtQuery := "SELECT COUNT(*) FROM bank_statements WHERE id = ?"
-				var stmtCount int
-				err := ts.helper.QuerySingleRow(checkStmtQuery, bankStatementId).Scan(&stmtCount)
-				if err != nil || stmtCount == 0 {
-					ts.debugLogger.Printf("[ERROR] Bank statement ID %d does not exist - cannot create audit event", bankStatementId)
-					continue
-				}
-			}
+		// Record the audit event
+		// Validate foreign key references before creating audit event
 
-			// Check if category exists
-			checkCatQuery := "SELECT COUNT(*) FROM categories WHERE id = ?"
-			var catCount int
-			err = ts.helper.QuerySingleRow(checkCatQuery, tx.CategoryId).Scan(&catCount)
-			if err != nil || catCount == 0 {
-				ts.debugLogger.Printf("[ERROR] Category ID %d does not exist - cannot create audit event", tx.CategoryId)
+		// Check if bank statement exists (if StatementId > 0)
+		if bankStatementId > 0 {
+			checkStmtQuery := "SELECT COUNT(*) FROM bank_statements WHERE id = ?"
+			var stmtCount int
+			err := ts.helper.QuerySingleRow(checkStmtQuery, bankStatementId).Scan(&stmtCount)
+			if err != nil || stmtCount == 0 {
+				slog.Error("bank statement does not exist; cannot create audit event", "statement_id", bankStatementId)
 				continue
 			}
+		}
 
-			ts.debugLogger.Printf("[DEBUG] All foreign key references validated successfully")
+		// Check if category exists
+		checkCatQuery := "SELECT COUNT(*) FROM categories WHERE id = ?"
+		var catCount int
+		err = ts.helper.QuerySingleRow(checkCatQuery, tx.CategoryId).Scan(&catCount)
+		if err != nil || catCount == 0 {
+			slog.Error("category does not exist; cannot create audit event", "category_id", tx.CategoryId)
+			continue
+		}
 
-			err = ts.transactionAudits.RecordEvent(auditEvent)
-			if err != nil {
-				// Log individual failures but continue with other events
-				ts.debugLogger.Printf("[ERROR] Failed to create audit event for transaction %s: %v", tx.Description, err)
-				ts.debugLogger.Printf("[ERROR] Audit event details - TxId:%d, StatementId:%d, CategoryId:%d, Source:%s",
-					actualTxId, bankStatementId, tx.CategoryId, source)
-			} else {
-				ts.debugLogger.Printf("[ML] Created audit event for auto-categorized transaction: %s → Category %d", tx.Description, tx.CategoryId)
-			}
+		err = ts.transactionAudits.RecordEvent(auditEvent)
+		if err != nil {
+			// Log individual failures but continue with other events
+			slog.Error("failed to create import audit event", "transaction_id", actualTxId, "statement_id", bankStatementId,
+				"category_id", tx.CategoryId, "source", source, "error", err)
 		} else {
-			// Regular import transactions don't need audit events
-			ts.debugLogger.Printf("[DEBUG] Skipping audit event for regular import transaction: %s", tx.Description)
+			slog.Debug("created import audit event", "transaction_id", actualTxId, "source", source, "confidence", confidenceScore)
 		}
 	}
 
@@ -0,0 +1,459 @@
+package storage
+
+import (
+	"budget-tracker-tui/internal/types"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// undoHistoryLimit caps how many actions can be undone
+const undoHistoryLimit = 100
+
+// transactionActionLabels names audit event actions in undo labels
+var transactionActionLabels = map[string]string{
+	types.ActionTypeEdit:    "Edit",
+	types.ActionTypeSplit:   "Split",
+	types.ActionTypeDelete:  "Delete",
+	types.ActionTypeRestore: "Restore",
+}
+
+// UndoChange is one recorded change inside an undoable action
+// Transaction changes point at the audit event holding their pre/post snapshots. Categories have
+// no audit events, so category changes carry their before/after state directly.
+type UndoChange struct {
+	AuditEventId   int64
+	TransactionId  int64
+	CategoryId     int64
+	CategoryBefore *types.Category // nil when the change created the category
+	CategoryAfter  *types.Category // nil when the change deleted the category
+
+	// Snapshots read from the audit event, kept because undoing a split removes the event with its row
+	snapshotsLoaded bool
+	preSnapshot     *types.TransactionSnapshot
+	postSnapshot    *types.TransactionSnapshot
+}
+
+// UndoEntry is one user action; undo and redo apply all of its changes together
+type UndoEntry struct {
+	Label   string
+	Changes []UndoChange
+}
+
+// UndoManager keeps the undo and redo stacks for user changes in the open ledger
+// Stores record into it as they write; methods on a nil manager record nothing.
+type UndoManager struct {
+	transactions *TransactionStore
+	categories   *CategoryStore
+	audits       *TransactionAuditStore
+
+	undoStack []UndoEntry
+	redoStack []UndoEntry
+
+	group      *UndoEntry // Open group collecting changes into one entry
+	groupDepth int
+	replaying  bool // Set while undoing or redoing so replayed writes are not recorded again
+}
+
+// NewUndoManager creates an empty undo history for the given stores
+func NewUndoManager(transactions *TransactionStore, categories *CategoryStore, audits *TransactionAuditStore) *UndoManager {
+	return &UndoManager{
+		transactions: transactions,
+		categories:   categories,
+		audits:       audits,
+	}
+}
+
+// CanUndo reports whether there is an action to undo
+func (um *UndoManager) CanUndo() bool {
+	return um != nil && len(um.undoStack) > 0
+}
+
+// CanRedo reports whether there is an undone action to redo
+func (um *UndoManager) CanRedo() bool {
+	return um != nil && len(um.redoStack) > 0
+}
+
+// UndoLabel describes the action Undo would revert, or "" when there is none
+func (um *UndoManager) UndoLabel() string {
+	if !um.CanUndo() {
+		return ""
+	}
+	return um.undoStack[len(um.undoStack)-1].Label
+}
+
+// RedoLabel describes the action Redo would reapply, or "" when there is none
+func (um *UndoManager) RedoLabel() string {
+	if !um.CanRedo() {
+		return ""
+	}
+	return um.redoStack[len(um.redoStack)-1].Label
+}
+
+// Group runs fn and records every change it makes as a single undoable action
+// Nested groups join the outermost one. Changes made before fn fails are still recorded.
+func (um *UndoManager) Group(label string, fn func() error) error {
+	if um == nil || um.replaying {
+		return fn()
+	}
+
+	if um.groupDepth == 0 {
+		um.group = &UndoEntry{Label: label}
+	}
+	um.groupDepth++
+	err := fn()
+	um.groupDepth--
+
+	if um.groupDepth == 0 {
+		group := um.group
+		um.group = nil
+		um.push(*group)
+	}
+	return err
+}
+
+// Clear forgets the undo and redo history
+func (um *UndoManager) Clear() {
+	if um == nil {
+		return
+	}
+	um.undoStack = nil
+	um.redoStack = nil
+}
+
+// record adds a change to the open group, or as its own action when no group is open
+func (um *UndoManager) record(label string, change UndoChange) {
+	if um == nil || um.replaying {
+		return
+	}
+	if um.group != nil {
+		um.group.Changes = append(um.group.Changes, change)
+		return
+	}
+	um.push(UndoEntry{Label: label, Changes: []UndoChange{change}})
+}
+
+// push adds a new action to the undo stack; any new action makes the redo stack stale
+func (um *UndoManager) push(entry UndoEntry) {
+	if len(entry.Changes) == 0 {
+		return
+	}
+	um.undoStack = append(um.undoStack, entry)
+	if len(um.undoStack) > undoHistoryLimit {
+		um.undoStack = um.undoStack[len(um.undoStack)-undoHistoryLimit:]
+	}
+	um.redoStack = nil
+}
+
+// recordTransactionEvent records a user change to a transaction by its audit event
+func (um *UndoManager) recordTransactionEvent(event *types.TransactionAuditEvent) {
+	verb, ok := transactionActionLabels[event.ActionType]
+	if !ok {
+		verb = "Change"
+	}
+	um.record(fmt.Sprintf("%s '%s'", verb, event.DescriptionFingerprint),
+		UndoChange{AuditEventId: event.Id, TransactionId: event.TransactionId})
+}
+
+// recordCategoryChange records a category edit or delete with its before and after state
+func (um *UndoManager) recordCategoryChange(label string, before, after *types.Category) {
+	change := UndoChange{CategoryBefore: before, CategoryAfter: after}
+	if before != nil {
+		change.CategoryId = before.Id
+	} else if after != nil {
+		change.CategoryId = after.Id
+	}
+	um.record(label, change)
+}
+
+// Undo reverts the most recent action and returns its label
+// All of its changes are applied in one database transaction. If any of them fails nothing is
+// reverted and the action stays on the undo stack (e.g. when its transaction was purged).
+func (um *UndoManager) Undo() (string, error) {
+	if !um.CanUndo() {
+		return "", fmt.Errorf("nothing to undo")
+	}
+
+	entry := um.undoStack[len(um.undoStack)-1]
+	um.undoStack = um.undoStack[:len(um.undoStack)-1]
+
+	if err := um.replay(&entry, true); err != nil {
+		um.undoStack = append(um.undoStack, entry)
+		return entry.Label, fmt.Errorf("could not undo %s: %w", entry.Label, err)
+	}
+
+	um.redoStack = append(um.redoStack, entry)
+	return entry.Label, nil
+}
+
+// Redo reapplies the most recently undone action and returns its label
+// Like Undo, either every change is reapplied or the action stays on the redo stack.
+func (um *UndoManager) Redo() (string, error) {
+	if !um.CanRedo() {
+		return "", fmt.Errorf("nothing to redo")
+	}
+
+	entry := um.redoStack[len(um.redoStack)-1]
+	um.redoStack = um.redoStack[:len(um.redoStack)-1]
+
+	if err := um.replay(&entry, false); err != nil {
+		um.redoStack = append(um.redoStack, entry)
+		return entry.Label, fmt.Errorf("could not redo %s: %w", entry.Label, err)
+	}
+
+	um.undoStack = append(um.undoStack, entry)
+	return entry.Label, nil
+}
+
+// replayAudits collects the audit writes of a replayed action, to run once it is committed
+type replayAudits []func()
+
+// add queues an audit write
+func (ra *replayAudits) add(record func()) {
+	*ra = append(*ra, record)
+}
+
+// replay applies every change of entry in one database transaction, last change first when undoing
+// Snapshots are read before the transaction opens and the audit log is written after it commits,
+// so a change that fails leaves both the ledger and its history as they were.
+func (um *UndoManager) replay(entry *UndoEntry, undo bool) error {
+	um.replaying = true
+	defer func() { um.replaying = false }()
+
+	for i := range entry.Changes {
+		if err := um.loadSnapshots(&entry.Changes[i]); err != nil {
+			return err
+		}
+	}
+
+	var audits replayAudits
+	err := um.transactions.db.ExecuteInTransaction(func(dbTx *sql.Tx) error {
+		audits = nil
+		for n := range entry.Changes {
+			i := n
+			if undo {
+				i = len(entry.Changes) - 1 - n
+			}
+			if err := um.apply(dbTx, &entry.Changes[i], undo, &audits); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, record := range audits {
+		record()
+	}
+	return nil
+}
+
+// apply puts the changed record into its state before (undo) or after (redo) the change through db
+func (um *UndoManager) apply(db sqlQuerier, change *UndoChange, undo bool, audits *replayAudits) error {
+	// A record may only be recreated when the change itself created or removed it
+	if change.AuditEventId == 0 {
+		target, other := change.CategoryAfter, change.CategoryBefore
+		if undo {
+			target, other = other, target
+		}
+		return um.categories.applyCategorySnapshot(db, change.CategoryId, target, other == nil, audits)
+	}
+
+	target, other := change.postSnapshot, change.preSnapshot
+	if undo {
+		target, other = other, target
+	}
+	return um.transactions.applyTransactionSnapshot(db, change.TransactionId, target, other == nil, audits)
+}
+
+// loadSnapshots reads a transaction change's pre/post snapshots from its audit event
+// Category changes carry their state directly and need nothing loaded.
+func (um *UndoManager) loadSnapshots(change *UndoChange) error {
+	if change.snapshotsLoaded || change.AuditEventId == 0 {
+		return nil
+	}
+
+	event, err := um.audits.GetEventByID(change.AuditEventId)
+	if err != nil {
+		return err
+	}
+	if change.preSnapshot, err = types.DecodeTransactionSnapshot(event.PreEditSnapshot); err != nil {
+		return err
+	}
+	if change.postSnapshot, err = types.DecodeTransactionSnapshot(event.PostEditSnapshot); err != nil {
+		return err
+	}
+
+	change.snapshotsLoaded = true
+	return nil
+}
+
+// applyTransactionSnapshot puts a transaction into the state captured by an audit snapshot
+// A nil snapshot means the transaction did not exist, so the row is removed.
+func (ts *TransactionStore) applyTransactionSnapshot(db sqlQuerier, id int64, snapshot *types.TransactionSnapshot, allowCreate bool, audits *replayAudits) error {
+	current := ts.getTransaction(db, id)
+	if snapshot == nil {
+		if current == nil {
+			return nil
+		}
+		if _, err := db.Exec("DELETE FROM transactions WHERE id = ?", id); err != nil {
+			return fmt.Errorf("failed to remove transaction %d: %w", id, err)
+		}
+		purged := []types.Transaction{*current}
+		audits.add(func() { ts.recordPurged(purged, types.SourceUser) })
+		return nil
+	}
+
+	tx, err := snapshot.Transaction()
+	if err != nil {
+		return err
+	}
+	tx.Id = id
+
+	now := time.Now()
+	if current == nil {
+		if !allowCreate {
+			return fmt.Errorf("transaction %d no longer exists", id)
+		}
+		if err := ts.insertTransaction(db, tx, now); err != nil {
+			return err
+		}
+		if current = ts.getTransaction(db, id); current == nil {
+			return fmt.Errorf("transaction %d could not be recreated", id)
+		}
+	} else if !sameSnapshotFields(types.NewTransactionSnapshot(*current), *snapshot) {
+		if err := ts.updateTransactionRow(db, tx, now); err != nil {
+			return err
+		}
+		before := current
+		if current = ts.getTransaction(db, id); current == nil {
+			return fmt.Errorf("transaction %d could not be updated", id)
+		}
+		event := newEditAuditEvent(before, current)
+		audits.add(func() { ts.recordAuditEvent(event) })
+	}
+
+	// Trash state goes through the delete/restore updates so split children follow their parent
+	var actionType string
+	switch {
+	case snapshot.Deleted && current.DeletedAt == nil:
+		actionType = types.ActionTypeDelete
+		err = ts.trashTransaction(db, id)
+	case !snapshot.Deleted && current.DeletedAt != nil:
+		actionType = types.ActionTypeRestore
+		err = ts.restoreTrashed(db, current)
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	before, after := current, ts.getTransaction(db, id)
+	audits.add(func() { ts.recordTrashEvent(actionType, before, after) })
+	return nil
+}
+
+// sameSnapshotFields reports whether two snapshots match apart from their trash state
+func sameSnapshotFields(a, b types.TransactionSnapshot) bool {
+	if (a.ParentId == nil) != (b.ParentId == nil) || (a.ParentId != nil && *a.ParentId != *b.ParentId) {
+		return false
+	}
+	a.ParentId, b.ParentId = nil, nil
+	a.Deleted, b.Deleted = false, false
+	return a == b
+}
+
+// applyCategorySnapshot puts a category into a recorded state through db; a nil snapshot deletes it
+func (cs *CategoryStore) applyCategorySnapshot(db sqlQuerier, id int64, snapshot *types.Category, allowCreate bool, audits *replayAudits) error {
+	current := cs.getCategoryRecord(db, id)
+	if snapshot == nil {
+		if current == nil {
+			return nil
+		}
+		// Transactions still using the category block the delete through their foreign key
+		var children int
+		if err := db.QueryRow("SELECT COUNT(*) FROM categories WHERE parent_id = ? AND is_active = 1", id).Scan(&children); err != nil {
+			return fmt.Errorf("failed to check for child categories: %w", err)
+		}
+		if children > 0 {
+			return fmt.Errorf("cannot delete category with active child categories")
+		}
+		if _, err := db.Exec("DELETE FROM categories WHERE id = ?", id); err != nil {
+			return fmt.Errorf("failed to delete category: %w", err)
+		}
+		audits.add(func() {
+			cs.resetDefaultCategory(id)
+			cs.audit.recordOrWarn(types.EntityCategory, auditId(id), types.AuditEventDelete, types.SourceUser, current, nil)
+		})
+		return nil
+	}
+
+	if current == nil {
+		if !allowCreate {
+			return fmt.Errorf("category %d no longer exists", id)
+		}
+		if err := cs.insertCategoryRecord(db, *snapshot); err != nil {
+			return err
+		}
+		after := cs.getCategoryRecord(db, id)
+		audits.add(func() {
+			cs.audit.recordOrWarn(types.EntityCategory, auditId(id), types.AuditEventCreate, types.SourceUser, nil, after)
+		})
+		return nil
+	}
+
+	restored := *snapshot
+	if err := cs.updateCategoryRecord(db, &restored, time.Now()); err != nil {
+		return err
+	}
+	after := cs.getCategoryRecord(db, id)
+	audits.add(func() {
+		cs.audit.recordOrWarn(types.EntityCategory, auditId(id), types.AuditEventUpdate, types.SourceUser, current, after)
+	})
+	return nil
+}
+
+// getCategoryRecord returns a category by ID through db whether or not it is active, or nil if it does not exist
+func (cs *CategoryStore) getCategoryRecord(db sqlQuerier, categoryId int64) *types.Category {
+	query := `
+		SELECT id, display_name, parent_id, color, is_active, created_at, updated_at
+		FROM categories
+		WHERE id = ?
+	`
+
+	category, err := cs.scanCategoryRow(db.QueryRow(query, categoryId))
+	if err != nil {
+		return nil
+	}
+	return &category
+}
+
+// insertCategoryRecord recreates a deleted category with its original ID through db
+func (cs *CategoryStore) insertCategoryRecord(db sqlExecer, category types.Category) error {
+	query := `
+		INSERT INTO categories (id, display_name, parent_id, color, is_active, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	var parentID interface{}
+	if category.ParentId != nil {
+		parentID = *category.ParentId
+	}
+
+	var color interface{}
+	if category.Color != "" {
+		color = category.Color
+	}
+
+	_, err := db.Exec(query,
+		category.Id, category.DisplayName, parentID, color, category.IsActive,
+		category.CreatedAt.Format(time.RFC3339), time.Now().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to recreate category: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,342 @@
+package storage
+
+import (
+	"budget-tracker-tui/internal/database"
+	"budget-tracker-tui/internal/types"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// setupUndoTestStore returns a test store with an undo manager wired like production
+func setupUndoTestStore(t *testing.T) (*Store, *database.Connection) {
+	t.Helper()
+
+	transactions, conn := setupTestStore(t)
+	store := transactions.store
+	store.Undo = NewUndoManager(store.Transactions, store.Categories, store.TransactionAudits)
+	store.Transactions.SetUndoManager(store.Undo)
+	store.Categories.SetUndoManager(store.Undo)
+	return store, conn
+}
+
+// saveUndoTestTransaction saves a transaction and returns it as stored
+func saveUndoTestTransaction(t *testing.T, store *Store, categoryId int64, cents int64, description string) types.Transaction {
+	t.Helper()
+
+	tx := createTestTransaction(0, description, categoryId)
+	tx.Amount = types.NewMoney(cents, types.DefaultCurrency)
+	if err := store.Transactions.SaveTransaction(tx); err != nil {
+		t.Fatalf("Failed to save '%s': %v", description, err)
+	}
+	saved, err := store.Transactions.QueryTransactions(TransactionQuery{Limit: 1, Sort: SortDateDesc})
+	if err != nil || len(saved) == 0 {
+		t.Fatalf("Failed to read back '%s': %v", description, err)
+	}
+	return saved[0]
+}
+
+func TestUndoRedoTransactionEdit(t *testing.T) {
+	store, conn := setupUndoTestStore(t)
+	defer teardownTestDB(t, conn)
+
+	groceries := createTestCategory(t, conn, "Groceries")
+	dining := createTestCategory(t, conn, "Dining")
+	original := saveUndoTestTransaction(t, store, groceries, -2500, "Corner store")
+
+	if store.Undo.CanUndo() {
+		t.Error("Creating a transaction should not be undoable")
+	}
+
+	edited := original
+	edited.Description = `Joe's "famous" diner`
+	edited.CategoryId = dining
+	if err := store.Transactions.SaveTransaction(edited); err != nil {
+		t.Fatalf("Failed to edit transaction: %v", err)
+	}
+
+	if label := store.Undo.UndoLabel(); label != `Edit 'Joe's "famous" diner'` {
+		t.Errorf("Unexpected undo label %q", label)
+	}
+
+	// Snapshots are real JSON, so quotes in descriptions survive the round trip
+	if _, err := store.Undo.Undo(); err != nil {
+		t.Fatalf("Undo() failed: %v", err)
+	}
+	current := store.Transactions.GetTransactionByID(original.Id)
+	if current.Description != "Corner store" || current.CategoryId != groceries {
+		t.Errorf("Expected original transaction after undo, got '%s' in category %d", current.Description, current.CategoryId)
+	}
+	if store.Undo.CanUndo() || !store.Undo.CanRedo() {
+		t.Error("Expected only a redo to be available after undoing the only action")
+	}
+
+	if _, err := store.Undo.Redo(); err != nil {
+		t.Fatalf("Redo() failed: %v", err)
+	}
+	current = store.Transactions.GetTransactionByID(original.Id)
+	if current.Description != `Joe's "famous" diner` || current.CategoryId != dining {
+		t.Errorf("Expected edit to be reapplied, got '%s' in category %d", current.Description, current.CategoryId)
+	}
+
+	// A new change after an undo discards the redo history
+	if _, err := store.Undo.Undo(); err != nil {
+		t.Fatalf("Undo() failed: %v", err)
+	}
+	current.Amount = types.NewMoney(-3000, types.DefaultCurrency)
+	if err := store.Transactions.SaveTransaction(*current); err != nil {
+		t.Fatalf("Failed to edit transaction: %v", err)
+	}
+	if store.Undo.CanRedo() {
+		t.Error("Expected a new change to clear the redo history")
+	}
+	if _, err := store.Undo.Redo(); err == nil {
+		t.Error("Expected Redo() with an empty history to fail")
+	}
+}
+
+func TestUndoRedoSplit(t *testing.T) {
+	store, conn := setupUndoTestStore(t)
+	defer teardownTestDB(t, conn)
+
+	categoryId := createTestCategory(t, conn, "Shopping")
+	original := saveUndoTestTransaction(t, store, categoryId, -10000, "Warehouse club")
+
+	splits := []types.Transaction{
+		{Amount: types.NewMoney(-6000, types.DefaultCurrency), Description: "Groceries part", CategoryId: categoryId},
+		{Amount: types.NewMoney(-4000, types.DefaultCurrency), Description: "Household part", CategoryId: categoryId},
+	}
+	if err := store.Transactions.SplitTransaction(original.Id, splits); err != nil {
+		t.Fatalf("SplitTransaction() failed: %v", err)
+	}
+	afterSplit, _ := store.Transactions.GetTransactions()
+	if len(afterSplit) != 2 {
+		t.Fatalf("Expected 2 transactions after split, got %d", len(afterSplit))
+	}
+	var secondId int64
+	for _, tx := range afterSplit {
+		if tx.Id != original.Id {
+			secondId = tx.Id
+		}
+	}
+
+	if _, err := store.Undo.Undo(); err != nil {
+		t.Fatalf("Undo() failed: %v", err)
+	}
+	transactions, _ := store.Transactions.GetTransactions()
+	if len(transactions) != 1 {
+		t.Fatalf("Expected the split to be undone as one action, got %d transactions", len(transactions))
+	}
+	if transactions[0].Amount.Cents != -10000 || transactions[0].IsSplit || transactions[0].Description != "Warehouse club" {
+		t.Errorf("Expected original transaction back, got %s '%s' (split %v)",
+			transactions[0].Amount, transactions[0].Description, transactions[0].IsSplit)
+	}
+
+	if _, err := store.Undo.Redo(); err != nil {
+		t.Fatalf("Redo() failed: %v", err)
+	}
+	second := store.Transactions.GetTransactionByID(secondId)
+	if second == nil || second.Description != "Household part" || second.Amount.Cents != -4000 {
+		t.Fatalf("Expected second split to be recreated with its original ID, got %+v", second)
+	}
+	if first := store.Transactions.GetTransactionByID(original.Id); first.Amount.Cents != -6000 || !first.IsSplit {
+		t.Errorf("Expected first split to be reapplied, got %s (split %v)", first.Amount, first.IsSplit)
+	}
+}
+
+func TestUndoGroupedBulkEdit(t *testing.T) {
+	store, conn := setupUndoTestStore(t)
+	defer teardownTestDB(t, conn)
+
+	categoryId := createTestCategory(t, conn, "Misc")
+	var saved []types.Transaction
+	for _, description := range []string{"One", "Two", "Three"} {
+		saved = append(saved, saveUndoTestTransaction(t, store, categoryId, -100, description))
+	}
+
+	err := store.Undo.Group("Bulk edit 3 transactions", func() error {
+		for _, tx := range saved {
+			tx.TransactionType = "transfer"
+			if err := store.Transactions.SaveTransaction(tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Bulk edit failed: %v", err)
+	}
+
+	label, err := store.Undo.Undo()
+	if err != nil {
+		t.Fatalf("Undo() failed: %v", err)
+	}
+	if label != "Bulk edit 3 transactions" {
+		t.Errorf("Expected group label, got %q", label)
+	}
+	if count, _ := store.Transactions.CountTransactions(TransactionQuery{TransactionType: "expense"}); count != 3 {
+		t.Errorf("Expected all 3 edits undone together, got %d expenses", count)
+	}
+	if store.Undo.CanUndo() {
+		t.Error("Expected the bulk edit to be a single undo step")
+	}
+}
+
+func TestUndoRedoDelete(t *testing.T) {
+	store, conn := setupUndoTestStore(t)
+	defer teardownTestDB(t, conn)
+
+	categoryId := createTestCategory(t, conn, "Travel")
+	tx := saveUndoTestTransaction(t, store, categoryId, -5000, "Train")
+
+	if err := store.Transactions.DeleteTransaction(tx.Id); err != nil {
+		t.Fatalf("DeleteTransaction() failed: %v", err)
+	}
+	if _, err := store.Undo.Undo(); err != nil {
+		t.Fatalf("Undo() failed: %v", err)
+	}
+	if restored := store.Transactions.GetTransactionByID(tx.Id); restored.DeletedAt != nil {
+		t.Error("Expected undo to take the transaction out of the trash")
+	}
+
+	if _, err := store.Undo.Redo(); err != nil {
+		t.Fatalf("Redo() failed: %v", err)
+	}
+	if trashed := store.Transactions.GetTransactionByID(tx.Id); trashed.DeletedAt == nil {
+		t.Error("Expected redo to move the transaction back to the trash")
+	}
+
+	// Purging removes the audit trail, so the action can no longer be replayed and stays put
+	if _, err := store.Undo.Undo(); err != nil {
+		t.Fatalf("Undo() failed: %v", err)
+	}
+	if err := store.Transactions.DeleteTransaction(tx.Id); err != nil {
+		t.Fatalf("DeleteTransaction() failed: %v", err)
+	}
+	if err := store.Transactions.PurgeTransaction(tx.Id); err != nil {
+		t.Fatalf("PurgeTransaction() failed: %v", err)
+	}
+	if _, err := store.Undo.Undo(); err == nil || !strings.Contains(err.Error(), "Delete 'Train'") {
+		t.Errorf("Expected undo of a purged transaction to fail, got %v", err)
+	}
+	if store.Transactions.GetTransactionByID(tx.Id) != nil {
+		t.Error("Purged transaction must not be recreated by undo")
+	}
+	if label := store.Undo.UndoLabel(); label != "Delete 'Train'" {
+		t.Errorf("Expected the failed action to stay on the undo stack, got %q", label)
+	}
+}
+
+func TestUndoRedoIsAtomic(t *testing.T) {
+	store, conn := setupUndoTestStore(t)
+	defer teardownTestDB(t, conn)
+
+	categoryId := createTestCategory(t, conn, "Misc")
+	var saved []types.Transaction
+	for _, description := range []string{"One", "Two", "Three"} {
+		saved = append(saved, saveUndoTestTransaction(t, store, categoryId, -100, description))
+	}
+
+	err := store.Undo.Group("Bulk edit 3 transactions", func() error {
+		for _, tx := range saved {
+			tx.TransactionType = "transfer"
+			if err := store.Transactions.SaveTransaction(tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Bulk edit failed: %v", err)
+	}
+
+	// The middle change fails after the last one has been replayed
+	failMiddle := fmt.Sprintf(`CREATE TRIGGER fail_replay BEFORE UPDATE ON transactions WHEN OLD.id = %d
+		BEGIN SELECT RAISE(ABORT, 'disk full'); END`, saved[1].Id)
+	if _, err := conn.DB.Exec(failMiddle); err != nil {
+		t.Fatalf("Failed to create trigger: %v", err)
+	}
+	eventsBefore, _ := store.TransactionAudits.GetRecentEvents(1000)
+
+	if _, err := store.Undo.Undo(); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Fatalf("Expected undo to fail on the middle change, got %v", err)
+	}
+	if count, _ := store.Transactions.CountTransactions(TransactionQuery{TransactionType: "transfer"}); count != 3 {
+		t.Errorf("Expected a failed undo to revert nothing, got %d of 3 transfers left", count)
+	}
+	if label := store.Undo.UndoLabel(); label != "Bulk edit 3 transactions" || store.Undo.CanRedo() {
+		t.Errorf("Expected the action to stay on the undo stack, got undo %q and redo %q", label, store.Undo.RedoLabel())
+	}
+	if events, _ := store.TransactionAudits.GetRecentEvents(1000); len(events) != len(eventsBefore) {
+		t.Errorf("Expected no audit events from a failed undo, got %d new", len(events)-len(eventsBefore))
+	}
+
+	if _, err := conn.DB.Exec("DROP TRIGGER fail_replay"); err != nil {
+		t.Fatalf("Failed to drop trigger: %v", err)
+	}
+	if _, err := store.Undo.Undo(); err != nil {
+		t.Fatalf("Undo() failed once the trigger was dropped: %v", err)
+	}
+	if count, _ := store.Transactions.CountTransactions(TransactionQuery{TransactionType: "expense"}); count != 3 {
+		t.Errorf("Expected all 3 edits undone, got %d expenses", count)
+	}
+
+	if _, err := conn.DB.Exec(failMiddle); err != nil {
+		t.Fatalf("Failed to create trigger: %v", err)
+	}
+	if _, err := store.Undo.Redo(); err == nil {
+		t.Fatal("Expected redo to fail on the middle change")
+	}
+	if count, _ := store.Transactions.CountTransactions(TransactionQuery{TransactionType: "expense"}); count != 3 {
+		t.Errorf("Expected a failed redo to reapply nothing, got %d of 3 expenses left", count)
+	}
+	if label := store.Undo.RedoLabel(); label != "Bulk edit 3 transactions" || store.Undo.CanUndo() {
+		t.Errorf("Expected the action to stay on the redo stack, got redo %q and undo %q", label, store.Undo.UndoLabel())
+	}
+}
+
+func TestUndoRedoCategoryChanges(t *testing.T) {
+	store, conn := setupUndoTestStore(t)
+	defer teardownTestDB(t, conn)
+
+	createTestCategory(t, conn, "Keep")
+	categoryId := createTestCategory(t, conn, "Hobbies")
+
+	category := store.Categories.GetCategoryById(categoryId)
+	category.DisplayName = "Crafts"
+	category.Color = "#FF0000"
+	if err := store.Categories.UpdateCategory(category); err != nil {
+		t.Fatalf("UpdateCategory() failed: %v", err)
+	}
+	if err := store.Categories.DeleteCategory(categoryId); err != nil {
+		t.Fatalf("DeleteCategory() failed: %v", err)
+	}
+
+	if label := store.Undo.UndoLabel(); label != "Delete category 'Crafts'" {
+		t.Errorf("Unexpected undo label %q", label)
+	}
+	if _, err := store.Undo.Undo(); err != nil {
+		t.Fatalf("Undo() of delete failed: %v", err)
+	}
+	restored := store.Categories.GetCategoryById(categoryId)
+	if restored == nil || restored.DisplayName != "Crafts" {
+		t.Fatalf("Expected deleted category to be recreated with its ID, got %+v", restored)
+	}
+
+	if _, err := store.Undo.Undo(); err != nil {
+		t.Fatalf("Undo() of edit failed: %v", err)
+	}
+	restored = store.Categories.GetCategoryById(categoryId)
+	if restored.DisplayName != "Hobbies" || restored.Color != "" {
+		t.Errorf("Expected original name and color, got '%s' %q", restored.DisplayName, restored.Color)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := store.Undo.Redo(); err != nil {
+			t.Fatalf("Redo() failed: %v", err)
+		}
+	}
+	if store.Categories.GetCategoryById(categoryId) != nil {
+		t.Error("Expected redo to delete the category again")
+	}
+}
@@ -2,14 +2,26 @@ package storage
 
 import (
 	"budget-tracker-tui/internal/database"
+	"budget-tracker-tui/internal/types"
 	"database/sql"
 	"fmt"
+	"strconv"
 )
 
+// DefaultTrashRetentionDays is how long deleted transactions stay in the trash unless configured otherwise
+const DefaultTrashRetentionDays = 30
+
 // UserPreferencesStore handles all user preference operations using SQLite
 type UserPreferencesStore struct {
 	db     *database.Connection
 	helper *database.SQLHelper
+	audit  *AuditStore // General audit log of creates, updates and deletes
+}
+
+// preferenceState is a preference as stored in the audit log
+type preferenceState struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
 }
 
 // NewUserPreferencesStore creates a new UserPreferencesStore instance
@@ -20,6 +32,20 @@ func NewUserPreferencesStore(db *database.Connection) *UserPreferencesStore {
 	}
 }
 
+// SetAuditStore sets the audit log that preference changes are recorded in
+func (ups *UserPreferencesStore) SetAuditStore(audit *AuditStore) {
+	ups.audit = audit
+}
+
+// preferenceAuditState returns the current audit state of a preference, or nil if it is not set
+func (ups *UserPreferencesStore) preferenceAuditState(key string) *preferenceState {
+	value, err := ups.GetPreference(key)
+	if err != nil {
+		return nil
+	}
+	return &preferenceState{Key: key, Value: value}
+}
+
 // GetPreference retrieves a preference value by key
 func (ups *UserPreferencesStore) GetPreference(key string) (string, error) {
 	if key == "" {
@@ -49,6 +75,8 @@ func (ups *UserPreferencesStore) SetPreference(key, value string) error {
 		return fmt.Errorf("preference value cannot be empty")
 	}
 
+	before := ups.preferenceAuditState(key)
+
 	// Use UPSERT (INSERT OR REPLACE) to handle both insert and update
 	query := `INSERT OR REPLACE INTO user_preferences (preference_key, preference_value, updated_at) 
 			  VALUES (?, ?, CURRENT_TIMESTAMP)`
@@ -58,6 +86,12 @@ func (ups *UserPreferencesStore) SetPreference(key, value string) error {
 		return fmt.Errorf("failed to set preference %s: %v", key, err)
 	}
 
+	eventType := types.AuditEventUpdate
+	if before == nil {
+		eventType = types.AuditEventCreate
+	}
+	ups.audit.recordOrWarn(types.EntityUserPreferences, key, eventType, types.SourceUser, before, &preferenceState{Key: key, Value: value})
+
 	return nil
 }
 
@@ -67,6 +101,8 @@ func (ups *UserPreferencesStore) DeletePreference(key string) error {
 		return fmt.Errorf("preference key cannot be empty")
 	}
 
+	before := ups.preferenceAuditState(key)
+
 	query := `DELETE FROM user_preferences WHERE preference_key = ?`
 
 	result, err := ups.db.DB.Exec(query, key)
@@ -83,6 +119,8 @@ func (ups *UserPreferencesStore) DeletePreference(key string) error {
 		return fmt.Errorf("preference not found: %s", key)
 	}
 
+	ups.audit.recordOrWarn(types.EntityUserPreferences, key, types.AuditEventDelete, types.SourceUser, before, nil)
+
 	return nil
 }
 
@@ -111,3 +149,39 @@ func (ups *UserPreferencesStore) GetPreferenceWithDefault(key, defaultValue stri
 	}
 	return value
 }
+
+// GetBaseCurrency returns the currency analytics are reported in, falling back to the default currency
+func (ups *UserPreferencesStore) GetBaseCurrency() string {
+	currency, err := types.NormalizeCurrencyCode(ups.GetPreferenceWithDefault("base_currency", types.DefaultCurrency))
+	if err != nil {
+		return types.DefaultCurrency
+	}
+	return currency
+}
+
+// SetBaseCurrency validates and stores the reporting currency
+func (ups *UserPreferencesStore) SetBaseCurrency(currency string) error {
+	normalized, err := types.NormalizeCurrencyCode(currency)
+	if err != nil {
+		return err
+	}
+	return ups.SetPreference("base_currency", normalized)
+}
+
+// GetTrashRetentionDays returns how many days deleted transactions stay in the trash before being purged
+// Zero keeps them until the trash is emptied by hand.
+func (ups *UserPreferencesStore) GetTrashRetentionDays() int {
+	days, err := strconv.Atoi(ups.GetPreferenceWithDefault("trash_retention_days", strconv.Itoa(DefaultTrashRetentionDays)))
+	if err != nil || days < 0 {
+		return DefaultTrashRetentionDays
+	}
+	return days
+}
+
+// SetTrashRetentionDays stores how many days deleted transactions stay in the trash
+func (ups *UserPreferencesStore) SetTrashRetentionDays(days int) error {
+	if days < 0 {
+		return fmt.Errorf("trash retention cannot be negative")
+	}
+	return ups.SetPreference("trash_retention_days", strconv.Itoa(days))
+}
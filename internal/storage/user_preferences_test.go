@@ -512,6 +512,125 @@ func TestGetPreferenceWithDefault(t *testing.T) {
 	}
 }
 
+// TestBaseCurrency tests the base currency convenience accessors
+func TestBaseCurrency(t *testing.T) {
+	tests := []struct {
+		name        string
+		setupData   func(*testing.T, *database.Connection)
+		setValue    string
+		expectError bool
+		expected    string
+	}{
+		{
+			name:      "unset returns default currency",
+			setupData: func(t *testing.T, conn *database.Connection) {},
+			expected:  "USD",
+		},
+		{
+			name:      "set value is normalized",
+			setupData: func(t *testing.T, conn *database.Connection) {},
+			setValue:  " eur ",
+			expected:  "EUR",
+		},
+		{
+			name:        "invalid code is rejected",
+			setupData:   func(t *testing.T, conn *database.Connection) {},
+			setValue:    "EURO",
+			expectError: true,
+			expected:    "USD",
+		},
+		{
+			name: "corrupt stored value falls back to default",
+			setupData: func(t *testing.T, conn *database.Connection) {
+				createTestPreference(t, conn, "base_currency", "not-a-code")
+			},
+			expected: "USD",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, conn := setupTestUserPreferencesStore(t)
+			defer teardownTestDB(t, conn)
+
+			tt.setupData(t, conn)
+
+			if tt.setValue != "" {
+				err := store.SetBaseCurrency(tt.setValue)
+				if tt.expectError && err == nil {
+					t.Error("Expected error, got none")
+				}
+				if !tt.expectError && err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			}
+
+			if result := store.GetBaseCurrency(); result != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestTrashRetentionDays(t *testing.T) {
+	tests := []struct {
+		name        string
+		setupData   func(*testing.T, *database.Connection)
+		setValue    *int
+		expectError bool
+		expected    int
+	}{
+		{
+			name:      "unset returns default retention",
+			setupData: func(t *testing.T, conn *database.Connection) {},
+			expected:  DefaultTrashRetentionDays,
+		},
+		{
+			name:      "zero keeps the trash forever",
+			setupData: func(t *testing.T, conn *database.Connection) {},
+			setValue:  intPtr(0),
+			expected:  0,
+		},
+		{
+			name:        "negative retention is rejected",
+			setupData:   func(t *testing.T, conn *database.Connection) {},
+			setValue:    intPtr(-5),
+			expectError: true,
+			expected:    DefaultTrashRetentionDays,
+		},
+		{
+			name: "corrupt stored value falls back to default",
+			setupData: func(t *testing.T, conn *database.Connection) {
+				createTestPreference(t, conn, "trash_retention_days", "a month")
+			},
+			expected: DefaultTrashRetentionDays,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, conn := setupTestUserPreferencesStore(t)
+			defer teardownTestDB(t, conn)
+
+			tt.setupData(t, conn)
+
+			if tt.setValue != nil {
+				err := store.SetTrashRetentionDays(*tt.setValue)
+				if tt.expectError && err == nil {
+					t.Error("Expected error, got none")
+				}
+				if !tt.expectError && err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			}
+
+			if result := store.GetTrashRetentionDays(); result != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, result)
+			}
+		})
+	}
+}
+
 // TestUserPreferencesIntegration tests the complete workflow
 func TestUserPreferencesIntegration(t *testing.T) {
 	store, conn := setupTestUserPreferencesStore(t)
@@ -1,19 +1,30 @@
 package types
 
+import "time"
+
 // AnalyticsSummary represents aggregated transaction data for reporting
 type AnalyticsSummary struct {
 	DateRange         string
-	TotalIncome       float64
-	TotalExpenses     float64
-	NetAmount         float64
+	TotalIncome       Money
+	TotalExpenses     Money
+	NetAmount         Money
 	TransactionCount  int
 	CategoryBreakdown []CategorySpending
 }
 
 // CategorySpending represents spending breakdown by category
 type CategorySpending struct {
+	CategoryId       int64
 	CategoryName     string
-	Amount           float64
+	Amount           Money
 	Percentage       float64
 	TransactionCount int
 }
+
+// AccountBalance represents an account with its running balance
+type AccountBalance struct {
+	Account          Account
+	Balance          Money
+	TransactionCount int
+	LastActivity     time.Time
+}
@@ -1,6 +1,8 @@
 package types
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 )
@@ -9,26 +11,28 @@ import (
 type TransactionAuditEvent struct {
 	Id                     int64     `db:"id"`
 	TransactionId          int64     `db:"transaction_id"`
-	BankStatementId        int64     `db:"bank_statement_id"`
+	BankStatementId        int64     `db:"bank_statement_id"` // 0 when the transaction is not from a statement
 	Timestamp              time.Time `db:"timestamp"`
-	ActionType             string    `db:"action_type"` // "edit", "import", "split"
+	ActionType             string    `db:"action_type"` // "edit", "import", "split", "delete", "restore"
 	Source                 string    `db:"source"`      // "user", "import", "auto"
 	DescriptionFingerprint string    `db:"description_fingerprint"`
 	CategoryAssigned       int64     `db:"category_assigned"`
 	CategoryConfidence     float64   `db:"category_confidence"` // ML prediction confidence (0.0-1.0)
 	PreviousCategory       int64     `db:"previous_category"`
 	ModificationReason     *string   `db:"modification_reason"` // "description", "transaction type", "category"
-	PreEditSnapshot        *string   `db:"pre_edit_snapshot"`   // json transaction state
-	PostEditSnapshot       *string   `db:"post_edit_snapshot"`  // json transaction state
+	PreEditSnapshot        *string   `db:"pre_edit_snapshot"`   // TransactionSnapshot JSON; nil when the transaction did not exist
+	PostEditSnapshot       *string   `db:"post_edit_snapshot"`  // TransactionSnapshot JSON
 	CreatedAt              time.Time `db:"created_at"`
 }
 
 // TransactionAuditEvent constants
 const (
 	// Action Types
-	ActionTypeEdit   = "edit"
-	ActionTypeImport = "import"
-	ActionTypeSplit  = "split"
+	ActionTypeEdit    = "edit"
+	ActionTypeImport  = "import"
+	ActionTypeSplit   = "split"
+	ActionTypeDelete  = "delete"  // Moved to the trash
+	ActionTypeRestore = "restore" // Restored from the trash
 
 	// Source Types
 	SourceUser   = "user"
@@ -71,7 +75,7 @@ func (tae *TransactionAuditEvent) validateActionType() error {
 		return fmt.Errorf("action type cannot be empty")
 	}
 
-	validTypes := []string{ActionTypeEdit, ActionTypeImport, ActionTypeSplit}
+	validTypes := []string{ActionTypeEdit, ActionTypeImport, ActionTypeSplit, ActionTypeDelete, ActionTypeRestore}
 	for _, validType := range validTypes {
 		if tae.ActionType == validType {
 			return nil
@@ -104,3 +108,232 @@ func (tae *TransactionAuditEvent) validateCategoryConfidence() error {
 	}
 	return nil
 }
+
+// TransactionSnapshot is the transaction state stored as JSON in audit event pre/post snapshots
+// Snapshots hold every persisted field so undo and redo can put a transaction back exactly.
+type TransactionSnapshot struct {
+	Id              int64  `json:"id"`
+	ParentId        *int64 `json:"parent_id,omitempty"`
+	AmountCents     int64  `json:"amount_cents"`
+	Currency        string `json:"currency"`
+	Description     string `json:"description"`
+	RawDescription  string `json:"raw_description,omitempty"`
+	Date            string `json:"date"`
+	CategoryId      int64  `json:"category_id"`
+	TransactionType string `json:"transaction_type"`
+	IsSplit         bool   `json:"is_split"`
+	StatementId     int64  `json:"statement_id,omitempty"`
+	AccountId       int64  `json:"account_id,omitempty"`
+	Deleted         bool   `json:"deleted,omitempty"` // In the trash
+}
+
+// NewTransactionSnapshot captures the persisted state of a transaction
+func NewTransactionSnapshot(tx Transaction) TransactionSnapshot {
+	currency := tx.Amount.Currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	return TransactionSnapshot{
+		Id:              tx.Id,
+		ParentId:        tx.ParentId,
+		AmountCents:     tx.Amount.Cents,
+		Currency:        currency,
+		Description:     tx.Description,
+		RawDescription:  tx.RawDescription,
+		Date:            tx.Date.Format("2006-01-02"),
+		CategoryId:      tx.CategoryId,
+		TransactionType: tx.TransactionType,
+		IsSplit:         tx.IsSplit,
+		StatementId:     tx.StatementId,
+		AccountId:       tx.AccountId,
+		Deleted:         tx.DeletedAt != nil,
+	}
+}
+
+// Transaction returns the snapshot as a transaction; DeletedAt and timestamps are left unset
+func (s TransactionSnapshot) Transaction() (Transaction, error) {
+	date, err := time.Parse("2006-01-02", s.Date)
+	if err != nil {
+		return Transaction{}, fmt.Errorf("invalid snapshot date '%s': %w", s.Date, err)
+	}
+	return Transaction{
+		Id:              s.Id,
+		ParentId:        s.ParentId,
+		Amount:          NewMoney(s.AmountCents, s.Currency),
+		Description:     s.Description,
+		RawDescription:  s.RawDescription,
+		Date:            date,
+		CategoryId:      s.CategoryId,
+		TransactionType: s.TransactionType,
+		IsSplit:         s.IsSplit,
+		StatementId:     s.StatementId,
+		AccountId:       s.AccountId,
+	}, nil
+}
+
+// EncodeTransactionSnapshot returns the JSON audit snapshot of tx, or nil when there is no transaction
+func EncodeTransactionSnapshot(tx *Transaction) *string {
+	if tx == nil {
+		return nil
+	}
+	data, err := json.Marshal(NewTransactionSnapshot(*tx))
+	if err != nil {
+		return nil
+	}
+	snapshot := string(data)
+	return &snapshot
+}
+
+// ErrLegacySnapshot is returned for audit snapshots written before whole versions were kept
+// Those only hold a few hand-picked fields (amount, description, category, type), not a version
+// that can be shown field by field or restored.
+var ErrLegacySnapshot = errors.New("this change was recorded before transaction versions were kept")
+
+// DecodeTransactionSnapshot parses a JSON audit snapshot; nil means the transaction did not exist
+// Every full snapshot has a date and currency, so one without them is reported as ErrLegacySnapshot.
+func DecodeTransactionSnapshot(data *string) (*TransactionSnapshot, error) {
+	if data == nil {
+		return nil, nil
+	}
+	var snapshot TransactionSnapshot
+	if err := json.Unmarshal([]byte(*data), &snapshot); err != nil {
+		return nil, fmt.Errorf("invalid transaction snapshot: %w", err)
+	}
+	if snapshot.Date == "" || snapshot.Currency == "" {
+		return nil, ErrLegacySnapshot
+	}
+	return &snapshot, nil
+}
+
+// SnapshotChange is one field that differs between two versions of a transaction
+type SnapshotChange struct {
+	Field string // Display name, e.g. "Category"
+	Old   string // Empty when the transaction did not exist before the change
+	New   string // Empty when the transaction did not exist after the change
+}
+
+// DiffTransactionSnapshots lists the fields that differ between two versions of a transaction
+// categoryName formats category IDs (nil shows the ID). A nil side shows every field of the other as set or cleared.
+func DiffTransactionSnapshots(before, after *TransactionSnapshot, categoryName func(int64) string) []SnapshotChange {
+	if categoryName == nil {
+		categoryName = func(id int64) string { return fmt.Sprintf("#%d", id) }
+	}
+
+	fields := []string{"Amount", "Description", "Date", "Category", "Type", "Split", "Status"}
+	values := func(s *TransactionSnapshot) []string {
+		if s == nil {
+			return make([]string, len(fields))
+		}
+		split, status := "no", "active"
+		if s.IsSplit {
+			split = "yes"
+		}
+		if s.Deleted {
+			status = "in trash"
+		}
+		return []string{
+			NewMoney(s.AmountCents, s.Currency).String(),
+			s.Description,
+			s.Date,
+			categoryName(s.CategoryId),
+			s.TransactionType,
+			split,
+			status,
+		}
+	}
+
+	oldValues, newValues := values(before), values(after)
+	var changes []SnapshotChange
+	for i, field := range fields {
+		if oldValues[i] == newValues[i] {
+			continue
+		}
+		// A created or removed transaction only lists split and trash state when they are set
+		if (before == nil || after == nil) && (field == "Split" || field == "Status") &&
+			oldValues[i]+newValues[i] != "yes" && oldValues[i]+newValues[i] != "in trash" {
+			continue
+		}
+		changes = append(changes, SnapshotChange{Field: field, Old: oldValues[i], New: newValues[i]})
+	}
+	return changes
+}
+
+// AuditEvent is one entry of the general audit log: a create, update or delete of any entity
+// Transaction edits, imports and splits are tracked in more detail by TransactionAuditEvent.
+type AuditEvent struct {
+	Id          int64     `db:"id"`
+	EntityType  string    `db:"entity_type"`  // One of the Entity* constants
+	EntityId    string    `db:"entity_id"`    // Record ID, or the preference key for UserPreferences
+	EventType   string    `db:"event_type"`   // "create", "update", "delete"
+	Source      string    `db:"source"`       // "user", "import", "auto"
+	BeforeState *string   `db:"before_state"` // JSON; nil on create
+	AfterState  *string   `db:"after_state"`  // JSON; nil on delete
+	Timestamp   time.Time `db:"timestamp"`
+}
+
+// AuditEvent constants
+const (
+	// Entity Types
+	EntityCategory        = "Category"
+	EntityCSVTemplate     = "CSVTemplate"
+	EntityBankStatement   = "BankStatement"
+	EntitySnapshot        = "Snapshot"
+	EntityUserPreferences = "UserPreferences"
+	EntityTransaction     = "Transaction"
+
+	// Event Types
+	AuditEventCreate = "create"
+	AuditEventUpdate = "update"
+	AuditEventDelete = "delete"
+)
+
+// Validate validates the audit event and returns a ValidationResult
+func (ae *AuditEvent) Validate() ValidationResult {
+	result := ValidationResult{IsValid: true}
+
+	validEntities := []string{EntityCategory, EntityCSVTemplate, EntityBankStatement, EntitySnapshot, EntityUserPreferences, EntityTransaction}
+	if !containsString(validEntities, ae.EntityType) {
+		result.AddError("entityType", fmt.Sprintf("invalid entity type: %s", ae.EntityType))
+	}
+
+	if ae.EntityId == "" {
+		result.AddError("entityId", "entity ID cannot be empty")
+	}
+
+	if !containsString([]string{AuditEventCreate, AuditEventUpdate, AuditEventDelete}, ae.EventType) {
+		result.AddError("eventType", fmt.Sprintf("invalid event type: %s", ae.EventType))
+	}
+
+	if !containsString([]string{SourceUser, SourceAuto, SourceImport}, ae.Source) {
+		result.AddError("source", fmt.Sprintf("invalid source: %s", ae.Source))
+	}
+
+	if ae.BeforeState == nil && ae.AfterState == nil {
+		result.AddError("state", "audit event needs a before or after state")
+	}
+
+	return result
+}
+
+// EncodeAuditState returns the JSON audit state of v, or nil when v is nil (including a nil pointer)
+func EncodeAuditState(v interface{}) (*string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit state: %w", err)
+	}
+	if string(data) == "null" {
+		return nil, nil
+	}
+	state := string(data)
+	return &state, nil
+}
+
+// containsString reports whether value is one of options
+func containsString(options []string, value string) bool {
+	for _, option := range options {
+		if option == value {
+			return true
+		}
+	}
+	return false
+}
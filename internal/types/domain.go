@@ -8,62 +8,121 @@ import (
 
 // Transaction represents a financial transaction
 type Transaction struct {
-	Id              int64     `db:"id"`
-	ParentId        *int64    `db:"parent_id"`
-	Amount          float64   `db:"amount"`
-	Description     string    `db:"description"`
-	RawDescription  string    `db:"raw_description"`
-	Date            time.Time `db:"date"`
-	CategoryId      int64     `db:"category_id"`
-	TransactionType string    `db:"transaction_type"`
-	IsSplit         bool      `db:"is_split"`
-	StatementId     int64     `db:"statement_id"`
-	CreatedAt       time.Time `db:"created_at"`
-	UpdatedAt       time.Time `db:"updated_at"`
+	Id              int64      `db:"id"`
+	ParentId        *int64     `db:"parent_id"`
+	Amount          Money      `db:"amount_cents"`
+	Description     string     `db:"description"`
+	RawDescription  string     `db:"raw_description"`
+	Date            time.Time  `db:"date"`
+	CategoryId      int64      `db:"category_id"`
+	TransactionType string     `db:"transaction_type"`
+	IsSplit         bool       `db:"is_split"`
+	StatementId     int64      `db:"statement_id"`
+	AccountId       int64      `db:"account_id"`
+	ExternalId      string     `db:"external_id"` // The bank's own ID, such as an OFX FITID; empty for CSV rows
+	CreatedAt       time.Time  `db:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at"`
+	DeletedAt       *time.Time `db:"deleted_at"` // Set while the transaction is in the trash
 }
 
 // Category represents a transaction category
 type Category struct {
-	Id          int64     `db:"id"`
-	DisplayName string    `db:"display_name"`
-	ParentId    *int64    `db:"parent_id"`
-	Color       string    `db:"color"`
-	IsActive    bool      `db:"is_active"`
-	CreatedAt   time.Time `db:"created_at"`
-	UpdatedAt   time.Time `db:"updated_at"`
+	Id          int64     `db:"id" json:"id"`
+	DisplayName string    `db:"display_name" json:"display_name"`
+	ParentId    *int64    `db:"parent_id" json:"parent_id"`
+	Color       string    `db:"color" json:"color"`
+	IsActive    bool      `db:"is_active" json:"is_active"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
 }
 
 // BankStatement represents an imported bank statement
 type BankStatement struct {
-	Id             int64     `db:"id"`
-	Filename       string    `db:"filename"`
-	ImportDate     time.Time `db:"import_date"`
-	PeriodStart    time.Time `db:"period_start"`
-	PeriodEnd      time.Time `db:"period_end"`
-	TemplateUsed   int64     `db:"template_used"`
-	TxCount        int       `db:"tx_count"`
-	Status         string    `db:"status"`
-	ProcessingTime int64     `db:"processing_time"`
-	ErrorLog       string    `db:"error_log"`
-	CreatedAt      time.Time `db:"created_at"`
-	UpdatedAt      time.Time `db:"updated_at"`
+	Id             int64     `db:"id" json:"id"`
+	Filename       string    `db:"filename" json:"filename"`
+	ImportDate     time.Time `db:"import_date" json:"import_date"`
+	PeriodStart    time.Time `db:"period_start" json:"period_start"`
+	PeriodEnd      time.Time `db:"period_end" json:"period_end"`
+	TemplateUsed   int64     `db:"template_used" json:"template_used"`
+	TxCount        int       `db:"tx_count" json:"tx_count"`
+	Status         string    `db:"status" json:"status"`
+	ProcessingTime int64     `db:"processing_time" json:"processing_time"`
+	ErrorLog       string    `db:"error_log" json:"error_log"`
+	AccountId      int64     `db:"account_id" json:"account_id"`
+	Format         string    `db:"format" json:"format"`                                   // One of the StatementFormat* constants
+	OpeningBalance *Money    `db:"opening_balance_cents" json:"opening_balance,omitempty"` // As reported by the bank, when the file has one
+	ClosingBalance *Money    `db:"closing_balance_cents" json:"closing_balance,omitempty"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at" json:"updated_at"`
 }
 
 // CSVTemplate represents a CSV import template configuration
 type CSVTemplate struct {
+	Id             int64     `db:"id" json:"id"`
+	Name           string    `db:"name" json:"name"`
+	PostDateColumn int       `db:"post_date_column" json:"post_date_column"`
+	AmountColumn   int       `db:"amount_column" json:"amount_column"`
+	DescColumn     int       `db:"desc_column" json:"desc_column"`
+	CategoryColumn *int      `db:"category_column" json:"category_column"`
+	HasHeader      bool      `db:"has_header" json:"has_header"`
+	DateFormat     string    `db:"date_format" json:"date_format"`
+	Delimiter      string    `db:"delimiter" json:"delimiter"`
+	Currency       string    `db:"currency" json:"currency"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at" json:"updated_at"`
+
+	// DebitColumn and CreditColumn, set together, replace AmountColumn for files that put money
+	// out and money in in separate columns, leaving the other one blank
+	DebitColumn  *int `db:"debit_column" json:"debit_column"`
+	CreditColumn *int `db:"credit_column" json:"credit_column"`
+	// TypeColumn optionally names each row's type, such as "Debit"/"Credit" or "DR"/"CR"
+	TypeColumn *int `db:"type_column" json:"type_column"`
+	// ExpensesPositive is set for files that show money out as positive amounts
+	ExpensesPositive bool `db:"expenses_positive" json:"expenses_positive"`
+	// SkipLines is the number of lines before the header, such as account details, to skip
+	SkipLines int `db:"skip_lines" json:"skip_lines"`
+	// FooterLines is the number of summary rows after the last transaction to ignore
+	FooterLines int `db:"footer_lines" json:"footer_lines"`
+}
+
+// HasDebitCreditColumns reports whether the template reads amounts from a debit/credit column pair
+func (ct *CSVTemplate) HasDebitCreditColumns() bool {
+	return ct.DebitColumn != nil && ct.CreditColumn != nil
+}
+
+// Account types supported by the accounts table
+const (
+	AccountTypeChecking = "checking"
+	AccountTypeCredit   = "credit"
+	AccountTypeSavings  = "savings"
+	AccountTypeCash     = "cash"
+)
+
+// AccountTypes lists the valid account types in display order
+var AccountTypes = []string{AccountTypeChecking, AccountTypeCredit, AccountTypeSavings, AccountTypeCash}
+
+// Account represents a bank account, card or cash pot that statements are imported into
+type Account struct {
 	Id             int64     `db:"id"`
 	Name           string    `db:"name"`
-	PostDateColumn int       `db:"post_date_column"`
-	AmountColumn   int       `db:"amount_column"`
-	DescColumn     int       `db:"desc_column"`
-	CategoryColumn *int      `db:"category_column"`
-	HasHeader      bool      `db:"has_header"`
-	DateFormat     string    `db:"date_format"`
-	Delimiter      string    `db:"delimiter"`
+	AccountType    string    `db:"account_type"`
+	Institution    string    `db:"institution"`
+	AccountNumber  string    `db:"account_number"` // IBAN or bank account number, matched against statement files
+	OpeningBalance Money     `db:"opening_balance_cents"`
 	CreatedAt      time.Time `db:"created_at"`
 	UpdatedAt      time.Time `db:"updated_at"`
 }
 
+// ExchangeRate is the value of one unit of FromCurrency in ToCurrency on a given date
+type ExchangeRate struct {
+	Id           int64     `db:"id"`
+	RateDate     time.Time `db:"rate_date"`
+	FromCurrency string    `db:"from_currency"`
+	ToCurrency   string    `db:"to_currency"`
+	Rate         float64   `db:"rate"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
 // Display and conversion methods for Transaction (pure utility methods)
 func (t *Transaction) GetDateForDisplay() string {
 	return t.Date.Format("01/02/2006") // MM/DD/YYYY for UI display
@@ -155,6 +214,26 @@ func (ct *CSVTemplate) Validate() ValidationResult {
 		result.AddError("categoryColumn", err.Error())
 	}
 
+	if err := ct.validateDebitCreditColumns(); err != nil {
+		result.AddError("debitCreditColumns", err.Error())
+	}
+
+	if err := ct.validateTypeColumn(); err != nil {
+		result.AddError("typeColumn", err.Error())
+	}
+
+	if err := ct.validateCurrency(); err != nil {
+		result.AddError("currency", err.Error())
+	}
+
+	if err := ct.validateSkipLines(); err != nil {
+		result.AddError("skipLines", err.Error())
+	}
+
+	if err := ct.validateFooterLines(); err != nil {
+		result.AddError("footerLines", err.Error())
+	}
+
 	// Check for duplicate column indices
 	if err := ct.validateUniqueColumns(); err != nil {
 		result.AddError("columns", err.Error())
@@ -176,6 +255,16 @@ func (ct *CSVTemplate) ValidateField(field string) error {
 		return ct.validateDescColumn()
 	case "category":
 		return ct.validateCategoryColumn()
+	case "debit", "credit":
+		return ct.validateDebitCreditColumns()
+	case "type":
+		return ct.validateTypeColumn()
+	case "currency":
+		return ct.validateCurrency()
+	case "skiplines":
+		return ct.validateSkipLines()
+	case "footerlines":
+		return ct.validateFooterLines()
 	default:
 		return fmt.Errorf("unknown field: %s", field)
 	}
@@ -225,6 +314,50 @@ func (ct *CSVTemplate) validateCategoryColumn() error {
 	return nil
 }
 
+// validateDebitCreditColumns validates the optional debit/credit column pair
+func (ct *CSVTemplate) validateDebitCreditColumns() error {
+	if (ct.DebitColumn == nil) != (ct.CreditColumn == nil) {
+		return fmt.Errorf("debit and credit columns must be set together")
+	}
+	if ct.DebitColumn != nil && (*ct.DebitColumn < 0 || *ct.CreditColumn < 0) {
+		return fmt.Errorf("debit and credit column indices cannot be negative")
+	}
+	return nil
+}
+
+// validateTypeColumn validates the transaction type column index (optional)
+func (ct *CSVTemplate) validateTypeColumn() error {
+	if ct.TypeColumn != nil && *ct.TypeColumn < 0 {
+		return fmt.Errorf("type column index cannot be negative")
+	}
+	return nil
+}
+
+// validateSkipLines validates the number of preamble lines to skip
+func (ct *CSVTemplate) validateSkipLines() error {
+	if ct.SkipLines < 0 {
+		return fmt.Errorf("lines to skip cannot be negative")
+	}
+	return nil
+}
+
+// validateFooterLines validates the number of trailing summary rows to ignore
+func (ct *CSVTemplate) validateFooterLines() error {
+	if ct.FooterLines < 0 {
+		return fmt.Errorf("footer lines cannot be negative")
+	}
+	return nil
+}
+
+// validateCurrency validates the template currency code (empty means the default currency)
+func (ct *CSVTemplate) validateCurrency() error {
+	if ct.Currency == "" {
+		return nil
+	}
+	_, err := NormalizeCurrencyCode(ct.Currency)
+	return err
+}
+
 // validateUniqueColumns ensures no duplicate column indices
 func (ct *CSVTemplate) validateUniqueColumns() error {
 	usedColumns := make(map[int]string)
@@ -232,40 +365,113 @@ func (ct *CSVTemplate) validateUniqueColumns() error {
 	// Check each required column
 	usedColumns[ct.PostDateColumn] = "PostDate"
 
-	if existing, exists := usedColumns[ct.AmountColumn]; exists {
-		return fmt.Errorf("amount column %d is already used by %s column", ct.AmountColumn, existing)
+	// A debit/credit pair takes the place of the amount column
+	if ct.HasDebitCreditColumns() {
+		if existing, exists := usedColumns[*ct.DebitColumn]; exists {
+			return fmt.Errorf("debit column %d is already used by %s column", *ct.DebitColumn, existing)
+		}
+		usedColumns[*ct.DebitColumn] = "Debit"
+		if existing, exists := usedColumns[*ct.CreditColumn]; exists {
+			return fmt.Errorf("credit column %d is already used by %s column", *ct.CreditColumn, existing)
+		}
+		usedColumns[*ct.CreditColumn] = "Credit"
+	} else {
+		if existing, exists := usedColumns[ct.AmountColumn]; exists {
+			return fmt.Errorf("amount column %d is already used by %s column", ct.AmountColumn, existing)
+		}
+		usedColumns[ct.AmountColumn] = "Amount"
 	}
-	usedColumns[ct.AmountColumn] = "Amount"
 
 	if existing, exists := usedColumns[ct.DescColumn]; exists {
 		return fmt.Errorf("description column %d is already used by %s column", ct.DescColumn, existing)
 	}
 	usedColumns[ct.DescColumn] = "Description"
 
-	// Check optional category column
+	// Check optional category and type columns
 	if ct.CategoryColumn != nil {
 		if existing, exists := usedColumns[*ct.CategoryColumn]; exists {
 			return fmt.Errorf("category column %d is already used by %s column", *ct.CategoryColumn, existing)
 		}
+		usedColumns[*ct.CategoryColumn] = "Category"
+	}
+	if ct.TypeColumn != nil {
+		if existing, exists := usedColumns[*ct.TypeColumn]; exists {
+			return fmt.Errorf("type column %d is already used by %s column", *ct.TypeColumn, existing)
+		}
 	}
 
 	return nil
 }
 
+// Legacy validation methods for Account (should eventually be moved to validation package)
+
+// Validate validates the account and returns a ValidationResult
+func (a *Account) Validate() ValidationResult {
+	result := ValidationResult{IsValid: true}
+
+	if err := a.validateName(); err != nil {
+		result.AddError("name", err.Error())
+	}
+
+	if err := a.validateAccountType(); err != nil {
+		result.AddError("accountType", err.Error())
+	}
+
+	if err := a.validateCurrency(); err != nil {
+		result.AddError("currency", err.Error())
+	}
+
+	return result
+}
+
+// validateName validates the account name
+func (a *Account) validateName() error {
+	trimmed := strings.TrimSpace(a.Name)
+	if trimmed == "" {
+		return fmt.Errorf("account name cannot be empty")
+	}
+	if len(trimmed) > 100 {
+		return fmt.Errorf("account name cannot exceed 100 characters")
+	}
+	return nil
+}
+
+// validateAccountType validates the account type (empty means checking)
+func (a *Account) validateAccountType() error {
+	if a.AccountType == "" {
+		return nil
+	}
+	for _, accountType := range AccountTypes {
+		if a.AccountType == accountType {
+			return nil
+		}
+	}
+	return fmt.Errorf("account type must be one of: %s", strings.Join(AccountTypes, ", "))
+}
+
+// validateCurrency validates the opening balance currency (empty means the default currency)
+func (a *Account) validateCurrency() error {
+	if a.OpeningBalance.Currency == "" {
+		return nil
+	}
+	_, err := NormalizeCurrencyCode(a.OpeningBalance.Currency)
+	return err
+}
+
 // Snapshot represents a database snapshot
 type Snapshot struct {
-	Id               int64     `db:"id"`
-	Name             string    `db:"name"`
-	Description      string    `db:"description"`
-	FilePath         string    `db:"file_path"`
-	FileSize         int64     `db:"file_size"`
-	TransactionCount int       `db:"transaction_count"`
-	CategoryCount    int       `db:"category_count"`
-	StatementCount   int       `db:"statement_count"`
-	TemplateCount    int       `db:"template_count"`
-	AuditEventCount  int       `db:"audit_event_count"`
-	CreatedAt        time.Time `db:"created_at"`
-	UpdatedAt        time.Time `db:"updated_at"`
+	Id               int64     `db:"id" json:"id"`
+	Name             string    `db:"name" json:"name"`
+	Description      string    `db:"description" json:"description"`
+	FilePath         string    `db:"file_path" json:"file_path"`
+	FileSize         int64     `db:"file_size" json:"file_size"`
+	TransactionCount int       `db:"transaction_count" json:"transaction_count"`
+	CategoryCount    int       `db:"category_count" json:"category_count"`
+	StatementCount   int       `db:"statement_count" json:"statement_count"`
+	TemplateCount    int       `db:"template_count" json:"template_count"`
+	AuditEventCount  int       `db:"audit_event_count" json:"audit_event_count"`
+	CreatedAt        time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt        time.Time `db:"updated_at" json:"updated_at"`
 }
 
 // GetSizeDisplay returns human-readable file size
@@ -0,0 +1,187 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the ISO 4217 code assumed when none is specified
+const DefaultCurrency = "USD"
+
+// Errors ParseMoney returns for amounts that are well formed but cannot be stored exactly
+var (
+	ErrTooManyDecimals = errors.New("amount cannot have more than 2 decimal places")
+	ErrAmountTooLarge  = errors.New("amount exceeds maximum allowed value")
+)
+
+// maxMoneyDigits bounds the integer part of parsed amounts so cents fit in an int64
+const maxMoneyDigits = 15
+
+// Money is an exact monetary amount stored as integer cents with its currency code
+type Money struct {
+	Cents    int64  `db:"amount_cents"`
+	Currency string `db:"currency"`
+}
+
+// NewMoney creates a Money value, defaulting the currency when empty
+func NewMoney(cents int64, currency string) Money {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	return Money{Cents: cents, Currency: strings.ToUpper(currency)}
+}
+
+// MoneyFromFloat converts a float amount to Money, rounding half away from zero to the nearest cent
+// Only use this at boundaries where a float is unavoidable; prefer ParseMoney for user input
+func MoneyFromFloat(amount float64, currency string) Money {
+	return NewMoney(int64(math.Round(amount*100)), currency)
+}
+
+// ParseMoney parses a plain decimal string such as "-1234.5" into Money without float rounding
+// Currency symbols and thousands separators must be stripped by the caller
+func ParseMoney(amountStr, currency string) (Money, error) {
+	s := strings.TrimSpace(amountStr)
+	if s == "" {
+		return Money{}, fmt.Errorf("amount cannot be empty")
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return Money{}, fmt.Errorf("invalid amount format: %s", amountStr)
+	}
+	if !isDigits(whole) || !isDigits(frac) {
+		return Money{}, fmt.Errorf("invalid amount format: %s", amountStr)
+	}
+	if len(frac) > 2 {
+		return Money{}, fmt.Errorf("%w", ErrTooManyDecimals)
+	}
+	if len(strings.TrimLeft(whole, "0")) > maxMoneyDigits {
+		return Money{}, fmt.Errorf("%w", ErrAmountTooLarge)
+	}
+
+	if whole == "" {
+		whole = "0"
+	}
+	frac = (frac + "00")[:2]
+
+	cents, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount format: %s", amountStr)
+	}
+	if negative {
+		cents = -cents
+	}
+
+	return NewMoney(cents, currency), nil
+}
+
+// isDigits reports whether s contains only ASCII digits (empty strings count)
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Float64 returns the amount in major units for display math such as percentages
+func (m Money) Float64() float64 {
+	return float64(m.Cents) / 100
+}
+
+// String formats the amount as a plain decimal with two places, e.g. "-12.50"
+func (m Money) String() string {
+	sign := ""
+	cents := m.Cents
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// Display formats the amount followed by its currency code, e.g. "12.50 USD"
+func (m Money) Display() string {
+	if m.Currency == "" {
+		return m.String()
+	}
+	return m.String() + " " + m.Currency
+}
+
+// Add returns the sum of two amounts, keeping the receiver's currency
+func (m Money) Add(other Money) Money {
+	return Money{Cents: m.Cents + other.Cents, Currency: m.currencyOr(other)}
+}
+
+// Sub returns the difference of two amounts, keeping the receiver's currency
+func (m Money) Sub(other Money) Money {
+	return Money{Cents: m.Cents - other.Cents, Currency: m.currencyOr(other)}
+}
+
+// currencyOr lets a zero-value Money accumulator adopt the currency of the first amount added
+func (m Money) currencyOr(other Money) string {
+	if m.Currency == "" {
+		return other.Currency
+	}
+	return m.Currency
+}
+
+// Neg returns the amount with its sign flipped
+func (m Money) Neg() Money {
+	return Money{Cents: -m.Cents, Currency: m.Currency}
+}
+
+// Abs returns the absolute amount
+func (m Money) Abs() Money {
+	if m.Cents < 0 {
+		return m.Neg()
+	}
+	return m
+}
+
+// IsZero reports whether the amount is exactly zero
+func (m Money) IsZero() bool {
+	return m.Cents == 0
+}
+
+// IsNegative reports whether the amount is below zero
+func (m Money) IsNegative() bool {
+	return m.Cents < 0
+}
+
+// Equal reports whether two amounts have the same value and currency
+func (m Money) Equal(other Money) bool {
+	return m.Cents == other.Cents && m.Currency == other.Currency
+}
+
+// Convert returns the amount in another currency using a per-unit rate, rounding half away from zero
+func (m Money) Convert(currency string, rate float64) Money {
+	return NewMoney(int64(math.Round(float64(m.Cents)*rate)), currency)
+}
+
+// NormalizeCurrencyCode upper-cases a currency code and checks it is three ASCII letters
+func NormalizeCurrencyCode(code string) (string, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(code))
+	if len(normalized) != 3 {
+		return "", fmt.Errorf("currency code must be 3 letters: %q", code)
+	}
+	for i := 0; i < len(normalized); i++ {
+		if normalized[i] < 'A' || normalized[i] > 'Z' {
+			return "", fmt.Errorf("currency code must be 3 letters: %q", code)
+		}
+	}
+	return normalized, nil
+}
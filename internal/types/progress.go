@@ -0,0 +1,33 @@
+package types
+
+// Stages reported by long-running store operations
+const (
+	ProgressParsing   = "Parsing rows"
+	ProgressInserting = "Inserting rows"
+	ProgressAuditing  = "Recording history"
+	ProgressTraining  = "Retraining categorizer"
+	ProgressSnapshot  = "Writing snapshot"
+	ProgressAnalytics = "Running analytics"
+)
+
+// Progress reports how far a long-running operation has got
+// A Total of 0 means the amount of work is not known yet.
+type Progress struct {
+	Stage string
+	Done  int
+	Total int
+}
+
+// Fraction returns the completed share of the stage between 0 and 1, or -1 when it is unknown
+func (p Progress) Fraction() float64 {
+	if p.Total <= 0 {
+		return -1
+	}
+	if p.Done >= p.Total {
+		return 1
+	}
+	return float64(p.Done) / float64(p.Total)
+}
+
+// ProgressFunc receives progress updates; it must return quickly because it runs on the worker
+type ProgressFunc func(Progress)
@@ -54,4 +54,9 @@ type ImportResult struct {
 	Filename            string
 	HasValidationErrors bool
 	ValidationErrors    []ValidationError
+	DuplicateCount      int    // Rows skipped because they were already imported
+	Cancelled           bool   // The import was cancelled and nothing was saved
+	ValidCount          int    // Rows that parsed, which importing the valid rows brings in
+	RejectedCount       int    // Rows that failed to parse and were left out of a partial import
+	RejectFile          string // Where a partial import saved the rows it left out
 }
@@ -0,0 +1,60 @@
+package types
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Statement file formats recorded on bank statements
+const (
+	StatementFormatCSV   = "csv"   // Parsed with a CSV template
+	StatementFormatOFX   = "ofx"   // OFX 1.x (SGML) or 2.x (XML), including Quicken's .qfx
+	StatementFormatQIF   = "qif"   // Quicken Interchange Format bank, credit card and cash sections
+	StatementFormatCAMT  = "camt"  // ISO 20022 camt.053 statement or camt.052 account report XML
+	StatementFormatMT940 = "mt940" // SWIFT MT940 customer statement
+)
+
+// statementFormatsByExtension maps importable file extensions to their format
+var statementFormatsByExtension = map[string]string{
+	".csv":   StatementFormatCSV,
+	".ofx":   StatementFormatOFX,
+	".qfx":   StatementFormatOFX,
+	".qif":   StatementFormatQIF,
+	".xml":   StatementFormatCAMT,
+	".sta":   StatementFormatMT940,
+	".940":   StatementFormatMT940,
+	".mt940": StatementFormatMT940,
+}
+
+// StatementFileFormat returns the import format for a file name, or "" if it cannot be imported
+func StatementFileFormat(name string) string {
+	return statementFormatsByExtension[strings.ToLower(filepath.Ext(name))]
+}
+
+// StatementFile is a bank statement read from a file that describes itself, such as OFX,
+// so no CSV template is needed to import it
+type StatementFile struct {
+	Format         string
+	AccountNumber  string    // The bank's account identifier, when the file gives one
+	Currency       string    // ISO 4217 code of the statement
+	PeriodStart    time.Time // Zero when the file has no statement period
+	PeriodEnd      time.Time
+	OpeningBalance *Money        // Nil when the file reports no opening balance
+	ClosingBalance *Money        // The ledger balance at the end of the period, when reported
+	Transactions   []Transaction // ExternalId identifies each row for duplicate detection, when the file allows it
+	ZeroAmounts    int           // Entries left out of Transactions because they move no money
+
+	// Categories holds the category the file names for the transaction at each index, as a
+	// "Parent:Child" path; files without categories leave it nil
+	Categories map[int]string
+	// Splits holds the split lines of the transaction at each index, which add up to its amount
+	Splits map[int][]StatementSplit
+}
+
+// StatementSplit is one line of a transaction split across categories in a statement file
+type StatementSplit struct {
+	Amount   Money
+	Category string // "Parent:Child" path; empty when the line names no category
+	Memo     string
+}
@@ -1,7 +1,8 @@
 package types
 
-// - Domain objects (Transaction, Category, BankStatement, CSVTemplate) are in domain.go
+// - Domain objects (Transaction, Category, BankStatement, CSVTemplate, Account, ExchangeRate) are in domain.go
 // - Operation results (ValidationError, ValidationResult, ImportResult) are in results.go
+// - Money (exact cents plus currency) is in money.go
 // - Analytics types (AnalyticsSummary, CategorySpending) are in analytics.go
 // - Audit types (TransactionAuditEvent, constants) are in audit.go
 // - Date utilities are in types_dates.go
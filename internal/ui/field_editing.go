@@ -20,7 +20,7 @@ func (m model) handleFieldNavigation(direction int) (tea.Model, tea.Cmd) {
 
 	// Initialize amount string when entering amount field
 	if m.editField == editAmount && m.editAmountStr == "" {
-		m.editAmountStr = strconv.FormatFloat(m.currTransaction.Amount, 'f', 2, 64)
+		m.editAmountStr = m.currTransaction.Amount.String()
 	}
 
 	return m, nil
@@ -61,14 +61,14 @@ func (m model) handleFieldActivation() (tea.Model, tea.Cmd) {
 
 func (m model) enterAmountEditing() (tea.Model, tea.Cmd) {
 	m.isEditingAmount = true
-	m.editingAmountStr = strconv.FormatFloat(m.currTransaction.Amount, 'f', 2, 64)
+	m.editingAmountStr = m.currTransaction.Amount.String()
 	return m, nil
 }
 
 func (m model) enterAmountEditingWithBackspace() (tea.Model, tea.Cmd) {
 	m.isEditingAmount = true
 	// Start with current value and immediately apply backspace
-	m.editingAmountStr = strconv.FormatFloat(m.currTransaction.Amount, 'f', 2, 64)
+	m.editingAmountStr = m.currTransaction.Amount.String()
 	if len(m.editingAmountStr) > 0 {
 		m.editingAmountStr = m.editingAmountStr[:len(m.editingAmountStr)-1]
 	}
@@ -80,7 +80,7 @@ func (m model) handleAmountEditing(key string) (tea.Model, tea.Cmd) {
 	case "enter", "esc":
 		m.isEditingAmount = false
 		if key == "enter" && m.editingAmountStr != "" {
-			if amount, err := strconv.ParseFloat(m.editingAmountStr, 64); err == nil {
+			if amount, err := types.ParseMoney(m.editingAmountStr, m.currTransaction.Amount.Currency); err == nil {
 				m.currTransaction.Amount = amount
 				// Validate field on enter (field commit)
 				m.validateCurrentTransaction()
@@ -348,6 +348,9 @@ func (m *model) initTemplateEditingStrings() {
 	if m.editingTemplateCategoryStr == "" && m.newTemplate.CategoryColumn != nil {
 		m.editingTemplateCategoryStr = strconv.Itoa(*m.newTemplate.CategoryColumn)
 	}
+	if m.editingTemplateCurrencyStr == "" {
+		m.editingTemplateCurrencyStr = m.newTemplate.Currency
+	}
 }
 
 func (m model) handleTemplateBackspaceActivation() (tea.Model, tea.Cmd) {
@@ -362,6 +365,10 @@ func (m model) handleTemplateBackspaceActivation() (tea.Model, tea.Cmd) {
 		return m.enterTemplateDescEditingWithBackspace()
 	case templateCategory:
 		return m.enterTemplateCategoryEditingWithBackspace()
+	case templateCurrency:
+		return m.enterTemplateCurrencyEditingWithBackspace()
+	case templateDebit, templateCredit, templateTypeColumn, templateSkipLines, templateFooterLines:
+		return m.enterTemplateColumnEditing(true)
 	}
 	return m, nil
 }
@@ -378,6 +385,13 @@ func (m model) handleTemplateFieldActivation() (tea.Model, tea.Cmd) {
 		return m.enterTemplateDescEditing()
 	case templateCategory:
 		return m.enterTemplateCategoryEditing()
+	case templateCurrency:
+		return m.enterTemplateCurrencyEditing()
+	case templateDebit, templateCredit, templateTypeColumn, templateSkipLines, templateFooterLines:
+		return m.enterTemplateColumnEditing(false)
+	case templateSign:
+		m.newTemplate.ExpensesPositive = !m.newTemplate.ExpensesPositive
+		return m, nil
 	case templateHeader:
 		return m.enterTemplateHeaderMode()
 	}
@@ -593,6 +607,127 @@ func (m model) handleTemplateCategoryInput(key string) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// Template Debit, Credit and Type Column Editing
+
+// templateOptionalColumn returns the optional column a form field edits, with its validation field
+func (m *model) templateOptionalColumn(field uint) (**int, string) {
+	switch field {
+	case templateDebit:
+		return &m.newTemplate.DebitColumn, "debit"
+	case templateCredit:
+		return &m.newTemplate.CreditColumn, "credit"
+	case templateTypeColumn:
+		return &m.newTemplate.TypeColumn, "type"
+	}
+	return nil, ""
+}
+
+// templateLineCount returns the line count a form field edits, with its validation field
+func (m *model) templateLineCount(field uint) (*int, string) {
+	switch field {
+	case templateSkipLines:
+		return &m.newTemplate.SkipLines, "skiplines"
+	case templateFooterLines:
+		return &m.newTemplate.FooterLines, "footerlines"
+	}
+	return nil, ""
+}
+
+// enterTemplateColumnEditing edits an optional column or a line count, which share the number editor
+func (m model) enterTemplateColumnEditing(backspace bool) (tea.Model, tea.Cmd) {
+	m.isEditingTemplateColumn = true
+	m.editingTemplateColumnStr = ""
+	if count, _ := m.templateLineCount(m.createField); count != nil {
+		m.editingTemplateColumnStr = strconv.Itoa(*count)
+	} else if column, _ := m.templateOptionalColumn(m.createField); *column != nil {
+		m.editingTemplateColumnStr = strconv.Itoa(**column)
+	}
+	if backspace && len(m.editingTemplateColumnStr) > 0 {
+		m.editingTemplateColumnStr = m.editingTemplateColumnStr[:len(m.editingTemplateColumnStr)-1]
+	}
+	return m, nil
+}
+
+func (m model) handleTemplateColumnInput(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "enter", "esc":
+		count, field := m.templateLineCount(m.createField)
+		if count != nil {
+			if key == "enter" {
+				*count, _ = strconv.Atoi(m.editingTemplateColumnStr) // Blank is no lines
+			}
+			m.validateTemplateField(field)
+			m.isEditingTemplateColumn = false
+			m.editingTemplateColumnStr = ""
+			return m, nil
+		}
+
+		column, field := m.templateOptionalColumn(m.createField)
+		if key == "enter" {
+			if m.editingTemplateColumnStr == "" {
+				*column = nil
+			} else if value, err := strconv.Atoi(m.editingTemplateColumnStr); err == nil && value >= 0 {
+				*column = &value
+			}
+		}
+		m.validateTemplateField(field)
+		m.isEditingTemplateColumn = false
+		m.editingTemplateColumnStr = ""
+	case "backspace":
+		if len(m.editingTemplateColumnStr) > 0 {
+			m.editingTemplateColumnStr = m.editingTemplateColumnStr[:len(m.editingTemplateColumnStr)-1]
+		}
+	default:
+		if len(key) == 1 && key >= "0" && key <= "9" {
+			m.editingTemplateColumnStr += key
+		}
+	}
+	return m, nil
+}
+
+// Template Currency Editing
+
+func (m model) enterTemplateCurrencyEditing() (tea.Model, tea.Cmd) {
+	m.isEditingTemplateCurrency = true
+	if m.editingTemplateCurrencyStr == "" {
+		m.editingTemplateCurrencyStr = m.newTemplate.Currency
+	}
+	return m, nil
+}
+
+func (m model) enterTemplateCurrencyEditingWithBackspace() (tea.Model, tea.Cmd) {
+	m.isEditingTemplateCurrency = true
+	if m.editingTemplateCurrencyStr == "" {
+		m.editingTemplateCurrencyStr = m.newTemplate.Currency
+	}
+	if len(m.editingTemplateCurrencyStr) > 0 {
+		m.editingTemplateCurrencyStr = m.editingTemplateCurrencyStr[:len(m.editingTemplateCurrencyStr)-1]
+	}
+	return m, nil
+}
+
+func (m model) handleTemplateCurrencyInput(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "enter", "esc":
+		if key == "enter" {
+			// Empty keeps the default currency
+			m.newTemplate.Currency = strings.ToUpper(m.editingTemplateCurrencyStr)
+		}
+		m.validateTemplateField("currency")
+		m.isEditingTemplateCurrency = false
+	case "backspace":
+		if len(m.editingTemplateCurrencyStr) > 0 {
+			m.editingTemplateCurrencyStr = m.editingTemplateCurrencyStr[:len(m.editingTemplateCurrencyStr)-1]
+		}
+	default:
+		if len(key) == 1 && len(m.editingTemplateCurrencyStr) < 3 &&
+			((key >= "a" && key <= "z") || (key >= "A" && key <= "Z")) {
+			m.editingTemplateCurrencyStr += strings.ToUpper(key)
+		}
+	}
+	return m, nil
+}
+
 // Template Header Mode (Yes/No selection)
 
 func (m model) enterTemplateHeaderMode() (tea.Model, tea.Cmd) {
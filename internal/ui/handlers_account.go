@@ -0,0 +1,283 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"budget-tracker-tui/internal/types"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Account List View
+
+// handleAccountListView handles the account list with running balances
+func (m model) handleAccountListView(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "up":
+		if m.selectedAccountIdx > 0 {
+			m.selectedAccountIdx--
+		}
+	case "down":
+		if m.selectedAccountIdx < len(m.accountBalances)-1 {
+			m.selectedAccountIdx++
+		}
+	case "n":
+		m.editingAccount = types.Account{AccountType: types.AccountTypeChecking}
+		m.initAccountFields()
+		m.state = accountEditView
+	case "e":
+		if m.selectedAccountIdx >= 0 && m.selectedAccountIdx < len(m.accountBalances) {
+			m.editingAccount = m.accountBalances[m.selectedAccountIdx].Account
+			m.initAccountFields()
+			m.state = accountEditView
+		}
+	case "d":
+		if m.selectedAccountIdx >= 0 && m.selectedAccountIdx < len(m.accountBalances) {
+			account := m.accountBalances[m.selectedAccountIdx].Account
+			result := m.store.Accounts.DeleteAccount(account.Id)
+			m.loadAccountBalances()
+			m.accountMessage = result.Message
+			if result.Success && m.selectedAccountIdx >= len(m.accountBalances) && m.selectedAccountIdx > 0 {
+				m.selectedAccountIdx--
+			}
+		}
+	case "q", "esc":
+		m.state = menuView
+	}
+	return m, nil
+}
+
+// loadAccountBalances refreshes the account list and its running balances
+func (m *model) loadAccountBalances() {
+	balances, err := m.store.Accounts.GetAccountBalances()
+	if err != nil {
+		m.accountMessage = "Error loading accounts: " + err.Error()
+		return
+	}
+	m.accountBalances = balances
+	m.accountMessage = ""
+}
+
+// Account Edit View
+
+// initAccountFields loads the editing account into the form field values
+func (m *model) initAccountFields() {
+	m.accountActiveField = accountFieldName
+	m.accountEditingField = false
+	m.accountEditingStr = ""
+	m.accountMessage = ""
+
+	currency := m.editingAccount.OpeningBalance.Currency
+	if currency == "" {
+		currency = types.DefaultCurrency
+	}
+
+	m.accountFieldValues = map[int]string{
+		accountFieldName:           m.editingAccount.Name,
+		accountFieldType:           m.editingAccount.AccountType,
+		accountFieldInstitution:    m.editingAccount.Institution,
+		accountFieldAccountNumber:  m.editingAccount.AccountNumber,
+		accountFieldOpeningBalance: m.editingAccount.OpeningBalance.String(),
+		accountFieldCurrency:       currency,
+	}
+}
+
+// handleAccountEditView handles account creation and editing
+func (m model) handleAccountEditView(key string) (tea.Model, tea.Cmd) {
+	if m.accountEditingField {
+		return m.handleAccountFieldEdit(key)
+	}
+
+	switch key {
+	case "up", "shift+tab":
+		if m.accountActiveField > accountFieldName {
+			m.accountActiveField--
+		}
+	case "down", "tab":
+		if m.accountActiveField < accountFieldCurrency {
+			m.accountActiveField++
+		}
+	case "enter":
+		// Account type is a fixed list, so enter cycles it instead of opening a text field
+		if m.accountActiveField == accountFieldType {
+			m.accountFieldValues[accountFieldType] = nextAccountType(m.accountFieldValues[accountFieldType])
+			return m, nil
+		}
+		m.accountEditingField = true
+		m.accountEditingStr = m.accountFieldValues[m.accountActiveField]
+	case "backspace":
+		if m.accountActiveField == accountFieldType {
+			return m, nil
+		}
+		m.accountEditingField = true
+		m.accountEditingStr = m.accountFieldValues[m.accountActiveField]
+		if len(m.accountEditingStr) > 0 {
+			m.accountEditingStr = m.accountEditingStr[:len(m.accountEditingStr)-1]
+		}
+	case "ctrl+s":
+		return m.saveAccountAndReturn()
+	case "esc":
+		m.state = accountListView
+		m.loadAccountBalances()
+	}
+	return m, nil
+}
+
+// handleAccountFieldEdit handles text input while an account field is being edited
+func (m model) handleAccountFieldEdit(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "enter", "esc":
+		if key == "enter" {
+			m.accountFieldValues[m.accountActiveField] = m.accountEditingStr
+		}
+		m.accountEditingField = false
+		m.accountEditingStr = ""
+	case "backspace":
+		if len(m.accountEditingStr) > 0 {
+			m.accountEditingStr = m.accountEditingStr[:len(m.accountEditingStr)-1]
+		}
+	default:
+		if len(key) != 1 {
+			return m, nil
+		}
+		if m.accountActiveField == accountFieldCurrency {
+			// Currency codes are 3 letters, stored uppercase
+			if len(m.accountEditingStr) < 3 && strings.ContainsAny(key, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ") {
+				m.accountEditingStr += strings.ToUpper(key)
+			}
+			return m, nil
+		}
+		m.accountEditingStr += key
+	}
+	return m, nil
+}
+
+// nextAccountType returns the account type after current, wrapping around
+func nextAccountType(current string) string {
+	for i, accountType := range types.AccountTypes {
+		if accountType == current {
+			return types.AccountTypes[(i+1)%len(types.AccountTypes)]
+		}
+	}
+	return types.AccountTypes[0]
+}
+
+// saveAccountAndReturn builds the account from the form and creates or updates it
+func (m model) saveAccountAndReturn() (tea.Model, tea.Cmd) {
+	currency, err := types.NormalizeCurrencyCode(m.accountFieldValues[accountFieldCurrency])
+	if err != nil {
+		m.accountMessage = "Invalid currency: " + err.Error()
+		return m, nil
+	}
+
+	openingBalance := types.NewMoney(0, currency)
+	if balanceStr := strings.TrimSpace(m.accountFieldValues[accountFieldOpeningBalance]); balanceStr != "" {
+		openingBalance, err = types.ParseMoney(balanceStr, currency)
+		if err != nil {
+			m.accountMessage = "Invalid opening balance: " + err.Error()
+			return m, nil
+		}
+	}
+
+	account := m.editingAccount
+	account.Name = m.accountFieldValues[accountFieldName]
+	account.AccountType = m.accountFieldValues[accountFieldType]
+	account.Institution = m.accountFieldValues[accountFieldInstitution]
+	account.AccountNumber = m.accountFieldValues[accountFieldAccountNumber]
+	account.OpeningBalance = openingBalance
+
+	if account.Id == 0 {
+		result := m.store.Accounts.CreateAccount(account)
+		if !result.Success {
+			m.accountMessage = result.Message
+			return m, nil
+		}
+	} else if err := m.store.Accounts.UpdateAccount(account); err != nil {
+		m.accountMessage = "Error updating account: " + err.Error()
+		return m, nil
+	}
+
+	m.state = accountListView
+	m.loadAccountBalances()
+	m.accountMessage = fmt.Sprintf("Account '%s' saved", strings.TrimSpace(account.Name))
+	return m, nil
+}
+
+// Import Account Selection View
+
+// enterAccountSelection asks which account the selected statement file belongs to
+func (m model) enterAccountSelection() (tea.Model, tea.Cmd) {
+	accounts, err := m.store.Accounts.GetAccounts()
+	if err != nil {
+		m.statementMessage = "Error loading accounts: " + err.Error()
+		m.state = bankStatementView
+		return m, nil
+	}
+	m.importAccounts = accounts
+
+	// Preselect the account this template (or file format, for files imported without one)
+	// was last imported into, otherwise "No account"
+	m.importAccountIdx = len(accounts)
+	template := m.store.Templates.GetTemplateByName(m.selectedTemplate)
+	format := types.StatementFileFormat(m.selectedFile)
+	if template != nil || format != types.StatementFormatCSV {
+		for _, stmt := range m.store.Statements.GetStatementHistory() {
+			if stmt.AccountId == 0 {
+				continue
+			}
+			if template != nil && stmt.TemplateUsed != template.Id {
+				continue
+			}
+			if template == nil && (stmt.TemplateUsed != 0 || stmt.Format != format) {
+				continue
+			}
+			for i, account := range accounts {
+				if account.Id == stmt.AccountId {
+					m.importAccountIdx = i
+				}
+			}
+			break
+		}
+	}
+
+	// A statement file naming an account number goes to the account with that number
+	m.importMatched = false
+	if template == nil && format != types.StatementFormatCSV {
+		if matched := m.store.StatementFileAccount(m.selectedFile); matched != nil {
+			for i, account := range accounts {
+				if account.Id == matched.Id {
+					m.importAccountIdx = i
+					m.importMatched = true
+				}
+			}
+		}
+	}
+
+	m.state = accountSelectView
+	return m, nil
+}
+
+// handleAccountSelectView handles choosing the account for a statement import
+func (m model) handleAccountSelectView(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "up":
+		if m.importAccountIdx > 0 {
+			m.importAccountIdx--
+		}
+	case "down":
+		// The extra last entry is "No account"
+		if m.importAccountIdx < len(m.importAccounts) {
+			m.importAccountIdx++
+		}
+	case "enter":
+		m.importAccountId = 0
+		if m.importAccountIdx < len(m.importAccounts) {
+			m.importAccountId = m.importAccounts[m.importAccountIdx].Id
+		}
+		return m.importSelectedFile()
+	case "esc":
+		m.state = filePickerView
+	}
+	return m, nil
+}
@@ -1,6 +1,9 @@
 package ui
 
 import (
+	"budget-tracker-tui/internal/storage"
+	"budget-tracker-tui/internal/types"
+	"context"
 	"fmt"
 	"strconv"
 	"time"
@@ -50,27 +53,83 @@ func (m model) initAnalytics() (model, tea.Cmd) {
 	m.analyticsTable = t
 
 	// Load initial data
-	m.loadAnalyticsData()
+	cmd := m.startAnalyticsLoad("")
 
-	return m, nil
+	return m, cmd
 }
 
-// loadAnalyticsData loads and refreshes analytics data from storage
-func (m *model) loadAnalyticsData() {
+// analyticsLoadedMsg carries analytics data queried in the background
+type analyticsLoadedMsg struct {
+	summary          *types.AnalyticsSummary
+	categorySpending []types.CategorySpending
+	errMessage       string // Set when a query failed; data loaded before the failure is kept
+	notice           string // Shown ahead of the outcome, e.g. the result of a rates import
+}
+
+// fetchAnalytics queries the summary and category spending for the analytics period
+func (m model) fetchAnalytics(ctx context.Context) analyticsLoadedMsg {
+	var msg analyticsLoadedMsg
+
 	// Get summary data
-	summary, err := m.store.GetTransactionSummaryByDateRange(m.analyticsStartDate, m.analyticsEndDate)
+	summary, err := m.store.GetTransactionSummaryByDateRangeContext(ctx, m.analyticsStartDate, m.analyticsEndDate)
 	if err != nil {
-		m.analyticsMessage = fmt.Sprintf("Error loading summary: %v", err)
-		return
+		msg.errMessage = fmt.Sprintf("Error loading summary: %v (press 'i' to import exchange rates)", err)
+		return msg
 	}
-	m.analyticsSummary = summary
+	msg.summary = summary
 
 	// Get category spending data
-	categorySpending, err := m.store.GetCategorySpendingByDateRange(m.analyticsStartDate, m.analyticsEndDate)
+	categorySpending, err := m.store.GetCategorySpendingByDateRangeContext(ctx, m.analyticsStartDate, m.analyticsEndDate)
 	if err != nil {
-		m.analyticsMessage = fmt.Sprintf("Error loading category data: %v", err)
+		msg.errMessage = fmt.Sprintf("Error loading category data: %v", err)
+		return msg
+	}
+	msg.categorySpending = categorySpending
+	return msg
+}
+
+// startAnalyticsLoad reloads analytics data in the background, showing notice once it is done
+func (m *model) startAnalyticsLoad(notice string) tea.Cmd {
+	loader := *m
+	return m.startJob("Loading analytics", func(ctx context.Context, progress types.ProgressFunc) tea.Msg {
+		progress(types.Progress{Stage: types.ProgressAnalytics})
+		msg := loader.fetchAnalytics(ctx)
+		if ctx.Err() != nil && msg.errMessage != "" {
+			msg.errMessage = "Analytics refresh cancelled; showing the previous figures"
+		}
+		msg.notice = notice
+		return msg
+	})
+}
+
+// finishAnalyticsLoad shows analytics data loaded in the background
+func (m model) finishAnalyticsLoad(msg analyticsLoadedMsg) (tea.Model, tea.Cmd) {
+	m.applyAnalyticsData(msg)
+	if msg.notice != "" {
+		if m.analyticsMessage != "" {
+			m.analyticsMessage = msg.notice + ". " + m.analyticsMessage
+		} else {
+			m.analyticsMessage = msg.notice
+		}
+	}
+	return m, nil
+}
+
+// loadAnalyticsData loads and refreshes analytics data from storage
+func (m *model) loadAnalyticsData() {
+	m.applyAnalyticsData(m.fetchAnalytics(context.Background()))
+}
+
+// applyAnalyticsData shows loaded analytics data, or the error that stopped the load
+func (m *model) applyAnalyticsData(msg analyticsLoadedMsg) {
+	if msg.summary != nil {
+		m.analyticsSummary = msg.summary
+	}
+	if msg.errMessage != "" {
+		m.analyticsMessage = msg.errMessage
 		return
 	}
+	categorySpending := msg.categorySpending
 	m.categorySpending = categorySpending
 
 	// Build table rows
@@ -78,7 +137,7 @@ func (m *model) loadAnalyticsData() {
 	for _, spending := range categorySpending {
 		rows = append(rows, table.Row{
 			spending.CategoryName,
-			fmt.Sprintf("$%.2f", spending.Amount),
+			spending.Amount.Display(),
 			fmt.Sprintf("%.1f%%", spending.Percentage),
 			strconv.Itoa(spending.TransactionCount),
 		})
@@ -100,9 +159,10 @@ func (m *model) loadAnalyticsData() {
 		{Title: "Transactions", Width: 12},
 	}
 
+	// Focused so the cursor can pick a category to drill into
 	t := table.New(
 		table.WithColumns(columns),
-		table.WithFocused(false),
+		table.WithFocused(true),
 		table.WithHeight(tableHeight),
 		table.WithRows(rows),
 	)
@@ -138,17 +198,32 @@ func (m model) handleAnalyticsView(key string) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case "r":
-		// Refresh data with debug info
+		// Refresh data with debug info about categories
 		m.analyticsMessage = "Refreshing analytics data..."
-		m.loadAnalyticsData()
-
-		// Add debug info about categories
 		categories, err := m.store.Categories.GetCategories()
-		if err == nil {
-			m.analyticsMessage = fmt.Sprintf("Loaded analytics. Found %d categories total.", len(categories))
-		} else {
-			m.analyticsMessage = fmt.Sprintf("Refreshed analytics. Categories error: %v", err)
+		notice := fmt.Sprintf("Loaded analytics. Found %d categories total", len(categories))
+		if err != nil {
+			notice = fmt.Sprintf("Refreshed analytics. Categories error: %v", err)
+		}
+		return m, m.startAnalyticsLoad(notice)
+
+	case "c":
+		// Cycle the base currency used for reporting
+		return m.cycleBaseCurrency()
+
+	case "i":
+		// Import exchange rates from a CSV file
+		m.pickingRates = true
+		result := m.store.Statements.LoadDirectoryEntriesWithFallback(m.currentDir)
+		if !result.Success {
+			m.pickingRates = false
+			m.analyticsMessage = result.Message
+			return m, nil
 		}
+		m.dirEntries = result.Entries
+		m.currentDir = result.CurrentPath
+		m.fileIndex = 0
+		m.state = filePickerView
 		return m, nil
 
 	case "s":
@@ -171,8 +246,7 @@ func (m model) handleAnalyticsView(key string) (tea.Model, tea.Cmd) {
 			if date, err := m.parseDateInput(m.editingStartDateStr); err == nil {
 				m.analyticsStartDate = date
 				m.isEditingStartDate = false
-				m.loadAnalyticsData()
-				return m, nil
+				return m, m.startAnalyticsLoad("")
 			} else {
 				m.analyticsMessage = fmt.Sprintf("Invalid start date format: %v", err)
 			}
@@ -181,11 +255,12 @@ func (m model) handleAnalyticsView(key string) (tea.Model, tea.Cmd) {
 			if date, err := m.parseDateInput(m.editingEndDateStr); err == nil {
 				m.analyticsEndDate = date
 				m.isEditingEndDate = false
-				m.loadAnalyticsData()
-				return m, nil
+				return m, m.startAnalyticsLoad("")
 			} else {
 				m.analyticsMessage = fmt.Sprintf("Invalid end date format: %v", err)
 			}
+		} else {
+			return m.drillDownCategory()
 		}
 		return m, nil
 
@@ -211,6 +286,70 @@ func (m model) handleAnalyticsView(key string) (tea.Model, tea.Cmd) {
 	}
 }
 
+// drillDownCategory lists the expenses behind the selected category row for the analytics period
+func (m model) drillDownCategory() (tea.Model, tea.Cmd) {
+	row := m.analyticsTable.Cursor()
+	if row < 0 || row >= len(m.categorySpending) {
+		return m, nil
+	}
+	spending := m.categorySpending[row]
+
+	query := storage.TransactionQuery{
+		StartDate:       m.analyticsStartDate,
+		EndDate:         m.analyticsEndDate,
+		CategoryIds:     []int64{spending.CategoryId},
+		TransactionType: "expense",
+		Sort:            storage.SortAmountDesc,
+	}
+	opened, err := m.openFilteredTransactions(query, analyticsView)
+	if err != nil {
+		m.analyticsMessage = fmt.Sprintf("Error loading transactions: %v", err)
+		return m, nil
+	}
+	opened.filteredTitle = spending.CategoryName + " (including subcategories)"
+	return opened, nil
+}
+
+// cycleBaseCurrency switches analytics to the next known currency and reloads the data
+func (m model) cycleBaseCurrency() (tea.Model, tea.Cmd) {
+	currencies, err := m.store.GetKnownCurrencies()
+	if err != nil {
+		m.analyticsMessage = fmt.Sprintf("Error loading currencies: %v", err)
+		return m, nil
+	}
+
+	current := m.store.UserPreferences.GetBaseCurrency()
+	next := currencies[0]
+	for i, currency := range currencies {
+		if currency == current {
+			next = currencies[(i+1)%len(currencies)]
+			break
+		}
+	}
+
+	if err := m.store.UserPreferences.SetBaseCurrency(next); err != nil {
+		m.analyticsMessage = fmt.Sprintf("Error setting base currency: %v", err)
+		return m, nil
+	}
+
+	return m, m.startAnalyticsLoad("")
+}
+
+// handleRatesFileSelection imports an exchange-rate CSV and returns to analytics
+func (m model) handleRatesFileSelection(path string) (tea.Model, tea.Cmd) {
+	m.pickingRates = false
+	m.state = analyticsView
+
+	result, err := m.store.ExchangeRates.ImportRatesCSV(path)
+	if err != nil {
+		m.analyticsMessage = fmt.Sprintf("Error importing rates: %v", err)
+		return m, nil
+	}
+
+	// Keep any remaining conversion error visible next to the import outcome
+	return m, m.startAnalyticsLoad(result.Message)
+}
+
 // parseDateInput parses date input in various formats and returns time.Time
 func (m *model) parseDateInput(input string) (time.Time, error) {
 	// Try multiple date formats
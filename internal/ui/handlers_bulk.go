@@ -2,7 +2,7 @@ package ui
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"strconv"
 	"strings"
 	"time"
@@ -16,25 +16,14 @@ import (
 
 // handleMultiSelectToggle toggles multi-select mode for bulk operations
 func (m model) handleMultiSelectToggle() (tea.Model, tea.Cmd) {
-	if file, err := tea.LogToFile("debug.log", "debug"); err == nil {
-		defer file.Close()
-		log.Printf("handleMultiSelectToggle called: current mode=%v", m.isMultiSelectMode)
-	}
-
 	if !m.isMultiSelectMode {
 		// Enter multi-select mode
 		m.isMultiSelectMode = true
 		m.selectedTxIds = make(map[int64]bool)
-		if file, err := tea.LogToFile("debug.log", "debug"); err == nil {
-			defer file.Close()
-			log.Printf("Entered multi-select mode")
-		}
+		slog.Debug("entered multi-select mode")
 	} else {
 		// Exit multi-select mode
-		if file, err := tea.LogToFile("debug.log", "debug"); err == nil {
-			defer file.Close()
-			log.Printf("Exiting multi-select mode")
-		}
+		slog.Debug("exiting multi-select mode", "selected", len(m.selectedTxIds))
 		return m.exitMultiSelectMode()
 	}
 	return m, nil
@@ -170,45 +159,44 @@ func (m model) handleSaveBulkEdit() (tea.Model, tea.Cmd) {
 	}
 
 	// Validate individual transactions that will be modified
+	selected := m.selectedTransactions()
 	var brokenTransactions []int64
-	for i := range m.transactions {
-		if m.selectedTxIds[m.transactions[i].Id] {
-			tempTx := m.transactions[i] // Copy current transaction
+	for i := range selected {
+		tempTx := selected[i] // Copy current transaction
 
-			// Apply changes to temp transaction for validation
-			if !m.bulkAmountIsPlaceholder && strings.TrimSpace(m.bulkAmountValue) != "" {
-				if amount, err := strconv.ParseFloat(m.bulkAmountValue, 64); err == nil {
-					tempTx.Amount = amount
-				}
-			}
-			if !m.bulkDescriptionIsPlaceholder && strings.TrimSpace(m.bulkDescriptionValue) != "" {
-				tempTx.Description = m.bulkDescriptionValue
+		// Apply changes to temp transaction for validation
+		if !m.bulkAmountIsPlaceholder && strings.TrimSpace(m.bulkAmountValue) != "" {
+			if amount, err := types.ParseMoney(m.bulkAmountValue, tempTx.Amount.Currency); err == nil {
+				tempTx.Amount = amount
 			}
-			if !m.bulkDateIsPlaceholder && strings.TrimSpace(m.bulkDateValue) != "" {
-				// Parse date string into time.Time
-				if normalizedDate, err := types.NormalizeDateToISO8601(m.bulkDateValue, ""); err == nil {
-					if parsedDate, parseErr := time.Parse("2006-01-02", normalizedDate); parseErr == nil {
-						tempTx.Date = parsedDate
-					}
-				}
-			}
-			if !m.bulkCategoryIsPlaceholder && strings.TrimSpace(m.bulkCategoryValue) != "" {
-				// Find category by display name
-				if category := m.store.Categories.GetCategoryByDisplayName(m.bulkCategoryValue); category != nil {
-					tempTx.CategoryId = category.Id
+		}
+		if !m.bulkDescriptionIsPlaceholder && strings.TrimSpace(m.bulkDescriptionValue) != "" {
+			tempTx.Description = m.bulkDescriptionValue
+		}
+		if !m.bulkDateIsPlaceholder && strings.TrimSpace(m.bulkDateValue) != "" {
+			// Parse date string into time.Time
+			if normalizedDate, err := types.NormalizeDateToISO8601(m.bulkDateValue, ""); err == nil {
+				if parsedDate, parseErr := time.Parse("2006-01-02", normalizedDate); parseErr == nil {
+					tempTx.Date = parsedDate
 				}
 			}
-			if !m.bulkTypeIsPlaceholder && strings.TrimSpace(m.bulkTypeValue) != "" {
-				tempTx.TransactionType = m.bulkTypeValue
+		}
+		if !m.bulkCategoryIsPlaceholder && strings.TrimSpace(m.bulkCategoryValue) != "" {
+			// Find category by display name
+			if category := m.store.Categories.GetCategoryByDisplayName(m.bulkCategoryValue); category != nil {
+				tempTx.CategoryId = category.Id
 			}
+		}
+		if !m.bulkTypeIsPlaceholder && strings.TrimSpace(m.bulkTypeValue) != "" {
+			tempTx.TransactionType = m.bulkTypeValue
+		}
 
-			// Validate the modified transaction
-			categories, _ := m.store.Categories.GetCategories()
+		// Validate the modified transaction
+		categories, _ := m.store.Categories.GetCategories()
 
-			result := m.validator.ValidateTransaction(&tempTx, categories)
-			if !result.IsValid {
-				brokenTransactions = append(brokenTransactions, tempTx.Id)
-			}
+		result := m.validator.ValidateTransaction(&tempTx, categories)
+		if !result.IsValid {
+			brokenTransactions = append(brokenTransactions, tempTx.Id)
 		}
 	}
 
@@ -219,19 +207,19 @@ func (m model) handleSaveBulkEdit() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
-	// All validations passed, proceed with save
-	for i := range m.transactions {
-		if m.selectedTxIds[m.transactions[i].Id] {
+	// All validations passed, proceed with save as a single undoable action
+	m.store.Undo.Group(fmt.Sprintf("Bulk edit %d transactions", len(selected)), func() error {
+		for i := range selected {
 			// Apply amount if modified
 			if !m.bulkAmountIsPlaceholder && strings.TrimSpace(m.bulkAmountValue) != "" {
-				if amount, err := strconv.ParseFloat(m.bulkAmountValue, 64); err == nil {
-					m.transactions[i].Amount = amount
+				if amount, err := types.ParseMoney(m.bulkAmountValue, selected[i].Amount.Currency); err == nil {
+					selected[i].Amount = amount
 				}
 			}
 
 			// Apply description if modified
 			if !m.bulkDescriptionIsPlaceholder && strings.TrimSpace(m.bulkDescriptionValue) != "" {
-				m.transactions[i].Description = m.bulkDescriptionValue
+				selected[i].Description = m.bulkDescriptionValue
 			}
 
 			// Apply date if modified
@@ -239,7 +227,7 @@ func (m model) handleSaveBulkEdit() (tea.Model, tea.Cmd) {
 				// Parse date string into time.Time
 				if normalizedDate, err := types.NormalizeDateToISO8601(m.bulkDateValue, ""); err == nil {
 					if parsedDate, parseErr := time.Parse("2006-01-02", normalizedDate); parseErr == nil {
-						m.transactions[i].Date = parsedDate
+						selected[i].Date = parsedDate
 					}
 				}
 			}
@@ -248,27 +236,25 @@ func (m model) handleSaveBulkEdit() (tea.Model, tea.Cmd) {
 			if !m.bulkCategoryIsPlaceholder && strings.TrimSpace(m.bulkCategoryValue) != "" {
 				// Find category by display name
 				if category := m.store.Categories.GetCategoryByDisplayName(m.bulkCategoryValue); category != nil {
-					m.transactions[i].CategoryId = category.Id
+					selected[i].CategoryId = category.Id
 				}
 			}
 
 			// Apply type if modified
 			if !m.bulkTypeIsPlaceholder && strings.TrimSpace(m.bulkTypeValue) != "" {
-				m.transactions[i].TransactionType = m.bulkTypeValue
+				selected[i].TransactionType = m.bulkTypeValue
 			}
 
-			m.store.Transactions.SaveTransaction(m.transactions[i])
+			m.store.Transactions.SaveTransaction(selected[i])
 		}
-	}
+		return nil
+	})
 
-	m.transactions, _ = m.store.Transactions.GetTransactions()
-	m.sortTransactionsByDate()
+	m.loadTransactions()
 
 	// Reload filtered transactions if we came from statement transaction view
 	if m.previousState == statementTransactionListView {
-		if filteredTransactions, err := m.store.Transactions.GetTransactionsByStatement(m.currentStatementId); err == nil {
-			m.filteredTransactions = filteredTransactions
-		}
+		m.loadFilteredTransactions()
 	}
 
 	m.state = m.previousState // Return to previous state instead of hardcoded listView
@@ -1,8 +1,8 @@
 package ui
 
 import (
-	"log"
-	"strconv"
+	"budget-tracker-tui/internal/types"
+	"log/slog"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -29,6 +29,9 @@ func (m model) handleEditView(key string) (tea.Model, tea.Cmd) {
 	if m.isSplitMode {
 		return m.handleSplitFieldEditing(key)
 	}
+	if m.txHistoryOpen {
+		return m.handleTransactionHistory(key)
+	}
 
 	switch key {
 	case "esc":
@@ -47,6 +50,8 @@ func (m model) handleEditView(key string) (tea.Model, tea.Cmd) {
 		} else {
 			return m.exitSplitMode()
 		}
+	case "h":
+		return m.openTransactionHistory()
 	case "ctrl+s": // Save entire transaction
 		return m.handleSaveTransaction()
 	case "down", "tab":
@@ -75,23 +80,23 @@ func (m model) handleSaveTransaction() (tea.Model, tea.Cmd) {
 
 	// Validate and save amount from edit string with proper formatting
 	if m.editField == editAmount && m.editAmountStr != "" {
-		if amount, err := strconv.ParseFloat(m.editAmountStr, 64); err == nil {
+		if amount, err := types.ParseMoney(m.editAmountStr, m.currTransaction.Amount.Currency); err == nil {
 			m.currTransaction.Amount = amount
 		}
 	}
 	m.editAmountStr = ""
 	err := m.store.Transactions.SaveTransaction(m.currTransaction)
 	if err != nil {
-		log.Printf("Error saving transaction: %v", err)
+		slog.Error("failed to save transaction", "transaction_id", m.currTransaction.Id, "error", err)
 	} else {
-		m.transactions, _ = m.store.Transactions.GetTransactions()
-		m.sortTransactionsByDate()
+		m.loadTransactions()
 		// Reload filtered transactions if we came from statement transaction view
 		if m.previousState == statementTransactionListView {
-			filteredTransactions, err := m.store.Transactions.GetTransactionsByStatement(m.currentStatementId)
-			if err == nil {
-				m.filteredTransactions = filteredTransactions
-			}
+			m.loadFilteredTransactions()
+		}
+		// Rerun the search so edited descriptions are matched and highlighted again
+		if m.previousState == transactionSearchView {
+			m.runTransactionSearch()
 		}
 	}
 	m.state = m.previousState // Return to previous state instead of hardcoded listView
@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Transaction history pane (part of the edit view)
+
+// openTransactionHistory shows the audit history of the transaction being edited
+func (m model) openTransactionHistory() (tea.Model, tea.Cmd) {
+	if m.currTransaction.Id == 0 {
+		return m, nil
+	}
+	m.txHistoryOpen = true
+	m.txHistoryIndex = 0
+	m.txHistoryMessage = ""
+	m.loadTransactionHistory()
+	return m, nil
+}
+
+// loadTransactionHistory refreshes the history events of the transaction being edited, newest first
+func (m *model) loadTransactionHistory() {
+	events, err := m.store.TransactionAudits.GetEventsByTransaction(m.currTransaction.Id)
+	if err != nil {
+		m.txHistoryMessage = "Error loading history: " + err.Error()
+		return
+	}
+	m.txHistoryEvents = events
+
+	if m.txHistoryIndex >= len(m.txHistoryEvents) {
+		m.txHistoryIndex = len(m.txHistoryEvents) - 1
+	}
+	if m.txHistoryIndex < 0 {
+		m.txHistoryIndex = 0
+	}
+}
+
+// handleTransactionHistory handles browsing the history pane and reverting to a version
+func (m model) handleTransactionHistory(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "up":
+		if m.txHistoryIndex > 0 {
+			m.txHistoryIndex--
+		}
+	case "down":
+		if m.txHistoryIndex < len(m.txHistoryEvents)-1 {
+			m.txHistoryIndex++
+		}
+	case "r":
+		if len(m.txHistoryEvents) == 0 {
+			return m, nil
+		}
+		event := m.txHistoryEvents[m.txHistoryIndex]
+		if err := m.store.Transactions.RevertTransactionToEvent(event.Id); err != nil {
+			m.txHistoryMessage = "Cannot revert: " + err.Error()
+			return m, nil
+		}
+		m.refreshAfterUndo()
+		m.txHistoryIndex = 0
+		m.loadTransactionHistory()
+		m.txHistoryMessage = fmt.Sprintf("Reverted to the version from %s (Ctrl+Z to undo)",
+			event.Timestamp.Local().Format("01/02/2006 15:04"))
+	case "h", "esc":
+		m.txHistoryOpen = false
+		m.txHistoryEvents = nil
+		m.txHistoryMessage = ""
+	}
+	return m, nil
+}
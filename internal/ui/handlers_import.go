@@ -1,6 +1,8 @@
 package ui
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -26,10 +28,14 @@ func (m model) handleBackupView(key string) (tea.Model, tea.Cmd) {
 		m.snapshotMessage = ""
 	case "l":
 		// Load snapshot - open file picker
+		m.mergingLedger = false
 		m.state = snapshotLoadPickerView
 		m.snapshotMessage = ""
 		m.snapshotFileIndex = 0
 		return m.loadSnapshotDirectory()
+	case "m":
+		// Merge another ledger file into this one
+		return m.enterMergePicker()
 	}
 	return m, nil
 }
@@ -38,6 +44,11 @@ func (m model) handleBackupView(key string) (tea.Model, tea.Cmd) {
 func (m model) handleFilePickerView(key string) (tea.Model, tea.Cmd) {
 	switch key {
 	case "esc":
+		if m.pickingRates {
+			m.pickingRates = false
+			m.state = analyticsView
+			return m, nil
+		}
 		m.state = bankStatementView
 	case "up":
 		if m.fileIndex > 0 {
@@ -79,8 +90,39 @@ func (m model) handleFileSelection() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
-	// Handle CSV file selection
+	// Exchange-rate files go back to analytics instead of the statement import flow
+	if m.pickingRates && strings.HasSuffix(strings.ToLower(selected), ".csv") {
+		return m.handleRatesFileSelection(fullPath)
+	}
+
+	// Statement files such as OFX describe their own columns and import without a template
+	if format := types.StatementFileFormat(selected); format != "" && format != types.StatementFormatCSV && !m.pickingRates {
+		m.selectedTemplate = ""
+		m.selectedFile = fullPath
+		return m.enterAccountSelection()
+	}
+
+	// Handle CSV file selection - ask which account it belongs to before importing
 	if strings.HasSuffix(strings.ToLower(selected), ".csv") {
+		m.importDetected = false
+		if detection, err := m.store.DetectCSVTemplate(fullPath); err == nil {
+			if detection.Template != nil {
+				m.importDetected = true
+				m.selectedTemplate = detection.Template.Name
+				m.selectedFile = fullPath
+				return m.enterAccountSelection()
+			}
+
+			// No saved template reads the file, so propose one for the user to review
+			m.clearTemplateEditingState()
+			m.newTemplate = *detection.Draft
+			m.createField = templateName
+			m.createMessage = fmt.Sprintf("No template fits %s - review the detected columns and save", selected)
+			m.draftTemplateFile = fullPath
+			m.state = createTemplateView
+			return m, nil
+		}
+
 		templateToUse := m.store.Templates.GetDefaultTemplate()
 		if templateToUse == "" {
 			templates, _ := m.store.Templates.GetCSVTemplates()
@@ -89,38 +131,70 @@ func (m model) handleFileSelection() (tea.Model, tea.Cmd) {
 			}
 		}
 
-		result := m.store.ValidateAndImportCSV(fullPath, templateToUse)
+		m.selectedTemplate = templateToUse
+		m.selectedFile = fullPath
+		return m.enterAccountSelection()
+	}
 
-		// Check for validation errors first
-		if result.HasValidationErrors {
-			m.validationErrors = result.ValidationErrors
-			m.statementMessage = result.Message
-			m.state = validationErrorView
-			return m, nil
-		}
+	return m, nil
+}
 
-		if result.OverlapDetected {
-			m.overlappingStmts = result.OverlappingStmts
-			m.selectedTemplate = templateToUse
-			m.selectedFile = fullPath
-			// Store current import details for overlap warning
-			m.currentImportFilename = result.Filename
-			m.currentImportPeriodStart = result.PeriodStart
-			m.currentImportPeriodEnd = result.PeriodEnd
-			m.state = statementOverlapView
-			return m, nil
-		}
+// importFinishedMsg carries the outcome of a background import
+type importFinishedMsg struct {
+	result *types.ImportResult
+}
 
-		if result.Success {
-			m.transactions, _ = m.store.Transactions.GetTransactions()
-			m.sortTransactionsByDate()
-			// Clear any existing bank statement list message for fresh display
-			m.bankStatementListMessage = ""
-		}
+// importSelectedFile imports the selected file into the chosen account in the background
+// CSV files are staged for review first and imported from the review.
+func (m model) importSelectedFile() (tea.Model, tea.Cmd) {
+	filePath, accountId := m.selectedFile, m.importAccountId
+	if types.StatementFileFormat(filePath) == types.StatementFormatCSV {
+		return m.stageSelectedFile()
+	}
+	cmd := m.startJob("Importing "+filepath.Base(filePath), func(ctx context.Context, progress types.ProgressFunc) tea.Msg {
+		return importFinishedMsg{result: m.store.ImportStatementFileContext(ctx, filePath, accountId, progress)}
+	})
+	return m, cmd
+}
+
+// finishImport shows the outcome of an import, or the validation errors or overlaps that stopped it
+func (m model) finishImport(msg importFinishedMsg) (tea.Model, tea.Cmd) {
+	result := msg.result
+
+	// Check for validation errors first
+	if result.HasValidationErrors {
+		m.validationErrors = result.ValidationErrors
+		m.validationValidCount = result.ValidCount
 		m.statementMessage = result.Message
-		m.state = bankStatementView
+		m.state = validationErrorView
+		return m, nil
+	}
+
+	// A cancelled commit goes back to the review with its edits
+	if result.Cancelled && m.importStaging != nil {
+		m.reviewMessage = result.Message
+		m.state = importReviewView
+		return m, nil
+	}
+
+	if result.OverlapDetected {
+		m.overlappingStmts = result.OverlappingStmts
+		// Store current import details for overlap warning
+		m.currentImportFilename = result.Filename
+		m.currentImportPeriodStart = result.PeriodStart
+		m.currentImportPeriodEnd = result.PeriodEnd
+		m.state = statementOverlapView
+		return m, nil
 	}
 
+	if result.Success {
+		m.loadTransactions()
+		// Clear any existing bank statement list message for fresh display
+		m.bankStatementListMessage = ""
+	}
+	m.importStaging = nil
+	m.statementMessage = result.Message
+	m.state = bankStatementView
 	return m, nil
 }
 
@@ -160,9 +234,9 @@ func (m *model) loadDirectoryEntries() error {
 		}
 	}
 
-	// Add CSV files
+	// Add importable statement files
 	for _, entry := range entries {
-		if !entry.IsDir() && strings.HasSuffix(strings.ToLower(entry.Name()), ".csv") {
+		if !entry.IsDir() && types.StatementFileFormat(entry.Name()) != "" {
 			m.dirEntries = append(m.dirEntries, entry.Name())
 		}
 	}
@@ -245,11 +319,22 @@ func (m model) handleCreateTemplateView(key string) (tea.Model, tea.Cmd) {
 	if m.isEditingTemplateCategory {
 		return m.handleTemplateCategoryInput(key)
 	}
+	if m.isEditingTemplateCurrency {
+		return m.handleTemplateCurrencyInput(key)
+	}
+	if m.isEditingTemplateColumn {
+		return m.handleTemplateColumnInput(key)
+	}
 
 	// Handle navigation and general commands
 	switch key {
 	case "esc":
 		m.state = csvTemplateView
+		if m.draftTemplateFile != "" {
+			// Back to the files when the draft was proposed for a picked file
+			m.draftTemplateFile = ""
+			m.state = filePickerView
+		}
 		m.createMessage = ""
 	case "down", "tab":
 		return m.handleTemplateFieldNavigation(1)
@@ -274,6 +359,18 @@ func (m model) handleTemplateSave() (tea.Model, tea.Cmd) {
 
 	// Use store's business logic to create template
 	result := m.store.Templates.CreateCSVTemplate(m.newTemplate)
+	if result.Success && m.draftTemplateFile != "" {
+		// A draft proposed for a picked file goes on to import it
+		m.selectedTemplate = m.newTemplate.Name
+		m.selectedFile = m.draftTemplateFile
+		m.draftTemplateFile = ""
+		m.importDetected = false
+		m.newTemplate = types.CSVTemplate{}
+		m.createField = templateName
+		m.createMessage = ""
+		m.clearTemplateEditingState()
+		return m.enterAccountSelection()
+	}
 	if result.Success {
 		m.createMessage = "Template created successfully"
 		m.state = csvTemplateView
@@ -293,11 +390,15 @@ func (m *model) clearTemplateEditingState() {
 	m.isEditingTemplateAmount = false
 	m.isEditingTemplateDesc = false
 	m.isEditingTemplateCategory = false
+	m.isEditingTemplateCurrency = false
+	m.isEditingTemplateColumn = false
 	m.editingTemplateNameStr = ""
 	m.editingTemplatePostDateStr = ""
 	m.editingTemplateAmountStr = ""
 	m.editingTemplateDescStr = ""
 	m.editingTemplateCategoryStr = ""
+	m.editingTemplateCurrencyStr = ""
+	m.editingTemplateColumnStr = ""
 	m.templateFieldErrors = make(map[string]string)
 	m.templateValidationErrors = false
 	m.templateValidationNotification = ""
@@ -307,11 +408,22 @@ func (m *model) clearTemplateEditingState() {
 
 func (m model) handleValidationErrorView(key string) (tea.Model, tea.Cmd) {
 	switch key {
+	case "i":
+		if m.importStaging == nil || m.validationValidCount == 0 {
+			return m, nil
+		}
+		// Import the rows that parsed and save the failed ones to a reject file
+		m.validationErrors = nil
+		m.importStaging.Mode = types.SkipInvalid
+		return m.commitStagedImport(false)
 	case "esc":
-		// Clear validation errors and return to file picker
+		// Clear validation errors and return to the review, or the file picker without one
 		m.validationErrors = nil
 		m.statementMessage = ""
 		m.state = filePickerView
+		if m.importStaging != nil {
+			m.state = importReviewView
+		}
 	}
 	return m, nil
 }
@@ -383,6 +495,7 @@ func (m model) handleSnapshotLoadPickerView(key string) (tea.Model, tea.Cmd) {
 		// Return to backup view
 		m.state = backupView
 		m.snapshotMessage = ""
+		m.mergingLedger = false
 	case "up":
 		if m.snapshotFileIndex > 0 {
 			m.snapshotFileIndex--
@@ -446,32 +559,40 @@ func (m model) handleSnapshotSaveSelection() (tea.Model, tea.Cmd) {
 	}
 
 	// Save snapshot to selected directory
-	snapshotPath := filepath.Join(m.currentSnapshotDir, m.snapshotName+".db")
-	err := m.store.Snapshots.CreateSnapshotFile(snapshotPath)
-
-	if err == nil {
-		m.snapshotMessage = fmt.Sprintf("Snapshot saved successfully: %s", snapshotPath)
-		m.state = backupView
-	} else {
-		m.snapshotMessage = fmt.Sprintf("Failed to save snapshot: %s", err.Error())
-	}
-
-	return m, nil
+	return m.saveSnapshot()
 }
 
 // Handle saving to current directory
 func (m model) handleSnapshotSaveToCurrentDirectory() (tea.Model, tea.Cmd) {
-	// Save snapshot to current directory
+	return m.saveSnapshot()
+}
+
+// snapshotFinishedMsg carries the outcome of a background snapshot save
+type snapshotFinishedMsg struct {
+	path string
+	err  error
+}
+
+// saveSnapshot writes the named snapshot into the current snapshot directory in the background
+func (m model) saveSnapshot() (tea.Model, tea.Cmd) {
 	snapshotPath := filepath.Join(m.currentSnapshotDir, m.snapshotName+".db")
-	err := m.store.Snapshots.CreateSnapshotFile(snapshotPath)
+	cmd := m.startJob("Saving snapshot "+filepath.Base(snapshotPath), func(ctx context.Context, progress types.ProgressFunc) tea.Msg {
+		return snapshotFinishedMsg{path: snapshotPath, err: m.store.CreateSnapshotFileContext(ctx, snapshotPath, progress)}
+	})
+	return m, cmd
+}
 
-	if err == nil {
-		m.snapshotMessage = fmt.Sprintf("Snapshot saved successfully: %s", snapshotPath)
+// finishSnapshot reports the outcome of a snapshot save
+func (m model) finishSnapshot(msg snapshotFinishedMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.err == nil:
+		m.snapshotMessage = fmt.Sprintf("Snapshot saved successfully: %s", msg.path)
 		m.state = backupView
-	} else {
-		m.snapshotMessage = fmt.Sprintf("Failed to save snapshot: %s", err.Error())
+	case errors.Is(msg.err, context.Canceled):
+		m.snapshotMessage = "Snapshot cancelled; no file was written"
+	default:
+		m.snapshotMessage = fmt.Sprintf("Failed to save snapshot: %s", msg.err.Error())
 	}
-
 	return m, nil
 }
 
@@ -502,27 +623,23 @@ func (m model) handleSnapshotLoadSelection() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	// Preview merging the selected ledger instead of replacing this one
+	if m.mergingLedger && strings.HasSuffix(strings.ToLower(selected), ".db") {
+		return m.planLedgerMerge(fullPath)
+	}
+
 	// Load snapshot from selected file
 	if strings.HasSuffix(strings.ToLower(selected), ".db") {
-		// Create backup of current database before restore
-		backupPath, err := m.createCurrentDatabaseBackup()
+		// Backs up the current database, swaps in the snapshot and reconnects every store
+		result, err := m.store.RestoreFromSnapshotFile(fullPath)
 		if err != nil {
-			m.snapshotMessage = fmt.Sprintf("Failed to create backup before restore: %s", err.Error())
-			return m, nil
-		}
-
-		// Simple restore by copying file and reinitializing database
-		err = m.restoreFromSnapshotFile(fullPath)
-
-		if err == nil {
-			// Successful restore - reload all data
-			m.transactions, _ = m.store.Transactions.GetTransactions()
-			// Reset UI state
-			m.listIndex = 0
-			m.snapshotMessage = fmt.Sprintf("Snapshot restored successfully from: %s (backup saved to: %s)", fullPath, backupPath)
-			m.state = backupView
-		} else {
 			m.snapshotMessage = fmt.Sprintf("Failed to restore snapshot: %s", err.Error())
+		} else if !result.Success {
+			m.snapshotMessage = fmt.Sprintf("Failed to restore snapshot: %s", result.Message)
+		} else {
+			m.reloadFromStore()
+			m.snapshotMessage = fmt.Sprintf("Snapshot restored successfully: %d transactions loaded. %s", result.TxCount, result.Message)
+			m.state = backupView
 		}
 	} else {
 		m.snapshotMessage = "Please select a .db file to load"
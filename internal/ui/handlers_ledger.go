@@ -0,0 +1,138 @@
+package ui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Ledger Switch View
+
+// enterLedgerSwitcher opens the ledger switcher with the recent ledgers list
+func (m model) enterLedgerSwitcher() (tea.Model, tea.Cmd) {
+	m.state = ledgerSwitchView
+	m.ledgerMessage = ""
+	m.ledgerPathInput = false
+	m.ledgerPathStr = ""
+	m.selectedLedgerIdx = 0
+	m.loadRecentLedgers()
+	return m, nil
+}
+
+// loadRecentLedgers refreshes the recent ledgers list
+func (m *model) loadRecentLedgers() {
+	m.ledgerEntries = nil
+	if m.recentLedgers == nil {
+		return
+	}
+
+	ledgers, err := m.recentLedgers.List()
+	if err != nil {
+		m.ledgerMessage = "Error loading recent ledgers: " + err.Error()
+		return
+	}
+	m.ledgerEntries = ledgers
+	if m.selectedLedgerIdx > len(m.ledgerEntries) {
+		m.selectedLedgerIdx = len(m.ledgerEntries)
+	}
+}
+
+// handleLedgerSwitchView handles choosing a recent ledger or typing a ledger path
+func (m model) handleLedgerSwitchView(key string) (tea.Model, tea.Cmd) {
+	if m.ledgerPathInput {
+		return m.handleLedgerPathInput(key)
+	}
+
+	switch key {
+	case "up":
+		if m.selectedLedgerIdx > 0 {
+			m.selectedLedgerIdx--
+		}
+	case "down":
+		// The extra last entry opens the path input
+		if m.selectedLedgerIdx < len(m.ledgerEntries) {
+			m.selectedLedgerIdx++
+		}
+	case "enter":
+		if m.selectedLedgerIdx < len(m.ledgerEntries) {
+			return m.switchLedger(m.ledgerEntries[m.selectedLedgerIdx].Path)
+		}
+		m.ledgerPathInput = true
+		m.ledgerPathStr = ""
+		m.ledgerMessage = ""
+	case "d":
+		if m.selectedLedgerIdx >= len(m.ledgerEntries) || m.recentLedgers == nil {
+			return m, nil
+		}
+		path := m.ledgerEntries[m.selectedLedgerIdx].Path
+		if path == m.store.GetDatabasePath() {
+			m.ledgerMessage = "The open ledger cannot be removed from the list"
+			return m, nil
+		}
+		if err := m.recentLedgers.Remove(path); err != nil {
+			m.ledgerMessage = "Error updating recent ledgers: " + err.Error()
+			return m, nil
+		}
+		m.loadRecentLedgers()
+		m.ledgerMessage = fmt.Sprintf("Removed %s from recent ledgers (the file was not deleted)", path)
+	case "q", "esc":
+		m.state = menuView
+	}
+	return m, nil
+}
+
+// handleLedgerPathInput handles typing the path of a ledger file to open
+func (m model) handleLedgerPathInput(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "enter":
+		if m.ledgerPathStr == "" {
+			m.ledgerMessage = "Enter a ledger file path"
+			return m, nil
+		}
+		return m.switchLedger(m.ledgerPathStr)
+	case "esc":
+		m.ledgerPathInput = false
+		m.ledgerPathStr = ""
+		m.ledgerMessage = ""
+	case "backspace":
+		if len(m.ledgerPathStr) > 0 {
+			m.ledgerPathStr = m.ledgerPathStr[:len(m.ledgerPathStr)-1]
+		}
+	default:
+		if len(key) == 1 {
+			m.ledgerPathStr += key
+		}
+	}
+	return m, nil
+}
+
+// switchLedger closes the open ledger, reopens the store on path and reloads all cached data
+func (m model) switchLedger(path string) (tea.Model, tea.Cmd) {
+	previousPath := m.store.GetDatabasePath()
+	err := m.store.SwitchLedger(path)
+
+	// Even a failed switch reopens the previous ledger, so cached data must be rebuilt either way
+	m.reloadFromStore()
+	if err != nil {
+		m.ledgerMessage = "Error switching ledger: " + err.Error()
+		return m, nil
+	}
+
+	currentPath := m.store.GetDatabasePath()
+	if currentPath == previousPath {
+		m.ledgerMessage = "Already using this ledger"
+		return m, nil
+	}
+
+	m.ledgerPathInput = false
+	m.ledgerPathStr = ""
+	m.ledgerMessage = fmt.Sprintf("Switched to ledger %s", currentPath)
+	if m.recentLedgers != nil {
+		if err := m.recentLedgers.Add(currentPath); err != nil {
+			m.ledgerMessage += " (could not update recent ledgers: " + err.Error() + ")"
+		}
+	}
+	m.selectedLedgerIdx = 0
+	m.loadRecentLedgers()
+	return m, nil
+}
@@ -1,8 +1,6 @@
 package ui
 
 import (
-	"fmt"
-
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -14,6 +12,10 @@ func (m model) handleListView(key string) (tea.Model, tea.Cmd) {
 			m.listIndex--
 		}
 	case "down":
+		// Fetch the next page before stepping past the last loaded row
+		if m.listIndex >= len(m.transactions)-1 {
+			m.loadMoreTransactions()
+		}
 		if len(m.transactions) > 0 && m.listIndex < len(m.transactions)-1 {
 			m.listIndex++
 		}
@@ -38,26 +40,33 @@ func (m model) handleListView(key string) (tea.Model, tea.Cmd) {
 		}
 	case "m":
 		return m.handleMultiSelectToggle()
+	case "/":
+		if !m.isMultiSelectMode && !m.pendingDeleteTx {
+			return m.enterTransactionSearch()
+		}
+	case "t":
+		if !m.isMultiSelectMode && !m.pendingDeleteTx {
+			return m.enterTrash()
+		}
 	case "enter":
 		if m.isMultiSelectMode && len(m.transactions) > 0 {
 			// Toggle selection for current transaction
 			return m.handleToggleSelection()
 		}
 	case "d":
-		if !m.isMultiSelectMode && !m.pendingDeleteTx {
+		if !m.isMultiSelectMode && !m.pendingDeleteTx && len(m.transactions) > 0 {
 			// Setup deletion confirmation
 			tx := m.transactions[m.listIndex]
 			m.pendingDeleteTx = true
 			m.deleteTransactionId = tx.Id
 			m.deleteTransactionDesc = tx.Description
-			m.deleteTransactionAmount = fmt.Sprintf("%.2f", tx.Amount)
+			m.deleteTransactionAmount = tx.Amount.Display()
 		}
 	case "y":
 		if m.pendingDeleteTx {
-			// Confirm deletion
+			// Confirm deletion; the transaction can still be restored from the trash
 			m.store.Transactions.DeleteTransaction(m.deleteTransactionId)
-			m.transactions, _ = m.store.Transactions.GetTransactions()
-			m.sortTransactionsByDate()
+			m.loadTransactions()
 			// Bounds checking for list index
 			if m.listIndex >= len(m.transactions) && len(m.transactions) > 0 {
 				m.listIndex = len(m.transactions) - 1
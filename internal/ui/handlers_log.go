@@ -0,0 +1,80 @@
+package ui
+
+import (
+	"log/slog"
+
+	"budget-tracker-tui/internal/logging"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Log View
+
+// logViewLimit is how many of the newest log entries the viewer loads
+const logViewLimit = 500
+
+// logLevels are the minimum levels the viewer cycles through with 'f'
+var logLevels = []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError}
+
+// enterLogView opens the log viewer scrolled to the newest entry
+func (m model) enterLogView() (tea.Model, tea.Cmd) {
+	m.state = logView
+	m.logMessage = ""
+	m.logMinLevel = logging.Level()
+	m.loadLogEntries()
+	return m, nil
+}
+
+// loadLogEntries reloads the log file at the current filter and moves to the newest entry
+func (m *model) loadLogEntries() {
+	path := logging.ActivePath()
+	if path == "" {
+		m.logEntries = nil
+		m.logMessage = "Logging is not set up"
+		return
+	}
+
+	entries, err := logging.ReadEntries(path, m.logMinLevel, logViewLimit)
+	if err != nil {
+		m.logMessage = "Error reading log: " + err.Error()
+		return
+	}
+	m.logEntries = entries
+	m.logIndex = len(entries) - 1
+	if m.logIndex < 0 {
+		m.logIndex = 0
+	}
+}
+
+// handleLogView handles scrolling and filtering the log viewer
+func (m model) handleLogView(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "up":
+		if m.logIndex > 0 {
+			m.logIndex--
+		}
+	case "down":
+		if m.logIndex < len(m.logEntries)-1 {
+			m.logIndex++
+		}
+	case "f":
+		// Cycle the minimum level shown
+		next := logLevels[0]
+		for i, lvl := range logLevels {
+			if lvl == m.logMinLevel {
+				next = logLevels[(i+1)%len(logLevels)]
+				break
+			}
+		}
+		m.logMinLevel = next
+		m.logMessage = ""
+		m.loadLogEntries()
+	case "r":
+		m.logMessage = ""
+		m.loadLogEntries()
+	case "q", "esc":
+		m.state = menuView
+		m.logEntries = nil
+	}
+	return m, nil
+}
@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"budget-tracker-tui/internal/types"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Maintenance View
+
+// enterMaintenance opens the integrity report for the open ledger
+func (m model) enterMaintenance() (tea.Model, tea.Cmd) {
+	m.state = maintenanceView
+	m.maintenanceIndex = 0
+	m.maintenanceConfirm = false
+	m.maintenanceMessage = ""
+	m.runIntegrityCheck()
+	return m, nil
+}
+
+// runIntegrityCheck refreshes the integrity report
+func (m *model) runIntegrityCheck() {
+	report, err := m.store.CheckIntegrity()
+	if err != nil {
+		m.maintenanceMessage = "Error checking ledger: " + err.Error()
+		return
+	}
+	m.integrityReport = report
+
+	if m.maintenanceIndex >= len(report.Issues) {
+		m.maintenanceIndex = len(report.Issues) - 1
+	}
+	if m.maintenanceIndex < 0 {
+		m.maintenanceIndex = 0
+	}
+}
+
+// handleMaintenanceView handles browsing the integrity report and repairing issues
+func (m model) handleMaintenanceView(key string) (tea.Model, tea.Cmd) {
+	if m.maintenanceConfirm {
+		return m.handleMaintenanceConfirm(key)
+	}
+
+	switch key {
+	case "up":
+		if m.maintenanceIndex > 0 {
+			m.maintenanceIndex--
+		}
+	case "down":
+		if m.integrityReport != nil && m.maintenanceIndex < len(m.integrityReport.Issues)-1 {
+			m.maintenanceIndex++
+		}
+	case "c":
+		m.runIntegrityCheck()
+		m.maintenanceMessage = "Ledger checked"
+	case "f":
+		if m.integrityReport != nil && m.integrityReport.RepairableCount() > 0 {
+			m.maintenanceConfirm = true
+			m.maintenanceMessage = ""
+		}
+	case "t":
+		m.maintenanceMessage = ""
+		cmd := m.startJob("Retraining categorizer", func(ctx context.Context, progress types.ProgressFunc) tea.Msg {
+			return retrainFinishedMsg{err: m.store.RetrainMLCategorizerContext(ctx, progress)}
+		})
+		return m, cmd
+	case "q", "esc":
+		m.state = menuView
+	}
+	return m, nil
+}
+
+// retrainFinishedMsg carries the outcome of retraining the categorizer in the background
+type retrainFinishedMsg struct {
+	err error
+}
+
+// finishRetrain reports the outcome of retraining the categorizer
+func (m model) finishRetrain(msg retrainFinishedMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.err == nil:
+		stats := m.store.GetMLCategorizerStats()
+		m.maintenanceMessage = fmt.Sprintf("Categorizer retrained with %v examples", stats["total_examples"])
+	case errors.Is(msg.err, context.Canceled):
+		m.maintenanceMessage = "Retraining cancelled; the previous categorizer is still in use"
+	default:
+		m.maintenanceMessage = "Error retraining categorizer: " + msg.err.Error()
+	}
+	return m, nil
+}
+
+// handleMaintenanceConfirm handles the y/n prompt before repairing the ledger
+func (m model) handleMaintenanceConfirm(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "y":
+		m.maintenanceConfirm = false
+		result, err := m.store.RepairIntegrity()
+		if err != nil {
+			m.maintenanceMessage = "Error repairing ledger: " + err.Error()
+			return m, nil
+		}
+
+		m.integrityReport = result.Remaining
+		m.maintenanceIndex = 0
+		m.loadTransactions()
+
+		m.maintenanceMessage = fmt.Sprintf("Repaired %d issue(s)", len(result.Repaired))
+		if len(result.Failed) > 0 {
+			m.maintenanceMessage += fmt.Sprintf("; %d failed: %s", len(result.Failed), result.Failed[0])
+		}
+	case "n", "esc":
+		m.maintenanceConfirm = false
+	}
+	return m, nil
+}
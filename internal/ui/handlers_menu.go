@@ -25,6 +25,16 @@ func (m model) handleMenuView(key string) (tea.Model, tea.Cmd) {
 		m.categoryMessage = ""
 		m.selectedCategoryIdx = 0
 		return m, m.loadCategories()
+	case "o":
+		m.state = accountListView
+		m.selectedAccountIdx = 0
+		m.loadAccountBalances()
+	case "l":
+		return m.enterLedgerSwitcher()
+	case "m":
+		return m.enterMaintenance()
+	case "v":
+		return m.enterLogView()
 	case "a":
 		m.state = analyticsView
 		m.analyticsMessage = ""
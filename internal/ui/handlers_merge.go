@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Ledger Merge
+
+// enterMergePicker opens the snapshot file picker to choose a ledger to merge in
+func (m model) enterMergePicker() (tea.Model, tea.Cmd) {
+	m.mergingLedger = true
+	m.state = snapshotLoadPickerView
+	m.snapshotMessage = ""
+	m.snapshotFileIndex = 0
+	return m.loadSnapshotDirectory()
+}
+
+// planLedgerMerge reads the chosen ledger file and shows what merging it would add
+func (m model) planLedgerMerge(path string) (tea.Model, tea.Cmd) {
+	plan, err := m.store.PlanMerge(path)
+	if err != nil {
+		m.snapshotMessage = fmt.Sprintf("Cannot merge %s: %s", path, err.Error())
+		return m, nil
+	}
+
+	m.mergePlan = plan
+	m.mergeIndex = 0
+	m.mergeMessage = ""
+	m.state = mergePreviewView
+	return m, nil
+}
+
+// handleMergePreviewView scrolls the transactions a merge will add and applies or cancels it
+func (m model) handleMergePreviewView(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "up":
+		if m.mergeIndex > 0 {
+			m.mergeIndex--
+		}
+	case "down":
+		if m.mergePlan != nil && m.mergeIndex < len(m.mergePlan.NewTransactions)-1 {
+			m.mergeIndex++
+		}
+	case "y", "enter":
+		result, err := m.store.ApplyMerge(m.mergePlan)
+		if err != nil {
+			m.mergeMessage = "Merge failed: " + err.Error()
+			return m, nil
+		}
+		m.reloadFromStore()
+		m.mergePlan = nil
+		m.mergingLedger = false
+		m.snapshotMessage = result.Message
+		m.state = backupView
+	case "esc", "n":
+		// Back to the picker to choose another file
+		m.mergePlan = nil
+		m.mergeMessage = ""
+		m.state = snapshotLoadPickerView
+	}
+	return m, nil
+}
@@ -0,0 +1,175 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"budget-tracker-tui/internal/storage"
+	"budget-tracker-tui/internal/types"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Import Review
+
+// importStagedMsg carries a CSV file parsed for review
+type importStagedMsg struct {
+	staging *storage.ImportStaging
+	err     error
+}
+
+// stageSelectedFile parses the selected CSV file in the background for review before importing
+func (m model) stageSelectedFile() (tea.Model, tea.Cmd) {
+	filePath, templateName, accountId := m.selectedFile, m.selectedTemplate, m.importAccountId
+	cmd := m.startJob("Reading "+filepath.Base(filePath), func(ctx context.Context, progress types.ProgressFunc) tea.Msg {
+		staging, err := m.store.StageCSVImportContext(ctx, filePath, templateName, accountId, progress)
+		return importStagedMsg{staging: staging, err: err}
+	})
+	return m, cmd
+}
+
+// finishStaging opens the review of a parsed file, or reports why it could not be read
+func (m model) finishStaging(msg importStagedMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case errors.Is(msg.err, context.Canceled):
+		m.statementMessage = fmt.Sprintf("Import of %s cancelled; nothing was saved", filepath.Base(m.selectedFile))
+		m.state = bankStatementView
+	case msg.err != nil:
+		m.statementMessage = "Import failed: " + msg.err.Error()
+		m.state = bankStatementView
+	default:
+		m.importStaging = msg.staging
+		m.reviewIndex = 0
+		m.reviewMessage = ""
+		m.reviewEditingDesc = false
+		m.reviewPickingCategory = false
+		m.state = importReviewView
+	}
+	return m, nil
+}
+
+// commitStagedImport imports the reviewed rows in the background
+// With override set the rows are imported even though the statement overlaps an earlier one.
+func (m model) commitStagedImport(override bool) (tea.Model, tea.Cmd) {
+	staging := m.importStaging
+	cmd := m.startJob("Importing "+filepath.Base(staging.FilePath), func(ctx context.Context, progress types.ProgressFunc) tea.Msg {
+		return importFinishedMsg{result: m.store.CommitStagedImportContext(ctx, staging, override, progress)}
+	})
+	return m, cmd
+}
+
+// handleImportReviewView moves through the staged rows, edits and excludes them, and commits
+func (m model) handleImportReviewView(key string) (tea.Model, tea.Cmd) {
+	if m.importStaging == nil {
+		m.state = filePickerView
+		return m, nil
+	}
+	if m.reviewEditingDesc {
+		return m.handleReviewDescriptionInput(key)
+	}
+	if m.reviewPickingCategory {
+		return m.handleReviewCategoryPick(key)
+	}
+
+	rows := m.importStaging.Rows
+	row := &rows[m.reviewIndex]
+	m.reviewMessage = ""
+
+	switch key {
+	case "up":
+		if m.reviewIndex > 0 {
+			m.reviewIndex--
+		}
+	case "down":
+		if m.reviewIndex < len(rows)-1 {
+			m.reviewIndex++
+		}
+	case " ", "x":
+		if row.Error != nil {
+			m.reviewMessage = "Rows that failed to parse are never imported"
+			return m, nil
+		}
+		row.Excluded = !row.Excluded
+	case "e":
+		if row.Error != nil {
+			m.reviewMessage = "Rows that failed to parse cannot be edited"
+			return m, nil
+		}
+		m.reviewEditingDesc = true
+		m.reviewDescStr = row.Transaction.Description
+	case "c":
+		if row.Error != nil {
+			m.reviewMessage = "Rows that failed to parse cannot be edited"
+			return m, nil
+		}
+		categories, err := m.store.Categories.GetCategories()
+		if err != nil || len(categories) == 0 {
+			m.reviewMessage = "No categories to choose from"
+			return m, nil
+		}
+		m.reviewCategories = categories
+		m.reviewCategoryIdx = 0
+		for i, category := range categories {
+			if category.Id == row.Transaction.CategoryId {
+				m.reviewCategoryIdx = i
+			}
+		}
+		m.reviewPickingCategory = true
+	case "a":
+		// Rows that failed to parse stop the commit, which then offers to import the rest
+		m.importStaging.Mode = types.FailFast
+		return m.commitStagedImport(false)
+	case "esc":
+		m.importStaging = nil
+		m.state = filePickerView
+	}
+	return m, nil
+}
+
+// handleReviewDescriptionInput edits the description of the selected staged row
+func (m model) handleReviewDescriptionInput(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "enter":
+		if m.reviewDescStr == "" {
+			m.reviewMessage = "Description cannot be empty"
+			return m, nil
+		}
+		m.importStaging.Rows[m.reviewIndex].Transaction.Description = m.reviewDescStr
+		m.reviewEditingDesc = false
+		m.reviewDescStr = ""
+	case "esc":
+		m.reviewEditingDesc = false
+		m.reviewDescStr = ""
+	case "backspace":
+		if len(m.reviewDescStr) > 0 {
+			m.reviewDescStr = m.reviewDescStr[:len(m.reviewDescStr)-1]
+		}
+	default:
+		if len(key) == 1 {
+			m.reviewDescStr += key
+		}
+	}
+	return m, nil
+}
+
+// handleReviewCategoryPick chooses the category of the selected staged row
+func (m model) handleReviewCategoryPick(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "up":
+		if m.reviewCategoryIdx > 0 {
+			m.reviewCategoryIdx--
+		}
+	case "down":
+		if m.reviewCategoryIdx < len(m.reviewCategories)-1 {
+			m.reviewCategoryIdx++
+		}
+	case "enter":
+		m.importStaging.Rows[m.reviewIndex].Transaction.CategoryId = m.reviewCategories[m.reviewCategoryIdx].Id
+		m.reviewPickingCategory = false
+	case "esc":
+		m.reviewPickingCategory = false
+	}
+	return m, nil
+}
@@ -0,0 +1,81 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// searchResultLimit caps how many ranked matches the search view shows
+const searchResultLimit = 100
+
+// Transaction Search View
+
+// enterTransactionSearch opens an empty search prompt over the transaction list
+func (m model) enterTransactionSearch() (tea.Model, tea.Cmd) {
+	m.state = transactionSearchView
+	m.searchQuery = ""
+	m.searchResults = nil
+	m.searchIndex = 0
+	m.searchMessage = ""
+	return m, nil
+}
+
+// runTransactionSearch reruns the search for the current prompt text
+func (m *model) runTransactionSearch() {
+	results, err := m.store.Transactions.SearchTransactions(m.searchQuery, searchResultLimit)
+	if err != nil {
+		m.searchMessage = "Search error: " + err.Error()
+		return
+	}
+	m.searchResults = results
+	m.searchMessage = ""
+
+	if m.searchIndex >= len(m.searchResults) {
+		m.searchIndex = len(m.searchResults) - 1
+	}
+	if m.searchIndex < 0 {
+		m.searchIndex = 0
+	}
+}
+
+// handleTransactionSearchView handles typing the search and picking a result
+// Every printable key goes to the prompt, so results update live while typing.
+func (m model) handleTransactionSearchView(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "up":
+		if m.searchIndex > 0 {
+			m.searchIndex--
+		}
+	case "down":
+		if m.searchIndex < len(m.searchResults)-1 {
+			m.searchIndex++
+		}
+	case "enter":
+		if m.searchIndex < len(m.searchResults) {
+			m.currTransaction = m.searchResults[m.searchIndex].Transaction
+			m.editField = editAmount
+			m.editAmountStr = ""
+			m.previousState = transactionSearchView // Track where to return
+			m.state = editView
+		}
+	case "backspace":
+		if len(m.searchQuery) > 0 {
+			runes := []rune(m.searchQuery)
+			m.searchQuery = string(runes[:len(runes)-1])
+			m.searchIndex = 0
+			m.runTransactionSearch()
+		}
+	case "ctrl+u":
+		m.searchQuery = ""
+		m.searchIndex = 0
+		m.runTransactionSearch()
+	case "esc":
+		m.state = listView
+	default:
+		if len([]rune(key)) == 1 {
+			m.searchQuery += key
+			m.searchIndex = 0
+			m.runTransactionSearch()
+		}
+	}
+	return m, nil
+}
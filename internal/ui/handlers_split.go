@@ -3,7 +3,6 @@ package ui
 import (
 	"budget-tracker-tui/internal/types"
 	"fmt"
-	"math"
 	"strconv"
 	"strings"
 
@@ -18,10 +17,11 @@ func (m model) enterSplitMode() (tea.Model, tea.Cmd) {
 	m.splitField = splitAmount1Field
 	m.splitMessage = ""
 
-	// Pre-populate with half amounts (requirement E)
-	halfAmount := m.currTransaction.Amount / 2
-	m.splitAmount1 = fmt.Sprintf("%.2f", halfAmount)
-	m.splitAmount2 = fmt.Sprintf("%.2f", halfAmount)
+	// Pre-populate with half amounts (requirement E); any odd cent goes to part 2
+	amount := m.currTransaction.Amount
+	halfAmount := types.NewMoney(amount.Cents/2, amount.Currency)
+	m.splitAmount1 = halfAmount.String()
+	m.splitAmount2 = amount.Sub(halfAmount).String()
 
 	// Add default description values with part tags
 	m.splitDesc1 = m.currTransaction.Description + " (part 1)"
@@ -443,18 +443,19 @@ func (m model) handleSaveSplit() (tea.Model, tea.Cmd) {
 	}
 
 	// Parse amounts
-	amount1, err1 := strconv.ParseFloat(m.splitAmount1, 64)
-	amount2, err2 := strconv.ParseFloat(m.splitAmount2, 64)
+	currency := m.currTransaction.Amount.Currency
+	amount1, err1 := types.ParseMoney(m.splitAmount1, currency)
+	amount2, err2 := types.ParseMoney(m.splitAmount2, currency)
 
 	if err1 != nil || err2 != nil {
 		m.splitMessage = "Error: Invalid amount format"
 		return m, nil
 	}
 
-	// Validate amounts add up to original (with epsilon for floating point)
-	total := amount1 + amount2
-	if math.Abs(total-m.currTransaction.Amount) > 0.01 {
-		m.splitMessage = fmt.Sprintf("Error: Split amounts (%.2f) don't match original (%.2f)",
+	// Validate amounts add up exactly to original
+	total := amount1.Add(amount2)
+	if !total.Equal(m.currTransaction.Amount) {
+		m.splitMessage = fmt.Sprintf("Error: Split amounts (%s) don't match original (%s)",
 			total, m.currTransaction.Amount)
 		return m, nil
 	}
@@ -505,8 +506,7 @@ func (m model) handleSaveSplit() (tea.Model, tea.Cmd) {
 	}
 
 	// Refresh transactions and exit
-	m.transactions, _ = m.store.Transactions.GetTransactions()
-	m.sortTransactionsByDate()
+	m.loadTransactions()
 	m.state = listView
 	return m.exitSplitMode()
 }
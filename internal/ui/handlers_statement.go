@@ -1,8 +1,11 @@
 package ui
 
 import (
+	"budget-tracker-tui/internal/storage"
 	"budget-tracker-tui/internal/types"
+	"context"
 	"os"
+	"path/filepath"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -51,18 +54,24 @@ func (m model) handleBankStatementView(key string) (tea.Model, tea.Cmd) {
 func (m model) handleStatementOverlapView(key string) (tea.Model, tea.Cmd) {
 	switch key {
 	case "y":
-		// Use current template stored from file selection
-		result := m.store.ImportCSVWithOverride(m.selectedFile, m.selectedTemplate)
-		if result.Success {
-			m.transactions, _ = m.store.Transactions.GetTransactions()
-			m.sortTransactionsByDate()
-			// Clear any existing bank statement list message for fresh display
-			m.bankStatementListMessage = ""
-		}
-		m.statementMessage = result.Message
 		m.state = bankStatementView
+		if m.importStaging != nil {
+			// Import the reviewed rows as they are
+			return m.commitStagedImport(true)
+		}
+
+		// Use current template and account stored from file selection
+		filePath, templateName, accountId := m.selectedFile, m.selectedTemplate, m.importAccountId
+		cmd := m.startJob("Importing "+filepath.Base(filePath), func(ctx context.Context, progress types.ProgressFunc) tea.Msg {
+			return importFinishedMsg{result: m.store.ImportCSVWithOverrideContext(ctx, filePath, templateName, accountId, progress)}
+		})
+		return m, cmd
 	case "n", "esc":
 		m.state = bankStatementView
+		if m.importStaging != nil {
+			// Back to the review to exclude the rows already imported
+			m.state = importReviewView
+		}
 	}
 	return m, nil
 }
@@ -183,17 +192,13 @@ func (m model) executeStatementAction(stmt types.BankStatement, action string) (
 	switch action {
 	case "Manage Transactions":
 		// Load filtered transactions for this statement
-		filteredTransactions, err := m.store.Transactions.GetTransactionsByStatement(stmt.Id)
+		opened, err := m.openFilteredTransactions(storage.TransactionQuery{StatementId: stmt.Id}, bankStatementManageView)
 		if err != nil {
 			m.bankStatementListMessage = "Error loading transactions: " + err.Error()
 			m.state = bankStatementListView
 			return m, nil
 		}
-		m.filteredTransactions = filteredTransactions
-		m.currentStatementId = stmt.Id
-		m.filteredListIndex = 0
-		m.statementTxMessage = ""
-		m.state = statementTransactionListView
+		m = opened
 	case "Undo Import":
 		m.initUndoConfirmationById(stmt.Id)
 	case "Delete Statement":
@@ -1,11 +1,43 @@
 package ui
 
 import (
-	"fmt"
+	"budget-tracker-tui/internal/storage"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// openFilteredTransactions shows the transactions matching query in the filtered list view
+// Esc returns to returnState. Statement lists keep currentStatementId set for their header.
+func (m model) openFilteredTransactions(query storage.TransactionQuery, returnState uint) (model, error) {
+	m.filteredQuery = query
+	m.filteredListIndex = 0
+	if err := m.loadFilteredTransactions(); err != nil {
+		return m, err
+	}
+	m.currentStatementId = query.StatementId
+	m.filteredReturnState = returnState
+	m.statementTxMessage = ""
+	m.state = statementTransactionListView
+	return m, nil
+}
+
+// loadFilteredTransactions reruns the filtered list query, keeping the cursor in bounds
+func (m *model) loadFilteredTransactions() error {
+	transactions, err := m.store.Transactions.QueryTransactions(m.filteredQuery)
+	if err != nil {
+		return err
+	}
+	m.filteredTransactions = transactions
+
+	if m.filteredListIndex >= len(m.filteredTransactions) {
+		m.filteredListIndex = len(m.filteredTransactions) - 1
+	}
+	if m.filteredListIndex < 0 {
+		m.filteredListIndex = 0
+	}
+	return nil
+}
+
 // Statement transaction list handler
 func (m model) handleStatementTransactionListView(key string) (tea.Model, tea.Cmd) {
 	switch key {
@@ -50,7 +82,7 @@ func (m model) handleStatementTransactionListView(key string) (tea.Model, tea.Cm
 			m.pendingDeleteTx = true
 			m.deleteTransactionId = tx.Id
 			m.deleteTransactionDesc = tx.Description
-			m.deleteTransactionAmount = fmt.Sprintf("%.2f", tx.Amount)
+			m.deleteTransactionAmount = tx.Amount.Display()
 		}
 	case "y":
 		if m.pendingDeleteTx {
@@ -58,24 +90,12 @@ func (m model) handleStatementTransactionListView(key string) (tea.Model, tea.Cm
 			m.store.Transactions.DeleteTransaction(m.deleteTransactionId)
 
 			// Reload filtered transactions
-			filteredTransactions, err := m.store.Transactions.GetTransactionsByStatement(m.currentStatementId)
-			if err != nil {
+			if err := m.loadFilteredTransactions(); err != nil {
 				m.statementTxMessage = "Error reloading transactions: " + err.Error()
-			} else {
-				m.filteredTransactions = filteredTransactions
 			}
 
 			// Also reload main transaction list so deletion is reflected everywhere
-			m.transactions, _ = m.store.Transactions.GetTransactions()
-			m.sortTransactionsByDate()
-
-			// Bounds checking for list index
-			if m.filteredListIndex >= len(m.filteredTransactions) && len(m.filteredTransactions) > 0 {
-				m.filteredListIndex = len(m.filteredTransactions) - 1
-			}
-			if len(m.filteredTransactions) == 0 {
-				m.filteredListIndex = 0
-			}
+			m.loadTransactions()
 
 			// Clear confirmation state
 			m.pendingDeleteTx = false
@@ -103,9 +123,13 @@ func (m model) handleStatementTransactionListView(key string) (tea.Model, tea.Cm
 		if m.isMultiSelectMode {
 			return m.exitMultiSelectMode()
 		}
-		// Return to bank statement manage view instead of menu
-		m.state = bankStatementManageView
+		// Return to wherever the filtered list was opened from instead of menu
+		m.state = m.filteredReturnState
 		m.statementTxMessage = ""
+		if m.state == analyticsView {
+			// Edits made while drilled down change the totals
+			return m, m.startAnalyticsLoad("")
+		}
 	}
 	return m, nil
 }
@@ -0,0 +1,158 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+
+	"budget-tracker-tui/internal/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Permanent actions that wait for confirmation in the trash view
+const (
+	trashConfirmPurge = "purge"
+	trashConfirmEmpty = "empty"
+)
+
+// Trash View
+
+// enterTrash opens the list of deleted transactions
+func (m model) enterTrash() (tea.Model, tea.Cmd) {
+	m.state = trashView
+	m.trashIndex = 0
+	m.trashMessage = ""
+	m.trashConfirm = ""
+	m.trashRetentionInput = false
+	m.trashRetentionStr = ""
+	m.loadTrash()
+	return m, nil
+}
+
+// loadTrash refreshes the deleted transactions, most recently deleted first
+func (m *model) loadTrash() {
+	trashed, err := m.store.Transactions.QueryTransactions(storage.TransactionQuery{Deleted: storage.DeletedOnly})
+	if err != nil {
+		m.trashMessage = "Error loading trash: " + err.Error()
+		return
+	}
+	m.trashTransactions = trashed
+
+	if m.trashIndex >= len(m.trashTransactions) {
+		m.trashIndex = len(m.trashTransactions) - 1
+	}
+	if m.trashIndex < 0 {
+		m.trashIndex = 0
+	}
+}
+
+// handleTrashView handles restoring and purging deleted transactions
+func (m model) handleTrashView(key string) (tea.Model, tea.Cmd) {
+	if m.trashRetentionInput {
+		return m.handleTrashRetentionInput(key)
+	}
+	if m.trashConfirm != "" {
+		return m.handleTrashConfirm(key)
+	}
+
+	switch key {
+	case "up":
+		if m.trashIndex > 0 {
+			m.trashIndex--
+		}
+	case "down":
+		if m.trashIndex < len(m.trashTransactions)-1 {
+			m.trashIndex++
+		}
+	case "r":
+		if len(m.trashTransactions) == 0 {
+			return m, nil
+		}
+		tx := m.trashTransactions[m.trashIndex]
+		if err := m.store.Transactions.RestoreTransaction(tx.Id); err != nil {
+			m.trashMessage = "Error restoring transaction: " + err.Error()
+			return m, nil
+		}
+		m.loadTrash()
+		m.loadTransactions()
+		m.trashMessage = fmt.Sprintf("Restored '%s'", tx.Description)
+	case "p":
+		if len(m.trashTransactions) > 0 {
+			m.trashConfirm = trashConfirmPurge
+			m.trashMessage = ""
+		}
+	case "x":
+		if len(m.trashTransactions) > 0 {
+			m.trashConfirm = trashConfirmEmpty
+			m.trashMessage = ""
+		}
+	case "s":
+		m.trashRetentionInput = true
+		m.trashRetentionStr = strconv.Itoa(m.store.UserPreferences.GetTrashRetentionDays())
+		m.trashMessage = ""
+	case "q", "esc":
+		m.state = listView
+	}
+	return m, nil
+}
+
+// handleTrashConfirm handles the y/n prompt before permanently deleting transactions
+func (m model) handleTrashConfirm(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "y":
+		action := m.trashConfirm
+		m.trashConfirm = ""
+
+		if action == trashConfirmEmpty {
+			removed, err := m.store.Transactions.EmptyTrash()
+			if err != nil {
+				m.trashMessage = "Error emptying trash: " + err.Error()
+				return m, nil
+			}
+			m.trashMessage = fmt.Sprintf("Permanently deleted %d transaction(s)", removed)
+		} else if m.trashIndex < len(m.trashTransactions) {
+			tx := m.trashTransactions[m.trashIndex]
+			if err := m.store.Transactions.PurgeTransaction(tx.Id); err != nil {
+				m.trashMessage = "Error purging transaction: " + err.Error()
+				return m, nil
+			}
+			m.trashMessage = fmt.Sprintf("Permanently deleted '%s'", tx.Description)
+		}
+		m.loadTrash()
+	case "n", "esc":
+		m.trashConfirm = ""
+	}
+	return m, nil
+}
+
+// handleTrashRetentionInput handles typing how many days deleted transactions are kept
+func (m model) handleTrashRetentionInput(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "enter":
+		days, err := strconv.Atoi(m.trashRetentionStr)
+		if err != nil {
+			m.trashMessage = "Enter a whole number of days (0 keeps the trash until emptied)"
+			return m, nil
+		}
+		if err := m.store.UserPreferences.SetTrashRetentionDays(days); err != nil {
+			m.trashMessage = "Error saving retention: " + err.Error()
+			return m, nil
+		}
+		m.trashRetentionInput = false
+		m.trashRetentionStr = ""
+		m.trashMessage = "Trash retention updated; expired transactions are purged at startup"
+	case "esc":
+		m.trashRetentionInput = false
+		m.trashRetentionStr = ""
+		m.trashMessage = ""
+	case "backspace":
+		if len(m.trashRetentionStr) > 0 {
+			m.trashRetentionStr = m.trashRetentionStr[:len(m.trashRetentionStr)-1]
+		}
+	default:
+		if len(key) == 1 && key[0] >= '0' && key[0] <= '9' {
+			m.trashRetentionStr += key
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,77 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Undo / Redo (available in every view)
+
+// undoLastChange reverts the most recent change and refreshes the data on screen
+func (m model) undoLastChange() (tea.Model, tea.Cmd) {
+	label, err := m.store.Undo.Undo()
+	if err != nil {
+		m.historyMessage = "Undo failed: " + err.Error()
+		m.historyFailed = true
+		return m, nil
+	}
+
+	m.historyMessage = "Undid: " + label
+	m.historyFailed = false
+	m.refreshAfterUndo()
+	return m, nil
+}
+
+// redoLastChange reapplies the most recently undone change and refreshes the data on screen
+func (m model) redoLastChange() (tea.Model, tea.Cmd) {
+	label, err := m.store.Undo.Redo()
+	if err != nil {
+		m.historyMessage = "Redo failed: " + err.Error()
+		m.historyFailed = true
+		return m, nil
+	}
+
+	m.historyMessage = "Redid: " + label
+	m.historyFailed = false
+	m.refreshAfterUndo()
+	return m, nil
+}
+
+// refreshAfterUndo reloads every cached list an undo or redo may have changed
+func (m *model) refreshAfterUndo() {
+	m.loadTransactions()
+	if m.listIndex >= len(m.transactions) {
+		m.listIndex = len(m.transactions) - 1
+	}
+	if m.listIndex < 0 {
+		m.listIndex = 0
+	}
+
+	if len(m.filteredTransactions) > 0 || m.state == statementTransactionListView {
+		m.loadFilteredTransactions()
+	}
+	if len(m.categories) > 0 {
+		m.loadCategories()
+	}
+
+	switch m.state {
+	case editView:
+		// Show the reverted values instead of the stale form contents
+		if m.currTransaction.Id != 0 {
+			if current := m.store.Transactions.GetTransactionByID(m.currTransaction.Id); current != nil {
+				m.currTransaction = *current
+				m.editAmountStr = ""
+			}
+			if m.txHistoryOpen {
+				m.loadTransactionHistory()
+			}
+		}
+	case transactionSearchView:
+		m.runTransactionSearch()
+	case trashView:
+		m.loadTrash()
+	case analyticsView:
+		m.loadAnalyticsData()
+	case accountListView:
+		m.loadAccountBalances()
+	}
+}
@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"context"
+
+	"budget-tracker-tui/internal/types"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Background jobs
+//
+// Imports, snapshots, analytics and retraining run in a goroutine so Update never blocks on them.
+// The job streams progress over a channel that a tea.Cmd waits on, one message at a time.
+
+// progressBarWidth is the width of the job progress bar in cells
+const progressBarWidth = 40
+
+// backgroundJob is a long-running store operation started from the UI
+type backgroundJob struct {
+	id         int
+	title      string
+	cancel     context.CancelFunc
+	updates    chan tea.Msg
+	progress   types.Progress
+	cancelling bool // esc was pressed; waiting for the operation to roll back
+}
+
+// jobProgressMsg carries the latest progress of a running job
+type jobProgressMsg struct {
+	jobId    int
+	progress types.Progress
+}
+
+// jobDoneMsg carries the message a job produced when it finished
+type jobDoneMsg struct {
+	jobId  int
+	result tea.Msg
+}
+
+// startJob runs fn in the background and shows its progress until it finishes
+// fn receives a context that is cancelled by esc and returns the message handled once it is done.
+func (m *model) startJob(title string, fn func(ctx context.Context, progress types.ProgressFunc) tea.Msg) tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.jobSeq++
+	job := &backgroundJob{
+		id:      m.jobSeq,
+		title:   title,
+		cancel:  cancel,
+		updates: make(chan tea.Msg, 1),
+	}
+	m.job = job
+
+	go func() {
+		defer cancel()
+		report := func(progress types.Progress) {
+			// Replace an update the UI has not picked up yet so the worker never waits on rendering
+			select {
+			case <-job.updates:
+			default:
+			}
+			select {
+			case job.updates <- jobProgressMsg{jobId: job.id, progress: progress}:
+			default:
+			}
+		}
+		result := fn(ctx, report)
+		job.updates <- jobDoneMsg{jobId: job.id, result: result}
+	}()
+
+	return waitForJob(job.updates)
+}
+
+// waitForJob waits for the next message from a running job
+func waitForJob(updates chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-updates
+	}
+}
+
+// handleJobProgress records the latest progress and keeps listening
+func (m model) handleJobProgress(msg jobProgressMsg) (tea.Model, tea.Cmd) {
+	if m.job == nil || msg.jobId != m.job.id {
+		return m, nil
+	}
+	m.job.progress = msg.progress
+	return m, waitForJob(m.job.updates)
+}
+
+// handleJobDone clears the finished job and handles its result
+func (m model) handleJobDone(msg jobDoneMsg) (tea.Model, tea.Cmd) {
+	if m.job == nil || msg.jobId != m.job.id {
+		return m, nil
+	}
+	m.job = nil
+	if msg.result == nil {
+		return m, nil
+	}
+	return m.Update(msg.result)
+}
+
+// handleJobKey cancels the running job on esc; other keys wait until it is done
+func (m model) handleJobKey(key string) (tea.Model, tea.Cmd) {
+	if key == "esc" && !m.job.cancelling {
+		m.job.cancelling = true
+		m.job.cancel()
+	}
+	return m, nil
+}
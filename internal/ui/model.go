@@ -1,10 +1,11 @@
 package ui
 
 import (
+	"budget-tracker-tui/internal/logging"
 	"budget-tracker-tui/internal/storage"
 	"budget-tracker-tui/internal/validation"
 	"fmt"
-	"log"
+	"log/slog"
 	"sort"
 	"strings"
 	"time"
@@ -15,15 +16,19 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// transactionPageSize is how many transactions the main list loads at a time
+const transactionPageSize = 200
+
 // Main application model
 type model struct {
 	// Core state
-	state           uint
-	store           *storage.Store
-	transactions    []types.Transaction
-	currTransaction types.Transaction
-	listIndex       int
-	windowHeight    int
+	state            uint
+	store            *storage.Store
+	transactions     []types.Transaction // Loaded pages of the main list, newest first
+	transactionTotal int                 // Transactions in the ledger, loaded or not
+	currTransaction  types.Transaction
+	listIndex        int
+	windowHeight     int
 
 	// Edit transaction fields
 	editField     uint
@@ -49,6 +54,7 @@ type model struct {
 	dirEntries   []string
 	fileIndex    int
 	selectedFile string
+	pickingRates bool // file picker is choosing an exchange-rate CSV for analytics
 
 	// CSV template management
 	templateIndex    int
@@ -57,17 +63,25 @@ type model struct {
 	createField      uint
 	createMessage    string
 
+	draftTemplateFile string // Picked file the template being created was drafted from
+
 	// Template creation editing state
 	isEditingTemplateName      bool
 	isEditingTemplatePostDate  bool
 	isEditingTemplateAmount    bool
 	isEditingTemplateDesc      bool
 	isEditingTemplateCategory  bool
+	isEditingTemplateCurrency  bool
 	editingTemplateNameStr     string
 	editingTemplatePostDateStr string
 	editingTemplateAmountStr   string
 	editingTemplateDescStr     string
 	editingTemplateCategoryStr string
+	editingTemplateCurrencyStr string
+
+	// Debit, credit and type columns share one editor, as only one is edited at a time
+	isEditingTemplateColumn  bool
+	editingTemplateColumnStr string
 
 	// Template validation state
 	templateFieldErrors            map[string]string
@@ -194,6 +208,9 @@ type model struct {
 
 	// Statement transaction management
 	filteredTransactions []types.Transaction
+	filteredQuery        storage.TransactionQuery // Query behind filteredTransactions
+	filteredTitle        string                   // Header for filtered lists that are not a statement
+	filteredReturnState  uint                     // Where Esc leaves the filtered list
 	currentStatementId   int64
 	statementTxMessage   string
 	filteredListIndex    int
@@ -204,7 +221,8 @@ type model struct {
 	importMessage string
 
 	// Validation errors
-	validationErrors []types.ValidationError
+	validationErrors     []types.ValidationError
+	validationValidCount int // Rows of the file that parsed and can be imported without the others
 
 	// Undo import functionality
 	undoStatementId   int64
@@ -235,41 +253,152 @@ type model struct {
 	editingStartDateStr string
 	editingEndDateStr   string
 	analyticsDateField  int // 0 for start date, 1 for end date
+
+	// Account management state
+	accountBalances     []types.AccountBalance // Accounts with running balances for the list view
+	selectedAccountIdx  int                    // Currently selected account in list
+	accountMessage      string                 // Status message for account views
+	editingAccount      types.Account          // Account being edited/created
+	accountActiveField  int                    // Currently active field index
+	accountEditingField bool                   // Whether currently editing a field
+	accountFieldValues  map[int]string         // Current field values indexed by field constant
+	accountEditingStr   string                 // Current editing text for active field
+
+	// Import account selection
+	importAccounts   []types.Account // Accounts offered when importing a file
+	importAccountIdx int             // Selected entry; len(importAccounts) means no account
+	importAccountId  int64           // Account the pending import goes into (0 = none)
+	importMatched    bool            // The preselected account was matched by the number in the file
+	importDetected   bool            // The template was detected from the file's contents
+
+	// Transaction search
+	searchQuery   string
+	searchResults []storage.TransactionSearchResult
+	searchIndex   int
+	searchMessage string
+
+	// Ledger switcher
+	recentLedgers     *storage.RecentLedgers // nil when the recent list is unavailable
+	ledgerEntries     []storage.RecentLedger
+	selectedLedgerIdx int // Selected entry; len(ledgerEntries) means "open another file"
+	ledgerMessage     string
+	ledgerPathInput   bool // Typing a ledger path
+	ledgerPathStr     string
+
+	// Trash
+	trashTransactions   []types.Transaction
+	trashIndex          int
+	trashMessage        string
+	trashConfirm        string // Pending permanent action: trashConfirmPurge or trashConfirmEmpty
+	trashRetentionInput bool   // Typing the retention period
+	trashRetentionStr   string
+
+	// Log viewer
+	logEntries  []logging.Entry // Oldest first
+	logIndex    int
+	logMinLevel slog.Level
+	logMessage  string
+
+	// Ledger merge
+	mergingLedger bool               // The snapshot picker is choosing a ledger to merge in
+	mergePlan     *storage.MergePlan // Preview of the pending merge
+	mergeIndex    int
+	mergeMessage  string
+
+	// Import review
+	importStaging         *storage.ImportStaging // Parsed CSV rows waiting to be committed
+	reviewIndex           int
+	reviewMessage         string
+	reviewEditingDesc     bool // Typing a new description for the selected row
+	reviewDescStr         string
+	reviewPickingCategory bool // Choosing a category for the selected row
+	reviewCategories      []types.Category
+	reviewCategoryIdx     int
+
+	// Ledger maintenance
+	integrityReport    *storage.IntegrityReport
+	maintenanceIndex   int
+	maintenanceMessage string
+	maintenanceConfirm bool // Waiting for y/n before repairing
+
+	// Transaction history pane in the edit view
+	txHistoryOpen    bool
+	txHistoryEvents  []types.TransactionAuditEvent // Newest first
+	txHistoryIndex   int
+	txHistoryMessage string
+
+	// Undo/redo result shown above the current view
+	historyMessage string
+	historyFailed  bool
+
+	// Long-running store operation; while set, keys other than esc are ignored
+	job    *backgroundJob
+	jobSeq int // Id of the most recently started job
 }
 
-// sortTransactionsByDate sorts transactions by date in descending order (newest first)
-func (m *model) sortTransactionsByDate() {
-	sort.Slice(m.transactions, func(i, j int) bool {
-		// Compare dates directly since they're now time.Time
-		dateI := m.transactions[i].Date
-		dateJ := m.transactions[j].Date
+// loadTransactions reloads the main transaction list, keeping at least as many rows as were loaded
+func (m *model) loadTransactions() error {
+	limit := len(m.transactions)
+	if limit < transactionPageSize {
+		limit = transactionPageSize
+	}
 
-		// Handle zero times (treat as very old dates for sorting)
-		if dateI.IsZero() && dateJ.IsZero() {
-			return false // Equal
-		}
-		if dateI.IsZero() {
-			return false // i is older
-		}
-		if dateJ.IsZero() {
-			return true // j is older
-		}
+	transactions, err := m.store.Transactions.QueryTransactions(storage.TransactionQuery{Limit: limit})
+	if err != nil {
+		return err
+	}
+	total, err := m.store.Transactions.CountTransactions(storage.TransactionQuery{})
+	if err != nil {
+		return err
+	}
 
-		// Return true if i's date is after j's date (descending order)
-		return dateI.After(dateJ)
-	})
+	m.transactions = transactions
+	m.transactionTotal = total
+	return nil
 }
 
-// NewModel creates a new model instance
-func NewModel(store *storage.Store) model {
-	transactions, err := store.Transactions.GetTransactions()
+// loadMoreTransactions appends the next page of the main transaction list, if there is one
+func (m *model) loadMoreTransactions() {
+	if len(m.transactions) >= m.transactionTotal {
+		return
+	}
+
+	page, err := m.store.Transactions.QueryTransactions(storage.TransactionQuery{
+		Limit:  transactionPageSize,
+		Offset: len(m.transactions),
+	})
 	if err != nil {
-		log.Fatalf("unable to get transactions: %v", err)
+		return
+	}
+	m.transactions = append(m.transactions, page...)
+}
+
+// selectedTransactions loads every multi-selected transaction from the store
+// Selections can come from the paged main list or a filtered list, so they are read by ID.
+func (m model) selectedTransactions() []types.Transaction {
+	ids := make([]int64, 0, len(m.selectedTxIds))
+	for id, selected := range m.selectedTxIds {
+		if selected {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var transactions []types.Transaction
+	for _, id := range ids {
+		if tx := m.store.Transactions.GetTransactionByID(id); tx != nil {
+			transactions = append(transactions, *tx)
+		}
 	}
+	return transactions
+}
+
+// NewModel creates a new model instance
+func NewModel(store *storage.Store, recentLedgers *storage.RecentLedgers) model {
 	m := model{
 		state:               menuView,
 		store:               store,
-		transactions:        transactions,
+		recentLedgers:       recentLedgers,
 		listIndex:           0,
 		availableTypes:      []string{"income", "expense", "transfer"},
 		selectedTxIds:       make(map[int64]bool),
@@ -278,8 +407,10 @@ func NewModel(store *storage.Store) model {
 		validator:           validation.NewTransactionValidator(),
 		previousState:       listView, // Default to listView for backward compatibility
 	}
-	// Sort transactions by date (newest first)
-	m.sortTransactionsByDate()
+	// Only the first page is loaded; the list view fetches more as the cursor reaches the end
+	if err := m.loadTransactions(); err != nil {
+		slog.Error("unable to get transactions", "error", err)
+	}
 	return m
 }
 
@@ -299,6 +430,21 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		key := msg.String()
+
+		// A running job owns the keyboard until it finishes or is cancelled
+		if m.job != nil {
+			return m.handleJobKey(key)
+		}
+		m.historyMessage = ""
+
+		// Undo and redo work the same in every view
+		switch key {
+		case "ctrl+z":
+			return m.undoLastChange()
+		case "ctrl+y":
+			return m.redoLastChange()
+		}
+
 		switch m.state {
 		case menuView:
 			return m.handleMenuView(key)
@@ -348,10 +494,44 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.handleSnapshotSavePickerView(key)
 		case snapshotLoadPickerView:
 			return m.handleSnapshotLoadPickerView(key)
+		case accountListView:
+			return m.handleAccountListView(key)
+		case accountEditView:
+			return m.handleAccountEditView(key)
+		case accountSelectView:
+			return m.handleAccountSelectView(key)
+		case ledgerSwitchView:
+			return m.handleLedgerSwitchView(key)
+		case transactionSearchView:
+			return m.handleTransactionSearchView(key)
+		case trashView:
+			return m.handleTrashView(key)
+		case maintenanceView:
+			return m.handleMaintenanceView(key)
+		case logView:
+			return m.handleLogView(key)
+		case mergePreviewView:
+			return m.handleMergePreviewView(key)
+		case importReviewView:
+			return m.handleImportReviewView(key)
 		}
 	case tea.WindowSizeMsg:
 		m.windowHeight = msg.Height
 		return m, nil
+	case jobProgressMsg:
+		return m.handleJobProgress(msg)
+	case jobDoneMsg:
+		return m.handleJobDone(msg)
+	case importStagedMsg:
+		return m.finishStaging(msg)
+	case importFinishedMsg:
+		return m.finishImport(msg)
+	case snapshotFinishedMsg:
+		return m.finishSnapshot(msg)
+	case analyticsLoadedMsg:
+		return m.finishAnalyticsLoad(msg)
+	case retrainFinishedMsg:
+		return m.finishRetrain(msg)
 	}
 	return m, nil
 }
@@ -427,9 +607,7 @@ func (m *model) executeUndo() {
 	}
 
 	// Refresh transactions
-	m.transactions, _ = m.store.Transactions.GetTransactions()
-	// Sort transactions by date (newest first)
-	m.sortTransactionsByDate()
+	m.loadTransactions()
 
 	// Set success message for both views
 	successMsg := fmt.Sprintf("Successfully undone import of %s - removed %d transactions",
@@ -452,7 +630,7 @@ func (m *model) validateBulkEditData() {
 
 	// Create temporary transaction with bulk edit values
 	tempTx := types.Transaction{
-		Amount:      0, // Will be parsed from string
+		Amount:      types.Money{}, // Will be parsed from string
 		Description: m.bulkDescriptionValue,
 		Date:        time.Time{}, // Zero time for placeholder
 		CategoryId:  0,           // Will be set based on category value
@@ -28,6 +28,16 @@ const (
 	snapshotNameInputView             = 23
 	snapshotSavePickerView            = 24
 	snapshotLoadPickerView            = 25
+	accountListView                   = 26
+	accountEditView                   = 27
+	accountSelectView                 = 28
+	ledgerSwitchView                  = 29
+	transactionSearchView             = 30
+	trashView                         = 31
+	maintenanceView                   = 32
+	logView                           = 33
+	mergePreviewView                  = 34
+	importReviewView                  = 35
 )
 
 // Edit field constants
@@ -47,6 +57,13 @@ const (
 	templateAmount
 	templateDesc
 	templateCategory
+	templateDebit
+	templateCredit
+	templateTypeColumn
+	templateSign
+	templateCurrency
+	templateSkipLines
+	templateFooterLines
 	templateHeader
 )
 
@@ -71,6 +88,16 @@ const (
 	bulkEditType
 )
 
+// Account field constants (using int to match model field types)
+const (
+	accountFieldName int = iota
+	accountFieldType
+	accountFieldInstitution
+	accountFieldAccountNumber
+	accountFieldOpeningBalance
+	accountFieldCurrency
+)
+
 // Phase 3: Category field constants (using int to match model field types)
 const (
 	categoryFieldDisplayName int = iota
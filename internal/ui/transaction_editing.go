@@ -3,7 +3,6 @@ package ui
 import (
 	"fmt"
 	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
@@ -40,7 +39,7 @@ func NewTransactionEditState(tx *types.Transaction) *TransactionEditState {
 
 	return &TransactionEditState{
 		Original:         tx,
-		AmountInput:      fmt.Sprintf("%.2f", tx.Amount),
+		AmountInput:      tx.Amount.String(),
 		DescriptionInput: tx.Description,
 		DateInput:        tx.GetDateForDisplay(),
 		CategoryId:       tx.CategoryId,
@@ -62,7 +61,7 @@ func (es *TransactionEditState) ToTransaction() (*types.Transaction, error) {
 	// Parse amount
 	var err error
 	if es.AmountInput != "" {
-		tx.Amount, err = parseAmount(es.AmountInput)
+		tx.Amount, err = parseAmount(es.AmountInput, tx.Amount.Currency)
 		if err != nil {
 			return nil, fmt.Errorf("amount: %w", err)
 		}
@@ -94,18 +93,18 @@ func (es *TransactionEditState) ToTransaction() (*types.Transaction, error) {
 }
 
 // Helper method for amount parsing
-func parseAmount(amountStr string) (float64, error) {
+func parseAmount(amountStr, currency string) (types.Money, error) {
 	trimmed := strings.TrimSpace(amountStr)
 	if trimmed == "" {
-		return 0, fmt.Errorf("amount cannot be empty")
+		return types.Money{}, fmt.Errorf("amount cannot be empty")
 	}
 
 	// Remove currency symbols and commas
 	cleaned := regexp.MustCompile(`[\$,]`).ReplaceAllString(trimmed, "")
 
-	amount, err := strconv.ParseFloat(cleaned, 64)
+	amount, err := types.ParseMoney(cleaned, currency)
 	if err != nil {
-		return 0, fmt.Errorf("invalid amount format")
+		return types.Money{}, fmt.Errorf("invalid amount format")
 	}
 
 	return amount, nil
@@ -2,12 +2,14 @@ package ui
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"time"
 
+	"budget-tracker-tui/internal/logging"
+	"budget-tracker-tui/internal/storage"
 	"budget-tracker-tui/internal/types"
 
 	"github.com/charmbracelet/lipgloss"
@@ -46,6 +48,16 @@ var (
 	formSectionStyle       = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("240")).Padding(1).MarginBottom(1)
 	helpTextStyle          = lipgloss.NewStyle().Foreground(lipgloss.Color("244")).Italic(true).MarginTop(1)
 	statusIconStyle        = lipgloss.NewStyle().Bold(true).MarginRight(1)
+
+	// Search match highlighting
+	searchMatchStyle = lipgloss.NewStyle().Bold(true).Background(lipgloss.Color("214")).Foreground(lipgloss.Color("0"))
+
+	// Progress bar of a running background job
+	progressFilledStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("99"))
+
+	// Log viewer lines by level
+	logErrorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	logWarnStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
 )
 
 // formatDateForDisplay formats a date string to MM/DD/YYYY for display
@@ -87,20 +99,39 @@ func formatTimestampForDisplay(timestampStr string) string {
 func (m model) View() string {
 	s := appNameStyle.Render("Finances Wrapped") + "\n\n"
 
+	// A running job replaces the current view until it is done
+	if m.job != nil {
+		return s + m.renderJobView()
+	}
+
 	// Add multi-select indicator
 	if m.isMultiSelectMode {
 		s += headerStyle.Render(fmt.Sprintf("MULTI-SELECT MODE (%d selected)", len(m.selectedTxIds))) + "\n"
 	}
 
+	// Result of the last ctrl+z / ctrl+y
+	if m.historyMessage != "" {
+		if m.historyFailed {
+			s += notificationStyle.Render(m.historyMessage) + "\n"
+		} else {
+			s += successStyle.Render(m.historyMessage) + "\n"
+		}
+	}
+
 	switch m.state {
 	case menuView:
 		s += headerStyle.Render("Manage Transactions ('t')") + "\n"
 		s += headerStyle.Render("Import Bank Statement ('i')") + "\n"
 		s += headerStyle.Render("Manage Bank Statements ('b')") + "\n"
 		s += headerStyle.Render("Manage Categories ('c')") + "\n"
+		s += headerStyle.Render("Accounts ('o')") + "\n"
+		s += headerStyle.Render("Switch Ledger ('l')") + "\n"
+		s += headerStyle.Render("Maintenance ('m')") + "\n"
+		s += headerStyle.Render("View Log ('v')") + "\n"
 		s += headerStyle.Render("Analytics ('a')") + "\n"
 		s += headerStyle.Render("Settings ('r')") + "\n"
 		s += headerStyle.Render("Quit ('q')") + "\n"
+		s += "\n" + faintStyle.Render("Ledger: "+m.store.GetDatabasePath()) + "\n"
 	case listView:
 		// view transactions in one large list
 		// headers stay along top with aligned columns
@@ -111,10 +142,10 @@ func (m model) View() string {
 			if len(desc) > 30 {
 				desc = desc[:27] + "..."
 			}
-			s += warningStyle.Render(fmt.Sprintf("Delete transaction: %s ($%s)? (y/n/Esc)", desc, m.deleteTransactionAmount)) + "\n\n"
+			s += warningStyle.Render(fmt.Sprintf("Move transaction to trash: %s (%s)? (y/n/Esc)", desc, m.deleteTransactionAmount)) + "\n\n"
 		}
 
-		s += fmt.Sprintf("%-12s | %-40s | %12s | %-20s | %-15s\n",
+		s += fmt.Sprintf("%-12s | %-40s | %16s | %-20s | %-15s\n",
 			headerStyle.Render("Date"),
 			headerStyle.Render("Description"),
 			headerStyle.Render("Amount"),
@@ -182,10 +213,10 @@ func (m model) View() string {
 				transactionType = transactionType[:12] + "..."
 			}
 
-			s += enumeratorStyle.Render(prefix) + fmt.Sprintf("%-12s | %-40s | %12.2f | %-20s | %-15s\n",
+			s += enumeratorStyle.Render(prefix) + fmt.Sprintf("%-12s | %-40s | %16s | %-20s | %-15s\n",
 				formatDateForDisplay(t.Date.Format("2006-01-02")),
 				description,
-				t.Amount,
+				t.Amount.Display(),
 				categoryName,
 				transactionType)
 		}
@@ -196,18 +227,18 @@ func (m model) View() string {
 		}
 
 		if len(m.transactions) == 0 {
-			s += faintStyle.Render("Import a bank statement to view transactions.")
+			s += faintStyle.Render("Import a bank statement to view transactions. | t: Trash | Esc: Menu")
 		} else {
 			scrollInfo := ""
-			if len(m.transactions) > availableHeight {
-				scrollInfo = fmt.Sprintf(" (%d/%d)", m.listIndex+1, len(m.transactions))
+			if m.transactionTotal > availableHeight {
+				scrollInfo = fmt.Sprintf(" (%d/%d)", m.listIndex+1, m.transactionTotal)
 			}
 
 			// Updated help text based on mode
 			if m.isMultiSelectMode {
 				s += faintStyle.Render("Enter: Toggle Selection | e: Edit Selected | m: Exit Multi-Select | Esc: Menu" + scrollInfo)
 			} else {
-				s += faintStyle.Render("Up/Down: Navigate | e: Edit | m: Multi-Select | d: Delete | Esc: Menu" + scrollInfo)
+				s += faintStyle.Render("Up/Down: Navigate | e: Edit | /: Search | m: Multi-Select | d: Delete | t: Trash | Ctrl+Z/Ctrl+Y: Undo/Redo | Esc: Menu" + scrollInfo)
 			}
 		}
 	case editView:
@@ -270,7 +301,8 @@ func (m model) View() string {
 		s += headerStyle.Render("Snapshot Options:") + "\n\n"
 
 		s += faintStyle.Render("s: Save Snapshot") + "\n"
-		s += faintStyle.Render("l: Load Snapshot") + "\n\n"
+		s += faintStyle.Render("l: Load Snapshot") + "\n"
+		s += faintStyle.Render("m: Merge Ledger File") + "\n\n"
 
 		if m.snapshotMessage != "" {
 			if strings.Contains(m.snapshotMessage, "successfully") {
@@ -282,11 +314,11 @@ func (m model) View() string {
 
 		s += faintStyle.Render("Esc: Return to menu") + "\n\n"
 	case filePickerView:
-		s += headerStyle.Render("Select CSV File") + "\n\n"
+		s += headerStyle.Render("Select Statement File") + "\n\n"
 		s += faintStyle.Render("Current Directory: "+m.currentDir) + "\n\n"
 
 		if len(m.dirEntries) == 0 {
-			s += faintStyle.Render("No directories or CSV files found in this location.") + "\n\n"
+			s += faintStyle.Render("No directories or statement files (CSV, OFX, QFX, QIF, camt XML, MT940) found in this location.") + "\n\n"
 		} else {
 			// Display directory entries
 			for i, entry := range m.dirEntries {
@@ -328,8 +360,17 @@ func (m model) View() string {
 				}
 
 				// Show template details
-				templateDetails := fmt.Sprintf("%s - Date:%d, Amount:%d, Desc:%d, Header:%v%s",
-					template.Name, template.PostDateColumn, template.AmountColumn, template.DescColumn, template.HasHeader, suffix)
+				amountDetails := fmt.Sprintf("Amount:%d", template.AmountColumn)
+				if template.HasDebitCreditColumns() {
+					amountDetails = fmt.Sprintf("Debit:%d, Credit:%d", *template.DebitColumn, *template.CreditColumn)
+				} else if template.ExpensesPositive {
+					amountDetails += " (expenses positive)"
+				}
+				if template.SkipLines > 0 || template.FooterLines > 0 {
+					amountDetails += fmt.Sprintf(", Skip:%d, Footer:%d", template.SkipLines, template.FooterLines)
+				}
+				templateDetails := fmt.Sprintf("%s - Date:%d, %s, Desc:%d, Header:%v, Currency:%s%s",
+					template.Name, template.PostDateColumn, amountDetails, template.DescColumn, template.HasHeader, template.Currency, suffix)
 
 				s += enumeratorStyle.Render(prefix) + templateDetails + "\n"
 			}
@@ -400,6 +441,66 @@ func (m model) View() string {
 		s += formLabelStyle.Render("Category Column Index (optional):") + "\n" + categoryStyle.Render(categoryValue) + "\n"
 		s += m.renderTemplateFieldError("category")
 
+		// Debit/credit pair and type column (optional)
+		for _, f := range []struct {
+			label string
+			field uint
+		}{
+			{"Debit Column Index (optional, replaces Amount with Credit):", templateDebit},
+			{"Credit Column Index (optional):", templateCredit},
+			{"Type Column Index (optional, e.g. Debit/Credit):", templateTypeColumn},
+		} {
+			column, key := m.templateOptionalColumn(f.field)
+			isEditing := m.createField == f.field && m.isEditingTemplateColumn
+			value := "Not specified"
+			if isEditing {
+				value = m.editingTemplateColumnStr
+			} else if *column != nil {
+				value = fmt.Sprintf("%d", **column)
+			}
+			style := m.getTemplateFieldStyle(key, m.createField == f.field, isEditing)
+			s += formLabelStyle.Render(f.label) + "\n" + style.Render(value) + "\n"
+			s += m.renderTemplateFieldError(key)
+		}
+
+		// Sign convention of the amount column
+		signStyle := m.getTemplateFieldStyle("sign", m.createField == templateSign, false)
+		signValue := "Negative (money out is -)"
+		if m.newTemplate.ExpensesPositive {
+			signValue = "Positive (money out is +)"
+		}
+		s += formLabelStyle.Render("Expenses In Amount Column:") + "\n" + signStyle.Render(signValue) + "\n"
+
+		// Currency field (defaults when empty)
+		currencyStyle := m.getTemplateFieldStyle("currency", m.createField == templateCurrency, m.isEditingTemplateCurrency)
+		currencyValue := types.DefaultCurrency + " (default)"
+		if m.isEditingTemplateCurrency {
+			currencyValue = m.editingTemplateCurrencyStr
+		} else if m.newTemplate.Currency != "" {
+			currencyValue = m.newTemplate.Currency
+		}
+		s += formLabelStyle.Render("Currency:") + "\n" + currencyStyle.Render(currencyValue) + "\n"
+		s += m.renderTemplateFieldError("currency")
+
+		// Preamble and footer lines around the transactions
+		for _, f := range []struct {
+			label string
+			field uint
+		}{
+			{"Lines To Skip Before Header:", templateSkipLines},
+			{"Footer Rows To Ignore:", templateFooterLines},
+		} {
+			count, key := m.templateLineCount(f.field)
+			isEditing := m.createField == f.field && m.isEditingTemplateColumn
+			value := fmt.Sprintf("%d", *count)
+			if isEditing {
+				value = m.editingTemplateColumnStr
+			}
+			style := m.getTemplateFieldStyle(key, m.createField == f.field, isEditing)
+			s += formLabelStyle.Render(f.label) + "\n" + style.Render(value) + "\n"
+			s += m.renderTemplateFieldError(key)
+		}
+
 		// Has Header field
 		headerStyle := m.getTemplateFieldStyle("header", m.createField == templateHeader, false)
 		headerValue := "No"
@@ -408,6 +509,12 @@ func (m model) View() string {
 		}
 		s += formLabelStyle.Render("Has Header:") + "\n" + headerStyle.Render(headerValue) + "\n\n"
 
+		// Settings detected from a picked file that the form does not edit
+		if m.draftTemplateFile != "" {
+			s += faintStyle.Render(fmt.Sprintf("Detected from %s: delimiter %q, date format %q",
+				filepath.Base(m.draftTemplateFile), m.newTemplate.Delimiter, m.newTemplate.DateFormat)) + "\n\n"
+		}
+
 		s += faintStyle.Render("Up/Down: Navigate | Enter/Backspace: Edit | Ctrl+S: Save | Esc: Cancel")
 	case bulkEditView:
 		s += headerStyle.Render(fmt.Sprintf("Bulk Edit %d Transactions", len(m.selectedTxIds))) + "\n\n"
@@ -515,7 +622,11 @@ func (m model) View() string {
 
 		s += "\n" + faintStyle.Render("This may create duplicate transactions in your data.") + "\n\n"
 
-		s += faintStyle.Render("y: Import Anyway | n: Cancel Import | Esc: Cancel")
+		if m.importStaging != nil {
+			s += faintStyle.Render("y: Import Anyway | n/Esc: Back to Review")
+		} else {
+			s += faintStyle.Render("y: Import Anyway | n: Cancel Import | Esc: Cancel")
+		}
 
 	case validationErrorView:
 		s += headerStyle.Render("CSV Validation Errors") + "\n\n"
@@ -523,7 +634,13 @@ func (m model) View() string {
 		// Display error summary
 		errorCount := len(m.validationErrors)
 		s += notificationStyle.Render(fmt.Sprintf(" ✗ Found %d formatting error(s) in CSV file ", errorCount)) + "\n\n"
-		s += faintStyle.Render("Please fix these errors and try importing again.") + "\n\n"
+		partial := m.importStaging != nil && m.validationValidCount > 0
+		if partial {
+			s += faintStyle.Render(fmt.Sprintf("Fix these errors and try importing again, or import the %d valid rows now and save the failed rows to %s to fix and import later.",
+				m.validationValidCount, filepath.Base(m.importStaging.RejectFilePath()))) + "\n\n"
+		} else {
+			s += faintStyle.Render("Please fix these errors and try importing again.") + "\n\n"
+		}
 
 		// Display errors (first 5)
 		displayCount := errorCount
@@ -550,7 +667,11 @@ func (m model) View() string {
 			s += "\n" + faintStyle.Render(fmt.Sprintf("... and %d more error(s)", errorCount-5)) + "\n"
 		}
 
-		s += "\n" + faintStyle.Render("Esc: Go Back")
+		if partial {
+			s += "\n" + faintStyle.Render(fmt.Sprintf("i: Import the %d valid rows and save the failed rows to a reject file | Esc: Back to review", m.validationValidCount))
+		} else {
+			s += "\n" + faintStyle.Render("Esc: Go Back")
+		}
 
 	case bankStatementListView:
 		return m.renderBankStatementListView()
@@ -569,6 +690,26 @@ func (m model) View() string {
 		return m.renderSnapshotSavePickerView()
 	case snapshotLoadPickerView:
 		return m.renderSnapshotLoadPickerView()
+	case accountListView:
+		return s + m.renderAccountListView()
+	case accountEditView:
+		return s + m.renderAccountEditView()
+	case accountSelectView:
+		return s + m.renderAccountSelectView()
+	case ledgerSwitchView:
+		return s + m.renderLedgerSwitchView()
+	case transactionSearchView:
+		return s + m.renderTransactionSearchView()
+	case trashView:
+		return s + m.renderTrashView()
+	case maintenanceView:
+		return s + m.renderMaintenanceView()
+	case logView:
+		return s + m.renderLogView()
+	case mergePreviewView:
+		return s + m.renderMergePreviewView()
+	case importReviewView:
+		return s + m.renderImportReviewView()
 	}
 
 	return s
@@ -736,10 +877,7 @@ func (m model) renderBulkTypeOptions() string {
 func (m model) renderSplitView() string {
 	var s string
 	// Show amount with proper sign formatting
-	amountDisplay := fmt.Sprintf("$%.2f", m.currTransaction.Amount)
-	if m.currTransaction.Amount < 0 {
-		amountDisplay = fmt.Sprintf("-$%.2f", -m.currTransaction.Amount)
-	}
+	amountDisplay := m.currTransaction.Amount.Display()
 
 	s += headerStyle.Render(fmt.Sprintf("Split Transaction: %s", amountDisplay)) + "\n\n"
 
@@ -767,14 +905,13 @@ func (m model) renderSplitView() string {
 	s += m.renderSplitField("Category:", m.splitCategory2, splitCategory2Field) + "\n\n"
 
 	// Show remaining amount with proper formatting
-	total1, _ := strconv.ParseFloat(m.splitAmount1, 64)
-	total2, _ := strconv.ParseFloat(m.splitAmount2, 64)
-	remaining := m.currTransaction.Amount - total1 - total2
-
-	remainingDisplay := fmt.Sprintf("Remaining: $%.2f", remaining)
-	if remaining < 0 {
-		remainingDisplay = fmt.Sprintf("Remaining: -$%.2f", -remaining)
-	} else if remaining == 0 {
+	currency := m.currTransaction.Amount.Currency
+	total1, _ := types.ParseMoney(m.splitAmount1, currency)
+	total2, _ := types.ParseMoney(m.splitAmount2, currency)
+	remaining := m.currTransaction.Amount.Sub(total1).Sub(total2)
+
+	remainingDisplay := fmt.Sprintf("Remaining: %s", remaining.Display())
+	if remaining.IsZero() {
 		remainingDisplay = faintStyle.Render("✓ Balanced")
 	}
 	s += faintStyle.Render(remainingDisplay) + "\n\n"
@@ -938,7 +1075,7 @@ func (m model) renderNormalEditView() string {
 	// Amount field
 	amountStyle := m.getFieldStyle("amount", m.editField == editAmount, m.isEditingAmount)
 
-	amountValue := fmt.Sprintf("%.2f", m.currTransaction.Amount)
+	amountValue := m.currTransaction.Amount.String()
 	if m.isEditingAmount && m.editingAmountStr != "" {
 		amountValue = m.editingAmountStr
 	}
@@ -1036,7 +1173,93 @@ func (m model) renderNormalEditView() string {
 		saveInstruction = faintStyle.Render("Ctrl+S: Save (fix errors first)")
 	}
 
-	s += "\n" + faintStyle.Render("Up/Down: Navigate | Enter: Edit Field | "+saveInstruction+" | s: Split | Esc: Cancel")
+	if m.txHistoryOpen {
+		s += "\n" + m.renderTransactionHistory()
+		return s
+	}
+
+	s += "\n" + faintStyle.Render("Up/Down: Navigate | Enter: Edit Field | "+saveInstruction+" | s: Split | h: History | Esc: Cancel")
+	return s
+}
+
+// historyActionLabels names transaction audit actions in the history pane
+var historyActionLabels = map[string]string{
+	types.ActionTypeImport:  "Imported",
+	types.ActionTypeEdit:    "Edited",
+	types.ActionTypeSplit:   "Split",
+	types.ActionTypeDelete:  "Moved to trash",
+	types.ActionTypeRestore: "Restored",
+}
+
+// renderTransactionHistory lists the audit events of the transaction being edited with field-level diffs
+func (m model) renderTransactionHistory() string {
+	s := headerStyle.Render("History") + "\n"
+
+	if len(m.txHistoryEvents) == 0 {
+		s += faintStyle.Render("No changes have been recorded for this transaction.") + "\n"
+	}
+
+	// Each event takes a few lines, so show a window of events around the selection
+	const visibleEvents = 5
+	startIndex := m.txHistoryIndex - visibleEvents/2
+	if startIndex > len(m.txHistoryEvents)-visibleEvents {
+		startIndex = len(m.txHistoryEvents) - visibleEvents
+	}
+	if startIndex < 0 {
+		startIndex = 0
+	}
+	endIndex := startIndex + visibleEvents
+	if endIndex > len(m.txHistoryEvents) {
+		endIndex = len(m.txHistoryEvents)
+	}
+
+	for i := startIndex; i < endIndex; i++ {
+		event := m.txHistoryEvents[i]
+		prefix := " "
+		if i == m.txHistoryIndex {
+			prefix = ">"
+		}
+
+		action, ok := historyActionLabels[event.ActionType]
+		if !ok {
+			action = event.ActionType
+		}
+		s += enumeratorStyle.Render(prefix) + fmt.Sprintf("%s  %s by %s\n",
+			event.Timestamp.Local().Format("01/02/2006 15:04"), action, event.Source)
+
+		// Events recorded before versions were kept (ErrLegacySnapshot) only carry the category
+		before, errBefore := types.DecodeTransactionSnapshot(event.PreEditSnapshot)
+		after, errAfter := types.DecodeTransactionSnapshot(event.PostEditSnapshot)
+		if errBefore != nil || errAfter != nil || (before == nil && after == nil) {
+			if event.PreviousCategory != event.CategoryAssigned {
+				s += fmt.Sprintf("      Category: %s → %s\n",
+					m.getCategoryDisplayName(event.PreviousCategory), m.getCategoryDisplayName(event.CategoryAssigned))
+			} else {
+				s += faintStyle.Render("      No version recorded") + "\n"
+			}
+			continue
+		}
+
+		changes := types.DiffTransactionSnapshots(before, after, m.getCategoryDisplayName)
+		if len(changes) == 0 {
+			s += faintStyle.Render("      No field changes") + "\n"
+		}
+		for _, change := range changes {
+			switch {
+			case before == nil:
+				s += fmt.Sprintf("      %s: %s\n", change.Field, change.New)
+			case after == nil:
+				s += fmt.Sprintf("      %s: %s (removed)\n", change.Field, change.Old)
+			default:
+				s += fmt.Sprintf("      %s: %s → %s\n", change.Field, change.Old, change.New)
+			}
+		}
+	}
+
+	s += "\n" + faintStyle.Render("Up/Down: Navigate | r: Revert to this version | h/Esc: Close history")
+	if m.txHistoryMessage != "" {
+		s += "\n\n" + m.txHistoryMessage
+	}
 	return s
 }
 
@@ -1639,16 +1862,17 @@ func (m model) renderAnalyticsView() string {
 
 	s += formLabelStyle.Render("Date Range:") + "\n"
 	s += "Start Date ('s'): " + "\n" + startDateStyle.Render(m.editingStartDateStr) + "\n"
-	s += "End Date   ('e'): " + "\n" + endDateStyle.Render(m.editingEndDateStr) + "\n\n"
+	s += "End Date   ('e'): " + "\n" + endDateStyle.Render(m.editingEndDateStr) + "\n"
+	s += "Base Currency ('c'): " + m.store.UserPreferences.GetBaseCurrency() + "\n\n"
 
 	if m.analyticsSummary != nil {
 		// Summary section
 		s += headerStyle.Render("💰 Summary") + "\n"
 		s += fmt.Sprintf("Period: %s\n", m.analyticsSummary.DateRange)
 		s += fmt.Sprintf("Total Income:    %s\n",
-			successStyle.Render(fmt.Sprintf("$%.2f", m.analyticsSummary.TotalIncome)))
+			successStyle.Render(m.analyticsSummary.TotalIncome.Display()))
 		s += fmt.Sprintf("Total Expenses:  %s\n",
-			warningStyle.Render(fmt.Sprintf("$%.2f", m.analyticsSummary.TotalExpenses)))
+			warningStyle.Render(m.analyticsSummary.TotalExpenses.Display()))
 		s += fmt.Sprintf("Net Amount:      %s\n",
 			m.formatNetAmount(m.analyticsSummary.NetAmount))
 		s += fmt.Sprintf("Transactions:    %d\n\n", m.analyticsSummary.TransactionCount)
@@ -1666,17 +1890,17 @@ func (m model) renderAnalyticsView() string {
 	}
 
 	// Command tips at bottom like other views
-	s += faintStyle.Render("s: Start Date | e: End Date | r: Refresh | Esc: Menu")
+	s += faintStyle.Render("Up/Down: Category | Enter: Transactions | s: Start Date | e: End Date | c: Base Currency | i: Import Rates | r: Refresh | Esc: Menu")
 
 	return s
 }
 
 // formatNetAmount formats net amount with appropriate styling
-func (m model) formatNetAmount(amount float64) string {
-	if amount >= 0 {
-		return successStyle.Render(fmt.Sprintf("$%.2f", amount))
+func (m model) formatNetAmount(amount types.Money) string {
+	if !amount.IsNegative() {
+		return successStyle.Render(amount.Display())
 	} else {
-		return notificationStyle.Render(fmt.Sprintf("-$%.2f", -amount))
+		return notificationStyle.Render(amount.Display())
 	}
 }
 
@@ -1753,8 +1977,13 @@ func (m model) renderSnapshotSavePickerView() string {
 
 func (m model) renderSnapshotLoadPickerView() string {
 	var s string
-	s += headerStyle.Render("Load Snapshot") + "\n\n"
-	s += faintStyle.Render("Choose snapshot file to load:") + "\n"
+	if m.mergingLedger {
+		s += headerStyle.Render("Merge Ledger File") + "\n\n"
+		s += faintStyle.Render("Choose a ledger or snapshot file to merge into this ledger:") + "\n"
+	} else {
+		s += headerStyle.Render("Load Snapshot") + "\n\n"
+		s += faintStyle.Render("Choose snapshot file to load:") + "\n"
+	}
 	s += faintStyle.Render("Current Directory: "+m.currentSnapshotDir) + "\n\n"
 
 	if len(m.snapshotDirectoryEntries) == 0 {
@@ -1789,7 +2018,11 @@ func (m model) renderSnapshotLoadPickerView() string {
 		}
 	}
 
-	s += faintStyle.Render("Up/Down: Navigate | Enter: Load File/Enter Directory | Esc: Back") + "\n"
+	if m.mergingLedger {
+		s += faintStyle.Render("Up/Down: Navigate | Enter: Preview Merge/Enter Directory | Esc: Back") + "\n"
+	} else {
+		s += faintStyle.Render("Up/Down: Navigate | Enter: Load File/Enter Directory | Esc: Back") + "\n"
+	}
 	return s
 }
 
@@ -1900,9 +2133,16 @@ func (m model) renderBankStatementListView() string {
 	if len(statements) > 0 && m.bankStatementListIndex >= 0 && m.bankStatementListIndex < len(statements) {
 		stmt := statements[m.bankStatementListIndex]
 		s += formLabelStyle.Render("Selected:") + " " + stmt.Filename + "\n"
+		if stmt.AccountId != 0 {
+			if account := m.store.Accounts.GetAccountById(stmt.AccountId); account != nil {
+				s += formLabelStyle.Render("Account:") + " " + account.Name + "\n"
+			}
+		}
 		if stmt.ErrorLog != "" {
+			// Only the summary; the manage view lists every rejected row
+			summary, _, _ := strings.Cut(stmt.ErrorLog, "\n")
 			s += formLabelStyle.Render("Error:") + " " +
-				lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(stmt.ErrorLog) + "\n"
+				lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(summary) + "\n"
 		}
 		s += "\n"
 	}
@@ -1928,11 +2168,21 @@ func (m model) renderBankStatementManageView() string {
 	s += formLabelStyle.Render("File:") + " " + stmt.Filename + "\n"
 	s += formLabelStyle.Render("Period:") + " " + formatDateForDisplay(stmt.PeriodStart.Format("2006-01-02")) + " to " + formatDateForDisplay(stmt.PeriodEnd.Format("2006-01-02")) + "\n"
 	s += formLabelStyle.Render("Transactions:") + " " + fmt.Sprintf("%d", stmt.TxCount) + "\n"
-	templateName := m.store.Templates.GetTemplateNameById(stmt.TemplateUsed)
-	if templateName == "" {
-		templateName = fmt.Sprintf("Template ID: %d", stmt.TemplateUsed)
+	if stmt.TemplateUsed != 0 {
+		templateName := m.store.Templates.GetTemplateNameById(stmt.TemplateUsed)
+		if templateName == "" {
+			templateName = fmt.Sprintf("Template ID: %d", stmt.TemplateUsed)
+		}
+		s += formLabelStyle.Render("Template:") + " " + templateName + "\n"
+	} else {
+		s += formLabelStyle.Render("Format:") + " " + strings.ToUpper(stmt.Format) + "\n"
+	}
+	if stmt.OpeningBalance != nil {
+		s += formLabelStyle.Render("Opening Balance:") + " " + stmt.OpeningBalance.Display() + "\n"
+	}
+	if stmt.ClosingBalance != nil {
+		s += formLabelStyle.Render("Closing Balance:") + " " + stmt.ClosingBalance.Display() + "\n"
 	}
-	s += formLabelStyle.Render("Template:") + " " + templateName + "\n"
 	s += formLabelStyle.Render("Import Date:") + " " + formatTimestampForDisplay(stmt.ImportDate.Format(time.RFC3339)) + "\n"
 
 	// Status with color
@@ -1967,16 +2217,22 @@ func (m model) renderBankStatementManageView() string {
 	return s
 }
 
-// renderStatementTransactionListView renders the filtered transaction list for a specific bank statement
+// renderStatementTransactionListView renders the filtered transaction list for a bank statement or analytics drill-down
 func (m model) renderStatementTransactionListView() string {
-	stmt, err := m.store.Statements.GetStatementById(m.currentStatementId)
-	if err != nil {
-		return "Error: Statement not found"
+	title := m.filteredTitle
+	periodStart, periodEnd := m.filteredQuery.StartDate, m.filteredQuery.EndDate
+	if m.currentStatementId != 0 {
+		stmt, err := m.store.Statements.GetStatementById(m.currentStatementId)
+		if err != nil {
+			return "Error: Statement not found"
+		}
+		title = stmt.Filename
+		periodStart, periodEnd = stmt.PeriodStart, stmt.PeriodEnd
 	}
 
 	var s string
-	s += headerStyle.Render("Transactions: "+stmt.Filename) + "\n"
-	s += faintStyle.Render("Period: "+stmt.PeriodStart.Format("2006-01-02")+" to "+stmt.PeriodEnd.Format("2006-01-02")) + " | " +
+	s += headerStyle.Render("Transactions: "+title) + "\n"
+	s += faintStyle.Render("Period: "+periodStart.Format("2006-01-02")+" to "+periodEnd.Format("2006-01-02")) + " | " +
 		faintStyle.Render(fmt.Sprintf("%d transactions", len(m.filteredTransactions))) + "\n\n"
 
 	// Show deletion confirmation if pending
@@ -1985,11 +2241,11 @@ func (m model) renderStatementTransactionListView() string {
 		if len(desc) > 30 {
 			desc = desc[:27] + "..."
 		}
-		s += warningStyle.Render(fmt.Sprintf("Delete transaction: %s ($%s)? (y/n/Esc)", desc, m.deleteTransactionAmount)) + "\n\n"
+		s += warningStyle.Render(fmt.Sprintf("Move transaction to trash: %s (%s)? (y/n/Esc)", desc, m.deleteTransactionAmount)) + "\n\n"
 	}
 
 	// Column headers with aligned columns
-	s += fmt.Sprintf("%-12s | %-40s | %12s | %-20s | %-15s\n",
+	s += fmt.Sprintf("%-12s | %-40s | %16s | %-20s | %-15s\n",
 		headerStyle.Render("Date"),
 		headerStyle.Render("Description"),
 		headerStyle.Render("Amount"),
@@ -2062,10 +2318,10 @@ func (m model) renderStatementTransactionListView() string {
 			transactionType = transactionType[:12] + "..."
 		}
 
-		s += enumeratorStyle.Render(prefix) + fmt.Sprintf("%-12s | %-40s | %12.2f | %-20s | %-15s\n",
+		s += enumeratorStyle.Render(prefix) + fmt.Sprintf("%-12s | %-40s | %16s | %-20s | %-15s\n",
 			formatDateForDisplay(t.Date.Format("2006-01-02")),
 			description,
-			t.Amount,
+			t.Amount.Display(),
 			categoryName,
 			transactionType)
 	}
@@ -2076,7 +2332,7 @@ func (m model) renderStatementTransactionListView() string {
 	}
 
 	if len(m.filteredTransactions) == 0 {
-		s += faintStyle.Render("No transactions found for this statement.")
+		s += faintStyle.Render("No transactions found.")
 	} else {
 		scrollInfo := ""
 		if len(m.filteredTransactions) > availableHeight {
@@ -2130,3 +2386,796 @@ func (m model) renderTemplateFieldError(fieldName string) string {
 	}
 	return ""
 }
+
+// Account Views
+
+// renderAccountListView renders all accounts with their running balances
+func (m model) renderAccountListView() string {
+	s := headerStyle.Render("Accounts") + "\n\n"
+
+	if m.accountMessage != "" {
+		if strings.Contains(m.accountMessage, "Error") || strings.Contains(m.accountMessage, "Cannot") {
+			s += warningStyle.Render(m.accountMessage) + "\n\n"
+		} else {
+			s += successStyle.Render(m.accountMessage) + "\n\n"
+		}
+	}
+
+	if len(m.accountBalances) == 0 {
+		s += faintStyle.Render("No accounts yet. Create one to keep statements from different cards apart.") + "\n\n"
+		s += faintStyle.Render("n: New Account | Esc: Menu")
+		return s
+	}
+
+	s += fmt.Sprintf("  %-25s | %-9s | %-20s | %18s | %6s | %-10s\n",
+		headerStyle.Render("Name"),
+		headerStyle.Render("Type"),
+		headerStyle.Render("Institution"),
+		headerStyle.Render("Balance"),
+		headerStyle.Render("Txns"),
+		headerStyle.Render("Last Activity")) + "\n"
+
+	for i, balance := range m.accountBalances {
+		prefix := "  "
+		if i == m.selectedAccountIdx {
+			prefix = "> "
+		}
+
+		name := balance.Account.Name
+		if len(name) > 25 {
+			name = name[:22] + "..."
+		}
+		institution := balance.Account.Institution
+		if len(institution) > 20 {
+			institution = institution[:17] + "..."
+		}
+		lastActivity := "-"
+		if !balance.LastActivity.IsZero() {
+			lastActivity = balance.LastActivity.Format("01/02/2006")
+		}
+
+		s += enumeratorStyle.Render(prefix) + fmt.Sprintf("%-25s | %-9s | %-20s | %18s | %6d | %-10s\n",
+			name, balance.Account.AccountType, institution, balance.Balance.Display(),
+			balance.TransactionCount, lastActivity)
+	}
+
+	s += "\n" + faintStyle.Render("Up/Down: Navigate | n: New | e: Edit | d: Delete | Esc: Menu")
+	return s
+}
+
+// renderAccountEditView renders the account create/edit form
+func (m model) renderAccountEditView() string {
+	title := "Edit Account"
+	if m.editingAccount.Id == 0 {
+		title = "New Account"
+	}
+	s := headerStyle.Render(title) + "\n\n"
+
+	if m.accountMessage != "" {
+		s += warningStyle.Render(m.accountMessage) + "\n\n"
+	}
+
+	fields := []struct {
+		label string
+		field int
+	}{
+		{"Name:", accountFieldName},
+		{"Type:", accountFieldType},
+		{"Institution:", accountFieldInstitution},
+		{"Account No.:", accountFieldAccountNumber},
+		{"Opening Bal.:", accountFieldOpeningBalance},
+		{"Currency:", accountFieldCurrency},
+	}
+
+	for _, f := range fields {
+		isActive := m.accountActiveField == f.field
+		isEditing := isActive && m.accountEditingField
+
+		value := m.accountFieldValues[f.field]
+		if isEditing {
+			value = m.accountEditingStr
+		}
+		if f.field == accountFieldType && isActive {
+			value += " (Enter to change)"
+		}
+
+		style := formFieldStyle
+		if isEditing {
+			style = selectingFieldStyle
+		} else if isActive {
+			style = activeFieldStyle
+		}
+		s += formLabelStyle.Render(f.label) + "\n" + style.Render(value) + "\n"
+	}
+
+	s += "\n" + faintStyle.Render("Up/Down: Navigate | Enter/Backspace: Edit | Ctrl+S: Save | Esc: Cancel")
+	return s
+}
+
+// renderAccountSelectView renders the account picker shown before importing a statement
+func (m model) renderAccountSelectView() string {
+	s := headerStyle.Render("Select Account") + "\n\n"
+	if m.selectedTemplate != "" {
+		template := m.selectedTemplate
+		if m.importDetected {
+			template += " (detected)"
+		}
+		s += faintStyle.Render("File: "+filepath.Base(m.selectedFile)+" | Template: "+template) + "\n\n"
+	} else {
+		s += faintStyle.Render("File: "+filepath.Base(m.selectedFile)+" | Format: "+strings.ToUpper(types.StatementFileFormat(m.selectedFile))) + "\n\n"
+	}
+
+	for i, account := range m.importAccounts {
+		prefix := "  "
+		if i == m.importAccountIdx {
+			prefix = "> "
+		}
+		details := fmt.Sprintf("%s (%s", account.Name, account.AccountType)
+		if account.Institution != "" {
+			details += ", " + account.Institution
+		}
+		s += enumeratorStyle.Render(prefix) + details + ")"
+		if i == m.importAccountIdx && m.importMatched {
+			s += faintStyle.Render(" - matches the account number in the file")
+		}
+		s += "\n"
+	}
+
+	prefix := "  "
+	if m.importAccountIdx == len(m.importAccounts) {
+		prefix = "> "
+	}
+	s += enumeratorStyle.Render(prefix) + faintStyle.Render("No account (detect overlaps by template)") + "\n"
+
+	if len(m.importAccounts) == 0 {
+		s += "\n" + faintStyle.Render("Create accounts from the main menu ('o') to keep cards that share a template apart.") + "\n"
+	}
+
+	s += "\n" + faintStyle.Render("Up/Down: Navigate | Enter: Import | Esc: Back to files")
+	return s
+}
+
+// renderLedgerSwitchView renders the recent ledgers list and the path input
+func (m model) renderLedgerSwitchView() string {
+	s := headerStyle.Render("Switch Ledger") + "\n\n"
+	s += faintStyle.Render("Current: "+m.store.GetDatabasePath()) + "\n\n"
+
+	if m.ledgerPathInput {
+		s += "Ledger file: " + m.ledgerPathStr + "_\n\n"
+		s += faintStyle.Render("A file that does not exist yet is created as a new ledger.") + "\n\n"
+		s += faintStyle.Render("Enter: Open | Esc: Cancel")
+		if m.ledgerMessage != "" {
+			s += "\n\n" + m.ledgerMessage
+		}
+		return s
+	}
+
+	current := m.store.GetDatabasePath()
+	for i, ledger := range m.ledgerEntries {
+		prefix := "  "
+		if i == m.selectedLedgerIdx {
+			prefix = "> "
+		}
+		line := ledger.Path
+		if ledger.Path == current {
+			line += " (open)"
+		}
+		s += enumeratorStyle.Render(prefix) + line + " " + faintStyle.Render(ledger.LastOpened.Format("01/02/2006 3:04 PM")) + "\n"
+	}
+
+	prefix := "  "
+	if m.selectedLedgerIdx == len(m.ledgerEntries) {
+		prefix = "> "
+	}
+	s += enumeratorStyle.Render(prefix) + faintStyle.Render("Open another ledger file...") + "\n"
+
+	s += "\n" + faintStyle.Render("Up/Down: Navigate | Enter: Open | d: Forget ledger | Esc: Back to menu")
+	if m.ledgerMessage != "" {
+		s += "\n\n" + m.ledgerMessage
+	}
+	return s
+}
+
+// renderTransactionSearchView renders the search prompt and ranked, highlighted results
+func (m model) renderTransactionSearchView() string {
+	s := headerStyle.Render("Search Transactions") + "\n\n"
+	s += "/ " + m.searchQuery + "_\n"
+
+	switch {
+	case m.searchMessage != "":
+		s += notificationStyle.Render(m.searchMessage) + "\n"
+	case strings.TrimSpace(m.searchQuery) == "":
+		s += faintStyle.Render("Type to search descriptions, e.g. \"plumber\"") + "\n"
+	case len(m.searchResults) == searchResultLimit:
+		s += faintStyle.Render(fmt.Sprintf("Best %d matches", searchResultLimit)) + "\n"
+	default:
+		s += faintStyle.Render(fmt.Sprintf("%d matches", len(m.searchResults))) + "\n"
+	}
+	s += "\n"
+
+	s += fmt.Sprintf("  %-12s | %-40s | %16s | %-20s\n",
+		headerStyle.Render("Date"),
+		headerStyle.Render("Description"),
+		headerStyle.Render("Amount"),
+		headerStyle.Render("Category")) + "\n"
+
+	headerLines := 8 // Title + prompt + status + headers + padding
+	availableHeight := m.windowHeight - headerLines - 2
+	if availableHeight <= 0 {
+		availableHeight = 10 // Fallback minimum
+	}
+
+	startIndex := 0
+	if len(m.searchResults) > availableHeight {
+		startIndex = m.searchIndex - availableHeight/2
+		if startIndex < 0 {
+			startIndex = 0
+		}
+		if startIndex > len(m.searchResults)-availableHeight {
+			startIndex = len(m.searchResults) - availableHeight
+		}
+	}
+	endIndex := startIndex + availableHeight
+	if endIndex > len(m.searchResults) {
+		endIndex = len(m.searchResults)
+	}
+
+	for i := startIndex; i < endIndex; i++ {
+		result := m.searchResults[i]
+		prefix := " "
+		if i == m.searchIndex {
+			prefix = ">"
+		}
+
+		// Show the raw description when only it matched, so the reason for the hit is visible
+		description := result.HighlightedDescription
+		if !strings.Contains(description, storage.SearchMatchStart) && strings.Contains(result.HighlightedRaw, storage.SearchMatchStart) {
+			description = result.HighlightedRaw
+		}
+
+		categoryName := m.getCategoryDisplayName(result.Transaction.CategoryId)
+		if len(categoryName) > 20 {
+			categoryName = categoryName[:17] + "..."
+		}
+
+		s += enumeratorStyle.Render(prefix) + fmt.Sprintf("%-12s | %s | %16s | %-20s\n",
+			formatDateForDisplay(result.Transaction.Date.Format("2006-01-02")),
+			renderHighlightedText(description, 40),
+			result.Transaction.Amount.Display(),
+			categoryName)
+	}
+
+	s += "\n" + faintStyle.Render("Type: Search | Up/Down: Navigate | Enter: Edit | Ctrl+U: Clear | Esc: Back to list")
+	return s
+}
+
+// renderTrashView renders deleted transactions with their deletion dates and the retention period
+func (m model) renderTrashView() string {
+	s := headerStyle.Render("Trash") + "\n"
+	if days := m.store.UserPreferences.GetTrashRetentionDays(); days > 0 {
+		s += faintStyle.Render(fmt.Sprintf("Deleted transactions are purged permanently after %d days", days)) + "\n\n"
+	} else {
+		s += faintStyle.Render("Deleted transactions are kept until the trash is emptied") + "\n\n"
+	}
+
+	if m.trashRetentionInput {
+		s += "Keep deleted transactions for (days): " + m.trashRetentionStr + "_\n\n"
+		s += faintStyle.Render("0 keeps them until the trash is emptied | Enter: Save | Esc: Cancel")
+		if m.trashMessage != "" {
+			s += "\n\n" + m.trashMessage
+		}
+		return s
+	}
+
+	switch m.trashConfirm {
+	case trashConfirmPurge:
+		desc := m.trashTransactions[m.trashIndex].Description
+		if len(desc) > 30 {
+			desc = desc[:27] + "..."
+		}
+		s += warningStyle.Render(fmt.Sprintf("Permanently delete %s? This cannot be undone. (y/n/Esc)", desc)) + "\n\n"
+	case trashConfirmEmpty:
+		s += warningStyle.Render(fmt.Sprintf("Permanently delete all %d transaction(s) in the trash? This cannot be undone. (y/n/Esc)", len(m.trashTransactions))) + "\n\n"
+	}
+
+	if len(m.trashTransactions) == 0 {
+		s += faintStyle.Render("The trash is empty.") + "\n"
+	} else {
+		s += fmt.Sprintf("  %-12s | %-40s | %16s | %-20s | %-12s\n",
+			headerStyle.Render("Date"),
+			headerStyle.Render("Description"),
+			headerStyle.Render("Amount"),
+			headerStyle.Render("Category"),
+			headerStyle.Render("Deleted")) + "\n"
+
+		headerLines := 8 // Title + retention + headers + padding
+		availableHeight := m.windowHeight - headerLines - 2
+		if availableHeight <= 0 {
+			availableHeight = 10 // Fallback minimum
+		}
+
+		startIndex := 0
+		if len(m.trashTransactions) > availableHeight {
+			startIndex = m.trashIndex - availableHeight/2
+			if startIndex < 0 {
+				startIndex = 0
+			}
+			if startIndex > len(m.trashTransactions)-availableHeight {
+				startIndex = len(m.trashTransactions) - availableHeight
+			}
+		}
+		endIndex := startIndex + availableHeight
+		if endIndex > len(m.trashTransactions) {
+			endIndex = len(m.trashTransactions)
+		}
+
+		for i := startIndex; i < endIndex; i++ {
+			tx := m.trashTransactions[i]
+			prefix := " "
+			if i == m.trashIndex {
+				prefix = ">"
+			}
+
+			description := tx.Description
+			if len(description) > 40 {
+				description = description[:37] + "..."
+			}
+			categoryName := m.getCategoryDisplayName(tx.CategoryId)
+			if len(categoryName) > 20 {
+				categoryName = categoryName[:17] + "..."
+			}
+			deleted := ""
+			if tx.DeletedAt != nil {
+				deleted = tx.DeletedAt.Local().Format("01/02/2006")
+			}
+
+			s += enumeratorStyle.Render(prefix) + fmt.Sprintf("%-12s | %-40s | %16s | %-20s | %-12s\n",
+				formatDateForDisplay(tx.Date.Format("2006-01-02")),
+				description,
+				tx.Amount.Display(),
+				categoryName,
+				deleted)
+		}
+	}
+
+	s += "\n" + faintStyle.Render("Up/Down: Navigate | r: Restore | p: Purge | x: Empty trash | s: Retention | Esc: Back to list")
+	if m.trashMessage != "" {
+		s += "\n\n" + m.trashMessage
+	}
+	return s
+}
+
+// renderMaintenanceView lists the issues found by the last integrity check
+func (m model) renderMaintenanceView() string {
+	s := headerStyle.Render("Ledger Maintenance") + "\n"
+
+	report := m.integrityReport
+	if report == nil {
+		s += "\n" + faintStyle.Render("c: Check ledger | t: Retrain categorizer | Esc: Back to menu")
+		if m.maintenanceMessage != "" {
+			s += "\n\n" + m.maintenanceMessage
+		}
+		return s
+	}
+	s += faintStyle.Render("Last checked "+report.CheckedAt.Format("01/02/2006 15:04:05")) + "\n\n"
+
+	if m.maintenanceConfirm {
+		s += warningStyle.Render(fmt.Sprintf("Repair %d issue(s)? Undo history will be cleared. (y/n/Esc)", report.RepairableCount())) + "\n\n"
+	}
+
+	if len(report.Issues) == 0 {
+		s += successStyle.Render("No issues found") + "\n"
+	} else {
+		s += fmt.Sprintf("%d issue(s) found, %d repairable", len(report.Issues), report.RepairableCount()) + "\n\n"
+
+		headerLines := 10 // Title + summary + help + padding
+		availableHeight := m.windowHeight - headerLines
+		if availableHeight <= 0 {
+			availableHeight = 10 // Fallback minimum
+		}
+
+		startIndex := 0
+		if len(report.Issues) > availableHeight {
+			startIndex = m.maintenanceIndex - availableHeight/2
+			if startIndex < 0 {
+				startIndex = 0
+			}
+			if startIndex > len(report.Issues)-availableHeight {
+				startIndex = len(report.Issues) - availableHeight
+			}
+		}
+		endIndex := startIndex + availableHeight
+		if endIndex > len(report.Issues) {
+			endIndex = len(report.Issues)
+		}
+
+		for i := startIndex; i < endIndex; i++ {
+			issue := report.Issues[i]
+			prefix := " "
+			if i == m.maintenanceIndex {
+				prefix = ">"
+			}
+
+			fix := "fix by hand"
+			if issue.Repairable() {
+				fix = "repair: " + issue.Fix
+			}
+			s += enumeratorStyle.Render(prefix) + issue.Description + " " + faintStyle.Render("("+fix+")") + "\n"
+		}
+	}
+
+	s += "\n" + faintStyle.Render("Up/Down: Navigate | c: Check again | f: Repair | t: Retrain categorizer | Esc: Back to menu")
+	if m.maintenanceMessage != "" {
+		s += "\n\n" + m.maintenanceMessage
+	}
+	return s
+}
+
+// renderLogView renders the newest log entries around the cursor
+func (m model) renderLogView() string {
+	s := headerStyle.Render("Log") + "\n"
+	s += faintStyle.Render(fmt.Sprintf("%s | showing %s and above", logging.ActivePath(), m.logMinLevel)) + "\n\n"
+
+	if len(m.logEntries) == 0 {
+		s += faintStyle.Render("No log entries") + "\n"
+	} else {
+		headerLines := 10 // Title + path + help + padding
+		availableHeight := m.windowHeight - headerLines
+		if availableHeight <= 0 {
+			availableHeight = 10 // Fallback minimum
+		}
+
+		startIndex := 0
+		if len(m.logEntries) > availableHeight {
+			startIndex = m.logIndex - availableHeight/2
+			if startIndex < 0 {
+				startIndex = 0
+			}
+			if startIndex > len(m.logEntries)-availableHeight {
+				startIndex = len(m.logEntries) - availableHeight
+			}
+		}
+		endIndex := startIndex + availableHeight
+		if endIndex > len(m.logEntries) {
+			endIndex = len(m.logEntries)
+		}
+
+		for i := startIndex; i < endIndex; i++ {
+			entry := m.logEntries[i]
+			prefix := " "
+			if i == m.logIndex {
+				prefix = ">"
+			}
+
+			line := entry.Line
+			switch {
+			case entry.Level >= slog.LevelError:
+				line = logErrorStyle.Render(line)
+			case entry.Level >= slog.LevelWarn:
+				line = logWarnStyle.Render(line)
+			case entry.Level < slog.LevelInfo:
+				line = faintStyle.Render(line)
+			}
+			s += enumeratorStyle.Render(prefix) + line + "\n"
+		}
+	}
+
+	s += "\n" + faintStyle.Render("Up/Down: Scroll | f: Filter level | r: Reload | Esc: Back to menu")
+	if m.logMessage != "" {
+		s += "\n\n" + m.logMessage
+	}
+	return s
+}
+
+// renderJobView shows the progress of the running background job
+func (m model) renderJobView() string {
+	job := m.job
+	s := headerStyle.Render(job.title) + "\n\n"
+
+	stage := job.progress.Stage
+	if stage == "" {
+		stage = "Starting"
+	}
+	if fraction := job.progress.Fraction(); fraction >= 0 {
+		filled := int(fraction * progressBarWidth)
+		bar := progressFilledStyle.Render(strings.Repeat("█", filled)) + faintStyle.Render(strings.Repeat("░", progressBarWidth-filled))
+		s += bar + fmt.Sprintf(" %3.0f%%", fraction*100) + "\n"
+		s += faintStyle.Render(fmt.Sprintf("%s: %d of %d", stage, job.progress.Done, job.progress.Total)) + "\n"
+	} else {
+		s += faintStyle.Render(stage+"...") + "\n"
+	}
+
+	if job.cancelling {
+		s += "\n" + warningStyle.Render("Cancelling, rolling back...")
+	} else {
+		s += "\n" + faintStyle.Render("Esc: Cancel")
+	}
+	return s
+}
+
+// renderHighlightedText styles search matches and pads or truncates the visible text to width
+// Matches arrive wrapped in storage.SearchMatchStart/SearchMatchEnd markers.
+func renderHighlightedText(text string, width int) string {
+	runes := []rune(text)
+
+	// Count visible runes to decide whether the text needs truncating
+	visible := 0
+	for _, r := range runes {
+		if string(r) != storage.SearchMatchStart && string(r) != storage.SearchMatchEnd {
+			visible++
+		}
+	}
+	limit := visible
+	if visible > width {
+		limit = width - 3
+	}
+
+	var out, match strings.Builder
+	inMatch := false
+	shown := 0
+	flush := func() {
+		if match.Len() > 0 {
+			out.WriteString(searchMatchStyle.Render(match.String()))
+			match.Reset()
+		}
+	}
+	for _, r := range runes {
+		switch string(r) {
+		case storage.SearchMatchStart:
+			inMatch = true
+			continue
+		case storage.SearchMatchEnd:
+			flush()
+			inMatch = false
+			continue
+		}
+		if shown == limit {
+			break
+		}
+		if inMatch {
+			match.WriteRune(r)
+		} else {
+			out.WriteRune(r)
+		}
+		shown++
+	}
+	flush()
+
+	if visible > width {
+		out.WriteString("...")
+		shown += 3
+	}
+	return out.String() + strings.Repeat(" ", width-shown)
+}
+
+// renderMergePreviewView shows what merging another ledger file will add before it is applied
+func (m model) renderMergePreviewView() string {
+	plan := m.mergePlan
+	if plan == nil {
+		return ""
+	}
+
+	s := headerStyle.Render("Merge Preview") + "\n"
+	s += faintStyle.Render(plan.SourcePath) + "\n\n"
+
+	s += formLabelStyle.Render("Transactions:") + " " + headerStyle.Render(fmt.Sprintf("%d new", len(plan.NewTransactions))) +
+		faintStyle.Render(fmt.Sprintf(" (%d already in this ledger, skipped)", len(plan.Duplicates))) + "\n"
+	s += formLabelStyle.Render("Categories:") + " " + headerStyle.Render(fmt.Sprintf("%d new", len(plan.NewCategories))) +
+		faintStyle.Render(fmt.Sprintf(" (%d matched by name)", plan.MatchedCategories)) + "\n"
+	s += formLabelStyle.Render("Statements:") + " " + headerStyle.Render(fmt.Sprintf("%d new", len(plan.NewStatements))) + "\n"
+	s += formLabelStyle.Render("Templates:") + " " + headerStyle.Render(fmt.Sprintf("%d new", len(plan.NewTemplates))) + "\n"
+	s += formLabelStyle.Render("Accounts:") + " " + headerStyle.Render(fmt.Sprintf("%d new", len(plan.NewAccounts))) + "\n"
+
+	if len(plan.NewCategories) > 0 {
+		var names []string
+		for _, category := range plan.NewCategories {
+			names = append(names, category.DisplayName)
+		}
+		s += faintStyle.Render("New categories: "+strings.Join(names, ", ")) + "\n"
+	}
+	s += "\n"
+
+	if len(plan.NewTransactions) == 0 {
+		s += faintStyle.Render("Nothing new to merge.") + "\n"
+	} else {
+		s += fmt.Sprintf("  %-12s | %-40s | %16s | %-20s\n",
+			headerStyle.Render("Date"),
+			headerStyle.Render("Description"),
+			headerStyle.Render("Amount"),
+			headerStyle.Render("Category")) + "\n"
+
+		headerLines := 16 // Title + path + summary + headers + padding
+		availableHeight := m.windowHeight - headerLines
+		if availableHeight <= 0 {
+			availableHeight = 10 // Fallback minimum
+		}
+
+		startIndex := 0
+		if len(plan.NewTransactions) > availableHeight {
+			startIndex = m.mergeIndex - availableHeight/2
+			if startIndex < 0 {
+				startIndex = 0
+			}
+			if startIndex > len(plan.NewTransactions)-availableHeight {
+				startIndex = len(plan.NewTransactions) - availableHeight
+			}
+		}
+		endIndex := startIndex + availableHeight
+		if endIndex > len(plan.NewTransactions) {
+			endIndex = len(plan.NewTransactions)
+		}
+
+		for i := startIndex; i < endIndex; i++ {
+			tx := plan.NewTransactions[i]
+			prefix := " "
+			if i == m.mergeIndex {
+				prefix = ">"
+			}
+
+			description := tx.Description
+			if len(description) > 40 {
+				description = description[:37] + "..."
+			}
+			categoryName := plan.SourceCategoryName(tx.CategoryId)
+			if len(categoryName) > 20 {
+				categoryName = categoryName[:17] + "..."
+			}
+
+			s += enumeratorStyle.Render(prefix) + fmt.Sprintf("%-12s | %-40s | %16s | %-20s\n",
+				formatDateForDisplay(tx.Date.Format("2006-01-02")),
+				description,
+				tx.Amount.Display(),
+				categoryName)
+		}
+	}
+
+	s += "\n" + faintStyle.Render("Up/Down: Scroll | y/Enter: Merge | Esc: Choose another file")
+	if m.mergeMessage != "" {
+		s += "\n\n" + warningStyle.Render(m.mergeMessage)
+	}
+	return s
+}
+
+// renderImportReviewView shows the rows of a parsed CSV file for review before they are imported
+func (m model) renderImportReviewView() string {
+	staging := m.importStaging
+	if staging == nil {
+		return ""
+	}
+
+	s := headerStyle.Render("Review Import") + "\n"
+	s += faintStyle.Render(staging.FilePath) + "\n\n"
+
+	included := len(staging.Transactions())
+	failed := len(staging.FailedRows())
+	s += formLabelStyle.Render("Rows:") + " " + headerStyle.Render(fmt.Sprintf("%d to import", included)) +
+		faintStyle.Render(fmt.Sprintf(" of %d (%d duplicate, %d failed to parse)", len(staging.Rows), staging.Duplicates(), failed)) + "\n"
+	if failed > 0 {
+		s += warningStyle.Render(fmt.Sprintf("%d rows failed to parse; importing offers to leave them out and save them to %s",
+			failed, filepath.Base(staging.RejectFilePath()))) + "\n"
+	}
+	s += "\n"
+
+	s += fmt.Sprintf("  %-5s | %-12s | %-32s | %14s | %-18s | %-22s | %-10s\n",
+		headerStyle.Render("Line"),
+		headerStyle.Render("Date"),
+		headerStyle.Render("Description"),
+		headerStyle.Render("Amount"),
+		headerStyle.Render("Category"),
+		headerStyle.Render("Predicted"),
+		headerStyle.Render("Status")) + "\n"
+
+	headerLines := 14 // Title + path + summary + headers + help + padding
+	availableHeight := m.windowHeight - headerLines
+	if availableHeight <= 0 {
+		availableHeight = 10 // Fallback minimum
+	}
+
+	startIndex := 0
+	if len(staging.Rows) > availableHeight {
+		startIndex = m.reviewIndex - availableHeight/2
+		if startIndex < 0 {
+			startIndex = 0
+		}
+		if startIndex > len(staging.Rows)-availableHeight {
+			startIndex = len(staging.Rows) - availableHeight
+		}
+	}
+	endIndex := startIndex + availableHeight
+	if endIndex > len(staging.Rows) {
+		endIndex = len(staging.Rows)
+	}
+
+	for i := startIndex; i < endIndex; i++ {
+		row := staging.Rows[i]
+		prefix := " "
+		if i == m.reviewIndex {
+			prefix = ">"
+		}
+
+		date, description, amount, categoryName, predicted := "", row.RawRow, "", "", ""
+		status := "import"
+		switch {
+		case row.Error != nil:
+			status = "error"
+		case row.Excluded && row.Duplicate:
+			status = "duplicate"
+		case row.Excluded:
+			status = "excluded"
+		case row.Duplicate:
+			status = "dup, import"
+		}
+		if row.Error == nil {
+			tx := row.Transaction
+			date = formatDateForDisplay(tx.Date.Format("2006-01-02"))
+			description = tx.Description
+			amount = tx.Amount.Display()
+			categoryName = m.getCategoryDisplayName(tx.CategoryId)
+			if row.PredictedCategoryId != 0 {
+				predicted = fmt.Sprintf("%.0f%% %s", row.Confidence*100, m.getCategoryDisplayName(row.PredictedCategoryId))
+			}
+		}
+		if len(description) > 32 {
+			description = description[:29] + "..."
+		}
+		if len(categoryName) > 18 {
+			categoryName = categoryName[:15] + "..."
+		}
+		if len(predicted) > 22 {
+			predicted = predicted[:19] + "..."
+		}
+
+		line := fmt.Sprintf("%-5d | %-12s | %-32s | %14s | %-18s | %-22s | %-10s",
+			row.LineNumber, date, description, amount, categoryName, predicted, status)
+		switch {
+		case row.Error != nil:
+			line = warningStyle.Render(line)
+		case !row.Included():
+			line = faintStyle.Render(line)
+		}
+		s += enumeratorStyle.Render(prefix) + line + "\n"
+	}
+
+	selected := staging.Rows[m.reviewIndex]
+	if selected.Error != nil {
+		s += "\n" + warningStyle.Render(fmt.Sprintf("Line %d [%s]: %s", selected.LineNumber, selected.Error.Field, selected.Error.Message)) + "\n"
+	}
+
+	switch {
+	case m.reviewEditingDesc:
+		s += "\nDescription: " + m.reviewDescStr + "_\n\n"
+		s += faintStyle.Render("Enter: Save | Esc: Cancel")
+	case m.reviewPickingCategory:
+		s += "\n" + faintStyle.Render("Categories:") + "\n"
+		maxVisible := 5
+		startIdx := 0
+		if len(m.reviewCategories) > maxVisible {
+			startIdx = m.reviewCategoryIdx - maxVisible/2
+			if startIdx < 0 {
+				startIdx = 0
+			}
+			if startIdx > len(m.reviewCategories)-maxVisible {
+				startIdx = len(m.reviewCategories) - maxVisible
+			}
+		}
+		endIdx := startIdx + maxVisible
+		if endIdx > len(m.reviewCategories) {
+			endIdx = len(m.reviewCategories)
+		}
+		for i := startIdx; i < endIdx; i++ {
+			if i == m.reviewCategoryIdx {
+				s += enumeratorStyle.Render("> ") + headerStyle.Render(m.reviewCategories[i].DisplayName) + "\n"
+			} else {
+				s += faintStyle.Render("  "+m.reviewCategories[i].DisplayName) + "\n"
+			}
+		}
+		s += "\n" + faintStyle.Render("Up/Down: Choose | Enter: Set category | Esc: Cancel")
+	default:
+		s += "\n" + faintStyle.Render("Up/Down: Navigate | Space/x: Exclude | c: Category | e: Description | a: Accept all and import | Esc: Cancel")
+	}
+
+	if m.reviewMessage != "" {
+		s += "\n\n" + warningStyle.Render(m.reviewMessage)
+	}
+	return s
+}
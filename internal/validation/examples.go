@@ -23,7 +23,7 @@ func Example() {
 	// Example 1: Valid transaction
 	validDate, _ := time.Parse("01-02-2006", "02-22-2024")
 	validTx := &types.Transaction{
-		Amount:      45.99,
+		Amount:      types.NewMoney(4599, types.DefaultCurrency),
 		Description: "Lunch at downtown cafe",
 		Date:        validDate,
 		CategoryId:  1, // Food
@@ -43,10 +43,10 @@ func Example() {
 	// Example 2: Invalid transaction
 	invalidDate, _ := time.Parse("2006-01-02", "2024-02-22")
 	invalidTx := &types.Transaction{
-		Amount:      0,           // Error: zero amount
-		Description: "",          // Error: empty description
-		Date:        invalidDate, // This date is valid, other fields will cause errors
-		CategoryId:  99,          // Error: category ID doesn't exist
+		Amount:      types.Money{}, // Error: zero amount
+		Description: "",            // Error: empty description
+		Date:        invalidDate,   // This date is valid, other fields will cause errors
+		CategoryId:  99,            // Error: category ID doesn't exist
 	}
 
 	fmt.Println("\n=== Validating Invalid Transaction ===")
@@ -63,14 +63,17 @@ func Example() {
 	// Example 3: Field-by-field validation
 	// fmt.Println(\"\\n=== Field-by-field Validation ===\")
 	testDate, _ := time.Parse("01-02-2006", "12-31-2024")
+	testAmount, amountErr := AmountValidator{}.ParseAmount("123.456") // Too many decimals
 	testTx := &types.Transaction{
-		Amount:      123.456, // Too many decimals
+		Amount:      testAmount,
 		Description: "Valid description",
 		Date:        testDate,
 		CategoryId:  1, // Food
 	}
 
-	if err := validator.ValidateField(testTx, "amount", categories); err != nil {
+	if amountErr != nil {
+		fmt.Printf("Amount error: %s\n", amountErr)
+	} else if err := validator.ValidateField(testTx, "amount", categories); err != nil {
 		fmt.Printf("Amount error: %s\n", err)
 	}
 
@@ -84,7 +87,7 @@ func Example() {
 	fmt.Println("\n=== Individual Validator Usage ===")
 
 	amountValidator := AmountValidator{}
-	if err := amountValidator.Validate(99.99); err != nil {
+	if err := amountValidator.Validate(types.NewMoney(9999, types.DefaultCurrency)); err != nil {
 		fmt.Printf("Amount validation failed: %s\n", err)
 	} else {
 		fmt.Println("Amount $99.99 is valid")
@@ -128,7 +131,7 @@ func Example() {
 		if amount, err := amountValidator.ParseAmount(amountStr); err != nil {
 			fmt.Printf("'%s' -> Error: %s\n", amountStr, err)
 		} else {
-			fmt.Printf("'%s' -> %.2f (valid)\n", amountStr, amount)
+			fmt.Printf("'%s' -> %s (valid)\n", amountStr, amount)
 		}
 	}
 }
@@ -202,9 +205,9 @@ func BulkValidationExample() {
 	transaction3Date, _ := time.Parse("01-02-2006", "02-21-2024")
 
 	transactions := []*types.Transaction{
-		{Id: 1, Amount: 25.50, Description: "Lunch", Date: transaction1Date, CategoryId: 1},    // Food
-		{Id: 2, Amount: 0, Description: "", Date: transaction2Date, CategoryId: 99},            // Invalid
-		{Id: 3, Amount: 15.99, Description: "Bus fare", Date: transaction3Date, CategoryId: 2}, // Transportation
+		{Id: 1, Amount: types.NewMoney(2550, types.DefaultCurrency), Description: "Lunch", Date: transaction1Date, CategoryId: 1},    // Food
+		{Id: 2, Amount: types.Money{}, Description: "", Date: transaction2Date, CategoryId: 99},                                      // Invalid
+		{Id: 3, Amount: types.NewMoney(1599, types.DefaultCurrency), Description: "Bus fare", Date: transaction3Date, CategoryId: 2}, // Transportation
 	}
 
 	results := validator.ValidateBulkEdit(transactions, categories)
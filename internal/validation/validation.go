@@ -1,10 +1,10 @@
 package validation
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
@@ -15,34 +15,38 @@ import (
 type AmountValidator struct{}
 
 // Validate validates an amount value
-func (av AmountValidator) Validate(amount float64) error {
+// Money is stored in whole cents, so decimal precision is enforced by ParseAmount
+func (av AmountValidator) Validate(amount types.Money) error {
 	// Check for zero amount
-	if amount == 0 {
-		return fmt.Errorf("amount cannot be zero")
+	if amount.IsZero() {
+		return ErrAmountZero
 	}
 
-	// Check for max 2 decimal places
-	rounded := math.Round(amount*100) / 100
-	if math.Abs(amount-rounded) > 0.001 {
-		return fmt.Errorf("amount cannot have more than 2 decimal places")
+	if amount.Abs().Cents > int64(math.Round(MaxAmountValue*100)) {
+		return ErrAmountTooLarge
 	}
 
 	return nil
 }
 
 // ParseAmount parses and validates an amount string
-func (av AmountValidator) ParseAmount(amountStr string) (float64, error) {
+func (av AmountValidator) ParseAmount(amountStr string) (types.Money, error) {
 	trimmed := strings.TrimSpace(amountStr)
 	if trimmed == "" {
-		return 0, fmt.Errorf("amount cannot be empty")
+		return types.Money{}, fmt.Errorf("amount cannot be empty")
 	}
 
 	// Remove currency symbols and commas
 	cleaned := regexp.MustCompile(`[\$,]`).ReplaceAllString(trimmed, "")
 
-	amount, err := strconv.ParseFloat(cleaned, 64)
-	if err != nil {
-		return 0, fmt.Errorf("invalid amount format")
+	amount, err := types.ParseMoney(cleaned, types.DefaultCurrency)
+	switch {
+	case errors.Is(err, types.ErrTooManyDecimals):
+		return types.Money{}, ErrAmountTooManyDecimals
+	case errors.Is(err, types.ErrAmountTooLarge):
+		return types.Money{}, ErrAmountTooLarge
+	case err != nil:
+		return types.Money{}, ErrAmountInvalid
 	}
 
 	return amount, av.Validate(amount)
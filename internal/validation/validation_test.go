@@ -3,6 +3,7 @@ package validation_test
 import (
 	"budget-tracker-tui/internal/types"
 	"budget-tracker-tui/internal/validation"
+	"errors"
 	"testing"
 	"time"
 )
@@ -12,21 +13,28 @@ func TestAmountValidator(t *testing.T) {
 
 	tests := []struct {
 		name        string
-		amount      float64
+		amount      string
 		expectError bool
 		errorMsg    string
+		wantCents   int64
 	}{
-		{"Valid positive amount", 123.45, false, ""},
-		{"Valid negative amount", -50.99, false, ""},
-		{"Zero amount", 0, true, "amount cannot be zero"},
-		{"Too many decimals", 123.456, true, "amount cannot have more than 2 decimal places"},
-		{"Valid single decimal", 99.9, false, ""},
-		{"Valid whole number", 100, false, ""},
+		{"Valid positive amount", "123.45", false, "", 12345},
+		{"Valid negative amount", "-50.99", false, "", -5099},
+		{"Zero amount", "0", true, "amount cannot be zero", 0},
+		{"Too many decimals", "123.456", true, "amount cannot have more than 2 decimal places", 0},
+		{"Valid single decimal", "99.9", false, "", 9990},
+		{"Valid whole number", "100", false, "", 10000},
+		{"Currency symbol and separators", "$1,234.56", false, "", 123456},
+		{"Sub-cent float drift", "0.29", false, "", 29},
+		{"Not a number", "abc", true, "invalid amount format", 0},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := validator.Validate(tt.amount)
+			amount, err := validator.ParseAmount(tt.amount)
+			if err == nil && amount.Cents != tt.wantCents {
+				t.Errorf("Expected %d cents but got %d", tt.wantCents, amount.Cents)
+			}
 			if tt.expectError {
 				if err == nil {
 					t.Errorf("Expected error but got none")
@@ -42,6 +50,36 @@ func TestAmountValidator(t *testing.T) {
 	}
 }
 
+// TestAmountValidatorErrors tests that amount errors can be told apart with errors.Is
+func TestAmountValidatorErrors(t *testing.T) {
+	validator := validation.AmountValidator{}
+
+	tests := []struct {
+		amount  string
+		want    error
+		wantRaw error // What types.ParseMoney reports, when it fails
+	}{
+		{"123.456", validation.ErrAmountTooManyDecimals, types.ErrTooManyDecimals},
+		{"1234567890123456", validation.ErrAmountTooLarge, types.ErrAmountTooLarge},
+		{"abc", validation.ErrAmountInvalid, nil},
+		{"0", validation.ErrAmountZero, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.amount, func(t *testing.T) {
+			if _, err := validator.ParseAmount(tt.amount); !errors.Is(err, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+			if tt.wantRaw == nil {
+				return
+			}
+			if _, err := types.ParseMoney(tt.amount, types.DefaultCurrency); !errors.Is(err, tt.wantRaw) {
+				t.Errorf("Expected ParseMoney to return %v, got %v", tt.wantRaw, err)
+			}
+		})
+	}
+}
+
 func TestDateValidator(t *testing.T) {
 	validator := validation.DateValidator{}
 
@@ -196,17 +234,17 @@ func TestTransactionValidation(t *testing.T) {
 	}
 
 	validTransaction := &types.Transaction{
-		Amount:      123.45,
+		Amount:      types.NewMoney(12345, types.DefaultCurrency),
 		Description: "Coffee purchase",
 		Date:        time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC),
 		CategoryId:  1, // Food category
 	}
 
 	invalidTransaction := &types.Transaction{
-		Amount:      0,           // Invalid: zero amount
-		Description: "",          // Invalid: empty description
-		Date:        time.Time{}, // Invalid: zero value date
-		CategoryId:  99,          // Invalid: category ID doesn't exist
+		Amount:      types.Money{}, // Invalid: zero amount
+		Description: "",            // Invalid: empty description
+		Date:        time.Time{},   // Invalid: zero value date
+		CategoryId:  99,            // Invalid: category ID doesn't exist
 	}
 
 	t.Run("Valid transaction", func(t *testing.T) {
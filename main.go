@@ -1,8 +1,13 @@
 package main
 
 import (
+	"budget-tracker-tui/internal/database"
+	"budget-tracker-tui/internal/logging"
 	"budget-tracker-tui/internal/storage"
-	"log"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
 
 	"budget-tracker-tui/internal/ui"
 
@@ -10,21 +15,128 @@ import (
 )
 
 func main() {
-	store := storage.NewStore()
-	if err := store.Init(); err != nil {
-		log.Fatalf("unable to init store: %v", err)
+	ledgerFlag := flag.String("ledger", "", "ledger database file (overrides $"+database.LedgerEnvVar+", default ~/.finance-wrapped/finance.db)")
+	checkFlag := flag.Bool("check", false, "check the ledger for inconsistent data, print a report and exit")
+	repairFlag := flag.Bool("repair", false, "like -check, but also fix the issues that can be repaired automatically")
+	logLevelFlag := flag.String("log-level", "info", "minimum level written to the log file: debug, info, warn or error")
+	flag.Parse()
+
+	logLevel, err := logging.ParseLevel(*logLevelFlag)
+	if err != nil {
+		fatalf("%v", err)
+	}
+
+	// Everything logged from here on goes to the log file, never onto the TUI screen
+	dataDir, err := database.DefaultDataDir()
+	if err != nil {
+		fatalf("unable to resolve data directory: %v", err)
+	}
+	logFile, err := logging.Setup(dataDir, logLevel)
+	if err != nil {
+		fatalf("unable to open log file: %v", err)
+	}
+
+	ledgerPath, err := database.ResolveLedgerPath(*ledgerFlag)
+	if err != nil {
+		fatalf("unable to resolve ledger path: %v", err)
+	}
+
+	// A plain -check only reads the ledger; -repair and the TUI migrate and maintain it on open
+	store := storage.NewStoreAt(ledgerPath)
+	if *checkFlag && !*repairFlag {
+		if err := store.InitForCheck(); err != nil {
+			fatalf("unable to check ledger: %v", err)
+		}
+	} else if err := store.Init(); err != nil {
+		fatalf("unable to init store: %v", err)
 	}
+	slog.Info("ledger opened", "path", store.GetDatabasePath(), "log_level", logLevel.String())
 
-	// Ensure proper cleanup of database connection
+	// Headless maintenance runs without the TUI; os.Exit skips deferred calls, so close first
+	if *checkFlag || *repairFlag {
+		code := runIntegrityCheck(store, *repairFlag)
+		if err := store.Close(); err != nil {
+			slog.Error("error closing store", "error", err)
+		}
+		logFile.Close()
+		os.Exit(code)
+	}
+
+	// Ensure proper cleanup of database connection and log file
+	defer logFile.Close()
 	defer func() {
 		if err := store.Close(); err != nil {
-			log.Printf("Error closing store: %v", err)
+			slog.Error("error closing store", "error", err)
 		}
 	}()
 
-	m := ui.NewModel(store)
+	// Remember the ledger for the in-app switcher; failing to do so is not fatal
+	var recentLedgers *storage.RecentLedgers
+	if recentPath, err := storage.DefaultRecentLedgersPath(); err == nil {
+		recentLedgers = storage.NewRecentLedgers(recentPath)
+		if err := recentLedgers.Add(store.GetDatabasePath()); err != nil {
+			slog.Warn("error recording recent ledger", "error", err)
+		}
+	}
+
+	m := ui.NewModel(store, recentLedgers)
 	p := tea.NewProgram(m, tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
-		log.Fatalf("unable to run tui: %v", err)
+		fatalf("unable to run tui: %v", err)
+	}
+}
+
+// fatalf reports a startup error on stderr and, once it is open, in the log file, then exits
+func fatalf(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	if logging.ActivePath() != "" {
+		slog.Error(message)
+	}
+	fmt.Fprintln(os.Stderr, message)
+	os.Exit(1)
+}
+
+// runIntegrityCheck prints an integrity report for the open ledger, repairing issues first if asked
+// It returns the process exit code: 0 when no issues remain, 1 otherwise.
+func runIntegrityCheck(store *storage.Store, repair bool) int {
+	fmt.Printf("Integrity check of %s\n", store.GetDatabasePath())
+
+	var report *storage.IntegrityReport
+	if repair {
+		result, err := store.RepairIntegrity()
+		if err != nil {
+			fatalf("unable to repair ledger: %v", err)
+		}
+		fmt.Printf("Repaired %d issue(s)\n", len(result.Repaired))
+		for _, issue := range result.Repaired {
+			fmt.Printf("  fixed [%s] %s: %s\n", issue.Kind, issue.Description, issue.Fix)
+		}
+		for _, failure := range result.Failed {
+			fmt.Printf("  failed %s\n", failure)
+		}
+		report = result.Remaining
+	} else {
+		var err error
+		if report, err = store.CheckIntegrity(); err != nil {
+			fatalf("unable to check ledger: %v", err)
+		}
+	}
+
+	if len(report.Issues) == 0 {
+		fmt.Println("No issues found")
+		return 0
+	}
+
+	fmt.Printf("%d issue(s) found, %d repairable\n", len(report.Issues), report.RepairableCount())
+	for _, issue := range report.Issues {
+		line := fmt.Sprintf("  [%s] %s", issue.Kind, issue.Description)
+		if issue.Repairable() {
+			line += " (repair: " + issue.Fix + ")"
+		}
+		fmt.Println(line)
+	}
+	if !repair && report.RepairableCount() > 0 {
+		fmt.Println("Run with -repair to fix the repairable issues")
 	}
+	return 1
 }